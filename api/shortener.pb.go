@@ -9,6 +9,8 @@ package api
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,16 +23,93 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Привязка метода к маршруту REST-шлюза (internal/app/handlers/gateway).
+// Маршруты генерируются cmd/genserver; потоковые методы через шлюз недоступны.
+type HttpRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Шаблон пути для соответствующего метода HTTP; {field} - поле запроса.
+	Get    string `protobuf:"bytes,1,opt,name=get,proto3" json:"get,omitempty"`
+	Post   string `protobuf:"bytes,2,opt,name=post,proto3" json:"post,omitempty"`
+	Delete string `protobuf:"bytes,3,opt,name=delete,proto3" json:"delete,omitempty"`
+	// "*" - остальные поля запроса передаются в теле JSON,
+	// пусто - в параметрах строки запроса.
+	Body          string `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HttpRule) Reset() {
+	*x = HttpRule{}
+	mi := &file_api_shortener_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HttpRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HttpRule) ProtoMessage() {}
+
+func (x *HttpRule) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HttpRule.ProtoReflect.Descriptor instead.
+func (*HttpRule) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HttpRule) GetGet() string {
+	if x != nil {
+		return x.Get
+	}
+	return ""
+}
+
+func (x *HttpRule) GetPost() string {
+	if x != nil {
+		return x.Post
+	}
+	return ""
+}
+
+func (x *HttpRule) GetDelete() string {
+	if x != nil {
+		return x.Delete
+	}
+	return ""
+}
+
+func (x *HttpRule) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
 type CreateRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OriginalUrl   string                 `protobuf:"bytes,1,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	OriginalUrl string                 `protobuf:"bytes,1,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	CustomAlias string                 `protobuf:"bytes,2,opt,name=custom_alias,json=customAlias,proto3" json:"custom_alias,omitempty"`
+	// Срок действия ссылки: абсолютный момент либо TTL в секундах (взаимоисключающие).
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateRequest) Reset() {
 	*x = CreateRequest{}
-	mi := &file_api_shortener_proto_msgTypes[0]
+	mi := &file_api_shortener_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -42,7 +121,7 @@ func (x *CreateRequest) String() string {
 func (*CreateRequest) ProtoMessage() {}
 
 func (x *CreateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[0]
+	mi := &file_api_shortener_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -55,7 +134,7 @@ func (x *CreateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
 func (*CreateRequest) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{0}
+	return file_api_shortener_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *CreateRequest) GetOriginalUrl() string {
@@ -65,6 +144,27 @@ func (x *CreateRequest) GetOriginalUrl() string {
 	return ""
 }
 
+func (x *CreateRequest) GetCustomAlias() string {
+	if x != nil {
+		return x.CustomAlias
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
 type CreateResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
@@ -74,7 +174,7 @@ type CreateResponse struct {
 
 func (x *CreateResponse) Reset() {
 	*x = CreateResponse{}
-	mi := &file_api_shortener_proto_msgTypes[1]
+	mi := &file_api_shortener_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -86,7 +186,7 @@ func (x *CreateResponse) String() string {
 func (*CreateResponse) ProtoMessage() {}
 
 func (x *CreateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[1]
+	mi := &file_api_shortener_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -99,7 +199,7 @@ func (x *CreateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateResponse.ProtoReflect.Descriptor instead.
 func (*CreateResponse) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{1}
+	return file_api_shortener_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *CreateResponse) GetShortUrl() string {
@@ -118,7 +218,7 @@ type GetRequest struct {
 
 func (x *GetRequest) Reset() {
 	*x = GetRequest{}
-	mi := &file_api_shortener_proto_msgTypes[2]
+	mi := &file_api_shortener_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -130,7 +230,7 @@ func (x *GetRequest) String() string {
 func (*GetRequest) ProtoMessage() {}
 
 func (x *GetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[2]
+	mi := &file_api_shortener_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -143,7 +243,7 @@ func (x *GetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
 func (*GetRequest) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{2}
+	return file_api_shortener_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *GetRequest) GetShortUrl() string {
@@ -163,7 +263,7 @@ type GetResponse struct {
 
 func (x *GetResponse) Reset() {
 	*x = GetResponse{}
-	mi := &file_api_shortener_proto_msgTypes[3]
+	mi := &file_api_shortener_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -175,7 +275,7 @@ func (x *GetResponse) String() string {
 func (*GetResponse) ProtoMessage() {}
 
 func (x *GetResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[3]
+	mi := &file_api_shortener_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -188,7 +288,7 @@ func (x *GetResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
 func (*GetResponse) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{3}
+	return file_api_shortener_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetResponse) GetOriginalUrl() string {
@@ -213,7 +313,7 @@ type PingRequest struct {
 
 func (x *PingRequest) Reset() {
 	*x = PingRequest{}
-	mi := &file_api_shortener_proto_msgTypes[4]
+	mi := &file_api_shortener_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -225,7 +325,7 @@ func (x *PingRequest) String() string {
 func (*PingRequest) ProtoMessage() {}
 
 func (x *PingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[4]
+	mi := &file_api_shortener_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -238,7 +338,7 @@ func (x *PingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{4}
+	return file_api_shortener_proto_rawDescGZIP(), []int{5}
 }
 
 type PingResponse struct {
@@ -250,7 +350,7 @@ type PingResponse struct {
 
 func (x *PingResponse) Reset() {
 	*x = PingResponse{}
-	mi := &file_api_shortener_proto_msgTypes[5]
+	mi := &file_api_shortener_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -262,7 +362,7 @@ func (x *PingResponse) String() string {
 func (*PingResponse) ProtoMessage() {}
 
 func (x *PingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[5]
+	mi := &file_api_shortener_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -275,7 +375,7 @@ func (x *PingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{5}
+	return file_api_shortener_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PingResponse) GetOk() bool {
@@ -293,7 +393,7 @@ type StatsRequest struct {
 
 func (x *StatsRequest) Reset() {
 	*x = StatsRequest{}
-	mi := &file_api_shortener_proto_msgTypes[6]
+	mi := &file_api_shortener_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -305,7 +405,7 @@ func (x *StatsRequest) String() string {
 func (*StatsRequest) ProtoMessage() {}
 
 func (x *StatsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[6]
+	mi := &file_api_shortener_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -318,20 +418,24 @@ func (x *StatsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
 func (*StatsRequest) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{6}
+	return file_api_shortener_proto_rawDescGZIP(), []int{7}
 }
 
 type StatsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Urls          int64                  `protobuf:"varint,1,opt,name=urls,proto3" json:"urls,omitempty"`
-	Users         int64                  `protobuf:"varint,2,opt,name=users,proto3" json:"users,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Urls  int64                  `protobuf:"varint,1,opt,name=urls,proto3" json:"urls,omitempty"`
+	Users int64                  `protobuf:"varint,2,opt,name=users,proto3" json:"users,omitempty"`
+	// Счётчики кэша редиректов; не заполняется, если кэш отключён.
+	Cache *CacheStats `protobuf:"bytes,3,opt,name=cache,proto3" json:"cache,omitempty"`
+	// Счётчики фоновой очистки; не заполняется, если очистка отключена.
+	Purge         *PurgeStats `protobuf:"bytes,4,opt,name=purge,proto3" json:"purge,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StatsResponse) Reset() {
 	*x = StatsResponse{}
-	mi := &file_api_shortener_proto_msgTypes[7]
+	mi := &file_api_shortener_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -343,7 +447,7 @@ func (x *StatsResponse) String() string {
 func (*StatsResponse) ProtoMessage() {}
 
 func (x *StatsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[7]
+	mi := &file_api_shortener_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -356,7 +460,7 @@ func (x *StatsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
 func (*StatsResponse) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{7}
+	return file_api_shortener_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *StatsResponse) GetUrls() int64 {
@@ -373,63 +477,43 @@ func (x *StatsResponse) GetUsers() int64 {
 	return 0
 }
 
-type UserURLsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *UserURLsRequest) Reset() {
-	*x = UserURLsRequest{}
-	mi := &file_api_shortener_proto_msgTypes[8]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *UserURLsRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*UserURLsRequest) ProtoMessage() {}
-
-func (x *UserURLsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[8]
+func (x *StatsResponse) GetCache() *CacheStats {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Cache
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use UserURLsRequest.ProtoReflect.Descriptor instead.
-func (*UserURLsRequest) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{8}
+func (x *StatsResponse) GetPurge() *PurgeStats {
+	if x != nil {
+		return x.Purge
+	}
+	return nil
 }
 
-type UserURLsResponse struct {
+type CacheStats struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Urls          []*UserURL             `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	Hits          int64                  `protobuf:"varint,1,opt,name=hits,proto3" json:"hits,omitempty"`
+	Misses        int64                  `protobuf:"varint,2,opt,name=misses,proto3" json:"misses,omitempty"`
+	Entries       int64                  `protobuf:"varint,3,opt,name=entries,proto3" json:"entries,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UserURLsResponse) Reset() {
-	*x = UserURLsResponse{}
+func (x *CacheStats) Reset() {
+	*x = CacheStats{}
 	mi := &file_api_shortener_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UserURLsResponse) String() string {
+func (x *CacheStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserURLsResponse) ProtoMessage() {}
+func (*CacheStats) ProtoMessage() {}
 
-func (x *UserURLsResponse) ProtoReflect() protoreflect.Message {
+func (x *CacheStats) ProtoReflect() protoreflect.Message {
 	mi := &file_api_shortener_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -441,40 +525,55 @@ func (x *UserURLsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserURLsResponse.ProtoReflect.Descriptor instead.
-func (*UserURLsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use CacheStats.ProtoReflect.Descriptor instead.
+func (*CacheStats) Descriptor() ([]byte, []int) {
 	return file_api_shortener_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *UserURLsResponse) GetUrls() []*UserURL {
+func (x *CacheStats) GetHits() int64 {
 	if x != nil {
-		return x.Urls
+		return x.Hits
 	}
-	return nil
+	return 0
 }
 
-type UserURL struct {
+func (x *CacheStats) GetMisses() int64 {
+	if x != nil {
+		return x.Misses
+	}
+	return 0
+}
+
+func (x *CacheStats) GetEntries() int64 {
+	if x != nil {
+		return x.Entries
+	}
+	return 0
+}
+
+type PurgeStats struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
-	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	Runs          int64                  `protobuf:"varint,1,opt,name=runs,proto3" json:"runs,omitempty"`
+	Purged        int64                  `protobuf:"varint,2,opt,name=purged,proto3" json:"purged,omitempty"`
+	Errors        int64                  `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UserURL) Reset() {
-	*x = UserURL{}
+func (x *PurgeStats) Reset() {
+	*x = PurgeStats{}
 	mi := &file_api_shortener_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UserURL) String() string {
+func (x *PurgeStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserURL) ProtoMessage() {}
+func (*PurgeStats) ProtoMessage() {}
 
-func (x *UserURL) ProtoReflect() protoreflect.Message {
+func (x *PurgeStats) ProtoReflect() protoreflect.Message {
 	mi := &file_api_shortener_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -486,46 +585,65 @@ func (x *UserURL) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserURL.ProtoReflect.Descriptor instead.
-func (*UserURL) Descriptor() ([]byte, []int) {
+// Deprecated: Use PurgeStats.ProtoReflect.Descriptor instead.
+func (*PurgeStats) Descriptor() ([]byte, []int) {
 	return file_api_shortener_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *UserURL) GetShortUrl() string {
+func (x *PurgeStats) GetRuns() int64 {
 	if x != nil {
-		return x.ShortUrl
+		return x.Runs
 	}
-	return ""
+	return 0
 }
 
-func (x *UserURL) GetOriginalUrl() string {
+func (x *PurgeStats) GetPurged() int64 {
 	if x != nil {
-		return x.OriginalUrl
+		return x.Purged
 	}
-	return ""
+	return 0
 }
 
-type DeleteRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ShortUrls     []string               `protobuf:"bytes,1,rep,name=short_urls,json=shortUrls,proto3" json:"short_urls,omitempty"`
+func (x *PurgeStats) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+// Все поля необязательные: без них возвращаются все URL пользователя
+// по возрастанию даты создания.
+type UserURLsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Курсор следующей страницы из предыдущего ответа.
+	Cursor string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Размер страницы, от 1 до 1000 (0 - все записи).
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Подстрока оригинального URL (без учёта регистра).
+	Search string `protobuf:"bytes,3,opt,name=search,proto3" json:"search,omitempty"`
+	// Диапазон дат создания [created_from, created_to).
+	CreatedFrom *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	// Порядок по дате создания: asc (по умолчанию) или desc.
+	Order         string `protobuf:"bytes,6,opt,name=order,proto3" json:"order,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteRequest) Reset() {
-	*x = DeleteRequest{}
+func (x *UserURLsRequest) Reset() {
+	*x = UserURLsRequest{}
 	mi := &file_api_shortener_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteRequest) String() string {
+func (x *UserURLsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteRequest) ProtoMessage() {}
+func (*UserURLsRequest) ProtoMessage() {}
 
-func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+func (x *UserURLsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_shortener_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -537,38 +655,76 @@ func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
-func (*DeleteRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UserURLsRequest.ProtoReflect.Descriptor instead.
+func (*UserURLsRequest) Descriptor() ([]byte, []int) {
 	return file_api_shortener_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *DeleteRequest) GetShortUrls() []string {
+func (x *UserURLsRequest) GetCursor() string {
 	if x != nil {
-		return x.ShortUrls
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *UserURLsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *UserURLsRequest) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *UserURLsRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
 	}
 	return nil
 }
 
-type DeleteResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *UserURLsRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *UserURLsRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+type UserURLsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Urls  []*UserURL             `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	// Курсор следующей страницы (пусто - страница последняя).
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteResponse) Reset() {
-	*x = DeleteResponse{}
+func (x *UserURLsResponse) Reset() {
+	*x = UserURLsResponse{}
 	mi := &file_api_shortener_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteResponse) String() string {
+func (x *UserURLsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteResponse) ProtoMessage() {}
+func (*UserURLsResponse) ProtoMessage() {}
 
-func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+func (x *UserURLsResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_shortener_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -580,33 +736,655 @@ func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
-func (*DeleteResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use UserURLsResponse.ProtoReflect.Descriptor instead.
+func (*UserURLsResponse) Descriptor() ([]byte, []int) {
 	return file_api_shortener_proto_rawDescGZIP(), []int{12}
 }
 
-type BatchCreateRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *UserURLsResponse) GetUrls() []*UserURL {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+func (x *UserURLsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type UserURL struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserURL) Reset() {
+	*x = UserURL{}
+	mi := &file_api_shortener_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserURL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserURL) ProtoMessage() {}
+
+func (x *UserURL) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserURL.ProtoReflect.Descriptor instead.
+func (*UserURL) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UserURL) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *UserURL) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *UserURL) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *UserURL) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrls     []string               `protobuf:"bytes,1,rep,name=short_urls,json=shortUrls,proto3" json:"short_urls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_api_shortener_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteRequest) GetShortUrls() []string {
+	if x != nil {
+		return x.ShortUrls
+	}
+	return nil
+}
+
+type DeleteResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Идентификатор задания для отслеживания хода удаления через GetDeleteJob.
+	JobId         string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_api_shortener_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DeleteResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type RestoreRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrls     []string               `protobuf:"bytes,1,rep,name=short_urls,json=shortUrls,proto3" json:"short_urls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreRequest) Reset() {
+	*x = RestoreRequest{}
+	mi := &file_api_shortener_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreRequest) ProtoMessage() {}
+
+func (x *RestoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreRequest.ProtoReflect.Descriptor instead.
+func (*RestoreRequest) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RestoreRequest) GetShortUrls() []string {
+	if x != nil {
+		return x.ShortUrls
+	}
+	return nil
+}
+
+type RestoreResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Идентификатор задания для отслеживания хода восстановления через GetDeleteJob.
+	JobId         string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreResponse) Reset() {
+	*x = RestoreResponse{}
+	mi := &file_api_shortener_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreResponse) ProtoMessage() {}
+
+func (x *RestoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreResponse.ProtoReflect.Descriptor instead.
+func (*RestoreResponse) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RestoreResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type DeleteJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteJobRequest) Reset() {
+	*x = DeleteJobRequest{}
+	mi := &file_api_shortener_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteJobRequest) ProtoMessage() {}
+
+func (x *DeleteJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteJobRequest.ProtoReflect.Descriptor instead.
+func (*DeleteJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *DeleteJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type DeleteJobResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	JobId string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// Статус задания: pending, done или failed.
+	Status    string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Pending   []string               `protobuf:"bytes,3,rep,name=pending,proto3" json:"pending,omitempty"`
+	Done      []string               `protobuf:"bytes,4,rep,name=done,proto3" json:"done,omitempty"`
+	Failed    []string               `protobuf:"bytes,5,rep,name=failed,proto3" json:"failed,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Задание восстанавливает удалённые ссылки (RestoreUserURLs).
+	Restore       bool `protobuf:"varint,8,opt,name=restore,proto3" json:"restore,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteJobResponse) Reset() {
+	*x = DeleteJobResponse{}
+	mi := &file_api_shortener_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteJobResponse) ProtoMessage() {}
+
+func (x *DeleteJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteJobResponse.ProtoReflect.Descriptor instead.
+func (*DeleteJobResponse) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeleteJobResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *DeleteJobResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *DeleteJobResponse) GetPending() []string {
+	if x != nil {
+		return x.Pending
+	}
+	return nil
+}
+
+func (x *DeleteJobResponse) GetDone() []string {
+	if x != nil {
+		return x.Done
+	}
+	return nil
+}
+
+func (x *DeleteJobResponse) GetFailed() []string {
+	if x != nil {
+		return x.Failed
+	}
+	return nil
+}
+
+func (x *DeleteJobResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *DeleteJobResponse) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *DeleteJobResponse) GetRestore() bool {
+	if x != nil {
+		return x.Restore
+	}
+	return false
+}
+
+type BatchCreateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
 	Items         []*BatchCreateItem     `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchCreateRequest) Reset() {
-	*x = BatchCreateRequest{}
-	mi := &file_api_shortener_proto_msgTypes[13]
+func (x *BatchCreateRequest) Reset() {
+	*x = BatchCreateRequest{}
+	mi := &file_api_shortener_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateRequest) ProtoMessage() {}
+
+func (x *BatchCreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateRequest.ProtoReflect.Descriptor instead.
+func (*BatchCreateRequest) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *BatchCreateRequest) GetItems() []*BatchCreateItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type BatchCreateItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CorrelationId string                 `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateItem) Reset() {
+	*x = BatchCreateItem{}
+	mi := &file_api_shortener_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateItem) ProtoMessage() {}
+
+func (x *BatchCreateItem) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateItem.ProtoReflect.Descriptor instead.
+func (*BatchCreateItem) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BatchCreateItem) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *BatchCreateItem) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *BatchCreateItem) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *BatchCreateItem) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type BatchCreateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*BatchCreateResult   `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateResponse) Reset() {
+	*x = BatchCreateResponse{}
+	mi := &file_api_shortener_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateResponse) ProtoMessage() {}
+
+func (x *BatchCreateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateResponse.ProtoReflect.Descriptor instead.
+func (*BatchCreateResponse) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *BatchCreateResponse) GetItems() []*BatchCreateResult {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type BatchCreateResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CorrelationId string                 `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	ShortUrl      string                 `protobuf:"bytes,2,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateResult) Reset() {
+	*x = BatchCreateResult{}
+	mi := &file_api_shortener_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateResult) ProtoMessage() {}
+
+func (x *BatchCreateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateResult.ProtoReflect.Descriptor instead.
+func (*BatchCreateResult) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BatchCreateResult) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *BatchCreateResult) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *BatchCreateResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type URLStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *URLStatsRequest) Reset() {
+	*x = URLStatsRequest{}
+	mi := &file_api_shortener_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchCreateRequest) String() string {
+func (x *URLStatsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchCreateRequest) ProtoMessage() {}
+func (*URLStatsRequest) ProtoMessage() {}
 
-func (x *BatchCreateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[13]
+func (x *URLStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -617,41 +1395,142 @@ func (x *BatchCreateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchCreateRequest.ProtoReflect.Descriptor instead.
-func (*BatchCreateRequest) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use URLStatsRequest.ProtoReflect.Descriptor instead.
+func (*URLStatsRequest) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *BatchCreateRequest) GetItems() []*BatchCreateItem {
+func (x *URLStatsRequest) GetShortUrl() string {
 	if x != nil {
-		return x.Items
+		return x.ShortUrl
+	}
+	return ""
+}
+
+type URLStatsResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl    string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	Clicks      int64                  `protobuf:"varint,3,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	// Количество различных префиксов сети клиентов (/24 для IPv4, /48 для IPv6).
+	UniqueVisitors int64                  `protobuf:"varint,4,opt,name=unique_visitors,json=uniqueVisitors,proto3" json:"unique_visitors,omitempty"`
+	FirstClickAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=first_click_at,json=firstClickAt,proto3" json:"first_click_at,omitempty"`
+	LastClickAt    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_click_at,json=lastClickAt,proto3" json:"last_click_at,omitempty"`
+	TopReferrers   []*ClickCount          `protobuf:"bytes,7,rep,name=top_referrers,json=topReferrers,proto3" json:"top_referrers,omitempty"`
+	TopUserAgents  []*ClickCount          `protobuf:"bytes,8,rep,name=top_user_agents,json=topUserAgents,proto3" json:"top_user_agents,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *URLStatsResponse) Reset() {
+	*x = URLStatsResponse{}
+	mi := &file_api_shortener_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLStatsResponse) ProtoMessage() {}
+
+func (x *URLStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLStatsResponse.ProtoReflect.Descriptor instead.
+func (*URLStatsResponse) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *URLStatsResponse) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *URLStatsResponse) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *URLStatsResponse) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+func (x *URLStatsResponse) GetUniqueVisitors() int64 {
+	if x != nil {
+		return x.UniqueVisitors
+	}
+	return 0
+}
+
+func (x *URLStatsResponse) GetFirstClickAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstClickAt
 	}
 	return nil
 }
 
-type BatchCreateItem struct {
+func (x *URLStatsResponse) GetLastClickAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastClickAt
+	}
+	return nil
+}
+
+func (x *URLStatsResponse) GetTopReferrers() []*ClickCount {
+	if x != nil {
+		return x.TopReferrers
+	}
+	return nil
+}
+
+func (x *URLStatsResponse) GetTopUserAgents() []*ClickCount {
+	if x != nil {
+		return x.TopUserAgents
+	}
+	return nil
+}
+
+type ClickCount struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	CorrelationId string                 `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
-	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Clicks        int64                  `protobuf:"varint,2,opt,name=clicks,proto3" json:"clicks,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchCreateItem) Reset() {
-	*x = BatchCreateItem{}
-	mi := &file_api_shortener_proto_msgTypes[14]
+func (x *ClickCount) Reset() {
+	*x = ClickCount{}
+	mi := &file_api_shortener_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchCreateItem) String() string {
+func (x *ClickCount) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchCreateItem) ProtoMessage() {}
+func (*ClickCount) ProtoMessage() {}
 
-func (x *BatchCreateItem) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[14]
+func (x *ClickCount) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -662,47 +1541,46 @@ func (x *BatchCreateItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchCreateItem.ProtoReflect.Descriptor instead.
-func (*BatchCreateItem) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use ClickCount.ProtoReflect.Descriptor instead.
+func (*ClickCount) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *BatchCreateItem) GetCorrelationId() string {
+func (x *ClickCount) GetValue() string {
 	if x != nil {
-		return x.CorrelationId
+		return x.Value
 	}
 	return ""
 }
 
-func (x *BatchCreateItem) GetOriginalUrl() string {
+func (x *ClickCount) GetClicks() int64 {
 	if x != nil {
-		return x.OriginalUrl
+		return x.Clicks
 	}
-	return ""
+	return 0
 }
 
-type BatchCreateResponse struct {
+type ListDeadLettersRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Items         []*BatchCreateResult   `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchCreateResponse) Reset() {
-	*x = BatchCreateResponse{}
-	mi := &file_api_shortener_proto_msgTypes[15]
+func (x *ListDeadLettersRequest) Reset() {
+	*x = ListDeadLettersRequest{}
+	mi := &file_api_shortener_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchCreateResponse) String() string {
+func (x *ListDeadLettersRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchCreateResponse) ProtoMessage() {}
+func (*ListDeadLettersRequest) ProtoMessage() {}
 
-func (x *BatchCreateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[15]
+func (x *ListDeadLettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -713,41 +1591,83 @@ func (x *BatchCreateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchCreateResponse.ProtoReflect.Descriptor instead.
-func (*BatchCreateResponse) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use ListDeadLettersRequest.ProtoReflect.Descriptor instead.
+func (*ListDeadLettersRequest) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *BatchCreateResponse) GetItems() []*BatchCreateResult {
+type ListDeadLettersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Letters       []*DeadLetter          `protobuf:"bytes,1,rep,name=letters,proto3" json:"letters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLettersResponse) Reset() {
+	*x = ListDeadLettersResponse{}
+	mi := &file_api_shortener_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLettersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLettersResponse) ProtoMessage() {}
+
+func (x *ListDeadLettersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[28]
 	if x != nil {
-		return x.Items
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLettersResponse.ProtoReflect.Descriptor instead.
+func (*ListDeadLettersResponse) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListDeadLettersResponse) GetLetters() []*DeadLetter {
+	if x != nil {
+		return x.Letters
 	}
 	return nil
 }
 
-type BatchCreateResult struct {
+// Задача удаления, не выполненная после всех повторных попыток.
+type DeadLetter struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	CorrelationId string                 `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
-	ShortUrl      string                 `protobuf:"bytes,2,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ShortUrls     []string               `protobuf:"bytes,3,rep,name=short_urls,json=shortUrls,proto3" json:"short_urls,omitempty"`
+	Attempts      int32                  `protobuf:"varint,4,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	LastError     string                 `protobuf:"bytes,5,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	FailedAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=failed_at,json=failedAt,proto3" json:"failed_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchCreateResult) Reset() {
-	*x = BatchCreateResult{}
-	mi := &file_api_shortener_proto_msgTypes[16]
+func (x *DeadLetter) Reset() {
+	*x = DeadLetter{}
+	mi := &file_api_shortener_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchCreateResult) String() string {
+func (x *DeadLetter) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchCreateResult) ProtoMessage() {}
+func (*DeadLetter) ProtoMessage() {}
 
-func (x *BatchCreateResult) ProtoReflect() protoreflect.Message {
-	mi := &file_api_shortener_proto_msgTypes[16]
+func (x *DeadLetter) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -758,32 +1678,176 @@ func (x *BatchCreateResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchCreateResult.ProtoReflect.Descriptor instead.
-func (*BatchCreateResult) Descriptor() ([]byte, []int) {
-	return file_api_shortener_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use DeadLetter.ProtoReflect.Descriptor instead.
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *BatchCreateResult) GetCorrelationId() string {
+func (x *DeadLetter) GetId() int64 {
 	if x != nil {
-		return x.CorrelationId
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetUserId() string {
+	if x != nil {
+		return x.UserId
 	}
 	return ""
 }
 
-func (x *BatchCreateResult) GetShortUrl() string {
+func (x *DeadLetter) GetShortUrls() []string {
 	if x != nil {
-		return x.ShortUrl
+		return x.ShortUrls
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetLastError() string {
+	if x != nil {
+		return x.LastError
 	}
 	return ""
 }
 
+func (x *DeadLetter) GetFailedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FailedAt
+	}
+	return nil
+}
+
+type ReplayDeadLettersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Идентификаторы задач; пустой список повторяет все задачи.
+	Ids           []int64 `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayDeadLettersRequest) Reset() {
+	*x = ReplayDeadLettersRequest{}
+	mi := &file_api_shortener_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayDeadLettersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayDeadLettersRequest) ProtoMessage() {}
+
+func (x *ReplayDeadLettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayDeadLettersRequest.ProtoReflect.Descriptor instead.
+func (*ReplayDeadLettersRequest) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ReplayDeadLettersRequest) GetIds() []int64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type ReplayDeadLettersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Replayed      int64                  `protobuf:"varint,1,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayDeadLettersResponse) Reset() {
+	*x = ReplayDeadLettersResponse{}
+	mi := &file_api_shortener_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayDeadLettersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayDeadLettersResponse) ProtoMessage() {}
+
+func (x *ReplayDeadLettersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_shortener_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayDeadLettersResponse.ProtoReflect.Descriptor instead.
+func (*ReplayDeadLettersResponse) Descriptor() ([]byte, []int) {
+	return file_api_shortener_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ReplayDeadLettersResponse) GetReplayed() int64 {
+	if x != nil {
+		return x.Replayed
+	}
+	return 0
+}
+
+var file_api_shortener_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*HttpRule)(nil),
+		Field:         50001,
+		Name:          "shortener.http",
+		Tag:           "bytes,50001,opt,name=http",
+		Filename:      "api/shortener.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// optional shortener.HttpRule http = 50001;
+	E_Http = &file_api_shortener_proto_extTypes[0]
+)
+
 var File_api_shortener_proto protoreflect.FileDescriptor
 
 const file_api_shortener_proto_rawDesc = "" +
 	"\n" +
-	"\x13api/shortener.proto\x12\tshortener\"2\n" +
+	"\x13api/shortener.proto\x12\tshortener\x1a google/protobuf/descriptor.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\\\n" +
+	"\bHttpRule\x12\x10\n" +
+	"\x03get\x18\x01 \x01(\tR\x03get\x12\x12\n" +
+	"\x04post\x18\x02 \x01(\tR\x04post\x12\x16\n" +
+	"\x06delete\x18\x03 \x01(\tR\x06delete\x12\x12\n" +
+	"\x04body\x18\x04 \x01(\tR\x04body\"\xb1\x01\n" +
 	"\rCreateRequest\x12!\n" +
-	"\foriginal_url\x18\x01 \x01(\tR\voriginalUrl\"-\n" +
+	"\foriginal_url\x18\x01 \x01(\tR\voriginalUrl\x12!\n" +
+	"\fcustom_alias\x18\x02 \x01(\tR\vcustomAlias\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1f\n" +
+	"\vttl_seconds\x18\x04 \x01(\x03R\n" +
+	"ttlSeconds\"-\n" +
 	"\x0eCreateResponse\x12\x1b\n" +
 	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\")\n" +
 	"\n" +
@@ -795,38 +1859,136 @@ const file_api_shortener_proto_rawDesc = "" +
 	"\vPingRequest\"\x1e\n" +
 	"\fPingResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\"\x0e\n" +
-	"\fStatsRequest\"9\n" +
+	"\fStatsRequest\"\x93\x01\n" +
 	"\rStatsResponse\x12\x12\n" +
 	"\x04urls\x18\x01 \x01(\x03R\x04urls\x12\x14\n" +
-	"\x05users\x18\x02 \x01(\x03R\x05users\"\x11\n" +
-	"\x0fUserURLsRequest\":\n" +
+	"\x05users\x18\x02 \x01(\x03R\x05users\x12+\n" +
+	"\x05cache\x18\x03 \x01(\v2\x15.shortener.CacheStatsR\x05cache\x12+\n" +
+	"\x05purge\x18\x04 \x01(\v2\x15.shortener.PurgeStatsR\x05purge\"R\n" +
+	"\n" +
+	"CacheStats\x12\x12\n" +
+	"\x04hits\x18\x01 \x01(\x03R\x04hits\x12\x16\n" +
+	"\x06misses\x18\x02 \x01(\x03R\x06misses\x12\x18\n" +
+	"\aentries\x18\x03 \x01(\x03R\aentries\"P\n" +
+	"\n" +
+	"PurgeStats\x12\x12\n" +
+	"\x04runs\x18\x01 \x01(\x03R\x04runs\x12\x16\n" +
+	"\x06purged\x18\x02 \x01(\x03R\x06purged\x12\x16\n" +
+	"\x06errors\x18\x03 \x01(\x03R\x06errors\"\xe7\x01\n" +
+	"\x0fUserURLsRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06search\x18\x03 \x01(\tR\x06search\x12=\n" +
+	"\fcreated_from\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x14\n" +
+	"\x05order\x18\x06 \x01(\tR\x05order\"[\n" +
 	"\x10UserURLsResponse\x12&\n" +
-	"\x04urls\x18\x01 \x03(\v2\x12.shortener.UserURLR\x04urls\"I\n" +
+	"\x04urls\x18\x01 \x03(\v2\x12.shortener.UserURLR\x04urls\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"\xbf\x01\n" +
 	"\aUserURL\x12\x1b\n" +
 	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
-	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\".\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\".\n" +
 	"\rDeleteRequest\x12\x1d\n" +
 	"\n" +
-	"short_urls\x18\x01 \x03(\tR\tshortUrls\"\x10\n" +
-	"\x0eDeleteResponse\"F\n" +
+	"short_urls\x18\x01 \x03(\tR\tshortUrls\"'\n" +
+	"\x0eDeleteResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"/\n" +
+	"\x0eRestoreRequest\x12\x1d\n" +
+	"\n" +
+	"short_urls\x18\x01 \x03(\tR\tshortUrls\"(\n" +
+	"\x0fRestoreResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\")\n" +
+	"\x10DeleteJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\x98\x02\n" +
+	"\x11DeleteJobResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\apending\x18\x03 \x03(\tR\apending\x12\x12\n" +
+	"\x04done\x18\x04 \x03(\tR\x04done\x12\x16\n" +
+	"\x06failed\x18\x05 \x03(\tR\x06failed\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x18\n" +
+	"\arestore\x18\b \x01(\bR\arestore\"F\n" +
 	"\x12BatchCreateRequest\x120\n" +
-	"\x05items\x18\x01 \x03(\v2\x1a.shortener.BatchCreateItemR\x05items\"[\n" +
+	"\x05items\x18\x01 \x03(\v2\x1a.shortener.BatchCreateItemR\x05items\"\xb7\x01\n" +
 	"\x0fBatchCreateItem\x12%\n" +
 	"\x0ecorrelation_id\x18\x01 \x01(\tR\rcorrelationId\x12!\n" +
-	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\"I\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1f\n" +
+	"\vttl_seconds\x18\x04 \x01(\x03R\n" +
+	"ttlSeconds\"I\n" +
 	"\x13BatchCreateResponse\x122\n" +
-	"\x05items\x18\x01 \x03(\v2\x1c.shortener.BatchCreateResultR\x05items\"W\n" +
+	"\x05items\x18\x01 \x03(\v2\x1c.shortener.BatchCreateResultR\x05items\"m\n" +
 	"\x11BatchCreateResult\x12%\n" +
 	"\x0ecorrelation_id\x18\x01 \x01(\tR\rcorrelationId\x12\x1b\n" +
-	"\tshort_url\x18\x02 \x01(\tR\bshortUrl2\xe8\x03\n" +
-	"\tShortener\x12E\n" +
-	"\x0eCreateShortURL\x12\x18.shortener.CreateRequest\x1a\x19.shortener.CreateResponse\x12?\n" +
-	"\x0eGetOriginalURL\x12\x15.shortener.GetRequest\x1a\x16.shortener.GetResponse\x127\n" +
-	"\x04Ping\x12\x16.shortener.PingRequest\x1a\x17.shortener.PingResponse\x12=\n" +
-	"\bGetStats\x12\x17.shortener.StatsRequest\x1a\x18.shortener.StatsResponse\x12F\n" +
-	"\vGetUserURLs\x12\x1a.shortener.UserURLsRequest\x1a\x1b.shortener.UserURLsResponse\x12E\n" +
-	"\x0eDeleteUserURLs\x12\x18.shortener.DeleteRequest\x1a\x19.shortener.DeleteResponse\x12L\n" +
-	"\vBatchCreate\x12\x1d.shortener.BatchCreateRequest\x1a\x1e.shortener.BatchCreateResponseB(Z&github.com/ryabkov82/shortener/api;apib\x06proto3"
+	"\tshort_url\x18\x02 \x01(\tR\bshortUrl\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\".\n" +
+	"\x0fURLStatsRequest\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\"\x90\x03\n" +
+	"\x10URLStatsResponse\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x12\x16\n" +
+	"\x06clicks\x18\x03 \x01(\x03R\x06clicks\x12'\n" +
+	"\x0funique_visitors\x18\x04 \x01(\x03R\x0euniqueVisitors\x12@\n" +
+	"\x0efirst_click_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ffirstClickAt\x12>\n" +
+	"\rlast_click_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\vlastClickAt\x12:\n" +
+	"\rtop_referrers\x18\a \x03(\v2\x15.shortener.ClickCountR\ftopReferrers\x12=\n" +
+	"\x0ftop_user_agents\x18\b \x03(\v2\x15.shortener.ClickCountR\rtopUserAgents\":\n" +
+	"\n" +
+	"ClickCount\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x16\n" +
+	"\x06clicks\x18\x02 \x01(\x03R\x06clicks\"\x18\n" +
+	"\x16ListDeadLettersRequest\"J\n" +
+	"\x17ListDeadLettersResponse\x12/\n" +
+	"\aletters\x18\x01 \x03(\v2\x15.shortener.DeadLetterR\aletters\"\xc8\x01\n" +
+	"\n" +
+	"DeadLetter\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"short_urls\x18\x03 \x03(\tR\tshortUrls\x12\x1a\n" +
+	"\battempts\x18\x04 \x01(\x05R\battempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x05 \x01(\tR\tlastError\x127\n" +
+	"\tfailed_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bfailedAt\",\n" +
+	"\x18ReplayDeadLettersRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x03R\x03ids\"7\n" +
+	"\x19ReplayDeadLettersResponse\x12\x1a\n" +
+	"\breplayed\x18\x01 \x01(\x03R\breplayed2\xe5\n" +
+	"\n" +
+	"\tShortener\x12X\n" +
+	"\x0eCreateShortURL\x12\x18.shortener.CreateRequest\x1a\x19.shortener.CreateResponse\"\x11\x8a\xb5\x18\r\x12\b/v1/urls\"\x01*\x12[\n" +
+	"\x0eGetOriginalURL\x12\x15.shortener.GetRequest\x1a\x16.shortener.GetResponse\"\x1a\x8a\xb5\x18\x16\n" +
+	"\x14/v1/urls/{short_url}\x12G\n" +
+	"\x04Ping\x12\x16.shortener.PingRequest\x1a\x17.shortener.PingResponse\"\x0e\x8a\xb5\x18\n" +
+	"\n" +
+	"\b/v1/ping\x12W\n" +
+	"\bGetStats\x12\x17.shortener.StatsRequest\x1a\x18.shortener.StatsResponse\"\x18\x8a\xb5\x18\x14\n" +
+	"\x12/v1/internal/stats\x12[\n" +
+	"\vGetUserURLs\x12\x1a.shortener.UserURLsRequest\x1a\x1b.shortener.UserURLsResponse\"\x13\x8a\xb5\x18\x0f\n" +
+	"\r/v1/user/urls\x12]\n" +
+	"\x0eDeleteUserURLs\x12\x18.shortener.DeleteRequest\x1a\x19.shortener.DeleteResponse\"\x16\x8a\xb5\x18\x12\x1a\r/v1/user/urls\"\x01*\x12h\n" +
+	"\x0fRestoreUserURLs\x12\x19.shortener.RestoreRequest\x1a\x1a.shortener.RestoreResponse\"\x1e\x8a\xb5\x18\x1a\x12\x15/v1/user/urls/restore\"\x01*\x12l\n" +
+	"\fGetDeleteJob\x12\x1b.shortener.DeleteJobRequest\x1a\x1c.shortener.DeleteJobResponse\"!\x8a\xb5\x18\x1d\n" +
+	"\x1b/v1/user/deletions/{job_id}\x12e\n" +
+	"\vBatchCreate\x12\x1d.shortener.BatchCreateRequest\x1a\x1e.shortener.BatchCreateResponse\"\x17\x8a\xb5\x18\x13\x12\x0e/v1/urls/batch\"\x01*\x12m\n" +
+	"\vGetURLStats\x12\x1a.shortener.URLStatsRequest\x1a\x1b.shortener.URLStatsResponse\"%\x8a\xb5\x18!\n" +
+	"\x1f/v1/user/urls/{short_url}/stats\x12x\n" +
+	"\x0fListDeadLetters\x12!.shortener.ListDeadLettersRequest\x1a\".shortener.ListDeadLettersResponse\"\x1e\x8a\xb5\x18\x1a\n" +
+	"\x18/v1/internal/deadletters\x12\x88\x01\n" +
+	"\x11ReplayDeadLetters\x12#.shortener.ReplayDeadLettersRequest\x1a$.shortener.ReplayDeadLettersResponse\"(\x8a\xb5\x18$\x12\x1f/v1/internal/deadletters/replay\"\x01*\x12L\n" +
+	"\fStreamCreate\x12\x1a.shortener.BatchCreateItem\x1a\x1e.shortener.BatchCreateResponse(\x01\x12B\n" +
+	"\x0eStreamUserURLs\x12\x1a.shortener.UserURLsRequest\x1a\x12.shortener.UserURL0\x01:I\n" +
+	"\x04http\x12\x1e.google.protobuf.MethodOptions\x18ц\x03 \x01(\v2\x13.shortener.HttpRuleR\x04httpB(Z&github.com/ryabkov82/shortener/api;apib\x06proto3"
 
 var (
 	file_api_shortener_proto_rawDescOnce sync.Once
@@ -840,49 +2002,98 @@ func file_api_shortener_proto_rawDescGZIP() []byte {
 	return file_api_shortener_proto_rawDescData
 }
 
-var file_api_shortener_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_api_shortener_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
 var file_api_shortener_proto_goTypes = []any{
-	(*CreateRequest)(nil),       // 0: shortener.CreateRequest
-	(*CreateResponse)(nil),      // 1: shortener.CreateResponse
-	(*GetRequest)(nil),          // 2: shortener.GetRequest
-	(*GetResponse)(nil),         // 3: shortener.GetResponse
-	(*PingRequest)(nil),         // 4: shortener.PingRequest
-	(*PingResponse)(nil),        // 5: shortener.PingResponse
-	(*StatsRequest)(nil),        // 6: shortener.StatsRequest
-	(*StatsResponse)(nil),       // 7: shortener.StatsResponse
-	(*UserURLsRequest)(nil),     // 8: shortener.UserURLsRequest
-	(*UserURLsResponse)(nil),    // 9: shortener.UserURLsResponse
-	(*UserURL)(nil),             // 10: shortener.UserURL
-	(*DeleteRequest)(nil),       // 11: shortener.DeleteRequest
-	(*DeleteResponse)(nil),      // 12: shortener.DeleteResponse
-	(*BatchCreateRequest)(nil),  // 13: shortener.BatchCreateRequest
-	(*BatchCreateItem)(nil),     // 14: shortener.BatchCreateItem
-	(*BatchCreateResponse)(nil), // 15: shortener.BatchCreateResponse
-	(*BatchCreateResult)(nil),   // 16: shortener.BatchCreateResult
+	(*HttpRule)(nil),                   // 0: shortener.HttpRule
+	(*CreateRequest)(nil),              // 1: shortener.CreateRequest
+	(*CreateResponse)(nil),             // 2: shortener.CreateResponse
+	(*GetRequest)(nil),                 // 3: shortener.GetRequest
+	(*GetResponse)(nil),                // 4: shortener.GetResponse
+	(*PingRequest)(nil),                // 5: shortener.PingRequest
+	(*PingResponse)(nil),               // 6: shortener.PingResponse
+	(*StatsRequest)(nil),               // 7: shortener.StatsRequest
+	(*StatsResponse)(nil),              // 8: shortener.StatsResponse
+	(*CacheStats)(nil),                 // 9: shortener.CacheStats
+	(*PurgeStats)(nil),                 // 10: shortener.PurgeStats
+	(*UserURLsRequest)(nil),            // 11: shortener.UserURLsRequest
+	(*UserURLsResponse)(nil),           // 12: shortener.UserURLsResponse
+	(*UserURL)(nil),                    // 13: shortener.UserURL
+	(*DeleteRequest)(nil),              // 14: shortener.DeleteRequest
+	(*DeleteResponse)(nil),             // 15: shortener.DeleteResponse
+	(*RestoreRequest)(nil),             // 16: shortener.RestoreRequest
+	(*RestoreResponse)(nil),            // 17: shortener.RestoreResponse
+	(*DeleteJobRequest)(nil),           // 18: shortener.DeleteJobRequest
+	(*DeleteJobResponse)(nil),          // 19: shortener.DeleteJobResponse
+	(*BatchCreateRequest)(nil),         // 20: shortener.BatchCreateRequest
+	(*BatchCreateItem)(nil),            // 21: shortener.BatchCreateItem
+	(*BatchCreateResponse)(nil),        // 22: shortener.BatchCreateResponse
+	(*BatchCreateResult)(nil),          // 23: shortener.BatchCreateResult
+	(*URLStatsRequest)(nil),            // 24: shortener.URLStatsRequest
+	(*URLStatsResponse)(nil),           // 25: shortener.URLStatsResponse
+	(*ClickCount)(nil),                 // 26: shortener.ClickCount
+	(*ListDeadLettersRequest)(nil),     // 27: shortener.ListDeadLettersRequest
+	(*ListDeadLettersResponse)(nil),    // 28: shortener.ListDeadLettersResponse
+	(*DeadLetter)(nil),                 // 29: shortener.DeadLetter
+	(*ReplayDeadLettersRequest)(nil),   // 30: shortener.ReplayDeadLettersRequest
+	(*ReplayDeadLettersResponse)(nil),  // 31: shortener.ReplayDeadLettersResponse
+	(*timestamppb.Timestamp)(nil),      // 32: google.protobuf.Timestamp
+	(*descriptorpb.MethodOptions)(nil), // 33: google.protobuf.MethodOptions
 }
 var file_api_shortener_proto_depIdxs = []int32{
-	10, // 0: shortener.UserURLsResponse.urls:type_name -> shortener.UserURL
-	14, // 1: shortener.BatchCreateRequest.items:type_name -> shortener.BatchCreateItem
-	16, // 2: shortener.BatchCreateResponse.items:type_name -> shortener.BatchCreateResult
-	0,  // 3: shortener.Shortener.CreateShortURL:input_type -> shortener.CreateRequest
-	2,  // 4: shortener.Shortener.GetOriginalURL:input_type -> shortener.GetRequest
-	4,  // 5: shortener.Shortener.Ping:input_type -> shortener.PingRequest
-	6,  // 6: shortener.Shortener.GetStats:input_type -> shortener.StatsRequest
-	8,  // 7: shortener.Shortener.GetUserURLs:input_type -> shortener.UserURLsRequest
-	11, // 8: shortener.Shortener.DeleteUserURLs:input_type -> shortener.DeleteRequest
-	13, // 9: shortener.Shortener.BatchCreate:input_type -> shortener.BatchCreateRequest
-	1,  // 10: shortener.Shortener.CreateShortURL:output_type -> shortener.CreateResponse
-	3,  // 11: shortener.Shortener.GetOriginalURL:output_type -> shortener.GetResponse
-	5,  // 12: shortener.Shortener.Ping:output_type -> shortener.PingResponse
-	7,  // 13: shortener.Shortener.GetStats:output_type -> shortener.StatsResponse
-	9,  // 14: shortener.Shortener.GetUserURLs:output_type -> shortener.UserURLsResponse
-	12, // 15: shortener.Shortener.DeleteUserURLs:output_type -> shortener.DeleteResponse
-	15, // 16: shortener.Shortener.BatchCreate:output_type -> shortener.BatchCreateResponse
-	10, // [10:17] is the sub-list for method output_type
-	3,  // [3:10] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	32, // 0: shortener.CreateRequest.expires_at:type_name -> google.protobuf.Timestamp
+	9,  // 1: shortener.StatsResponse.cache:type_name -> shortener.CacheStats
+	10, // 2: shortener.StatsResponse.purge:type_name -> shortener.PurgeStats
+	32, // 3: shortener.UserURLsRequest.created_from:type_name -> google.protobuf.Timestamp
+	32, // 4: shortener.UserURLsRequest.created_to:type_name -> google.protobuf.Timestamp
+	13, // 5: shortener.UserURLsResponse.urls:type_name -> shortener.UserURL
+	32, // 6: shortener.UserURL.created_at:type_name -> google.protobuf.Timestamp
+	32, // 7: shortener.UserURL.expires_at:type_name -> google.protobuf.Timestamp
+	32, // 8: shortener.DeleteJobResponse.created_at:type_name -> google.protobuf.Timestamp
+	32, // 9: shortener.DeleteJobResponse.updated_at:type_name -> google.protobuf.Timestamp
+	21, // 10: shortener.BatchCreateRequest.items:type_name -> shortener.BatchCreateItem
+	32, // 11: shortener.BatchCreateItem.expires_at:type_name -> google.protobuf.Timestamp
+	23, // 12: shortener.BatchCreateResponse.items:type_name -> shortener.BatchCreateResult
+	32, // 13: shortener.URLStatsResponse.first_click_at:type_name -> google.protobuf.Timestamp
+	32, // 14: shortener.URLStatsResponse.last_click_at:type_name -> google.protobuf.Timestamp
+	26, // 15: shortener.URLStatsResponse.top_referrers:type_name -> shortener.ClickCount
+	26, // 16: shortener.URLStatsResponse.top_user_agents:type_name -> shortener.ClickCount
+	29, // 17: shortener.ListDeadLettersResponse.letters:type_name -> shortener.DeadLetter
+	32, // 18: shortener.DeadLetter.failed_at:type_name -> google.protobuf.Timestamp
+	33, // 19: shortener.http:extendee -> google.protobuf.MethodOptions
+	0,  // 20: shortener.http:type_name -> shortener.HttpRule
+	1,  // 21: shortener.Shortener.CreateShortURL:input_type -> shortener.CreateRequest
+	3,  // 22: shortener.Shortener.GetOriginalURL:input_type -> shortener.GetRequest
+	5,  // 23: shortener.Shortener.Ping:input_type -> shortener.PingRequest
+	7,  // 24: shortener.Shortener.GetStats:input_type -> shortener.StatsRequest
+	11, // 25: shortener.Shortener.GetUserURLs:input_type -> shortener.UserURLsRequest
+	14, // 26: shortener.Shortener.DeleteUserURLs:input_type -> shortener.DeleteRequest
+	16, // 27: shortener.Shortener.RestoreUserURLs:input_type -> shortener.RestoreRequest
+	18, // 28: shortener.Shortener.GetDeleteJob:input_type -> shortener.DeleteJobRequest
+	20, // 29: shortener.Shortener.BatchCreate:input_type -> shortener.BatchCreateRequest
+	24, // 30: shortener.Shortener.GetURLStats:input_type -> shortener.URLStatsRequest
+	27, // 31: shortener.Shortener.ListDeadLetters:input_type -> shortener.ListDeadLettersRequest
+	30, // 32: shortener.Shortener.ReplayDeadLetters:input_type -> shortener.ReplayDeadLettersRequest
+	21, // 33: shortener.Shortener.StreamCreate:input_type -> shortener.BatchCreateItem
+	11, // 34: shortener.Shortener.StreamUserURLs:input_type -> shortener.UserURLsRequest
+	2,  // 35: shortener.Shortener.CreateShortURL:output_type -> shortener.CreateResponse
+	4,  // 36: shortener.Shortener.GetOriginalURL:output_type -> shortener.GetResponse
+	6,  // 37: shortener.Shortener.Ping:output_type -> shortener.PingResponse
+	8,  // 38: shortener.Shortener.GetStats:output_type -> shortener.StatsResponse
+	12, // 39: shortener.Shortener.GetUserURLs:output_type -> shortener.UserURLsResponse
+	15, // 40: shortener.Shortener.DeleteUserURLs:output_type -> shortener.DeleteResponse
+	17, // 41: shortener.Shortener.RestoreUserURLs:output_type -> shortener.RestoreResponse
+	19, // 42: shortener.Shortener.GetDeleteJob:output_type -> shortener.DeleteJobResponse
+	22, // 43: shortener.Shortener.BatchCreate:output_type -> shortener.BatchCreateResponse
+	25, // 44: shortener.Shortener.GetURLStats:output_type -> shortener.URLStatsResponse
+	28, // 45: shortener.Shortener.ListDeadLetters:output_type -> shortener.ListDeadLettersResponse
+	31, // 46: shortener.Shortener.ReplayDeadLetters:output_type -> shortener.ReplayDeadLettersResponse
+	22, // 47: shortener.Shortener.StreamCreate:output_type -> shortener.BatchCreateResponse
+	13, // 48: shortener.Shortener.StreamUserURLs:output_type -> shortener.UserURL
+	35, // [35:49] is the sub-list for method output_type
+	21, // [21:35] is the sub-list for method input_type
+	20, // [20:21] is the sub-list for extension type_name
+	19, // [19:20] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_api_shortener_proto_init() }
@@ -896,13 +2107,14 @@ func file_api_shortener_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_shortener_proto_rawDesc), len(file_api_shortener_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   17,
-			NumExtensions: 0,
+			NumMessages:   32,
+			NumExtensions: 1,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_shortener_proto_goTypes,
 		DependencyIndexes: file_api_shortener_proto_depIdxs,
 		MessageInfos:      file_api_shortener_proto_msgTypes,
+		ExtensionInfos:    file_api_shortener_proto_extTypes,
 	}.Build()
 	File_api_shortener_proto = out.File
 	file_api_shortener_proto_goTypes = nil
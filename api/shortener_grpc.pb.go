@@ -19,13 +19,20 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Shortener_CreateShortURL_FullMethodName = "/shortener.Shortener/CreateShortURL"
-	Shortener_GetOriginalURL_FullMethodName = "/shortener.Shortener/GetOriginalURL"
-	Shortener_Ping_FullMethodName           = "/shortener.Shortener/Ping"
-	Shortener_GetStats_FullMethodName       = "/shortener.Shortener/GetStats"
-	Shortener_GetUserURLs_FullMethodName    = "/shortener.Shortener/GetUserURLs"
-	Shortener_DeleteUserURLs_FullMethodName = "/shortener.Shortener/DeleteUserURLs"
-	Shortener_BatchCreate_FullMethodName    = "/shortener.Shortener/BatchCreate"
+	Shortener_CreateShortURL_FullMethodName    = "/shortener.Shortener/CreateShortURL"
+	Shortener_GetOriginalURL_FullMethodName    = "/shortener.Shortener/GetOriginalURL"
+	Shortener_Ping_FullMethodName              = "/shortener.Shortener/Ping"
+	Shortener_GetStats_FullMethodName          = "/shortener.Shortener/GetStats"
+	Shortener_GetUserURLs_FullMethodName       = "/shortener.Shortener/GetUserURLs"
+	Shortener_DeleteUserURLs_FullMethodName    = "/shortener.Shortener/DeleteUserURLs"
+	Shortener_RestoreUserURLs_FullMethodName   = "/shortener.Shortener/RestoreUserURLs"
+	Shortener_GetDeleteJob_FullMethodName      = "/shortener.Shortener/GetDeleteJob"
+	Shortener_BatchCreate_FullMethodName       = "/shortener.Shortener/BatchCreate"
+	Shortener_GetURLStats_FullMethodName       = "/shortener.Shortener/GetURLStats"
+	Shortener_ListDeadLetters_FullMethodName   = "/shortener.Shortener/ListDeadLetters"
+	Shortener_ReplayDeadLetters_FullMethodName = "/shortener.Shortener/ReplayDeadLetters"
+	Shortener_StreamCreate_FullMethodName      = "/shortener.Shortener/StreamCreate"
+	Shortener_StreamUserURLs_FullMethodName    = "/shortener.Shortener/StreamUserURLs"
 )
 
 // ShortenerClient is the client API for Shortener service.
@@ -38,7 +45,17 @@ type ShortenerClient interface {
 	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
 	GetUserURLs(ctx context.Context, in *UserURLsRequest, opts ...grpc.CallOption) (*UserURLsResponse, error)
 	DeleteUserURLs(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	RestoreUserURLs(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error)
+	GetDeleteJob(ctx context.Context, in *DeleteJobRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error)
 	BatchCreate(ctx context.Context, in *BatchCreateRequest, opts ...grpc.CallOption) (*BatchCreateResponse, error)
+	GetURLStats(ctx context.Context, in *URLStatsRequest, opts ...grpc.CallOption) (*URLStatsResponse, error)
+	ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*ListDeadLettersResponse, error)
+	ReplayDeadLetters(ctx context.Context, in *ReplayDeadLettersRequest, opts ...grpc.CallOption) (*ReplayDeadLettersResponse, error)
+	// Пакетное создание для больших объёмов: элементы передаются потоком,
+	// результаты возвращаются одним ответом после закрытия потока клиентом.
+	StreamCreate(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BatchCreateItem, BatchCreateResponse], error)
+	// Выгрузка URL пользователя потоком; limit ограничивает общее количество записей.
+	StreamUserURLs(ctx context.Context, in *UserURLsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserURL], error)
 }
 
 type shortenerClient struct {
@@ -109,6 +126,26 @@ func (c *shortenerClient) DeleteUserURLs(ctx context.Context, in *DeleteRequest,
 	return out, nil
 }
 
+func (c *shortenerClient) RestoreUserURLs(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreResponse)
+	err := c.cc.Invoke(ctx, Shortener_RestoreUserURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) GetDeleteJob(ctx context.Context, in *DeleteJobRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteJobResponse)
+	err := c.cc.Invoke(ctx, Shortener_GetDeleteJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *shortenerClient) BatchCreate(ctx context.Context, in *BatchCreateRequest, opts ...grpc.CallOption) (*BatchCreateResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(BatchCreateResponse)
@@ -119,6 +156,68 @@ func (c *shortenerClient) BatchCreate(ctx context.Context, in *BatchCreateReques
 	return out, nil
 }
 
+func (c *shortenerClient) GetURLStats(ctx context.Context, in *URLStatsRequest, opts ...grpc.CallOption) (*URLStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(URLStatsResponse)
+	err := c.cc.Invoke(ctx, Shortener_GetURLStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*ListDeadLettersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeadLettersResponse)
+	err := c.cc.Invoke(ctx, Shortener_ListDeadLetters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) ReplayDeadLetters(ctx context.Context, in *ReplayDeadLettersRequest, opts ...grpc.CallOption) (*ReplayDeadLettersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplayDeadLettersResponse)
+	err := c.cc.Invoke(ctx, Shortener_ReplayDeadLetters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) StreamCreate(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BatchCreateItem, BatchCreateResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[0], Shortener_StreamCreate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BatchCreateItem, BatchCreateResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Shortener_StreamCreateClient = grpc.ClientStreamingClient[BatchCreateItem, BatchCreateResponse]
+
+func (c *shortenerClient) StreamUserURLs(ctx context.Context, in *UserURLsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserURL], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[1], Shortener_StreamUserURLs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UserURLsRequest, UserURL]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Shortener_StreamUserURLsClient = grpc.ServerStreamingClient[UserURL]
+
 // ShortenerServer is the server API for Shortener service.
 // All implementations must embed UnimplementedShortenerServer
 // for forward compatibility.
@@ -129,7 +228,17 @@ type ShortenerServer interface {
 	GetStats(context.Context, *StatsRequest) (*StatsResponse, error)
 	GetUserURLs(context.Context, *UserURLsRequest) (*UserURLsResponse, error)
 	DeleteUserURLs(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	RestoreUserURLs(context.Context, *RestoreRequest) (*RestoreResponse, error)
+	GetDeleteJob(context.Context, *DeleteJobRequest) (*DeleteJobResponse, error)
 	BatchCreate(context.Context, *BatchCreateRequest) (*BatchCreateResponse, error)
+	GetURLStats(context.Context, *URLStatsRequest) (*URLStatsResponse, error)
+	ListDeadLetters(context.Context, *ListDeadLettersRequest) (*ListDeadLettersResponse, error)
+	ReplayDeadLetters(context.Context, *ReplayDeadLettersRequest) (*ReplayDeadLettersResponse, error)
+	// Пакетное создание для больших объёмов: элементы передаются потоком,
+	// результаты возвращаются одним ответом после закрытия потока клиентом.
+	StreamCreate(grpc.ClientStreamingServer[BatchCreateItem, BatchCreateResponse]) error
+	// Выгрузка URL пользователя потоком; limit ограничивает общее количество записей.
+	StreamUserURLs(*UserURLsRequest, grpc.ServerStreamingServer[UserURL]) error
 	mustEmbedUnimplementedShortenerServer()
 }
 
@@ -158,9 +267,30 @@ func (UnimplementedShortenerServer) GetUserURLs(context.Context, *UserURLsReques
 func (UnimplementedShortenerServer) DeleteUserURLs(context.Context, *DeleteRequest) (*DeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserURLs not implemented")
 }
+func (UnimplementedShortenerServer) RestoreUserURLs(context.Context, *RestoreRequest) (*RestoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreUserURLs not implemented")
+}
+func (UnimplementedShortenerServer) GetDeleteJob(context.Context, *DeleteJobRequest) (*DeleteJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeleteJob not implemented")
+}
 func (UnimplementedShortenerServer) BatchCreate(context.Context, *BatchCreateRequest) (*BatchCreateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchCreate not implemented")
 }
+func (UnimplementedShortenerServer) GetURLStats(context.Context, *URLStatsRequest) (*URLStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLStats not implemented")
+}
+func (UnimplementedShortenerServer) ListDeadLetters(context.Context, *ListDeadLettersRequest) (*ListDeadLettersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeadLetters not implemented")
+}
+func (UnimplementedShortenerServer) ReplayDeadLetters(context.Context, *ReplayDeadLettersRequest) (*ReplayDeadLettersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplayDeadLetters not implemented")
+}
+func (UnimplementedShortenerServer) StreamCreate(grpc.ClientStreamingServer[BatchCreateItem, BatchCreateResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamCreate not implemented")
+}
+func (UnimplementedShortenerServer) StreamUserURLs(*UserURLsRequest, grpc.ServerStreamingServer[UserURL]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamUserURLs not implemented")
+}
 func (UnimplementedShortenerServer) mustEmbedUnimplementedShortenerServer() {}
 func (UnimplementedShortenerServer) testEmbeddedByValue()                   {}
 
@@ -290,6 +420,42 @@ func _Shortener_DeleteUserURLs_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_RestoreUserURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).RestoreUserURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortener_RestoreUserURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).RestoreUserURLs(ctx, req.(*RestoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_GetDeleteJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).GetDeleteJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortener_GetDeleteJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).GetDeleteJob(ctx, req.(*DeleteJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Shortener_BatchCreate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(BatchCreateRequest)
 	if err := dec(in); err != nil {
@@ -308,6 +474,78 @@ func _Shortener_BatchCreate_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_GetURLStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(URLStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).GetURLStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortener_GetURLStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).GetURLStats(ctx, req.(*URLStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_ListDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).ListDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortener_ListDeadLetters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).ListDeadLetters(ctx, req.(*ListDeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_ReplayDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayDeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).ReplayDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortener_ReplayDeadLetters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).ReplayDeadLetters(ctx, req.(*ReplayDeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_StreamCreate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ShortenerServer).StreamCreate(&grpc.GenericServerStream[BatchCreateItem, BatchCreateResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Shortener_StreamCreateServer = grpc.ClientStreamingServer[BatchCreateItem, BatchCreateResponse]
+
+func _Shortener_StreamUserURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(UserURLsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShortenerServer).StreamUserURLs(m, &grpc.GenericServerStream[UserURLsRequest, UserURL]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Shortener_StreamUserURLsServer = grpc.ServerStreamingServer[UserURL]
+
 // Shortener_ServiceDesc is the grpc.ServiceDesc for Shortener service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -339,11 +577,42 @@ var Shortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUserURLs",
 			Handler:    _Shortener_DeleteUserURLs_Handler,
 		},
+		{
+			MethodName: "RestoreUserURLs",
+			Handler:    _Shortener_RestoreUserURLs_Handler,
+		},
+		{
+			MethodName: "GetDeleteJob",
+			Handler:    _Shortener_GetDeleteJob_Handler,
+		},
 		{
 			MethodName: "BatchCreate",
 			Handler:    _Shortener_BatchCreate_Handler,
 		},
+		{
+			MethodName: "GetURLStats",
+			Handler:    _Shortener_GetURLStats_Handler,
+		},
+		{
+			MethodName: "ListDeadLetters",
+			Handler:    _Shortener_ListDeadLetters_Handler,
+		},
+		{
+			MethodName: "ReplayDeadLetters",
+			Handler:    _Shortener_ReplayDeadLetters_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamCreate",
+			Handler:       _Shortener_StreamCreate_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamUserURLs",
+			Handler:       _Shortener_StreamUserURLs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/shortener.proto",
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 
@@ -162,3 +163,54 @@ func benchmarkHandleRedirect(b *testing.B, serv *httptest.Server, cookie *http.C
 	})
 
 }
+
+// go test -bench=RedirectMixed_InMemory -benchmem -cpu=4 -benchtime=5s ./benchmark
+//
+// Редиректы выполняются одновременно с сохранением новых ссылок: writePercent
+// задаёт долю запросов на сохранение, sync - режим сброса файла хранилища на диск.
+func BenchmarkRedirectMixed_InMemory(b *testing.B) {
+	for _, syncMode := range []inmemory.SyncMode{inmemory.SyncNever, inmemory.SyncAlways} {
+		for _, writePercent := range []int64{0, 10, 50} {
+			name := fmt.Sprintf("sync=%s/writes=%d%%", syncMode, writePercent)
+			b.Run(name, func(b *testing.B) {
+				benchmarkRedirectMixed(b, syncMode, writePercent)
+			})
+		}
+	}
+}
+
+func benchmarkRedirectMixed(b *testing.B, syncMode inmemory.SyncMode, writePercent int64) {
+	fileStorage := filepath.Join(b.TempDir(), "test.dat")
+
+	st, err := inmemory.NewInMemoryStorage(fileStorage, inmemory.WithSyncMode(syncMode, 0))
+	if err != nil {
+		b.Fatalf("Не удалось инициализировать хранилище: %v", err)
+	}
+	defer st.Close()
+
+	srv := service.NewService(st)
+
+	_, userID := createSignedCookie()
+	initStorage(userID, srv)
+
+	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+	var counter, written int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := atomic.AddInt64(&counter, 1)
+			if n%100 < writePercent {
+				originalURL := fmt.Sprintf("https://example.com/new/%d", atomic.AddInt64(&written, 1))
+				if _, err := srv.GetShortKey(ctx, originalURL); err != nil {
+					b.Errorf("Save failed: %v", err)
+				}
+				continue
+			}
+
+			if _, err := srv.GetRedirectURL(ctx, shortURLs[n%prefillCount]); err != nil {
+				b.Errorf("Redirect failed: %v", err)
+			}
+		}
+	})
+}
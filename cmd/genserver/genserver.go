@@ -2,19 +2,38 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"go/format"
 	"log"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"text/template"
 )
 
 type Method struct {
-	Name       string
-	Request    string
-	Response   string
-	HandlerVar string
+	Name         string
+	Request      string
+	Response     string
+	HandlerVar   string
+	ClientStream bool // Запрос передаётся потоком (stream в аргументе)
+	ServerStream bool // Ответ передаётся потоком (stream в результате)
+	HTTP         *HTTPRule
+}
+
+// HTTPRule - маршрут REST-шлюза из опции (http) метода.
+type HTTPRule struct {
+	Method     string   // Метод HTTP: GET, POST или DELETE
+	Path       string   // Шаблон пути в синтаксисе chi
+	Body       bool     // Поля запроса передаются в теле JSON
+	PathParams []string // Поля запроса, заданные в пути
+}
+
+// Streaming сообщает, что метод использует потоки хотя бы в одном направлении.
+func (m Method) Streaming() bool {
+	return m.ClientStream || m.ServerStream
 }
 
 const tmpl = `// Code generated by genserver from proto; DO NOT EDIT.
@@ -34,7 +53,7 @@ type ServerOption func(s *Server)
 
 {{range .}}
 type {{.Name}}Endpoint interface {
-	{{.Name}}(ctx context.Context, req *api.{{.Request}}) (*api.{{.Response}}, error)
+	{{template "signature" .}}
 }
 
 func With{{.Name}}Endpoint(h {{.Name}}Endpoint) ServerOption {
@@ -61,15 +80,111 @@ func NewServer(baseHandler *base.BaseHandler, opts ...ServerOption) *Server {
 }
 
 {{range .}}
-func (s *Server) {{.Name}}(ctx context.Context, req *api.{{.Request}}) (*api.{{.Response}}, error) {
+func (s *Server) {{template "signature" .}} {
 	if s.{{.HandlerVar}} == nil {
-		return nil, status.Error(codes.Unimplemented, "{{.Name}} handler not provided")
+		return {{if not .Streaming}}nil, {{end}}status.Error(codes.Unimplemented, "{{.Name}} handler not provided")
 	}
-	return s.{{.HandlerVar}}.{{.Name}}(ctx, req)
+	return s.{{.HandlerVar}}.{{.Name}}({{template "args" .}})
 }
 {{end}}
+{{- define "signature"}}{{if .ClientStream}}{{.Name}}(stream api.Shortener_{{.Name}}Server) error{{else if .ServerStream}}{{.Name}}(req *api.{{.Request}}, stream api.Shortener_{{.Name}}Server) error{{else}}{{.Name}}(ctx context.Context, req *api.{{.Request}}) (*api.{{.Response}}, error){{end}}{{end}}
+{{- define "args"}}{{if .ClientStream}}stream{{else if .ServerStream}}req, stream{{else}}ctx, req{{end}}{{end}}`
+
+const routesTmpl = `// Code generated by genserver from proto; DO NOT EDIT.
+
+package gateway
+
+import (
+	"context"
+
+	"github.com/ryabkov82/shortener/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// Routes возвращает маршруты REST-шлюза для методов srv, размеченных опцией (http).
+func Routes(srv api.ShortenerServer) []Route {
+	return []Route{
+{{- range .}}{{if .HTTP}}
+		{
+			Method:     "{{.HTTP.Method}}",
+			Pattern:    "{{.HTTP.Path}}",
+			FullMethod: api.Shortener_{{.Name}}_FullMethodName,
+			PathParams: []string{ {{- range $i, $p := .HTTP.PathParams}}{{if $i}}, {{end}}"{{$p}}"{{end -}} },
+			Body:       {{.HTTP.Body}},
+			NewRequest: func() proto.Message { return &api.{{.Request}}{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.{{.Name}}(ctx, req.(*api.{{.Request}}))
+			},
+		},
+{{- end}}{{end}}
+	}
+}
 `
 
+// parseHTTPRule разбирает тело опции (http), например
+// { get: "/v1/urls/{short_url}" } или { post: "/v1/urls" body: "*" }.
+// Тело, содержащее что-либо кроме полей вида имя: "значение", отклоняется,
+// чтобы маршрут не был молча сгенерирован не полностью.
+func parseHTTPRule(method, body string) *HTTPRule {
+	fieldRe := regexp.MustCompile(`(\w+)\s*:\s*"([^"]*)"`)
+	paramRe := regexp.MustCompile(`\{(\w+)\}`)
+
+	if rest := strings.Trim(fieldRe.ReplaceAllString(body, ""), " \t,;"); rest != "" {
+		log.Fatalf("%s: cannot parse option (http) { %s }: unexpected %q", method, strings.TrimSpace(body), rest)
+	}
+
+	rule := &HTTPRule{}
+	for _, field := range fieldRe.FindAllStringSubmatch(body, -1) {
+		switch field[1] {
+		case "get", "post", "delete":
+			if rule.Method != "" {
+				log.Fatalf("%s: option (http) must define exactly one path", method)
+			}
+			rule.Method = strings.ToUpper(field[1])
+			rule.Path = field[2]
+		case "body":
+			if field[2] != "*" && field[2] != "" {
+				log.Fatalf("%s: option (http) supports only body: \"*\"", method)
+			}
+			rule.Body = field[2] == "*"
+		default:
+			log.Fatalf("%s: unknown option (http) field %q", method, field[1])
+		}
+	}
+	if rule.Method == "" {
+		log.Fatalf("%s: option (http) must define a path", method)
+	}
+	if rule.Body && rule.Method == "GET" {
+		log.Fatalf("%s: GET route cannot have a body", method)
+	}
+
+	for _, param := range paramRe.FindAllStringSubmatch(rule.Path, -1) {
+		rule.PathParams = append(rule.PathParams, param[1])
+	}
+	return rule
+}
+
+// generate выполняет шаблон, форматирует результат как gofmt и записывает его в файл.
+func generate(name, text, outFile string, methods []Method) {
+	t := template.Must(template.New(name).Parse(text))
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, methods); err != nil {
+		log.Fatalf("template execution failed: %v", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("generated %s is not valid Go: %v", filepath.Base(outFile), err)
+	}
+
+	if err := os.WriteFile(outFile, src, 0644); err != nil {
+		log.Fatalf("cannot write output file: %v", err)
+	}
+
+	fmt.Printf("%s успешно сгенерирован: %s\n", filepath.Base(outFile), outFile)
+}
+
 func main() {
 	protoFile := "./api/shortener.proto"
 
@@ -79,14 +194,20 @@ func main() {
 	}
 	defer f.Close()
 
-	rpcRe := regexp.MustCompile(`rpc\s+(\w+)\s*\(\s*(\w+)\s*\)\s+returns\s*\(\s*(\w+)\s*\);`)
+	// Необязательное ключевое слово stream перед типом запроса и ответа
+	// задаёт потоковый метод (клиентский, серверный или двунаправленный).
+	// Метод с опциями завершается не точкой с запятой, а блоком { ... }.
+	rpcRe := regexp.MustCompile(`rpc\s+(\w+)\s*\(\s*(stream\s+)?(\w+)\s*\)\s+returns\s*\(\s*(stream\s+)?(\w+)\s*\)\s*(;|\{)`)
+	httpOptionRe := regexp.MustCompile(`option\s+\(http\)\s*=\s*\{(.*)\}\s*;`)
 	serviceStartRe := regexp.MustCompile(`service\s+(\w+)\s*{`)
 
-	var inService bool
+	var inService, inRPC bool
 	var methods []Method
 
 	scanner := bufio.NewScanner(f)
+	lineNo := 0
 	for scanner.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scanner.Text())
 
 		if !inService {
@@ -95,43 +216,71 @@ func main() {
 			}
 			continue
 		}
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		// Блок опций метода
+		if inRPC {
+			m := &methods[len(methods)-1]
+			switch {
+			case line == "}":
+				inRPC = false
+			case rpcRe.MatchString(line):
+				log.Fatalf("%s:%d: options block of %s is not closed", protoFile, lineNo, m.Name)
+			case strings.Contains(line, "(http)"):
+				// Опция поддерживается только в однострочной форме; любую другую
+				// запись отклоняем, а не пропускаем маршрут
+				matches := httpOptionRe.FindStringSubmatch(line)
+				if matches == nil {
+					log.Fatalf("%s:%d: cannot parse option (http) of %s: expected option (http) = { ... }; on a single line",
+						protoFile, lineNo, m.Name)
+				}
+				if m.Streaming() {
+					log.Fatalf("%s: streaming methods cannot have option (http)", m.Name)
+				}
+				if m.HTTP != nil {
+					log.Fatalf("%s: option (http) is defined more than once", m.Name)
+				}
+				m.HTTP = parseHTTPRule(m.Name, matches[1])
+			}
+			continue
+		}
 
 		if line == "}" {
 			inService = false
 			break
 		}
+		if strings.Contains(line, "(http)") && !rpcRe.MatchString(line) {
+			log.Fatalf("%s:%d: option (http) outside of an rpc options block", protoFile, lineNo)
+		}
 
 		matches := rpcRe.FindStringSubmatch(line)
-		if len(matches) == 4 {
+		if len(matches) == 7 {
 			name := matches[1]
-			req := matches[2]
-			resp := matches[3]
+			req := matches[3]
+			resp := matches[5]
 
 			methods = append(methods, Method{
-				Name:       name,
-				Request:    req,
-				Response:   resp,
-				HandlerVar: name + "Handler",
+				Name:         name,
+				Request:      req,
+				Response:     resp,
+				HandlerVar:   name + "Handler",
+				ClientStream: matches[2] != "",
+				ServerStream: matches[4] != "",
 			})
+			inRPC = matches[6] == "{"
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		log.Fatalf("reading proto file error: %v", err)
 	}
-
-	// Генерируем файл
-	t := template.Must(template.New("server").Parse(tmpl))
-	outFile := "./internal/app/handlers/grpc/server_gen.go"
-	out, err := os.Create(outFile)
-	if err != nil {
-		log.Fatalf("cannot create output file: %v", err)
-	}
-	defer out.Close()
-
-	if err := t.Execute(out, methods); err != nil {
-		log.Fatalf("template execution failed: %v", err)
+	if inService {
+		log.Fatalf("%s: service definition is not closed", protoFile)
 	}
 
-	fmt.Printf("server_gen.go успешно сгенерирован: %s\n", outFile)
+	// Генерируем файлы
+	generate("server", tmpl, "./internal/app/handlers/grpc/server_gen.go", methods)
+	generate("routes", routesTmpl, "./internal/app/handlers/gateway/routes_gen.go", methods)
 }
@@ -0,0 +1,48 @@
+// Команда storageconv переводит файл in-memory хранилища в другой формат.
+//
+// Использование:
+//
+//	storageconv -src storage.dat [-dst storage.bin] [-format protobuf+zstd]
+//
+// Формат исходного файла определяется автоматически. Без -dst файл
+// конвертируется на месте (атомарной заменой).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/ryabkov82/shortener/internal/app/logger"
+	"github.com/ryabkov82/shortener/internal/app/storage/inmemory"
+)
+
+func main() {
+	src := flag.String("src", "storage.dat", "Path to the source storage file")
+	dst := flag.String("dst", "", "Path to the converted file (defaults to -src)")
+	formatName := flag.String("format", string(inmemory.FormatProto),
+		"Target format: json, protobuf, protobuf+gzip, protobuf+zstd")
+	flag.Parse()
+
+	format, err := inmemory.ParseFormat(*formatName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Отброшенные при чтении записи попадают в лог
+	if err := logger.Initialize("warn"); err != nil {
+		log.Fatal(err)
+	}
+	defer logger.Log.Sync()
+
+	if *dst == "" {
+		*dst = *src
+	}
+
+	records, err := inmemory.ConvertFile(*src, *dst, format, inmemory.WithLogger(logger.Log))
+	if err != nil {
+		log.Fatalf("Ошибка конвертации %s: %v", *src, err)
+	}
+
+	fmt.Printf("%s -> %s (%s): записей %d\n", *src, *dst, format, records)
+}
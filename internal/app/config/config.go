@@ -20,15 +20,110 @@ Package config предоставляет загрузку и валидацию
 	    "base_url": "http://localhost",
 	    "file_storage_path": "/path/to/file.db",
 	    "database_dsn": "",
+	    "redis_url": "",
+	    "kv_storage_path": "",
 	    "enable_https": true,
 	    "jwt_secret": "secret_key",
 	    "pprof": {
 	        "enabled": true,
 	        "auth_user": "admin",
 	        "auth_pass": "password"
+	    },
+	    "purge": {
+	        "enabled": true,
+	        "interval": "1h",
+	        "retention": "168h",
+	        "batch_size": 1000
+	    },
+	    "persistence": {
+	        "format": "json",
+	        "compact_interval": "10m",
+	        "sync": "interval",
+	        "sync_interval": "1s"
+	    },
+	    "key_generator": {
+	        "strategy": "random",
+	        "encoding": "base62",
+	        "length": 8,
+	        "salt": "",
+	        "max_attempts": 5
+	    },
+	    "redirect_cache": {
+	        "enabled": false,
+	        "size": 10000,
+	        "ttl": "1m"
+	    },
+	    "delete_queue": {
+	        "enabled": false,
+	        "path": "delete_queue.log"
+	    },
+	    "delete_worker": {
+	        "workers": 1,
+	        "batch_size": 100,
+	        "flush_interval": "500ms",
+	        "queue_size": 10000,
+	        "retry_attempts": 3,
+	        "retry_initial_backoff": "100ms",
+	        "retry_max_backoff": "5s",
+	        "dead_letter_limit": 1000,
+	        "restore_window": "24h"
+	    },
+	    "grpc": {
+	        "reflection": false,
+	        "health_check_interval": "10s"
 	    }
 	}
 
+Длительности в секциях purge и persistence задаются в формате time.ParseDuration.
+Секция persistence относится к файловому хранилищу. Параметр format задаёт формат
+новых файлов (json, protobuf, protobuf+gzip, protobuf+zstd); существующий файл
+переводится в него при сжатии. Нулевой compact_interval отключает периодическое
+сжатие файла (при остановке сервиса файл сжимается всегда).
+
+Режимы сброса записей файла хранилища на диск (persistence.sync):
+- always: запрос подтверждается только после fsync (одновременные записи объединяются)
+- interval: fsync выполняется раз в sync_interval
+- never: fsync не выполняется, сброс на диск остаётся за ОС
+
+Хранилище выбирается по настройкам: PostgreSQL (database_dsn), затем Redis
+(redis_url вида redis://[user:password@]host:port[/db]), затем встроенное
+хранилище ключ-значение в каталоге kv_storage_path, иначе файловое хранилище.
+
+Стратегии генерации коротких ключей (key_generator.strategy):
+- random: случайные ключи из crypto/rand
+- counter: счётчик в памяти процесса (только с файловым хранилищем, т.е. для одного экземпляра сервиса)
+- sequence: последовательность PostgreSQL или счётчик Redis и встроенного хранилища (требует database_dsn, redis_url или kv_storage_path)
+
+Для стратегий counter и sequence encoding задаёт кодирование идентификатора
+(base62 или hashids), а length - минимальную длину ключа.
+
+Секция redirect_cache включает LRU-кэш редиректов перед любым хранилищем:
+size - максимальное количество записей, ttl - время жизни записи. Удаления,
+выполненные другими экземплярами сервиса, становятся видны не позже чем через ttl.
+
+Секция delete_queue включает постоянную очередь асинхронного удаления: задачи,
+не обработанные до остановки сервиса, выполняются после перезапуска. С PostgreSQL
+очередь хранится в таблице delete_queue, с остальными хранилищами - в журнале path.
+
+Секция delete_worker настраивает асинхронное удаление: workers - количество
+воркеров, batch_size - количество URL, при накоплении которого пакет отправляется
+в обработку, не дожидаясь flush_interval, queue_size - ёмкость очереди задач.
+При заполненной очереди запросы на удаление отклоняются (HTTP 503 с заголовком
+Retry-After, gRPC ResourceExhausted). Повторные попытки при ошибках хранилища:
+retry_attempts - общее количество попыток, пауза между ними удваивается
+от retry_initial_backoff до retry_max_backoff. Задачи, не выполненные после всех
+попыток, попадают в хранилище dead-letter (не более dead_letter_limit записей),
+откуда их можно повторить через внутренний API.
+
+Восстановить удалённую ссылку можно в течение restore_window после удаления.
+Ссылки, физически удалённые фоновой очисткой, не восстанавливаются, поэтому окно
+имеет смысл задавать меньше purge.retention.
+
+gRPC-сервер публикует стандартный сервис grpc.health.v1: состояние каждой
+зависимости (сейчас - storage) проверяется раз в grpc.health_check_interval,
+а при остановке сервиса все статусы переводятся в NOT_SERVING. Параметр
+grpc.reflection включает сервис рефлексии для grpcurl и подобных инструментов.
+
 Путь к JSON-файлу конфигурации можно указать:
 - Через флаг -c или --config
 - Через переменную окружения CONFIG
@@ -46,22 +141,32 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config содержит все параметры конфигурации приложения.
 type Config struct {
-	HTTPServerAddr string      `json:"server_address"`      // Адрес HTTP-сервера в формате host:port
-	GRPCServerAddr string      `json:"grpc_server_address"` // Адрес gRPC-сервера
-	BaseURL        string      `json:"base_url"`            // Базовый URL для сокращённых ссылок
-	LogLevel       string      `json:"log_level"`           // Уровень логирования (debug, info, warn, error)
-	FileStorage    string      `json:"file_storage_path"`   // Путь к файлу хранилища
-	DBConnect      string      `json:"database_dsn"`        // Строка подключения к БД
-	JwtKey         string      `json:"jwt_secret"`          // Секретный ключ для JWT
-	ConfigPProf    PProfConfig `json:"pprof"`               // Настройки pprof
-	EnableHTTPS    bool        `json:"enable_https"`        // Включение HTTPS
-	SSLCertFile    string      `json:"ssl_cert_file"`       // Путь к SSL сертификату
-	SSLKeyFile     string      `json:"ssl_key_file"`        // Путь к SSL ключу
-	TrustedSubnet  string      `json:"trusted_subnet"`      // Доверенная подсеть
+	HTTPServerAddr string             `json:"server_address"`      // Адрес HTTP-сервера в формате host:port
+	GRPCServerAddr string             `json:"grpc_server_address"` // Адрес gRPC-сервера
+	BaseURL        string             `json:"base_url"`            // Базовый URL для сокращённых ссылок
+	LogLevel       string             `json:"log_level"`           // Уровень логирования (debug, info, warn, error)
+	FileStorage    string             `json:"file_storage_path"`   // Путь к файлу хранилища
+	DBConnect      string             `json:"database_dsn"`        // Строка подключения к БД
+	RedisURL       string             `json:"redis_url"`           // Адрес сервера Redis
+	KVStorage      string             `json:"kv_storage_path"`     // Каталог встроенного хранилища ключ-значение
+	JwtKey         string             `json:"jwt_secret"`          // Секретный ключ для JWT
+	ConfigPProf    PProfConfig        `json:"pprof"`               // Настройки pprof
+	EnableHTTPS    bool               `json:"enable_https"`        // Включение HTTPS
+	SSLCertFile    string             `json:"ssl_cert_file"`       // Путь к SSL сертификату
+	SSLKeyFile     string             `json:"ssl_key_file"`        // Путь к SSL ключу
+	TrustedSubnet  string             `json:"trusted_subnet"`      // Доверенная подсеть
+	Purge          PurgeConfig        `json:"purge"`               // Настройки фоновой очистки
+	KeyGen         KeyGenConfig       `json:"key_generator"`       // Настройки генерации коротких ключей
+	Persistence    PersistenceConfig  `json:"persistence"`         // Настройки файлового хранилища
+	Cache          CacheConfig        `json:"redirect_cache"`      // Настройки кэша редиректов
+	DeleteQueue    DeleteQueueConfig  `json:"delete_queue"`        // Настройки очереди удаления
+	DeleteWorker   DeleteWorkerConfig `json:"delete_worker"`       // Настройки воркера удаления
+	GRPC           GRPCConfig         `json:"grpc"`                // Дополнительные настройки gRPC-сервера
 }
 
 // PProfConfig содержит настройки профилирования pprof.
@@ -73,6 +178,331 @@ type PProfConfig struct {
 	Enabled  bool   `json:"enabled"`
 }
 
+// PurgeConfig содержит настройки фоновой очистки удалённых и истёкших ссылок.
+type PurgeConfig struct {
+	Interval  time.Duration `json:"interval"`   // Период между проходами очистки
+	Retention time.Duration `json:"retention"`  // Срок хранения перед физическим удалением
+	BatchSize int           `json:"batch_size"` // Размер порции удаления
+	Enabled   bool          `json:"enabled"`    // Включение очистки
+}
+
+// UnmarshalJSON разбирает настройки очистки, принимая длительности в виде строк ("1h", "30m").
+func (p *PurgeConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Interval  string `json:"interval"`
+		Retention string `json:"retention"`
+		BatchSize *int   `json:"batch_size"`
+		Enabled   bool   `json:"enabled"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var err error
+	if raw.Interval != "" {
+		if p.Interval, err = time.ParseDuration(raw.Interval); err != nil {
+			return fmt.Errorf("invalid purge interval: %w", err)
+		}
+	}
+	if raw.Retention != "" {
+		if p.Retention, err = time.ParseDuration(raw.Retention); err != nil {
+			return fmt.Errorf("invalid purge retention: %w", err)
+		}
+	}
+	if raw.BatchSize != nil {
+		if *raw.BatchSize <= 0 {
+			return fmt.Errorf("invalid purge batch size: %d", *raw.BatchSize)
+		}
+		p.BatchSize = *raw.BatchSize
+	}
+	p.Enabled = raw.Enabled
+
+	return nil
+}
+
+// PersistenceConfig содержит настройки файлового хранилища.
+type PersistenceConfig struct {
+	Format          string        `json:"format"`           // Формат файла хранилища
+	CompactInterval time.Duration `json:"compact_interval"` // Период сжатия файла (0 - только при остановке)
+	Sync            string        `json:"sync"`             // Режим сброса записей на диск
+	SyncInterval    time.Duration `json:"sync_interval"`    // Период fsync в режиме interval
+}
+
+// storageFormats - допустимые форматы файла хранилища.
+var storageFormats = []string{"json", "protobuf", "protobuf+gzip", "protobuf+zstd"}
+
+// validateStorageFormat проверяет название формата файла хранилища.
+func validateStorageFormat(format string) error {
+	for _, f := range storageFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown storage format %q (expected one of %s)", format, strings.Join(storageFormats, ", "))
+}
+
+// syncModes - допустимые режимы сброса записей файла хранилища на диск.
+var syncModes = []string{"always", "interval", "never"}
+
+// validatePersistence проверяет настройки файлового хранилища.
+func validatePersistence(p PersistenceConfig) error {
+	if err := validateStorageFormat(p.Format); err != nil {
+		return err
+	}
+	for _, mode := range syncModes {
+		if p.Sync == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sync mode %q (expected one of %s)", p.Sync, strings.Join(syncModes, ", "))
+}
+
+// UnmarshalJSON разбирает настройки файлового хранилища, принимая длительности в виде строк ("10m").
+func (p *PersistenceConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Format          string `json:"format"`
+		CompactInterval string `json:"compact_interval"`
+		Sync            string `json:"sync"`
+		SyncInterval    string `json:"sync_interval"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	p.Format = raw.Format
+	p.Sync = raw.Sync
+
+	if raw.CompactInterval != "" {
+		interval, err := time.ParseDuration(raw.CompactInterval)
+		if err != nil || interval < 0 {
+			return fmt.Errorf("invalid compact interval: %q", raw.CompactInterval)
+		}
+		p.CompactInterval = interval
+	}
+
+	if raw.SyncInterval != "" {
+		interval, err := time.ParseDuration(raw.SyncInterval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid sync interval: %q", raw.SyncInterval)
+		}
+		p.SyncInterval = interval
+	}
+
+	return nil
+}
+
+// CacheConfig содержит настройки кэша редиректов.
+type CacheConfig struct {
+	Enabled bool          `json:"enabled"` // Включение кэша
+	Size    int           `json:"size"`    // Максимальное количество записей
+	TTL     time.Duration `json:"ttl"`     // Время жизни записи
+}
+
+// DeleteQueueConfig содержит настройки постоянной очереди удаления URL.
+type DeleteQueueConfig struct {
+	Enabled bool   `json:"enabled"` // Включение постоянной очереди
+	Path    string `json:"path"`    // Файл журнала очереди (не используется с PostgreSQL)
+}
+
+// DeleteWorkerConfig содержит настройки воркера асинхронного удаления URL.
+type DeleteWorkerConfig struct {
+	Workers             int           `json:"workers"`               // Количество воркеров
+	BatchSize           int           `json:"batch_size"`            // Количество URL, при котором пакет отправляется в обработку
+	FlushInterval       time.Duration `json:"flush_interval"`        // Максимальное время накопления пакета
+	QueueSize           int           `json:"queue_size"`            // Ёмкость очереди задач
+	RetryAttempts       int           `json:"retry_attempts"`        // Общее количество попыток пометки URL
+	RetryInitialBackoff time.Duration `json:"retry_initial_backoff"` // Пауза перед второй попыткой
+	RetryMaxBackoff     time.Duration `json:"retry_max_backoff"`     // Максимальная пауза между попытками
+	DeadLetterLimit     int           `json:"dead_letter_limit"`     // Максимальное количество записей dead-letter
+	RestoreWindow       time.Duration `json:"restore_window"`        // Время после удаления, в течение которого ссылку можно восстановить
+}
+
+// UnmarshalJSON разбирает настройки воркера удаления, принимая длительности в виде строк ("100ms").
+func (d *DeleteWorkerConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Workers             int    `json:"workers"`
+		BatchSize           int    `json:"batch_size"`
+		FlushInterval       string `json:"flush_interval"`
+		QueueSize           int    `json:"queue_size"`
+		RetryAttempts       int    `json:"retry_attempts"`
+		RetryInitialBackoff string `json:"retry_initial_backoff"`
+		RetryMaxBackoff     string `json:"retry_max_backoff"`
+		DeadLetterLimit     int    `json:"dead_letter_limit"`
+		RestoreWindow       string `json:"restore_window"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.Workers < 0 {
+		return fmt.Errorf("invalid delete workers count: %d", raw.Workers)
+	}
+	d.Workers = raw.Workers
+
+	if raw.BatchSize < 0 {
+		return fmt.Errorf("invalid delete batch size: %d", raw.BatchSize)
+	}
+	d.BatchSize = raw.BatchSize
+
+	if raw.QueueSize < 0 {
+		return fmt.Errorf("invalid delete queue size: %d", raw.QueueSize)
+	}
+	d.QueueSize = raw.QueueSize
+
+	if raw.FlushInterval != "" {
+		interval, err := time.ParseDuration(raw.FlushInterval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid delete flush interval: %q", raw.FlushInterval)
+		}
+		d.FlushInterval = interval
+	}
+
+	if raw.RetryAttempts < 0 {
+		return fmt.Errorf("invalid retry attempts: %d", raw.RetryAttempts)
+	}
+	d.RetryAttempts = raw.RetryAttempts
+
+	if raw.DeadLetterLimit < 0 {
+		return fmt.Errorf("invalid dead letter limit: %d", raw.DeadLetterLimit)
+	}
+	d.DeadLetterLimit = raw.DeadLetterLimit
+
+	if raw.RetryInitialBackoff != "" {
+		backoff, err := time.ParseDuration(raw.RetryInitialBackoff)
+		if err != nil || backoff <= 0 {
+			return fmt.Errorf("invalid retry initial backoff: %q", raw.RetryInitialBackoff)
+		}
+		d.RetryInitialBackoff = backoff
+	}
+
+	if raw.RetryMaxBackoff != "" {
+		backoff, err := time.ParseDuration(raw.RetryMaxBackoff)
+		if err != nil || backoff <= 0 {
+			return fmt.Errorf("invalid retry max backoff: %q", raw.RetryMaxBackoff)
+		}
+		d.RetryMaxBackoff = backoff
+	}
+
+	if raw.RestoreWindow != "" {
+		window, err := time.ParseDuration(raw.RestoreWindow)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("invalid restore window: %q", raw.RestoreWindow)
+		}
+		d.RestoreWindow = window
+	}
+
+	return nil
+}
+
+// GRPCConfig содержит дополнительные настройки gRPC-сервера.
+type GRPCConfig struct {
+	Reflection          bool          `json:"reflection"`            // Регистрация сервиса рефлексии (grpcurl)
+	HealthCheckInterval time.Duration `json:"health_check_interval"` // Период проверки зависимостей для grpc.health.v1
+}
+
+// UnmarshalJSON разбирает настройки gRPC-сервера, принимая длительности в виде строк ("10s").
+func (g *GRPCConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Reflection          bool   `json:"reflection"`
+		HealthCheckInterval string `json:"health_check_interval"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	g.Reflection = raw.Reflection
+	if raw.HealthCheckInterval != "" {
+		interval, err := time.ParseDuration(raw.HealthCheckInterval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid health check interval: %q", raw.HealthCheckInterval)
+		}
+		g.HealthCheckInterval = interval
+	}
+
+	return nil
+}
+
+// UnmarshalJSON разбирает настройки кэша, принимая время жизни в виде строки ("1m").
+func (c *CacheConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Enabled bool   `json:"enabled"`
+		Size    int    `json:"size"`
+		TTL     string `json:"ttl"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	c.Enabled = raw.Enabled
+	if raw.Size < 0 {
+		return fmt.Errorf("invalid cache size: %d", raw.Size)
+	}
+	c.Size = raw.Size
+
+	if raw.TTL != "" {
+		ttl, err := time.ParseDuration(raw.TTL)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid cache ttl: %q", raw.TTL)
+		}
+		c.TTL = ttl
+	}
+
+	return nil
+}
+
+// Стратегии генерации коротких ключей.
+const (
+	KeyStrategyRandom   = "random"   // Случайные ключи
+	KeyStrategyCounter  = "counter"  // Счётчик в памяти процесса (только с файловым хранилищем)
+	KeyStrategySequence = "sequence" // Последовательность PostgreSQL или счётчик Redis и встроенного хранилища
+)
+
+// Кодирование идентификатора в короткий ключ.
+const (
+	KeyEncodingBase62  = "base62"  // Base62 с дополнением до минимальной длины
+	KeyEncodingHashids = "hashids" // Hashids с солью
+)
+
+// Допустимая длина короткого ключа (ограничена размером столбца short_code).
+const (
+	minKeyLength = 4
+	maxKeyLength = 20
+)
+
+// KeyGenConfig содержит настройки генерации коротких ключей.
+type KeyGenConfig struct {
+	Strategy    string `json:"strategy"`     // Стратегия: random, counter, sequence
+	Encoding    string `json:"encoding"`     // Кодирование для counter и sequence: base62, hashids
+	Length      int    `json:"length"`       // Длина (минимальная длина) ключа
+	Salt        string `json:"salt"`         // Соль для hashids
+	MaxAttempts int    `json:"max_attempts"` // Количество попыток при коллизии ключа
+}
+
+// validateKeyGen проверяет настройки генерации коротких ключей.
+func validateKeyGen(kg KeyGenConfig) error {
+	switch kg.Strategy {
+	case KeyStrategyRandom, KeyStrategyCounter, KeyStrategySequence:
+	default:
+		return fmt.Errorf("unknown strategy %q", kg.Strategy)
+	}
+
+	switch kg.Encoding {
+	case KeyEncodingBase62, KeyEncodingHashids:
+	default:
+		return fmt.Errorf("unknown encoding %q", kg.Encoding)
+	}
+
+	if kg.Length < minKeyLength || kg.Length > maxKeyLength {
+		return fmt.Errorf("length must be between %d and %d", minKeyLength, maxKeyLength)
+	}
+
+	if kg.MaxAttempts < 1 {
+		return errors.New("max attempts must be positive")
+	}
+
+	return nil
+}
+
 const (
 	minDynamicPort = 49152 // Начало диапазона динамических/частных портов (IANA)
 	maxPort        = 65535 // Максимальный допустимый номер порта
@@ -185,6 +615,45 @@ func Load() (*Config, error) {
 			Endpoint: "/debug/pprof",
 			BindAddr: ":6060",
 		},
+		Purge: PurgeConfig{
+			Enabled:   true,
+			Interval:  time.Hour,
+			Retention: 7 * 24 * time.Hour,
+			BatchSize: 1000,
+		},
+		Persistence: PersistenceConfig{
+			Format:          "json",
+			CompactInterval: 10 * time.Minute,
+			Sync:            "interval",
+			SyncInterval:    time.Second,
+		},
+		KeyGen: KeyGenConfig{
+			Strategy:    KeyStrategyRandom,
+			Encoding:    KeyEncodingBase62,
+			Length:      8,
+			MaxAttempts: 5,
+		},
+		Cache: CacheConfig{
+			Size: 10000,
+			TTL:  time.Minute,
+		},
+		DeleteQueue: DeleteQueueConfig{
+			Path: "delete_queue.log",
+		},
+		DeleteWorker: DeleteWorkerConfig{
+			Workers:             1,
+			BatchSize:           100,
+			FlushInterval:       500 * time.Millisecond,
+			QueueSize:           10000,
+			RetryAttempts:       3,
+			RetryInitialBackoff: 100 * time.Millisecond,
+			RetryMaxBackoff:     5 * time.Second,
+			DeadLetterLimit:     1000,
+			RestoreWindow:       24 * time.Hour,
+		},
+		GRPC: GRPCConfig{
+			HealthCheckInterval: 10 * time.Second,
+		},
 	}
 
 	// Загрузка из JSON-файла если указан
@@ -218,6 +687,14 @@ func Load() (*Config, error) {
 		}
 	}
 
+	if err := validatePersistence(cfg.Persistence); err != nil {
+		return nil, fmt.Errorf("persistence configuration invalid: %w", err)
+	}
+
+	if err := validateKeyGen(cfg.KeyGen); err != nil {
+		return nil, fmt.Errorf("key generator configuration invalid: %w", err)
+	}
+
 	return cfg, nil
 }
 
@@ -276,6 +753,12 @@ func mergeConfigs(original, new *Config) {
 	if new.DBConnect != "" {
 		original.DBConnect = new.DBConnect
 	}
+	if new.RedisURL != "" {
+		original.RedisURL = new.RedisURL
+	}
+	if new.KVStorage != "" {
+		original.KVStorage = new.KVStorage
+	}
 	if new.JwtKey != "" {
 		original.JwtKey = new.JwtKey
 	}
@@ -311,6 +794,107 @@ func mergeConfigs(original, new *Config) {
 	if new.ConfigPProf.Enabled {
 		original.ConfigPProf.Enabled = new.ConfigPProf.Enabled
 	}
+
+	// Объединение PurgeConfig
+	if new.Purge.Interval > 0 {
+		original.Purge.Interval = new.Purge.Interval
+	}
+	if new.Purge.Retention > 0 {
+		original.Purge.Retention = new.Purge.Retention
+	}
+	if new.Purge.BatchSize > 0 {
+		original.Purge.BatchSize = new.Purge.BatchSize
+	}
+	if new.Purge.Enabled {
+		original.Purge.Enabled = new.Purge.Enabled
+	}
+
+	// Объединение PersistenceConfig
+	if new.Persistence.Format != "" {
+		original.Persistence.Format = new.Persistence.Format
+	}
+	if new.Persistence.CompactInterval > 0 {
+		original.Persistence.CompactInterval = new.Persistence.CompactInterval
+	}
+	if new.Persistence.Sync != "" {
+		original.Persistence.Sync = new.Persistence.Sync
+	}
+	if new.Persistence.SyncInterval > 0 {
+		original.Persistence.SyncInterval = new.Persistence.SyncInterval
+	}
+
+	// Объединение KeyGenConfig
+	if new.KeyGen.Strategy != "" {
+		original.KeyGen.Strategy = new.KeyGen.Strategy
+	}
+	if new.KeyGen.Encoding != "" {
+		original.KeyGen.Encoding = new.KeyGen.Encoding
+	}
+	if new.KeyGen.Length > 0 {
+		original.KeyGen.Length = new.KeyGen.Length
+	}
+	if new.KeyGen.Salt != "" {
+		original.KeyGen.Salt = new.KeyGen.Salt
+	}
+	if new.KeyGen.MaxAttempts > 0 {
+		original.KeyGen.MaxAttempts = new.KeyGen.MaxAttempts
+	}
+
+	// Объединение CacheConfig
+	if new.Cache.Enabled {
+		original.Cache.Enabled = new.Cache.Enabled
+	}
+	if new.Cache.Size > 0 {
+		original.Cache.Size = new.Cache.Size
+	}
+	if new.Cache.TTL > 0 {
+		original.Cache.TTL = new.Cache.TTL
+	}
+
+	// Объединение DeleteQueueConfig
+	if new.DeleteQueue.Enabled {
+		original.DeleteQueue.Enabled = new.DeleteQueue.Enabled
+	}
+	if new.DeleteQueue.Path != "" {
+		original.DeleteQueue.Path = new.DeleteQueue.Path
+	}
+
+	// Объединение DeleteWorkerConfig
+	if new.DeleteWorker.Workers > 0 {
+		original.DeleteWorker.Workers = new.DeleteWorker.Workers
+	}
+	if new.DeleteWorker.BatchSize > 0 {
+		original.DeleteWorker.BatchSize = new.DeleteWorker.BatchSize
+	}
+	if new.DeleteWorker.FlushInterval > 0 {
+		original.DeleteWorker.FlushInterval = new.DeleteWorker.FlushInterval
+	}
+	if new.DeleteWorker.QueueSize > 0 {
+		original.DeleteWorker.QueueSize = new.DeleteWorker.QueueSize
+	}
+	if new.DeleteWorker.RetryAttempts > 0 {
+		original.DeleteWorker.RetryAttempts = new.DeleteWorker.RetryAttempts
+	}
+	if new.DeleteWorker.RetryInitialBackoff > 0 {
+		original.DeleteWorker.RetryInitialBackoff = new.DeleteWorker.RetryInitialBackoff
+	}
+	if new.DeleteWorker.RetryMaxBackoff > 0 {
+		original.DeleteWorker.RetryMaxBackoff = new.DeleteWorker.RetryMaxBackoff
+	}
+	if new.DeleteWorker.DeadLetterLimit > 0 {
+		original.DeleteWorker.DeadLetterLimit = new.DeleteWorker.DeadLetterLimit
+	}
+	if new.DeleteWorker.RestoreWindow > 0 {
+		original.DeleteWorker.RestoreWindow = new.DeleteWorker.RestoreWindow
+	}
+
+	// Объединение GRPCConfig
+	if new.GRPC.Reflection {
+		original.GRPC.Reflection = new.GRPC.Reflection
+	}
+	if new.GRPC.HealthCheckInterval > 0 {
+		original.GRPC.HealthCheckInterval = new.GRPC.HealthCheckInterval
+	}
 }
 
 // loadFromFlags загружает значения из флагов командной строки
@@ -338,6 +922,8 @@ func loadFromFlags(cfg *Config) error {
 	flag.StringVar(&cfg.LogLevel, "l", cfg.LogLevel, "Log level (debug, info, warn, error)")
 	flag.StringVar(&cfg.FileStorage, "f", cfg.FileStorage, "Path to file storage")
 	flag.StringVar(&cfg.DBConnect, "d", cfg.DBConnect, "Database connection string")
+	flag.StringVar(&cfg.RedisURL, "r", cfg.RedisURL, "Redis URL (redis://host:port/db)")
+	flag.StringVar(&cfg.KVStorage, "k", cfg.KVStorage, "Path to embedded key-value storage directory")
 	flag.BoolVar(&cfg.EnableHTTPS, "s", cfg.EnableHTTPS, "Enable HTTPS server")
 	flag.StringVar(&cfg.TrustedSubnet, "t", "", "trusted subnet in CIDR notation")
 
@@ -381,6 +967,14 @@ func loadFromEnv(cfg *Config) error {
 		cfg.DBConnect = envDB
 	}
 
+	if envRedis := os.Getenv("REDIS_URL"); envRedis != "" {
+		cfg.RedisURL = envRedis
+	}
+
+	if envKV := os.Getenv("KV_STORAGE_PATH"); envKV != "" {
+		cfg.KVStorage = envKV
+	}
+
 	if envJWT := os.Getenv("JWT_SECRET"); envJWT != "" {
 		if len(envJWT) < 32 {
 			return fmt.Errorf("JWT_SECRET must be at least 32 characters long")
@@ -435,5 +1029,200 @@ func loadFromEnv(cfg *Config) error {
 		}
 	}
 
+	// Обработка настроек очистки
+	if enabled := os.Getenv("PURGE_ENABLED"); enabled != "" {
+		if v, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Purge.Enabled = v
+		} else {
+			return fmt.Errorf("invalid PURGE_ENABLED value: %w", err)
+		}
+	}
+	if interval := os.Getenv("PURGE_INTERVAL"); interval != "" {
+		if v, err := time.ParseDuration(interval); err == nil && v > 0 {
+			cfg.Purge.Interval = v
+		} else {
+			return fmt.Errorf("invalid PURGE_INTERVAL value: %q", interval)
+		}
+	}
+	if retention := os.Getenv("PURGE_RETENTION"); retention != "" {
+		if v, err := time.ParseDuration(retention); err == nil && v >= 0 {
+			cfg.Purge.Retention = v
+		} else {
+			return fmt.Errorf("invalid PURGE_RETENTION value: %q", retention)
+		}
+	}
+	if batchSize := os.Getenv("PURGE_BATCH_SIZE"); batchSize != "" {
+		if v, err := strconv.Atoi(batchSize); err == nil && v > 0 {
+			cfg.Purge.BatchSize = v
+		} else {
+			return fmt.Errorf("invalid PURGE_BATCH_SIZE value: %q", batchSize)
+		}
+	}
+
+	// Обработка настроек файлового хранилища
+	if format := os.Getenv("FILE_STORAGE_FORMAT"); format != "" {
+		cfg.Persistence.Format = format
+	}
+	if interval := os.Getenv("FILE_COMPACT_INTERVAL"); interval != "" {
+		if v, err := time.ParseDuration(interval); err == nil && v >= 0 {
+			cfg.Persistence.CompactInterval = v
+		} else {
+			return fmt.Errorf("invalid FILE_COMPACT_INTERVAL value: %q", interval)
+		}
+	}
+	if mode := os.Getenv("FILE_SYNC_MODE"); mode != "" {
+		cfg.Persistence.Sync = mode
+	}
+	if interval := os.Getenv("FILE_SYNC_INTERVAL"); interval != "" {
+		if v, err := time.ParseDuration(interval); err == nil && v > 0 {
+			cfg.Persistence.SyncInterval = v
+		} else {
+			return fmt.Errorf("invalid FILE_SYNC_INTERVAL value: %q", interval)
+		}
+	}
+
+	// Обработка настроек генерации коротких ключей
+	if strategy := os.Getenv("KEY_STRATEGY"); strategy != "" {
+		cfg.KeyGen.Strategy = strategy
+	}
+	if encoding := os.Getenv("KEY_ENCODING"); encoding != "" {
+		cfg.KeyGen.Encoding = encoding
+	}
+	if length := os.Getenv("KEY_LENGTH"); length != "" {
+		if v, err := strconv.Atoi(length); err == nil {
+			cfg.KeyGen.Length = v
+		} else {
+			return fmt.Errorf("invalid KEY_LENGTH value: %q", length)
+		}
+	}
+	if salt := os.Getenv("KEY_SALT"); salt != "" {
+		cfg.KeyGen.Salt = salt
+	}
+	if attempts := os.Getenv("KEY_MAX_ATTEMPTS"); attempts != "" {
+		if v, err := strconv.Atoi(attempts); err == nil && v > 0 {
+			cfg.KeyGen.MaxAttempts = v
+		} else {
+			return fmt.Errorf("invalid KEY_MAX_ATTEMPTS value: %q", attempts)
+		}
+	}
+
+	// Обработка настроек кэша редиректов
+	if enabled := os.Getenv("REDIRECT_CACHE_ENABLED"); enabled != "" {
+		if v, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Cache.Enabled = v
+		} else {
+			return fmt.Errorf("invalid REDIRECT_CACHE_ENABLED value: %w", err)
+		}
+	}
+	if size := os.Getenv("REDIRECT_CACHE_SIZE"); size != "" {
+		if v, err := strconv.Atoi(size); err == nil && v > 0 {
+			cfg.Cache.Size = v
+		} else {
+			return fmt.Errorf("invalid REDIRECT_CACHE_SIZE value: %q", size)
+		}
+	}
+	if ttl := os.Getenv("REDIRECT_CACHE_TTL"); ttl != "" {
+		if v, err := time.ParseDuration(ttl); err == nil && v > 0 {
+			cfg.Cache.TTL = v
+		} else {
+			return fmt.Errorf("invalid REDIRECT_CACHE_TTL value: %q", ttl)
+		}
+	}
+
+	// Обработка настроек очереди удаления
+	if enabled := os.Getenv("DELETE_QUEUE_ENABLED"); enabled != "" {
+		if v, err := strconv.ParseBool(enabled); err == nil {
+			cfg.DeleteQueue.Enabled = v
+		} else {
+			return fmt.Errorf("invalid DELETE_QUEUE_ENABLED value: %w", err)
+		}
+	}
+	if path := os.Getenv("DELETE_QUEUE_PATH"); path != "" {
+		cfg.DeleteQueue.Path = path
+	}
+
+	// Обработка настроек воркера удаления
+	if workers := os.Getenv("DELETE_WORKERS"); workers != "" {
+		if v, err := strconv.Atoi(workers); err == nil && v > 0 {
+			cfg.DeleteWorker.Workers = v
+		} else {
+			return fmt.Errorf("invalid DELETE_WORKERS value: %q", workers)
+		}
+	}
+	if size := os.Getenv("DELETE_BATCH_SIZE"); size != "" {
+		if v, err := strconv.Atoi(size); err == nil && v > 0 {
+			cfg.DeleteWorker.BatchSize = v
+		} else {
+			return fmt.Errorf("invalid DELETE_BATCH_SIZE value: %q", size)
+		}
+	}
+	if interval := os.Getenv("DELETE_FLUSH_INTERVAL"); interval != "" {
+		if v, err := time.ParseDuration(interval); err == nil && v > 0 {
+			cfg.DeleteWorker.FlushInterval = v
+		} else {
+			return fmt.Errorf("invalid DELETE_FLUSH_INTERVAL value: %q", interval)
+		}
+	}
+	if size := os.Getenv("DELETE_QUEUE_SIZE"); size != "" {
+		if v, err := strconv.Atoi(size); err == nil && v > 0 {
+			cfg.DeleteWorker.QueueSize = v
+		} else {
+			return fmt.Errorf("invalid DELETE_QUEUE_SIZE value: %q", size)
+		}
+	}
+
+	// Обработка настроек повторных попыток удаления
+	if attempts := os.Getenv("DELETE_RETRY_ATTEMPTS"); attempts != "" {
+		if v, err := strconv.Atoi(attempts); err == nil && v > 0 {
+			cfg.DeleteWorker.RetryAttempts = v
+		} else {
+			return fmt.Errorf("invalid DELETE_RETRY_ATTEMPTS value: %q", attempts)
+		}
+	}
+	if backoff := os.Getenv("DELETE_RETRY_INITIAL_BACKOFF"); backoff != "" {
+		if v, err := time.ParseDuration(backoff); err == nil && v > 0 {
+			cfg.DeleteWorker.RetryInitialBackoff = v
+		} else {
+			return fmt.Errorf("invalid DELETE_RETRY_INITIAL_BACKOFF value: %q", backoff)
+		}
+	}
+	if backoff := os.Getenv("DELETE_RETRY_MAX_BACKOFF"); backoff != "" {
+		if v, err := time.ParseDuration(backoff); err == nil && v > 0 {
+			cfg.DeleteWorker.RetryMaxBackoff = v
+		} else {
+			return fmt.Errorf("invalid DELETE_RETRY_MAX_BACKOFF value: %q", backoff)
+		}
+	}
+	if limit := os.Getenv("DELETE_DEAD_LETTER_LIMIT"); limit != "" {
+		if v, err := strconv.Atoi(limit); err == nil && v > 0 {
+			cfg.DeleteWorker.DeadLetterLimit = v
+		} else {
+			return fmt.Errorf("invalid DELETE_DEAD_LETTER_LIMIT value: %q", limit)
+		}
+	}
+	if window := os.Getenv("DELETE_RESTORE_WINDOW"); window != "" {
+		if v, err := time.ParseDuration(window); err == nil && v > 0 {
+			cfg.DeleteWorker.RestoreWindow = v
+		} else {
+			return fmt.Errorf("invalid DELETE_RESTORE_WINDOW value: %q", window)
+		}
+	}
+
+	// Обработка настроек gRPC-сервера
+	if enabled := os.Getenv("GRPC_REFLECTION"); enabled != "" {
+		if v, err := strconv.ParseBool(enabled); err == nil {
+			cfg.GRPC.Reflection = v
+		} else {
+			return fmt.Errorf("invalid GRPC_REFLECTION value: %w", err)
+		}
+	}
+	if interval := os.Getenv("GRPC_HEALTH_CHECK_INTERVAL"); interval != "" {
+		if v, err := time.ParseDuration(interval); err == nil && v > 0 {
+			cfg.GRPC.HealthCheckInterval = v
+		} else {
+			return fmt.Errorf("invalid GRPC_HEALTH_CHECK_INTERVAL value: %q", interval)
+		}
+	}
+
 	return nil
 }
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"encoding/json"
 	"flag"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestConfig(t *testing.T) {
@@ -268,4 +270,450 @@ func TestConfig(t *testing.T) {
 		}
 	})
 
+	// --- Тест 13: Настройки фоновой очистки ---
+	t.Run("Purge config", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test13", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+		configPath := filepath.Join("testdata", "valid_config.json")
+		t.Setenv("CONFIG", configPath) // interval = "30m", retention = "24h" в JSON
+		t.Setenv("PURGE_BATCH_SIZE", "50")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !cfg.Purge.Enabled {
+			t.Error("Purge should be enabled by default")
+		}
+		if cfg.Purge.Interval != 30*time.Minute {
+			t.Errorf("Expected JSON purge interval 30m, got %v", cfg.Purge.Interval)
+		}
+		if cfg.Purge.Retention != 24*time.Hour {
+			t.Errorf("Expected JSON purge retention 24h, got %v", cfg.Purge.Retention)
+		}
+		if cfg.Purge.BatchSize != 50 {
+			t.Errorf("Expected env purge batch size 50, got %d", cfg.Purge.BatchSize)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test13b", flag.PanicOnError)
+		t.Setenv("PURGE_INTERVAL", "soon")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for invalid PURGE_INTERVAL")
+		}
+
+		var fromJSON PurgeConfig
+		for _, data := range []string{`{"batch_size":0}`, `{"batch_size":-1}`} {
+			if err := json.Unmarshal([]byte(data), &fromJSON); err == nil {
+				t.Errorf("Expected error for %s", data)
+			}
+		}
+		if err := json.Unmarshal([]byte(`{"interval":"1h","batch_size":200}`), &fromJSON); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if fromJSON.BatchSize != 200 || fromJSON.Interval != time.Hour {
+			t.Errorf("Unexpected purge config from JSON: %+v", fromJSON)
+		}
+	})
+
+	// --- Тест 14: Настройки генерации коротких ключей ---
+	t.Run("Key generator config", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test14", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+		t.Setenv("KEY_STRATEGY", KeyStrategyCounter)
+		t.Setenv("KEY_ENCODING", KeyEncodingHashids)
+		t.Setenv("KEY_LENGTH", "10")
+		t.Setenv("KEY_SALT", "salt")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.KeyGen.Strategy != KeyStrategyCounter || cfg.KeyGen.Encoding != KeyEncodingHashids {
+			t.Errorf("Expected counter/hashids, got %s/%s", cfg.KeyGen.Strategy, cfg.KeyGen.Encoding)
+		}
+		if cfg.KeyGen.Length != 10 {
+			t.Errorf("Expected key length 10, got %d", cfg.KeyGen.Length)
+		}
+		if cfg.KeyGen.MaxAttempts != 5 {
+			t.Errorf("Expected default max attempts 5, got %d", cfg.KeyGen.MaxAttempts)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test14b", flag.PanicOnError)
+		t.Setenv("KEY_LENGTH", "64")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for KEY_LENGTH out of range")
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test14c", flag.PanicOnError)
+		t.Setenv("KEY_LENGTH", "8")
+		t.Setenv("KEY_STRATEGY", "uuid")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for unknown KEY_STRATEGY")
+		}
+	})
+
+	// --- Тест 15: Настройки файлового хранилища ---
+	t.Run("Persistence config", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test15", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+		t.Setenv("CONFIG", filepath.Join("testdata", "valid_config.json")) // compact_interval = "5m" в JSON
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Persistence.CompactInterval != 5*time.Minute {
+			t.Errorf("Expected JSON compact interval 5m, got %v", cfg.Persistence.CompactInterval)
+		}
+		if cfg.Persistence.Format != "json" {
+			t.Errorf("Expected default storage format json, got %s", cfg.Persistence.Format)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test15b", flag.PanicOnError)
+		t.Setenv("FILE_COMPACT_INTERVAL", "0s")
+		cfg, err = Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Persistence.CompactInterval != 0 {
+			t.Errorf("Expected env to disable periodic compaction, got %v", cfg.Persistence.CompactInterval)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test15c", flag.PanicOnError)
+		t.Setenv("FILE_STORAGE_FORMAT", "protobuf+zstd")
+		if cfg, err = Load(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Persistence.Format != "protobuf+zstd" {
+			t.Errorf("Expected env storage format protobuf+zstd, got %s", cfg.Persistence.Format)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test15d", flag.PanicOnError)
+		t.Setenv("FILE_STORAGE_FORMAT", "xml")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for unknown FILE_STORAGE_FORMAT")
+		}
+	})
+
+	// --- Тест 16: Режим сброса файла хранилища на диск ---
+	t.Run("Persistence sync mode", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test16", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+		t.Setenv("CONFIG", filepath.Join("testdata", "valid_config.json")) // sync = "always" в JSON
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Persistence.Sync != "always" {
+			t.Errorf("Expected JSON sync mode always, got %s", cfg.Persistence.Sync)
+		}
+		if cfg.Persistence.SyncInterval != time.Second {
+			t.Errorf("Expected default sync interval 1s, got %v", cfg.Persistence.SyncInterval)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test16b", flag.PanicOnError)
+		t.Setenv("FILE_SYNC_MODE", "interval")
+		t.Setenv("FILE_SYNC_INTERVAL", "250ms")
+		if cfg, err = Load(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Persistence.Sync != "interval" || cfg.Persistence.SyncInterval != 250*time.Millisecond {
+			t.Errorf("Expected env sync interval 250ms, got %s %v", cfg.Persistence.Sync, cfg.Persistence.SyncInterval)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test16c", flag.PanicOnError)
+		t.Setenv("FILE_SYNC_MODE", "sometimes")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for unknown FILE_SYNC_MODE")
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test16d", flag.PanicOnError)
+		t.Setenv("FILE_SYNC_MODE", "interval")
+		t.Setenv("FILE_SYNC_INTERVAL", "0s")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for zero FILE_SYNC_INTERVAL")
+		}
+	})
+
+	// --- Тест 17: Адрес сервера Redis ---
+	t.Run("Redis URL", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test17", flag.PanicOnError)
+		os.Args = []string{"cmd", "-r", "redis://flaghost:6379/1"}
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.RedisURL != "redis://flaghost:6379/1" {
+			t.Errorf("Expected redis URL from flag, got %q", cfg.RedisURL)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test17b", flag.PanicOnError)
+		t.Setenv("REDIS_URL", "redis://envhost:6379")
+		if cfg, err = Load(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.RedisURL != "redis://envhost:6379" {
+			t.Errorf("Expected redis URL from env, got %q", cfg.RedisURL)
+		}
+	})
+
+	// --- Тест 18: Каталог встроенного хранилища ключ-значение ---
+	t.Run("KV storage path", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test18", flag.PanicOnError)
+		os.Args = []string{"cmd", "-k", "/var/lib/shortener/kv"}
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.KVStorage != "/var/lib/shortener/kv" {
+			t.Errorf("Expected KV storage path from flag, got %q", cfg.KVStorage)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test18b", flag.PanicOnError)
+		t.Setenv("KV_STORAGE_PATH", "/tmp/kv")
+		if cfg, err = Load(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.KVStorage != "/tmp/kv" {
+			t.Errorf("Expected KV storage path from env, got %q", cfg.KVStorage)
+		}
+	})
+
+	// --- Тест 19: Кэш редиректов ---
+	t.Run("Redirect cache", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test19", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Cache.Enabled || cfg.Cache.Size != 10000 || cfg.Cache.TTL != time.Minute {
+			t.Errorf("Unexpected default cache config: %+v", cfg.Cache)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test19b", flag.PanicOnError)
+		t.Setenv("REDIRECT_CACHE_ENABLED", "true")
+		t.Setenv("REDIRECT_CACHE_SIZE", "500")
+		t.Setenv("REDIRECT_CACHE_TTL", "30s")
+		if cfg, err = Load(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !cfg.Cache.Enabled || cfg.Cache.Size != 500 || cfg.Cache.TTL != 30*time.Second {
+			t.Errorf("Expected cache config from env, got %+v", cfg.Cache)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test19c", flag.PanicOnError)
+		t.Setenv("REDIRECT_CACHE_TTL", "0s")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for zero REDIRECT_CACHE_TTL")
+		}
+	})
+
+	// --- Тест 20: Очередь удаления ---
+	t.Run("Delete queue", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test20", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.DeleteQueue.Enabled || cfg.DeleteQueue.Path != "delete_queue.log" {
+			t.Errorf("Unexpected default delete queue config: %+v", cfg.DeleteQueue)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test20b", flag.PanicOnError)
+		t.Setenv("DELETE_QUEUE_ENABLED", "true")
+		t.Setenv("DELETE_QUEUE_PATH", "/tmp/deletes.log")
+		if cfg, err = Load(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !cfg.DeleteQueue.Enabled || cfg.DeleteQueue.Path != "/tmp/deletes.log" {
+			t.Errorf("Expected delete queue config from env, got %+v", cfg.DeleteQueue)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test20c", flag.PanicOnError)
+		t.Setenv("DELETE_QUEUE_ENABLED", "maybe")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for invalid DELETE_QUEUE_ENABLED")
+		}
+	})
+
+	// --- Тест 21: Повторные попытки удаления ---
+	t.Run("Delete worker retries", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test21", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := DeleteWorkerConfig{
+			Workers:             1,
+			BatchSize:           100,
+			FlushInterval:       500 * time.Millisecond,
+			QueueSize:           10000,
+			RetryAttempts:       3,
+			RetryInitialBackoff: 100 * time.Millisecond,
+			RetryMaxBackoff:     5 * time.Second,
+			DeadLetterLimit:     1000,
+			RestoreWindow:       24 * time.Hour,
+		}
+		if cfg.DeleteWorker != expected {
+			t.Errorf("Unexpected default delete worker config: %+v", cfg.DeleteWorker)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test21b", flag.PanicOnError)
+		t.Setenv("DELETE_RETRY_ATTEMPTS", "5")
+		t.Setenv("DELETE_RETRY_INITIAL_BACKOFF", "50ms")
+		t.Setenv("DELETE_RETRY_MAX_BACKOFF", "1s")
+		t.Setenv("DELETE_DEAD_LETTER_LIMIT", "10")
+		if cfg, err = Load(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected = DeleteWorkerConfig{
+			Workers:             1,
+			BatchSize:           100,
+			FlushInterval:       500 * time.Millisecond,
+			QueueSize:           10000,
+			RetryAttempts:       5,
+			RetryInitialBackoff: 50 * time.Millisecond,
+			RetryMaxBackoff:     time.Second,
+			DeadLetterLimit:     10,
+			RestoreWindow:       24 * time.Hour,
+		}
+		if cfg.DeleteWorker != expected {
+			t.Errorf("Expected delete worker config from env, got %+v", cfg.DeleteWorker)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test21c", flag.PanicOnError)
+		t.Setenv("DELETE_RETRY_MAX_BACKOFF", "soon")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for invalid DELETE_RETRY_MAX_BACKOFF")
+		}
+
+		var fromJSON DeleteWorkerConfig
+		if err := json.Unmarshal([]byte(`{"retry_initial_backoff":"250ms","dead_letter_limit":-1}`), &fromJSON); err == nil {
+			t.Error("Expected error for negative dead_letter_limit")
+		}
+		if err := json.Unmarshal([]byte(`{"retry_attempts":2,"retry_initial_backoff":"250ms"}`), &fromJSON); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if fromJSON.RetryAttempts != 2 || fromJSON.RetryInitialBackoff != 250*time.Millisecond {
+			t.Errorf("Unexpected delete worker config from JSON: %+v", fromJSON)
+		}
+	})
+
+	// --- Тест 22: Параметры воркера удаления ---
+	t.Run("Delete worker tuning", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test22", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+		t.Setenv("DELETE_WORKERS", "4")
+		t.Setenv("DELETE_BATCH_SIZE", "500")
+		t.Setenv("DELETE_FLUSH_INTERVAL", "2s")
+		t.Setenv("DELETE_QUEUE_SIZE", "100")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		dw := cfg.DeleteWorker
+		if dw.Workers != 4 || dw.BatchSize != 500 || dw.FlushInterval != 2*time.Second || dw.QueueSize != 100 {
+			t.Errorf("Expected delete worker tuning from env, got %+v", dw)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test22b", flag.PanicOnError)
+		t.Setenv("DELETE_QUEUE_SIZE", "0")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for zero DELETE_QUEUE_SIZE")
+		}
+
+		var fromJSON DeleteWorkerConfig
+		if err := json.Unmarshal([]byte(`{"workers":2,"batch_size":50,"flush_interval":"1s","queue_size":20}`), &fromJSON); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if fromJSON.Workers != 2 || fromJSON.BatchSize != 50 || fromJSON.FlushInterval != time.Second || fromJSON.QueueSize != 20 {
+			t.Errorf("Unexpected delete worker tuning from JSON: %+v", fromJSON)
+		}
+		if err := json.Unmarshal([]byte(`{"flush_interval":"0s"}`), &fromJSON); err == nil {
+			t.Error("Expected error for zero flush_interval")
+		}
+	})
+
+	// --- Тест 23: Окно восстановления удалённых ссылок ---
+	t.Run("Delete worker restore window", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test23", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+		t.Setenv("DELETE_RESTORE_WINDOW", "2h")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.DeleteWorker.RestoreWindow != 2*time.Hour {
+			t.Errorf("Expected restore window 2h, got %v", cfg.DeleteWorker.RestoreWindow)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test23b", flag.PanicOnError)
+		t.Setenv("DELETE_RESTORE_WINDOW", "-1h")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for negative DELETE_RESTORE_WINDOW")
+		}
+
+		var fromJSON DeleteWorkerConfig
+		if err := json.Unmarshal([]byte(`{"restore_window":"30m"}`), &fromJSON); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if fromJSON.RestoreWindow != 30*time.Minute {
+			t.Errorf("Unexpected restore window from JSON: %v", fromJSON.RestoreWindow)
+		}
+		if err := json.Unmarshal([]byte(`{"restore_window":"later"}`), &fromJSON); err == nil {
+			t.Error("Expected error for invalid restore_window")
+		}
+	})
+
+	// --- Тест 24: Настройки gRPC-сервера ---
+	t.Run("GRPC health and reflection", func(t *testing.T) {
+		flag.CommandLine = flag.NewFlagSet("test24", flag.PanicOnError)
+		os.Args = []string{"cmd"}
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.GRPC.Reflection || cfg.GRPC.HealthCheckInterval != 10*time.Second {
+			t.Errorf("Unexpected gRPC defaults: %+v", cfg.GRPC)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test24b", flag.PanicOnError)
+		t.Setenv("GRPC_REFLECTION", "true")
+		t.Setenv("GRPC_HEALTH_CHECK_INTERVAL", "3s")
+		if cfg, err = Load(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !cfg.GRPC.Reflection || cfg.GRPC.HealthCheckInterval != 3*time.Second {
+			t.Errorf("Expected gRPC settings from env, got %+v", cfg.GRPC)
+		}
+
+		flag.CommandLine = flag.NewFlagSet("test24c", flag.PanicOnError)
+		t.Setenv("GRPC_HEALTH_CHECK_INTERVAL", "0s")
+		if _, err := Load(); err == nil {
+			t.Error("Expected error for zero GRPC_HEALTH_CHECK_INTERVAL")
+		}
+
+		var fromJSON GRPCConfig
+		if err := json.Unmarshal([]byte(`{"reflection":true,"health_check_interval":"30s"}`), &fromJSON); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !fromJSON.Reflection || fromJSON.HealthCheckInterval != 30*time.Second {
+			t.Errorf("Unexpected gRPC settings from JSON: %+v", fromJSON)
+		}
+		if err := json.Unmarshal([]byte(`{"health_check_interval":"often"}`), &fromJSON); err == nil {
+			t.Error("Expected error for invalid health_check_interval")
+		}
+	})
 }
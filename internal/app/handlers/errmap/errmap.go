@@ -0,0 +1,149 @@
+// Package errmap содержит общую таблицу соответствия доменных ошибок
+// кодам gRPC и статусам HTTP.
+//
+// Таблицей пользуются HTTP- и gRPC-обработчики, а также REST-шлюз,
+// поэтому одна и та же ошибка сервиса или хранилища приводит к одинаковому
+// ответу во всех транспортах. Статус HTTP выводится из кода gRPC
+// функцией HTTPStatus.
+//
+// Ошибки с одинаковым кодом gRPC, которые клиенту нужно различать
+// (удалённая и просроченная ссылки), дополняются подробностью
+// errdetails.ErrorInfo с причиной из констант Reason*; причину из ответа
+// возвращает функция Reason.
+package errmap
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAfterSeconds - значение заголовка Retry-After для ответов 503.
+const RetryAfterSeconds = "1"
+
+// ErrorDomain - домен причин в errdetails.ErrorInfo.
+const ErrorDomain = "shortener"
+
+// Причины ошибок, передаваемые в errdetails.ErrorInfo.
+const (
+	ReasonURLDeleted = "URL_DELETED" // Ссылка удалена владельцем
+	ReasonURLExpired = "URL_EXPIRED" // Срок действия ссылки истёк
+)
+
+// Rule описывает ответ на доменную ошибку.
+type Rule struct {
+	Err     error      // Доменная ошибка (сравнивается через errors.Is)
+	Code    codes.Code // Код gRPC
+	Message string     // Текст ответа; пустой - передаётся текст ошибки
+	Reason  string     // Причина для errdetails.ErrorInfo; пустая - без подробностей
+}
+
+// rules - таблица соответствия. Правила проверяются по порядку.
+var rules = []Rule{
+	{Err: storage.ErrURLNotFound, Code: codes.NotFound, Message: "Shortened key not found"},
+	{Err: storage.ErrURLDeleted, Code: codes.FailedPrecondition, Message: "URL has been deleted", Reason: ReasonURLDeleted},
+	{Err: storage.ErrURLExpired, Code: codes.FailedPrecondition, Message: "URL has expired", Reason: ReasonURLExpired},
+	{Err: storage.ErrShortURLExists, Code: codes.AlreadyExists, Message: "Custom alias already in use"},
+	{Err: storage.ErrURLExists, Code: codes.AlreadyExists, Message: "URL already exists"},
+	{Err: service.ErrAliasMismatch, Code: codes.AlreadyExists, Message: "URL already shortened with another key"},
+	{Err: service.ErrInvalidAlias, Code: codes.InvalidArgument, Message: "Invalid custom alias"},
+	{Err: service.ErrInvalidExpiry, Code: codes.InvalidArgument, Message: "Invalid expiration"},
+	{Err: service.ErrInvalidUserURLsQuery, Code: codes.InvalidArgument},
+	{Err: service.ErrDeleteJobNotFound, Code: codes.NotFound, Message: "Delete job not found"},
+	{Err: service.ErrDeleteQueueFull, Code: codes.ResourceExhausted, Message: "Delete queue is full, retry later"},
+	{Err: service.ErrDeleteWorkerStopped, Code: codes.Unavailable, Message: "Service is shutting down, retry later"},
+}
+
+// Lookup возвращает правило для ошибки err.
+// Второе значение равно false, если ошибка не относится к доменным
+// и должна обрабатываться как внутренняя.
+func Lookup(err error) (Rule, bool) {
+	for _, rule := range rules {
+		if errors.Is(err, rule.Err) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Text возвращает текст ответа на ошибку err.
+func (r Rule) Text(err error) string {
+	if r.Message != "" {
+		return r.Message
+	}
+	return err.Error()
+}
+
+// GRPCError возвращает ошибку gRPC для ошибки err.
+// Если у правила задана причина, она передаётся в errdetails.ErrorInfo.
+func (r Rule) GRPCError(err error) error {
+	st := status.New(r.Code, r.Text(err))
+	if r.Reason != "" {
+		if detailed, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason: r.Reason,
+			Domain: ErrorDomain,
+		}); detailsErr == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+// Reason возвращает причину из errdetails.ErrorInfo ошибки gRPC err
+// или пустую строку, если причина не передана.
+func Reason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+// WriteHTTPError отправляет текстовый ответ на ошибку err со статусом,
+// соответствующим коду правила. Для переполненной очереди добавляется
+// заголовок Retry-After.
+func (r Rule) WriteHTTPError(res http.ResponseWriter, err error) {
+	if r.Code == codes.ResourceExhausted {
+		res.Header().Set("Retry-After", RetryAfterSeconds)
+	}
+	http.Error(res, r.Text(err), HTTPStatus(r.Code))
+}
+
+// HTTPStatus возвращает статус HTTP для кода gRPC.
+//
+// FailedPrecondition соответствует 410 Gone: в сервисе этим кодом
+// обозначаются только удалённые и просроченные ссылки (их различает Reason).
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusGone
+	case codes.ResourceExhausted, codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Canceled:
+		return 499 // Клиент закрыл соединение
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,73 @@
+package errmap_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantStatus  int
+		wantMessage string
+		wantReason  string
+	}{
+		{"not found", storage.ErrURLNotFound, codes.NotFound, http.StatusNotFound, "Shortened key not found", ""},
+		{"deleted", storage.ErrURLDeleted, codes.FailedPrecondition, http.StatusGone, "URL has been deleted", errmap.ReasonURLDeleted},
+		{"expired", fmt.Errorf("lookup: %w", storage.ErrURLExpired), codes.FailedPrecondition, http.StatusGone, "URL has expired", errmap.ReasonURLExpired},
+		{"alias in use", storage.ErrShortURLExists, codes.AlreadyExists, http.StatusConflict, "Custom alias already in use", ""},
+		{"alias mismatch", service.ErrAliasMismatch, codes.AlreadyExists, http.StatusConflict, "URL already shortened with another key", ""},
+		{"invalid query", fmt.Errorf("%w: unknown order", service.ErrInvalidUserURLsQuery), codes.InvalidArgument, http.StatusBadRequest, "invalid user URLs query: unknown order", ""},
+		{"queue full", service.ErrDeleteQueueFull, codes.ResourceExhausted, http.StatusServiceUnavailable, "Delete queue is full, retry later", ""},
+		{"shutting down", service.ErrDeleteWorkerStopped, codes.Unavailable, http.StatusServiceUnavailable, "Service is shutting down, retry later", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := errmap.Lookup(tt.err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.wantCode, rule.Code)
+			assert.Equal(t, tt.wantStatus, errmap.HTTPStatus(rule.Code))
+
+			st := status.Convert(rule.GRPCError(tt.err))
+			assert.Equal(t, tt.wantCode, st.Code())
+			assert.Equal(t, tt.wantMessage, st.Message())
+			// Ошибки с одинаковым кодом различаются причиной
+			assert.Equal(t, tt.wantReason, errmap.Reason(st.Err()))
+		})
+	}
+
+	t.Run("reason of plain error", func(t *testing.T) {
+		assert.Empty(t, errmap.Reason(status.Error(codes.FailedPrecondition, "URL has expired")))
+		assert.Empty(t, errmap.Reason(errors.New("connection refused")))
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		_, ok := errmap.Lookup(errors.New("connection refused"))
+		assert.False(t, ok)
+	})
+}
+
+func TestRule_WriteHTTPError(t *testing.T) {
+	rule, _ := errmap.Lookup(service.ErrDeleteQueueFull)
+
+	rec := httptest.NewRecorder()
+	rule.WriteHTTPError(rec, service.ErrDeleteQueueFull)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, errmap.RetryAfterSeconds, rec.Header().Get("Retry-After"))
+	assert.Equal(t, "Delete queue is full, retry later\n", rec.Body.String())
+}
@@ -0,0 +1,327 @@
+// Package gateway предоставляет REST-шлюз к gRPC-сервису Shortener.
+//
+// Маршруты (routes_gen.go) генерируются cmd/genserver из опций (http)
+// в api/shortener.proto, поэтому REST и gRPC используют одни и те же
+// обработчики и не могут разойтись. Шлюз вызывает обработчики напрямую,
+// без сетевого соединения:
+//   - поля из шаблона пути берутся из параметров маршрута chi
+//   - для маршрутов с body: "*" остальные поля передаются в теле JSON (protojson),
+//     для остальных - в параметрах строки запроса
+//   - заголовки User-Agent, Referer, X-Real-IP и X-Forwarded-For передаются
+//     как входящие метаданные gRPC, адрес клиента - как peer
+//   - ответ кодируется protojson с именами полей из proto
+//   - ошибки gRPC преобразуются в статус HTTP через errmap.HTTPStatus
+//     и тело {"code": ..., "message": ..., "reason": ...}; причина из errmap.Reason
+//     передаётся, если она есть (например, удалённая или просроченная ссылка)
+//
+// Аутентификация, логирование и сжатие выполняются middleware HTTP-сервера.
+// Методы, требующие доверенной подсети, защищаются опцией WithProtection.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+)
+
+// Route - маршрут REST-шлюза, привязанный к методу gRPC.
+type Route struct {
+	Method     string   // Метод HTTP
+	Pattern    string   // Шаблон пути в синтаксисе chi
+	FullMethod string   // Полное имя метода gRPC
+	PathParams []string // Поля запроса, заданные в пути
+	Body       bool     // Поля запроса передаются в теле JSON
+
+	// NewRequest создаёт пустое сообщение запроса.
+	NewRequest func() proto.Message
+	// Handle вызывает обработчик метода gRPC.
+	Handle func(ctx context.Context, req proto.Message) (proto.Message, error)
+}
+
+// forwardedHeaders - заголовки, передаваемые обработчикам как метаданные gRPC.
+var forwardedHeaders = []string{"User-Agent", "Referer", "X-Real-IP", "X-Forwarded-For"}
+
+var (
+	unmarshalOptions = protojson.UnmarshalOptions{}
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true}
+)
+
+// errorResponse - тело ответа с ошибкой.
+type errorResponse struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+	Reason  string     `json:"reason,omitempty"`
+}
+
+type options struct {
+	protected map[string]bool
+	protect   func(http.Handler) http.Handler
+}
+
+// Option настраивает регистрацию маршрутов.
+type Option func(*options)
+
+// WithProtection оборачивает маршруты методов из methods (полные имена gRPC)
+// в middleware mw, например проверку доверенной подсети.
+func WithProtection(methods map[string]bool, mw func(http.Handler) http.Handler) Option {
+	return func(o *options) {
+		o.protected = methods
+		o.protect = mw
+	}
+}
+
+// Register регистрирует маршруты шлюза в router.
+//
+// Паникует, если параметр пути не соответствует скалярному полю запроса:
+// такая ошибка разметки proto должна обнаруживаться при запуске.
+func Register(router chi.Router, routes []Route, log *zap.Logger, opts ...Option) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for _, route := range routes {
+		fields := route.NewRequest().ProtoReflect().Descriptor().Fields()
+		for _, param := range route.PathParams {
+			fd := fields.ByName(protoreflect.Name(param))
+			if fd == nil || fd.IsList() || fd.IsMap() || fd.Message() != nil {
+				panic(fmt.Sprintf("gateway: %s: path parameter %q is not a scalar request field", route.FullMethod, param))
+			}
+		}
+
+		var h http.Handler = handler(route, log)
+		if o.protect != nil && o.protected[route.FullMethod] {
+			h = o.protect(h)
+		}
+		router.Method(route.Method, route.Pattern, h)
+	}
+}
+
+// handler создаёт HTTP-обработчик маршрута.
+func handler(route Route, log *zap.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		msg := route.NewRequest()
+		if err := decodeRequest(req, route, msg); err != nil {
+			log.Info("Invalid gateway request",
+				zap.Error(err),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+			writeError(res, status.Error(codes.InvalidArgument, err.Error()), log)
+			return
+		}
+
+		resp, err := route.Handle(incomingContext(req), msg)
+		if err != nil {
+			// Обработчик может вернуть ответ вместе с ошибкой
+			// (например, существующую ссылку при AlreadyExists)
+			if resp != nil && resp.ProtoReflect().IsValid() {
+				writeMessage(res, errmap.HTTPStatus(status.Code(err)), resp, log)
+				return
+			}
+			writeError(res, err, log)
+			return
+		}
+
+		writeMessage(res, http.StatusOK, resp, log)
+	}
+}
+
+// decodeRequest заполняет сообщение запроса из тела, строки запроса и пути.
+func decodeRequest(req *http.Request, route Route, msg proto.Message) error {
+	if route.Body {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		// Пустое тело соответствует запросу со значениями по умолчанию
+		if len(body) > 0 {
+			if err := unmarshalOptions.Unmarshal(body, msg); err != nil {
+				return fmt.Errorf("invalid request body: %w", err)
+			}
+		}
+	} else {
+		for key, values := range req.URL.Query() {
+			if err := setField(msg.ProtoReflect(), key, values); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, param := range route.PathParams {
+		if err := setField(msg.ProtoReflect(), param, []string{chi.URLParam(req, param)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField присваивает полю name (имя из proto или JSON) значения из строк.
+func setField(msg protoreflect.Message, name string, values []string) error {
+	fields := msg.Descriptor().Fields()
+	fd := fields.ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = fields.ByJSONName(name)
+	}
+	if fd == nil || fd.IsMap() {
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+
+	if fd.IsList() {
+		list := msg.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseValue(fd, s)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+
+	if len(values) != 1 {
+		return fmt.Errorf("parameter %q must have a single value", name)
+	}
+	v, err := parseValue(fd, values[0])
+	if err != nil {
+		return err
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+// parseValue разбирает значение скалярного поля или google.protobuf.Timestamp (RFC 3339).
+func parseValue(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	invalid := func(err error) (protoreflect.Value, error) {
+		return protoreflect.Value{}, fmt.Errorf("invalid parameter %q: %w", fd.Name(), err)
+	}
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfBool(b), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfInt32(int32(n)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfInt64(n), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfUint32(uint32(n)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfUint64(n), nil
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfFloat32(float32(f)), nil
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	case protoreflect.MessageKind:
+		if fd.Message().FullName() == "google.protobuf.Timestamp" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return invalid(err)
+			}
+			return protoreflect.ValueOfMessage(timestamppb.New(t).ProtoReflect()), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("parameter %q cannot be set from a string", fd.Name())
+}
+
+// incomingContext переносит сведения о клиенте из HTTP-запроса в контекст
+// так, как их получают обработчики при вызове по gRPC.
+func incomingContext(req *http.Request) context.Context {
+	ctx := req.Context()
+
+	md := metadata.MD{}
+	for _, header := range forwardedHeaders {
+		if value := req.Header.Get(header); value != "" {
+			md.Set(header, value)
+		}
+	}
+	ctx = metadata.NewIncomingContext(ctx, md)
+
+	if host, port, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		if ip := net.ParseIP(host); ip != nil {
+			portNum, _ := strconv.Atoi(port)
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: ip, Port: portNum}})
+		}
+	}
+	return ctx
+}
+
+// writeMessage отправляет сообщение в формате JSON.
+func writeMessage(res http.ResponseWriter, code int, msg proto.Message, log *zap.Logger) {
+	data, err := marshalOptions.Marshal(msg)
+	if err != nil {
+		log.Error("Failed to encode gateway response", zap.Error(err))
+		writeError(res, status.Error(codes.Internal, "Failed to encode response"), log)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(code)
+	if _, err := res.Write(data); err != nil {
+		log.Debug("Failed to write gateway response", zap.Error(err))
+	}
+}
+
+// writeError отправляет ошибку gRPC в формате JSON со статусом HTTP из errmap.
+func writeError(res http.ResponseWriter, err error, log *zap.Logger) {
+	st := status.Convert(err)
+	if st.Code() == codes.ResourceExhausted {
+		res.Header().Set("Retry-After", errmap.RetryAfterSeconds)
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(errmap.HTTPStatus(st.Code()))
+	if err := json.NewEncoder(res).Encode(errorResponse{
+		Code:    st.Code(),
+		Message: st.Message(),
+		Reason:  errmap.Reason(err),
+	}); err != nil {
+		log.Debug("Failed to write gateway error", zap.Error(err))
+	}
+}
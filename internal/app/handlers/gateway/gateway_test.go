@@ -0,0 +1,406 @@
+package gateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/config"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/handlers/gateway"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/redirect"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/shortenapi"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/stats"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/userurls"
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/logger"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	grpcserver "github.com/ryabkov82/shortener/internal/app/server/grpc"
+	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/auth"
+	mwlogger "github.com/ryabkov82/shortener/internal/app/server/http/middleware/logger"
+	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/mwgzip"
+	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/trustednet"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/test/testhandlers"
+	"github.com/ryabkov82/shortener/test/testutils"
+)
+
+func TestRegister_InMemory(t *testing.T) {
+
+	st, err := testutils.InitializeInMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	if err := logger.Initialize("debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	service := service.NewService(st)
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	handler := grpcserver.NewHandler(base.NewBaseHandler(logger.Log), cfg, service)
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(mwlogger.RequestLogging(logger.Log))
+		r.Use(mwgzip.Gzip)
+		r.Use(auth.JWTAutoIssue(testutils.TestSecretKey))
+
+		gateway.Register(r, gateway.Routes(handler), logger.Log,
+			gateway.WithProtection(base.ProtectedMethods, trustednet.CheckTrustedSubnet(cfg.TrustedSubnet)))
+	})
+	defer tc.Close()
+
+	testhandlers.TestGateway(t, tc.Client)
+}
+
+// errorBody - тело ответа шлюза с ошибкой.
+type errorBody struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+	Reason  string     `json:"reason"`
+}
+
+// echoRoute возвращает маршрут, отвечающий декодированным сообщением запроса.
+func echoRoute(method, pattern string, pathParams []string, body bool, newRequest func() proto.Message) gateway.Route {
+	return gateway.Route{
+		Method:     method,
+		Pattern:    pattern,
+		FullMethod: "/test.Echo" + pattern,
+		PathParams: pathParams,
+		Body:       body,
+		NewRequest: newRequest,
+		Handle: func(_ context.Context, req proto.Message) (proto.Message, error) {
+			return req, nil
+		},
+	}
+}
+
+func TestRegister_Decoding(t *testing.T) {
+	routes := []gateway.Route{
+		echoRoute(http.MethodGet, "/items/{limit}", []string{"limit"}, false,
+			func() proto.Message { return &api.UserURLsRequest{} }),
+		echoRoute(http.MethodPost, "/items", nil, true,
+			func() proto.Message { return &api.CreateRequest{} }),
+	}
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		gateway.Register(r, routes, zap.NewNop())
+	})
+	defer tc.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		wantStatus  int
+		wantBody    string // Ожидаемый ответ (для успешных запросов)
+		wantMessage string // Фрагмент сообщения об ошибке
+	}{
+		{
+			name:       "path and query",
+			method:     http.MethodGet,
+			path:       "/items/5?order=desc&createdFrom=2024-01-02T03:04:05Z",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"limit": 5, "order": "desc", "created_from": "2024-01-02T03:04:05Z"}`,
+		},
+		{
+			name:        "path not a number",
+			method:      http.MethodGet,
+			path:        "/items/abc",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `invalid parameter "limit"`,
+		},
+		{
+			name:        "path out of range",
+			method:      http.MethodGet,
+			path:        "/items/99999999999",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `invalid parameter "limit"`,
+		},
+		{
+			name:        "unknown query parameter",
+			method:      http.MethodGet,
+			path:        "/items/5?unknown=1",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `unknown parameter "unknown"`,
+		},
+		{
+			name:        "repeated scalar parameter",
+			method:      http.MethodGet,
+			path:        "/items/5?order=asc&order=desc",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `parameter "order" must have a single value`,
+		},
+		{
+			name:        "invalid timestamp",
+			method:      http.MethodGet,
+			path:        "/items/5?created_to=yesterday",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `invalid parameter "created_to"`,
+		},
+		{
+			name:       "empty body",
+			method:     http.MethodPost,
+			path:       "/items",
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+		{
+			name:       "body",
+			method:     http.MethodPost,
+			path:       "/items",
+			body:       `{"originalUrl": "https://example.com/", "ttl_seconds": "60"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"original_url": "https://example.com/", "ttl_seconds": "60"}`,
+		},
+		{
+			name:        "body field type",
+			method:      http.MethodPost,
+			path:        "/items",
+			body:        `{"original_url": 42}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "invalid request body",
+		},
+		{
+			name:        "unknown body field",
+			method:      http.MethodPost,
+			path:        "/items",
+			body:        `{"unknown": 1}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "invalid request body",
+		},
+		{
+			name:        "malformed body",
+			method:      http.MethodPost,
+			path:        "/items",
+			body:        `{"original_url":`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "invalid request body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tc.Client.R().SetBody(tt.body).Execute(tt.method, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode())
+			assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+			if tt.wantStatus == http.StatusOK {
+				assert.JSONEq(t, tt.wantBody, resp.String())
+				return
+			}
+			var body errorBody
+			require.NoError(t, json.Unmarshal(resp.Body(), &body))
+			assert.Equal(t, codes.InvalidArgument, body.Code)
+			assert.Contains(t, body.Message, tt.wantMessage)
+		})
+	}
+
+	t.Run("non-scalar path parameter", func(t *testing.T) {
+		route := echoRoute(http.MethodGet, "/items/{created_from}", []string{"created_from"}, false,
+			func() proto.Message { return &api.UserURLsRequest{} })
+		assert.Panics(t, func() {
+			gateway.Register(chi.NewRouter(), []gateway.Route{route}, zap.NewNop())
+		})
+	})
+}
+
+// newShortenerHandler создаёт сервис и обработчики gRPC поверх хранилища в памяти.
+func newShortenerHandler(t *testing.T, cfg *config.Config) (service.Repository, *service.Service, api.ShortenerServer) {
+	t.Helper()
+
+	st, err := testutils.InitializeInMemoryStorage()
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+
+	srv := service.NewService(st)
+	return st, srv, grpcserver.NewHandler(base.NewBaseHandler(zap.NewNop()), cfg, srv)
+}
+
+func TestRegister_WithProtection(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080", TrustedSubnet: "10.0.0.0/8"}
+	_, _, handler := newShortenerHandler(t, cfg)
+	routes := gateway.Routes(handler)
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(auth.JWTAutoIssue(testutils.TestSecretKey))
+		gateway.Register(r, routes, zap.NewNop(),
+			gateway.WithProtection(base.ProtectedMethods, trustednet.CheckTrustedSubnet(cfg.TrustedSubnet)))
+	})
+	defer tc.Close()
+
+	protected := 0
+	for _, route := range routes {
+		if !base.ProtectedMethods[route.FullMethod] {
+			continue
+		}
+		protected++
+		require.True(t, strings.HasPrefix(route.Pattern, "/v1/internal/"), route.Pattern)
+
+		t.Run(route.Pattern, func(t *testing.T) {
+			for _, ip := range []string{"", "192.168.1.10", "not-an-ip"} {
+				resp, err := tc.Client.R().SetHeader("X-Real-IP", ip).Execute(route.Method, route.Pattern)
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode(), "X-Real-IP %q", ip)
+			}
+
+			resp, err := tc.Client.R().SetHeader("X-Real-IP", "10.1.2.3").Execute(route.Method, route.Pattern)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode())
+		})
+	}
+	assert.Len(t, base.ProtectedMethods, protected, "every protected method has a gateway route")
+
+	t.Run("unprotected route", func(t *testing.T) {
+		resp, err := tc.Client.R().SetHeader("X-Real-IP", "192.168.1.10").Get("/v1/urls/NoSuchKey")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+	})
+}
+
+func TestGateway_ParityWithHTTPAPI(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	st, srv, handler := newShortenerHandler(t, cfg)
+	log := zap.NewNop()
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(auth.JWTAutoIssue(testutils.TestSecretKey))
+
+		r.Get("/{id}", redirect.GetHandler(srv, log))
+		r.Post("/api/shorten", shortenapi.GetHandler(srv, cfg.BaseURL, log))
+		r.Get("/api/user/urls", userurls.GetHandler(srv, cfg.BaseURL, log))
+		r.With(trustednet.CheckTrustedSubnet(cfg.TrustedSubnet)).Get("/api/internal/stats", stats.GetHandler(srv, log))
+
+		gateway.Register(r, gateway.Routes(handler), log,
+			gateway.WithProtection(base.ProtectedMethods, trustednet.CheckTrustedSubnet(cfg.TrustedSubnet)))
+	})
+	defer tc.Close()
+
+	cookie, userID := testutils.CreateSignedCookie()
+	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+	expiredAt := time.Now().Add(-time.Hour)
+	for _, mapping := range []models.URLMapping{
+		{ShortURL: "taken", OriginalURL: "https://example.com/taken"},
+		{ShortURL: "expired", OriginalURL: "https://example.com/expired", ExpiresAt: &expiredAt},
+		{ShortURL: "deleted", OriginalURL: "https://example.com/deleted"},
+	} {
+		require.NoError(t, st.SaveURL(ctx, &mapping))
+	}
+	_, err := st.BatchMarkAsDeleted(ctx, userID, []string{"deleted"})
+	require.NoError(t, err)
+
+	type request struct {
+		method string
+		path   string
+		body   string
+	}
+
+	tests := []struct {
+		name       string
+		api        request // Маршрут HTTP API (ответ - текст)
+		v1         request // Маршрут шлюза (ответ - JSON)
+		wantStatus int
+		wantReason string
+		sameText   bool // Тексты ошибок совпадают (общее правило errmap)
+	}{
+		{
+			name:       "not found",
+			api:        request{http.MethodGet, "/NoSuchKey", ""},
+			v1:         request{http.MethodGet, "/v1/urls/NoSuchKey", ""},
+			wantStatus: http.StatusNotFound,
+			sameText:   true,
+		},
+		{
+			name:       "deleted",
+			api:        request{http.MethodGet, "/deleted", ""},
+			v1:         request{http.MethodGet, "/v1/urls/deleted", ""},
+			wantStatus: http.StatusGone,
+			wantReason: errmap.ReasonURLDeleted,
+			sameText:   true,
+		},
+		{
+			name:       "expired",
+			api:        request{http.MethodGet, "/expired", ""},
+			v1:         request{http.MethodGet, "/v1/urls/expired", ""},
+			wantStatus: http.StatusGone,
+			wantReason: errmap.ReasonURLExpired,
+			sameText:   true,
+		},
+		{
+			name:       "invalid alias",
+			api:        request{http.MethodPost, "/api/shorten", `{"url": "https://example.com/a", "custom_alias": "ab"}`},
+			v1:         request{http.MethodPost, "/v1/urls", `{"original_url": "https://example.com/a", "custom_alias": "ab"}`},
+			wantStatus: http.StatusBadRequest,
+			sameText:   true,
+		},
+		{
+			name:       "alias in use",
+			api:        request{http.MethodPost, "/api/shorten", `{"url": "https://example.com/b", "custom_alias": "taken"}`},
+			v1:         request{http.MethodPost, "/v1/urls", `{"original_url": "https://example.com/b", "custom_alias": "taken"}`},
+			wantStatus: http.StatusConflict,
+			sameText:   true,
+		},
+		{
+			name:       "invalid order",
+			api:        request{http.MethodGet, "/api/user/urls?order=sideways", ""},
+			v1:         request{http.MethodGet, "/v1/user/urls?order=sideways", ""},
+			wantStatus: http.StatusBadRequest,
+			sameText:   true,
+		},
+		{
+			name:       "invalid limit",
+			api:        request{http.MethodGet, "/api/user/urls?limit=abc", ""},
+			v1:         request{http.MethodGet, "/v1/user/urls?limit=abc", ""},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "untrusted subnet",
+			api:        request{http.MethodGet, "/api/internal/stats", ""},
+			v1:         request{http.MethodGet, "/v1/internal/stats", ""},
+			wantStatus: http.StatusForbidden,
+			sameText:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiResp, err := tc.Client.R().SetCookie(cookie).SetBody(tt.api.body).Execute(tt.api.method, tt.api.path)
+			require.NoError(t, err)
+			v1Resp, err := tc.Client.R().SetCookie(cookie).SetBody(tt.v1.body).Execute(tt.v1.method, tt.v1.path)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, apiResp.StatusCode())
+			assert.Equal(t, tt.wantStatus, v1Resp.StatusCode())
+
+			apiText := strings.TrimSpace(apiResp.String())
+			if tt.wantStatus == http.StatusForbidden {
+				// Отказ формирует общий middleware, а не шлюз
+				assert.Equal(t, apiText, strings.TrimSpace(v1Resp.String()))
+				return
+			}
+
+			var body errorBody
+			require.NoError(t, json.Unmarshal(v1Resp.Body(), &body))
+			assert.Equal(t, tt.wantStatus, errmap.HTTPStatus(body.Code))
+			assert.Equal(t, tt.wantReason, body.Reason)
+			if tt.sameText {
+				assert.Equal(t, apiText, body.Message)
+			}
+		})
+	}
+}
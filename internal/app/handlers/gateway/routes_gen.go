@@ -0,0 +1,148 @@
+// Code generated by genserver from proto; DO NOT EDIT.
+
+package gateway
+
+import (
+	"context"
+
+	"github.com/ryabkov82/shortener/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// Routes возвращает маршруты REST-шлюза для методов srv, размеченных опцией (http).
+func Routes(srv api.ShortenerServer) []Route {
+	return []Route{
+		{
+			Method:     "POST",
+			Pattern:    "/v1/urls",
+			FullMethod: api.Shortener_CreateShortURL_FullMethodName,
+			PathParams: []string{},
+			Body:       true,
+			NewRequest: func() proto.Message { return &api.CreateRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.CreateShortURL(ctx, req.(*api.CreateRequest))
+			},
+		},
+		{
+			Method:     "GET",
+			Pattern:    "/v1/urls/{short_url}",
+			FullMethod: api.Shortener_GetOriginalURL_FullMethodName,
+			PathParams: []string{"short_url"},
+			Body:       false,
+			NewRequest: func() proto.Message { return &api.GetRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.GetOriginalURL(ctx, req.(*api.GetRequest))
+			},
+		},
+		{
+			Method:     "GET",
+			Pattern:    "/v1/ping",
+			FullMethod: api.Shortener_Ping_FullMethodName,
+			PathParams: []string{},
+			Body:       false,
+			NewRequest: func() proto.Message { return &api.PingRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.Ping(ctx, req.(*api.PingRequest))
+			},
+		},
+		{
+			Method:     "GET",
+			Pattern:    "/v1/internal/stats",
+			FullMethod: api.Shortener_GetStats_FullMethodName,
+			PathParams: []string{},
+			Body:       false,
+			NewRequest: func() proto.Message { return &api.StatsRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.GetStats(ctx, req.(*api.StatsRequest))
+			},
+		},
+		{
+			Method:     "GET",
+			Pattern:    "/v1/user/urls",
+			FullMethod: api.Shortener_GetUserURLs_FullMethodName,
+			PathParams: []string{},
+			Body:       false,
+			NewRequest: func() proto.Message { return &api.UserURLsRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.GetUserURLs(ctx, req.(*api.UserURLsRequest))
+			},
+		},
+		{
+			Method:     "DELETE",
+			Pattern:    "/v1/user/urls",
+			FullMethod: api.Shortener_DeleteUserURLs_FullMethodName,
+			PathParams: []string{},
+			Body:       true,
+			NewRequest: func() proto.Message { return &api.DeleteRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.DeleteUserURLs(ctx, req.(*api.DeleteRequest))
+			},
+		},
+		{
+			Method:     "POST",
+			Pattern:    "/v1/user/urls/restore",
+			FullMethod: api.Shortener_RestoreUserURLs_FullMethodName,
+			PathParams: []string{},
+			Body:       true,
+			NewRequest: func() proto.Message { return &api.RestoreRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.RestoreUserURLs(ctx, req.(*api.RestoreRequest))
+			},
+		},
+		{
+			Method:     "GET",
+			Pattern:    "/v1/user/deletions/{job_id}",
+			FullMethod: api.Shortener_GetDeleteJob_FullMethodName,
+			PathParams: []string{"job_id"},
+			Body:       false,
+			NewRequest: func() proto.Message { return &api.DeleteJobRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.GetDeleteJob(ctx, req.(*api.DeleteJobRequest))
+			},
+		},
+		{
+			Method:     "POST",
+			Pattern:    "/v1/urls/batch",
+			FullMethod: api.Shortener_BatchCreate_FullMethodName,
+			PathParams: []string{},
+			Body:       true,
+			NewRequest: func() proto.Message { return &api.BatchCreateRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.BatchCreate(ctx, req.(*api.BatchCreateRequest))
+			},
+		},
+		{
+			Method:     "GET",
+			Pattern:    "/v1/user/urls/{short_url}/stats",
+			FullMethod: api.Shortener_GetURLStats_FullMethodName,
+			PathParams: []string{"short_url"},
+			Body:       false,
+			NewRequest: func() proto.Message { return &api.URLStatsRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.GetURLStats(ctx, req.(*api.URLStatsRequest))
+			},
+		},
+		{
+			Method:     "GET",
+			Pattern:    "/v1/internal/deadletters",
+			FullMethod: api.Shortener_ListDeadLetters_FullMethodName,
+			PathParams: []string{},
+			Body:       false,
+			NewRequest: func() proto.Message { return &api.ListDeadLettersRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.ListDeadLetters(ctx, req.(*api.ListDeadLettersRequest))
+			},
+		},
+		{
+			Method:     "POST",
+			Pattern:    "/v1/internal/deadletters/replay",
+			FullMethod: api.Shortener_ReplayDeadLetters_FullMethodName,
+			PathParams: []string{},
+			Body:       true,
+			NewRequest: func() proto.Message { return &api.ReplayDeadLettersRequest{} },
+			Handle: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return srv.ReplayDeadLetters(ctx, req.(*api.ReplayDeadLettersRequest))
+			},
+		},
+	}
+}
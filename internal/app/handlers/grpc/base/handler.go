@@ -25,13 +25,36 @@ func (h *BaseHandler) CommonInterceptors(cfg *config.Config) []grpc.UnaryServerI
 	return []grpc.UnaryServerInterceptor{
 		interceptors.LoggingInterceptor(h.Logger),
 		interceptors.JWTAutoIssueGRPC([]byte(cfg.JwtKey), h.Logger),
-		interceptors.TrustedSubnetInterceptor(interceptors.TrustedSubnetConfig{
-			TrustedSubnet: cfg.TrustedSubnet,
-			ProtectedMethods: map[string]bool{
-				"/shortener.Shortener/GetStats": true,
-			},
-			DenyIfNotConfigured: true, // Блокировать если подсеть не настроена
-		}),
+		interceptors.TrustedSubnetInterceptor(trustedSubnetConfig(cfg)),
 	}
 
 }
+
+// CommonStreamInterceptors возвращает цепочку общих интерцепторов для потоковых методов.
+// Цепочка повторяет CommonInterceptors, чтобы потоковые методы не обходили
+// аутентификацию и проверку доверенной подсети.
+func (h *BaseHandler) CommonStreamInterceptors(cfg *config.Config) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		interceptors.LoggingStreamInterceptor(h.Logger),
+		interceptors.JWTAutoIssueStreamGRPC([]byte(cfg.JwtKey), h.Logger),
+		interceptors.TrustedSubnetStreamInterceptor(trustedSubnetConfig(cfg)),
+	}
+}
+
+// ProtectedMethods - методы, доступные только из доверенной подсети.
+// Используется интерцепторами gRPC и REST-шлюзом.
+var ProtectedMethods = map[string]bool{
+	"/shortener.Shortener/GetStats":          true,
+	"/shortener.Shortener/ListDeadLetters":   true,
+	"/shortener.Shortener/ReplayDeadLetters": true,
+}
+
+// trustedSubnetConfig возвращает настройки проверки доверенной подсети,
+// общие для унарных и потоковых методов.
+func trustedSubnetConfig(cfg *config.Config) interceptors.TrustedSubnetConfig {
+	return interceptors.TrustedSubnetConfig{
+		TrustedSubnet:       cfg.TrustedSubnet,
+		ProtectedMethods:    ProtectedMethods,
+		DenyIfNotConfigured: true, // Блокировать если подсеть не настроена
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
 	"github.com/ryabkov82/shortener/internal/app/models"
 	"go.uber.org/zap"
@@ -46,14 +47,24 @@ func (h *Handler) BatchCreate(
 	// Преобразуем в []models.BatchRequest
 	batchReq := make([]models.BatchRequest, 0, len(req.Items))
 	for _, item := range req.Items {
-		batchReq = append(batchReq, models.BatchRequest{
+		batchItem := models.BatchRequest{
 			CorrelationID: item.CorrelationId,
 			OriginalURL:   item.OriginalUrl,
-		})
+			TTLSeconds:    item.TtlSeconds,
+		}
+		if item.ExpiresAt != nil {
+			expiresAt := item.ExpiresAt.AsTime()
+			batchItem.ExpiresAt = &expiresAt
+		}
+		batchReq = append(batchReq, batchItem)
 	}
 
 	// Обработка
 	batchResp, err := h.service.Batch(ctx, batchReq, h.baseURL)
+	if rule, ok := errmap.Lookup(err); ok {
+		h.Logger.Info("Batch create rejected", zap.Error(err))
+		return nil, rule.GRPCError(err)
+	}
 	if err != nil {
 		h.Logger.Error("Failed to process batch create", zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to process batch create")
@@ -65,6 +76,7 @@ func (h *Handler) BatchCreate(
 		resp.Items = append(resp.Items, &pb.BatchCreateResult{
 			CorrelationId: item.CorrelationID,
 			ShortUrl:      item.ShortURL,
+			Error:         item.Error,
 		})
 	}
 
@@ -0,0 +1,69 @@
+package deadletters
+
+import (
+	"context"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/models"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// URLHandler определяет интерфейс для получения невыполненных задач удаления.
+type URLHandler interface {
+	// ListDeadLetters возвращает задачи из хранилища dead-letter.
+	ListDeadLetters(ctx context.Context) ([]models.DeadLetter, error)
+}
+
+// Handler обрабатывает gRPC-запросы просмотра невыполненных задач удаления.
+type Handler struct {
+	*base.BaseHandler // Встраиваем базовый обработчик
+	service           URLHandler
+}
+
+// New создает новый экземпляр Handler с указанными зависимостями.
+// baseHandler - базовый обработчик с общими зависимостями,
+// service - реализация бизнес-логики.
+func New(
+	baseHandler *base.BaseHandler,
+	service URLHandler,
+) *Handler {
+	return &Handler{
+		BaseHandler: baseHandler,
+		service:     service,
+	}
+}
+
+// ListDeadLetters реализует gRPC хендлер получения невыполненных задач удаления
+func (h *Handler) ListDeadLetters(
+	ctx context.Context,
+	_ *pb.ListDeadLettersRequest,
+) (*pb.ListDeadLettersResponse, error) {
+
+	letters, err := h.service.ListDeadLetters(ctx)
+	if err != nil {
+		h.Logger.Error("Failed to get dead letters", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to get dead letters")
+	}
+
+	h.Logger.Debug("Dead letters received successfully", zap.Int("count", len(letters)))
+
+	resp := &pb.ListDeadLettersResponse{
+		Letters: make([]*pb.DeadLetter, 0, len(letters)),
+	}
+	for _, letter := range letters {
+		resp.Letters = append(resp.Letters, &pb.DeadLetter{
+			Id:        letter.ID,
+			UserId:    letter.UserID,
+			ShortUrls: letter.ShortURLs,
+			Attempts:  int32(letter.Attempts),
+			LastError: letter.LastError,
+			FailedAt:  timestamppb.New(letter.FailedAt),
+		})
+	}
+	return resp, nil
+}
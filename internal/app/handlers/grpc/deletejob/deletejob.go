@@ -0,0 +1,76 @@
+package deletejob
+
+import (
+	"context"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/models"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// URLHandler определяет контракт для получения состояния задания на удаление.
+type URLHandler interface {
+	// GetDeleteJob возвращает ход выполнения задания пользователя.
+	// Если задание не найдено или создано другим пользователем, возвращает service.ErrDeleteJobNotFound.
+	GetDeleteJob(ctx context.Context, jobID string) (models.DeleteJob, error)
+}
+
+// Handler обрабатывает gRPC-запросы получения хода выполнения заданий на удаление.
+type Handler struct {
+	*base.BaseHandler // Встраиваем базовый обработчик
+	service           URLHandler
+}
+
+// New создает новый экземпляр Handler с указанными зависимостями.
+// baseHandler - базовый обработчик с общими зависимостями,
+// service - реализация бизнес-логики.
+func New(
+	baseHandler *base.BaseHandler,
+	service URLHandler,
+) *Handler {
+	return &Handler{
+		BaseHandler: baseHandler,
+		service:     service,
+	}
+}
+
+// GetDeleteJob возвращает ход выполнения задания на удаление, созданного DeleteUserURLs.
+// Если задание не найдено или создано другим пользователем, возвращает codes.NotFound.
+func (h *Handler) GetDeleteJob(
+	ctx context.Context,
+	req *pb.DeleteJobRequest,
+) (*pb.DeleteJobResponse, error) {
+	if req.JobId == "" {
+		h.Logger.Error("Empty job ID in request")
+		return nil, status.Error(codes.InvalidArgument, "job_id parameter is missing")
+	}
+
+	job, err := h.service.GetDeleteJob(ctx, req.JobId)
+	if err != nil {
+		if rule, ok := errmap.Lookup(err); ok {
+			h.Logger.Info(rule.Text(err), zap.String("jobID", req.JobId))
+			return nil, rule.GRPCError(err)
+		}
+		h.Logger.Error("Failed to get delete job",
+			zap.Error(err),
+			zap.String("jobID", req.JobId))
+		return nil, status.Error(codes.Internal, "Failed to get delete job")
+	}
+
+	return &pb.DeleteJobResponse{
+		JobId:     job.ID,
+		Status:    job.Status,
+		Pending:   job.Pending,
+		Done:      job.Done,
+		Failed:    job.Failed,
+		CreatedAt: timestamppb.New(job.CreatedAt),
+		UpdatedAt: timestamppb.New(job.UpdatedAt),
+		Restore:   job.Restore,
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
@@ -12,7 +13,7 @@ import (
 
 // URLHandler определяет контракт для обработки удаления URL.
 type URLHandler interface {
-	DeleteUserUrls(ctx context.Context, ids []string) error
+	DeleteUserUrls(ctx context.Context, ids []string) (string, error)
 }
 
 type Handler struct {
@@ -42,12 +43,17 @@ func (h *Handler) DeleteUserURLs(
 	h.Logger.Debug("Processing DeleteUserURLs request",
 		zap.Int("url_count", len(req.ShortUrls)))
 
-	err := h.service.DeleteUserUrls(ctx, req.ShortUrls)
+	jobID, err := h.service.DeleteUserUrls(ctx, req.ShortUrls)
+	if rule, ok := errmap.Lookup(err); ok {
+		h.Logger.Warn(rule.Text(err), zap.Int("url_count", len(req.ShortUrls)))
+		return nil, rule.GRPCError(err)
+	}
 	if err != nil {
 		h.Logger.Error("Failed to delete user URLs", zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to delete user URLs")
 	}
 
 	// 202 Accepted, но в gRPC нет статус-кодов как в HTTP — просто возвращаем OK
-	return &pb.DeleteResponse{}, nil
+	// с идентификатором задания для опроса через GetDeleteJob
+	return &pb.DeleteResponse{JobId: jobID}, nil
 }
@@ -1,6 +1,7 @@
 package deluserurls_test
 
 import (
+	"context"
 	"testing"
 
 	pb "github.com/ryabkov82/shortener/api"
@@ -12,8 +13,11 @@ import (
 	"github.com/ryabkov82/shortener/internal/app/service"
 	"github.com/ryabkov82/shortener/test/testhandlers"
 	"github.com/ryabkov82/shortener/test/testutils"
+	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestDelUserURLsGRPC(t *testing.T) {
@@ -60,3 +64,37 @@ func TestDelUserURLsGRPC(t *testing.T) {
 	testhandlers.TestDelUserUrlsGRPC(t, serv, client)
 
 }
+
+// fullQueueService имитирует сервис с заполненной очередью удаления.
+type fullQueueService struct{}
+
+func (fullQueueService) DeleteUserUrls(ctx context.Context, ids []string) (string, error) {
+	return "", service.ErrDeleteQueueFull
+}
+
+func TestDelUserURLsGRPC_QueueFull(t *testing.T) {
+	logger := zap.NewNop()
+	baseHandler := &base.BaseHandler{Logger: logger}
+
+	tc, err := testutils.NewTestGRPCClient(
+		[]grpc.UnaryServerInterceptor{
+			interceptors.JWTAutoIssueGRPC(testutils.TestSecretKey, logger),
+		},
+		grpchandlers.NewServer(
+			baseHandler,
+			grpchandlers.WithDeleteUserURLsEndpoint(deluserurls.New(baseHandler, fullQueueService{})),
+		),
+		logger,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+
+	client := pb.NewShortenerClient(tc.Conn)
+
+	cookie, _ := testutils.CreateSignedCookie()
+	ctx := testutils.ContextWithJWT(context.Background(), cookie.Value)
+	_, err = client.DeleteUserURLs(ctx, &pb.DeleteRequest{ShortUrls: []string{"abc"}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
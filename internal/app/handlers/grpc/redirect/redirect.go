@@ -2,13 +2,15 @@ package redirect
 
 import (
 	"context"
-	"errors"
 
 	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
-	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/server/grpc/interceptors"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -25,8 +27,12 @@ type URLHandler interface {
 	//   error - возможные ошибки:
 	//     - storage.ErrURLNotFound: URL не существует
 	//     - storage.ErrURLDeleted: URL был удален
+	//     - storage.ErrURLExpired: срок действия URL истёк
 	//     - другие внутренние ошибки
 	GetRedirectURL(ctx context.Context, id string) (string, error)
+
+	// RecordClick асинхронно регистрирует успешный переход по короткой ссылке.
+	RecordClick(ctx context.Context, event models.ClickEvent)
 }
 
 type Handler struct {
@@ -61,29 +67,46 @@ func (h *Handler) GetOriginalURL(
 	// Получаем оригинальный URL
 	originalURL, err := h.service.GetRedirectURL(ctx, req.ShortUrl)
 	if err != nil {
-		switch {
-		case errors.Is(err, storage.ErrURLNotFound):
-			h.Logger.Info("Shortened key not found",
-				zap.String("shortKey", req.ShortUrl))
-			return nil, status.Error(codes.NotFound, "Shortened key not found")
-		case errors.Is(err, storage.ErrURLDeleted):
-			h.Logger.Info("URL has been deleted",
+		if rule, ok := errmap.Lookup(err); ok {
+			h.Logger.Info(rule.Text(err),
 				zap.String("shortKey", req.ShortUrl))
-			return nil, status.Error(codes.NotFound, "URL has been deleted")
-		default:
-			h.Logger.Error("Failed to get redirect URL",
-				zap.Error(err),
-				zap.String("shortKey", req.ShortUrl))
-			return nil, status.Error(codes.Internal, "Failed to get redirect URL")
+			return nil, rule.GRPCError(err)
 		}
+		h.Logger.Error("Failed to get redirect URL",
+			zap.Error(err),
+			zap.String("shortKey", req.ShortUrl))
+		return nil, status.Error(codes.Internal, "Failed to get redirect URL")
 	}
 
 	h.Logger.Info("Shortened key found",
 		zap.String("shortKey", req.ShortUrl),
 		zap.String("redirect", originalURL))
 
+	h.service.RecordClick(ctx, clickEvent(ctx, req.ShortUrl))
+
 	// Формируем ответ
 	return &pb.GetResponse{
 		OriginalUrl: originalURL,
 	}, nil
 }
+
+// clickEvent формирует событие перехода из метаданных запроса.
+// Источник и User-Agent берутся из метаданных referer и user-agent.
+func clickEvent(ctx context.Context, shortKey string) models.ClickEvent {
+	event := models.ClickEvent{ShortURL: shortKey}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if referrers := md.Get("referer"); len(referrers) > 0 {
+			event.Referrer = referrers[0]
+		}
+		if userAgents := md.Get("user-agent"); len(userAgents) > 0 {
+			event.UserAgent = userAgents[0]
+		}
+	}
+
+	if ip, err := interceptors.ClientIP(ctx); err == nil {
+		event.ClientIP = ip
+	}
+
+	return event
+}
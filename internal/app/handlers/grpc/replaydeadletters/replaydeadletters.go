@@ -0,0 +1,55 @@
+package replaydeadletters
+
+import (
+	"context"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// URLHandler определяет интерфейс для повторного выполнения задач удаления.
+type URLHandler interface {
+	// ReplayDeadLetters повторно ставит в очередь задачи из хранилища dead-letter
+	// (все задачи, если ids пуст) и возвращает количество поставленных задач.
+	ReplayDeadLetters(ctx context.Context, ids []int64) (int, error)
+}
+
+// Handler обрабатывает gRPC-запросы повторного выполнения задач удаления.
+type Handler struct {
+	*base.BaseHandler // Встраиваем базовый обработчик
+	service           URLHandler
+}
+
+// New создает новый экземпляр Handler с указанными зависимостями.
+// baseHandler - базовый обработчик с общими зависимостями,
+// service - реализация бизнес-логики.
+func New(
+	baseHandler *base.BaseHandler,
+	service URLHandler,
+) *Handler {
+	return &Handler{
+		BaseHandler: baseHandler,
+		service:     service,
+	}
+}
+
+// ReplayDeadLetters реализует gRPC хендлер повторного выполнения задач удаления
+func (h *Handler) ReplayDeadLetters(
+	ctx context.Context,
+	req *pb.ReplayDeadLettersRequest,
+) (*pb.ReplayDeadLettersResponse, error) {
+
+	replayed, err := h.service.ReplayDeadLetters(ctx, req.GetIds())
+	if err != nil {
+		h.Logger.Error("Failed to replay dead letters", zap.Int("replayed", replayed), zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to replay dead letters")
+	}
+
+	h.Logger.Info("Dead letters replayed", zap.Int("replayed", replayed))
+
+	return &pb.ReplayDeadLettersResponse{Replayed: int64(replayed)}, nil
+}
@@ -0,0 +1,59 @@
+package restoreurls
+
+import (
+	"context"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// URLHandler определяет контракт для восстановления удалённых URL.
+type URLHandler interface {
+	RestoreUserUrls(ctx context.Context, ids []string) (string, error)
+}
+
+type Handler struct {
+	*base.BaseHandler // Встраиваем базовый обработчик
+	service           URLHandler
+}
+
+func New(
+	baseHandler *base.BaseHandler,
+	service URLHandler,
+) *Handler {
+	return &Handler{
+		BaseHandler: baseHandler, // Инициализация базовых зависимостей
+		service:     service,
+	}
+}
+
+func (h *Handler) RestoreUserURLs(
+	ctx context.Context,
+	req *pb.RestoreRequest,
+) (*pb.RestoreResponse, error) {
+
+	if len(req.ShortUrls) == 0 {
+		h.Logger.Error("No short URLs provided for restore")
+		return nil, status.Error(codes.InvalidArgument, "No short URLs provided")
+	}
+
+	h.Logger.Debug("Processing RestoreUserURLs request",
+		zap.Int("url_count", len(req.ShortUrls)))
+
+	jobID, err := h.service.RestoreUserUrls(ctx, req.ShortUrls)
+	if rule, ok := errmap.Lookup(err); ok {
+		h.Logger.Warn(rule.Text(err), zap.Int("url_count", len(req.ShortUrls)))
+		return nil, rule.GRPCError(err)
+	}
+	if err != nil {
+		h.Logger.Error("Failed to restore user URLs", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to restore user URLs")
+	}
+
+	// Восстановление выполняется асинхронно, ход отслеживается через GetDeleteJob
+	return &pb.RestoreResponse{JobId: jobID}, nil
+}
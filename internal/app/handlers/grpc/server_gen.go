@@ -13,7 +13,6 @@ import (
 
 type ServerOption func(s *Server)
 
-
 type CreateShortURLEndpoint interface {
 	CreateShortURL(ctx context.Context, req *api.CreateRequest) (*api.CreateResponse, error)
 }
@@ -74,6 +73,26 @@ func WithDeleteUserURLsEndpoint(h DeleteUserURLsEndpoint) ServerOption {
 	}
 }
 
+type RestoreUserURLsEndpoint interface {
+	RestoreUserURLs(ctx context.Context, req *api.RestoreRequest) (*api.RestoreResponse, error)
+}
+
+func WithRestoreUserURLsEndpoint(h RestoreUserURLsEndpoint) ServerOption {
+	return func(s *Server) {
+		s.RestoreUserURLsHandler = h
+	}
+}
+
+type GetDeleteJobEndpoint interface {
+	GetDeleteJob(ctx context.Context, req *api.DeleteJobRequest) (*api.DeleteJobResponse, error)
+}
+
+func WithGetDeleteJobEndpoint(h GetDeleteJobEndpoint) ServerOption {
+	return func(s *Server) {
+		s.GetDeleteJobHandler = h
+	}
+}
+
 type BatchCreateEndpoint interface {
 	BatchCreate(ctx context.Context, req *api.BatchCreateRequest) (*api.BatchCreateResponse, error)
 }
@@ -84,19 +103,74 @@ func WithBatchCreateEndpoint(h BatchCreateEndpoint) ServerOption {
 	}
 }
 
+type GetURLStatsEndpoint interface {
+	GetURLStats(ctx context.Context, req *api.URLStatsRequest) (*api.URLStatsResponse, error)
+}
+
+func WithGetURLStatsEndpoint(h GetURLStatsEndpoint) ServerOption {
+	return func(s *Server) {
+		s.GetURLStatsHandler = h
+	}
+}
+
+type ListDeadLettersEndpoint interface {
+	ListDeadLetters(ctx context.Context, req *api.ListDeadLettersRequest) (*api.ListDeadLettersResponse, error)
+}
+
+func WithListDeadLettersEndpoint(h ListDeadLettersEndpoint) ServerOption {
+	return func(s *Server) {
+		s.ListDeadLettersHandler = h
+	}
+}
+
+type ReplayDeadLettersEndpoint interface {
+	ReplayDeadLetters(ctx context.Context, req *api.ReplayDeadLettersRequest) (*api.ReplayDeadLettersResponse, error)
+}
+
+func WithReplayDeadLettersEndpoint(h ReplayDeadLettersEndpoint) ServerOption {
+	return func(s *Server) {
+		s.ReplayDeadLettersHandler = h
+	}
+}
+
+type StreamCreateEndpoint interface {
+	StreamCreate(stream api.Shortener_StreamCreateServer) error
+}
+
+func WithStreamCreateEndpoint(h StreamCreateEndpoint) ServerOption {
+	return func(s *Server) {
+		s.StreamCreateHandler = h
+	}
+}
+
+type StreamUserURLsEndpoint interface {
+	StreamUserURLs(req *api.UserURLsRequest, stream api.Shortener_StreamUserURLsServer) error
+}
+
+func WithStreamUserURLsEndpoint(h StreamUserURLsEndpoint) ServerOption {
+	return func(s *Server) {
+		s.StreamUserURLsHandler = h
+	}
+}
 
 type Server struct {
 	api.UnimplementedShortenerServer
 	*base.BaseHandler
 
-	CreateShortURLHandler CreateShortURLEndpoint
-	GetOriginalURLHandler GetOriginalURLEndpoint
-	PingHandler PingEndpoint
-	GetStatsHandler GetStatsEndpoint
-	GetUserURLsHandler GetUserURLsEndpoint
-	DeleteUserURLsHandler DeleteUserURLsEndpoint
-	BatchCreateHandler BatchCreateEndpoint
-	
+	CreateShortURLHandler    CreateShortURLEndpoint
+	GetOriginalURLHandler    GetOriginalURLEndpoint
+	PingHandler              PingEndpoint
+	GetStatsHandler          GetStatsEndpoint
+	GetUserURLsHandler       GetUserURLsEndpoint
+	DeleteUserURLsHandler    DeleteUserURLsEndpoint
+	RestoreUserURLsHandler   RestoreUserURLsEndpoint
+	GetDeleteJobHandler      GetDeleteJobEndpoint
+	BatchCreateHandler       BatchCreateEndpoint
+	GetURLStatsHandler       GetURLStatsEndpoint
+	ListDeadLettersHandler   ListDeadLettersEndpoint
+	ReplayDeadLettersHandler ReplayDeadLettersEndpoint
+	StreamCreateHandler      StreamCreateEndpoint
+	StreamUserURLsHandler    StreamUserURLsEndpoint
 }
 
 func NewServer(baseHandler *base.BaseHandler, opts ...ServerOption) *Server {
@@ -107,7 +181,6 @@ func NewServer(baseHandler *base.BaseHandler, opts ...ServerOption) *Server {
 	return s
 }
 
-
 func (s *Server) CreateShortURL(ctx context.Context, req *api.CreateRequest) (*api.CreateResponse, error) {
 	if s.CreateShortURLHandler == nil {
 		return nil, status.Error(codes.Unimplemented, "CreateShortURL handler not provided")
@@ -150,6 +223,20 @@ func (s *Server) DeleteUserURLs(ctx context.Context, req *api.DeleteRequest) (*a
 	return s.DeleteUserURLsHandler.DeleteUserURLs(ctx, req)
 }
 
+func (s *Server) RestoreUserURLs(ctx context.Context, req *api.RestoreRequest) (*api.RestoreResponse, error) {
+	if s.RestoreUserURLsHandler == nil {
+		return nil, status.Error(codes.Unimplemented, "RestoreUserURLs handler not provided")
+	}
+	return s.RestoreUserURLsHandler.RestoreUserURLs(ctx, req)
+}
+
+func (s *Server) GetDeleteJob(ctx context.Context, req *api.DeleteJobRequest) (*api.DeleteJobResponse, error) {
+	if s.GetDeleteJobHandler == nil {
+		return nil, status.Error(codes.Unimplemented, "GetDeleteJob handler not provided")
+	}
+	return s.GetDeleteJobHandler.GetDeleteJob(ctx, req)
+}
+
 func (s *Server) BatchCreate(ctx context.Context, req *api.BatchCreateRequest) (*api.BatchCreateResponse, error) {
 	if s.BatchCreateHandler == nil {
 		return nil, status.Error(codes.Unimplemented, "BatchCreate handler not provided")
@@ -157,3 +244,37 @@ func (s *Server) BatchCreate(ctx context.Context, req *api.BatchCreateRequest) (
 	return s.BatchCreateHandler.BatchCreate(ctx, req)
 }
 
+func (s *Server) GetURLStats(ctx context.Context, req *api.URLStatsRequest) (*api.URLStatsResponse, error) {
+	if s.GetURLStatsHandler == nil {
+		return nil, status.Error(codes.Unimplemented, "GetURLStats handler not provided")
+	}
+	return s.GetURLStatsHandler.GetURLStats(ctx, req)
+}
+
+func (s *Server) ListDeadLetters(ctx context.Context, req *api.ListDeadLettersRequest) (*api.ListDeadLettersResponse, error) {
+	if s.ListDeadLettersHandler == nil {
+		return nil, status.Error(codes.Unimplemented, "ListDeadLetters handler not provided")
+	}
+	return s.ListDeadLettersHandler.ListDeadLetters(ctx, req)
+}
+
+func (s *Server) ReplayDeadLetters(ctx context.Context, req *api.ReplayDeadLettersRequest) (*api.ReplayDeadLettersResponse, error) {
+	if s.ReplayDeadLettersHandler == nil {
+		return nil, status.Error(codes.Unimplemented, "ReplayDeadLetters handler not provided")
+	}
+	return s.ReplayDeadLettersHandler.ReplayDeadLetters(ctx, req)
+}
+
+func (s *Server) StreamCreate(stream api.Shortener_StreamCreateServer) error {
+	if s.StreamCreateHandler == nil {
+		return status.Error(codes.Unimplemented, "StreamCreate handler not provided")
+	}
+	return s.StreamCreateHandler.StreamCreate(stream)
+}
+
+func (s *Server) StreamUserURLs(req *api.UserURLsRequest, stream api.Shortener_StreamUserURLsServer) error {
+	if s.StreamUserURLsHandler == nil {
+		return status.Error(codes.Unimplemented, "StreamUserURLs handler not provided")
+	}
+	return s.StreamUserURLsHandler.StreamUserURLs(req, stream)
+}
@@ -6,7 +6,9 @@ import (
 	"net/url"
 
 	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/models"
 	"github.com/ryabkov82/shortener/internal/app/storage"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
@@ -27,6 +29,20 @@ type URLHandler interface {
 	//     - storage.ErrURLExists: URL уже существует
 	//     - другие внутренние ошибки
 	GetShortKey(ctx context.Context, originalURL string) (string, error)
+
+	// GetShortKeyWithOptions сохраняет URL с пользовательским псевдонимом и сроком действия.
+	// При пустом псевдониме короткий ключ генерируется автоматически.
+	//
+	// Возвращает:
+	//   string - короткий ключ
+	//   error - возможные ошибки:
+	//     - service.ErrInvalidAlias: псевдоним не прошёл валидацию
+	//     - service.ErrInvalidExpiry: срок действия задан некорректно
+	//     - storage.ErrShortURLExists: псевдоним уже занят
+	//     - service.ErrAliasMismatch: URL уже сокращён под другим ключом
+	//     - storage.ErrURLExists: URL уже существует
+	//     - другие внутренние ошибки
+	GetShortKeyWithOptions(ctx context.Context, originalURL string, opts models.ShortenOptions) (string, error)
 }
 
 type Handler struct {
@@ -69,8 +85,25 @@ func (h *Handler) CreateShortURL(
 		zap.String("originalURL", req.OriginalUrl))
 
 	// Генерация короткого ключа
-	shortKey, err := h.service.GetShortKey(ctx, req.OriginalUrl)
+	opts := models.ShortenOptions{
+		CustomAlias: req.CustomAlias,
+		TTLSeconds:  req.TtlSeconds,
+	}
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.AsTime()
+		opts.ExpiresAt = &expiresAt
+	}
+
+	shortKey, err := h.service.GetShortKeyWithOptions(ctx, req.OriginalUrl, opts)
+
+	// ErrURLExists обрабатывается ниже: клиенту возвращается существующая ссылка
 	if err != nil && !errors.Is(err, storage.ErrURLExists) {
+		if rule, ok := errmap.Lookup(err); ok {
+			h.Logger.Info(rule.Text(err),
+				zap.String("alias", req.CustomAlias),
+				zap.Int64("ttlSeconds", req.TtlSeconds))
+			return nil, rule.GRPCError(err)
+		}
 		h.Logger.Error("Short URL generation failed",
 			zap.Error(err),
 			zap.String("originalURL", req.OriginalUrl))
@@ -86,7 +119,8 @@ func (h *Handler) CreateShortURL(
 		h.Logger.Debug("URL already exists",
 			zap.String("shortKey", shortKey),
 			zap.String("originalURL", req.OriginalUrl))
-		return response, status.Error(codes.AlreadyExists, "URL already exists")
+		rule, _ := errmap.Lookup(err)
+		return response, rule.GRPCError(err)
 	}
 
 	h.Logger.Debug("URL successfully shortened",
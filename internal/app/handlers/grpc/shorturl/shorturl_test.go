@@ -55,4 +55,5 @@ func TestShortenUrlsGRPC(t *testing.T) {
 	client := pb.NewShortenerClient(tc.Conn)
 
 	testhandlers.TestShortenURLGRPC(t, client)
+	testhandlers.TestShortenURLCustomAliasGRPC(t, client)
 }
@@ -58,8 +58,23 @@ func (h *Handler) GetStats(
 	h.Logger.Debug("Stats received successfully")
 
 	// Формирование и возврат ответа
-	return &pb.StatsResponse{
+	resp := &pb.StatsResponse{
 		Urls:  int64(stats.URLs),
 		Users: int64(stats.Users),
-	}, nil
+	}
+	if stats.Cache != nil {
+		resp.Cache = &pb.CacheStats{
+			Hits:    stats.Cache.Hits,
+			Misses:  stats.Cache.Misses,
+			Entries: int64(stats.Cache.Entries),
+		}
+	}
+	if stats.Purge != nil {
+		resp.Purge = &pb.PurgeStats{
+			Runs:   stats.Purge.Runs,
+			Purged: stats.Purge.Purged,
+			Errors: stats.Purge.Errors,
+		}
+	}
+	return resp, nil
 }
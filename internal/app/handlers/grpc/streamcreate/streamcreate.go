@@ -0,0 +1,122 @@
+package streamcreate
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chunkSize - количество элементов потока, сохраняемых одним пакетом.
+const chunkSize = 1000
+
+// URLHandler определяет контракт для пакетного создания коротких URL.
+type URLHandler interface {
+	Batch(ctx context.Context, requests []models.BatchRequest, baseURL string) ([]models.BatchResponse, error)
+}
+
+// Handler обрабатывает клиентский поток StreamCreate.
+type Handler struct {
+	*base.BaseHandler // Встраиваем базовый обработчик
+	service           URLHandler
+	baseURL           string
+}
+
+// New создает новый экземпляр Handler с указанными зависимостями.
+func New(
+	baseHandler *base.BaseHandler,
+	service URLHandler,
+	baseURL string,
+) *Handler {
+	return &Handler{
+		BaseHandler: baseHandler, // Инициализация базовых зависимостей
+		service:     service,
+		baseURL:     baseURL,
+	}
+}
+
+// StreamCreate принимает элементы пакета потоком и возвращает результаты
+// одним ответом после закрытия потока клиентом.
+//
+// Элементы сохраняются частями по chunkSize, поэтому размер пакета
+// не ограничен размером одного сообщения. При ошибке части, сохранённые
+// до неё, остаются в хранилище; клиент может повторить поток целиком:
+// уже сокращённые URL вернутся с прежними короткими ссылками.
+func (h *Handler) StreamCreate(stream pb.Shortener_StreamCreateServer) error {
+	ctx := stream.Context()
+
+	var (
+		chunk = make([]models.BatchRequest, 0, chunkSize)
+		resp  = &pb.BatchCreateResponse{}
+	)
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		batchResp, err := h.service.Batch(ctx, chunk, h.baseURL)
+		if rule, ok := errmap.Lookup(err); ok {
+			h.Logger.Info("Stream create rejected", zap.Error(err))
+			return rule.GRPCError(err)
+		}
+		if err != nil {
+			h.Logger.Error("Failed to process stream create", zap.Error(err))
+			return status.Error(codes.Internal, "Failed to process stream create")
+		}
+		for _, item := range batchResp {
+			resp.Items = append(resp.Items, &pb.BatchCreateResult{
+				CorrelationId: item.CorrelationID,
+				ShortUrl:      item.ShortURL,
+				Error:         item.Error,
+			})
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			h.Logger.Debug("Stream create interrupted", zap.Error(err))
+			return err
+		}
+
+		batchItem := models.BatchRequest{
+			CorrelationID: item.CorrelationId,
+			OriginalURL:   item.OriginalUrl,
+			TTLSeconds:    item.TtlSeconds,
+		}
+		if item.ExpiresAt != nil {
+			expiresAt := item.ExpiresAt.AsTime()
+			batchItem.ExpiresAt = &expiresAt
+		}
+		chunk = append(chunk, batchItem)
+
+		if len(chunk) == chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(chunk) == 0 && len(resp.Items) == 0 {
+		h.Logger.Error("Empty stream create request")
+		return status.Error(codes.InvalidArgument, "Request contains no items")
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	h.Logger.Debug("Stream create processed", zap.Int("count", len(resp.Items)))
+	return stream.SendAndClose(resp)
+}
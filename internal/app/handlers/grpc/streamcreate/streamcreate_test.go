@@ -0,0 +1,59 @@
+package streamcreate_test
+
+import (
+	"testing"
+
+	pb "github.com/ryabkov82/shortener/api"
+	grpchandlers "github.com/ryabkov82/shortener/internal/app/handlers/grpc"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/streamcreate"
+	"github.com/ryabkov82/shortener/internal/app/server/grpc/interceptors"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/test/testhandlers"
+	"github.com/ryabkov82/shortener/test/testutils"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func TestStreamCreateGRPC(t *testing.T) {
+	// Инициализация
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := testutils.InitializeInMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	serv := service.NewService(st)
+	baseHandler := &base.BaseHandler{Logger: logger}
+
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		interceptors.LoggingStreamInterceptor(logger),
+		interceptors.JWTAutoIssueStreamGRPC(testutils.TestSecretKey, logger),
+	}
+
+	// Создаем тестовый клиент
+	tc, err := testutils.NewTestGRPCStreamClient(
+		nil,
+		streamInterceptors,
+		grpchandlers.NewServer(
+			baseHandler,
+			grpchandlers.WithStreamCreateEndpoint(streamcreate.New(baseHandler, serv, "http://localhost:8080")),
+		),
+		logger,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tc.Close()
+
+	// Создаем клиент
+	client := pb.NewShortenerClient(tc.Conn)
+
+	testhandlers.TestStreamCreateGRPC(t, client)
+}
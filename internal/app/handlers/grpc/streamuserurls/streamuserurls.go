@@ -0,0 +1,119 @@
+package streamuserurls
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// pageSize - количество записей, запрашиваемых у хранилища за один раз.
+const pageSize = 500
+
+// URLHandler определяет контракт для получения URL пользователя.
+type URLHandler interface {
+	GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error)
+}
+
+// Handler обрабатывает серверный поток StreamUserURLs.
+type Handler struct {
+	*base.BaseHandler // Встраиваем базовый обработчик
+	service           URLHandler
+	baseURL           string
+}
+
+// New создает новый экземпляр Handler с указанными зависимостями.
+func New(
+	baseHandler *base.BaseHandler,
+	service URLHandler,
+	baseURL string,
+) *Handler {
+	return &Handler{
+		BaseHandler: baseHandler, // Инициализация базовых зависимостей
+		service:     service,
+		baseURL:     baseURL,
+	}
+}
+
+// StreamUserURLs выгружает URL пользователя потоком.
+//
+// Фильтры, порядок и начальный курсор задаются так же, как в GetUserURLs,
+// а limit ограничивает общее количество записей (0 - все записи).
+// Записи читаются из хранилища страницами по pageSize, поэтому объём
+// выгрузки не ограничен размером одного сообщения.
+func (h *Handler) StreamUserURLs(req *pb.UserURLsRequest, stream pb.Shortener_StreamUserURLsServer) error {
+	ctx := stream.Context()
+
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	query := models.UserURLsQuery{
+		Cursor:      req.GetCursor(),
+		Search:      req.GetSearch(),
+		CreatedFrom: timePtr(req.GetCreatedFrom()),
+		CreatedTo:   timePtr(req.GetCreatedTo()),
+		Order:       req.GetOrder(),
+	}
+
+	sent := 0
+	for {
+		query.Limit = pageSize
+		if limit > 0 && limit-sent < pageSize {
+			query.Limit = limit - sent
+		}
+
+		page, err := h.service.GetUserUrls(ctx, h.baseURL, query)
+		if rule, ok := errmap.Lookup(err); ok {
+			return rule.GRPCError(err)
+		}
+		if err != nil {
+			h.Logger.Error("Failed to retrieve user URLs",
+				zap.Error(err))
+			return status.Error(codes.Internal, "Failed to retrieve user URLs")
+		}
+
+		for _, u := range page.URLs {
+			pbURL := &pb.UserURL{
+				ShortUrl:    u.ShortURL,
+				OriginalUrl: u.OriginalURL,
+			}
+			if u.CreatedAt != nil {
+				pbURL.CreatedAt = timestamppb.New(*u.CreatedAt)
+			}
+			if u.ExpiresAt != nil {
+				pbURL.ExpiresAt = timestamppb.New(*u.ExpiresAt)
+			}
+			if err := stream.Send(pbURL); err != nil {
+				h.Logger.Debug("Stream user URLs interrupted", zap.Error(err))
+				return err
+			}
+		}
+		sent += len(page.URLs)
+
+		if page.NextCursor == "" || (limit > 0 && sent >= limit) {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	h.Logger.Debug("Successfully streamed user URLs", zap.Int("count", sent))
+	return nil
+}
+
+// timePtr преобразует необязательную отметку времени protobuf.
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
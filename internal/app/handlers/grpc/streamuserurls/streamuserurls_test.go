@@ -0,0 +1,145 @@
+package streamuserurls_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	pb "github.com/ryabkov82/shortener/api"
+	grpchandlers "github.com/ryabkov82/shortener/internal/app/handlers/grpc"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/streamuserurls"
+	"github.com/ryabkov82/shortener/internal/app/server/grpc/interceptors"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/test/testhandlers"
+	"github.com/ryabkov82/shortener/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestStreamUserURLsGRPC(t *testing.T) {
+	// Инициализация
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := testutils.InitializeInMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	serv := service.NewService(st)
+	baseHandler := &base.BaseHandler{Logger: logger}
+
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		interceptors.LoggingStreamInterceptor(logger),
+		interceptors.JWTAutoIssueStreamGRPC(testutils.TestSecretKey, logger),
+	}
+
+	// Создаем тестовый клиент
+	tc, err := testutils.NewTestGRPCStreamClient(
+		nil,
+		streamInterceptors,
+		grpchandlers.NewServer(
+			baseHandler,
+			grpchandlers.WithStreamUserURLsEndpoint(streamuserurls.New(baseHandler, serv, "http://localhost:8080")),
+		),
+		logger,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tc.Close()
+
+	// Создаем клиент
+	client := pb.NewShortenerClient(tc.Conn)
+
+	testhandlers.TestStreamUserURLsGRPC(t, serv, client)
+}
+
+func TestStreamUserURLsGRPC_Interceptors(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := testutils.InitializeInMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	serv := service.NewService(st)
+	baseHandler := &base.BaseHandler{Logger: logger}
+
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		interceptors.LoggingStreamInterceptor(logger),
+		interceptors.StrictJWTAutoIssueStreamGRPC(testutils.TestSecretKey, logger),
+		interceptors.TrustedSubnetStreamInterceptor(interceptors.TrustedSubnetConfig{
+			TrustedSubnet:       "192.168.1.0/24",
+			ProtectedMethods:    map[string]bool{pb.Shortener_StreamUserURLs_FullMethodName: true},
+			DenyIfNotConfigured: true,
+		}),
+	}
+
+	tc, err := testutils.NewTestGRPCStreamClient(
+		nil,
+		streamInterceptors,
+		grpchandlers.NewServer(
+			baseHandler,
+			grpchandlers.WithStreamUserURLsEndpoint(streamuserurls.New(baseHandler, serv, "http://localhost:8080")),
+		),
+		logger,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+
+	client := pb.NewShortenerClient(tc.Conn)
+	cookie, _ := testutils.CreateSignedCookie()
+
+	tests := []struct {
+		name      string
+		token     string
+		ip        string
+		wantCode  codes.Code
+		wantToken bool
+	}{
+		{name: "без токена", ip: "192.168.1.10", wantCode: codes.Unauthenticated, wantToken: true},
+		{name: "невалидный токен", token: "invalid", ip: "192.168.1.10", wantCode: codes.Unauthenticated, wantToken: true},
+		{name: "IP вне доверенной подсети", token: cookie.Value, ip: "10.0.0.1", wantCode: codes.PermissionDenied},
+		{name: "разрешённый вызов", token: cookie.Value, ip: "192.168.1.10", wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.Pairs("x-real-ip", tt.ip)
+			if tt.token != "" {
+				md.Set("token", tt.token)
+			}
+			ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+			stream, err := client.StreamUserURLs(ctx, &pb.UserURLsRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = stream.Recv()
+			if tt.wantCode == codes.OK {
+				assert.ErrorIs(t, err, io.EOF)
+			} else {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+			}
+
+			header, _ := stream.Header()
+			assert.Equal(t, tt.wantToken, len(header.Get("token")) > 0)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package urlstats
+
+import (
+	"context"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// URLHandler определяет контракт для получения статистики переходов.
+type URLHandler interface {
+	// GetURLStats возвращает статистику переходов по ссылке пользователя.
+	// Возвращает storage.ErrURLNotFound, если ссылка не существует
+	// или принадлежит другому пользователю.
+	GetURLStats(ctx context.Context, shortKey string, baseURL string) (models.URLStats, error)
+}
+
+// Handler обрабатывает gRPC-запросы статистики переходов по ссылкам пользователя.
+// Встраивает базовый обработчик (логирование, утилиты) и использует сервис для работы с URL.
+type Handler struct {
+	*base.BaseHandler // Встраиваем базовый обработчик
+	service           URLHandler
+	baseURL           string
+}
+
+// New создает новый экземпляр Handler с указанными зависимостями.
+// baseHandler - базовый обработчик с общими зависимостями,
+// service - реализация бизнес-логики работы с URL,
+// baseURL - корневой URL для генерации коротких ссылок.
+func New(
+	baseHandler *base.BaseHandler,
+	service URLHandler,
+	baseURL string,
+) *Handler {
+	return &Handler{
+		BaseHandler: baseHandler, // Инициализация базовых зависимостей
+		service:     service,
+		baseURL:     baseURL,
+	}
+}
+
+// GetURLStats возвращает агрегированную статистику переходов по ссылке пользователя.
+// Если ссылка не найдена или принадлежит другому пользователю, возвращает codes.NotFound.
+func (h *Handler) GetURLStats(
+	ctx context.Context,
+	req *pb.URLStatsRequest,
+) (*pb.URLStatsResponse, error) {
+	if req.ShortUrl == "" {
+		h.Logger.Error("Empty ID in request")
+		return nil, status.Error(codes.InvalidArgument, "ID parameter is missing")
+	}
+
+	stats, err := h.service.GetURLStats(ctx, req.ShortUrl, h.baseURL)
+	if err != nil {
+		if rule, ok := errmap.Lookup(err); ok {
+			h.Logger.Info(rule.Text(err),
+				zap.String("shortKey", req.ShortUrl))
+			return nil, rule.GRPCError(err)
+		}
+		h.Logger.Error("Failed to get URL stats",
+			zap.Error(err),
+			zap.String("shortKey", req.ShortUrl))
+		return nil, status.Error(codes.Internal, "Failed to get URL stats")
+	}
+
+	resp := &pb.URLStatsResponse{
+		ShortUrl:       stats.ShortURL,
+		OriginalUrl:    stats.OriginalURL,
+		Clicks:         stats.Clicks,
+		UniqueVisitors: stats.UniqueVisitors,
+		TopReferrers:   toPBClickCounts(stats.TopReferrers),
+		TopUserAgents:  toPBClickCounts(stats.TopUserAgents),
+	}
+	if stats.FirstClickAt != nil {
+		resp.FirstClickAt = timestamppb.New(*stats.FirstClickAt)
+	}
+	if stats.LastClickAt != nil {
+		resp.LastClickAt = timestamppb.New(*stats.LastClickAt)
+	}
+
+	h.Logger.Debug("Successfully returned URL stats",
+		zap.String("shortKey", req.ShortUrl),
+		zap.Int64("clicks", stats.Clicks))
+
+	return resp, nil
+}
+
+// toPBClickCounts преобразует разбивку переходов в protobuf-формат.
+func toPBClickCounts(counts []models.ClickCount) []*pb.ClickCount {
+	result := make([]*pb.ClickCount, 0, len(counts))
+	for _, c := range counts {
+		result = append(result, &pb.ClickCount{Value: c.Value, Clicks: c.Clicks})
+	}
+	return result
+}
@@ -2,18 +2,21 @@ package userurls
 
 import (
 	"context"
+	"time"
 
 	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
 	"github.com/ryabkov82/shortener/internal/app/models"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // URLHandler определяет контракт для получения URL пользователя.
 type URLHandler interface {
-	GetUserUrls(ctx context.Context, baseURL string) ([]models.URLMapping, error)
+	GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error)
 }
 
 // Handler обрабатывает gRPC-запросы для работы с URL пользователя.
@@ -40,24 +43,40 @@ func New(
 	}
 }
 
-// GetUserURLs возвращает все URL пользователя в формате OriginalURL -> ShortURL.
+// GetUserURLs возвращает страницу URL пользователя в формате OriginalURL -> ShortURL.
 // Реализует gRPC-метод, обрабатывая запрос и возвращая ответ в protobuf-формате.
-// При ошибках возвращает status.Error с соответствующим кодом.
+// Курсор следующей страницы передаётся в next_cursor.
+// При ошибках возвращает status.Error с соответствующим кодом
+// (InvalidArgument при неверных параметрах страницы или фильтров).
 func (h *Handler) GetUserURLs(
 	ctx context.Context,
-	_ *pb.UserURLsRequest,
+	req *pb.UserURLsRequest,
 ) (*pb.UserURLsResponse, error) {
 
 	h.Logger.Debug("Processing GetUserURLs request")
 
+	query := models.UserURLsQuery{
+		Limit:       int(req.GetLimit()),
+		Cursor:      req.GetCursor(),
+		Search:      req.GetSearch(),
+		CreatedFrom: timePtr(req.GetCreatedFrom()),
+		CreatedTo:   timePtr(req.GetCreatedTo()),
+		Order:       req.GetOrder(),
+	}
+
 	// Получение URL пользователя
-	urls, err := h.service.GetUserUrls(ctx, h.baseURL)
+	page, err := h.service.GetUserUrls(ctx, h.baseURL, query)
+	if rule, ok := errmap.Lookup(err); ok {
+		return nil, rule.GRPCError(err)
+	}
 	if err != nil {
 		h.Logger.Error("Failed to retrieve user URLs",
 			zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to retrieve user URLs")
 	}
 
+	urls := page.URLs
+
 	// Если нет данных — возвращаем пустой ответ
 	if len(urls) == 0 {
 		h.Logger.Debug("No URLs found for user")
@@ -67,15 +86,32 @@ func (h *Handler) GetUserURLs(
 	// Формирование ответа
 	var pbUrls []*pb.UserURL
 	for _, u := range urls {
-		pbUrls = append(pbUrls, &pb.UserURL{
+		pbURL := &pb.UserURL{
 			ShortUrl:    u.ShortURL,
 			OriginalUrl: u.OriginalURL,
-		})
+		}
+		if u.CreatedAt != nil {
+			pbURL.CreatedAt = timestamppb.New(*u.CreatedAt)
+		}
+		if u.ExpiresAt != nil {
+			pbURL.ExpiresAt = timestamppb.New(*u.ExpiresAt)
+		}
+		pbUrls = append(pbUrls, pbURL)
 	}
 
 	h.Logger.Debug("Successfully returned user URLs", zap.Int("count", len(pbUrls)))
 
 	return &pb.UserURLsResponse{
-		Urls: pbUrls,
+		Urls:       pbUrls,
+		NextCursor: page.NextCursor,
 	}, nil
 }
+
+// timePtr преобразует необязательную отметку времени protobuf.
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
@@ -55,4 +55,5 @@ func TestShortenUrlsGRPC(t *testing.T) {
 	client := pb.NewShortenerClient(tc.Conn)
 
 	testhandlers.TestUserUrlsGRPC(t, serv, client)
+	testhandlers.TestUserUrlsPaginationGRPC(t, serv, client)
 }
@@ -44,11 +44,14 @@ type URLHandler interface {
 //	[
 //	  {
 //	    "correlation_id": "уникальный_идентификатор",
-//	    "original_url": "https://example.com"
+//	    "original_url": "https://example.com",
+//	    "ttl_seconds": 3600
 //	  },
 //	  ...
 //	]
 //
+// Поля expires_at (RFC 3339) и ttl_seconds необязательны и взаимоисключающие.
+//
 // Формат ответа:
 //
 //	[
@@ -59,9 +62,12 @@ type URLHandler interface {
 //	  ...
 //	]
 //
+// Элемент, который не удалось сохранить из-за конфликта ключей, содержит
+// пустой short_url и описание ошибки в поле error.
+//
 // Коды ответа:
 //   - 201 Created - успешная обработка
-//   - 400 Bad Request - невалидный JSON
+//   - 400 Bad Request - невалидный JSON или срок действия
 //   - 500 Internal Server Error - внутренняя ошибка сервера
 //
 // Параметры:
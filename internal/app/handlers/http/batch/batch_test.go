@@ -1,9 +1,16 @@
 package batch_test
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/auth"
 	mwlogger "github.com/ryabkov82/shortener/internal/app/server/http/middleware/logger"
 	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/mwgzip"
@@ -11,7 +18,9 @@ import (
 
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/batch"
 	"github.com/ryabkov82/shortener/internal/app/logger"
+	"github.com/ryabkov82/shortener/internal/app/models"
 	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage"
 	"github.com/ryabkov82/shortener/test/testhandlers"
 	"github.com/ryabkov82/shortener/test/testutils"
 
@@ -80,3 +89,75 @@ func TestGetHandler_InMemory(t *testing.T) {
 
 	testhandlers.TestBatch(t, tc.Client)
 }
+
+func TestGetHandler_PartialConflicts(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockRepository(ctrl)
+
+	m.EXPECT().GetExistingURLs(gomock.Any(), gomock.Any()).Return(nil, nil)
+	gomock.InOrder(
+		// Первая строка сохранена, вторая уже сокращена пользователем, у третьей и четвёртой занят ключ
+		m.EXPECT().SaveNewURLs(gomock.Any(), gomock.Len(4)).DoAndReturn(
+			func(_ context.Context, urls []models.URLMapping) error {
+				urls[1].ShortURL = "existing"
+				return &storage.BatchConflictError{Conflicts: map[int]error{
+					1: storage.ErrURLExists,
+					2: storage.ErrShortURLExists,
+					3: storage.ErrShortURLExists,
+				}}
+			}),
+		// Повторяются только строки с занятым ключом; для четвёртой ключ так и не найден
+		m.EXPECT().SaveNewURLs(gomock.Any(), gomock.Len(2)).Return(
+			&storage.BatchConflictError{Conflicts: map[int]error{1: storage.ErrShortURLExists}}),
+		m.EXPECT().SaveNewURLs(gomock.Any(), gomock.Len(1)).Return(
+			&storage.BatchConflictError{Conflicts: map[int]error{0: storage.ErrShortURLExists}}),
+	)
+
+	svc := service.NewService(m, service.WithKeyAttempts(3))
+
+	if err := logger.Initialize("debug"); err != nil {
+		panic(err)
+	}
+
+	baseURL := "http://localhost:8080"
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(mwlogger.RequestLogging(logger.Log))
+		r.Use(auth.JWTAutoIssue(testutils.TestSecretKey))
+
+		r.Post("/api/shorten/batch", batch.GetHandler(svc, baseURL, logger.Log))
+	})
+	defer tc.Close()
+
+	resp, err := tc.Client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(`[
+			{"correlation_id": "1", "original_url": "https://example.com/1"},
+			{"correlation_id": "2", "original_url": "https://example.com/2"},
+			{"correlation_id": "3", "original_url": "https://example.com/3"},
+			{"correlation_id": "4", "original_url": "https://example.com/4"}
+		]`).
+		Post("/api/shorten/batch")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+
+	var response []models.BatchResponse
+	require.NoError(t, json.Unmarshal(resp.Body(), &response))
+	require.Len(t, response, 4)
+
+	for i, item := range response[:3] {
+		assert.True(t, strings.HasPrefix(item.ShortURL, baseURL+"/"), "элемент %d: %q", i, item.ShortURL)
+	}
+	assert.Empty(t, response[0].Error)
+	assert.Empty(t, response[2].Error)
+	// Уже сокращённый URL возвращается с существующей ссылкой и причиной конфликта
+	assert.Equal(t, baseURL+"/existing", response[1].ShortURL)
+	assert.Equal(t, storage.ErrURLExists.Error(), response[1].Error)
+
+	assert.Equal(t, "4", response[3].CorrelationID)
+	assert.Empty(t, response[3].ShortURL)
+	assert.Equal(t, service.ErrKeyCollision.Error(), response[3].Error)
+}
@@ -0,0 +1,84 @@
+// Package deadletters предоставляет обработчик HTTP для просмотра задач удаления,
+// не выполненных после всех повторных попыток.
+package deadletters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"go.uber.org/zap"
+)
+
+// URLHandler определяет интерфейс для получения невыполненных задач удаления.
+type URLHandler interface {
+	// ListDeadLetters возвращает задачи из хранилища dead-letter.
+	// Возвращает:
+	//   - []models.DeadLetter в порядке поступления
+	//   - error в случае ошибки при чтении хранилища
+	ListDeadLetters(ctx context.Context) ([]models.DeadLetter, error)
+}
+
+// GetHandler создает HTTP-обработчик для получения невыполненных задач удаления.
+//
+// Параметры:
+//   - urlHandler: реализация интерфейса URLHandler
+//   - log: логгер для записи событий и ошибок
+//
+// Возвращает:
+//   - http.HandlerFunc, который обрабатывает GET-запросы по пути /api/internal/deadletters
+//
+// Поведение обработчика:
+//   - Проверяет доступ по trusted_subnet (должен быть установлен через middleware)
+//   - Возвращает:
+//   - 200 OK и JSON-массив задач (пустой массив, если задач нет)
+//   - 403 Forbidden если IP не в доверенной подсети
+//   - 500 Internal Server Error при ошибках получения данных
+//
+// Пример ответа при успехе:
+//
+//	[
+//	  {
+//	    "id": 1,
+//	    "user_id": "8fc2785a-1bd1-49c0-9bfb-428a93b42030",
+//	    "short_urls": ["abc", "def"],
+//	    "attempts": 3,
+//	    "last_error": "connection refused",
+//	    "failed_at": "2025-01-01T00:00:00Z"
+//	  }
+//	]
+//
+// Middleware:
+//   - Должен быть установлен trustednet.CheckTrustedSubnet перед этим обработчиком
+func GetHandler(urlHandler URLHandler, log *zap.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+
+		letters, err := urlHandler.ListDeadLetters(req.Context())
+		if err != nil {
+			http.Error(res, "Failed to get dead letters", http.StatusInternalServerError)
+			log.Error("Failed to get dead letters",
+				zap.Error(err),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+			return
+		}
+		if letters == nil {
+			letters = []models.DeadLetter{}
+		}
+
+		log.Debug("Dead letters received successfully",
+			zap.Int("count", len(letters)),
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path))
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(res).Encode(letters); err != nil {
+			log.Error("Failed to encode response",
+				zap.Error(err),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+		}
+	}
+}
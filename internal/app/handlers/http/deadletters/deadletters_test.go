@@ -0,0 +1,134 @@
+package deadletters_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/deadletters"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/replaydeadletters"
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/trustednet"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/service/mocks"
+	"github.com/ryabkov82/shortener/internal/app/workers/deleteurls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetHandler_ReplayDeadLetters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Хранилище недоступно, пока не установлен флаг available
+	var available atomic.Bool
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().
+		BatchMarkAsDeleted(gomock.Any(), "user1", []string{"abc", "def"}).
+		DoAndReturn(func(ctx context.Context, userID string, urls []string) (models.DeleteResult, error) {
+			if !available.Load() {
+				return models.DeleteResult{}, errors.New("connection refused")
+			}
+			return models.DeleteResult{Deleted: urls}, nil
+		}).
+		AnyTimes()
+	mockRepo.EXPECT().RecordClicks(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	svc := service.NewService(mockRepo, service.WithDeleteWorkerOptions(
+		deleteurls.WithRetryPolicy(deleteurls.RetryPolicy{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     time.Millisecond,
+		}),
+	))
+	defer svc.GracefulStop(time.Second)
+
+	r := chi.NewRouter()
+	r.Use(trustednet.CheckTrustedSubnet("192.168.1.0/24"))
+	r.Get("/api/internal/deadletters", deadletters.GetHandler(svc, zap.NewNop()))
+	r.Post("/api/internal/deadletters/replay", replaydeadletters.GetHandler(svc, zap.NewNop()))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	list := func() []models.DeadLetter {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/internal/deadletters", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Real-IP", "192.168.1.10")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var letters []models.DeadLetter
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&letters))
+		return letters
+	}
+
+	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, "user1")
+	jobID, err := svc.DeleteUserUrls(ctx, []string{"abc", "def"})
+	require.NoError(t, err)
+
+	// После исчерпания попыток задача попадает в dead-letter
+	var letters []models.DeadLetter
+	require.Eventually(t, func() bool {
+		letters = list()
+		return len(letters) == 1
+	}, 3*time.Second, 50*time.Millisecond)
+	assert.Equal(t, "user1", letters[0].UserID)
+	assert.Equal(t, []string{"abc", "def"}, letters[0].ShortURLs)
+	assert.Equal(t, 2, letters[0].Attempts)
+	assert.Equal(t, "connection refused", letters[0].LastError)
+	assert.Equal(t, uint64(1), svc.DeleteStats().Dead)
+	assert.Equal(t, uint64(1), svc.DeleteStats().Retries)
+
+	job, err := svc.GetDeleteJob(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+	assert.Equal(t, []string{"abc", "def"}, job.Failed)
+
+	// Запрос не из доверенной подсети отклоняется
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/internal/deadletters/replay", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Real-IP", "10.0.0.1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Хранилище восстановлено - повторяем задачу по идентификатору
+	available.Store(true)
+	body, err := json.Marshal(replaydeadletters.Request{IDs: []int64{letters[0].ID}})
+	require.NoError(t, err)
+	req, err = http.NewRequest(http.MethodPost, srv.URL+"/api/internal/deadletters/replay", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("X-Real-IP", "192.168.1.10")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var replay replaydeadletters.Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&replay))
+	assert.Equal(t, 1, replay.Replayed)
+	assert.Empty(t, list())
+
+	assert.Eventually(t, func() bool {
+		return svc.DeleteStats().Deleted == 2
+	}, 3*time.Second, 50*time.Millisecond)
+
+	// Задание учитывает результат повтора
+	job, err = svc.GetDeleteJob(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeleteJobDone, job.Status)
+	assert.Equal(t, []string{"abc", "def"}, job.Done)
+}
@@ -0,0 +1,110 @@
+// Package deletejob предоставляет обработчик для получения хода выполнения задания на удаление URL.
+//
+// Пакет реализует:
+// - Получение состояния задания по идентификатору, возвращённому DELETE /api/user/urls
+// - Проверку, что задание создано авторизованным пользователем
+// - Возврат данных в JSON-формате
+package deletejob
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// URLHandler определяет контракт для получения состояния задания на удаление.
+type URLHandler interface {
+	// GetDeleteJob возвращает ход выполнения задания на удаление.
+	//
+	// Параметры:
+	//   ctx - контекст выполнения (должен содержать идентификатор пользователя)
+	//   jobID - идентификатор задания
+	//
+	// Возвращает:
+	//   models.DeleteJob - состояние задания
+	//   error - возможные ошибки:
+	//     - service.ErrDeleteJobNotFound: задание не найдено или создано другим пользователем
+	GetDeleteJob(ctx context.Context, jobID string) (models.DeleteJob, error)
+}
+
+// GetHandler создаёт HTTP-обработчик для получения хода выполнения задания на удаление.
+//
+// Спецификация API:
+//
+//	Метод: GET
+//	Путь: /api/user/deletions/{id}
+//	Требуется: JWT-аутентификация
+//
+// Формат ответа:
+//
+//	{
+//	  "id": "5a1c3c2e-2d1f-4f7e-9a53-6f0f0a5b8f61",
+//	  "status": "pending",
+//	  "pending": ["def"],
+//	  "done": ["abc"],
+//	  "failed": [],
+//	  "created_at": "2025-01-01T00:00:00Z",
+//	  "updated_at": "2025-01-01T00:00:01Z"
+//	}
+//
+// Коды ответа:
+//   - 200 OK: успешный запрос
+//   - 404 Not Found: задание не найдено или создано другим пользователем
+//
+// Задания хранятся в памяти сервиса ограниченное время: после перезапуска
+// или вытеснения более новыми заданиями возвращается 404.
+//
+// Параметры:
+//
+//	urlHandler - сервис для получения состояния задания
+//	log - логгер для записи событий
+//
+// Возвращает:
+//
+//	http.HandlerFunc - HTTP-обработчик
+func GetHandler(urlHandler URLHandler, log *zap.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+
+		job, err := urlHandler.GetDeleteJob(req.Context(), id)
+		if err != nil {
+			if rule, ok := errmap.Lookup(err); ok {
+				rule.WriteHTTPError(res, err)
+				log.Info(rule.Text(err),
+					zap.String("jobID", id),
+					zap.String("method", req.Method),
+					zap.String("path", req.URL.Path))
+				return
+			}
+			http.Error(res, "Failed to get delete job", http.StatusInternalServerError)
+			log.Error("Failed to get delete job",
+				zap.Error(err),
+				zap.String("jobID", id),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(res).Encode(job); err != nil {
+			log.Error("Failed to encode response",
+				zap.Error(err),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+		}
+
+		log.Debug("Successfully returned delete job",
+			zap.String("jobID", id),
+			zap.String("status", job.Status),
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path))
+	}
+}
@@ -0,0 +1,42 @@
+package deletejob_test
+
+import (
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/deletejob"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/deluserurls"
+	"github.com/ryabkov82/shortener/internal/app/logger"
+	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/auth"
+	mwlogger "github.com/ryabkov82/shortener/internal/app/server/http/middleware/logger"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/test/testhandlers"
+	"github.com/ryabkov82/shortener/test/testutils"
+)
+
+func TestGetHandler_InMemory(t *testing.T) {
+
+	st, err := testutils.InitializeInMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	service := service.NewService(st)
+
+	if err := logger.Initialize("debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(mwlogger.RequestLogging(logger.Log))
+		r.Use(auth.StrictJWTAutoIssue(testutils.TestSecretKey))
+
+		r.Delete("/api/user/urls", deluserurls.GetHandler(service, "http://localhost:8080/", logger.Log))
+		r.Get("/api/user/deletions/{id}", deletejob.GetHandler(service, logger.Log))
+	})
+	defer tc.Close()
+
+	testhandlers.TestDeleteJob(t, service, tc.Client)
+}
@@ -3,7 +3,7 @@
 // Пакет реализует:
 // - Приём списка URL для удаления в JSON-формате
 // - Асинхронное удаление URL
-// - Подтверждение принятия запроса
+// - Подтверждение принятия запроса с идентификатором задания на удаление
 package deluserurls
 
 import (
@@ -12,6 +12,9 @@ import (
 	"net/http"
 
 	"go.uber.org/zap"
+
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/models"
 )
 
 // URLHandler определяет контракт для обработки удаления URL.
@@ -23,8 +26,10 @@ type URLHandler interface {
 	//   urls - список коротких URL для удаления (только идентификаторы)
 	//
 	// Возвращает:
-	//   error - ошибка выполнения (не влияет на HTTP-статус ответа)
-	DeleteUserUrls(ctx context.Context, urls []string) error
+	//   string - идентификатор задания для отслеживания хода удаления
+	//   error - service.ErrDeleteQueueFull при заполненной очереди
+	//     или другая ошибка постановки задачи в очередь
+	DeleteUserUrls(ctx context.Context, urls []string) (string, error)
 }
 
 // GetHandler создаёт HTTP-обработчик для массового удаления URL пользователя.
@@ -41,12 +46,16 @@ type URLHandler interface {
 //
 // Формат ответа:
 //
-//	Тело ответа пустое
+//	{"job_id": "5a1c3c2e-2d1f-4f7e-9a53-6f0f0a5b8f61"}
+//
+// Ход удаления можно отслеживать по GET /api/user/deletions/{job_id}.
 //
 // Коды ответа:
 //   - 202 Accepted - запрос принят в обработку
 //   - 400 Bad Request - невалидный JSON
 //   - 401 Unauthorized - пользователь не аутентифицирован
+//   - 503 Service Unavailable - очередь удаления заполнена, запрос следует
+//     повторить через время из заголовка Retry-After
 //   - 500 Internal Server Error - внутренняя ошибка сервера
 //
 // Особенности:
@@ -71,14 +80,23 @@ func GetHandler(urlHandler URLHandler, baseURL string, log *zap.Logger) http.Han
 			return
 		}
 
-		err := urlHandler.DeleteUserUrls(req.Context(), shortURLs)
+		jobID, err := urlHandler.DeleteUserUrls(req.Context(), shortURLs)
 
+		if rule, ok := errmap.Lookup(err); ok {
+			rule.WriteHTTPError(res, err)
+			log.Warn(rule.Text(err), zap.Int("url_count", len(shortURLs)))
+			return
+		}
 		if err != nil {
 			http.Error(res, "Failed to delete user urls", http.StatusBadRequest)
 			log.Error("Failed to delete user urls", zap.Error(err))
 			return
 		}
 
+		res.Header().Set("Content-Type", "application/json")
 		res.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(res).Encode(models.DeleteResponse{JobID: jobID}); err != nil {
+			log.Error("Failed to encode response", zap.Error(err))
+		}
 	}
 }
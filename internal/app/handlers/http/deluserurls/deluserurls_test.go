@@ -1,7 +1,14 @@
 package deluserurls_test
 
 import (
+	"context"
+	"net/http"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/deluserurls"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/redirect"
@@ -9,8 +16,12 @@ import (
 	mwlogger "github.com/ryabkov82/shortener/internal/app/server/http/middleware/logger"
 	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/mwgzip"
 
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
 	"github.com/ryabkov82/shortener/internal/app/logger"
+	"github.com/ryabkov82/shortener/internal/app/models"
 	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/ryabkov82/shortener/internal/app/workers/deleteurls"
 	"github.com/ryabkov82/shortener/test/testhandlers"
 	"github.com/ryabkov82/shortener/test/testutils"
 
@@ -47,3 +58,102 @@ func TestGetHandler_InMemory(t *testing.T) {
 	testhandlers.TestDelUserUrls(t, service, tc.Client)
 
 }
+
+// TestGetHandler_DeleteQueue проверяет, что задача, оставшаяся в постоянной очереди
+// после остановки, выполняется при запуске, а новые задачи подтверждаются после обработки.
+func TestGetHandler_DeleteQueue(t *testing.T) {
+
+	st, err := testutils.InitializeInMemoryStorage()
+	require.NoError(t, err)
+	defer st.Close()
+
+	cookie, userID := testutils.CreateSignedCookie()
+	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+	for _, code := range []string{"queued1", "queued2"} {
+		mapping := models.URLMapping{ShortURL: code, OriginalURL: "https://example.com/" + code}
+		require.NoError(t, st.SaveURL(ctx, &mapping))
+	}
+
+	// Задача, не обработанная до остановки сервиса
+	queuePath := filepath.Join(t.TempDir(), "delete_queue.log")
+	queue, err := deleteurls.OpenFileQueue(queuePath)
+	require.NoError(t, err)
+	_, err = queue.Enqueue(ctx, deleteurls.DeleteTask{UserID: userID, ShortURLs: []string{"queued1"}})
+	require.NoError(t, err)
+	require.NoError(t, queue.Close())
+
+	queue, err = deleteurls.OpenFileQueue(queuePath)
+	require.NoError(t, err)
+	defer queue.Close()
+	svc := service.NewService(st, service.WithDeleteQueue(queue))
+	defer svc.GracefulStop(time.Second)
+
+	isDeleted := func(code string) func() bool {
+		return func() bool {
+			_, err := st.GetRedirectURL(ctx, code)
+			return err == storage.ErrURLDeleted
+		}
+	}
+	isEmpty := func() bool {
+		pending, err := queue.Pending(ctx)
+		return err == nil && len(pending) == 0
+	}
+
+	assert.Eventually(t, isDeleted("queued1"), 2*time.Second, 50*time.Millisecond)
+	assert.Eventually(t, isEmpty, 2*time.Second, 50*time.Millisecond)
+
+	if err := logger.Initialize("debug"); err != nil {
+		panic(err)
+	}
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(mwlogger.RequestLogging(logger.Log))
+		r.Use(auth.StrictJWTAutoIssue(testutils.TestSecretKey))
+
+		r.Delete("/api/user/urls", deluserurls.GetHandler(svc, "http://localhost:8080/", logger.Log))
+	})
+	defer tc.Close()
+
+	resp, err := tc.Client.R().
+		SetCookie(cookie).
+		SetHeader("Content-Type", "application/json").
+		SetBody(`["queued2"]`).
+		Delete("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode())
+
+	assert.Eventually(t, isDeleted("queued2"), 2*time.Second, 50*time.Millisecond)
+	assert.Eventually(t, isEmpty, 2*time.Second, 50*time.Millisecond)
+}
+
+// fullQueueService имитирует сервис с заполненной очередью удаления.
+type fullQueueService struct{}
+
+func (fullQueueService) DeleteUserUrls(ctx context.Context, urls []string) (string, error) {
+	return "", service.ErrDeleteQueueFull
+}
+
+// TestGetHandler_QueueFull проверяет, что при заполненной очереди удаления
+// клиент получает 503 с заголовком Retry-After.
+func TestGetHandler_QueueFull(t *testing.T) {
+
+	if err := logger.Initialize("debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(auth.StrictJWTAutoIssue(testutils.TestSecretKey))
+		r.Delete("/api/user/urls", deluserurls.GetHandler(fullQueueService{}, "http://localhost:8080/", logger.Log))
+	})
+	defer tc.Close()
+
+	cookie, _ := testutils.CreateSignedCookie()
+	resp, err := tc.Client.R().
+		SetCookie(cookie).
+		SetHeader("Content-Type", "application/json").
+		SetBody(`["abc"]`).
+		Delete("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode())
+	assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+}
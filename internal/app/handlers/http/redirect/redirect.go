@@ -4,18 +4,20 @@
 // - Поиск оригинального URL по короткому идентификатору
 // - Обработку различных статусов URL (активен, удален, не найден)
 // - Логирование всех операций перенаправления
+// - Регистрацию переходов для статистики
 package redirect
 
 import (
 	"context"
-	"errors"
+	"net"
 	"net/http"
 
 	"go.uber.org/zap"
 
 	"github.com/go-chi/chi/v5"
 
-	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/models"
 )
 
 // URLHandler определяет контракт для получения оригинального URL.
@@ -31,8 +33,12 @@ type URLHandler interface {
 	//   error - возможные ошибки:
 	//     - storage.ErrURLNotFound: URL не существует
 	//     - storage.ErrURLDeleted: URL был удален
+	//     - storage.ErrURLExpired: срок действия URL истёк
 	//     - другие внутренние ошибки
 	GetRedirectURL(ctx context.Context, id string) (string, error)
+
+	// RecordClick асинхронно регистрирует успешный переход по короткой ссылке.
+	RecordClick(ctx context.Context, event models.ClickEvent)
 }
 
 // GetHandler создаёт HTTP-обработчик для перенаправления по коротким URL.
@@ -49,12 +55,13 @@ type URLHandler interface {
 // Ответы:
 //   - 307 Temporary Redirect: успешное перенаправление (с Location header)
 //   - 404 Not Found: короткий URL не существует
-//   - 410 Gone: URL был удален
+//   - 410 Gone: URL был удален или срок его действия истёк
 //   - 500 Internal Server Error: внутренняя ошибка сервера
 //
 // Особенности:
 //   - Все запросы логируются с указанием shortKey
 //   - Для удаленных URL возвращается специальный статус 410
+//   - Успешные переходы регистрируются для статистики (Referer, User-Agent, префикс сети клиента)
 //   - Поддерживается контекст для отмены операций
 //
 // Параметры:
@@ -72,17 +79,9 @@ func GetHandler(urlHandler URLHandler, log *zap.Logger) http.HandlerFunc {
 		// Получаем адрес перенаправления
 		originalURL, err := urlHandler.GetRedirectURL(req.Context(), id)
 		if err != nil {
-			if errors.Is(err, storage.ErrURLNotFound) {
-				http.Error(res, "Shortened key not found", http.StatusNotFound)
-				log.Info("Shortened key not found",
-					zap.String("shortKey", id),
-					zap.String("method", req.Method),
-					zap.String("path", req.URL.Path))
-				return
-			}
-			if errors.Is(err, storage.ErrURLDeleted) {
-				http.Error(res, "URL has been deleted", http.StatusGone)
-				log.Info("URL has been deleted",
+			if rule, ok := errmap.Lookup(err); ok {
+				rule.WriteHTTPError(res, err)
+				log.Info(rule.Text(err),
 					zap.String("shortKey", id),
 					zap.String("method", req.Method),
 					zap.String("path", req.URL.Path))
@@ -103,9 +102,30 @@ func GetHandler(urlHandler URLHandler, log *zap.Logger) http.HandlerFunc {
 			zap.String("method", req.Method),
 			zap.String("path", req.URL.Path))
 
+		urlHandler.RecordClick(req.Context(), models.ClickEvent{
+			ShortURL:  id,
+			Referrer:  req.Referer(),
+			UserAgent: req.UserAgent(),
+			ClientIP:  clientIP(req),
+		})
+
 		// Устанавливаем заголовок ответа Location
 		res.Header().Set("Location", originalURL)
 		// устанавливаем код 307
 		res.WriteHeader(http.StatusTemporaryRedirect)
 	}
 }
+
+// clientIP возвращает IP-адрес клиента.
+// За обратным прокси адрес берётся из заголовка X-Real-IP, иначе - из RemoteAddr.
+func clientIP(req *http.Request) string {
+	if realIP := req.Header.Get("X-Real-IP"); realIP != "" {
+		return realIP
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ryabkov82/shortener/internal/app/logger"
 	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage/cache"
 	"github.com/ryabkov82/shortener/test/testhandlers"
 	"github.com/ryabkov82/shortener/test/testutils"
 
@@ -42,3 +43,30 @@ func TestGetHandler_InMemory(t *testing.T) {
 
 	testhandlers.TestRedirect(t, st, tc.Client)
 }
+
+func TestGetHandler_Cached(t *testing.T) {
+
+	st, err := testutils.InitializeInMemoryStorage()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	cached := cache.New(st)
+	service := service.NewService(cached)
+
+	if err := logger.Initialize("debug"); err != nil {
+		panic(err)
+	}
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(mwlogger.RequestLogging(logger.Log))
+		r.Use(auth.JWTAutoIssue(testutils.TestSecretKey))
+
+		r.Get("/{id}", redirect.GetHandler(service, logger.Log))
+	})
+	defer tc.Close()
+
+	testhandlers.TestRedirectCached(t, cached, tc.Client)
+}
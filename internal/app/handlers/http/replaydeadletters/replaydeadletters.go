@@ -0,0 +1,92 @@
+// Package replaydeadletters предоставляет обработчик HTTP для повторного выполнения
+// задач удаления из хранилища dead-letter.
+package replaydeadletters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// URLHandler определяет интерфейс для повторного выполнения задач удаления.
+type URLHandler interface {
+	// ReplayDeadLetters повторно ставит в очередь задачи из хранилища dead-letter.
+	// Возвращает:
+	//   - int - количество задач, поставленных в очередь
+	//   - error в случае ошибки постановки задач в очередь
+	ReplayDeadLetters(ctx context.Context, ids []int64) (int, error)
+}
+
+// Request - тело запроса на повтор задач.
+type Request struct {
+	IDs []int64 `json:"ids"` // Идентификаторы задач (все задачи, если пуст)
+}
+
+// Response - ответ с количеством повторённых задач.
+type Response struct {
+	Replayed int `json:"replayed"`
+}
+
+// GetHandler создает HTTP-обработчик для повторного выполнения задач удаления.
+//
+// Спецификация API:
+//
+//	Метод: POST
+//	Content-Type: application/json
+//	Путь: /api/internal/deadletters/replay
+//
+// Формат запроса (тело необязательно, без него повторяются все задачи):
+//
+//	{"ids": [1, 2]}
+//
+// Формат ответа:
+//
+//	{"replayed": 2}
+//
+// Коды ответа:
+//   - 200 OK - задачи поставлены в очередь
+//   - 400 Bad Request - невалидный JSON
+//   - 403 Forbidden - IP не в доверенной подсети
+//   - 500 Internal Server Error - задачи не удалось поставить в очередь
+//     (невыполненные задачи остаются в хранилище dead-letter)
+//
+// Middleware:
+//   - Должен быть установлен trustednet.CheckTrustedSubnet перед этим обработчиком
+func GetHandler(urlHandler URLHandler, log *zap.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		var request Request
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(res, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		replayed, err := urlHandler.ReplayDeadLetters(req.Context(), request.IDs)
+		if err != nil {
+			http.Error(res, "Failed to replay dead letters", http.StatusInternalServerError)
+			log.Error("Failed to replay dead letters",
+				zap.Error(err),
+				zap.Int("replayed", replayed),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+			return
+		}
+
+		log.Info("Dead letters replayed",
+			zap.Int("replayed", replayed),
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path))
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(res).Encode(Response{Replayed: replayed}); err != nil {
+			log.Error("Failed to encode response",
+				zap.Error(err),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package restoreurls предоставляет обработчик для восстановления удалённых URL пользователя.
+//
+// Пакет реализует:
+// - Приём списка URL для восстановления в JSON-формате
+// - Асинхронное снятие пометки удаления
+// - Подтверждение принятия запроса с идентификатором задания
+package restoreurls
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// URLHandler определяет контракт для восстановления URL.
+type URLHandler interface {
+	// RestoreUserUrls восстанавливает указанные URL, принадлежащие пользователю.
+	//
+	// Параметры:
+	//   ctx - контекст выполнения
+	//   urls - список коротких URL для восстановления (только идентификаторы)
+	//
+	// Возвращает:
+	//   string - идентификатор задания для отслеживания хода восстановления
+	//   error - service.ErrDeleteQueueFull при заполненной очереди
+	//     или другая ошибка постановки задачи в очередь
+	RestoreUserUrls(ctx context.Context, urls []string) (string, error)
+}
+
+// GetHandler создаёт HTTP-обработчик для восстановления удалённых URL пользователя.
+//
+// Спецификация API:
+//
+//	Метод: POST
+//	Content-Type: application/json
+//	Путь: /api/user/urls/restore
+//
+// Формат запроса:
+//
+//	["url1", "url2", ...]
+//
+// Формат ответа:
+//
+//	{"job_id": "5a1c3c2e-2d1f-4f7e-9a53-6f0f0a5b8f61"}
+//
+// Ход восстановления можно отслеживать по GET /api/user/deletions/{job_id}.
+// Восстанавливаются только ссылки пользователя, удалённые в пределах окна
+// восстановления; остальные коды попадают в список failed задания.
+//
+// Коды ответа:
+//   - 202 Accepted - запрос принят в обработку
+//   - 400 Bad Request - невалидный JSON или пустой список
+//   - 401 Unauthorized - пользователь не аутентифицирован
+//   - 503 Service Unavailable - очередь заполнена, запрос следует
+//     повторить через время из заголовка Retry-After
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+//
+// Особенности:
+//   - Восстановление выполняется тем же воркером, что и удаление,
+//     в порядке поступления запросов
+//   - Для аутентификации используется JWT-токен в Cookie
+//
+// Параметры:
+//
+//	urlHandler - сервис для обработки URL
+//	log - логгер для записи событий
+//
+// Возвращает:
+//
+//	http.HandlerFunc - HTTP-обработчик
+func GetHandler(urlHandler URLHandler, log *zap.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		var shortURLs []string
+		if err := json.NewDecoder(req.Body).Decode(&shortURLs); err != nil {
+			http.Error(res, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if len(shortURLs) == 0 {
+			http.Error(res, "No short URLs provided", http.StatusBadRequest)
+			return
+		}
+
+		jobID, err := urlHandler.RestoreUserUrls(req.Context(), shortURLs)
+		if rule, ok := errmap.Lookup(err); ok {
+			rule.WriteHTTPError(res, err)
+			log.Warn(rule.Text(err), zap.Int("url_count", len(shortURLs)))
+			return
+		}
+		if err != nil {
+			http.Error(res, "Failed to restore user urls", http.StatusInternalServerError)
+			log.Error("Failed to restore user urls", zap.Error(err))
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(res).Encode(models.DeleteResponse{JobID: jobID}); err != nil {
+			log.Error("Failed to encode response", zap.Error(err))
+		}
+	}
+}
@@ -13,9 +13,12 @@ import (
 	"errors"
 	"net/http"
 	"net/url"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/models"
 	"github.com/ryabkov82/shortener/internal/app/storage"
 )
 
@@ -33,11 +36,32 @@ type URLHandler interface {
 	//     - storage.ErrURLExists: URL уже существует
 	//     - другие внутренние ошибки
 	GetShortKey(ctx context.Context, originalURL string) (string, error)
+
+	// GetShortKeyWithOptions сохраняет URL с пользовательским псевдонимом и сроком действия.
+	//
+	// Параметры:
+	//   ctx - контекст выполнения
+	//   originalURL - URL для сокращения (должен быть валидным)
+	//   opts - необязательные параметры (псевдоним, expires_at или ttl_seconds)
+	//
+	// Возвращает:
+	//   string - короткий ключ
+	//   error - возможные ошибки:
+	//     - service.ErrInvalidAlias: псевдоним не прошёл валидацию
+	//     - service.ErrInvalidExpiry: срок действия задан некорректно
+	//     - storage.ErrShortURLExists: псевдоним уже занят
+	//     - service.ErrAliasMismatch: URL уже сокращён под другим ключом
+	//     - storage.ErrURLExists: URL уже существует
+	//     - другие внутренние ошибки
+	GetShortKeyWithOptions(ctx context.Context, originalURL string, opts models.ShortenOptions) (string, error)
 }
 
 // Request представляет структуру входящего JSON-запроса.
 type Request struct {
-	URL string `json:"url"` // Оригинальный URL для сокращения
+	URL         string     `json:"url"`                    // Оригинальный URL для сокращения
+	CustomAlias string     `json:"custom_alias,omitempty"` // Пользовательский псевдоним (необязательно)
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`   // Абсолютный момент истечения срока действия (RFC 3339)
+	TTLSeconds  int64      `json:"ttl_seconds,omitempty"`  // Время жизни ссылки в секундах
 }
 
 // Response представляет структуру исходящего JSON-ответа.
@@ -56,9 +80,16 @@ type Response struct {
 // Формат запроса:
 //
 //	{
-//	  "url": "https://example.com/very/long/url"
+//	  "url": "https://example.com/very/long/url",
+//	  "custom_alias": "promo2026"
 //	}
 //
+// Поле custom_alias необязательно. Допустимы символы [a-zA-Z0-9_-],
+// длина от 3 до 20 символов; имена служебных маршрутов (ping, api) зарезервированы.
+//
+// Срок действия задаётся необязательным полем expires_at (RFC 3339)
+// или ttl_seconds; указывать оба поля одновременно нельзя.
+//
 // Формат ответа:
 //
 //	{
@@ -67,8 +98,8 @@ type Response struct {
 //
 // Коды ответа:
 //   - 201 Created: URL успешно сокращён
-//   - 400 Bad Request: невалидный запрос
-//   - 409 Conflict: URL уже существует
+//   - 400 Bad Request: невалидный запрос, псевдоним или срок действия
+//   - 409 Conflict: URL уже существует, псевдоним занят или URL сокращён под другим ключом
 //   - 500 Internal Server Error: внутренняя ошибка сервера
 //
 // Параметры:
@@ -122,10 +153,24 @@ func GetHandler(urlHandler URLHandler, baseURL string, log *zap.Logger) http.Han
 			zap.String("path", req.URL.Path))
 
 		// Генерация короткого ключа
-		shortKey, err := urlHandler.GetShortKey(req.Context(), originalURL)
+		shortKey, err := urlHandler.GetShortKeyWithOptions(req.Context(), originalURL, models.ShortenOptions{
+			CustomAlias: request.CustomAlias,
+			ExpiresAt:   request.ExpiresAt,
+			TTLSeconds:  request.TTLSeconds,
+		})
 
 		// Обработка ошибок
+		// При ErrURLExists клиенту возвращается существующая ссылка со статусом 409
 		if err != nil && !errors.Is(err, storage.ErrURLExists) {
+			if rule, ok := errmap.Lookup(err); ok {
+				rule.WriteHTTPError(res, err)
+				log.Info(rule.Text(err),
+					zap.String("alias", request.CustomAlias),
+					zap.Int64("ttlSeconds", request.TTLSeconds),
+					zap.String("method", req.Method),
+					zap.String("path", req.URL.Path))
+				return
+			}
 			http.Error(res, "Failed to generate short URL", http.StatusInternalServerError)
 			log.Error("Short URL generation failed",
 				zap.Error(err),
@@ -43,4 +43,5 @@ func TestGetHandler_InMemory(t *testing.T) {
 	defer tc.Close()
 
 	testhandlers.TestShortenAPI(t, tc.Client)
+	testhandlers.TestShortenAPICustomAlias(t, tc.Client)
 }
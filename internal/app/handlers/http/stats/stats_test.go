@@ -1,11 +1,13 @@
 package stats_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
@@ -17,6 +19,8 @@ import (
 	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/trustednet"
 	"github.com/ryabkov82/shortener/internal/app/service"
 	"github.com/ryabkov82/shortener/internal/app/service/mocks"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/ryabkov82/shortener/internal/app/storage/cache"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -195,3 +199,115 @@ func TestGetHandler_WithoutTrustedSubnet(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
 	})
 }
+
+func TestGetHandler_CacheStats(t *testing.T) {
+	// Инициализация логгера
+	if err := logger.Initialize("debug"); err != nil {
+		t.Fatalf("logger initialization failed: %v", err)
+	}
+
+	// Создаём контроллер
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Создаём мок репозитория в кэширующей обёртке
+	mockRepo := mocks.NewMockRepository(ctrl)
+	cached := cache.New(mockRepo)
+	service := service.NewService(cached)
+
+	r := chi.NewRouter()
+	r.Use(trustednet.CheckTrustedSubnet("192.168.1.0/24"))
+	r.Get("/api/internal/stats", stats.GetHandler(service, zap.L()))
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	t.Run("cache counters are reported", func(t *testing.T) {
+		// Второй запрос отрицательного результата обслуживается из кэша
+		mockRepo.EXPECT().
+			GetRedirectURL(gomock.Any(), "missing").
+			Return(models.URLMapping{}, storage.ErrURLNotFound).
+			Times(1)
+		for i := 0; i < 2; i++ {
+			_, err := cached.GetRedirectURL(context.Background(), "missing")
+			assert.ErrorIs(t, err, storage.ErrURLNotFound)
+		}
+
+		mockRepo.EXPECT().CountURLs(gomock.Any()).Return(10, nil)
+		mockRepo.EXPECT().CountUsers(gomock.Any()).Return(5, nil)
+
+		req, err := http.NewRequest("GET", srv.URL+"/api/internal/stats", nil)
+		assert.NoError(t, err)
+		req.Header.Set("X-Real-IP", "192.168.1.100")
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response models.StatsResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, models.StatsResponse{
+			URLs:  10,
+			Users: 5,
+			Cache: &models.CacheStats{Hits: 1, Misses: 1, Entries: 1},
+		}, response)
+	})
+}
+
+func TestGetHandler_PurgeStats(t *testing.T) {
+	// Инициализация логгера
+	if err := logger.Initialize("debug"); err != nil {
+		t.Fatalf("logger initialization failed: %v", err)
+	}
+
+	// Создаём контроллер
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Каждый проход очистки удаляет одну запись
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().PurgeURLs(gomock.Any(), gomock.Any(), 100).Return(1, nil).AnyTimes()
+	mockRepo.EXPECT().CountURLs(gomock.Any()).Return(10, nil).AnyTimes()
+	mockRepo.EXPECT().CountUsers(gomock.Any()).Return(5, nil).AnyTimes()
+
+	service := service.NewService(mockRepo, service.WithPurgeWorker(10*time.Millisecond, time.Hour, 100))
+	defer service.GracefulStop(time.Second)
+
+	r := chi.NewRouter()
+	r.Use(trustednet.CheckTrustedSubnet("192.168.1.0/24"))
+	r.Get("/api/internal/stats", stats.GetHandler(service, zap.L()))
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	t.Run("purge counters are reported", func(t *testing.T) {
+		var response models.StatsResponse
+		assert.Eventually(t, func() bool {
+			req, err := http.NewRequest("GET", srv.URL+"/api/internal/stats", nil)
+			if err != nil {
+				return false
+			}
+			req.Header.Set("X-Real-IP", "192.168.1.100")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return false
+			}
+			defer resp.Body.Close()
+
+			return resp.StatusCode == http.StatusOK &&
+				json.NewDecoder(resp.Body).Decode(&response) == nil &&
+				response.Purge != nil && response.Purge.Runs >= 2
+		}, 5*time.Second, 20*time.Millisecond)
+
+		if assert.NotNil(t, response.Purge) {
+			// Проход может выполняться во время запроса статистики
+			assert.GreaterOrEqual(t, response.Purge.Purged, response.Purge.Runs-1)
+			assert.LessOrEqual(t, response.Purge.Purged, response.Purge.Runs)
+			assert.Zero(t, response.Purge.Errors)
+		}
+		assert.Nil(t, response.Cache)
+	})
+}
@@ -0,0 +1,114 @@
+// Package urlstats предоставляет обработчик для получения статистики переходов по ссылке.
+//
+// Пакет реализует:
+// - Получение агрегированной статистики переходов по короткой ссылке
+// - Проверку, что ссылка принадлежит авторизованному пользователю
+// - Возврат данных в JSON-формате
+package urlstats
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// URLHandler определяет контракт для получения статистики переходов.
+type URLHandler interface {
+	// GetURLStats возвращает статистику переходов по ссылке пользователя.
+	//
+	// Параметры:
+	//   ctx - контекст выполнения (должен содержать идентификатор пользователя)
+	//   shortKey - короткий идентификатор URL
+	//   baseURL - базовый адрес для построения полного короткого URL
+	//
+	// Возвращает:
+	//   models.URLStats - агрегированная статистика переходов
+	//   error - возможные ошибки:
+	//     - storage.ErrURLNotFound: ссылка не существует или принадлежит другому пользователю
+	//     - другие внутренние ошибки
+	GetURLStats(ctx context.Context, shortKey string, baseURL string) (models.URLStats, error)
+}
+
+// GetHandler создаёт HTTP-обработчик для получения статистики переходов по ссылке.
+//
+// Спецификация API:
+//
+//	Метод: GET
+//	Путь: /api/user/urls/{id}/stats
+//	Требуется: JWT-аутентификация
+//
+// Формат ответа:
+//
+//	{
+//	  "short_url": "http://short.ly/abc123",
+//	  "original_url": "https://example.com/long/url",
+//	  "clicks": 42,
+//	  "unique_visitors": 17,
+//	  "first_click_at": "2025-01-01T10:00:00Z",
+//	  "last_click_at": "2025-01-02T18:30:00Z",
+//	  "top_referrers": [{"value": "https://news.example", "clicks": 30}],
+//	  "top_user_agents": [{"value": "Mozilla/5.0", "clicks": 40}]
+//	}
+//
+// Коды ответа:
+//   - 200 OK: успешный запрос
+//   - 404 Not Found: ссылка не существует или принадлежит другому пользователю
+//   - 500 Internal Server Error: внутренняя ошибка сервера
+//
+// Переходы записываются асинхронно, поэтому статистика может отставать на несколько секунд.
+//
+// Параметры:
+//
+//	urlHandler - сервис для получения статистики
+//	baseURL - базовый адрес сервиса
+//	log - логгер для записи событий
+//
+// Возвращает:
+//
+//	http.HandlerFunc - HTTP-обработчик
+func GetHandler(urlHandler URLHandler, baseURL string, log *zap.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+
+		stats, err := urlHandler.GetURLStats(req.Context(), id, baseURL)
+		if err != nil {
+			if rule, ok := errmap.Lookup(err); ok {
+				rule.WriteHTTPError(res, err)
+				log.Info(rule.Text(err),
+					zap.String("shortKey", id),
+					zap.String("method", req.Method),
+					zap.String("path", req.URL.Path))
+				return
+			}
+			http.Error(res, "Failed to get URL stats", http.StatusInternalServerError)
+			log.Error("Failed to get URL stats",
+				zap.Error(err),
+				zap.String("shortKey", id),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(res).Encode(stats); err != nil {
+			log.Error("Failed to encode response",
+				zap.Error(err),
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path))
+		}
+
+		log.Debug("Successfully returned URL stats",
+			zap.String("shortKey", id),
+			zap.Int64("clicks", stats.Clicks),
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path))
+	}
+}
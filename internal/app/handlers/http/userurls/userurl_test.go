@@ -43,4 +43,5 @@ func TestGetHandler_InMemory(t *testing.T) {
 	defer tc.Close()
 
 	testhandlers.TestUserUrls(t, service, tc.Client)
+	testhandlers.TestUserUrlsPagination(t, service, tc.Client)
 }
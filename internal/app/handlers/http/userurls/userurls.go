@@ -1,7 +1,9 @@
 // Package userurls предоставляет обработчик для получения списка URL пользователя.
 //
 // Пакет реализует:
-// - Получение всех сокращённых URL авторизованного пользователя
+// - Получение сокращённых URL авторизованного пользователя
+// - Постраничную выдачу с курсором
+// - Поиск по оригинальному URL, фильтр по дате создания и выбор порядка сортировки
 // - Возврат данных в JSON-формате
 // - Обработку случая отсутствия URL
 package userurls
@@ -9,25 +11,35 @@ package userurls
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
 	"github.com/ryabkov82/shortener/internal/app/models"
 )
 
+// NextCursorHeader - заголовок ответа с курсором следующей страницы.
+const NextCursorHeader = "X-Next-Cursor"
+
 // URLHandler определяет контракт для получения URL пользователя.
 type URLHandler interface {
-	// GetUserUrls возвращает все сокращённые URL пользователя.
+	// GetUserUrls возвращает страницу сокращённых URL пользователя.
 	//
 	// Параметры:
 	//   ctx - контекст выполнения (должен содержать идентификатор пользователя)
 	//   baseURL - базовый адрес для построения полных коротких URL
+	//   query - фильтры, порядок и страница
 	//
 	// Возвращает:
-	//   []models.URLMapping - список сопоставлений оригинальных и коротких URL
-	//   error - ошибка выполнения (например, проблемы с хранилищем)
-	GetUserUrls(ctx context.Context, baseURL string) ([]models.URLMapping, error)
+	//   models.UserURLsPage - сопоставления оригинальных и коротких URL и курсор следующей страницы
+	//   error - service.ErrInvalidUserURLsQuery при неверных параметрах
+	//     или другая ошибка выполнения (например, проблемы с хранилищем)
+	GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error)
 }
 
 // GetHandler создаёт HTTP-обработчик для получения URL пользователя.
@@ -38,20 +50,33 @@ type URLHandler interface {
 //	Путь: /api/user/urls
 //	Требуется: JWT-аутентификация
 //
+// Параметры запроса (все необязательные):
+//
+//	limit - размер страницы, от 1 до 1000 (без параметра возвращаются все URL)
+//	cursor - курсор следующей страницы из заголовка X-Next-Cursor
+//	search - подстрока оригинального URL (без учёта регистра)
+//	created_from, created_to - диапазон дат создания в RFC 3339 ([from, to))
+//	order - порядок по дате создания: asc (по умолчанию) или desc
+//
 // Формат ответа:
 //
 //	[
 //	  {
 //	    "short_url": "http://short.ly/abc123",
-//	    "original_url": "https://example.com/long/url"
+//	    "original_url": "https://example.com/long/url",
+//	    "created_at": "2025-01-01T00:00:00Z"
 //	  },
 //	  ...
 //	]
 //
+// Если есть следующая страница, её курсор передаётся в заголовке X-Next-Cursor.
+// Следующую страницу нужно запрашивать с теми же фильтрами и порядком.
+//
 // Коды ответа:
 //   - 200 OK: успешный запрос (возвращает список URL)
-//   - 204 No Content: у пользователя нет сохранённых URL
-//   - 400 Bad Request: ошибка аутентификации
+//   - 204 No Content: у пользователя нет URL, подходящих под фильтры
+//   - 400 Bad Request: неверные параметры запроса
+//   - 401 Unauthorized: пользователь не аутентифицирован
 //   - 500 Internal Server Error: внутренняя ошибка сервера
 //
 // Параметры:
@@ -65,8 +90,18 @@ type URLHandler interface {
 //	http.HandlerFunc - HTTP-обработчик
 func GetHandler(urlHandler URLHandler, baseURL string, log *zap.Logger) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
+		query, err := parseQuery(req.URL.Query())
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Получение данных из хранилища
-		responseData, err := urlHandler.GetUserUrls(req.Context(), baseURL)
+		page, err := urlHandler.GetUserUrls(req.Context(), baseURL, query)
+		if rule, ok := errmap.Lookup(err); ok {
+			rule.WriteHTTPError(res, err)
+			return
+		}
 		if err != nil {
 			http.Error(res, "Failed to get user URLs", http.StatusInternalServerError)
 			log.Error("Failed to retrieve user URLs",
@@ -76,6 +111,11 @@ func GetHandler(urlHandler URLHandler, baseURL string, log *zap.Logger) http.Han
 			return
 		}
 
+		responseData := page.URLs
+		if page.NextCursor != "" {
+			res.Header().Set(NextCursorHeader, page.NextCursor)
+		}
+
 		// Обработка случая отсутствия URL
 		if len(responseData) == 0 {
 			res.WriteHeader(http.StatusNoContent)
@@ -104,3 +144,39 @@ func GetHandler(urlHandler URLHandler, baseURL string, log *zap.Logger) http.Han
 			zap.String("path", req.URL.Path))
 	}
 }
+
+// parseQuery разбирает параметры запроса списка URL.
+// Допустимость значений (диапазон limit, порядок) проверяет сервис.
+func parseQuery(values url.Values) (models.UserURLsQuery, error) {
+	query := models.UserURLsQuery{
+		Cursor: values.Get("cursor"),
+		Search: values.Get("search"),
+		Order:  values.Get("order"),
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		v, err := strconv.Atoi(limit)
+		if err != nil || v < 1 {
+			return query, fmt.Errorf("invalid limit: %q", limit)
+		}
+		query.Limit = v
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_from", &query.CreatedFrom},
+		{"created_to", &query.CreatedTo},
+	} {
+		if value := values.Get(p.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s: %q", p.name, value)
+			}
+			*p.dst = &t
+		}
+	}
+
+	return query, nil
+}
@@ -4,8 +4,11 @@
 // - Модели для хранения URL
 // - Модели для пакетной обработки
 // - Структуры для API-ответов
+// - Модели статистики переходов
 package models
 
+import "time"
+
 // URLMapping представляет соответствие между коротким и оригинальным URL.
 //
 // Используется в API-ответах при:
@@ -19,8 +22,20 @@ package models
 //	  "original_url": "https://example.com/long/url"
 //	}
 type URLMapping struct {
-	ShortURL    string `json:"short_url"`    // Полный сокращённый URL
-	OriginalURL string `json:"original_url"` // Оригинальный длинный URL
+	ShortURL    string     `json:"short_url"`            // Полный сокращённый URL
+	OriginalURL string     `json:"original_url"`         // Оригинальный длинный URL
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // Момент истечения срока действия (nil - бессрочно)
+	CreatedAt   *time.Time `json:"created_at,omitempty"` // Момент создания (заполняется в списке URL пользователя)
+}
+
+// ShortenOptions содержит необязательные параметры создания короткой ссылки.
+//
+// Срок действия задаётся либо абсолютным моментом ExpiresAt,
+// либо относительным TTLSeconds - одновременно указывать оба нельзя.
+type ShortenOptions struct {
+	CustomAlias string     // Пользовательский псевдоним (пусто - сгенерировать)
+	ExpiresAt   *time.Time // Абсолютный момент истечения срока действия
+	TTLSeconds  int64      // Время жизни ссылки в секундах
 }
 
 // UserURLMapping расширяет URLMapping информацией о пользователе и статусе.
@@ -29,6 +44,9 @@ type URLMapping struct {
 // - UUID - уникальный идентификатор записи
 // - UserID - идентификатор пользователя-владельца
 // - DeletedFlag - флаг мягкого удаления
+// - ExpiresAt - момент истечения срока действия ссылки
+// - DeletedAt - момент мягкого удаления
+// - CreatedAt - момент создания ссылки
 //
 // Используется в:
 // - Системе хранения URL
@@ -39,6 +57,42 @@ type UserURLMapping struct {
 	UserID      string `json:"user_id"`
 	UUID        uint64 `json:"uuid"`
 	DeletedFlag bool   `json:"is_deleted"`
+	// ExpiresAt - момент истечения срока действия (nil - бессрочно)
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// DeletedAt - момент мягкого удаления (nil - запись не удалялась)
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// CreatedAt - момент создания (nil - запись создана до появления поля)
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// Порядок сортировки списка URL пользователя по моменту создания.
+const (
+	SortAsc  = "asc"  // Сначала старые ссылки
+	SortDesc = "desc" // Сначала новые ссылки
+)
+
+// UserURLsQuery задаёт фильтры, сортировку и страницу списка URL пользователя.
+//
+// Используется в API:
+//
+//	GET /api/user/urls?limit=20&search=example&created_from=2025-01-01T00:00:00Z&order=desc
+//	GET /api/user/urls?limit=20&cursor=<X-Next-Cursor предыдущей страницы>
+//
+// Курсор запоминает позицию последней выданной записи, поэтому следующую
+// страницу нужно запрашивать с теми же фильтрами и порядком.
+type UserURLsQuery struct {
+	Limit       int        // Размер страницы (0 - все записи)
+	Cursor      string     // Курсор следующей страницы ("" - с начала)
+	Search      string     // Подстрока оригинального URL (без учёта регистра)
+	CreatedFrom *time.Time // Нижняя граница момента создания (включительно)
+	CreatedTo   *time.Time // Верхняя граница момента создания (не включительно)
+	Order       string     // Порядок по моменту создания: SortAsc (по умолчанию) или SortDesc
+}
+
+// UserURLsPage - страница списка URL пользователя.
+type UserURLsPage struct {
+	URLs       []URLMapping // URL страницы
+	NextCursor string       // Курсор следующей страницы ("" - страница последняя)
 }
 
 // BatchRequest представляет элемент запроса для пакетного создания URL.
@@ -51,11 +105,16 @@ type UserURLMapping struct {
 //
 //	{
 //	  "correlation_id": "123e4567",
-//	  "original_url": "https://example.com"
+//	  "original_url": "https://example.com",
+//	  "ttl_seconds": 3600
 //	}
+//
+// Поля expires_at и ttl_seconds необязательны и взаимоисключающие.
 type BatchRequest struct {
-	CorrelationID string `json:"correlation_id"` // Уникальный ID для сопоставления запроса/ответа
-	OriginalURL   string `json:"original_url"`   // URL для сокращения
+	CorrelationID string     `json:"correlation_id"`        // Уникальный ID для сопоставления запроса/ответа
+	OriginalURL   string     `json:"original_url"`          // URL для сокращения
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`  // Абсолютный момент истечения срока действия
+	TTLSeconds    int64      `json:"ttl_seconds,omitempty"` // Время жизни ссылки в секундах
 }
 
 // BatchResponse представляет элемент ответа при пакетном создании URL.
@@ -63,6 +122,8 @@ type BatchRequest struct {
 // Содержит:
 // - CorrelationID из исходного запроса
 // - Сгенерированный короткий URL
+// - Описание ошибки, если элемент не удалось сохранить (short_url при этом пуст,
+// кроме URL, уже сокращённого пользователем: тогда в short_url существующая ссылка)
 //
 // Пример JSON:
 //
@@ -71,13 +132,175 @@ type BatchRequest struct {
 //	  "short_url": "http://short.ly/abc"
 //	}
 type BatchResponse struct {
-	CorrelationID string `json:"correlation_id"` // Соответствует ID из запроса
-	ShortURL      string `json:"short_url"`      // Полный сокращённый URL
+	CorrelationID string `json:"correlation_id"`  // Соответствует ID из запроса
+	ShortURL      string `json:"short_url"`       // Полный сокращённый URL
+	Error         string `json:"error,omitempty"` // Причина, по которой элемент не сохранён
+}
+
+// DeleteTask представляет запрос на удаление (или восстановление) нескольких
+// сокращенных URL пользователя.
+type DeleteTask struct {
+	ID        int64    // Идентификатор задачи в постоянной очереди (0 - задача не сохранялась)
+	UserID    string   // ID пользователя, инициировавшего запрос
+	ShortURLs []string // Список сокращенных URL для пометки как удаленных
+	Restore   bool     // Снять пометку удаления вместо её установки
+}
+
+// DeadLetter описывает задачу удаления, не выполненную после всех повторных попыток.
+//
+// Пример JSON:
+//
+//	{
+//	  "id": 1,
+//	  "user_id": "8fc2785a-1bd1-49c0-9bfb-428a93b42030",
+//	  "short_urls": ["abc", "def"],
+//	  "attempts": 3,
+//	  "last_error": "connection refused",
+//	  "failed_at": "2025-01-01T00:00:00Z"
+//	}
+type DeadLetter struct {
+	ID        int64     `json:"id"`         // Идентификатор записи
+	UserID    string    `json:"user_id"`    // ID пользователя, запросившего удаление
+	ShortURLs []string  `json:"short_urls"` // Необработанные короткие URL
+	Attempts  int       `json:"attempts"`   // Количество выполненных попыток
+	LastError string    `json:"last_error"` // Ошибка последней попытки
+	FailedAt  time.Time `json:"failed_at"`  // Момент перевода в dead-letter
+	// Restore - задача восстановления URL
+	Restore bool `json:"restore,omitempty"`
+	// TaskIDs - задачи постоянной очереди, подтверждаемые после повторной обработки
+	TaskIDs []int64 `json:"-"`
+}
+
+// DeleteResult содержит результат пакетного удаления URL пользователя.
+// Каждый код из запроса попадает ровно в один из списков.
+type DeleteResult struct {
+	Deleted  []string // Коды, помеченные удалёнными этим вызовом
+	NotOwned []string // Коды, принадлежащие другому пользователю
+	Skipped  []string // Коды, которые не найдены или уже были удалены
+}
+
+// Статусы задания на удаление URL.
+const (
+	DeleteJobPending = "pending" // Часть кодов ещё не обработана
+	DeleteJobDone    = "done"    // Все коды обработаны успешно
+	DeleteJobFailed  = "failed"  // Все коды обработаны, часть из них удалить не удалось
+)
+
+// DeleteResponse - ответ на запрос удаления URL пользователя.
+type DeleteResponse struct {
+	JobID string `json:"job_id"` // Идентификатор задания для отслеживания хода удаления
+}
+
+// DeleteJob описывает ход выполнения запроса на удаление или восстановление URL пользователя.
+//
+// При удалении коды, которые не найдены или уже были удалены, считаются
+// обработанными (done). При восстановлении обработанными считаются и коды,
+// которые не были удалены, а в failed попадают коды, не найденные или удалённые
+// раньше окна восстановления. В обоих случаях в failed попадают коды,
+// принадлежащие другому пользователю, и коды, не обработанные после всех
+// повторных попыток.
+//
+// Пример JSON:
+//
+//	{
+//	  "id": "5a1c3c2e-2d1f-4f7e-9a53-6f0f0a5b8f61",
+//	  "status": "pending",
+//	  "pending": ["def"],
+//	  "done": ["abc"],
+//	  "failed": [],
+//	  "created_at": "2025-01-01T00:00:00Z",
+//	  "updated_at": "2025-01-01T00:00:01Z"
+//	}
+type DeleteJob struct {
+	ID        string    `json:"id"`         // Идентификатор задания
+	UserID    string    `json:"-"`          // ID пользователя, создавшего задание
+	Status    string    `json:"status"`     // Статус задания (DeleteJobPending, DeleteJobDone, DeleteJobFailed)
+	Pending   []string  `json:"pending"`    // Коды, ожидающие обработки
+	Done      []string  `json:"done"`       // Удалённые коды
+	Failed    []string  `json:"failed"`     // Коды, которые удалить не удалось
+	CreatedAt time.Time `json:"created_at"` // Момент создания задания
+	UpdatedAt time.Time `json:"updated_at"` // Момент последнего изменения
+	// Restore - задание на восстановление URL
+	Restore bool `json:"restore,omitempty"`
+}
+
+// RestoreResult содержит результат пакетного восстановления URL пользователя.
+// Каждый код из запроса попадает ровно в один из списков.
+type RestoreResult struct {
+	Restored    []string // Коды, восстановленные этим вызовом
+	Active      []string // Коды, которые не были удалены
+	NotOwned    []string // Коды, принадлежащие другому пользователю
+	Unavailable []string // Коды, которые не найдены или удалены раньше окна восстановления
 }
 
 // StatsResponse представляет структуру ответа для эндпоинта статистики.
 // Используется в обработчике stats.GetHandler.
 type StatsResponse struct {
-	URLs  int `json:"urls"`  // количество сокращённых URL в сервисе
-	Users int `json:"users"` // количество пользователей в сервисе
+	URLs  int         `json:"urls"`            // количество сокращённых URL в сервисе
+	Users int         `json:"users"`           // количество пользователей в сервисе
+	Cache *CacheStats `json:"cache,omitempty"` // счётчики кэша редиректов (nil - кэш отключён)
+	Purge *PurgeStats `json:"purge,omitempty"` // счётчики фоновой очистки (nil - очистка отключена)
+}
+
+// CacheStats содержит счётчики кэша редиректов.
+type CacheStats struct {
+	Hits    int64 `json:"hits"`    // количество ответов из кэша
+	Misses  int64 `json:"misses"`  // количество обращений к хранилищу
+	Entries int   `json:"entries"` // текущее количество записей кэша
+}
+
+// PurgeStats содержит счётчики фоновой очистки удалённых и истёкших ссылок.
+type PurgeStats struct {
+	Runs   int64 `json:"runs"`   // количество выполненных проходов очистки
+	Purged int64 `json:"purged"` // количество физически удалённых записей
+	Errors int64 `json:"errors"` // количество ошибок при обращении к хранилищу
+}
+
+// ClickEvent описывает один переход по короткой ссылке.
+//
+// Сырой IP-адрес клиента (ClientIP) не сохраняется: перед записью в хранилище
+// он заменяется префиксом сети IPPrefix (/24 для IPv4, /48 для IPv6).
+type ClickEvent struct {
+	ShortURL  string    `json:"short_url"`            // Короткий идентификатор URL
+	At        time.Time `json:"at"`                   // Момент перехода
+	Referrer  string    `json:"referrer,omitempty"`   // Заголовок Referer
+	UserAgent string    `json:"user_agent,omitempty"` // Заголовок User-Agent
+	IPPrefix  string    `json:"ip_prefix,omitempty"`  // Обезличенный префикс сети клиента
+	ClientIP  string    `json:"-"`                    // IP-адрес клиента (только для вычисления IPPrefix)
+}
+
+// ClickCount представляет количество переходов для одного значения измерения
+// (источника перехода или User-Agent).
+type ClickCount struct {
+	Value  string `json:"value"`  // Значение измерения
+	Clicks int64  `json:"clicks"` // Количество переходов
+}
+
+// URLStats представляет агрегированную статистику переходов по короткой ссылке.
+//
+// Используется в API:
+//
+//	GET /api/user/urls/{id}/stats
+//
+// Пример JSON:
+//
+//	{
+//	  "short_url": "http://short.ly/abc",
+//	  "original_url": "https://example.com",
+//	  "clicks": 42,
+//	  "unique_visitors": 17,
+//	  "first_click_at": "2025-01-01T10:00:00Z",
+//	  "last_click_at": "2025-01-02T18:30:00Z",
+//	  "top_referrers": [{"value": "https://news.example", "clicks": 30}],
+//	  "top_user_agents": [{"value": "Mozilla/5.0", "clicks": 40}]
+//	}
+type URLStats struct {
+	ShortURL       string       `json:"short_url"`                 // Полный сокращённый URL
+	OriginalURL    string       `json:"original_url"`              // Оригинальный длинный URL
+	Clicks         int64        `json:"clicks"`                    // Общее количество переходов
+	UniqueVisitors int64        `json:"unique_visitors"`           // Количество различных префиксов сети клиентов
+	FirstClickAt   *time.Time   `json:"first_click_at,omitempty"`  // Момент первого перехода
+	LastClickAt    *time.Time   `json:"last_click_at,omitempty"`   // Момент последнего перехода
+	TopReferrers   []ClickCount `json:"top_referrers,omitempty"`   // Самые частые источники переходов
+	TopUserAgents  []ClickCount `json:"top_user_agents,omitempty"` // Самые частые User-Agent
 }
@@ -0,0 +1,121 @@
+package grpcserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ryabkov82/shortener/api"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Dependency - зависимость сервиса, состояние которой публикуется
+// в grpc.health.v1 под именем Name.
+type Dependency struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthChecker публикует состояние сервиса через стандартный сервис grpc.health.v1.
+//
+// Каждая зависимость проверяется раз в interval и получает собственный статус.
+// Общий статус ("") и статус сервиса shortener.Shortener - SERVING, только
+// если доступны все зависимости. После Shutdown все статусы остаются
+// NOT_SERVING, чтобы оркестратор перестал направлять запросы до остановки сервера.
+type HealthChecker struct {
+	server   *health.Server
+	deps     []Dependency
+	interval time.Duration
+	log      *zap.Logger
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewHealthChecker создает проверку состояния для указанных зависимостей.
+// Проверки начинаются после вызова Start.
+func NewHealthChecker(log *zap.Logger, interval time.Duration, deps ...Dependency) *HealthChecker {
+	h := &HealthChecker{
+		server:   health.NewServer(),
+		deps:     deps,
+		interval: interval,
+		log:      log,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	// До первой проверки состояние неизвестно
+	h.setAll(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// Register регистрирует сервис grpc.health.v1 на gRPC-сервере.
+func (h *HealthChecker) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h.server)
+}
+
+// Start выполняет первую проверку зависимостей и запускает периодические проверки.
+func (h *HealthChecker) Start() {
+	h.check()
+
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				h.check()
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown останавливает проверки и переводит все статусы в NOT_SERVING.
+// Подписчики Watch получают новый статус сразу.
+func (h *HealthChecker) Shutdown() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+		h.server.Shutdown()
+	})
+}
+
+// check проверяет зависимости и обновляет их статусы.
+func (h *HealthChecker) check() {
+	overall := healthpb.HealthCheckResponse_SERVING
+	for _, dep := range h.deps {
+		ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+		err := dep.Check(ctx)
+		cancel()
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			h.log.Warn("Health check failed",
+				zap.String("dependency", dep.Name),
+				zap.Error(err))
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			overall = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		h.server.SetServingStatus(dep.Name, status)
+	}
+
+	h.server.SetServingStatus("", overall)
+	h.server.SetServingStatus(api.Shortener_ServiceDesc.ServiceName, overall)
+}
+
+// setAll устанавливает одинаковый статус для сервиса и всех зависимостей.
+func (h *HealthChecker) setAll(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, dep := range h.deps {
+		h.server.SetServingStatus(dep.Name, status)
+	}
+	h.server.SetServingStatus("", status)
+	h.server.SetServingStatus(api.Shortener_ServiceDesc.ServiceName, status)
+}
@@ -28,6 +28,11 @@
 //
 //   - Обработка ошибок
 //
+// Для потоковых методов предусмотрены аналоги с суффиксом Stream
+// (LoggingStreamInterceptor, JWTAutoIssueStreamGRPC, StrictJWTAutoIssueStreamGRPC,
+// TrustedSubnetStreamInterceptor). Проверки выполняются один раз при открытии
+// потока, а UserID передаётся обработчику через контекст потока.
+//
 // Все интерцепторы:
 //   - Поддерживают цепочки вызовов (chaining)
 //   - Совместимы со стандартными интерцепторами gRPC
@@ -42,5 +47,10 @@
 //	        interceptor.TrustedSubnet("192.168.1.0/24"),
 //	        interceptor.Logging(zapLogger),
 //	    ),
+//	    grpc.ChainStreamInterceptor(
+//	        interceptor.LoggingStream(zapLogger),
+//	        interceptor.JWTStream([]byte(secret)),
+//	        interceptor.TrustedSubnetStream("192.168.1.0/24"),
+//	    ),
 //	)
 package interceptors
@@ -2,6 +2,7 @@ package interceptors
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/ryabkov82/shortener/internal/app/jwtauth"
@@ -151,7 +152,141 @@ func StrictJWTAutoIssueGRPC(jwtKey []byte, log *zap.Logger) grpc.UnaryServerInte
 	}
 }
 
+// JWTAutoIssueStreamGRPC возвращает потоковый вариант JWTAutoIssueGRPC.
+//
+// Токен проверяется один раз при открытии потока. Если токена нет или он
+// невалиден, выдаётся новый токен (в заголовках ответа потока), а UserID
+// передаётся обработчику через контекст потока (ServerStream.Context).
+//
+// Параметры:
+//   - jwtKey: секретный ключ для подписи JWT-токенов
+//   - log: логгер zap
+//
+// Возвращает:
+//   - grpc.StreamServerInterceptor: настроенный интерцептор аутентификации
+func JWTAutoIssueStreamGRPC(jwtKey []byte, log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		// Пропускаем аутентификацию для публичных методов
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx := ss.Context()
+		claims, err := tokenClaims(ctx, jwtKey)
+		if err == nil {
+			return handler(srv, withUserID(ss, claims.UserID))
+		}
+		if errors.Is(err, errNoToken) {
+			log.Debug("no token provided",
+				zap.String("method", info.FullMethod))
+		} else {
+			log.Warn("invalid token",
+				zap.String("method", info.FullMethod),
+				zap.Error(err))
+		}
+
+		// Выдача нового токена (аналог issueNewTokenAndHandle)
+		token, userID, err := jwtauth.GenerateNewToken(jwtKey)
+		if err != nil {
+			log.Error("failed to issue new token",
+				zap.String("method", info.FullMethod),
+				zap.Error(err))
+			return status.Errorf(codes.Internal, "authentication error")
+		}
+		if err := ss.SetHeader(metadata.Pairs("token", token)); err != nil {
+			log.Error("failed to set token header",
+				zap.String("method", info.FullMethod),
+				zap.Error(err))
+		}
+
+		return handler(srv, withUserID(ss, userID))
+	}
+}
+
+// StrictJWTAutoIssueStreamGRPC возвращает потоковый вариант StrictJWTAutoIssueGRPC.
+//
+// Без валидного токена поток отклоняется с кодом Unauthenticated, а новый
+// токен передаётся в заголовках ответа, чтобы клиент мог повторить вызов.
+//
+// Параметры:
+//   - jwtKey: секретный ключ для подписи JWT-токенов
+//   - log: логгер zap
+//
+// Возвращает:
+//   - grpc.StreamServerInterceptor: настроенный интерцептор аутентификации
+func StrictJWTAutoIssueStreamGRPC(jwtKey []byte, log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		// Пропускаем аутентификацию для публичных методов
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx := ss.Context()
+		claims, err := tokenClaims(ctx, jwtKey)
+		if errors.Is(err, errNoToken) {
+			log.Warn("no token provided",
+				zap.String("method", info.FullMethod))
+			_, err = handleMissingToken(ctx, jwtKey, log, info.FullMethod)
+			return err
+		}
+		if err != nil {
+			log.Warn("invalid token",
+				zap.String("method", info.FullMethod),
+				zap.Error(err))
+			_, err = handleInvalidToken(ctx, jwtKey, log, info.FullMethod)
+			return err
+		}
+
+		return handler(srv, withUserID(ss, claims.UserID))
+	}
+}
+
 // Вспомогательные функции
+
+// errNoToken - в метаданных запроса нет токена.
+var errNoToken = errors.New("no token provided")
+
+// tokenClaims извлекает и проверяет токен из метаданных входящего запроса.
+// Возвращает errNoToken, если токена нет, и ошибку проверки, если токен
+// невалиден или не содержит UserID.
+func tokenClaims(ctx context.Context, jwtKey []byte) (*jwtauth.Claims, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, errNoToken
+	}
+	tokens := md.Get("token")
+	if len(tokens) == 0 {
+		return nil, errNoToken
+	}
+
+	claims, err := validateToken(tokens[0], jwtKey)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("empty userID in token")
+	}
+	return claims, nil
+}
+
+// withUserID возвращает поток, контекст которого содержит UserID.
+func withUserID(ss grpc.ServerStream, userID string) grpc.ServerStream {
+	return &serverStream{
+		ServerStream: ss,
+		ctx:          context.WithValue(ss.Context(), jwtauth.UserIDContextKey, userID),
+	}
+}
+
 func validateToken(tokenString string, jwtKey []byte) (*jwtauth.Claims, error) {
 	claims := &jwtauth.Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
@@ -237,6 +372,10 @@ func issueNewTokenAndHandle(
 func isPublicMethod(method string) bool {
 	publicMethods := map[string]bool{
 		"/grpc.health.v1.Health/Check": true,
+		"/grpc.health.v1.Health/Watch": true,
+		// Рефлексия (grpcurl)
+		"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      true,
+		"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
 		// Другие публичные методы
 	}
 	return publicMethods[method]
@@ -75,3 +75,41 @@ func LoggingInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
 		return handler(ctx, req)
 	}
 }
+
+// LoggingStreamInterceptor возвращает потоковый gRPC-интерцептор логирования,
+// аналогичный LoggingInterceptor.
+//
+// Сообщение записывается после завершения потока; вместо параметров запроса
+// фиксируется направление потока (client_stream, server_stream).
+//
+// Параметры:
+//   - log: логгер zap для записи сообщений
+//
+// Возвращает:
+//   - grpc.StreamServerInterceptor: настроенный интерцептор логирования
+func LoggingStreamInterceptor(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) (err error) {
+		startTime := time.Now()
+
+		defer func() {
+			duration := time.Since(startTime)
+			st, _ := status.FromError(err)
+
+			log.Info("gRPC stream completed",
+				zap.String("method", info.FullMethod),
+				zap.Bool("client_stream", info.IsClientStream),
+				zap.Bool("server_stream", info.IsServerStream),
+				zap.Int("status_code", int(st.Code())),
+				zap.String("status", st.Code().String()),
+				zap.String("duration", duration.String()),
+			)
+		}()
+
+		return handler(srv, ss)
+	}
+}
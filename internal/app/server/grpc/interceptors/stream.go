@@ -0,0 +1,20 @@
+package interceptors
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// serverStream подменяет контекст потока, чтобы потоковые интерцепторы
+// могли передавать обработчику значения (например, UserID), как это делают
+// унарные интерцепторы через ctx.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context возвращает контекст потока с добавленными интерцепторами значениями.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
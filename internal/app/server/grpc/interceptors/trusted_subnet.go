@@ -99,39 +99,66 @@ func TrustedSubnetInterceptor(cfg TrustedSubnetConfig) grpc.UnaryServerIntercept
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		// Проверяем, требуется ли проверка для этого метода
-		if !cfg.isMethodProtected(info.FullMethod) {
-			return handler(ctx, req)
+		if err := cfg.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
 		}
+		return handler(ctx, req)
+	}
+}
 
-		// Если подсеть не задана
-		if cfg.TrustedSubnet == "" {
-			if cfg.DenyIfNotConfigured {
-				return nil, status.Error(codes.PermissionDenied, "trusted subnet not configured")
-			}
-			return handler(ctx, req)
+// TrustedSubnetStreamInterceptor возвращает потоковый вариант TrustedSubnetInterceptor.
+//
+// Проверка выполняется один раз при открытии потока по тем же правилам
+// (TrustedSubnetConfig), что и для унарных методов.
+func TrustedSubnetStreamInterceptor(cfg TrustedSubnetConfig) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := cfg.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
 		}
+		return handler(srv, ss)
+	}
+}
 
-		// Получаем IP клиента
-		clientIP, err := getClientIP(ctx)
-		if err != nil {
-			return nil, status.Error(codes.PermissionDenied, err.Error())
-		}
+// authorize проверяет доступ к методу. Возвращает nil, если вызов разрешён,
+// иначе - gRPC-статус с причиной отказа.
+func (c *TrustedSubnetConfig) authorize(ctx context.Context, method string) error {
+	// Проверяем, требуется ли проверка для этого метода
+	if !c.isMethodProtected(method) {
+		return nil
+	}
 
-		// Парсим доверенную подсеть
-		_, subnet, err := net.ParseCIDR(cfg.TrustedSubnet)
-		if err != nil {
-			return nil, status.Error(codes.Internal, "invalid trusted subnet configuration")
+	// Если подсеть не задана
+	if c.TrustedSubnet == "" {
+		if c.DenyIfNotConfigured {
+			return status.Error(codes.PermissionDenied, "trusted subnet not configured")
 		}
+		return nil
+	}
 
-		// Проверяем принадлежность IP к подсети
-		ip := net.ParseIP(clientIP)
-		if ip == nil || !subnet.Contains(ip) {
-			return nil, status.Error(codes.PermissionDenied, "access denied: IP not in trusted subnet")
-		}
+	// Получаем IP клиента
+	clientIP, err := ClientIP(ctx)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
 
-		return handler(ctx, req)
+	// Парсим доверенную подсеть
+	_, subnet, err := net.ParseCIDR(c.TrustedSubnet)
+	if err != nil {
+		return status.Error(codes.Internal, "invalid trusted subnet configuration")
 	}
+
+	// Проверяем принадлежность IP к подсети
+	ip := net.ParseIP(clientIP)
+	if ip == nil || !subnet.Contains(ip) {
+		return status.Error(codes.PermissionDenied, "access denied: IP not in trusted subnet")
+	}
+
+	return nil
 }
 
 func (c *TrustedSubnetConfig) isMethodProtected(method string) bool {
@@ -150,7 +177,11 @@ func (c *TrustedSubnetConfig) isMethodProtected(method string) bool {
 	return false
 }
 
-func getClientIP(ctx context.Context) (string, error) {
+// ClientIP определяет IP-адрес клиента gRPC-запроса.
+//
+// Порядок поиска: метаданные x-forwarded-for (первый адрес), x-real-ip,
+// затем адрес соединения из peer.
+func ClientIP(ctx context.Context) (string, error) {
 	// 1. Пробуем получить из X-Forwarded-For (если есть прокси)
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		if forwardedIPs := md.Get("x-forwarded-for"); len(forwardedIPs) > 0 {
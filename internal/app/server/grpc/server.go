@@ -8,22 +8,30 @@ import (
 	grpchandlers "github.com/ryabkov82/shortener/internal/app/handlers/grpc"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/batch"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/deadletters"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/deletejob"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/deluserurls"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/ping"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/redirect"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/replaydeadletters"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/restoreurls"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/shorturl"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/stats"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/streamcreate"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/streamuserurls"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/urlstats"
 	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/userurls"
 	"github.com/ryabkov82/shortener/internal/app/service"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
-// StartGRPCServer создает и запускает gRPC сервер
-func StartGRPCServer(log *zap.Logger, cfg *config.Config, srv *service.Service) *grpc.Server {
-
-	// Создаем базовый обработчик с общими зависимостями
-	baseHandler := base.NewBaseHandler(log)
+// NewHandler создает агрегированный обработчик сервиса Shortener.
+//
+// Обработчик используется gRPC сервером и REST-шлюзом HTTP сервера,
+// поэтому оба транспорта вызывают одни и те же обработчики методов.
+func NewHandler(baseHandler *base.BaseHandler, cfg *config.Config, srv *service.Service) *grpchandlers.Server {
 
 	// Инициализация конкретных обработчиков
 	shorturlHandler := shorturl.New(
@@ -48,12 +56,23 @@ func StartGRPCServer(log *zap.Logger, cfg *config.Config, srv *service.Service)
 		srv,
 	)
 
+	restoreurlsHandler := restoreurls.New(
+		baseHandler,
+		srv,
+	)
+
 	userurlsHandler := userurls.New(
 		baseHandler,
 		srv,
 		cfg.BaseURL,
 	)
 
+	urlstatsHandler := urlstats.New(
+		baseHandler,
+		srv,
+		cfg.BaseURL,
+	)
+
 	statsHandler := stats.New(
 		baseHandler,
 		srv,
@@ -64,27 +83,87 @@ func StartGRPCServer(log *zap.Logger, cfg *config.Config, srv *service.Service)
 		srv,
 	)
 
+	deletejobHandler := deletejob.New(
+		baseHandler,
+		srv,
+	)
+
+	deadlettersHandler := deadletters.New(
+		baseHandler,
+		srv,
+	)
+
+	replaydeadlettersHandler := replaydeadletters.New(
+		baseHandler,
+		srv,
+	)
+
+	streamcreateHandler := streamcreate.New(
+		baseHandler,
+		srv,
+		cfg.BaseURL,
+	)
+
+	streamuserurlsHandler := streamuserurls.New(
+		baseHandler,
+		srv,
+		cfg.BaseURL,
+	)
+
 	// Создаем агрегированный сервер
-	aggregateHandler := grpchandlers.NewServer(
+	return grpchandlers.NewServer(
 		baseHandler,
 		grpchandlers.WithCreateShortURLEndpoint(shorturlHandler),
 		grpchandlers.WithGetOriginalURLEndpoint(redirectHandler),
 		grpchandlers.WithBatchCreateEndpoint(batchHandler),
 		grpchandlers.WithDeleteUserURLsEndpoint(deluserurlsHandler),
+		grpchandlers.WithRestoreUserURLsEndpoint(restoreurlsHandler),
+		grpchandlers.WithGetDeleteJobEndpoint(deletejobHandler),
 		grpchandlers.WithGetUserURLsEndpoint(userurlsHandler),
+		grpchandlers.WithGetURLStatsEndpoint(urlstatsHandler),
 		grpchandlers.WithGetStatsEndpoint(statsHandler),
 		grpchandlers.WithPingEndpoint(pingHandler),
+		grpchandlers.WithListDeadLettersEndpoint(deadlettersHandler),
+		grpchandlers.WithReplayDeadLettersEndpoint(replaydeadlettersHandler),
+		grpchandlers.WithStreamCreateEndpoint(streamcreateHandler),
+		grpchandlers.WithStreamUserURLsEndpoint(streamuserurlsHandler),
 	)
+}
+
+// StartGRPCServer создает и запускает gRPC сервер.
+//
+// Вместе с сервисом Shortener регистрируется стандартный сервис grpc.health.v1
+// (и сервис рефлексии, если он включён в настройках). Возвращаемый HealthChecker
+// нужно перевести в NOT_SERVING через Shutdown перед остановкой сервера.
+func StartGRPCServer(log *zap.Logger, cfg *config.Config, srv *service.Service) (*grpc.Server, *HealthChecker) {
+
+	// Создаем базовый обработчик с общими зависимостями
+	baseHandler := base.NewBaseHandler(log)
+	aggregateHandler := NewHandler(baseHandler, cfg, srv)
 
 	commonInterceptors := baseHandler.CommonInterceptors(cfg)
+	streamInterceptors := baseHandler.CommonStreamInterceptors(cfg)
 
 	grpcServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(commonInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
 	)
 
 	// Регистрация gRPC сервиса
 	api.RegisterShortenerServer(grpcServer, aggregateHandler)
 
+	// Проверка состояния для оркестратора и grpcurl
+	healthChecker := NewHealthChecker(log, cfg.GRPC.HealthCheckInterval,
+		Dependency{Name: "storage", Check: srv.Ping},
+	)
+	healthChecker.Register(grpcServer)
+	healthChecker.Start()
+
+	if cfg.GRPC.Reflection {
+		reflection.Register(grpcServer)
+		log.Info("gRPC server reflection enabled")
+	}
+
 	lis, err := net.Listen("tcp", cfg.GRPCServerAddr)
 	if err != nil {
 		log.Fatal("Failed to listen gRPC", zap.Error(err))
@@ -97,5 +176,5 @@ func StartGRPCServer(log *zap.Logger, cfg *config.Config, srv *service.Service)
 		}
 	}()
 
-	return grpcServer
+	return grpcServer, healthChecker
 }
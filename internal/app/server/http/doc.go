@@ -16,7 +16,9 @@
 //   - /api/shorten - JSON API создания ссылки
 //   - /api/shorten/batch - Пакетное создание
 //   - /api/user/urls - Список ссылок пользователя
+//   - /api/user/urls/{id}/stats - Статистика переходов по ссылке
 //   - /ping - Проверка доступности БД
+//   - /v1/... - REST-шлюз к методам gRPC (маршруты из api/shortener.proto)
 //
 // Пример запуска:
 //
@@ -6,14 +6,22 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/ryabkov82/shortener/internal/app/config"
+	"github.com/ryabkov82/shortener/internal/app/handlers/gateway"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/batch"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/deadletters"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/deletejob"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/deluserurls"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/ping"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/redirect"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/replaydeadletters"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/restoreurls"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/shortenapi"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/shorturl"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/stats"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/urlstats"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/userurls"
+	grpcserver "github.com/ryabkov82/shortener/internal/app/server/grpc"
 	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/auth"
 	mwlogger "github.com/ryabkov82/shortener/internal/app/server/http/middleware/logger"
 	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/mwgzip"
@@ -59,12 +67,24 @@ func setupRouter(log *zap.Logger, cfg *config.Config, srv *service.Service) http
 	router.Post("/api/shorten/batch", batch.GetHandler(srv, cfg.BaseURL, log))
 	router.Get("/api/user/urls", userurls.GetHandler(srv, cfg.BaseURL, log))
 	router.Delete("/api/user/urls", deluserurls.GetHandler(srv, cfg.BaseURL, log))
+	router.Post("/api/user/urls/restore", restoreurls.GetHandler(srv, log))
+	router.Get("/api/user/deletions/{id}", deletejob.GetHandler(srv, log))
+	router.Get("/api/user/urls/{id}/stats", urlstats.GetHandler(srv, cfg.BaseURL, log))
 
 	router.Group(func(router chi.Router) {
 		router.Use(trustednet.CheckTrustedSubnet(cfg.TrustedSubnet))
 		router.Get("/api/internal/stats", stats.GetHandler(srv, log))
+		router.Get("/api/internal/deadletters", deadletters.GetHandler(srv, log))
+		router.Post("/api/internal/deadletters/replay", replaydeadletters.GetHandler(srv, log))
 	})
 
+	// REST-шлюз к обработчикам gRPC (маршруты /v1/...)
+	gateway.Register(router,
+		gateway.Routes(grpcserver.NewHandler(base.NewBaseHandler(log), cfg, srv)),
+		log,
+		gateway.WithProtection(base.ProtectedMethods, trustednet.CheckTrustedSubnet(cfg.TrustedSubnet)),
+	)
+
 	return router
 }
 
@@ -3,6 +3,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -15,8 +16,14 @@ import (
 	grpcserver "github.com/ryabkov82/shortener/internal/app/server/grpc"
 	httpserver "github.com/ryabkov82/shortener/internal/app/server/http"
 	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage/cache"
 	"github.com/ryabkov82/shortener/internal/app/storage/inmemory"
+	"github.com/ryabkov82/shortener/internal/app/storage/kvstore"
 	"github.com/ryabkov82/shortener/internal/app/storage/postgres"
+	"github.com/ryabkov82/shortener/internal/app/storage/redis"
+	"github.com/ryabkov82/shortener/internal/app/workers/clickstats"
+	"github.com/ryabkov82/shortener/internal/app/workers/deleteurls"
+	"github.com/ryabkov82/shortener/internal/app/workers/purgeurls"
 	"google.golang.org/grpc"
 
 	"go.uber.org/zap"
@@ -32,14 +39,78 @@ func StartServers(log *zap.Logger, cfg *config.Config) {
 		log.Fatal("Failed to initialize storage", zap.Error(err))
 	}
 
-	appService := service.NewService(storage)
+	keygen, err := initKeyGenerator(cfg, storage)
+	if err != nil {
+		log.Fatal("Failed to initialize key generator", zap.Error(err))
+	}
+	log.Info("Using key generator",
+		zap.String("strategy", cfg.KeyGen.Strategy),
+		zap.Int("length", cfg.KeyGen.Length),
+	)
+
+	opts := []service.Option{
+		service.WithKeyGenerator(keygen),
+		service.WithKeyAttempts(cfg.KeyGen.MaxAttempts),
+	}
+
+	if cfg.DeleteQueue.Enabled {
+		queue, err := initDeleteQueue(cfg, storage, log)
+		if err != nil {
+			log.Fatal("Failed to initialize delete queue", zap.Error(err))
+		}
+		opts = append(opts, service.WithDeleteQueue(queue))
+	}
+
+	opts = append(opts, service.WithDeleteWorkerPool(
+		cfg.DeleteWorker.Workers,
+		cfg.DeleteWorker.BatchSize,
+		cfg.DeleteWorker.FlushInterval,
+	))
+	opts = append(opts, service.WithDeleteWorkerOptions(
+		deleteurls.WithLogger(log),
+		deleteurls.WithQueueSize(cfg.DeleteWorker.QueueSize),
+		deleteurls.WithRetryPolicy(deleteurls.RetryPolicy{
+			MaxAttempts:    cfg.DeleteWorker.RetryAttempts,
+			InitialBackoff: cfg.DeleteWorker.RetryInitialBackoff,
+			MaxBackoff:     cfg.DeleteWorker.RetryMaxBackoff,
+		}),
+		deleteurls.WithDeadLetters(deleteurls.NewMemoryDeadLetters(cfg.DeleteWorker.DeadLetterLimit)),
+		deleteurls.WithRestoreWindow(cfg.DeleteWorker.RestoreWindow),
+	))
+
+	// Кэш оборачивает хранилище после выбора генератора ключей и очереди удаления,
+	// которым нужен доступ к самому хранилищу
+	if cfg.Cache.Enabled {
+		storage = cache.New(storage, cache.WithSize(cfg.Cache.Size), cache.WithTTL(cfg.Cache.TTL))
+		log.Info("Redirect cache enabled",
+			zap.Int("size", cfg.Cache.Size),
+			zap.Duration("ttl", cfg.Cache.TTL),
+		)
+	}
+
+	if cfg.Purge.Enabled {
+		opts = append(opts, service.WithPurgeWorker(
+			cfg.Purge.Interval,
+			cfg.Purge.Retention,
+			cfg.Purge.BatchSize,
+			purgeurls.WithLogger(log),
+		))
+		log.Info("Purge worker enabled",
+			zap.Duration("interval", cfg.Purge.Interval),
+			zap.Duration("retention", cfg.Purge.Retention),
+		)
+	}
+
+	opts = append(opts, service.WithClickRecorderOptions(clickstats.WithLogger(log)))
+
+	appService := service.NewService(storage, opts...)
 
 	// 2. Запуск серверов
 	httpServer := httpserver.StartHTTPServer(log, cfg, appService)
-	grpcServer := grpcserver.StartGRPCServer(log, cfg, appService)
+	grpcServer, healthChecker := grpcserver.StartGRPCServer(log, cfg, appService)
 
 	// 3. Graceful shutdown
-	waitForShutdown(log, httpServer, grpcServer, appService)
+	waitForShutdown(log, httpServer, grpcServer, healthChecker, appService)
 }
 
 // Вспомогательные функции
@@ -53,7 +124,40 @@ func initStorage(cfg *config.Config, log *zap.Logger) (service.Repository, error
 		return pg, nil
 	}
 
-	mem, err := inmemory.NewInMemoryStorage(cfg.FileStorage)
+	if cfg.RedisURL != "" {
+		rs, err := redis.NewRedisStorage(cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Using Redis storage")
+		return rs, nil
+	}
+
+	if cfg.KVStorage != "" {
+		kv, err := kvstore.NewKVStorage(cfg.KVStorage, kvstore.WithLogger(log))
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Using embedded key-value storage", zap.String("dir", cfg.KVStorage))
+		return kv, nil
+	}
+
+	format, err := inmemory.ParseFormat(cfg.Persistence.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	syncMode, err := inmemory.ParseSyncMode(cfg.Persistence.Sync)
+	if err != nil {
+		return nil, err
+	}
+
+	mem, err := inmemory.NewInMemoryStorage(cfg.FileStorage,
+		inmemory.WithFormat(format),
+		inmemory.WithCompactionInterval(cfg.Persistence.CompactInterval),
+		inmemory.WithSyncMode(syncMode, cfg.Persistence.SyncInterval),
+		inmemory.WithLogger(log),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -62,14 +166,71 @@ func initStorage(cfg *config.Config, log *zap.Logger) (service.Repository, error
 		return nil, err
 	}
 
-	log.Info("Using in-memory storage", zap.String("file", cfg.FileStorage))
+	log.Info("Using in-memory storage",
+		zap.String("file", cfg.FileStorage),
+		zap.String("format", cfg.Persistence.Format),
+		zap.Duration("compact_interval", cfg.Persistence.CompactInterval),
+		zap.String("sync", cfg.Persistence.Sync),
+	)
 	return mem, nil
 }
 
+func initKeyGenerator(cfg *config.Config, storage service.Repository) (service.KeyGenerator, error) {
+	if cfg.KeyGen.Strategy == config.KeyStrategyRandom {
+		return service.NewRandomKeyGenerator(cfg.KeyGen.Length), nil
+	}
+
+	var encoder service.KeyEncoder = service.Base62Encoder{MinLength: cfg.KeyGen.Length}
+	if cfg.KeyGen.Encoding == config.KeyEncodingHashids {
+		hashids, err := service.NewHashidsEncoder(cfg.KeyGen.Salt, cfg.KeyGen.Length)
+		if err != nil {
+			return nil, err
+		}
+		encoder = hashids
+	}
+
+	if cfg.KeyGen.Strategy == config.KeyStrategyCounter {
+		// Счётчик не сохраняется и не разделяется между экземплярами: два экземпляра
+		// над общим хранилищем выдавали бы одни и те же ключи. Поэтому он допускается
+		// только с файловым хранилищем, которое обслуживает один экземпляр, а остальные
+		// хранилища предоставляют для этого постоянную последовательность
+		if _, ok := storage.(service.Sequence); ok {
+			return nil, errors.New("counter key strategy requires file storage, use the sequence strategy with PostgreSQL, Redis or embedded key-value storage")
+		}
+		// Начинаем с текущего времени, чтобы после перезапуска не повторять ранее
+		// выданные значения. Повторы после перевода часов назад отклоняются
+		// хранилищем и генерируются заново
+		return service.NewCounterKeyGenerator(uint64(time.Now().UnixMilli()), encoder), nil
+	}
+
+	sequence, ok := storage.(service.Sequence)
+	if !ok {
+		return nil, errors.New("sequence key strategy requires PostgreSQL, Redis or embedded key-value storage")
+	}
+	return service.NewSequenceKeyGenerator(sequence, encoder), nil
+}
+
+// initDeleteQueue создает постоянную очередь задач удаления:
+// таблицу в PostgreSQL или журнал на диске для остальных хранилищ.
+func initDeleteQueue(cfg *config.Config, storage service.Repository, log *zap.Logger) (deleteurls.Queue, error) {
+	if pg, ok := storage.(*postgres.PostgresStorage); ok {
+		log.Info("Using PostgreSQL delete queue")
+		return pg.DeleteQueue(), nil
+	}
+
+	queue, err := deleteurls.OpenFileQueue(cfg.DeleteQueue.Path)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Using file delete queue", zap.String("path", cfg.DeleteQueue.Path))
+	return queue, nil
+}
+
 func waitForShutdown(
 	log *zap.Logger,
 	httpServer *http.Server,
 	grpcServer *grpc.Server,
+	healthChecker *grpcserver.HealthChecker,
 	service *service.Service,
 ) {
 	quit := make(chan os.Signal, 1)
@@ -78,6 +239,9 @@ func waitForShutdown(
 
 	log.Info("Shutting down servers...")
 
+	// Сообщаем оркестратору, что новые запросы принимать не нужно
+	healthChecker.Shutdown()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"sync/atomic"
+)
+
+// base62Charset - алфавит коротких ключей по умолчанию.
+const base62Charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Значения по умолчанию для генерации коротких ключей.
+const (
+	defaultKeyLength   = 8 // Длина ключа
+	defaultKeyAttempts = 5 // Количество попыток при коллизии ключа
+)
+
+// KeyGenerator определяет стратегию генерации коротких ключей.
+//
+// Реализации не обязаны гарантировать уникальность: при коллизии
+// (storage.ErrShortURLExists) сервис запрашивает следующий ключ.
+type KeyGenerator interface {
+	// NextKey возвращает очередной короткий ключ.
+	NextKey(ctx context.Context) (string, error)
+}
+
+// KeyEncoder преобразует числовой идентификатор в короткий ключ.
+// Используется генераторами на основе счётчика и последовательности БД.
+type KeyEncoder interface {
+	// Encode возвращает короткий ключ для идентификатора n.
+	Encode(n uint64) string
+}
+
+// Sequence определяет источник монотонно возрастающих идентификаторов,
+// например последовательность PostgreSQL.
+type Sequence interface {
+	// NextSequenceValue возвращает очередное значение последовательности.
+	NextSequenceValue(ctx context.Context) (uint64, error)
+}
+
+// RandomKeyGenerator генерирует случайные ключи из алфавита base62
+// с помощью криптографически стойкого генератора crypto/rand.
+type RandomKeyGenerator struct {
+	length int
+}
+
+// NewRandomKeyGenerator создает генератор случайных ключей заданной длины.
+func NewRandomKeyGenerator(length int) *RandomKeyGenerator {
+	return &RandomKeyGenerator{length: length}
+}
+
+// NextKey возвращает случайный ключ длины length.
+// Символы выбираются равномерно, без смещения по модулю.
+func (g *RandomKeyGenerator) NextKey(_ context.Context) (string, error) {
+	charsetLen := big.NewInt(int64(len(base62Charset)))
+
+	key := make([]byte, g.length)
+	for i := range key {
+		n, err := rand.Int(rand.Reader, charsetLen)
+		if err != nil {
+			return "", err
+		}
+		key[i] = base62Charset[n.Int64()]
+	}
+	return string(key), nil
+}
+
+// CounterKeyGenerator генерирует ключи из монотонного счётчика в памяти процесса.
+//
+// Счётчик не сохраняется между перезапусками, поэтому начальное значение
+// должно превышать все ранее выданные (например, текущее время в миллисекундах).
+// Подходит только для одного экземпляра сервиса: экземпляры с общим хранилищем
+// должны использовать SequenceKeyGenerator.
+type CounterKeyGenerator struct {
+	counter atomic.Uint64
+	encoder KeyEncoder
+}
+
+// NewCounterKeyGenerator создает генератор на основе счётчика.
+//
+// Параметры:
+//   - start: начальное значение счётчика
+//   - encoder: кодировщик идентификатора в ключ
+func NewCounterKeyGenerator(start uint64, encoder KeyEncoder) *CounterKeyGenerator {
+	g := &CounterKeyGenerator{encoder: encoder}
+	g.counter.Store(start)
+	return g
+}
+
+// NextKey увеличивает счётчик и возвращает закодированное значение.
+func (g *CounterKeyGenerator) NextKey(_ context.Context) (string, error) {
+	return g.encoder.Encode(g.counter.Add(1)), nil
+}
+
+// SequenceKeyGenerator генерирует ключи из внешней последовательности,
+// общей для всех экземпляров сервиса.
+type SequenceKeyGenerator struct {
+	sequence Sequence
+	encoder  KeyEncoder
+}
+
+// NewSequenceKeyGenerator создает генератор на основе последовательности.
+//
+// Параметры:
+//   - sequence: источник идентификаторов
+//   - encoder: кодировщик идентификатора в ключ
+func NewSequenceKeyGenerator(sequence Sequence, encoder KeyEncoder) *SequenceKeyGenerator {
+	return &SequenceKeyGenerator{sequence: sequence, encoder: encoder}
+}
+
+// NextKey получает очередное значение последовательности и возвращает его в закодированном виде.
+func (g *SequenceKeyGenerator) NextKey(ctx context.Context) (string, error) {
+	n, err := g.sequence.NextSequenceValue(ctx)
+	if err != nil {
+		return "", err
+	}
+	return g.encoder.Encode(n), nil
+}
+
+// Base62Encoder кодирует идентификатор в base62 с дополнением до минимальной длины.
+//
+// Последовательные идентификаторы дают последовательные ключи,
+// поэтому ключи легко перебрать. Если это нежелательно, используйте HashidsEncoder.
+type Base62Encoder struct {
+	MinLength int // Минимальная длина ключа (дополняется ведущими символами 'a')
+}
+
+// Encode возвращает base62-представление n.
+func (e Base62Encoder) Encode(n uint64) string {
+	var buf [11]byte // 62^11 > 2^64
+	i := len(buf)
+	for {
+		i--
+		buf[i] = base62Charset[n%62]
+		n /= 62
+		if n == 0 {
+			break
+		}
+	}
+
+	key := string(buf[i:])
+	if pad := e.MinLength - len(key); pad > 0 {
+		key = strings.Repeat(base62Charset[:1], pad) + key
+	}
+	return key
+}
+
+// Параметры алгоритма Hashids.
+const (
+	hashidsAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+	hashidsSeps     = "cfhistuCFHISTU"
+	hashidsSepDiv   = 3.5
+	hashidsGuardDiv = 12.0
+)
+
+// HashidsEncoder кодирует идентификатор по алгоритму Hashids (https://hashids.org).
+//
+// Ключи совместимы с другими реализациями Hashids при тех же соли и минимальной длине.
+// Последовательные идентификаторы дают непохожие ключи, что затрудняет их перебор.
+type HashidsEncoder struct {
+	salt      []rune
+	alphabet  []rune
+	guards    []rune
+	minLength int
+}
+
+// NewHashidsEncoder создает кодировщик Hashids со стандартным алфавитом.
+//
+// Параметры:
+//   - salt: соль, от которой зависит вид ключей
+//   - minLength: минимальная длина ключа
+func NewHashidsEncoder(salt string, minLength int) (*HashidsEncoder, error) {
+	if minLength < 0 {
+		return nil, errors.New("hashids: negative min length")
+	}
+
+	alphabet := []rune(hashidsAlphabet)
+	saltRunes := []rune(salt)
+
+	// Разделители не должны входить в основной алфавит. Для одного числа они
+	// в ключ не попадают, но влияют на итоговый алфавит и охранные символы
+	var seps []rune
+	for _, r := range hashidsSeps {
+		if i := indexRune(alphabet, r); i >= 0 {
+			seps = append(seps, r)
+			alphabet = append(alphabet[:i], alphabet[i+1:]...)
+		}
+	}
+	consistentShuffle(seps, saltRunes)
+
+	if len(seps) == 0 || float64(len(alphabet))/float64(len(seps)) > hashidsSepDiv {
+		sepsLength := int(math.Ceil(float64(len(alphabet)) / hashidsSepDiv))
+		if sepsLength == 1 {
+			sepsLength++
+		}
+		if sepsLength > len(seps) {
+			diff := sepsLength - len(seps)
+			seps = append(seps, alphabet[:diff]...)
+			alphabet = alphabet[diff:]
+		} else {
+			seps = seps[:sepsLength]
+		}
+	}
+	consistentShuffle(alphabet, saltRunes)
+
+	guardCount := int(math.Ceil(float64(len(alphabet)) / hashidsGuardDiv))
+	guards := append([]rune(nil), alphabet[:guardCount]...)
+	alphabet = alphabet[guardCount:]
+
+	return &HashidsEncoder{
+		salt:      saltRunes,
+		alphabet:  alphabet,
+		guards:    guards,
+		minLength: minLength,
+	}, nil
+}
+
+// Encode возвращает Hashids-представление n.
+func (e *HashidsEncoder) Encode(n uint64) string {
+	alphabet := append([]rune(nil), e.alphabet...)
+
+	numbersHash := int(n % 100)
+	lottery := alphabet[numbersHash%len(alphabet)]
+
+	buffer := make([]rune, 0, 1+len(e.salt)+len(alphabet))
+	buffer = append(buffer, lottery)
+	buffer = append(buffer, e.salt...)
+	buffer = append(buffer, alphabet...)
+	consistentShuffle(alphabet, buffer[:len(alphabet)])
+
+	result := append([]rune{lottery}, hashidsHash(n, alphabet)...)
+
+	if len(result) < e.minLength {
+		guardIndex := (numbersHash + int(result[0])) % len(e.guards)
+		result = append([]rune{e.guards[guardIndex]}, result...)
+
+		if len(result) < e.minLength {
+			guardIndex = (numbersHash + int(result[2])) % len(e.guards)
+			result = append(result, e.guards[guardIndex])
+		}
+	}
+
+	halfLength := len(alphabet) / 2
+	for len(result) < e.minLength {
+		consistentShuffle(alphabet, append([]rune(nil), alphabet...))
+
+		padded := make([]rune, 0, len(result)+len(alphabet))
+		padded = append(padded, alphabet[halfLength:]...)
+		padded = append(padded, result...)
+		padded = append(padded, alphabet[:halfLength]...)
+		result = padded
+
+		if excess := len(result) - e.minLength; excess > 0 {
+			result = result[excess/2 : excess/2+e.minLength]
+		}
+	}
+
+	return string(result)
+}
+
+// hashidsHash записывает n в системе счисления с основанием len(alphabet).
+func hashidsHash(n uint64, alphabet []rune) []rune {
+	base := uint64(len(alphabet))
+
+	var result []rune
+	for {
+		result = append(result, alphabet[n%base])
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// consistentShuffle детерминированно перемешивает alphabet в зависимости от salt.
+func consistentShuffle(alphabet, salt []rune) {
+	if len(salt) == 0 {
+		return
+	}
+
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i-- {
+		p += int(salt[v])
+		j := (int(salt[v]) + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+		v = (v + 1) % len(salt)
+	}
+}
+
+// indexRune возвращает индекс символа r в срезе или -1.
+func indexRune(runes []rune, r rune) int {
+	for i, c := range runes {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
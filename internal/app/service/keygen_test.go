@@ -0,0 +1,310 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/service/mocks"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase62Encoder(t *testing.T) {
+	tests := []struct {
+		name      string
+		minLength int
+		n         uint64
+		want      string
+	}{
+		{name: "zero", n: 0, want: "a"},
+		{name: "last digit", n: 61, want: "9"},
+		{name: "two digits", n: 62, want: "ba"},
+		{name: "zero padded", minLength: 4, n: 0, want: "aaaa"},
+		{name: "padded", minLength: 6, n: 62, want: "aaaaba"},
+		{name: "longer than min length", minLength: 1, n: 62 * 62, want: "baa"},
+		{name: "max uint64", n: math.MaxUint64, want: "v8QrKbgkrIp"},
+		{name: "max uint64 padded", minLength: 12, n: math.MaxUint64, want: "av8QrKbgkrIp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, service.Base62Encoder{MinLength: tt.minLength}.Encode(tt.n))
+		})
+	}
+}
+
+// TestHashidsEncoder сверяет ключи с эталонными значениями
+// из документации реализаций Hashids (hashids.js, hashids.py, hashids.php).
+func TestHashidsEncoder(t *testing.T) {
+	tests := []struct {
+		salt      string
+		minLength int
+		n         uint64
+		want      string
+	}{
+		{salt: "", minLength: 0, n: 1, want: "jR"},
+		{salt: "", minLength: 0, n: 123, want: "Mj3"},
+		{salt: "", minLength: 16, n: 1, want: "4q2VolejRejNmGQB"},
+		{salt: "this is my salt", minLength: 0, n: 1, want: "NV"},
+		{salt: "this is my salt", minLength: 0, n: 12345, want: "NkK9"},
+		{salt: "this is my salt", minLength: 8, n: 1, want: "gB0NV05e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q/%d/%d", tt.salt, tt.minLength, tt.n), func(t *testing.T) {
+			enc, err := service.NewHashidsEncoder(tt.salt, tt.minLength)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, enc.Encode(tt.n))
+		})
+	}
+
+	t.Run("min length", func(t *testing.T) {
+		enc, err := service.NewHashidsEncoder("salt", 10)
+		require.NoError(t, err)
+		for _, n := range []uint64{0, 1, 61, 1 << 32, math.MaxUint64} {
+			assert.GreaterOrEqual(t, len(enc.Encode(n)), 10, n)
+		}
+	})
+
+	t.Run("unique", func(t *testing.T) {
+		enc, err := service.NewHashidsEncoder("salt", 0)
+		require.NoError(t, err)
+		seen := make(map[string]uint64)
+		for n := uint64(0); n < 10000; n++ {
+			key := enc.Encode(n)
+			prev, dup := seen[key]
+			require.False(t, dup, "%d and %d encode to %q", prev, n, key)
+			seen[key] = n
+		}
+	})
+
+	t.Run("negative min length", func(t *testing.T) {
+		_, err := service.NewHashidsEncoder("salt", -1)
+		assert.Error(t, err)
+	})
+}
+
+func TestCounterKeyGenerator(t *testing.T) {
+	t.Run("sequential", func(t *testing.T) {
+		gen := service.NewCounterKeyGenerator(60, service.Base62Encoder{MinLength: 3})
+
+		var keys []string
+		for i := 0; i < 3; i++ {
+			key, err := gen.NextKey(context.Background())
+			require.NoError(t, err)
+			keys = append(keys, key)
+		}
+		// Счётчик увеличивается до кодирования: первым выдаётся start+1
+		assert.Equal(t, []string{"aa9", "aba", "abb"}, keys)
+	})
+
+	t.Run("concurrent keys are unique", func(t *testing.T) {
+		const goroutines, perGoroutine = 8, 500
+		gen := service.NewCounterKeyGenerator(0, service.Base62Encoder{})
+
+		var (
+			mu   sync.Mutex
+			wg   sync.WaitGroup
+			seen = make(map[string]bool)
+		)
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perGoroutine; i++ {
+					key, err := gen.NextKey(context.Background())
+					assert.NoError(t, err)
+					mu.Lock()
+					seen[key] = true
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, seen, goroutines*perGoroutine)
+	})
+}
+
+// collidingGenerator выдаёт ключи key1, key2, ... и считает вызовы.
+type collidingGenerator struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (g *collidingGenerator) NextKey(context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls++
+	return fmt.Sprintf("key%d", g.calls), nil
+}
+
+func TestGetShortKeyWithOptions_KeyCollision(t *testing.T) {
+	const attempts = 4
+
+	tests := []struct {
+		name       string
+		collisions int // Количество ключей подряд, уже занятых в хранилище
+		wantKey    string
+		wantErr    error
+	}{
+		{name: "no collision", collisions: 0, wantKey: "key1"},
+		{name: "success on last attempt", collisions: attempts - 1, wantKey: "key4"},
+		{name: "all attempts collide", collisions: attempts, wantErr: service.ErrKeyCollision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockRepository(ctrl)
+			gen := &collidingGenerator{}
+			serv := service.NewService(repo, service.WithKeyGenerator(gen), service.WithKeyAttempts(attempts))
+
+			var saved []string
+			repo.EXPECT().SaveURL(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, mapping *models.URLMapping) error {
+					saved = append(saved, mapping.ShortURL)
+					if len(saved) <= tt.collisions {
+						return storage.ErrShortURLExists
+					}
+					return nil
+				}).
+				Times(min(tt.collisions+1, attempts))
+
+			key, err := serv.GetShortKey(context.Background(), "https://example.com")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, key)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantKey, key)
+			}
+			assert.Equal(t, min(tt.collisions+1, attempts), gen.calls)
+		})
+	}
+
+	t.Run("storage error is not retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		gen := &collidingGenerator{}
+		serv := service.NewService(repo, service.WithKeyGenerator(gen), service.WithKeyAttempts(attempts))
+
+		failure := errors.New("connection refused")
+		repo.EXPECT().SaveURL(gomock.Any(), gomock.Any()).Return(failure).Times(1)
+
+		_, err := serv.GetShortKey(context.Background(), "https://example.com")
+		assert.ErrorIs(t, err, failure)
+		assert.Equal(t, 1, gen.calls)
+	})
+}
+
+func TestBatch_KeyCollision(t *testing.T) {
+	const (
+		attempts = 3
+		baseURL  = "http://localhost:8080"
+	)
+
+	request := []models.BatchRequest{
+		{CorrelationID: "1", OriginalURL: "https://example.com/1"},
+		{CorrelationID: "2", OriginalURL: "https://example.com/2"},
+	}
+
+	newService := func(t *testing.T) (*service.Service, *mocks.MockRepository, *collidingGenerator) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		gen := &collidingGenerator{}
+		serv := service.NewService(repo, service.WithKeyGenerator(gen), service.WithKeyAttempts(attempts))
+
+		repo.EXPECT().GetExistingURLs(gomock.Any(), gomock.Any()).Return(map[string]string{}, nil)
+		return serv, repo, gen
+	}
+
+	t.Run("atomic storage succeeds on last attempt", func(t *testing.T) {
+		serv, repo, gen := newService(t)
+
+		calls := 0
+		repo.EXPECT().SaveNewURLs(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(context.Context, []models.URLMapping) error {
+				calls++
+				if calls < attempts {
+					return storage.ErrShortURLExists
+				}
+				return nil
+			}).
+			Times(attempts)
+
+		resp, err := serv.Batch(context.Background(), request, baseURL)
+		require.NoError(t, err)
+		// Пакет отклоняется целиком, поэтому ключи генерируются заново для всех строк
+		assert.Equal(t, []models.BatchResponse{
+			{CorrelationID: "1", ShortURL: baseURL + "/key5"},
+			{CorrelationID: "2", ShortURL: baseURL + "/key6"},
+		}, resp)
+		assert.Equal(t, attempts*len(request), gen.calls)
+	})
+
+	t.Run("atomic storage fails after all attempts", func(t *testing.T) {
+		serv, repo, _ := newService(t)
+
+		repo.EXPECT().SaveNewURLs(gomock.Any(), gomock.Any()).
+			Return(storage.ErrShortURLExists).
+			Times(attempts)
+
+		resp, err := serv.Batch(context.Background(), request, baseURL)
+		assert.ErrorIs(t, err, service.ErrKeyCollision)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("partial conflicts retry only colliding rows", func(t *testing.T) {
+		serv, repo, gen := newService(t)
+
+		calls := 0
+		repo.EXPECT().SaveNewURLs(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, urls []models.URLMapping) error {
+				calls++
+				if calls == 1 {
+					// Первая строка сохранена, ключ второй занят
+					return &storage.BatchConflictError{Conflicts: map[int]error{1: storage.ErrShortURLExists}}
+				}
+				require.Len(t, urls, 1)
+				assert.Equal(t, "https://example.com/2", urls[0].OriginalURL)
+				return nil
+			}).
+			Times(2)
+
+		resp, err := serv.Batch(context.Background(), request, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, []models.BatchResponse{
+			{CorrelationID: "1", ShortURL: baseURL + "/key1"},
+			{CorrelationID: "2", ShortURL: baseURL + "/key3"},
+		}, resp)
+		assert.Equal(t, 3, gen.calls)
+	})
+
+	t.Run("partial conflicts fail after all attempts", func(t *testing.T) {
+		serv, repo, _ := newService(t)
+
+		repo.EXPECT().SaveNewURLs(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, urls []models.URLMapping) error {
+				return &storage.BatchConflictError{Conflicts: map[int]error{len(urls) - 1: storage.ErrShortURLExists}}
+			}).
+			Times(attempts)
+
+		resp, err := serv.Batch(context.Background(), request, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, baseURL+"/key1", resp[0].ShortURL)
+		assert.Empty(t, resp[0].Error)
+		// Строка, для которой не нашлось свободного ключа, содержит описание ошибки
+		assert.Empty(t, resp[1].ShortURL)
+		assert.Equal(t, service.ErrKeyCollision.Error(), resp[1].Error)
+	})
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/ryabkov82/shortener/internal/app/models"
@@ -36,17 +37,33 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // BatchMarkAsDeleted mocks base method.
-func (m *MockRepository) BatchMarkAsDeleted(arg0 string, arg1 []string) error {
+func (m *MockRepository) BatchMarkAsDeleted(arg0 context.Context, arg1 string, arg2 []string) (models.DeleteResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchMarkAsDeleted", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "BatchMarkAsDeleted", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.DeleteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // BatchMarkAsDeleted indicates an expected call of BatchMarkAsDeleted.
-func (mr *MockRepositoryMockRecorder) BatchMarkAsDeleted(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) BatchMarkAsDeleted(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchMarkAsDeleted", reflect.TypeOf((*MockRepository)(nil).BatchMarkAsDeleted), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchMarkAsDeleted", reflect.TypeOf((*MockRepository)(nil).BatchMarkAsDeleted), arg0, arg1, arg2)
+}
+
+// BatchRestore mocks base method.
+func (m *MockRepository) BatchRestore(arg0 context.Context, arg1 string, arg2 []string, arg3 time.Time) (models.RestoreResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchRestore", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(models.RestoreResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchRestore indicates an expected call of BatchRestore.
+func (mr *MockRepositoryMockRecorder) BatchRestore(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchRestore", reflect.TypeOf((*MockRepository)(nil).BatchRestore), arg0, arg1, arg2, arg3)
 }
 
 // Close mocks base method.
@@ -138,19 +155,34 @@ func (mr *MockRepositoryMockRecorder) GetShortKey(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShortKey", reflect.TypeOf((*MockRepository)(nil).GetShortKey), arg0, arg1)
 }
 
+// GetURLStats mocks base method.
+func (m *MockRepository) GetURLStats(arg0 context.Context, arg1 string, arg2 int) (models.URLStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLStats", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.URLStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLStats indicates an expected call of GetURLStats.
+func (mr *MockRepositoryMockRecorder) GetURLStats(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLStats", reflect.TypeOf((*MockRepository)(nil).GetURLStats), arg0, arg1, arg2)
+}
+
 // GetUserUrls mocks base method.
-func (m *MockRepository) GetUserUrls(arg0 context.Context, arg1 string) ([]models.URLMapping, error) {
+func (m *MockRepository) GetUserUrls(arg0 context.Context, arg1 string, arg2 models.UserURLsQuery) (models.UserURLsPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserUrls", arg0, arg1)
-	ret0, _ := ret[0].([]models.URLMapping)
+	ret := m.ctrl.Call(m, "GetUserUrls", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.UserURLsPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserUrls indicates an expected call of GetUserUrls.
-func (mr *MockRepositoryMockRecorder) GetUserUrls(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetUserUrls(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserUrls", reflect.TypeOf((*MockRepository)(nil).GetUserUrls), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserUrls", reflect.TypeOf((*MockRepository)(nil).GetUserUrls), arg0, arg1, arg2)
 }
 
 // Ping mocks base method.
@@ -167,6 +199,35 @@ func (mr *MockRepositoryMockRecorder) Ping(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRepository)(nil).Ping), arg0)
 }
 
+// PurgeURLs mocks base method.
+func (m *MockRepository) PurgeURLs(arg0 context.Context, arg1 time.Time, arg2 int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeURLs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeURLs indicates an expected call of PurgeURLs.
+func (mr *MockRepositoryMockRecorder) PurgeURLs(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeURLs", reflect.TypeOf((*MockRepository)(nil).PurgeURLs), arg0, arg1, arg2)
+}
+
+// RecordClicks mocks base method.
+func (m *MockRepository) RecordClicks(arg0 context.Context, arg1 []models.ClickEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordClicks", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordClicks indicates an expected call of RecordClicks.
+func (mr *MockRepositoryMockRecorder) RecordClicks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordClicks", reflect.TypeOf((*MockRepository)(nil).RecordClicks), arg0, arg1)
+}
+
 // SaveNewURLs mocks base method.
 func (m *MockRepository) SaveNewURLs(arg0 context.Context, arg1 []models.URLMapping) error {
 	m.ctrl.T.Helper()
@@ -5,16 +5,105 @@
 // - Управление хранилищем URL
 // - Пакетная обработка запросов
 // - Асинхронное удаление URL
+// - Фоновая очистка удалённых и истёкших URL
+// - Сбор статистики переходов по ссылкам
 package service
 
 import (
 	"context"
-	"math/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/netip"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ryabkov82/shortener/internal/app/jwtauth"
 	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/ryabkov82/shortener/internal/app/workers/clickstats"
 	"github.com/ryabkov82/shortener/internal/app/workers/deleteurls"
+	"github.com/ryabkov82/shortener/internal/app/workers/purgeurls"
+)
+
+// Ограничения на пользовательский псевдоним короткой ссылки.
+//
+// Максимальная длина совпадает с размером колонки short_code в PostgreSQL.
+const (
+	aliasMinLength = 3
+	aliasMaxLength = 20
+	aliasCharset   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-"
+)
+
+// maxTTLSeconds ограничивает ttl_seconds, чтобы значение помещалось в time.Duration.
+const maxTTLSeconds = int64(math.MaxInt64 / int64(time.Second))
+
+// Параметры статистики переходов.
+const (
+	// clickValueMaxLength ограничивает длину Referer и User-Agent,
+	// совпадает с размером колонки value в PostgreSQL
+	clickValueMaxLength = 256
+	// statsTopN - количество значений в разбивках статистики
+	statsTopN = 10
+)
+
+// reservedAliases содержит имена, совпадающие с маршрутами сервиса.
+// Такие псевдонимы перекрыли бы служебные эндпоинты (/ping, /api/...).
+var reservedAliases = map[string]bool{
+	"ping": true,
+	"api":  true,
+}
+
+// Ошибки валидации параметров создания ссылки.
+var (
+	// ErrInvalidAlias возвращается, когда пользовательский псевдоним не проходит валидацию:
+	// недопустимая длина, запрещённые символы или зарезервированное имя.
+	ErrInvalidAlias = errors.New("invalid custom alias")
+
+	// ErrAliasMismatch возвращается, когда пользователь запросил псевдоним для URL,
+	// который он уже сократил под другим ключом. Существующая ссылка не меняется.
+	ErrAliasMismatch = errors.New("URL already shortened with another key")
+
+	// ErrInvalidExpiry возвращается, когда срок действия ссылки задан некорректно:
+	// одновременно указаны expires_at и ttl_seconds, TTL отрицательный
+	// или момент истечения уже в прошлом.
+	ErrInvalidExpiry = errors.New("invalid expiration")
+
+	// ErrKeyCollision возвращается, когда за отведённое число попыток
+	// не удалось сгенерировать свободный короткий ключ.
+	ErrKeyCollision = errors.New("failed to generate unique short key")
+
+	// ErrDeleteJobNotFound возвращается, когда задание на удаление не найдено
+	// или создано другим пользователем.
+	ErrDeleteJobNotFound = errors.New("delete job not found")
+
+	// ErrDeleteQueueFull возвращается, когда очередь задач удаления заполнена.
+	// Запрос можно повторить позже.
+	ErrDeleteQueueFull = deleteurls.ErrQueueFull
+
+	// ErrDeleteWorkerStopped возвращается, когда сервис завершает работу
+	// и задачи удаления больше не принимаются.
+	ErrDeleteWorkerStopped = deleteurls.ErrStopped
+
+	// ErrInvalidUserURLsQuery возвращается, когда параметры списка URL пользователя
+	// заданы некорректно: размер страницы вне допустимого диапазона, неизвестный
+	// порядок сортировки, пустой диапазон дат или неверный курсор.
+	ErrInvalidUserURLsQuery = errors.New("invalid user URLs query")
+)
+
+// MaxUserURLsLimit - максимальный размер страницы списка URL пользователя.
+const MaxUserURLsLimit = 1000
+
+// defaultStopTimeout - время ожидания фоновых обработчиков при Close без предварительного GracefulStop.
+const defaultStopTimeout = 5 * time.Second
+
+// Параметры воркера удаления по умолчанию.
+const (
+	defaultDeleteWorkers       = 1
+	defaultDeleteBatchSize     = 100
+	defaultDeleteFlushInterval = 500 * time.Millisecond
 )
 
 // Repository определяет интерфейс для работы с хранилищем URL.
@@ -25,17 +114,120 @@ type Repository interface {
 	Ping(context.Context) error
 	SaveNewURLs(context.Context, []models.URLMapping) error
 	GetExistingURLs(context.Context, []string) (map[string]string, error)
-	GetUserUrls(context.Context, string) ([]models.URLMapping, error)
-	BatchMarkAsDeleted(userID string, urls []string) error
+	GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error)
+	BatchMarkAsDeleted(ctx context.Context, userID string, urls []string) (models.DeleteResult, error)
+	BatchRestore(ctx context.Context, userID string, urls []string, deletedAfter time.Time) (models.RestoreResult, error)
 	Close() error
 	CountURLs(ctx context.Context) (int, error)
 	CountUsers(ctx context.Context) (int, error)
+	PurgeURLs(ctx context.Context, before time.Time, limit int) (int, error)
+	RecordClicks(ctx context.Context, events []models.ClickEvent) error
+	GetURLStats(ctx context.Context, shortKey string, top int) (models.URLStats, error)
+}
+
+// CacheStatsProvider реализуется хранилищами с кэшем редиректов.
+// Если хранилище его реализует, счётчики кэша включаются в статистику сервиса.
+type CacheStatsProvider interface {
+	CacheStats() models.CacheStats
 }
 
 // Service реализует основной сервис приложения.
 type Service struct {
-	repo         Repository               // Хранилище данных
-	deleteworker *deleteurls.DeleteWorker // Воркер для асинхронного удаления
+	repo                Repository               // Хранилище данных
+	deleteworker        *deleteurls.DeleteWorker // Воркер для асинхронного удаления
+	deletequeue         deleteurls.Queue         // Постоянная очередь задач удаления (может быть nil)
+	deleteopts          []deleteurls.Option      // Дополнительные параметры воркера удаления
+	deleteWorkers       int                      // Количество воркеров удаления
+	deleteBatchSize     int                      // Количество URL в пакете удаления
+	deleteFlushInterval time.Duration            // Максимальное время накопления пакета удаления
+	clicks              *clickstats.Recorder     // Асинхронная запись статистики переходов
+	clickopts           []clickstats.Option      // Дополнительные параметры записи статистики переходов
+	keygen              KeyGenerator             // Генератор коротких ключей
+	keyAttempts         int                      // Количество попыток генерации ключа при коллизии
+	purgeworker         *purgeurls.PurgeWorker   // Воркер для физического удаления устаревших URL (может быть nil)
+	stopOnce            sync.Once                // Гарантирует однократную остановку фоновых обработчиков
+}
+
+// Option определяет функцию для настройки сервиса.
+type Option func(*Service)
+
+// WithKeyGenerator задаёт стратегию генерации коротких ключей.
+// По умолчанию используются случайные ключи длиной 8 символов (RandomKeyGenerator).
+func WithKeyGenerator(gen KeyGenerator) Option {
+	return func(s *Service) {
+		s.keygen = gen
+	}
+}
+
+// WithKeyAttempts задаёт количество попыток генерации ключа при коллизии.
+// Значения меньше 1 игнорируются.
+func WithKeyAttempts(attempts int) Option {
+	return func(s *Service) {
+		if attempts > 0 {
+			s.keyAttempts = attempts
+		}
+	}
+}
+
+// WithPurgeWorker включает фоновую очистку удалённых и истёкших ссылок.
+// Счётчики очистки возвращаются в GetStats.
+//
+// Параметры:
+//
+//	interval - период между проходами очистки
+//	retention - срок хранения удалённых и истёкших записей перед физическим удалением
+//	batchSize - максимальное количество записей, удаляемых за одно обращение к хранилищу
+//	opts - дополнительные параметры воркера (например, purgeurls.WithLogger)
+func WithPurgeWorker(interval, retention time.Duration, batchSize int, opts ...purgeurls.Option) Option {
+	return func(s *Service) {
+		s.purgeworker = purgeurls.NewPurgeWorker(interval, retention, batchSize, s.repo, opts...)
+	}
+}
+
+// WithDeleteQueue включает постоянную очередь задач удаления:
+// задачи, не обработанные до остановки сервиса, выполняются после перезапуска.
+func WithDeleteQueue(queue deleteurls.Queue) Option {
+	return func(s *Service) {
+		s.deletequeue = queue
+	}
+}
+
+// WithDeleteWorkerPool задаёт параметры воркера удаления.
+// Значения меньше 1 игнорируются.
+//
+// Параметры:
+//
+//	workers - количество воркеров
+//	batchSize - количество URL, при накоплении которого пакет отправляется в обработку
+//	flushInterval - максимальное время накопления пакета
+func WithDeleteWorkerPool(workers, batchSize int, flushInterval time.Duration) Option {
+	return func(s *Service) {
+		if workers > 0 {
+			s.deleteWorkers = workers
+		}
+		if batchSize > 0 {
+			s.deleteBatchSize = batchSize
+		}
+		if flushInterval > 0 {
+			s.deleteFlushInterval = flushInterval
+		}
+	}
+}
+
+// WithDeleteWorkerOptions передаёт дополнительные параметры воркеру удаления
+// (например, политику повторов, хранилище dead-letter или логгер).
+func WithDeleteWorkerOptions(opts ...deleteurls.Option) Option {
+	return func(s *Service) {
+		s.deleteopts = append(s.deleteopts, opts...)
+	}
+}
+
+// WithClickRecorderOptions передаёт дополнительные параметры записи статистики переходов
+// (например, clickstats.WithLogger).
+func WithClickRecorderOptions(opts ...clickstats.Option) Option {
+	return func(s *Service) {
+		s.clickopts = append(s.clickopts, opts...)
+	}
 }
 
 // NewService создает новый экземпляр сервиса.
@@ -43,22 +235,48 @@ type Service struct {
 // Параметры:
 //
 //	storage - реализация интерфейса Repository
+//	opts - дополнительные настройки сервиса
 //
 // Возвращает:
 //
 //	*Service - инициализированный сервис
-func NewService(storage Repository) *Service {
-	// Инициализация воркера для удаления:
-	// - 1 воркер
-	// - Буфер на 10 задач
-	// - Задержка 500мс перед обработкой
-	delworker := deleteurls.NewDeleteWorker(1, 10, 500*time.Millisecond, storage)
-	delworker.Start()
+func NewService(storage Repository, opts ...Option) *Service {
+	s := &Service{
+		repo:        storage,
+		keygen:      NewRandomKeyGenerator(defaultKeyLength),
+		keyAttempts: defaultKeyAttempts,
+
+		deleteWorkers:       defaultDeleteWorkers,
+		deleteBatchSize:     defaultDeleteBatchSize,
+		deleteFlushInterval: defaultDeleteFlushInterval,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Инициализация записи статистики переходов:
+	// - Очередь на 10000 событий
+	// - Пакеты до 500 событий
+	// - Запись не реже раза в 500мс
+	s.clicks = clickstats.NewRecorder(10000, 500, 500*time.Millisecond, storage, s.clickopts...)
+	s.clicks.Start()
+
+	// Инициализация воркера для удаления (по умолчанию 1 воркер,
+	// пакеты до 100 URL, накопление пакета не дольше 500мс)
+	var delopts []deleteurls.Option
+	if s.deletequeue != nil {
+		delopts = append(delopts, deleteurls.WithQueue(s.deletequeue))
+	}
+	delopts = append(delopts, s.deleteopts...)
+	s.deleteworker = deleteurls.NewDeleteWorker(s.deleteWorkers, s.deleteBatchSize, s.deleteFlushInterval, storage, delopts...)
+	s.deleteworker.Start()
 
-	return &Service{
-		repo:         storage,
-		deleteworker: delworker,
+	if s.purgeworker != nil {
+		s.purgeworker.Start()
 	}
+
+	return s
 }
 
 // GetShortKey генерирует и сохраняет короткий ключ для URL.
@@ -74,14 +292,72 @@ func NewService(storage Repository) *Service {
 //	error - ошибка при сохранении:
 //	  - storage.ErrURLExists если URL уже существует
 func (s *Service) GetShortKey(ctx context.Context, originalURL string) (string, error) {
-	shortKey := generateShortKey()
+	return s.GetShortKeyWithOptions(ctx, originalURL, models.ShortenOptions{})
+}
+
+// GetShortKeyWithOptions сохраняет URL с дополнительными параметрами:
+// пользовательским псевдонимом и сроком действия ссылки.
+// При пустом псевдониме короткий ключ генерируется автоматически, как в GetShortKey;
+// при коллизии сгенерированного ключа запрашивается следующий (не более keyAttempts раз).
+//
+// Если пользователь уже сократил URL, возвращается существующий ключ и storage.ErrURLExists.
+// Когда при этом запрошен псевдоним, отличный от существующего ключа,
+// возвращается ErrAliasMismatch: запрошенный псевдоним не создаётся.
+//
+// Параметры:
+//
+//	ctx - контекст с идентификатором пользователя
+//	originalURL - URL для сокращения
+//	opts - необязательные параметры (псевдоним, expires_at или ttl_seconds)
+//
+// Возвращает:
+//
+//	string - короткий ключ
+//	error - ошибка при сохранении:
+//	  - ErrInvalidAlias если псевдоним не прошёл валидацию
+//	  - ErrInvalidExpiry если срок действия задан некорректно
+//	  - ErrKeyCollision если не удалось сгенерировать свободный ключ
+//	  - storage.ErrShortURLExists если псевдоним уже занят
+//	  - ErrAliasMismatch если URL уже сокращён под другим ключом
+//	  - storage.ErrURLExists если URL уже существует
+func (s *Service) GetShortKeyWithOptions(ctx context.Context, originalURL string, opts models.ShortenOptions) (string, error) {
+	if opts.CustomAlias != "" {
+		if err := validateAlias(opts.CustomAlias); err != nil {
+			return "", err
+		}
+	}
+
+	expiresAt, err := resolveExpiry(opts.ExpiresAt, opts.TTLSeconds, time.Now())
+	if err != nil {
+		return "", err
+	}
+
 	mapping := models.URLMapping{
-		ShortURL:    shortKey,
+		ShortURL:    opts.CustomAlias,
 		OriginalURL: originalURL,
+		ExpiresAt:   expiresAt,
+	}
+
+	if opts.CustomAlias != "" {
+		err = s.repo.SaveURL(ctx, &mapping)
+		if errors.Is(err, storage.ErrURLExists) && mapping.ShortURL != opts.CustomAlias {
+			return mapping.ShortURL, ErrAliasMismatch
+		}
+		return mapping.ShortURL, err
+	}
+
+	for attempt := 0; attempt < s.keyAttempts; attempt++ {
+		if mapping.ShortURL, err = s.keygen.NextKey(ctx); err != nil {
+			return "", err
+		}
+
+		err = s.repo.SaveURL(ctx, &mapping)
+		if !errors.Is(err, storage.ErrShortURLExists) {
+			return mapping.ShortURL, err
+		}
 	}
 
-	err := s.repo.SaveURL(ctx, &mapping)
-	return mapping.ShortURL, err
+	return "", fmt.Errorf("%w: %d attempts", ErrKeyCollision, s.keyAttempts)
 }
 
 // GetRedirectURL возвращает оригинальный URL для редиректа.
@@ -97,11 +373,66 @@ func (s *Service) GetShortKey(ctx context.Context, originalURL string) (string,
 //	error:
 //	  - storage.ErrURLNotFound если URL не существует
 //	  - storage.ErrURLDeleted если URL помечен как удаленный
+//	  - storage.ErrURLExpired если срок действия URL истёк
 func (s *Service) GetRedirectURL(ctx context.Context, shortKey string) (string, error) {
 	mapping, err := s.repo.GetRedirectURL(ctx, shortKey)
 	return mapping.OriginalURL, err
 }
 
+// RecordClick регистрирует переход по короткой ссылке.
+//
+// Событие ставится в очередь и записывается в хранилище асинхронно,
+// поэтому вызов не замедляет редирект. При переполнении очереди событие отбрасывается.
+// IP-адрес клиента заменяется префиксом сети, Referer и User-Agent
+// приводятся к корректному UTF-8 и обрезаются до clickValueMaxLength символов.
+//
+// Параметры:
+//
+//	ctx - контекст запроса
+//	event - событие перехода (ShortURL и ClientIP обязательны, At по умолчанию - текущий момент)
+func (s *Service) RecordClick(ctx context.Context, event models.ClickEvent) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	event.IPPrefix = ipPrefix(event.ClientIP)
+	event.ClientIP = ""
+	event.Referrer = sanitizeClickValue(event.Referrer)
+	event.UserAgent = sanitizeClickValue(event.UserAgent)
+
+	s.clicks.Record(event)
+}
+
+// GetURLStats возвращает статистику переходов по ссылке пользователя.
+//
+// События, ещё не записанные в хранилище, в статистике не учитываются,
+// поэтому данные могут отставать на интервал записи.
+//
+// Параметры:
+//
+//	ctx - контекст с идентификатором пользователя
+//	shortKey - короткий идентификатор URL
+//	baseURL - базовый адрес для построения полного короткого URL
+//
+// Возвращает:
+//
+//	models.URLStats - статистика переходов
+//	error - ошибка при получении:
+//	  - storage.ErrURLNotFound если ссылка не найдена или принадлежит другому пользователю
+func (s *Service) GetURLStats(ctx context.Context, shortKey string, baseURL string) (models.URLStats, error) {
+	stats, err := s.repo.GetURLStats(ctx, shortKey, statsTopN)
+	if err != nil {
+		return models.URLStats{}, err
+	}
+
+	stats.ShortURL = baseURL + "/" + stats.ShortURL
+	return stats, nil
+}
+
+// ClickStats возвращает счётчики записи статистики переходов.
+func (s *Service) ClickStats() clickstats.Stats {
+	return s.clicks.Stats()
+}
+
 // Ping проверяет доступность хранилища.
 func (s *Service) Ping(ctx context.Context) error {
 	return s.repo.Ping(ctx)
@@ -117,8 +448,10 @@ func (s *Service) Ping(ctx context.Context) error {
 //
 // Возвращает:
 //
-//	[]models.BatchResponse - результаты обработки
-//	error - ошибка при сохранении
+//	[]models.BatchResponse - результаты обработки; элемент, который не удалось
+//	  сохранить из-за конфликтов, содержит описание ошибки в поле Error
+//	  (для URL, уже сокращённого пользователем, - вместе с существующей ссылкой)
+//	error - ошибка при сохранении или ErrInvalidExpiry для некорректного срока действия
 func (s *Service) Batch(ctx context.Context, batchRequest []models.BatchRequest, baseURL string) ([]models.BatchResponse, error) {
 	originalURLs := make([]string, len(batchRequest))
 	for i, item := range batchRequest {
@@ -130,50 +463,117 @@ func (s *Service) Batch(ctx context.Context, batchRequest []models.BatchRequest,
 		return nil, err
 	}
 
-	var newURLs []models.URLMapping
-	batchResponse := make([]models.BatchResponse, 0, len(batchRequest))
+	batchResponse := make([]models.BatchResponse, len(batchRequest))
+	var (
+		newURLs    []models.URLMapping
+		newIndexes []int // Позиции новых URL в batchResponse
+	)
+	now := time.Now()
+
+	for i, item := range batchRequest {
+		batchResponse[i].CorrelationID = item.CorrelationID
 
-	for _, item := range batchRequest {
 		if shortURL, ok := existingURLs[item.OriginalURL]; ok {
-			batchResponse = append(batchResponse, models.BatchResponse{
-				CorrelationID: item.CorrelationID,
-				ShortURL:      baseURL + "/" + shortURL,
-			})
+			batchResponse[i].ShortURL = baseURL + "/" + shortURL
+			batchResponse[i].Error = storage.ErrURLExists.Error()
 			continue
 		}
 
-		shortURL := generateShortKey()
+		expiresAt, err := resolveExpiry(item.ExpiresAt, item.TTLSeconds, now)
+		if err != nil {
+			return nil, err
+		}
+
 		newURLs = append(newURLs, models.URLMapping{
 			OriginalURL: item.OriginalURL,
-			ShortURL:    shortURL,
-		})
-
-		batchResponse = append(batchResponse, models.BatchResponse{
-			CorrelationID: item.CorrelationID,
-			ShortURL:      baseURL + "/" + shortURL,
+			ExpiresAt:   expiresAt,
 		})
+		newIndexes = append(newIndexes, i)
 	}
 
-	if err := s.repo.SaveNewURLs(ctx, newURLs); err != nil {
-		return nil, err
+	// Хранилища, сохраняющие пакет атомарно, при коллизии отклоняют его целиком,
+	// и ключи генерируются заново для всех строк. Хранилища с построчной обработкой
+	// конфликтов сохраняют остальные строки, и повторяются только строки с занятым ключом.
+	partial := false
+	for attempt := 0; len(newURLs) > 0; attempt++ {
+		if attempt >= s.keyAttempts {
+			if !partial {
+				return nil, fmt.Errorf("%w: %d attempts", ErrKeyCollision, s.keyAttempts)
+			}
+			for _, i := range newIndexes {
+				batchResponse[i].Error = ErrKeyCollision.Error()
+			}
+			break
+		}
+
+		for i := range newURLs {
+			if newURLs[i].ShortURL, err = s.keygen.NextKey(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		err = s.repo.SaveNewURLs(ctx, newURLs)
+		var conflicts *storage.BatchConflictError
+		switch {
+		case err == nil:
+			for i, url := range newURLs {
+				batchResponse[newIndexes[i]].ShortURL = baseURL + "/" + url.ShortURL
+			}
+			newURLs = nil
+		case errors.As(err, &conflicts):
+			partial = true
+			var (
+				retryURLs    []models.URLMapping
+				retryIndexes []int
+			)
+			for i, url := range newURLs {
+				if errors.Is(conflicts.Conflicts[i], storage.ErrShortURLExists) {
+					retryURLs = append(retryURLs, url)
+					retryIndexes = append(retryIndexes, newIndexes[i])
+					continue
+				}
+				// Сохранённая строка или URL, уже сокращённый пользователем
+				batchResponse[newIndexes[i]].ShortURL = baseURL + "/" + url.ShortURL
+				if errors.Is(conflicts.Conflicts[i], storage.ErrURLExists) {
+					batchResponse[newIndexes[i]].Error = storage.ErrURLExists.Error()
+				}
+			}
+			newURLs, newIndexes = retryURLs, retryIndexes
+		case !errors.Is(err, storage.ErrShortURLExists):
+			return nil, err
+		}
 	}
 
 	return batchResponse, nil
 }
 
-// GetUserUrls возвращает все сокращенные URL пользователя.
+// GetUserUrls возвращает страницу сокращенных URL пользователя.
 //
 // Параметры:
 //
 //	ctx - контекст с идентификатором пользователя
 //	baseURL - базовый адрес для построения полных коротких URL
+//	query - фильтры, порядок и страница (нулевое значение - все URL по возрастанию даты создания)
 //
 // Возвращает:
 //
-//	[]models.URLMapping - список URL пользователя
-//	error - ошибка при получении
-func (s *Service) GetUserUrls(ctx context.Context, baseURL string) ([]models.URLMapping, error) {
-	return s.repo.GetUserUrls(ctx, baseURL)
+//	models.UserURLsPage - страница URL пользователя и курсор следующей страницы
+//	error - ErrInvalidUserURLsQuery при неверных параметрах или ошибка хранилища
+func (s *Service) GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error) {
+	switch {
+	case query.Limit < 0 || query.Limit > MaxUserURLsLimit:
+		return models.UserURLsPage{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidUserURLsQuery, MaxUserURLsLimit)
+	case query.Order != "" && query.Order != models.SortAsc && query.Order != models.SortDesc:
+		return models.UserURLsPage{}, fmt.Errorf("%w: unknown order %q", ErrInvalidUserURLsQuery, query.Order)
+	case query.CreatedFrom != nil && query.CreatedTo != nil && !query.CreatedFrom.Before(*query.CreatedTo):
+		return models.UserURLsPage{}, fmt.Errorf("%w: created_from must be before created_to", ErrInvalidUserURLsQuery)
+	}
+
+	page, err := s.repo.GetUserUrls(ctx, baseURL, query)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		return page, fmt.Errorf("%w: %w", ErrInvalidUserURLsQuery, err)
+	}
+	return page, err
 }
 
 // GetStats возвращает статистику сервиса по количеству URL и пользователей.
@@ -185,6 +585,8 @@ func (s *Service) GetUserUrls(ctx context.Context, baseURL string) ([]models.URL
 //   - models.StatsResponse: структура с полями:
 //   - URLs: общее количество сокращенных URL в сервисе
 //   - Users: количество уникальных пользователей в сервисе
+//   - Cache: счётчики кэша редиректов, если хранилище реализует CacheStatsProvider
+//   - Purge: счётчики фоновой очистки, если она включена (WithPurgeWorker)
 //   - error: ошибка, если не удалось получить статистику:
 //   - Ошибка базы данных при запросе CountURLs
 //   - Ошибка базы данных при запросе CountUsers
@@ -194,7 +596,8 @@ func (s *Service) GetUserUrls(ctx context.Context, baseURL string) ([]models.URL
 //  2. При ошибке на этом шаге сразу возвращает ошибку
 //  3. Запрашивает количество пользователей через s.repo.CountUsers
 //  4. При ошибке на этом шаге возвращает ошибку
-//  5. Формирует и возвращает структуру StatsResponse с полученными данными
+//  5. Формирует и возвращает структуру StatsResponse с полученными данными,
+//     счётчиками кэша и фоновой очистки
 //
 // Пример использования:
 //
@@ -224,8 +627,21 @@ func (s *Service) GetStats(ctx context.Context) (models.StatsResponse, error) {
 		return models.StatsResponse{}, err
 	}
 
-	return models.StatsResponse{URLs: urlCount, Users: userCount}, nil
+	stats := models.StatsResponse{URLs: urlCount, Users: userCount}
+	if cache, ok := s.repo.(CacheStatsProvider); ok {
+		cacheStats := cache.CacheStats()
+		stats.Cache = &cacheStats
+	}
+	if s.purgeworker != nil {
+		purge := s.purgeworker.Stats()
+		stats.Purge = &models.PurgeStats{
+			Runs:   int64(purge.Runs),
+			Purged: int64(purge.Purged),
+			Errors: int64(purge.Errors),
+		}
+	}
 
+	return stats, nil
 }
 
 // DeleteUserUrls помечает URL пользователя как удаленные (асинхронно).
@@ -237,42 +653,186 @@ func (s *Service) GetStats(ctx context.Context) (models.StatsResponse, error) {
 //
 // Возвращает:
 //
-//	error - ошибка при постановке задачи в очередь
-func (s *Service) DeleteUserUrls(ctx context.Context, shortURLs []string) error {
+//	string - идентификатор задания для отслеживания хода удаления (см. GetDeleteJob)
+//	error - ErrDeleteQueueFull, если очередь заполнена, ErrDeleteWorkerStopped при завершении
+//	  работы сервиса или ошибка постановки задачи в очередь
+func (s *Service) DeleteUserUrls(ctx context.Context, shortURLs []string) (string, error) {
+	userID := ctx.Value(jwtauth.UserIDContextKey).(string)
+	return s.deleteworker.Submit(deleteurls.DeleteTask{
+		UserID:    userID,
+		ShortURLs: shortURLs,
+	})
+}
+
+// RestoreUserUrls снимает пометку удаления с URL пользователя (асинхронно).
+// Восстанавливаются только URL, удалённые в пределах окна восстановления.
+//
+// Параметры:
+//
+//	ctx - контекст с идентификатором пользователя
+//	shortURLs - список коротких URL для восстановления
+//
+// Возвращает:
+//
+//	string - идентификатор задания для отслеживания хода восстановления (см. GetDeleteJob)
+//	error - ErrDeleteQueueFull, если очередь заполнена, ErrDeleteWorkerStopped при завершении
+//	  работы сервиса или ошибка постановки задачи в очередь
+func (s *Service) RestoreUserUrls(ctx context.Context, shortURLs []string) (string, error) {
 	userID := ctx.Value(jwtauth.UserIDContextKey).(string)
 	return s.deleteworker.Submit(deleteurls.DeleteTask{
 		UserID:    userID,
 		ShortURLs: shortURLs,
+		Restore:   true,
 	})
 }
 
-// GracefulStop корректно останавливает сервис.
+// GetDeleteJob возвращает ход выполнения задания на удаление или восстановление.
+//
+// Параметры:
+//
+//	ctx - контекст с идентификатором пользователя
+//	jobID - идентификатор задания, возвращённый DeleteUserUrls или RestoreUserUrls
+//
+// Возвращает:
+//
+//	models.DeleteJob - состояние задания
+//	error - ErrDeleteJobNotFound, если задание не найдено или создано другим пользователем
+func (s *Service) GetDeleteJob(ctx context.Context, jobID string) (models.DeleteJob, error) {
+	userID, _ := ctx.Value(jwtauth.UserIDContextKey).(string)
+
+	job, ok := s.deleteworker.Job(jobID)
+	if !ok || job.UserID != userID {
+		return models.DeleteJob{}, ErrDeleteJobNotFound
+	}
+	return job, nil
+}
+
+// GracefulStop корректно останавливает фоновые обработчики сервиса.
+// Повторные вызовы ничего не делают.
 //
 // Параметры:
 //
 //	timeout - максимальное время ожидания завершения операций
 func (s *Service) GracefulStop(timeout time.Duration) {
-	s.deleteworker.GracefulStop(timeout)
+	s.stopOnce.Do(func() {
+		s.deleteworker.GracefulStop(timeout)
+		s.clicks.GracefulStop(timeout)
+		if s.purgeworker != nil {
+			s.purgeworker.GracefulStop(timeout)
+		}
+	})
 }
 
-// Close освобождает ресурсы
-func (s *Service) Close() error {
-	return s.repo.Close()
+// DeleteStats возвращает счётчики асинхронного удаления URL.
+func (s *Service) DeleteStats() deleteurls.Stats {
+	return s.deleteworker.Stats()
+}
+
+// ListDeadLetters возвращает задачи удаления, не выполненные после всех повторных попыток.
+func (s *Service) ListDeadLetters(ctx context.Context) ([]models.DeadLetter, error) {
+	return s.deleteworker.DeadLetters(ctx)
 }
 
-// generateShortKey генерирует случайный короткий ключ.
+// ReplayDeadLetters повторно ставит в очередь задачи удаления из хранилища dead-letter.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//	ids - идентификаторы задач (все задачи, если список пуст)
 //
 // Возвращает:
 //
-//	string - 8-символьный ключ из [a-zA-Z0-9]
-func generateShortKey() string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	const keyLength = 8
+//	int - количество задач, поставленных в очередь
+//	error - ошибка постановки задач в очередь (ErrDeleteWorkerStopped при завершении работы)
+func (s *Service) ReplayDeadLetters(ctx context.Context, ids []int64) (int, error) {
+	return s.deleteworker.ReplayDeadLetters(ctx, ids)
+}
+
+// Close освобождает ресурсы.
+// Если сервис ещё не остановлен, фоновые обработчики останавливаются до закрытия хранилища,
+// чтобы они не обращались к закрытому хранилищу.
+func (s *Service) Close() error {
+	s.GracefulStop(defaultStopTimeout)
+
+	var queueErr error
+	if closer, ok := s.deletequeue.(io.Closer); ok {
+		queueErr = closer.Close()
+	}
+	return errors.Join(queueErr, s.repo.Close())
+}
+
+// validateAlias проверяет пользовательский псевдоним на допустимую длину,
+// набор символов и совпадение с зарезервированными именами.
+func validateAlias(alias string) error {
+	if len(alias) < aliasMinLength || len(alias) > aliasMaxLength {
+		return ErrInvalidAlias
+	}
+
+	for _, r := range alias {
+		if !strings.ContainsRune(aliasCharset, r) {
+			return ErrInvalidAlias
+		}
+	}
+
+	if reservedAliases[strings.ToLower(alias)] {
+		return ErrInvalidAlias
+	}
+
+	return nil
+}
+
+// resolveExpiry приводит срок действия ссылки к абсолютному моменту времени.
+//
+// Возвращает nil, если срок действия не задан.
+func resolveExpiry(expiresAt *time.Time, ttlSeconds int64, now time.Time) (*time.Time, error) {
+	switch {
+	case expiresAt != nil && ttlSeconds != 0:
+		return nil, ErrInvalidExpiry
+	case ttlSeconds < 0 || ttlSeconds > maxTTLSeconds:
+		return nil, ErrInvalidExpiry
+	case ttlSeconds > 0:
+		t := now.Add(time.Duration(ttlSeconds) * time.Second)
+		return &t, nil
+	case expiresAt != nil:
+		if !expiresAt.After(now) {
+			return nil, ErrInvalidExpiry
+		}
+		return expiresAt, nil
+	default:
+		return nil, nil
+	}
+}
+
+// ipPrefix возвращает обезличенный префикс сети для IP-адреса:
+// /24 для IPv4 и /48 для IPv6. Для некорректного адреса возвращает пустую строку.
+func ipPrefix(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap().WithZone("")
+
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+// sanitizeClickValue приводит значение заголовка к корректному UTF-8 без NUL-символов
+// и обрезает его до clickValueMaxLength символов.
+func sanitizeClickValue(value string) string {
+	value = strings.ToValidUTF8(value, "")
+	value = strings.ReplaceAll(value, "\x00", "")
 
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-	shortKey := make([]byte, keyLength)
-	for i := range shortKey {
-		shortKey[i] = charset[rand.Intn(len(charset))]
+	runes := []rune(value)
+	if len(runes) > clickValueMaxLength {
+		value = string(runes[:clickValueMaxLength])
 	}
-	return string(shortKey)
+	return value
 }
@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/service/mocks"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetShortKeyWithOptions_CustomAlias(t *testing.T) {
+	const existingKey = "abc123"
+
+	tests := []struct {
+		name    string
+		alias   string
+		saveErr error  // Ошибка хранилища
+		saved   string // Ключ, записанный хранилищем в mapping при ErrURLExists
+		wantKey string
+		wantErr error
+	}{
+		{name: "alias saved", alias: "promo2026", wantKey: "promo2026"},
+		{name: "alias taken", alias: "promo2026", saveErr: storage.ErrShortURLExists, wantKey: "promo2026", wantErr: storage.ErrShortURLExists},
+		{name: "same alias for existing URL", alias: existingKey, saveErr: storage.ErrURLExists, saved: existingKey, wantKey: existingKey, wantErr: storage.ErrURLExists},
+		{name: "another alias for existing URL", alias: "promo2026", saveErr: storage.ErrURLExists, saved: existingKey, wantKey: existingKey, wantErr: service.ErrAliasMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockRepository(ctrl)
+			serv := service.NewService(repo)
+
+			repo.EXPECT().SaveURL(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, mapping *models.URLMapping) error {
+					assert.Equal(t, tt.alias, mapping.ShortURL)
+					if tt.saved != "" {
+						mapping.ShortURL = tt.saved
+					}
+					return tt.saveErr
+				})
+
+			key, err := serv.GetShortKeyWithOptions(context.Background(), "https://example.com",
+				models.ShortenOptions{CustomAlias: tt.alias})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantKey, key)
+		})
+	}
+
+	t.Run("invalid alias is not saved", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		serv := service.NewService(repo)
+
+		for _, alias := range []string{"ab", "has space", "api", "PING"} {
+			_, err := serv.GetShortKeyWithOptions(context.Background(), "https://example.com",
+				models.ShortenOptions{CustomAlias: alias})
+			assert.ErrorIs(t, err, service.ErrInvalidAlias, alias)
+		}
+	})
+}
+
+func TestBatch_ReportsExistingURLs(t *testing.T) {
+	const baseURL = "http://localhost:8080"
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	serv := service.NewService(repo)
+
+	// Первый URL найден до сохранения, второй сокращён параллельным запросом
+	// и обнаружен хранилищем при вставке
+	repo.EXPECT().GetExistingURLs(gomock.Any(), gomock.Any()).
+		Return(map[string]string{"https://example.com/1": "known"}, nil)
+	repo.EXPECT().SaveNewURLs(gomock.Any(), gomock.Len(2)).
+		DoAndReturn(func(_ context.Context, urls []models.URLMapping) error {
+			urls[0].ShortURL = "raced"
+			return &storage.BatchConflictError{Conflicts: map[int]error{0: storage.ErrURLExists}}
+		})
+
+	resp, err := serv.Batch(context.Background(), []models.BatchRequest{
+		{CorrelationID: "1", OriginalURL: "https://example.com/1"},
+		{CorrelationID: "2", OriginalURL: "https://example.com/2"},
+		{CorrelationID: "3", OriginalURL: "https://example.com/3"},
+	}, baseURL)
+	require.NoError(t, err)
+
+	require.Len(t, resp, 3)
+	assert.Equal(t, models.BatchResponse{CorrelationID: "1", ShortURL: baseURL + "/known", Error: storage.ErrURLExists.Error()}, resp[0])
+	assert.Equal(t, models.BatchResponse{CorrelationID: "2", ShortURL: baseURL + "/raced", Error: storage.ErrURLExists.Error()}, resp[1])
+	assert.Equal(t, "3", resp[2].CorrelationID)
+	assert.NotEmpty(t, resp[2].ShortURL)
+	assert.Empty(t, resp[2].Error)
+}
+
+func TestClose_FlushesClicksBeforeClosingStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	serv := service.NewService(repo)
+
+	gomock.InOrder(
+		repo.EXPECT().RecordClicks(gomock.Any(), gomock.Len(1)).Return(nil),
+		repo.EXPECT().Close().Return(nil),
+	)
+
+	serv.RecordClick(context.Background(), models.ClickEvent{ShortURL: "abc123", ClientIP: "192.0.2.10"})
+	require.NoError(t, serv.Close())
+	assert.Equal(t, uint64(1), serv.ClickStats().Recorded)
+
+	// Повторная остановка после Close не должна паниковать
+	serv.GracefulStop(time.Second)
+}
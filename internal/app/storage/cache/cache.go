@@ -0,0 +1,216 @@
+// Package cache предоставляет кэширующую обёртку над любым хранилищем URL.
+//
+// CachedRepository кэширует результаты GetRedirectURL в LRU-кэше с ограничением
+// размера и времени жизни записей. Кэшируются и отрицательные результаты
+// (ссылка не найдена, удалена или истекла), поэтому повторные запросы
+// несуществующих ссылок тоже не доходят до хранилища.
+//
+// Записи инвалидируются при сохранении и удалении ссылок через эту обёртку.
+// Изменения, сделанные другими экземплярами сервиса в общем хранилище,
+// становятся видны не позже чем через время жизни записи.
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// Параметры кэша по умолчанию.
+const (
+	defaultSize = 10000
+	defaultTTL  = time.Minute
+)
+
+// CachedRepository оборачивает service.Repository и кэширует редиректы.
+// Остальные методы передаются хранилищу без изменений.
+type CachedRepository struct {
+	service.Repository
+
+	cache  *lru
+	size   int
+	ttl    time.Duration
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Option задаёт дополнительные параметры CachedRepository.
+type Option func(*CachedRepository)
+
+// WithSize задаёт максимальное количество записей кэша.
+func WithSize(size int) Option {
+	return func(c *CachedRepository) {
+		if size > 0 {
+			c.size = size
+		}
+	}
+}
+
+// WithTTL задаёт время жизни записи кэша.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *CachedRepository) {
+		if ttl > 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
+// New создает кэширующую обёртку над хранилищем.
+//
+// Параметры:
+//   - repo: хранилище URL
+//   - opts: дополнительные параметры (например, WithSize, WithTTL)
+//
+// Возвращает:
+//   - *CachedRepository: хранилище с кэшем редиректов
+func New(repo service.Repository, opts ...Option) *CachedRepository {
+	c := &CachedRepository{
+		Repository: repo,
+		size:       defaultSize,
+		ttl:        defaultTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.cache = newLRU(c.size)
+
+	return c
+}
+
+// GetRedirectURL возвращает оригинальный URL для сокращенного, обращаясь
+// к хранилищу только при отсутствии записи в кэше.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//	shortKey - сокращенный ключ URL
+//
+// Возвращает:
+//
+//	models.URLMapping - соответствие URL
+//	error - ошибка хранилища (storage.ErrURLNotFound, storage.ErrURLDeleted, storage.ErrURLExpired и др.)
+func (c *CachedRepository) GetRedirectURL(ctx context.Context, shortKey string) (models.URLMapping, error) {
+	now := time.Now()
+	if entry, ok := c.cache.get(shortKey, now); ok {
+		c.hits.Add(1)
+		return entry.result(now)
+	}
+	c.misses.Add(1)
+
+	gen := c.cache.begin(shortKey)
+	mapping, err := c.Repository.GetRedirectURL(ctx, shortKey)
+
+	var entry *lruEntry
+	if isCacheable(err) {
+		entry = &lruEntry{
+			key:     shortKey,
+			mapping: mapping,
+			err:     err,
+			expires: now.Add(c.ttl),
+		}
+	}
+	c.cache.finish(shortKey, gen, entry)
+
+	return mapping, err
+}
+
+// result возвращает закэшированный результат с учётом срока действия ссылки.
+func (e lruEntry) result(now time.Time) (models.URLMapping, error) {
+	if e.err == nil && e.mapping.ExpiresAt != nil && !now.Before(*e.mapping.ExpiresAt) {
+		return models.URLMapping{}, storage.ErrURLExpired
+	}
+	return e.mapping, e.err
+}
+
+// isCacheable проверяет, можно ли закэшировать результат чтения.
+// Ошибки хранилища (например, недоступность БД) не кэшируются.
+func isCacheable(err error) bool {
+	return err == nil ||
+		errors.Is(err, storage.ErrURLNotFound) ||
+		errors.Is(err, storage.ErrURLDeleted) ||
+		errors.Is(err, storage.ErrURLExpired)
+}
+
+// SaveURL сохраняет URL и сбрасывает запись кэша для его короткого ключа
+// (например, отрицательный результат, закэшированный до создания ссылки).
+func (c *CachedRepository) SaveURL(ctx context.Context, mapping *models.URLMapping) error {
+	err := c.Repository.SaveURL(ctx, mapping)
+	c.cache.remove(mapping.ShortURL)
+	return err
+}
+
+// SaveNewURLs сохраняет пакет URL и сбрасывает записи кэша для их коротких ключей.
+func (c *CachedRepository) SaveNewURLs(ctx context.Context, urls []models.URLMapping) error {
+	err := c.Repository.SaveNewURLs(ctx, urls)
+
+	keys := make([]string, len(urls))
+	for i, url := range urls {
+		keys[i] = url.ShortURL
+	}
+	c.cache.remove(keys...)
+
+	return err
+}
+
+// BatchMarkAsDeleted помечает URL удалёнными и сбрасывает записи кэша для
+// удалённых кодов, чтобы следующий редирект сразу вернул 410 Gone.
+// При ошибке хранилища сбрасываются записи всех переданных кодов.
+func (c *CachedRepository) BatchMarkAsDeleted(ctx context.Context, userID string, urls []string) (models.DeleteResult, error) {
+	result, err := c.Repository.BatchMarkAsDeleted(ctx, userID, urls)
+	if err != nil {
+		c.cache.remove(urls...)
+		return result, err
+	}
+	c.cache.remove(result.Deleted...)
+	return result, nil
+}
+
+// BatchRestore восстанавливает URL и сбрасывает записи кэша для
+// восстановленных кодов (закэшированный ответ 410 Gone).
+// При ошибке хранилища сбрасываются записи всех переданных кодов.
+func (c *CachedRepository) BatchRestore(ctx context.Context, userID string, urls []string, deletedAfter time.Time) (models.RestoreResult, error) {
+	result, err := c.Repository.BatchRestore(ctx, userID, urls, deletedAfter)
+	if err != nil {
+		c.cache.remove(urls...)
+		return result, err
+	}
+	c.cache.remove(result.Restored...)
+	return result, nil
+}
+
+// PurgeURLs физически удаляет устаревшие записи.
+//
+// Удалённые коды хранилище не сообщает, поэтому при непустой очистке сбрасываются
+// записи, которые она могла затронуть: закэшированные ответы "удалена" и "истекла"
+// и ссылки, истёкшие раньше before. Действующие ссылки остаются в кэше.
+func (c *CachedRepository) PurgeURLs(ctx context.Context, before time.Time, limit int) (int, error) {
+	n, err := c.Repository.PurgeURLs(ctx, before, limit)
+	if n > 0 {
+		c.cache.removeIf(func(e lruEntry) bool {
+			return purgeable(e, before)
+		})
+	}
+	return n, err
+}
+
+// purgeable проверяет, могла ли очистка с границей before удалить ссылку записи кэша.
+func purgeable(e lruEntry, before time.Time) bool {
+	if errors.Is(e.err, storage.ErrURLDeleted) || errors.Is(e.err, storage.ErrURLExpired) {
+		return true
+	}
+	return e.err == nil && e.mapping.ExpiresAt != nil && e.mapping.ExpiresAt.Before(before)
+}
+
+// CacheStats возвращает счётчики попаданий и промахов кэша.
+func (c *CachedRepository) CacheStats() models.CacheStats {
+	return models.CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: c.cache.len(),
+	}
+}
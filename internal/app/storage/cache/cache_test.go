@@ -0,0 +1,308 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// stubRepository отвечает на GetRedirectURL по таблице results и считает обращения.
+// Если задан block, чтение ключа blockKey ждёт закрытия канала.
+type stubRepository struct {
+	service.Repository
+
+	mu       sync.Mutex
+	results  map[string]result
+	reads    map[string]int
+	purged   int
+	blockKey string
+	started  chan struct{}
+	block    chan struct{}
+}
+
+type result struct {
+	mapping models.URLMapping
+	err     error
+}
+
+func newStubRepository() *stubRepository {
+	return &stubRepository{
+		results: make(map[string]result),
+		reads:   make(map[string]int),
+	}
+}
+
+func (r *stubRepository) set(key string, mapping models.URLMapping, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results[key] = result{mapping: mapping, err: err}
+}
+
+func (r *stubRepository) readCount(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reads[key]
+}
+
+func (r *stubRepository) GetRedirectURL(_ context.Context, key string) (models.URLMapping, error) {
+	r.mu.Lock()
+	r.reads[key]++
+	res, ok := r.results[key]
+	block := r.block
+	blocked := key == r.blockKey && block != nil
+	r.mu.Unlock()
+
+	if blocked {
+		r.started <- struct{}{}
+		<-block
+	}
+	if !ok {
+		return models.URLMapping{}, storage.ErrURLNotFound
+	}
+	return res.mapping, res.err
+}
+
+func (r *stubRepository) SaveURL(_ context.Context, mapping *models.URLMapping) error {
+	r.set(mapping.ShortURL, *mapping, nil)
+	return nil
+}
+
+func (r *stubRepository) BatchMarkAsDeleted(_ context.Context, _ string, urls []string) (models.DeleteResult, error) {
+	for _, key := range urls {
+		r.set(key, models.URLMapping{}, storage.ErrURLDeleted)
+	}
+	return models.DeleteResult{Deleted: urls}, nil
+}
+
+func (r *stubRepository) PurgeURLs(context.Context, time.Time, int) (int, error) {
+	return r.purged, nil
+}
+
+func mapping(key string) models.URLMapping {
+	return models.URLMapping{ShortURL: key, OriginalURL: "https://example.com/" + key}
+}
+
+func TestCachedRepository_HitsAndMisses(t *testing.T) {
+	repo := newStubRepository()
+	repo.set("abc", mapping("abc"), nil)
+	repo.set("gone", models.URLMapping{}, storage.ErrURLDeleted)
+	failure := errors.New("connection refused")
+	repo.set("down", models.URLMapping{}, failure)
+	c := New(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		got, err := c.GetRedirectURL(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, mapping("abc"), got)
+	}
+	assert.Equal(t, 1, repo.readCount("abc"))
+
+	// Отрицательные результаты кэшируются
+	for i := 0; i < 2; i++ {
+		_, err := c.GetRedirectURL(ctx, "missing")
+		assert.ErrorIs(t, err, storage.ErrURLNotFound)
+		_, err = c.GetRedirectURL(ctx, "gone")
+		assert.ErrorIs(t, err, storage.ErrURLDeleted)
+	}
+	assert.Equal(t, 1, repo.readCount("missing"))
+	assert.Equal(t, 1, repo.readCount("gone"))
+
+	// Ошибки хранилища не кэшируются
+	for i := 0; i < 2; i++ {
+		_, err := c.GetRedirectURL(ctx, "down")
+		assert.ErrorIs(t, err, failure)
+	}
+	assert.Equal(t, 2, repo.readCount("down"))
+
+	assert.Equal(t, models.CacheStats{Hits: 4, Misses: 5, Entries: 3}, c.CacheStats())
+}
+
+func TestCachedRepository_EvictsLeastRecentlyUsed(t *testing.T) {
+	repo := newStubRepository()
+	for _, key := range []string{"a", "b", "c"} {
+		repo.set(key, mapping(key), nil)
+	}
+	c := New(repo, WithSize(2))
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "a", "c"} {
+		_, err := c.GetRedirectURL(ctx, key)
+		require.NoError(t, err)
+	}
+
+	// "b" использовалась давнее всех и вытеснена при добавлении "c"
+	assert.Equal(t, 2, c.CacheStats().Entries)
+	for _, key := range []string{"a", "c", "b"} {
+		_, err := c.GetRedirectURL(ctx, key)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, repo.readCount("a"))
+	assert.Equal(t, 1, repo.readCount("c"))
+	assert.Equal(t, 2, repo.readCount("b"))
+}
+
+func TestCachedRepository_TTL(t *testing.T) {
+	repo := newStubRepository()
+	repo.set("abc", mapping("abc"), nil)
+	c := New(repo, WithTTL(20*time.Millisecond))
+	ctx := context.Background()
+
+	_, err := c.GetRedirectURL(ctx, "abc")
+	require.NoError(t, err)
+	_, err = c.GetRedirectURL(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.readCount("abc"))
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = c.GetRedirectURL(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.readCount("abc"))
+}
+
+func TestCachedRepository_Invalidation(t *testing.T) {
+	repo := newStubRepository()
+	c := New(repo)
+	ctx := context.Background()
+
+	// Отрицательный результат сбрасывается при создании ссылки
+	_, err := c.GetRedirectURL(ctx, "abc")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+	m := mapping("abc")
+	require.NoError(t, c.SaveURL(ctx, &m))
+	got, err := c.GetRedirectURL(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, m, got)
+
+	// Удалённая ссылка сразу отдаёт ErrURLDeleted
+	_, err = c.BatchMarkAsDeleted(ctx, "user", []string{"abc"})
+	require.NoError(t, err)
+	_, err = c.GetRedirectURL(ctx, "abc")
+	assert.ErrorIs(t, err, storage.ErrURLDeleted)
+	assert.Equal(t, 3, repo.readCount("abc"))
+}
+
+func TestCachedRepository_InvalidationRacingFill(t *testing.T) {
+	// startFill начинает чтение key, которое ждёт release; возвращает канал завершения
+	startFill := func(c *CachedRepository, repo *stubRepository, key string) (release func(), done <-chan struct{}) {
+		repo.mu.Lock()
+		repo.blockKey = key
+		repo.started = make(chan struct{})
+		repo.block = make(chan struct{})
+		block := repo.block
+		repo.mu.Unlock()
+
+		finished := make(chan struct{})
+		go func() {
+			defer close(finished)
+			_, _ = c.GetRedirectURL(context.Background(), key)
+		}()
+		<-repo.started
+
+		repo.mu.Lock()
+		repo.blockKey = ""
+		repo.mu.Unlock()
+		return func() { close(block) }, finished
+	}
+
+	t.Run("invalidated key is not cached", func(t *testing.T) {
+		repo := newStubRepository()
+		repo.set("abc", mapping("abc"), nil)
+		c := New(repo)
+
+		release, done := startFill(c, repo, "abc")
+		// Ссылка удаляется, пока чтение ещё не вернуло старый результат
+		_, err := c.BatchMarkAsDeleted(context.Background(), "user", []string{"abc"})
+		require.NoError(t, err)
+		release()
+		<-done
+
+		_, err = c.GetRedirectURL(context.Background(), "abc")
+		assert.ErrorIs(t, err, storage.ErrURLDeleted)
+		assert.Equal(t, 2, repo.readCount("abc"))
+	})
+
+	t.Run("invalidation of another key keeps the fill", func(t *testing.T) {
+		repo := newStubRepository()
+		repo.set("abc", mapping("abc"), nil)
+		c := New(repo)
+
+		release, done := startFill(c, repo, "abc")
+		// Запись других ссылок не мешает кэшировать читаемую
+		for _, key := range []string{"x1", "x2", "x3"} {
+			m := mapping(key)
+			require.NoError(t, c.SaveURL(context.Background(), &m))
+		}
+		release()
+		<-done
+
+		_, err := c.GetRedirectURL(context.Background(), "abc")
+		require.NoError(t, err)
+		assert.Equal(t, 1, repo.readCount("abc"))
+	})
+
+	t.Run("purge cancels fills in flight", func(t *testing.T) {
+		repo := newStubRepository()
+		repo.set("abc", models.URLMapping{}, storage.ErrURLDeleted)
+		repo.purged = 1
+		c := New(repo)
+
+		release, done := startFill(c, repo, "abc")
+		_, err := c.PurgeURLs(context.Background(), time.Now(), 100)
+		require.NoError(t, err)
+		repo.set("abc", models.URLMapping{}, storage.ErrURLNotFound)
+		release()
+		<-done
+
+		_, err = c.GetRedirectURL(context.Background(), "abc")
+		assert.ErrorIs(t, err, storage.ErrURLNotFound)
+	})
+}
+
+func TestCachedRepository_PurgeKeepsLiveEntries(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	repo := newStubRepository()
+	repo.set("live", mapping("live"), nil)
+	repo.set("later", models.URLMapping{ShortURL: "later", ExpiresAt: &future}, nil)
+	repo.set("gone", models.URLMapping{}, storage.ErrURLDeleted)
+	repo.set("stale", models.URLMapping{}, storage.ErrURLExpired)
+	c := New(repo)
+	ctx := context.Background()
+
+	keys := []string{"live", "later", "gone", "stale", "missing"}
+	for _, key := range keys {
+		_, _ = c.GetRedirectURL(ctx, key)
+	}
+	require.Equal(t, len(keys), c.CacheStats().Entries)
+
+	// Пустая очистка кэш не трогает
+	_, err := c.PurgeURLs(ctx, past, 100)
+	require.NoError(t, err)
+	assert.Equal(t, len(keys), c.CacheStats().Entries)
+
+	repo.purged = 2
+	_, err = c.PurgeURLs(ctx, now, 100)
+	require.NoError(t, err)
+
+	for _, key := range keys {
+		_, _ = c.GetRedirectURL(ctx, key)
+	}
+	// Действующие ссылки и отсутствующие коды остались в кэше
+	assert.Equal(t, 1, repo.readCount("live"))
+	assert.Equal(t, 1, repo.readCount("later"))
+	assert.Equal(t, 1, repo.readCount("missing"))
+	// Ответы "удалена" и "истекла" перечитаны из хранилища
+	assert.Equal(t, 2, repo.readCount("gone"))
+	assert.Equal(t, 2, repo.readCount("stale"))
+}
@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// lruEntry - закэшированный результат GetRedirectURL.
+type lruEntry struct {
+	key     string
+	mapping models.URLMapping
+	err     error     // nil или одна из ошибок storage (отрицательный результат)
+	expires time.Time // Момент устаревания записи кэша
+}
+
+// fill - чтения из хранилища, выполняющиеся для одного ключа.
+type fill struct {
+	gen     uint64 // Увеличивается при инвалидации ключа во время чтения
+	readers int    // Количество незавершённых чтений
+}
+
+// lru - кэш фиксированного размера с вытеснением давно не использованных записей.
+//
+// Для ключей, которые сейчас читаются из хранилища, хранится поколение (fills).
+// Инвалидация ключа увеличивает его поколение, и результат чтения сохраняется,
+// только если поколение не изменилось с начала чтения: иначе запись, прочитанная
+// до удаления или сохранения ссылки, могла бы вернуться в кэш после инвалидации.
+// Инвалидация других ключей чтение не затрагивает.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List // От недавно использованных к давно использованным
+	fills map[string]*fill
+}
+
+// newLRU создает кэш на size записей.
+func newLRU(size int) *lru {
+	return &lru{
+		size:  size,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+		fills: make(map[string]*fill),
+	}
+}
+
+// get возвращает актуальную запись по ключу. Устаревшая запись удаляется.
+func (c *lru) get(key string, now time.Time) (lruEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return lruEntry{}, false
+	}
+
+	entry := elem.Value.(lruEntry)
+	if !now.Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.items, key)
+		return lruEntry{}, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry, true
+}
+
+// begin регистрирует чтение ключа из хранилища и возвращает поколение ключа.
+// Каждый вызов begin должен завершаться вызовом finish.
+func (c *lru) begin(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, ok := c.fills[key]
+	if !ok {
+		f = &fill{}
+		c.fills[key] = f
+	}
+	f.readers++
+	return f.gen
+}
+
+// finish завершает чтение ключа и сохраняет entry (если не nil),
+// когда ключ не инвалидировался с момента begin.
+func (c *lru) finish(key string, gen uint64, entry *lruEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := c.fills[key]
+	f.readers--
+	if f.readers == 0 {
+		delete(c.fills, key)
+	}
+
+	if entry == nil || f.gen != gen {
+		return
+	}
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value = *entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(*entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(lruEntry).key)
+	}
+}
+
+// remove удаляет записи по ключам и отменяет сохранение их текущих чтений.
+func (c *lru) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.order.Remove(elem)
+			delete(c.items, key)
+		}
+		if f, ok := c.fills[key]; ok {
+			f.gen++
+		}
+	}
+}
+
+// removeIf удаляет записи, для которых match возвращает true, и отменяет
+// сохранение всех текущих чтений: их ключи заранее неизвестны.
+func (c *lru) removeIf(match func(lruEntry) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(lruEntry); match(entry) {
+			c.order.Remove(elem)
+			delete(c.items, entry.key)
+		}
+		elem = next
+	}
+	for _, f := range c.fills {
+		f.gen++
+	}
+}
+
+// len возвращает количество записей.
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,382 @@
+package inmemory
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage/inmemory/recordpb"
+)
+
+// Бинарный файл хранилища (FormatProto, FormatProtoGzip, FormatProtoZstd):
+//
+//	заголовок: "SHRTLOG" | версия (1 байт) | сжатие (1 байт)
+//	кадр:      uvarint(длина тела) | CRC-32C тела (4 байта, big-endian) | тело
+//	тело:      (uvarint(длина записи) | запись)*, сжатое целиком, если сжатие включено
+//
+// Каждая дозапись образует отдельный кадр, снимок упаковывает записи в крупные кадры.
+// Записи - сообщения recordpb.LogRecord (recordpb/record.proto), сериализованные protobuf.
+const (
+	frameVersion   = 1
+	frameHeaderLen = 9
+	maxFrameSize   = 64 << 20 // Защита от чтения мусорной длины кадра
+)
+
+// frameMagic - сигнатура бинарного файла хранилища.
+var frameMagic = []byte("SHRTLOG")
+
+// Способ сжатия тела кадра.
+const (
+	compressionNone byte = iota
+	compressionGzip
+	compressionZstd
+)
+
+// Общие кодировщик и декодировщик zstd безопасны для параллельного использования
+// и создаются при первом обращении.
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) { return zstd.NewWriter(nil) })
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) { return zstd.NewReader(nil) })
+)
+
+// frameCodec реализует бинарные форматы файла хранилища.
+type frameCodec struct {
+	kind        Format
+	compression byte
+}
+
+// newFrameCodec создает кодек бинарного формата.
+func newFrameCodec(format Format) (frameCodec, error) {
+	switch format {
+	case FormatProto:
+		return frameCodec{kind: format, compression: compressionNone}, nil
+	case FormatProtoGzip:
+		return frameCodec{kind: format, compression: compressionGzip}, nil
+	case FormatProtoZstd:
+		return frameCodec{kind: format, compression: compressionZstd}, nil
+	default:
+		return frameCodec{}, fmt.Errorf("unknown storage format %q", format)
+	}
+}
+
+// formatFromHeader определяет формат по заголовку бинарного файла.
+func formatFromHeader(header []byte) (Format, error) {
+	if header[len(frameMagic)] != frameVersion {
+		return "", fmt.Errorf("unsupported storage file version %d", header[len(frameMagic)])
+	}
+
+	switch header[len(frameMagic)+1] {
+	case compressionNone:
+		return FormatProto, nil
+	case compressionGzip:
+		return FormatProtoGzip, nil
+	case compressionZstd:
+		return FormatProtoZstd, nil
+	default:
+		return "", fmt.Errorf("unsupported storage file compression %d", header[len(frameMagic)+1])
+	}
+}
+
+func (c frameCodec) format() Format { return c.kind }
+
+func (c frameCodec) header() []byte {
+	return append(append([]byte(nil), frameMagic...), frameVersion, c.compression)
+}
+
+// encode упаковывает записи в один кадр.
+func (c frameCodec) encode(records []logRecord) ([]byte, error) {
+	var body []byte
+	for _, record := range records {
+		data, err := proto.Marshal(recordToProto(record))
+		if err != nil {
+			return nil, err
+		}
+		body = protowire.AppendBytes(body, data)
+	}
+
+	body, err := c.compress(body)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := binary.AppendUvarint(make([]byte, 0, len(body)+binary.MaxVarintLen64+4), uint64(len(body)))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.Checksum(body, crcTable))
+	return append(frame, body...), nil
+}
+
+// compress сжимает тело кадра.
+func (c frameCodec) compress(body []byte) ([]byte, error) {
+	switch c.compression {
+	case compressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case compressionZstd:
+		enc, err := zstdEncoder()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		return enc.EncodeAll(body, nil), nil
+	default:
+		return body, nil
+	}
+}
+
+// decompress распаковывает тело кадра.
+func (c frameCodec) decompress(body []byte) ([]byte, error) {
+	switch c.compression {
+	case compressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case compressionZstd:
+		dec, err := zstdDecoder()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+		}
+		return dec.DecodeAll(body, nil)
+	default:
+		return body, nil
+	}
+}
+
+func (c frameCodec) newReader(file *os.File, offset int64, _ bool) logReader {
+	return &frameReader{
+		codec:  c,
+		reader: bufio.NewReader(io.NewSectionReader(file, offset, 1<<62)),
+		pos:    offset,
+	}
+}
+
+// frameReader читает кадры бинарного файла хранилища.
+type frameReader struct {
+	codec    frameCodec
+	reader   *bufio.Reader
+	pos      int64
+	frameNum int
+}
+
+func (r *frameReader) offset() int64 { return r.pos }
+
+// next возвращает записи очередного кадра.
+func (r *frameReader) next() ([]logRecord, error) {
+	size, err := binary.ReadUvarint(r.reader)
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	r.frameNum++
+	if err != nil {
+		return nil, r.corrupted(err)
+	}
+	if size > maxFrameSize {
+		return nil, r.corrupted(fmt.Errorf("frame size %d", size))
+	}
+
+	frame := make([]byte, 4+size)
+	if _, err := io.ReadFull(r.reader, frame); err != nil {
+		return nil, r.corrupted(err)
+	}
+
+	body := frame[4:]
+	if crc32.Checksum(body, crcTable) != binary.BigEndian.Uint32(frame) {
+		return nil, r.corrupted(errors.New("checksum mismatch"))
+	}
+
+	body, err = r.codec.decompress(body)
+	if err != nil {
+		return nil, r.corrupted(err)
+	}
+
+	var records []logRecord
+	for len(body) > 0 {
+		data, n := protowire.ConsumeBytes(body)
+		if n < 0 {
+			return nil, r.corrupted(protowire.ParseError(n))
+		}
+		body = body[n:]
+
+		var msg recordpb.LogRecord
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return nil, r.corrupted(err)
+		}
+		records = append(records, recordFromProto(&msg))
+	}
+
+	r.pos += int64(protowire.SizeVarint(size)) + int64(len(frame))
+	return records, nil
+}
+
+// corrupted возвращает errTornRecord для последнего кадра файла
+// и ErrCorruptedRecord для кадра в середине файла.
+func (r *frameReader) corrupted(cause error) error {
+	if errors.Is(cause, io.EOF) || errors.Is(cause, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: frame %d", errTornRecord, r.frameNum)
+	}
+	if _, err := r.reader.Peek(1); errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: frame %d: %v", errTornRecord, r.frameNum, cause)
+	}
+	return fmt.Errorf("%w: frame %d: %v", ErrCorruptedRecord, r.frameNum, cause)
+}
+
+// recordToProto преобразует запись в сообщение LogRecord.
+func recordToProto(record logRecord) *recordpb.LogRecord {
+	switch {
+	case record.Click != nil:
+		return &recordpb.LogRecord{Record: &recordpb.LogRecord_Click{Click: clickToProto(*record.Click)}}
+	case record.Stats != nil:
+		return &recordpb.LogRecord{Record: &recordpb.LogRecord_ClickStats{ClickStats: statsToProto(*record.Stats)}}
+	case record.Purged:
+		return &recordpb.LogRecord{Record: &recordpb.LogRecord_Purged{Purged: mappingToProto(record.UserURLMapping)}}
+	default:
+		return &recordpb.LogRecord{Record: &recordpb.LogRecord_Mapping{Mapping: mappingToProto(record.UserURLMapping)}}
+	}
+}
+
+// recordFromProto преобразует сообщение LogRecord в запись.
+// Сообщение без заполненного поля даёт пустую запись, которую Load пропускает.
+func recordFromProto(msg *recordpb.LogRecord) logRecord {
+	var record logRecord
+	switch r := msg.GetRecord().(type) {
+	case *recordpb.LogRecord_Mapping:
+		record.UserURLMapping = mappingFromProto(r.Mapping)
+	case *recordpb.LogRecord_Purged:
+		record.Purged = true
+		record.UserURLMapping = mappingFromProto(r.Purged)
+	case *recordpb.LogRecord_Click:
+		click := clickFromProto(r.Click)
+		record.Click = &click
+	case *recordpb.LogRecord_ClickStats:
+		snap := statsFromProto(r.ClickStats)
+		record.Stats = &snap
+	}
+	return record
+}
+
+func mappingToProto(m models.UserURLMapping) *recordpb.URLMapping {
+	return &recordpb.URLMapping{
+		ShortUrl:    m.ShortURL,
+		OriginalUrl: m.OriginalURL,
+		UserId:      m.UserID,
+		Uuid:        m.UUID,
+		IsDeleted:   m.DeletedFlag,
+		DeletedAt:   timestampPtr(m.DeletedAt),
+		ExpiresAt:   timestampPtr(m.ExpiresAt),
+		CreatedAt:   timestampPtr(m.CreatedAt),
+	}
+}
+
+func mappingFromProto(m *recordpb.URLMapping) models.UserURLMapping {
+	return models.UserURLMapping{
+		ShortURL:    m.GetShortUrl(),
+		OriginalURL: m.GetOriginalUrl(),
+		UserID:      m.GetUserId(),
+		UUID:        m.GetUuid(),
+		DeletedFlag: m.GetIsDeleted(),
+		DeletedAt:   timePtr(m.GetDeletedAt()),
+		ExpiresAt:   timePtr(m.GetExpiresAt()),
+		CreatedAt:   timePtr(m.GetCreatedAt()),
+	}
+}
+
+func clickToProto(e models.ClickEvent) *recordpb.ClickEvent {
+	return &recordpb.ClickEvent{
+		ShortUrl:  e.ShortURL,
+		At:        timestamppb.New(e.At),
+		Referrer:  e.Referrer,
+		UserAgent: e.UserAgent,
+		IpPrefix:  e.IPPrefix,
+	}
+}
+
+func clickFromProto(e *recordpb.ClickEvent) models.ClickEvent {
+	return models.ClickEvent{
+		ShortURL:  e.GetShortUrl(),
+		At:        e.GetAt().AsTime(),
+		Referrer:  e.GetReferrer(),
+		UserAgent: e.GetUserAgent(),
+		IPPrefix:  e.GetIpPrefix(),
+	}
+}
+
+func statsToProto(s clickSnapshot) *recordpb.ClickStats {
+	return &recordpb.ClickStats{
+		ShortUrl:     s.ShortURL,
+		Clicks:       s.Clicks,
+		FirstClickAt: timestamppb.New(s.FirstClick),
+		LastClickAt:  timestamppb.New(s.LastClick),
+		Referrers:    clickCountsToProto(s.Referrers),
+		UserAgents:   clickCountsToProto(s.UserAgents),
+		IpPrefixes:   s.IPPrefixes,
+	}
+}
+
+func statsFromProto(s *recordpb.ClickStats) clickSnapshot {
+	return clickSnapshot{
+		ShortURL:   s.GetShortUrl(),
+		Clicks:     s.GetClicks(),
+		FirstClick: s.GetFirstClickAt().AsTime(),
+		LastClick:  s.GetLastClickAt().AsTime(),
+		Referrers:  clickCountsFromProto(s.GetReferrers()),
+		UserAgents: clickCountsFromProto(s.GetUserAgents()),
+		IPPrefixes: s.GetIpPrefixes(),
+	}
+}
+
+// clickCountsToProto упорядочивает счётчики так же, как topClickCounts,
+// чтобы снимок был детерминированным.
+func clickCountsToProto(counts map[string]int64) []*api.ClickCount {
+	result := make([]*api.ClickCount, 0, len(counts))
+	for _, count := range topClickCounts(counts, len(counts)) {
+		result = append(result, &api.ClickCount{Value: count.Value, Clicks: count.Clicks})
+	}
+	return result
+}
+
+func clickCountsFromProto(counts []*api.ClickCount) map[string]int64 {
+	result := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		result[count.GetValue()] = count.GetClicks()
+	}
+	return result
+}
+
+// timestampPtr преобразует необязательный момент времени в google.protobuf.Timestamp.
+func timestampPtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// timePtr преобразует необязательный google.protobuf.Timestamp в момент времени (UTC).
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
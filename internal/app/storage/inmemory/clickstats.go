@@ -0,0 +1,268 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// maxTrackedValues ограничивает количество различных значений измерения,
+// хранимых для одной ссылки. Переходы с новыми значениями сверх лимита
+// учитываются в общем счётчике, но не в разбивке.
+const maxTrackedValues = 10000
+
+// clickRecord описывает строку файла хранилища с событием перехода.
+type clickRecord struct {
+	Click models.ClickEvent `json:"click"`
+}
+
+// clickStatsRecord описывает строку снимка хранилища с агрегатами переходов по ссылке.
+type clickStatsRecord struct {
+	Stats clickSnapshot `json:"click_stats"`
+}
+
+// clickSnapshot - сериализуемое представление clickAggregate для снимка хранилища.
+type clickSnapshot struct {
+	ShortURL   string           `json:"short_url"`
+	Clicks     int64            `json:"clicks"`
+	FirstClick time.Time        `json:"first_click_at"`
+	LastClick  time.Time        `json:"last_click_at"`
+	Referrers  map[string]int64 `json:"referrers,omitempty"`
+	UserAgents map[string]int64 `json:"user_agents,omitempty"`
+	IPPrefixes []string         `json:"ip_prefixes,omitempty"`
+}
+
+// clickAggregate содержит агрегированную статистику переходов по одной ссылке.
+type clickAggregate struct {
+	clicks     int64
+	firstClick time.Time
+	lastClick  time.Time
+	referrers  map[string]int64
+	userAgents map[string]int64
+	ipPrefixes map[string]struct{}
+}
+
+// RecordClicks сохраняет пакет событий перехода.
+//
+// Каждое событие дописывается в файл хранилища, чтобы статистика
+// восстанавливалась при следующей загрузке. События для несуществующих
+// ссылок пропускаются.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	events - события перехода
+//
+// Возвращает:
+//
+//	error - ошибка записи в файл
+func (s *InMemoryStorage) RecordClicks(ctx context.Context, events []models.ClickEvent) error {
+	s.mu.Lock()
+
+	records := make([]logRecord, 0, len(events))
+	undo := make([]func(), 0, len(events))
+	for _, event := range events {
+		if _, exists := s.shortCodes.get(event.ShortURL); !exists {
+			continue
+		}
+
+		records = append(records, logRecord{Click: &event})
+		undo = append(undo, s.applyClick(event))
+	}
+	c := s.appendRecords(func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}, records...)
+	s.mu.Unlock()
+
+	return s.await(c)
+}
+
+// GetURLStats возвращает статистику переходов по ссылке текущего пользователя.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	shortKey - короткий идентификатор URL
+//	top - количество значений в разбивках по источникам и User-Agent
+//
+// Возвращает:
+//
+//	models.URLStats - статистика переходов (ShortURL содержит короткий идентификатор)
+//	error - storage.ErrURLNotFound если ссылка не найдена или принадлежит другому пользователю
+func (s *InMemoryStorage) GetURLStats(ctx context.Context, shortKey string, top int) (models.URLStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userID := ctx.Value(jwtauth.UserIDContextKey)
+	if userID == nil {
+		return models.URLStats{}, errors.New("userID is not set")
+	}
+
+	mapping, exists := s.shortCodes.get(shortKey)
+	if !exists || mapping.UserID != userID.(string) {
+		return models.URLStats{}, storage.ErrURLNotFound
+	}
+
+	stats := models.URLStats{
+		ShortURL:    mapping.ShortURL,
+		OriginalURL: mapping.OriginalURL,
+	}
+
+	agg, exists := s.clickStats[shortKey]
+	if !exists {
+		return stats, nil
+	}
+
+	firstClick, lastClick := agg.firstClick, agg.lastClick
+	stats.Clicks = agg.clicks
+	stats.UniqueVisitors = int64(len(agg.ipPrefixes))
+	stats.FirstClickAt = &firstClick
+	stats.LastClickAt = &lastClick
+	stats.TopReferrers = topClickCounts(agg.referrers, top)
+	stats.TopUserAgents = topClickCounts(agg.userAgents, top)
+
+	return stats, nil
+}
+
+// applyClick учитывает событие перехода в агрегатах и возвращает функцию,
+// отменяющую учёт. Вызывается под блокировкой записи.
+func (s *InMemoryStorage) applyClick(event models.ClickEvent) (undo func()) {
+	agg, exists := s.clickStats[event.ShortURL]
+	if !exists {
+		agg = &clickAggregate{
+			firstClick: event.At,
+			lastClick:  event.At,
+			referrers:  make(map[string]int64),
+			userAgents: make(map[string]int64),
+			ipPrefixes: make(map[string]struct{}),
+		}
+		s.clickStats[event.ShortURL] = agg
+	}
+
+	firstClick, lastClick := agg.firstClick, agg.lastClick
+	agg.clicks++
+	if event.At.Before(agg.firstClick) {
+		agg.firstClick = event.At
+	}
+	if event.At.After(agg.lastClick) {
+		agg.lastClick = event.At
+	}
+
+	referrer := countValue(agg.referrers, event.Referrer)
+	userAgent := countValue(agg.userAgents, event.UserAgent)
+	_, knownPrefix := agg.ipPrefixes[event.IPPrefix]
+	newPrefix := event.IPPrefix != "" && !knownPrefix && len(agg.ipPrefixes) < maxTrackedValues
+	if newPrefix {
+		agg.ipPrefixes[event.IPPrefix] = struct{}{}
+	}
+
+	return func() {
+		if !exists {
+			delete(s.clickStats, event.ShortURL)
+			return
+		}
+		agg.clicks--
+		agg.firstClick, agg.lastClick = firstClick, lastClick
+		if referrer {
+			uncountValue(agg.referrers, event.Referrer)
+		}
+		if userAgent {
+			uncountValue(agg.userAgents, event.UserAgent)
+		}
+		if newPrefix {
+			delete(agg.ipPrefixes, event.IPPrefix)
+		}
+	}
+}
+
+// snapshot возвращает представление агрегатов для снимка хранилища.
+func (agg *clickAggregate) snapshot(shortURL string) clickSnapshot {
+	snap := clickSnapshot{
+		ShortURL:   shortURL,
+		Clicks:     agg.clicks,
+		FirstClick: agg.firstClick,
+		LastClick:  agg.lastClick,
+		Referrers:  agg.referrers,
+		UserAgents: agg.userAgents,
+		IPPrefixes: make([]string, 0, len(agg.ipPrefixes)),
+	}
+	for prefix := range agg.ipPrefixes {
+		snap.IPPrefixes = append(snap.IPPrefixes, prefix)
+	}
+	sort.Strings(snap.IPPrefixes)
+	return snap
+}
+
+// restoreClicks восстанавливает агрегаты переходов из снимка. Вызывается под блокировкой записи.
+func (s *InMemoryStorage) restoreClicks(snap clickSnapshot) {
+	agg := &clickAggregate{
+		clicks:     snap.Clicks,
+		firstClick: snap.FirstClick,
+		lastClick:  snap.LastClick,
+		referrers:  snap.Referrers,
+		userAgents: snap.UserAgents,
+		ipPrefixes: make(map[string]struct{}, len(snap.IPPrefixes)),
+	}
+	if agg.referrers == nil {
+		agg.referrers = make(map[string]int64)
+	}
+	if agg.userAgents == nil {
+		agg.userAgents = make(map[string]int64)
+	}
+	for _, prefix := range snap.IPPrefixes {
+		agg.ipPrefixes[prefix] = struct{}{}
+	}
+	s.clickStats[snap.ShortURL] = agg
+}
+
+// countValue увеличивает счётчик значения измерения с учётом лимита maxTrackedValues.
+// Возвращает false, если значение не учтено.
+func countValue(counts map[string]int64, value string) bool {
+	if value == "" {
+		return false
+	}
+	if _, exists := counts[value]; !exists && len(counts) >= maxTrackedValues {
+		return false
+	}
+	counts[value]++
+	return true
+}
+
+// uncountValue отменяет учёт значения функцией countValue.
+func uncountValue(counts map[string]int64, value string) {
+	counts[value]--
+	if counts[value] <= 0 {
+		delete(counts, value)
+	}
+}
+
+// topClickCounts возвращает не более top значений с наибольшим количеством переходов.
+// При равенстве счётчиков значения упорядочиваются лексикографически.
+func topClickCounts(counts map[string]int64, top int) []models.ClickCount {
+	result := make([]models.ClickCount, 0, len(counts))
+	for value, clicks := range counts {
+		result = append(result, models.ClickCount{Value: value, Clicks: clicks})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Clicks != result[j].Clicks {
+			return result[i].Clicks > result[j].Clicks
+		}
+		return result[i].Value < result[j].Value
+	})
+
+	if len(result) > top {
+		result = result[:top]
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,241 @@
+package inmemory
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"go.uber.org/zap"
+)
+
+// Option задаёт дополнительные параметры InMemoryStorage.
+type Option func(*InMemoryStorage)
+
+// WithCompactionInterval включает периодическое сжатие файла хранилища.
+// Сжатие выполняется только если в файле есть устаревшие записи.
+// Нулевой интервал отключает периодическое сжатие; при Close файл сжимается независимо от интервала.
+func WithCompactionInterval(interval time.Duration) Option {
+	return func(s *InMemoryStorage) {
+		s.compactInterval = interval
+	}
+}
+
+// WithFormat задаёт формат файла хранилища (по умолчанию FormatJSON).
+func WithFormat(format Format) Option {
+	return func(s *InMemoryStorage) {
+		s.format = format
+	}
+}
+
+// snapshotFrameRecords - количество записей в одном кадре снимка бинарного формата.
+const snapshotFrameRecords = 1024
+
+// Compact заменяет файл хранилища снимком текущего состояния.
+//
+// Снимок содержит по одной записи на каждую ссылку и агрегаты статистики
+// переходов вместо истории сохранений, удалений и переходов, и записывается
+// в формате, заданном WithFormat. Снимок пишется во временный файл в том же
+// каталоге и атомарно подменяет исходный файл, поэтому при сбое на любом этапе
+// остаётся целый старый или новый файл.
+//
+// Возвращает:
+//
+//	error - ошибка записи снимка
+func (s *InMemoryStorage) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.compact()
+}
+
+// compact выполняет сжатие. Вызывается под блокировкой записи.
+func (s *InMemoryStorage) compact() error {
+	if s.file == nil {
+		return nil
+	}
+
+	codec, err := newCodec(s.format)
+	if err != nil {
+		return err
+	}
+
+	// Снимок отражает состояние памяти, поэтому все записи очереди должны попасть в старый файл
+	if err := s.writer.flush(); err != nil {
+		return err
+	}
+
+	path := s.file.Name()
+	records, err := s.writeSnapshotFile(path, codec)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		// Снимок уже на месте, но дописывать в него нечем
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.writer.setFile(file, info.Size())
+	s.file.Close()
+	s.file = file
+	s.codec = codec
+	s.fileRecords = records
+	s.legacyRecords = 0
+
+	return nil
+}
+
+// writeSnapshotFile атомарно заменяет файл path снимком текущего состояния
+// и возвращает количество записанных записей. Вызывается под блокировкой.
+func (s *InMemoryStorage) writeSnapshotFile(path string, codec logCodec) (records int, err error) {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".compact-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	perm := os.FileMode(0666)
+	if info, statErr := os.Stat(path); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return 0, err
+	}
+
+	if records, err = s.writeSnapshot(tmp, codec); err != nil {
+		return 0, err
+	}
+	if err = tmp.Sync(); err != nil {
+		return 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	syncDir(dir)
+
+	return records, nil
+}
+
+// writeSnapshot записывает текущее состояние хранилища и возвращает количество записей.
+// Ссылки записываются в порядке UUID, чтобы снимок был детерминированным.
+func (s *InMemoryStorage) writeSnapshot(file *os.File, codec logCodec) (int, error) {
+	var mappings []models.UserURLMapping
+	s.shortCodes.rangeLocked(func(mapping models.UserURLMapping) bool {
+		mappings = append(mappings, mapping)
+		return true
+	})
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].UUID < mappings[j].UUID
+	})
+
+	stats := make([]string, 0, len(s.clickStats))
+	for code := range s.clickStats {
+		stats = append(stats, code)
+	}
+	sort.Strings(stats)
+
+	w := bufio.NewWriter(file)
+	if _, err := w.Write(codec.header()); err != nil {
+		return 0, err
+	}
+
+	records := 0
+	batch := make([]logRecord, 0, snapshotFrameRecords)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		data, err := codec.encode(batch)
+		if err != nil {
+			return err
+		}
+		records += len(batch)
+		batch = batch[:0]
+		_, err = w.Write(data)
+		return err
+	}
+	add := func(record logRecord) error {
+		batch = append(batch, record)
+		if len(batch) < snapshotFrameRecords {
+			return nil
+		}
+		return flush()
+	}
+
+	for _, mapping := range mappings {
+		if err := add(logRecord{UserURLMapping: mapping}); err != nil {
+			return records, err
+		}
+	}
+	for _, code := range stats {
+		snap := s.clickStats[code].snapshot(code)
+		if err := add(logRecord{Stats: &snap}); err != nil {
+			return records, err
+		}
+	}
+	if err := flush(); err != nil {
+		return records, err
+	}
+
+	return records, w.Flush()
+}
+
+// needsCompaction сообщает, есть ли в файле записи, которых не будет в снимке,
+// записи без контрольной суммы или файл записан не в заданном формате.
+// Вызывается под блокировкой.
+func (s *InMemoryStorage) needsCompaction() bool {
+	return s.legacyRecords > 0 || s.codec.format() != s.format ||
+		s.fileRecords > s.shortCodes.len()+len(s.clickStats)
+}
+
+// compactionLoop периодически сжимает файл хранилища до вызова Close.
+func (s *InMemoryStorage) compactionLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.compactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.needsCompaction() {
+				if err := s.compact(); err != nil {
+					s.log.Error("Failed to compact storage file", zap.Error(err))
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// syncDir сбрасывает на диск запись каталога, чтобы переименование файла пережило сбой.
+// Ошибки игнорируются: не все платформы поддерживают fsync каталога.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
@@ -0,0 +1,165 @@
+package inmemory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fillHistory записывает в хранилище историю изменений, которую сжатие
+// должно свернуть: сохранения, удаление, восстановление, физическое удаление и переходы.
+func fillHistory(t *testing.T, s *InMemoryStorage) {
+	t.Helper()
+
+	saveURLs(t, s, "user1", 4)
+	saveURLs(t, s, "user2", 2)
+
+	expires := time.Now().Add(time.Hour)
+	mapping := models.URLMapping{ShortURL: "expiring", OriginalURL: "https://example.com/expiring", ExpiresAt: &expires}
+	require.NoError(t, s.SaveURL(userContext("user2"), &mapping))
+
+	ctx := userContext("user1")
+	_, err := s.BatchMarkAsDeleted(ctx, "user1", []string{"user1-0", "user1-1"})
+	require.NoError(t, err)
+	_, err = s.BatchRestore(ctx, "user1", []string{"user1-1"}, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	purged, err := s.PurgeURLs(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+	_, err = s.BatchMarkAsDeleted(ctx, "user1", []string{"user1-2"})
+	require.NoError(t, err)
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	require.NoError(t, s.RecordClicks(ctx, []models.ClickEvent{
+		{ShortURL: "user1-3", At: at, Referrer: "https://ref.example", UserAgent: "curl", IPPrefix: "10.0.0.0/24"},
+		{ShortURL: "user1-3", At: at.Add(time.Second), UserAgent: "curl", IPPrefix: "10.0.1.0/24"},
+		{ShortURL: "user2-0", At: at.Add(time.Minute)},
+	}))
+}
+
+func TestCompact(t *testing.T) {
+	for _, from := range allFormats {
+		for _, to := range allFormats {
+			t.Run(string(from)+"->"+string(to), func(t *testing.T) {
+				path := storagePath(t)
+
+				s := openStorage(t, path, WithFormat(from))
+				fillHistory(t, s)
+				// Файл переводится в другой формат при сжатии
+				s.format = to
+
+				want := stateOf(s)
+				require.True(t, s.needsCompaction())
+
+				before, err := os.Stat(path)
+				require.NoError(t, err)
+
+				require.NoError(t, s.Compact())
+
+				after, err := os.Stat(path)
+				require.NoError(t, err)
+				// Снимок записывается в новый файл и подменяет исходный переименованием
+				assert.False(t, os.SameFile(before, after), "file must be replaced by rename")
+				if from == to {
+					assert.Less(t, after.Size(), before.Size())
+				}
+				assert.Equal(t, after.Mode().Perm(), before.Mode().Perm())
+
+				entries, err := os.ReadDir(filepath.Dir(path))
+				require.NoError(t, err)
+				assert.Len(t, entries, 1, "temporary snapshot file must not be left behind")
+
+				// Снимок содержит по одной записи на ссылку и агрегат переходов
+				assert.Equal(t, len(want.URLs)+len(want.Clicks), s.fileRecords)
+				assert.False(t, s.needsCompaction())
+				assert.Equal(t, want, stateOf(s))
+
+				// Дописанные после сжатия записи попадают в снимок
+				saveURLs(t, s, "user3", 1)
+				want = stateOf(s)
+				require.NoError(t, s.Close())
+
+				reloaded := openStorage(t, path, WithFormat(to))
+				defer reloaded.Close()
+				assert.Equal(t, want, stateOf(reloaded))
+
+				file, err := os.Open(path)
+				require.NoError(t, err)
+				defer file.Close()
+				codec, _, err := detectCodec(file)
+				require.NoError(t, err)
+				assert.Equal(t, to, codec.format())
+
+				// Новые ключи получают UUID больше существующих
+				saveURLs(t, reloaded, "user4", 1)
+				created, _ := reloaded.shortCodes.get("user4-0")
+				for code, mapping := range want.URLs {
+					assert.Greater(t, created.UUID, mapping.UUID, code)
+				}
+			})
+		}
+	}
+}
+
+func TestCompact_SnapshotMatchesLog(t *testing.T) {
+	for _, format := range allFormats {
+		t.Run(string(format), func(t *testing.T) {
+			path := storagePath(t)
+
+			s := openStorage(t, path, WithFormat(format))
+			fillHistory(t, s)
+			require.NoError(t, s.writer.flush())
+
+			// Состояние, восстановленное из полной истории, совпадает с восстановленным из снимка
+			fromLog := newStorage()
+			require.NoError(t, fromLog.load(path, false))
+
+			require.NoError(t, s.Compact())
+			require.NoError(t, s.Close())
+
+			fromSnapshot := newStorage()
+			require.NoError(t, fromSnapshot.load(path, false))
+
+			assert.Equal(t, stateOf(fromLog), stateOf(fromSnapshot))
+
+			ctx := userContext("user1")
+			_, err := fromSnapshot.GetRedirectURL(ctx, "user1-0")
+			assert.ErrorIs(t, err, storage.ErrURLNotFound, "purged URL must not be restored")
+			_, err = fromSnapshot.GetRedirectURL(ctx, "user1-2")
+			assert.ErrorIs(t, err, storage.ErrURLDeleted)
+			_, err = fromSnapshot.GetRedirectURL(ctx, "user1-1")
+			assert.NoError(t, err)
+
+			stats, err := fromSnapshot.GetURLStats(ctx, "user1-3", 10)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), stats.Clicks)
+			assert.Equal(t, int64(2), stats.UniqueVisitors)
+			assert.Equal(t, []models.ClickCount{{Value: "curl", Clicks: 2}}, stats.TopUserAgents)
+		})
+	}
+}
+
+func TestClose_CompactsStaleRecords(t *testing.T) {
+	path := storagePath(t)
+
+	s := openStorage(t, path)
+	saveURLs(t, s, "user1", 2)
+	_, err := s.BatchMarkAsDeleted(userContext("user1"), "user1", []string{"user1-0"})
+	require.NoError(t, err)
+	stale := fileSize(t, path)
+	require.NoError(t, s.Close())
+
+	assert.Less(t, fileSize(t, path), stale)
+
+	s = openStorage(t, path)
+	defer s.Close()
+	assert.Equal(t, 2, s.fileRecords)
+	_, err = s.GetRedirectURL(userContext("user1"), "user1-0")
+	assert.ErrorIs(t, err, storage.ErrURLDeleted)
+}
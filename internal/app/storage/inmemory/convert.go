@@ -0,0 +1,32 @@
+package inmemory
+
+// ConvertFile переписывает файл хранилища src в формате format в файл dst.
+//
+// Формат исходного файла определяется автоматически, исходный файл не изменяется
+// (оборванная последняя запись пропускается). Результат имеет вид снимка
+// хранилища и атомарно подменяет dst; src и dst могут совпадать.
+//
+// Параметры:
+//
+//	src - путь к исходному файлу
+//	dst - путь к результирующему файлу
+//	format - формат результирующего файла
+//	opts - дополнительные параметры (например, WithLogger)
+//
+// Возвращает:
+//
+//	int - количество записей в результирующем файле
+//	error - ошибка чтения или записи
+func ConvertFile(src, dst string, format Format, opts ...Option) (int, error) {
+	codec, err := newCodec(format)
+	if err != nil {
+		return 0, err
+	}
+
+	s := newStorage(append(opts, WithFormat(format))...)
+	if err := s.load(src, false); err != nil {
+		return 0, err
+	}
+
+	return s.writeSnapshotFile(dst, codec)
+}
@@ -0,0 +1,163 @@
+package inmemory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// legacyStorage - файл хранилища предыдущих версий сервиса: JSON-строки без
+// контрольной суммы, в том числе удалённые, просроченные и физически удалённые
+// записи, нечитаемая строка в середине и оборванная последняя запись.
+const legacyStorage = `{"uuid":1,"short_url":"active","original_url":"https://example.com/active","user_id":"user1"}
+{"uuid":2,"short_url":"deleted","original_url":"https://example.com/deleted","user_id":"user1","is_deleted":true,"deleted_at":"2025-01-02T03:04:05Z"}
+{"uuid":3,"short_url":"deleted-before-deleted-at","original_url":"https://example.com/old","user_id":"user2","is_deleted":true}
+{"uuid":
+{"uuid":4,"short_url":"expired","original_url":"https://example.com/expired","user_id":"user2","expires_at":"2020-01-01T00:00:00Z","created_at":"2019-12-01T00:00:00Z"}
+{"uuid":5,"short_url":"expiring","original_url":"https://example.com/expiring","user_id":"user2","expires_at":"2999-01-01T00:00:00Z"}
+{"uuid":6,"short_url":"purged","original_url":"https://example.com/purged","user_id":"user1","is_deleted":true}
+{"uuid":6,"short_url":"purged","original_url":"https://example.com/purged","user_id":"user1","is_purged":true}
+{"uuid":7,"short_url":"restored","original_url":"https://example.com/restored","user_id":"user1","is_deleted":true}
+{"uuid":7,"short_url":"restored","original_url":"https://example.com/restored","user_id":"user1"}
+{"click":{"short_url":"active","at":"2025-03-01T12:00:00Z","referrer":"https://ref.example","user_agent":"curl","ip_prefix":"10.0.0.0/24"}}
+{"uuid":8,"short_url":"torn","original_url":"https://exa`
+
+// loadState загружает файл хранилища и возвращает его состояние.
+//
+// Удалённым до появления deleted_at записям момент удаления назначается
+// при загрузке, поэтому он проверяется отдельно и в состояние не попадает.
+func loadState(t *testing.T, path string) storageState {
+	t.Helper()
+
+	state := stateOf(newLoaded(t, path))
+	mapping := state.URLs["deleted-before-deleted-at"]
+	require.NotNil(t, mapping.DeletedAt)
+	mapping.DeletedAt = nil
+	state.URLs["deleted-before-deleted-at"] = mapping
+	return state
+}
+
+func TestConvertFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "storage.dat")
+	require.NoError(t, os.WriteFile(src, []byte(legacyStorage), 0666))
+
+	want := loadState(t, src)
+	require.Len(t, want.URLs, 6)
+	assert.Contains(t, want.URLs, "expired")
+	assert.True(t, want.URLs["deleted"].DeletedFlag)
+	assert.NotContains(t, want.URLs, "purged")
+	assert.False(t, want.URLs["restored"].DeletedFlag)
+	assert.NotContains(t, want.URLs, "torn")
+	require.Contains(t, want.Clicks, "active")
+	assert.EqualValues(t, 1, want.Clicks["active"].Clicks)
+
+	tests := []struct {
+		name   string
+		format Format
+	}{
+		{name: "json", format: FormatJSON},
+		{name: "protobuf", format: FormatProto},
+		{name: "protobuf+gzip", format: FormatProtoGzip},
+		{name: "protobuf+zstd", format: FormatProtoZstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "storage.bin")
+
+			records, err := ConvertFile(src, dst, tt.format)
+			require.NoError(t, err)
+			// Снимок содержит по одной записи на ссылку и агрегат переходов
+			assert.Equal(t, len(want.URLs)+len(want.Clicks), records)
+
+			file, err := os.Open(dst)
+			require.NoError(t, err)
+			codec, _, err := detectCodec(file)
+			require.NoError(t, file.Close())
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, codec.format())
+
+			converted := loadState(t, dst)
+			assert.Equal(t, want, converted)
+
+			// Исходный файл не изменяется, оборванная запись в нём остаётся
+			data, err := os.ReadFile(src)
+			require.NoError(t, err)
+			assert.Equal(t, legacyStorage, string(data))
+
+			// Обратная конвертация в JSON сохраняет состояние, включая момент удаления
+			back := filepath.Join(t.TempDir(), "storage.dat")
+			records, err = ConvertFile(dst, back, FormatJSON)
+			require.NoError(t, err)
+			assert.Equal(t, len(want.URLs)+len(want.Clicks), records)
+
+			s := openStorage(t, back)
+			defer s.Close()
+			assert.False(t, s.needsCompaction(), "converted file must not contain legacy records")
+
+			ctx := userContext("user1")
+			_, err = s.GetRedirectURL(ctx, "active")
+			assert.NoError(t, err)
+			_, err = s.GetRedirectURL(ctx, "deleted")
+			assert.ErrorIs(t, err, storage.ErrURLDeleted)
+			_, err = s.GetRedirectURL(ctx, "expired")
+			assert.ErrorIs(t, err, storage.ErrURLExpired)
+
+			assert.Equal(t, stateOf(newLoaded(t, dst)), stateOf(s))
+
+			// Новые ключи получают UUID больше существующих
+			saveURLs(t, s, "user3", 1)
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			assert.Greater(t, s.countRecords, uint64(7))
+		})
+	}
+
+	t.Run("in place", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "storage.dat")
+		require.NoError(t, os.WriteFile(path, []byte(legacyStorage), 0666))
+
+		core, logs := observer.New(zap.WarnLevel)
+		_, err := ConvertFile(path, path, FormatProtoZstd, WithLogger(zap.New(core)))
+		require.NoError(t, err)
+		assert.Equal(t, want, loadState(t, path))
+		assert.Equal(t, 1, logs.FilterMessage("Discarding torn record at the end of storage file").Len())
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary snapshot file must not be left behind")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "storage.bin")
+		_, err := ConvertFile(src, dst, Format("xml"))
+		assert.Error(t, err)
+		assert.NoFileExists(t, dst)
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "storage.bin")
+		_, err := ConvertFile(filepath.Join(dir, "missing.dat"), dst, FormatProto)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.NoFileExists(t, dst)
+	})
+}
+
+// newLoaded возвращает хранилище, загруженное из файла path так же, как
+// ConvertFile: оборванная последняя запись пропускается, файл не изменяется.
+func newLoaded(t *testing.T, path string) *InMemoryStorage {
+	t.Helper()
+
+	s := newStorage()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.NoError(t, s.load(path, false))
+	return s
+}
@@ -0,0 +1,97 @@
+package inmemory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Format определяет формат файла хранилища.
+type Format string
+
+// Поддерживаемые форматы файла хранилища.
+const (
+	FormatJSON      Format = "json"          // JSON-строки с контрольной суммой
+	FormatProto     Format = "protobuf"      // Кадры protobuf-записей
+	FormatProtoGzip Format = "protobuf+gzip" // Кадры protobuf-записей, сжатые gzip
+	FormatProtoZstd Format = "protobuf+zstd" // Кадры protobuf-записей, сжатые zstd
+)
+
+// ParseFormat проверяет название формата файла хранилища.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatJSON, FormatProto, FormatProtoGzip, FormatProtoZstd:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown storage format %q", name)
+	}
+}
+
+// errTornRecord сообщает, что последняя запись файла оборвана при сбое
+// и файл нужно усечь до конца последней целой записи.
+var errTornRecord = errors.New("torn storage record")
+
+// logCodec сериализует записи файла хранилища в конкретном формате.
+type logCodec interface {
+	// format возвращает формат, который реализует кодек.
+	format() Format
+	// header возвращает заголовок нового файла (nil, если заголовок не нужен).
+	header() []byte
+	// encode сериализует записи в блок для дозаписи в конец файла.
+	encode(records []logRecord) ([]byte, error)
+	// newReader возвращает читатель записей файла, начиная со смещения offset.
+	// При repair читатель может исправлять файл (например, дописывать перевод строки).
+	newReader(file *os.File, offset int64, repair bool) logReader
+}
+
+// logReader последовательно читает записи файла хранилища.
+type logReader interface {
+	// next возвращает записи очередного блока. Возвращает io.EOF в конце файла,
+	// errTornRecord для оборванного последнего блока и ErrCorruptedRecord
+	// при повреждении в середине файла.
+	next() ([]logRecord, error)
+	// offset возвращает смещение конца последнего прочитанного целого блока.
+	offset() int64
+}
+
+// newCodec возвращает кодек для формата.
+func newCodec(format Format) (logCodec, error) {
+	switch format {
+	case FormatJSON:
+		return jsonCodec{}, nil
+	case FormatProto, FormatProtoGzip, FormatProtoZstd:
+		return newFrameCodec(format)
+	default:
+		return nil, fmt.Errorf("unknown storage format %q", format)
+	}
+}
+
+// detectCodec определяет формат файла по его началу и возвращает кодек
+// и длину заголовка. Для пустого файла возвращает nil-кодек.
+// Оборванный заголовок бинарного файла приводит к errTornRecord.
+func detectCodec(file *os.File) (logCodec, int64, error) {
+	buf := make([]byte, frameHeaderLen)
+	n, err := file.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, 0, err
+	}
+	buf = buf[:n]
+
+	switch {
+	case n == 0:
+		return nil, 0, nil
+	case n == frameHeaderLen && bytes.HasPrefix(buf, frameMagic):
+		format, err := formatFromHeader(buf)
+		if err != nil {
+			return nil, 0, err
+		}
+		codec, err := newFrameCodec(format)
+		return codec, frameHeaderLen, err
+	case bytes.HasPrefix(frameMagic, buf) || (n < frameHeaderLen && bytes.HasPrefix(buf, frameMagic)):
+		return nil, 0, errTornRecord
+	default:
+		return jsonCodec{}, 0, nil
+	}
+}
@@ -2,46 +2,93 @@
 //
 // Основные особенности:
 // - Хранение данных в памяти с синхронизацией через RWMutex
-// - Сохранение данных в файл в формате JSON (append-only лог)
+// - Индекс коротких ссылок разбит на сегменты, поэтому редиректы не ждут записи в файл
+// - Сохранение данных в файл (append-only лог с контрольными суммами записей)
+// - Форматы файла: JSON-строки или protobuf-кадры с необязательным сжатием gzip/zstd
+// - Периодическое сжатие файла в снимок текущего состояния
+// - Групповая запись в файл с настраиваемым режимом fsync (SyncMode)
+// - Изменения, которые не удалось записать в файл, отменяются в памяти;
+// после ошибки записи хранилище отклоняет изменения до перезапуска
 // - Поддержка транзакционности операций
 // - Оптимизированное чтение для операций редиректа
 package inmemory
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
+	"io"
 	"os"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/ryabkov82/shortener/internal/app/jwtauth"
 	"github.com/ryabkov82/shortener/internal/app/models"
 	"github.com/ryabkov82/shortener/internal/app/storage"
+	"go.uber.org/zap"
 )
 
+// logRecord описывает запись файла хранилища.
+//
+// Помимо самого соответствия URL запись может быть "надгробием" (Purged),
+// которое сообщает Load, что ссылка физически удалена и не должна загружаться,
+// либо событием перехода (Click), из которого восстанавливается статистика,
+// либо агрегатами переходов из снимка хранилища (Stats).
+// Встраивание сохраняет совместимость с файлами, записанными до появления поля.
+type logRecord struct {
+	models.UserURLMapping
+	Purged bool               `json:"is_purged,omitempty"`
+	Click  *models.ClickEvent `json:"click,omitempty"`
+	Stats  *clickSnapshot     `json:"click_stats,omitempty"`
+
+	legacy bool // Запись прочитана из строки без контрольной суммы
+}
+
 // InMemoryStorage реализует интерфейс хранилища с in-memory кешем и файловой персистентностью.
 //
 // Структура использует:
 // - userURLIndex: индекс для быстрого поиска по пользователю и оригинальному URL
-// - shortCodeMap: основное хранилище сопоставлений
+// - shortCodes: основное хранилище сопоставлений, разбитое на сегменты (см. shardedURLs)
 // - countRecords: счетчик записей для генерации UUID
-// - file/encoder: для персистентного хранения
-// - mu: RWMutex для синхронизации доступа
+// - clickStats: агрегаты переходов по коротким ссылкам
+// - file/codec: для персистентного хранения (codec соответствует формату открытого файла)
+// - writer: горутина групповой записи в file
+// - pending: изменения в памяти, запись которых в файл ещё не подтверждена
+// - format: формат, в который файл переводится при сжатии
+// - fileRecords/legacyRecords: количество записей в файле (всего и без контрольной суммы)
+// - mu: RWMutex для синхронизации изменений, файла и индексов, кроме чтения shortCodes
 type InMemoryStorage struct {
-	userURLIndex map[string]map[string]string
-	shortCodeMap map[string]models.UserURLMapping
-	file         *os.File
-	encoder      *json.Encoder
-	countRecords uint64
-	mu           sync.RWMutex
+	userURLIndex    map[string]map[string]string
+	shortCodes      *shardedURLs
+	clickStats      map[string]*clickAggregate
+	file            *os.File
+	codec           logCodec
+	writer          *logWriter
+	pending         []pendingChange
+	format          Format
+	syncMode        SyncMode
+	syncInterval    time.Duration
+	countRecords    uint64
+	fileRecords     int
+	legacyRecords   int
+	compactInterval time.Duration
+	log             *zap.Logger
+	stopChan        chan struct{}
+	stopOnce        sync.Once
+	wg              sync.WaitGroup
+	mu              sync.RWMutex
 }
 
 // NewInMemoryStorage создает новое in-memory хранилище с файловой персистентностью.
 //
+// Новый файл создаётся в формате, заданном WithFormat (по умолчанию FormatJSON).
+// В существующий файл записи дописываются в его собственном формате,
+// а в заданный формат он переводится при следующем сжатии.
+//
 // Параметры:
 //
 //	fileStoragePath - путь к файлу для хранения данных
+//	opts - дополнительные параметры (например, WithCompactionInterval)
 //
 // Возвращает:
 //
@@ -50,26 +97,93 @@ type InMemoryStorage struct {
 //
 // Пример:
 //
-//	storage, err := NewInMemoryStorage("data/storage.json")
-func NewInMemoryStorage(fileStoragePath string) (*InMemoryStorage, error) {
-	file, err := os.OpenFile(fileStoragePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+//	storage, err := NewInMemoryStorage("data/storage.json", WithCompactionInterval(10*time.Minute))
+func NewInMemoryStorage(fileStoragePath string, opts ...Option) (*InMemoryStorage, error) {
+	s := newStorage(opts...)
+
+	codec, err := newCodec(s.format)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(fileStoragePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, _, err := detectCodec(file)
+	if errors.Is(err, errTornRecord) {
+		// Сбой при записи заголовка нового файла: файл не содержит записей
+		err = file.Truncate(0)
+	}
 	if err != nil {
+		file.Close()
 		return nil, err
 	}
 
-	return &InMemoryStorage{
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s.file = file
+	s.codec = codec
+	s.writer = newLogWriter(file, info.Size(), s.syncMode, s.syncInterval, s.log)
+
+	if existing != nil {
+		s.codec = existing
+	} else if header := codec.header(); header != nil {
+		_, c := s.writer.submit(header)
+		if err := c.wait(); err != nil {
+			s.writer.close()
+			file.Close()
+			return nil, err
+		}
+	}
+
+	if s.compactInterval > 0 {
+		s.wg.Add(1)
+		go s.compactionLoop()
+	}
+
+	return s, nil
+}
+
+// WithLogger задаёт логгер хранилища: отброшенные при загрузке записи и ошибки
+// записи и сжатия файла. По умолчанию события не логируются.
+func WithLogger(log *zap.Logger) Option {
+	return func(s *InMemoryStorage) {
+		s.log = log
+	}
+}
+
+// newStorage создает хранилище без открытого файла.
+func newStorage(opts ...Option) *InMemoryStorage {
+	s := &InMemoryStorage{
 		userURLIndex: make(map[string]map[string]string),
-		shortCodeMap: make(map[string]models.UserURLMapping),
+		shortCodes:   newShardedURLs(),
+		clickStats:   make(map[string]*clickAggregate),
 		countRecords: 0,
-		file:         file,
-		encoder:      json.NewEncoder(file),
-	}, nil
+		format:       FormatJSON,
+		syncMode:     SyncNever,
+		log:          zap.NewNop(),
+		stopChan:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Load загружает данные из файла в память.
 //
-// Формат файла: JSON-строки (по одной на запись)
-// В случае ошибки в строке она пропускается, но загрузка продолжается
+// Формат файла определяется автоматически (см. Format). Для JSON-строк
+// поддерживаются строки без контрольной суммы, записанные предыдущими версиями;
+// нечитаемые строки такого формата пропускаются.
+// Оборванная при сбое последняя запись отбрасывается, а файл усекается до последней целой записи.
 //
 // Параметры:
 //
@@ -77,43 +191,212 @@ func NewInMemoryStorage(fileStoragePath string) (*InMemoryStorage, error) {
 //
 // Возвращает:
 //
-//	error - ошибка чтения файла
+//	error - ошибка чтения файла или ErrCorruptedRecord при повреждении записи в середине файла
 func (s *InMemoryStorage) Load(fileStoragePath string) error {
-	file, err := os.OpenFile(fileStoragePath, os.O_RDONLY|os.O_CREATE, 0666)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(fileStoragePath, true); err != nil {
+		return err
+	}
+
+	// Оборванная запись могла быть удалена из открытого файла
+	if s.file != nil && s.file.Name() == fileStoragePath {
+		if err := s.writer.flush(); err != nil {
+			return err
+		}
+		info, err := s.file.Stat()
+		if err != nil {
+			return err
+		}
+		s.writer.setFile(s.file, info.Size())
+	}
+	return nil
+}
+
+// load читает файл хранилища. При repair оборванная последняя запись
+// удаляется из файла, иначе только пропускается. Вызывается под блокировкой записи.
+func (s *InMemoryStorage) load(fileStoragePath string, repair bool) error {
+	flags := os.O_RDONLY
+	if repair {
+		flags = os.O_RDWR | os.O_CREATE
+	}
+
+	file, err := os.OpenFile(fileStoragePath, flags, 0666)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	var countRecords uint64
-
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
+	codec, headerLen, err := detectCodec(file)
+	if errors.Is(err, errTornRecord) {
+		if repair {
+			return file.Truncate(0)
 		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if codec == nil {
+		return nil
+	}
 
-		var url models.UserURLMapping
-		if err := json.Unmarshal(line, &url); err != nil {
-			continue
+	reader := codec.newReader(file, headerLen, repair)
+	var (
+		countRecords  uint64
+		fileRecords   int
+		legacyRecords int
+	)
+	loadedAt := time.Now()
+
+	for {
+		records, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
 		}
-
-		if url.UserID == "" || url.OriginalURL == "" || url.ShortURL == "" {
-			continue
+		if errors.Is(err, errTornRecord) {
+			// Запись оборвана при сбое: отбрасываем её, чтобы новые записи не склеились с мусором
+			s.log.Warn("Discarding torn record at the end of storage file",
+				zap.String("file", fileStoragePath),
+				zap.Error(err))
+			if repair {
+				if err := file.Truncate(reader.offset()); err != nil {
+					return err
+				}
+			}
+			break
 		}
-
-		if _, ok := s.userURLIndex[url.UserID]; !ok {
-			s.userURLIndex[url.UserID] = make(map[string]string)
+		if err != nil {
+			return err
 		}
 
-		s.userURLIndex[url.UserID][url.OriginalURL] = url.ShortURL
-		s.shortCodeMap[url.ShortURL] = url
-		countRecords++
+		for _, record := range records {
+			fileRecords++
+			if record.legacy {
+				legacyRecords++
+			}
+
+			if s.applyRecord(record, loadedAt) {
+				countRecords = max(countRecords+1, record.UUID)
+			}
+		}
 	}
 
 	s.countRecords = countRecords
-	return scanner.Err()
+	s.fileRecords = fileRecords
+	s.legacyRecords = legacyRecords
+	return nil
+}
+
+// applyRecord применяет запись файла к состоянию хранилища.
+// Возвращает true для записей соответствия URL, учитываемых в счётчике UUID.
+// Вызывается под блокировкой записи.
+func (s *InMemoryStorage) applyRecord(record logRecord, loadedAt time.Time) bool {
+	if record.Click != nil {
+		s.applyClick(*record.Click)
+		return false
+	}
+
+	if record.Stats != nil {
+		s.restoreClicks(*record.Stats)
+		return false
+	}
+
+	url := record.UserURLMapping
+	if url.UserID == "" || url.OriginalURL == "" || url.ShortURL == "" {
+		return false
+	}
+
+	if record.Purged {
+		s.removeMapping(url)
+		return true
+	}
+
+	// Записи, удалённые до появления deleted_at, отсчитывают срок хранения от момента загрузки
+	if url.DeletedFlag && url.DeletedAt == nil {
+		url.DeletedAt = &loadedAt
+	}
+
+	s.addMapping(url)
+	return true
+}
+
+// pendingChange - изменение в памяти, запись которого в файл ещё не подтверждена.
+type pendingChange struct {
+	seq  uint64 // Порядковый номер запроса записи (см. logWriter.submit)
+	undo func()
+}
+
+// appendRecords ставит записи в очередь на дозапись в файл хранилища.
+//
+// Вызывается под блокировкой записи после изменения памяти, поэтому порядок
+// записей в файле совпадает с порядком изменений. undo отменяет изменение
+// в памяти, если записать его не удастся. Возвращённый commit следует ожидать
+// через await после снятия блокировки, чтобы одновременные сохранения
+// объединялись в одну запись в файл и один fsync.
+func (s *InMemoryStorage) appendRecords(undo func(), records ...logRecord) commit {
+	if len(records) == 0 {
+		return nil
+	}
+	if s.writer == nil {
+		undo()
+		return failedCommit(os.ErrClosed)
+	}
+
+	data, err := s.codec.encode(records)
+	if err != nil {
+		undo()
+		return failedCommit(err)
+	}
+
+	seq, c := s.writer.submit(data)
+	s.fileRecords += len(records)
+
+	// Подтверждённые изменения отменять уже не нужно
+	committed := s.writer.lastCommitted()
+	n := 0
+	for n < len(s.pending) && s.pending[n].seq <= committed {
+		n++
+	}
+	s.pending = slices.Delete(s.pending, 0, n)
+
+	s.pending = append(s.pending, pendingChange{seq: seq, undo: func() {
+		s.fileRecords -= len(records)
+		undo()
+	}})
+	return c
+}
+
+// await дожидается записи в файл. Если запись не удалась, изменения,
+// не попавшие в файл, отменяются, чтобы повторный запрос не увидел
+// изменение, которое исчезнет после перезапуска.
+func (s *InMemoryStorage) await(c commit) error {
+	err := c.wait()
+	if errors.Is(err, errWriteFailed) {
+		s.mu.Lock()
+		s.rollback()
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// rollback отменяет в обратном порядке изменения, запись которых не подтверждена.
+//
+// После ошибки записи logWriter отклоняет все последующие запросы, поэтому
+// неподтверждённые изменения уже не попадут в файл, а подтверждённые
+// предшествуют им. Вызывается под блокировкой записи.
+func (s *InMemoryStorage) rollback() {
+	if s.writer == nil {
+		return
+	}
+
+	committed := s.writer.lastCommitted()
+	for len(s.pending) > 0 && s.pending[len(s.pending)-1].seq > committed {
+		change := s.pending[len(s.pending)-1]
+		s.pending = s.pending[:len(s.pending)-1]
+		change.undo()
+	}
 }
 
 // GetShortKey возвращает короткий ключ для оригинального URL пользователя.
@@ -149,6 +432,9 @@ func (s *InMemoryStorage) GetShortKey(ctx context.Context, originalURL string) (
 
 // GetRedirectURL возвращает оригинальный URL для редиректа.
 //
+// Блокируется только сегмент индекса коротких ссылок, поэтому редирект
+// не ждёт записи в файл, сжатия и изменений других сегментов.
+//
 // Параметры:
 //
 //	ctx - контекст запроса
@@ -160,11 +446,9 @@ func (s *InMemoryStorage) GetShortKey(ctx context.Context, originalURL string) (
 //	error:
 //	  - storage.ErrURLNotFound если URL не существует
 //	  - storage.ErrURLDeleted если URL помечен как удаленный
+//	  - storage.ErrURLExpired если срок действия URL истёк
 func (s *InMemoryStorage) GetRedirectURL(ctx context.Context, shortKey string) (models.URLMapping, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	url, found := s.shortCodeMap[shortKey]
+	url, found := s.shortCodes.load(shortKey)
 	if !found {
 		return models.URLMapping{}, storage.ErrURLNotFound
 	}
@@ -173,9 +457,14 @@ func (s *InMemoryStorage) GetRedirectURL(ctx context.Context, shortKey string) (
 		return models.URLMapping{}, storage.ErrURLDeleted
 	}
 
+	if url.ExpiresAt != nil && !time.Now().Before(*url.ExpiresAt) {
+		return models.URLMapping{}, storage.ErrURLExpired
+	}
+
 	return models.URLMapping{
 		ShortURL:    url.ShortURL,
 		OriginalURL: url.OriginalURL,
+		ExpiresAt:   url.ExpiresAt,
 	}, nil
 }
 
@@ -192,40 +481,61 @@ func (s *InMemoryStorage) GetRedirectURL(ctx context.Context, shortKey string) (
 //	  - storage.ErrShortURLExists если shortURL уже существует
 //	  - storage.ErrURLExists если originalURL уже существует
 func (s *InMemoryStorage) SaveURL(ctx context.Context, mapping *models.URLMapping) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, found := s.shortCodeMap[mapping.ShortURL]; found {
-		return storage.ErrShortURLExists
-	}
-
 	userID := ctx.Value(jwtauth.UserIDContextKey)
 	if userID == nil {
 		return errors.New("userID is not set")
 	}
 
-	if _, ok := s.userURLIndex[userID.(string)]; !ok {
-		s.userURLIndex[userID.(string)] = make(map[string]string)
+	s.mu.Lock()
+	record, err := s.insertURL(userID.(string), mapping)
+	var c commit
+	if err == nil {
+		c = s.appendRecords(func() { s.removeMapping(record) }, logRecord{UserURLMapping: record})
 	}
+	s.mu.Unlock()
 
-	if shortURL, exists := s.userURLIndex[userID.(string)][mapping.OriginalURL]; exists {
+	if err != nil {
+		return err
+	}
+	return s.await(c)
+}
+
+// insertURL добавляет соответствие URL пользователя userID в память
+// и возвращает запись для файла. Вызывается под блокировкой записи.
+func (s *InMemoryStorage) insertURL(userID string, mapping *models.URLMapping) (models.UserURLMapping, error) {
+	// Проверки выполняются под одной блокировкой записи, поэтому занять
+	// один и тот же короткий ключ (в т.ч. пользовательский псевдоним) дважды нельзя.
+	// Как и в PostgreSQL, существующая пара (пользователь, URL) имеет приоритет
+	// перед конфликтом по короткому ключу.
+	if shortURL, exists := s.userURLIndex[userID][mapping.OriginalURL]; exists {
 		mapping.ShortURL = shortURL
-		return storage.ErrURLExists
+		return models.UserURLMapping{}, storage.ErrURLExists
+	}
+
+	if _, found := s.shortCodes.get(mapping.ShortURL); found {
+		return models.UserURLMapping{}, storage.ErrShortURLExists
+	}
+
+	if _, ok := s.userURLIndex[userID]; !ok {
+		s.userURLIndex[userID] = make(map[string]string)
 	}
 
-	s.userURLIndex[userID.(string)][mapping.OriginalURL] = mapping.ShortURL
+	s.userURLIndex[userID][mapping.OriginalURL] = mapping.ShortURL
 	s.countRecords++
 
+	now := time.Now()
 	userURLMapping := models.UserURLMapping{
 		UUID:        s.countRecords,
 		ShortURL:    mapping.ShortURL,
 		OriginalURL: mapping.OriginalURL,
-		UserID:      userID.(string),
+		UserID:      userID,
 		DeletedFlag: false,
+		ExpiresAt:   mapping.ExpiresAt,
+		CreatedAt:   &now,
 	}
-	s.shortCodeMap[mapping.ShortURL] = userURLMapping
+	s.shortCodes.store(userURLMapping)
 
-	return s.encoder.Encode(userURLMapping)
+	return userURLMapping, nil
 }
 
 // Ping проверяет доступность хранилища (всегда возвращает nil).
@@ -266,6 +576,10 @@ func (s *InMemoryStorage) GetExistingURLs(ctx context.Context, originalURLs []st
 
 // SaveNewURLs сохраняет список новых URL.
 //
+// Пакет сохраняется целиком: если хотя бы один короткий ключ уже занят
+// (или повторяется внутри пакета), ни один URL не сохраняется.
+// URL, уже сокращённые пользователем, пропускаются.
+//
 // Параметры:
 //
 //	ctx - контекст с userID
@@ -273,49 +587,93 @@ func (s *InMemoryStorage) GetExistingURLs(ctx context.Context, originalURLs []st
 //
 // Возвращает:
 //
-//	error - первая ошибка при сохранении
+//	error - ошибка операции:
+//	  - storage.ErrShortURLExists если короткий ключ уже занят
 func (s *InMemoryStorage) SaveNewURLs(ctx context.Context, urls []models.URLMapping) error {
+	userID := ctx.Value(jwtauth.UserIDContextKey)
+	if userID == nil {
+		return errors.New("userID is not set")
+	}
+
+	c, err := s.saveNewURLs(userID.(string), urls)
+	if err != nil {
+		return err
+	}
+	return s.await(c)
+}
+
+// saveNewURLs проверяет и добавляет пакет URL в память под блокировкой записи.
+func (s *InMemoryStorage) saveNewURLs(userID string, urls []models.URLMapping) (commit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batchCodes := make(map[string]struct{}, len(urls))
 	for _, url := range urls {
-		if err := s.SaveURL(ctx, &url); err != nil && !errors.Is(err, storage.ErrURLExists) {
-			return err
+		if _, exists := s.userURLIndex[userID][url.OriginalURL]; exists {
+			continue
+		}
+		if _, found := s.shortCodes.get(url.ShortURL); found {
+			return nil, storage.ErrShortURLExists
+		}
+		if _, found := batchCodes[url.ShortURL]; found {
+			return nil, storage.ErrShortURLExists
 		}
+		batchCodes[url.ShortURL] = struct{}{}
 	}
-	return nil
+
+	records := make([]logRecord, 0, len(urls))
+	undo := func() {
+		for i := len(records) - 1; i >= 0; i-- {
+			s.removeMapping(records[i].UserURLMapping)
+		}
+	}
+	for _, url := range urls {
+		record, err := s.insertURL(userID, &url)
+		if errors.Is(err, storage.ErrURLExists) {
+			continue
+		}
+		if err != nil {
+			undo()
+			return nil, err
+		}
+		records = append(records, logRecord{UserURLMapping: record})
+	}
+	return s.appendRecords(undo, records...), nil
 }
 
-// GetUserUrls возвращает все URL пользователя.
+// GetUserUrls возвращает страницу URL пользователя.
+//
+// Записи упорядочены по моменту создания, затем по UUID, поэтому порядок
+// не меняется между запросами. Записи, созданные до появления момента
+// создания, идут первыми в порядке добавления.
 //
 // Параметры:
 //
 //	ctx - контекст с userID
 //	baseURL - базовый URL для построения полных коротких URL
+//	query - фильтры, порядок и страница
 //
 // Возвращает:
 //
-//	[]models.URLMapping - список URL пользователя
-//	error - ошибка операции
-func (s *InMemoryStorage) GetUserUrls(ctx context.Context, baseURL string) ([]models.URLMapping, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
+//	models.UserURLsPage - страница URL пользователя
+//	error - ошибка операции (storage.ErrInvalidCursor для неверного курсора)
+func (s *InMemoryStorage) GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error) {
 	userID := ctx.Value(jwtauth.UserIDContextKey)
 	if userID == nil {
-		return nil, errors.New("userID is not set")
+		return models.UserURLsPage{}, errors.New("userID is not set")
 	}
 
-	userURLs, exists := s.userURLIndex[userID.(string)]
-	if !exists {
-		return nil, nil
+	s.mu.RLock()
+	userURLs := s.userURLIndex[userID.(string)]
+	records := make([]models.UserURLMapping, 0, len(userURLs))
+	for _, shortCode := range userURLs {
+		if mapping, ok := s.shortCodes.get(shortCode); ok {
+			records = append(records, mapping)
+		}
 	}
+	s.mu.RUnlock()
 
-	var result []models.URLMapping
-	for originalURL, shortCode := range userURLs {
-		result = append(result, models.URLMapping{
-			OriginalURL: originalURL,
-			ShortURL:    baseURL + "/" + shortCode,
-		})
-	}
-	return result, nil
+	return storage.PageUserURLs(records, baseURL, query)
 }
 
 // CountURLs возвращает количество сокращённых URL в сервисе.
@@ -329,7 +687,7 @@ func (s *InMemoryStorage) GetUserUrls(ctx context.Context, baseURL string) ([]mo
 //	 int - количество сокращённых URL в сервисе
 //		error - ошибка операции
 func (s *InMemoryStorage) CountURLs(ctx context.Context) (int, error) {
-	count := len(s.shortCodeMap)
+	count := s.shortCodes.len()
 	return count, nil
 }
 
@@ -352,26 +710,188 @@ func (s *InMemoryStorage) CountUsers(ctx context.Context) (int, error) {
 //
 // Параметры:
 //
+//	ctx - контекст
 //	userID - идентификатор пользователя
 //	urls - список коротких URL для удаления
 //
 // Возвращает:
 //
-//	error - ошибка операции
-func (s *InMemoryStorage) BatchMarkAsDeleted(userID string, urls []string) error {
+//	models.DeleteResult - удалённые, чужие и пропущенные коды
+//	error - ошибка записи в файл
+func (s *InMemoryStorage) BatchMarkAsDeleted(ctx context.Context, userID string, urls []string) (models.DeleteResult, error) {
+	var result models.DeleteResult
+
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
+	now := time.Now()
+	var records []logRecord
+	var previous []models.UserURLMapping
 	for _, code := range urls {
-		if mapping, exists := s.shortCodeMap[code]; exists && mapping.UserID == userID {
+		mapping, exists := s.shortCodes.get(code)
+		switch {
+		case !exists || (mapping.UserID == userID && mapping.DeletedFlag):
+			result.Skipped = append(result.Skipped, code)
+		case mapping.UserID != userID:
+			result.NotOwned = append(result.NotOwned, code)
+		default:
+			previous = append(previous, mapping)
 			mapping.DeletedFlag = true
-			s.shortCodeMap[code] = mapping
-			if err := s.encoder.Encode(mapping); err != nil {
-				return err
+			mapping.DeletedAt = &now
+			s.shortCodes.store(mapping)
+			records = append(records, logRecord{UserURLMapping: mapping})
+			result.Deleted = append(result.Deleted, code)
+		}
+	}
+	c := s.appendRecords(func() { s.storeMappings(previous) }, records...)
+	s.mu.Unlock()
+
+	if err := s.await(c); err != nil {
+		return models.DeleteResult{}, err
+	}
+	return result, nil
+}
+
+// BatchRestore снимает пометку удаления с URL пользователя, удалённых
+// не раньше момента deletedAfter.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	userID - идентификатор пользователя
+//	urls - список коротких URL для восстановления
+//	deletedAfter - начало окна восстановления
+//
+// Возвращает:
+//
+//	models.RestoreResult - восстановленные, неудалённые, чужие и недоступные коды
+//	error - ошибка записи в файл
+func (s *InMemoryStorage) BatchRestore(ctx context.Context, userID string, urls []string, deletedAfter time.Time) (models.RestoreResult, error) {
+	var result models.RestoreResult
+
+	s.mu.Lock()
+
+	var records []logRecord
+	var previous []models.UserURLMapping
+	for _, code := range urls {
+		mapping, exists := s.shortCodes.get(code)
+		switch {
+		case !exists:
+			result.Unavailable = append(result.Unavailable, code)
+		case mapping.UserID != userID:
+			result.NotOwned = append(result.NotOwned, code)
+		case !mapping.DeletedFlag:
+			result.Active = append(result.Active, code)
+		case mapping.DeletedAt == nil || mapping.DeletedAt.Before(deletedAfter):
+			result.Unavailable = append(result.Unavailable, code)
+		default:
+			previous = append(previous, mapping)
+			mapping.DeletedFlag = false
+			mapping.DeletedAt = nil
+			s.shortCodes.store(mapping)
+			records = append(records, logRecord{UserURLMapping: mapping})
+			result.Restored = append(result.Restored, code)
+		}
+	}
+	c := s.appendRecords(func() { s.storeMappings(previous) }, records...)
+	s.mu.Unlock()
+
+	if err := s.await(c); err != nil {
+		return models.RestoreResult{}, err
+	}
+	return result, nil
+}
+
+// PurgeURLs физически удаляет устаревшие записи.
+//
+// Удаляются записи, помеченные как удалённые раньше момента before,
+// и записи, срок действия которых истёк раньше момента before.
+// Для каждой удалённой записи в файл дописывается "надгробие",
+// чтобы запись не восстановилась при следующей загрузке.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	before - граница срока хранения
+//	limit - максимальное количество удаляемых записей
+//
+// Возвращает:
+//
+//	int - количество удалённых записей
+//	error - ошибка записи в файл
+func (s *InMemoryStorage) PurgeURLs(ctx context.Context, before time.Time, limit int) (int, error) {
+	s.mu.Lock()
+
+	var records []logRecord
+	clicks := make(map[string]*clickAggregate)
+	s.shortCodes.rangeLocked(func(mapping models.UserURLMapping) bool {
+		if len(records) >= limit {
+			return false
+		}
+
+		if isPurgeable(mapping, before) {
+			records = append(records, logRecord{UserURLMapping: mapping, Purged: true})
+			if agg, ok := s.clickStats[mapping.ShortURL]; ok {
+				clicks[mapping.ShortURL] = agg
 			}
+			s.removeMapping(mapping)
+		}
+		return true
+	})
+	c := s.appendRecords(func() {
+		for _, record := range records {
+			s.addMapping(record.UserURLMapping)
+			if agg, ok := clicks[record.ShortURL]; ok {
+				s.clickStats[record.ShortURL] = agg
+			}
+		}
+	}, records...)
+	s.mu.Unlock()
+
+	if err := s.await(c); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// isPurgeable проверяет, истёк ли срок хранения удалённой или просроченной записи.
+func isPurgeable(mapping models.UserURLMapping, before time.Time) bool {
+	if mapping.DeletedFlag && mapping.DeletedAt != nil && mapping.DeletedAt.Before(before) {
+		return true
+	}
+	return mapping.ExpiresAt != nil && mapping.ExpiresAt.Before(before)
+}
+
+// addMapping добавляет запись во все индексы. Вызывается под блокировкой записи.
+func (s *InMemoryStorage) addMapping(mapping models.UserURLMapping) {
+	if _, ok := s.userURLIndex[mapping.UserID]; !ok {
+		s.userURLIndex[mapping.UserID] = make(map[string]string)
+	}
+
+	s.userURLIndex[mapping.UserID][mapping.OriginalURL] = mapping.ShortURL
+	s.shortCodes.store(mapping)
+}
+
+// storeMappings возвращает записям прежнее состояние в обратном порядке.
+// Вызывается под блокировкой записи.
+func (s *InMemoryStorage) storeMappings(mappings []models.UserURLMapping) {
+	for i := len(mappings) - 1; i >= 0; i-- {
+		s.shortCodes.store(mappings[i])
+	}
+}
+
+// removeMapping удаляет запись из всех индексов. Вызывается под блокировкой записи.
+func (s *InMemoryStorage) removeMapping(mapping models.UserURLMapping) {
+	s.shortCodes.delete(mapping.ShortURL)
+	delete(s.clickStats, mapping.ShortURL)
+
+	if userURLs, ok := s.userURLIndex[mapping.UserID]; ok {
+		if userURLs[mapping.OriginalURL] == mapping.ShortURL {
+			delete(userURLs, mapping.OriginalURL)
+		}
+		if len(userURLs) == 0 {
+			delete(s.userURLIndex, mapping.UserID)
 		}
 	}
-	return nil
 }
 
 // FilePath возвращает путь к файлу, используемому хранилищем.
@@ -385,10 +905,29 @@ func (s *InMemoryStorage) FilePath() string {
 	return s.file.Name()
 }
 
-// Close освобождает ресурсы
+// Close останавливает периодическое сжатие, сжимает файл хранилища
+// (если в нём есть устаревшие записи), дожидается записи очереди,
+// сбрасывает файл на диск и освобождает ресурсы.
 func (s *InMemoryStorage) Close() error {
-	if s.file != nil {
-		return s.file.Close()
+	s.stopOnce.Do(func() { close(s.stopChan) })
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
 	}
-	return nil
+
+	var compactErr error
+	if s.needsCompaction() {
+		compactErr = s.compact()
+	}
+
+	err := errors.Join(compactErr, s.writer.close(), s.file.Close())
+	s.rollback()
+	s.pending = nil
+	s.writer = nil
+	s.file = nil
+	return err
 }
@@ -0,0 +1,109 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/stretchr/testify/require"
+)
+
+// allFormats - все форматы файла хранилища.
+var allFormats = []Format{FormatJSON, FormatProto, FormatProtoGzip, FormatProtoZstd}
+
+// userContext возвращает контекст с идентификатором пользователя.
+func userContext(userID string) context.Context {
+	return context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+}
+
+// openStorage открывает хранилище и загружает файл path так же, как сервер при запуске.
+func openStorage(t *testing.T, path string, opts ...Option) *InMemoryStorage {
+	t.Helper()
+
+	s, err := NewInMemoryStorage(path, opts...)
+	require.NoError(t, err)
+	require.NoError(t, s.Load(path))
+	return s
+}
+
+// saveURLs сохраняет n ссылок пользователя и возвращает размер файла после каждой записи.
+func saveURLs(t *testing.T, s *InMemoryStorage, userID string, n int) []int64 {
+	t.Helper()
+
+	sizes := make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		mapping := models.URLMapping{
+			ShortURL:    fmt.Sprintf("%s-%d", userID, i),
+			OriginalURL: fmt.Sprintf("https://example.com/%s/%d", userID, i),
+		}
+		require.NoError(t, s.SaveURL(userContext(userID), &mapping))
+		sizes = append(sizes, fileSize(t, s.FilePath()))
+	}
+	return sizes
+}
+
+// fileSize возвращает размер файла.
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
+
+// storagePath возвращает путь к новому файлу хранилища во временном каталоге теста.
+func storagePath(t *testing.T) string {
+	return filepath.Join(t.TempDir(), "storage.db")
+}
+
+// storageState - состояние хранилища, не зависящее от формата файла и истории записей.
+type storageState struct {
+	URLs   map[string]models.UserURLMapping
+	Clicks map[string]clickSnapshot
+}
+
+// stateOf возвращает состояние хранилища с временем в UTC.
+func stateOf(s *InMemoryStorage) storageState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state := storageState{
+		URLs:   make(map[string]models.UserURLMapping),
+		Clicks: make(map[string]clickSnapshot),
+	}
+	s.shortCodes.rangeLocked(func(mapping models.UserURLMapping) bool {
+		mapping.ExpiresAt = utcPtr(mapping.ExpiresAt)
+		mapping.DeletedAt = utcPtr(mapping.DeletedAt)
+		mapping.CreatedAt = utcPtr(mapping.CreatedAt)
+		state.URLs[mapping.ShortURL] = mapping
+		return true
+	})
+	for code, agg := range s.clickStats {
+		snap := agg.snapshot(code)
+		snap.FirstClick = snap.FirstClick.UTC()
+		snap.LastClick = snap.LastClick.UTC()
+		if len(snap.Referrers) == 0 {
+			snap.Referrers = nil
+		}
+		if len(snap.UserAgents) == 0 {
+			snap.UserAgents = nil
+		}
+		sort.Strings(snap.IPPrefixes)
+		state.Clicks[code] = snap
+	}
+	return state
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
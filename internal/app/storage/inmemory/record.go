@@ -0,0 +1,200 @@
+package inmemory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Формат строки файла хранилища FormatJSON:
+//
+//	<CRC-32C JSON-записи, 8 hex-символов> <JSON-запись>\n
+//
+// Контрольная сумма позволяет отличить оборванную при сбое последнюю строку
+// от целой записи. Строки без заголовка (начинающиеся с '{') записаны
+// предыдущими версиями сервиса и читаются без проверки.
+const (
+	checksumLen     = 8               // Длина контрольной суммы в hex-представлении
+	recordHeaderLen = checksumLen + 1 // Контрольная сумма и разделитель
+)
+
+// ErrCorruptedRecord возвращается Load, если повреждена запись в середине файла.
+// Повреждённая последняя запись считается оборванной и отбрасывается.
+var ErrCorruptedRecord = errors.New("corrupted storage record")
+
+// crcTable - таблица полинома Castagnoli для CRC-32C.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// jsonCodec реализует формат FormatJSON.
+type jsonCodec struct{}
+
+func (jsonCodec) format() Format { return FormatJSON }
+
+func (jsonCodec) header() []byte { return nil }
+
+// encode сериализует записи в строки с контрольной суммой.
+func (jsonCodec) encode(records []logRecord) ([]byte, error) {
+	var buf []byte
+	for _, record := range records {
+		line, err := encodeRecord(record.value())
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, line...)
+	}
+	return buf, nil
+}
+
+func (jsonCodec) newReader(file *os.File, offset int64, repair bool) logReader {
+	return &jsonReader{
+		file:   file,
+		reader: bufio.NewReader(io.NewSectionReader(file, offset, 1<<62)),
+		pos:    offset,
+		repair: repair,
+	}
+}
+
+// value возвращает представление записи для JSON-строки.
+// Записи переходов и агрегатов не содержат полей соответствия URL.
+func (r logRecord) value() any {
+	switch {
+	case r.Click != nil:
+		return clickRecord{Click: *r.Click}
+	case r.Stats != nil:
+		return clickStatsRecord{Stats: *r.Stats}
+	case r.Purged:
+		return r
+	default:
+		return r.UserURLMapping
+	}
+}
+
+// jsonReader читает строки файла формата FormatJSON.
+type jsonReader struct {
+	file    *os.File
+	reader  *bufio.Reader
+	pos     int64
+	lineNum int
+	repair  bool
+}
+
+func (r *jsonReader) offset() int64 { return r.pos }
+
+// next возвращает запись очередной строки.
+// Нечитаемые строки без контрольной суммы пропускаются, как и раньше.
+func (r *jsonReader) next() ([]logRecord, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(line) == 0 {
+			return nil, io.EOF
+		}
+		r.lineNum++
+
+		raw := trimNewline(line)
+		complete := line[len(line)-1] == '\n'
+		if len(raw) == 0 {
+			r.pos += int64(len(line))
+			continue
+		}
+
+		var record logRecord
+		payload, legacy, decodeErr := decodeRecord(raw)
+		if decodeErr == nil {
+			if err := json.Unmarshal(payload, &record); err != nil {
+				decodeErr = fmt.Errorf("%w: %v", ErrCorruptedRecord, err)
+			}
+		}
+
+		if decodeErr != nil && (!complete || r.isLastLine()) {
+			return nil, fmt.Errorf("%w: line %d", errTornRecord, r.lineNum)
+		}
+		if decodeErr != nil && legacy {
+			r.pos += int64(len(line))
+			continue
+		}
+		if decodeErr != nil {
+			return nil, fmt.Errorf("%w: line %d", decodeErr, r.lineNum)
+		}
+
+		r.pos += int64(len(line))
+		if !complete && r.repair {
+			// Целая запись без перевода строки: дописываем его, чтобы следующая запись начиналась с новой строки
+			if _, err := r.file.WriteAt([]byte{'\n'}, r.pos); err != nil {
+				return nil, err
+			}
+			r.pos++
+		}
+
+		record.legacy = legacy
+		return []logRecord{record}, nil
+	}
+}
+
+// isLastLine проверяет, что после прочитанной строки в файле ничего нет.
+func (r *jsonReader) isLastLine() bool {
+	_, err := r.reader.Peek(1)
+	return errors.Is(err, io.EOF)
+}
+
+// encodeRecord сериализует запись в строку файла с контрольной суммой.
+func encodeRecord(v any) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.Checksum(payload, crcTable))
+
+	line := make([]byte, recordHeaderLen, recordHeaderLen+len(payload)+1)
+	hex.Encode(line, sum[:])
+	line[checksumLen] = ' '
+	line = append(line, payload...)
+	return append(line, '\n'), nil
+}
+
+// decodeRecord проверяет строку файла (без завершающего перевода строки)
+// и возвращает JSON-запись.
+//
+// Возвращает:
+//
+//	[]byte - JSON-запись
+//	bool - true для строки в формате без контрольной суммы
+//	error - ErrCorruptedRecord если заголовок или контрольная сумма не совпадают
+func decodeRecord(line []byte) ([]byte, bool, error) {
+	if len(line) > 0 && line[0] == '{' {
+		return line, true, nil
+	}
+
+	if len(line) <= recordHeaderLen || line[checksumLen] != ' ' {
+		return nil, false, ErrCorruptedRecord
+	}
+
+	var sum [4]byte
+	if _, err := hex.Decode(sum[:], line[:checksumLen]); err != nil {
+		return nil, false, ErrCorruptedRecord
+	}
+
+	payload := line[recordHeaderLen:]
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(sum[:]) {
+		return nil, false, ErrCorruptedRecord
+	}
+
+	return payload, false, nil
+}
+
+// trimNewline удаляет завершающий перевод строки (в т.ч. CRLF).
+func trimNewline(line []byte) []byte {
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	return bytes.TrimSuffix(line, []byte{'\r'})
+}
@@ -0,0 +1,132 @@
+package inmemory
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// corruptByte инвертирует байт файла по смещению offset.
+func corruptByte(t *testing.T, path string, offset int64) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	b := make([]byte, 1)
+	_, err = file.ReadAt(b, offset)
+	require.NoError(t, err)
+	b[0] ^= 0xff
+	_, err = file.WriteAt(b, offset)
+	require.NoError(t, err)
+}
+
+// TestLoad_DamagedLastRecord проверяет, что Load отбрасывает оборванную
+// или не прошедшую проверку контрольной суммы последнюю запись, усекает файл
+// до последней целой записи и что дописанные после этого записи читаются.
+func TestLoad_DamagedLastRecord(t *testing.T) {
+	damages := []struct {
+		name   string
+		damage func(t *testing.T, path string, end int64)
+	}{
+		{
+			name: "torn",
+			damage: func(t *testing.T, path string, end int64) {
+				// Запись оборвана посередине
+				require.NoError(t, os.Truncate(path, end-3))
+			},
+		},
+		{
+			name: "checksum mismatch",
+			damage: func(t *testing.T, path string, end int64) {
+				// Последний байт тела записи (для JSON - перед переводом строки)
+				corruptByte(t, path, end-2)
+			},
+		},
+	}
+
+	for _, format := range allFormats {
+		for _, damage := range damages {
+			t.Run(string(format)+"/"+damage.name, func(t *testing.T) {
+				path := storagePath(t)
+
+				s := openStorage(t, path, WithFormat(format))
+				sizes := saveURLs(t, s, "user1", 3)
+				require.NoError(t, s.Close())
+
+				damage.damage(t, path, sizes[2])
+
+				s = openStorage(t, path, WithFormat(format))
+				assert.Equal(t, sizes[1], fileSize(t, path), "file must be truncated to the last whole record")
+
+				ctx := userContext("user1")
+				for _, code := range []string{"user1-0", "user1-1"} {
+					_, err := s.GetRedirectURL(ctx, code)
+					assert.NoError(t, err, code)
+				}
+				_, err := s.GetRedirectURL(ctx, "user1-2")
+				assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+				saveURLs(t, s, "user2", 2)
+				require.NoError(t, s.Close())
+
+				s = openStorage(t, path, WithFormat(format))
+				defer s.Close()
+				for _, code := range []string{"user1-0", "user1-1", "user2-0", "user2-1"} {
+					_, err := s.GetRedirectURL(ctx, code)
+					assert.NoError(t, err, code)
+				}
+				count, err := s.CountURLs(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, 4, count)
+			})
+		}
+	}
+}
+
+// TestLoad_CorruptedRecordInMiddle проверяет, что повреждение записи
+// в середине файла не принимается за сбой при записи и не приводит к усечению.
+func TestLoad_CorruptedRecordInMiddle(t *testing.T) {
+	for _, format := range allFormats {
+		t.Run(string(format), func(t *testing.T) {
+			path := storagePath(t)
+
+			s := openStorage(t, path, WithFormat(format))
+			sizes := saveURLs(t, s, "user1", 3)
+			require.NoError(t, s.Close())
+
+			corruptByte(t, path, sizes[0]-2)
+
+			s = newStorage(WithFormat(format))
+			err := s.Load(path)
+			assert.ErrorIs(t, err, ErrCorruptedRecord)
+			assert.Equal(t, sizes[2], fileSize(t, path))
+		})
+	}
+}
+
+// TestLoad_LegacyJSONLines проверяет чтение строк без контрольной суммы,
+// записанных предыдущими версиями сервиса.
+func TestLoad_LegacyJSONLines(t *testing.T) {
+	path := storagePath(t)
+	data := `{"uuid":1,"short_url":"legacy1","original_url":"https://example.com/1","user_id":"user1"}` + "\n" +
+		`{"uuid":` + "\n" +
+		`{"uuid":2,"short_url":"legacy2","original_url":"https://example.com/2","user_id":"user1","is_deleted":true}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0666))
+
+	s := openStorage(t, path)
+	defer s.Close()
+
+	ctx := userContext("user1")
+	_, err := s.GetRedirectURL(ctx, "legacy1")
+	assert.NoError(t, err)
+	_, err = s.GetRedirectURL(ctx, "legacy2")
+	assert.ErrorIs(t, err, storage.ErrURLDeleted)
+
+	// Строки без контрольной суммы переписываются при сжатии
+	assert.True(t, s.needsCompaction())
+}
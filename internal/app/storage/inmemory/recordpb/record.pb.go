@@ -0,0 +1,533 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: internal/app/storage/inmemory/recordpb/record.proto
+
+// Записи бинарного файла in-memory хранилища (internal/app/storage/inmemory).
+//
+// Номера полей входят в формат файла: существующие номера нельзя менять
+// или переиспользовать, новые поля добавляются со следующими номерами.
+
+package recordpb
+
+import (
+	api "github.com/ryabkov82/shortener/api"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Запись файла хранилища. Заполнено ровно одно поле.
+type LogRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Record:
+	//
+	//	*LogRecord_Mapping
+	//	*LogRecord_Purged
+	//	*LogRecord_Click
+	//	*LogRecord_ClickStats
+	Record        isLogRecord_Record `protobuf_oneof:"record"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogRecord) Reset() {
+	*x = LogRecord{}
+	mi := &file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogRecord) ProtoMessage() {}
+
+func (x *LogRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogRecord.ProtoReflect.Descriptor instead.
+func (*LogRecord) Descriptor() ([]byte, []int) {
+	return file_internal_app_storage_inmemory_recordpb_record_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LogRecord) GetRecord() isLogRecord_Record {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *LogRecord) GetMapping() *URLMapping {
+	if x != nil {
+		if x, ok := x.Record.(*LogRecord_Mapping); ok {
+			return x.Mapping
+		}
+	}
+	return nil
+}
+
+func (x *LogRecord) GetPurged() *URLMapping {
+	if x != nil {
+		if x, ok := x.Record.(*LogRecord_Purged); ok {
+			return x.Purged
+		}
+	}
+	return nil
+}
+
+func (x *LogRecord) GetClick() *ClickEvent {
+	if x != nil {
+		if x, ok := x.Record.(*LogRecord_Click); ok {
+			return x.Click
+		}
+	}
+	return nil
+}
+
+func (x *LogRecord) GetClickStats() *ClickStats {
+	if x != nil {
+		if x, ok := x.Record.(*LogRecord_ClickStats); ok {
+			return x.ClickStats
+		}
+	}
+	return nil
+}
+
+type isLogRecord_Record interface {
+	isLogRecord_Record()
+}
+
+type LogRecord_Mapping struct {
+	// Соответствие URL (сохранение или изменение)
+	Mapping *URLMapping `protobuf:"bytes,1,opt,name=mapping,proto3,oneof"`
+}
+
+type LogRecord_Purged struct {
+	// "Надгробие" физически удалённой ссылки
+	Purged *URLMapping `protobuf:"bytes,2,opt,name=purged,proto3,oneof"`
+}
+
+type LogRecord_Click struct {
+	// Событие перехода
+	Click *ClickEvent `protobuf:"bytes,3,opt,name=click,proto3,oneof"`
+}
+
+type LogRecord_ClickStats struct {
+	// Агрегаты переходов по ссылке из снимка хранилища
+	ClickStats *ClickStats `protobuf:"bytes,4,opt,name=click_stats,json=clickStats,proto3,oneof"`
+}
+
+func (*LogRecord_Mapping) isLogRecord_Record() {}
+
+func (*LogRecord_Purged) isLogRecord_Record() {}
+
+func (*LogRecord_Click) isLogRecord_Record() {}
+
+func (*LogRecord_ClickStats) isLogRecord_Record() {}
+
+type URLMapping struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Uuid          uint64                 `protobuf:"varint,4,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	IsDeleted     bool                   `protobuf:"varint,5,opt,name=is_deleted,json=isDeleted,proto3" json:"is_deleted,omitempty"`
+	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *URLMapping) Reset() {
+	*x = URLMapping{}
+	mi := &file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLMapping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLMapping) ProtoMessage() {}
+
+func (x *URLMapping) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLMapping.ProtoReflect.Descriptor instead.
+func (*URLMapping) Descriptor() ([]byte, []int) {
+	return file_internal_app_storage_inmemory_recordpb_record_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *URLMapping) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *URLMapping) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *URLMapping) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *URLMapping) GetUuid() uint64 {
+	if x != nil {
+		return x.Uuid
+	}
+	return 0
+}
+
+func (x *URLMapping) GetIsDeleted() bool {
+	if x != nil {
+		return x.IsDeleted
+	}
+	return false
+}
+
+func (x *URLMapping) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *URLMapping) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *URLMapping) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ClickEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=at,proto3" json:"at,omitempty"`
+	Referrer      string                 `protobuf:"bytes,3,opt,name=referrer,proto3" json:"referrer,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,4,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	IpPrefix      string                 `protobuf:"bytes,5,opt,name=ip_prefix,json=ipPrefix,proto3" json:"ip_prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClickEvent) Reset() {
+	*x = ClickEvent{}
+	mi := &file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClickEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClickEvent) ProtoMessage() {}
+
+func (x *ClickEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClickEvent.ProtoReflect.Descriptor instead.
+func (*ClickEvent) Descriptor() ([]byte, []int) {
+	return file_internal_app_storage_inmemory_recordpb_record_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ClickEvent) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *ClickEvent) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *ClickEvent) GetReferrer() string {
+	if x != nil {
+		return x.Referrer
+	}
+	return ""
+}
+
+func (x *ClickEvent) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *ClickEvent) GetIpPrefix() string {
+	if x != nil {
+		return x.IpPrefix
+	}
+	return ""
+}
+
+type ClickStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	Clicks        int64                  `protobuf:"varint,2,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	FirstClickAt  *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=first_click_at,json=firstClickAt,proto3" json:"first_click_at,omitempty"`
+	LastClickAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_click_at,json=lastClickAt,proto3" json:"last_click_at,omitempty"`
+	Referrers     []*api.ClickCount      `protobuf:"bytes,5,rep,name=referrers,proto3" json:"referrers,omitempty"`
+	UserAgents    []*api.ClickCount      `protobuf:"bytes,6,rep,name=user_agents,json=userAgents,proto3" json:"user_agents,omitempty"`
+	IpPrefixes    []string               `protobuf:"bytes,7,rep,name=ip_prefixes,json=ipPrefixes,proto3" json:"ip_prefixes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClickStats) Reset() {
+	*x = ClickStats{}
+	mi := &file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClickStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClickStats) ProtoMessage() {}
+
+func (x *ClickStats) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClickStats.ProtoReflect.Descriptor instead.
+func (*ClickStats) Descriptor() ([]byte, []int) {
+	return file_internal_app_storage_inmemory_recordpb_record_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ClickStats) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *ClickStats) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+func (x *ClickStats) GetFirstClickAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstClickAt
+	}
+	return nil
+}
+
+func (x *ClickStats) GetLastClickAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastClickAt
+	}
+	return nil
+}
+
+func (x *ClickStats) GetReferrers() []*api.ClickCount {
+	if x != nil {
+		return x.Referrers
+	}
+	return nil
+}
+
+func (x *ClickStats) GetUserAgents() []*api.ClickCount {
+	if x != nil {
+		return x.UserAgents
+	}
+	return nil
+}
+
+func (x *ClickStats) GetIpPrefixes() []string {
+	if x != nil {
+		return x.IpPrefixes
+	}
+	return nil
+}
+
+var File_internal_app_storage_inmemory_recordpb_record_proto protoreflect.FileDescriptor
+
+const file_internal_app_storage_inmemory_recordpb_record_proto_rawDesc = "" +
+	"\n" +
+	"3internal/app/storage/inmemory/recordpb/record.proto\x12\x11shortener.storage\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x13api/shortener.proto\"\x82\x02\n" +
+	"\tLogRecord\x129\n" +
+	"\amapping\x18\x01 \x01(\v2\x1d.shortener.storage.URLMappingH\x00R\amapping\x127\n" +
+	"\x06purged\x18\x02 \x01(\v2\x1d.shortener.storage.URLMappingH\x00R\x06purged\x125\n" +
+	"\x05click\x18\x03 \x01(\v2\x1d.shortener.storage.ClickEventH\x00R\x05click\x12@\n" +
+	"\vclick_stats\x18\x04 \x01(\v2\x1d.shortener.storage.ClickStatsH\x00R\n" +
+	"clickStatsB\b\n" +
+	"\x06record\"\xc9\x02\n" +
+	"\n" +
+	"URLMapping\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04uuid\x18\x04 \x01(\x04R\x04uuid\x12\x1d\n" +
+	"\n" +
+	"is_deleted\x18\x05 \x01(\bR\tisDeleted\x129\n" +
+	"\n" +
+	"deleted_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xad\x01\n" +
+	"\n" +
+	"ClickEvent\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12*\n" +
+	"\x02at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x1a\n" +
+	"\breferrer\x18\x03 \x01(\tR\breferrer\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x04 \x01(\tR\tuserAgent\x12\x1b\n" +
+	"\tip_prefix\x18\x05 \x01(\tR\bipPrefix\"\xd1\x02\n" +
+	"\n" +
+	"ClickStats\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12\x16\n" +
+	"\x06clicks\x18\x02 \x01(\x03R\x06clicks\x12@\n" +
+	"\x0efirst_click_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ffirstClickAt\x12>\n" +
+	"\rlast_click_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vlastClickAt\x123\n" +
+	"\treferrers\x18\x05 \x03(\v2\x15.shortener.ClickCountR\treferrers\x126\n" +
+	"\vuser_agents\x18\x06 \x03(\v2\x15.shortener.ClickCountR\n" +
+	"userAgents\x12\x1f\n" +
+	"\vip_prefixes\x18\a \x03(\tR\n" +
+	"ipPrefixesBPZNgithub.com/ryabkov82/shortener/internal/app/storage/inmemory/recordpb;recordpbb\x06proto3"
+
+var (
+	file_internal_app_storage_inmemory_recordpb_record_proto_rawDescOnce sync.Once
+	file_internal_app_storage_inmemory_recordpb_record_proto_rawDescData []byte
+)
+
+func file_internal_app_storage_inmemory_recordpb_record_proto_rawDescGZIP() []byte {
+	file_internal_app_storage_inmemory_recordpb_record_proto_rawDescOnce.Do(func() {
+		file_internal_app_storage_inmemory_recordpb_record_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_internal_app_storage_inmemory_recordpb_record_proto_rawDesc), len(file_internal_app_storage_inmemory_recordpb_record_proto_rawDesc)))
+	})
+	return file_internal_app_storage_inmemory_recordpb_record_proto_rawDescData
+}
+
+var file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_internal_app_storage_inmemory_recordpb_record_proto_goTypes = []any{
+	(*LogRecord)(nil),             // 0: shortener.storage.LogRecord
+	(*URLMapping)(nil),            // 1: shortener.storage.URLMapping
+	(*ClickEvent)(nil),            // 2: shortener.storage.ClickEvent
+	(*ClickStats)(nil),            // 3: shortener.storage.ClickStats
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+	(*api.ClickCount)(nil),        // 5: shortener.ClickCount
+}
+var file_internal_app_storage_inmemory_recordpb_record_proto_depIdxs = []int32{
+	1,  // 0: shortener.storage.LogRecord.mapping:type_name -> shortener.storage.URLMapping
+	1,  // 1: shortener.storage.LogRecord.purged:type_name -> shortener.storage.URLMapping
+	2,  // 2: shortener.storage.LogRecord.click:type_name -> shortener.storage.ClickEvent
+	3,  // 3: shortener.storage.LogRecord.click_stats:type_name -> shortener.storage.ClickStats
+	4,  // 4: shortener.storage.URLMapping.deleted_at:type_name -> google.protobuf.Timestamp
+	4,  // 5: shortener.storage.URLMapping.expires_at:type_name -> google.protobuf.Timestamp
+	4,  // 6: shortener.storage.URLMapping.created_at:type_name -> google.protobuf.Timestamp
+	4,  // 7: shortener.storage.ClickEvent.at:type_name -> google.protobuf.Timestamp
+	4,  // 8: shortener.storage.ClickStats.first_click_at:type_name -> google.protobuf.Timestamp
+	4,  // 9: shortener.storage.ClickStats.last_click_at:type_name -> google.protobuf.Timestamp
+	5,  // 10: shortener.storage.ClickStats.referrers:type_name -> shortener.ClickCount
+	5,  // 11: shortener.storage.ClickStats.user_agents:type_name -> shortener.ClickCount
+	12, // [12:12] is the sub-list for method output_type
+	12, // [12:12] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_internal_app_storage_inmemory_recordpb_record_proto_init() }
+func file_internal_app_storage_inmemory_recordpb_record_proto_init() {
+	if File_internal_app_storage_inmemory_recordpb_record_proto != nil {
+		return
+	}
+	file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes[0].OneofWrappers = []any{
+		(*LogRecord_Mapping)(nil),
+		(*LogRecord_Purged)(nil),
+		(*LogRecord_Click)(nil),
+		(*LogRecord_ClickStats)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_app_storage_inmemory_recordpb_record_proto_rawDesc), len(file_internal_app_storage_inmemory_recordpb_record_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_app_storage_inmemory_recordpb_record_proto_goTypes,
+		DependencyIndexes: file_internal_app_storage_inmemory_recordpb_record_proto_depIdxs,
+		MessageInfos:      file_internal_app_storage_inmemory_recordpb_record_proto_msgTypes,
+	}.Build()
+	File_internal_app_storage_inmemory_recordpb_record_proto = out.File
+	file_internal_app_storage_inmemory_recordpb_record_proto_goTypes = nil
+	file_internal_app_storage_inmemory_recordpb_record_proto_depIdxs = nil
+}
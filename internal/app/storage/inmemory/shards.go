@@ -0,0 +1,106 @@
+package inmemory
+
+import (
+	"sync"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// shardCount - количество сегментов индекса коротких ссылок (степень двойки).
+const shardCount = 32
+
+// urlShard - сегмент индекса коротких ссылок со своей блокировкой.
+type urlShard struct {
+	mu   sync.RWMutex
+	urls map[string]models.UserURLMapping
+}
+
+// shardedURLs - индекс коротких ссылок, разбитый на сегменты по хешу ключа.
+//
+// Правила синхронизации:
+// - изменения выполняются только под блокировкой записи хранилища (InMemoryStorage.mu) и блокировкой сегмента
+// - чтение без блокировки хранилища (load, len) берёт блокировку чтения сегмента
+// - под блокировкой хранилища сегменты читаются без блокировки (get, rangeLocked), так как их никто не изменяет
+//
+// Поэтому редирект никогда не ждёт записи в файл или сжатия: блокировка сегмента
+// удерживается только на время изменения карты.
+type shardedURLs struct {
+	shards [shardCount]urlShard
+}
+
+// newShardedURLs создает пустой индекс коротких ссылок.
+func newShardedURLs() *shardedURLs {
+	m := &shardedURLs{}
+	for i := range m.shards {
+		m.shards[i].urls = make(map[string]models.UserURLMapping)
+	}
+	return m
+}
+
+// shard возвращает сегмент для короткого ключа (хеш FNV-1a).
+func (m *shardedURLs) shard(code string) *urlShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(code); i++ {
+		h ^= uint32(code[i])
+		h *= 16777619
+	}
+	return &m.shards[h&(shardCount-1)]
+}
+
+// load возвращает соответствие по короткому ключу. Не требует блокировки хранилища.
+func (m *shardedURLs) load(code string) (models.UserURLMapping, bool) {
+	shard := m.shard(code)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	mapping, ok := shard.urls[code]
+	return mapping, ok
+}
+
+// get возвращает соответствие по короткому ключу. Вызывается под блокировкой хранилища.
+func (m *shardedURLs) get(code string) (models.UserURLMapping, bool) {
+	mapping, ok := m.shard(code).urls[code]
+	return mapping, ok
+}
+
+// store сохраняет соответствие. Вызывается под блокировкой записи хранилища.
+func (m *shardedURLs) store(mapping models.UserURLMapping) {
+	shard := m.shard(mapping.ShortURL)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.urls[mapping.ShortURL] = mapping
+}
+
+// delete удаляет соответствие. Вызывается под блокировкой записи хранилища.
+func (m *shardedURLs) delete(code string) {
+	shard := m.shard(code)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	delete(shard.urls, code)
+}
+
+// len возвращает количество коротких ссылок. Не требует блокировки хранилища.
+func (m *shardedURLs) len() int {
+	count := 0
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		count += len(shard.urls)
+		shard.mu.RUnlock()
+	}
+	return count
+}
+
+// rangeLocked вызывает fn для каждого соответствия, пока fn возвращает true.
+// Вызывается под блокировкой хранилища; fn может удалять соответствия.
+func (m *shardedURLs) rangeLocked(fn func(mapping models.UserURLMapping) bool) {
+	for i := range m.shards {
+		for _, mapping := range m.shards[i].urls {
+			if !fn(mapping) {
+				return
+			}
+		}
+	}
+}
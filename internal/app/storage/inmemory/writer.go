@@ -0,0 +1,309 @@
+package inmemory
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SyncMode определяет, когда записи файла хранилища сбрасываются на диск (fsync).
+type SyncMode string
+
+// Режимы сброса записей на диск.
+const (
+	// SyncAlways - вызывающий получает подтверждение только после fsync своей записи.
+	// Одновременные записи объединяются в одну запись в файл и один fsync (group commit).
+	SyncAlways SyncMode = "always"
+	// SyncInterval - fsync выполняется периодически; при сбое ОС теряются записи последнего интервала.
+	SyncInterval SyncMode = "interval"
+	// SyncNever - fsync не выполняется, сброс на диск остаётся за ОС.
+	SyncNever SyncMode = "never"
+)
+
+// ParseSyncMode проверяет название режима сброса записей на диск.
+func ParseSyncMode(name string) (SyncMode, error) {
+	switch mode := SyncMode(name); mode {
+	case SyncAlways, SyncInterval, SyncNever:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown sync mode %q", name)
+	}
+}
+
+// WithSyncMode задаёт режим сброса записей на диск (по умолчанию SyncNever).
+// interval используется в режиме SyncInterval (по умолчанию 1 секунда).
+func WithSyncMode(mode SyncMode, interval time.Duration) Option {
+	return func(s *InMemoryStorage) {
+		s.syncMode = mode
+		s.syncInterval = interval
+	}
+}
+
+// Параметры группового сохранения.
+const (
+	writeQueueSize      = 1024 // Ёмкость очереди записей
+	maxWriteBatch       = 1024 // Максимальное количество запросов в одной групповой записи
+	defaultSyncInterval = time.Second
+)
+
+// commit - результат отложенной записи в файл хранилища.
+// Канал получает nil или ошибку записи после того, как запись выполнена
+// (а в режиме SyncAlways - сброшена на диск).
+type commit <-chan error
+
+// wait дожидается завершения записи.
+func (c commit) wait() error {
+	if c == nil {
+		return nil
+	}
+	return <-c
+}
+
+// failedCommit возвращает commit, завершившийся ошибкой.
+func failedCommit(err error) commit {
+	done := make(chan error, 1)
+	done <- err
+	return done
+}
+
+// errWriteFailed - запись в файл хранилища невозможна после ошибки записи или fsync.
+var errWriteFailed = errors.New("storage file write failed")
+
+// logFile - файл, в который logWriter дописывает записи (*os.File).
+type logFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+}
+
+// writeRequest - запрос на дозапись данных в файл.
+// Запрос без данных используется как барьер: он подтверждается после всех предыдущих.
+type writeRequest struct {
+	seq  uint64
+	data []byte
+	done chan error
+}
+
+// logWriter выполняет дозапись в файл хранилища в отдельной горутине.
+//
+// Запросы, накопившиеся в очереди, пока выполнялась предыдущая запись (и fsync),
+// объединяются в одну запись в файл, поэтому количество системных вызовов
+// не растёт пропорционально количеству одновременных сохранений.
+//
+// Ошибка записи или fsync необратима: содержимое файла после неё не определено
+// (после неудачного fsync ядро может отбросить несохранённые страницы),
+// поэтому файл усекается до последней подтверждённой записи, а этот и все
+// последующие запросы завершаются ошибкой до перезапуска.
+type logWriter struct {
+	mode     SyncMode
+	interval time.Duration
+	requests chan writeRequest
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	log      *zap.Logger
+
+	submitMu  sync.Mutex // Согласует порядковые номера с порядком очереди
+	seq       uint64
+	committed atomic.Uint64 // Номер последнего успешно записанного запроса
+
+	mu    sync.Mutex // Защищает file, size, dirty и err
+	file  logFile
+	size  int64 // Размер файла после последней подтверждённой записи
+	dirty bool  // В файле есть записи, не сброшенные на диск
+	err   error // Ошибка, после которой запись невозможна
+}
+
+// newLogWriter создает и запускает горутину записи в файл размером size.
+func newLogWriter(file logFile, size int64, mode SyncMode, interval time.Duration, log *zap.Logger) *logWriter {
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+
+	w := &logWriter{
+		mode:     mode,
+		interval: interval,
+		requests: make(chan writeRequest, writeQueueSize),
+		stopChan: make(chan struct{}),
+		log:      log,
+		file:     file,
+		size:     size,
+	}
+
+	w.wg.Add(1)
+	go w.loop()
+	return w
+}
+
+// submit ставит данные в очередь на запись и возвращает порядковый номер запроса.
+// Порядок записей в файле совпадает с порядком вызовов submit.
+func (w *logWriter) submit(data []byte) (uint64, commit) {
+	w.submitMu.Lock()
+	defer w.submitMu.Unlock()
+
+	w.seq++
+	done := make(chan error, 1)
+	w.requests <- writeRequest{seq: w.seq, data: data, done: done}
+	return w.seq, done
+}
+
+// flush дожидается записи всех ранее поставленных в очередь данных.
+func (w *logWriter) flush() error {
+	_, c := w.submit(nil)
+	return c.wait()
+}
+
+// lastCommitted возвращает порядковый номер последнего успешно записанного запроса.
+// Запросы с большими номерами ещё не записаны или завершились ошибкой.
+func (w *logWriter) lastCommitted() uint64 {
+	return w.committed.Load()
+}
+
+// setFile подменяет файл, в который выполняется запись (после сжатия).
+// Вызывающий должен предварительно выполнить flush.
+func (w *logWriter) setFile(file logFile, size int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.file = file
+	w.size = size
+	w.dirty = false
+}
+
+// loop обрабатывает очередь записей до вызова close.
+func (w *logWriter) loop() {
+	defer w.wg.Done()
+
+	var tick <-chan time.Time
+	if w.mode == SyncInterval {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case req := <-w.requests:
+			w.commit(w.collect(req))
+
+		case <-tick:
+			w.mu.Lock()
+			if err := w.sync(); err != nil {
+				// Записи уже подтверждены: при сбое ОС они будут потеряны
+				w.log.Error("Failed to sync storage file", zap.Error(err))
+			}
+			w.mu.Unlock()
+
+		case <-w.stopChan:
+			// Записываем запросы, успевшие попасть в очередь до остановки
+			for {
+				select {
+				case req := <-w.requests:
+					w.commit(w.collect(req))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// collect дополняет запрос запросами, уже ожидающими в очереди.
+func (w *logWriter) collect(first writeRequest) []writeRequest {
+	batch := []writeRequest{first}
+	for len(batch) < maxWriteBatch {
+		select {
+		case req := <-w.requests:
+			batch = append(batch, req)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// commit записывает пакет запросов одним вызовом Write и подтверждает их.
+func (w *logWriter) commit(batch []writeRequest) {
+	size := 0
+	for _, req := range batch {
+		size += len(req.data)
+	}
+
+	buf := make([]byte, 0, size)
+	for _, req := range batch {
+		buf = append(buf, req.data...)
+	}
+
+	w.mu.Lock()
+	err := w.err
+	if err == nil {
+		err = w.write(buf)
+	}
+	if err == nil {
+		w.committed.Store(batch[len(batch)-1].seq)
+	}
+	w.mu.Unlock()
+
+	for _, req := range batch {
+		req.done <- err
+	}
+}
+
+// write дописывает данные в файл (и сбрасывает на диск в режиме SyncAlways).
+// Вызывается под w.mu.
+func (w *logWriter) write(buf []byte) error {
+	if len(buf) > 0 {
+		if _, err := w.file.Write(buf); err != nil {
+			return w.fail(err)
+		}
+		w.dirty = true
+	}
+	if w.mode == SyncAlways {
+		if err := w.sync(); err != nil {
+			return w.fail(err)
+		}
+	}
+	w.size += int64(len(buf))
+	return nil
+}
+
+// fail усекает файл до последней подтверждённой записи, чтобы неподтверждённые
+// записи не загрузились после перезапуска, и запрещает дальнейшую запись.
+// Вызывается под w.mu.
+func (w *logWriter) fail(err error) error {
+	w.log.Error("Failed to write storage file, rejecting further writes", zap.Error(err))
+	if truncErr := w.file.Truncate(w.size); truncErr != nil {
+		w.log.Error("Failed to truncate storage file", zap.Int64("size", w.size), zap.Error(truncErr))
+	}
+	w.dirty = false
+	w.err = fmt.Errorf("%w: %w", errWriteFailed, err)
+	return w.err
+}
+
+// sync сбрасывает файл на диск, если в нём есть несохранённые записи.
+// Вызывается под w.mu.
+func (w *logWriter) sync() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.dirty = false
+	return nil
+}
+
+// close дожидается записи очереди, сбрасывает файл на диск и останавливает горутину.
+// Файл не закрывается.
+func (w *logWriter) close() error {
+	close(w.stopChan)
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sync()
+}
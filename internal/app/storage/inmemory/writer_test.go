@@ -0,0 +1,326 @@
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// fakeFile записывает данные в память. Write и Sync могут быть приостановлены
+// до закрытия gate и завершаться заданными ошибками после okWrites (okSyncs)
+// успешных вызовов.
+type fakeFile struct {
+	mu       sync.Mutex
+	data     bytes.Buffer
+	writes   [][]byte
+	syncs    int
+	synced   int // Размер данных на момент последнего успешного Sync
+	writeErr error
+	okWrites int
+	syncErr  error
+	okSyncs  int
+
+	writeGate chan struct{}
+	syncGate  chan struct{}
+	started   chan string // Сообщает о начале вызовов Write и Sync
+}
+
+func newFakeFile() *fakeFile {
+	return &fakeFile{started: make(chan string, 100)}
+}
+
+func (f *fakeFile) Write(p []byte) (int, error) {
+	f.started <- "write"
+	f.mu.Lock()
+	gate := f.writeGate
+	f.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes = append(f.writes, append([]byte(nil), p...))
+	if f.writeErr != nil && len(f.writes) > f.okWrites {
+		// Частичная запись перед ошибкой
+		f.data.Write(p[:len(p)/2])
+		return len(p) / 2, f.writeErr
+	}
+	return f.data.Write(p)
+}
+
+func (f *fakeFile) Sync() error {
+	f.started <- "sync"
+	f.mu.Lock()
+	gate := f.syncGate
+	f.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.syncs++
+	if f.syncErr != nil && f.syncs > f.okSyncs {
+		return f.syncErr
+	}
+	f.synced = f.data.Len()
+	return nil
+}
+
+func (f *fakeFile) Truncate(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data.Truncate(int(size))
+	return nil
+}
+
+func (f *fakeFile) state() (data string, writes [][]byte, syncs, synced int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.data.String(), append([][]byte(nil), f.writes...), f.syncs, f.synced
+}
+
+// awaitCall дожидается начала вызова Write или Sync.
+func awaitCall(t *testing.T, f *fakeFile, call string) {
+	t.Helper()
+	select {
+	case got := <-f.started:
+		require.Equal(t, call, got)
+	case <-time.After(time.Second):
+		t.Fatalf("%s was not called", call)
+	}
+}
+
+// assertPending проверяет, что запись ещё не подтверждена.
+func assertPending(t *testing.T, c commit) {
+	t.Helper()
+	select {
+	case err := <-c:
+		t.Fatalf("commit acknowledged early: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestLogWriter_SyncAlwaysAcksAfterFsync(t *testing.T) {
+	file := newFakeFile()
+	file.syncGate = make(chan struct{})
+	w := newLogWriter(file, 0, SyncAlways, 0, zap.NewNop())
+
+	_, c := w.submit([]byte("a\n"))
+	awaitCall(t, file, "write")
+	awaitCall(t, file, "sync")
+
+	// Данные записаны, но до завершения fsync вызывающий ждёт
+	assertPending(t, c)
+
+	close(file.syncGate)
+	require.NoError(t, c.wait())
+	data, _, syncs, synced := file.state()
+	assert.Equal(t, "a\n", data)
+	assert.Equal(t, 1, syncs)
+	assert.Equal(t, len(data), synced)
+
+	require.NoError(t, w.close())
+}
+
+func TestLogWriter_GroupCommit(t *testing.T) {
+	for _, mode := range []SyncMode{SyncAlways, SyncNever} {
+		t.Run(string(mode), func(t *testing.T) {
+			file := newFakeFile()
+			file.writeGate = make(chan struct{})
+			w := newLogWriter(file, 0, mode, 0, zap.NewNop())
+
+			// Первая запись задерживается, пока накапливаются остальные
+			_, first := w.submit([]byte("first\n"))
+			awaitCall(t, file, "write")
+
+			var wg sync.WaitGroup
+			commits := make([]commit, 10)
+			for i := range commits {
+				_, commits[i] = w.submit([]byte{byte('0' + i), '\n'})
+			}
+			close(file.writeGate)
+
+			require.NoError(t, first.wait())
+			for _, c := range commits {
+				wg.Add(1)
+				go func(c commit) {
+					defer wg.Done()
+					assert.NoError(t, c.wait())
+				}(c)
+			}
+			wg.Wait()
+
+			// Ожидавшие записи объединены в один Write (и один fsync)
+			data, writes, syncs, _ := file.state()
+			require.Len(t, writes, 2)
+			assert.Equal(t, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n", string(writes[1]))
+			assert.Equal(t, "first\n"+string(writes[1]), data)
+			if mode == SyncAlways {
+				assert.Equal(t, 2, syncs)
+			} else {
+				assert.Zero(t, syncs)
+			}
+
+			require.NoError(t, w.close())
+		})
+	}
+}
+
+func TestLogWriter_IntervalSync(t *testing.T) {
+	file := newFakeFile()
+	w := newLogWriter(file, 0, SyncInterval, 5*time.Millisecond, zap.NewNop())
+
+	// Подтверждение не ждёт fsync
+	_, c := w.submit([]byte("a\n"))
+	require.NoError(t, c.wait())
+
+	require.Eventually(t, func() bool {
+		_, _, syncs, synced := file.state()
+		return syncs == 1 && synced == 2
+	}, time.Second, time.Millisecond)
+
+	// Без новых записей fsync не повторяется
+	time.Sleep(30 * time.Millisecond)
+	_, _, syncs, _ := file.state()
+	assert.Equal(t, 1, syncs)
+
+	require.NoError(t, w.close())
+}
+
+func TestLogWriter_ErrorReachesEveryWaiter(t *testing.T) {
+	failure := errors.New("no space left on device")
+
+	tests := []struct {
+		name  string
+		mode  SyncMode
+		setup func(f *fakeFile)
+	}{
+		{name: "write error", mode: SyncNever, setup: func(f *fakeFile) { f.writeErr, f.okWrites = failure, 1 }},
+		{name: "sync error", mode: SyncAlways, setup: func(f *fakeFile) { f.syncErr, f.okSyncs = failure, 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newFakeFile()
+			file.writeGate = make(chan struct{})
+			// Ошибка возникает при записи второго пакета
+			tt.setup(file)
+			w := newLogWriter(file, 0, tt.mode, 0, zap.NewNop())
+
+			seq, first := w.submit([]byte("ok\n"))
+			awaitCall(t, file, "write")
+
+			commits := make([]commit, 5)
+			for i := range commits {
+				_, commits[i] = w.submit([]byte("lost\n"))
+			}
+			close(file.writeGate)
+			require.NoError(t, first.wait())
+
+			for _, c := range commits {
+				err := c.wait()
+				assert.ErrorIs(t, err, failure)
+				assert.ErrorIs(t, err, errWriteFailed)
+			}
+
+			// Неподтверждённые данные удалены из файла
+			data, _, _, _ := file.state()
+			assert.Equal(t, "ok\n", data)
+			assert.Equal(t, seq, w.lastCommitted())
+
+			// Последующие записи отклоняются, даже если файл снова доступен
+			file.mu.Lock()
+			file.writeErr, file.syncErr = nil, nil
+			file.mu.Unlock()
+			_, c := w.submit([]byte("later\n"))
+			assert.ErrorIs(t, c.wait(), errWriteFailed)
+			assert.ErrorIs(t, w.flush(), errWriteFailed)
+			data, _, _, _ = file.state()
+			assert.Equal(t, "ok\n", data)
+
+			assert.NoError(t, w.close())
+		})
+	}
+}
+
+func TestInMemoryStorage_RollbackOnWriteError(t *testing.T) {
+	ctx := userContext("user1")
+	path := storagePath(t)
+	s := openStorage(t, path)
+
+	kept := models.URLMapping{ShortURL: "kept", OriginalURL: "https://example.com/kept"}
+	require.NoError(t, s.SaveURL(ctx, &kept))
+	deleted := models.URLMapping{ShortURL: "deleted", OriginalURL: "https://example.com/deleted"}
+	require.NoError(t, s.SaveURL(ctx, &deleted))
+	_, err := s.BatchMarkAsDeleted(ctx, "user1", []string{"deleted"})
+	require.NoError(t, err)
+	require.NoError(t, s.RecordClicks(ctx, []models.ClickEvent{{ShortURL: "kept", At: time.Now(), Referrer: "a"}}))
+	require.NoError(t, s.writer.flush())
+
+	// Диск переполнен: запись в файл завершается ошибкой
+	failing := newFakeFile()
+	failing.writeErr = errors.New("no space left on device")
+	s.writer.setFile(failing, 0)
+
+	lost := models.URLMapping{ShortURL: "lost", OriginalURL: "https://example.com/lost"}
+	assert.ErrorIs(t, s.SaveURL(ctx, &lost), errWriteFailed)
+	_, err = s.GetRedirectURL(ctx, "lost")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+	_, err = s.GetShortKey(ctx, lost.OriginalURL)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	// Повторный запрос не получает ErrURLExists для несохранённой ссылки
+	assert.ErrorIs(t, s.SaveURL(ctx, &lost), errWriteFailed)
+	assert.ErrorIs(t, s.SaveNewURLs(ctx, []models.URLMapping{lost}), errWriteFailed)
+	_, err = s.GetShortKey(ctx, lost.OriginalURL)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	_, err = s.BatchMarkAsDeleted(ctx, "user1", []string{"kept"})
+	assert.ErrorIs(t, err, errWriteFailed)
+	_, err = s.GetRedirectURL(ctx, "kept")
+	assert.NoError(t, err)
+
+	_, err = s.BatchRestore(ctx, "user1", []string{"deleted"}, time.Time{})
+	assert.ErrorIs(t, err, errWriteFailed)
+	_, err = s.GetRedirectURL(ctx, "deleted")
+	assert.ErrorIs(t, err, storage.ErrURLDeleted)
+
+	_, err = s.PurgeURLs(ctx, time.Now().Add(time.Hour), 10)
+	assert.ErrorIs(t, err, errWriteFailed)
+	_, err = s.GetRedirectURL(ctx, "deleted")
+	assert.ErrorIs(t, err, storage.ErrURLDeleted)
+
+	assert.ErrorIs(t, s.RecordClicks(ctx, []models.ClickEvent{{ShortURL: "kept", At: time.Now(), Referrer: "b"}}), errWriteFailed)
+	stats, err := s.GetURLStats(ctx, "kept", 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Clicks)
+	assert.Equal(t, []models.ClickCount{{Value: "a", Clicks: 1}}, stats.TopReferrers)
+
+	count, err := s.CountURLs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Состояние в памяти совпадает с файлом
+	reopened := openStorage(t, path)
+	defer reopened.Close()
+	for _, code := range []string{"kept", "deleted", "lost"} {
+		_, want := s.GetRedirectURL(ctx, code)
+		_, got := reopened.GetRedirectURL(ctx, code)
+		assert.Equal(t, want, got, code)
+	}
+
+	// Сжатие при закрытии не записывает снимок после ошибки записи
+	assert.ErrorIs(t, s.Close(), errWriteFailed)
+}
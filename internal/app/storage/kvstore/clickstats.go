@@ -0,0 +1,206 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// maxTrackedValues ограничивает количество различных значений измерения,
+// хранимых для одной ссылки. Переходы с новыми значениями сверх лимита
+// учитываются в общем счётчике, но не в разбивке.
+const maxTrackedValues = 10000
+
+// clickAggregate - агрегаты переходов по ссылке (значение ключа c:<код>).
+type clickAggregate struct {
+	Clicks     int64            `json:"clicks"`
+	FirstClick time.Time        `json:"first_click_at"`
+	LastClick  time.Time        `json:"last_click_at"`
+	Referrers  map[string]int64 `json:"referrers,omitempty"`
+	UserAgents map[string]int64 `json:"user_agents,omitempty"`
+	IPPrefixes map[string]int64 `json:"ip_prefixes,omitempty"`
+}
+
+// RecordClicks сохраняет пакет событий перехода.
+//
+// Агрегаты каждой ссылки читаются, обновляются и записываются один раз
+// за пакет в одной транзакции. События для несуществующих ссылок пропускаются.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	events - события перехода
+//
+// Возвращает:
+//
+//	error - ошибка операции
+func (s *KVStorage) RecordClicks(ctx context.Context, events []models.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	return s.db.update(func(tx *txn) error {
+		aggregates := make(map[string]*clickAggregate)
+		for _, event := range events {
+			agg, loaded := aggregates[event.ShortURL]
+			if !loaded {
+				if exists, err := tx.has(urlKey(event.ShortURL)); err != nil {
+					return err
+				} else if !exists {
+					aggregates[event.ShortURL] = nil
+					continue
+				}
+
+				var err error
+				if agg, err = getClickAggregate(tx, event.ShortURL); err != nil {
+					return err
+				}
+				aggregates[event.ShortURL] = agg
+			}
+			if agg == nil {
+				continue
+			}
+			agg.apply(event)
+		}
+
+		for code, agg := range aggregates {
+			if agg == nil {
+				continue
+			}
+			data, err := json.Marshal(agg)
+			if err != nil {
+				return err
+			}
+			tx.put(clickKey(code), data)
+		}
+		return nil
+	})
+}
+
+// GetURLStats возвращает статистику переходов по ссылке текущего пользователя.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	shortKey - сокращенный ключ URL
+//	top - количество значений в разбивках по источникам и User-Agent
+//
+// Возвращает:
+//
+//	models.URLStats - статистика переходов (ShortURL содержит короткий идентификатор)
+//	error - ошибка операции:
+//	  - storage.ErrURLNotFound если ссылка не найдена или принадлежит другому пользователю
+func (s *KVStorage) GetURLStats(ctx context.Context, shortKey string, top int) (models.URLStats, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return models.URLStats{}, err
+	}
+
+	var (
+		record models.UserURLMapping
+		agg    *clickAggregate
+	)
+	err = s.db.view(func(tx *txn) error {
+		var err error
+		if record, err = getRecord(tx, shortKey); err != nil {
+			return err
+		}
+		agg, err = getClickAggregate(tx, shortKey)
+		return err
+	})
+	if err != nil {
+		return models.URLStats{}, err
+	}
+	if record.UserID != userID {
+		return models.URLStats{}, storage.ErrURLNotFound
+	}
+
+	stats := models.URLStats{
+		ShortURL:    shortKey,
+		OriginalURL: record.OriginalURL,
+	}
+	if agg.Clicks == 0 {
+		return stats, nil
+	}
+
+	stats.Clicks = agg.Clicks
+	stats.UniqueVisitors = int64(len(agg.IPPrefixes))
+	stats.FirstClickAt = &agg.FirstClick
+	stats.LastClickAt = &agg.LastClick
+	stats.TopReferrers = topClickCounts(agg.Referrers, top)
+	stats.TopUserAgents = topClickCounts(agg.UserAgents, top)
+
+	return stats, nil
+}
+
+// getClickAggregate читает агрегаты переходов по ссылке (пустые, если переходов не было).
+func getClickAggregate(tx *txn, code string) (*clickAggregate, error) {
+	agg := &clickAggregate{}
+	data, err := tx.get(clickKey(code))
+	if errors.Is(err, errNotFound) {
+		return agg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return agg, json.Unmarshal(data, agg)
+}
+
+// apply учитывает событие перехода в агрегатах.
+func (agg *clickAggregate) apply(event models.ClickEvent) {
+	if agg.Clicks == 0 || event.At.Before(agg.FirstClick) {
+		agg.FirstClick = event.At
+	}
+	if agg.Clicks == 0 || event.At.After(agg.LastClick) {
+		agg.LastClick = event.At
+	}
+	agg.Clicks++
+
+	agg.Referrers = countValue(agg.Referrers, event.Referrer)
+	agg.UserAgents = countValue(agg.UserAgents, event.UserAgent)
+	agg.IPPrefixes = countValue(agg.IPPrefixes, event.IPPrefix)
+}
+
+// countValue увеличивает счётчик значения измерения с учётом лимита maxTrackedValues.
+func countValue(counts map[string]int64, value string) map[string]int64 {
+	if value == "" {
+		return counts
+	}
+	if counts == nil {
+		counts = make(map[string]int64)
+	}
+	if _, exists := counts[value]; !exists && len(counts) >= maxTrackedValues {
+		return counts
+	}
+	counts[value]++
+	return counts
+}
+
+// topClickCounts возвращает не более top значений с наибольшим количеством переходов.
+// При равенстве счётчиков значения упорядочиваются лексикографически.
+func topClickCounts(counts map[string]int64, top int) []models.ClickCount {
+	result := make([]models.ClickCount, 0, len(counts))
+	for value, clicks := range counts {
+		result = append(result, models.ClickCount{Value: value, Clicks: clicks})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Clicks != result[j].Clicks {
+			return result[i].Clicks > result[j].Clicks
+		}
+		return result[i].Value < result[j].Value
+	})
+
+	if len(result) > top {
+		result = result[:top]
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
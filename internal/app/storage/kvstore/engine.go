@@ -0,0 +1,483 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Параметры движка по умолчанию.
+const (
+	defaultMemtableSize = 4 << 20 // Объём таблицы в памяти, после которого она сбрасывается на диск
+	defaultMaxTables    = 4       // Количество таблиц на диске, после которого запускается слияние
+)
+
+// Имена служебных файлов каталога хранилища.
+const (
+	walFileName      = "wal.log"
+	manifestFileName = "MANIFEST"
+)
+
+// errNotFound - ключ отсутствует.
+var errNotFound = errors.New("kvstore: key not found")
+
+// errClosed - хранилище закрыто.
+var errClosed = errors.New("kvstore: storage is closed")
+
+// manifest - список актуальных таблиц каталога.
+// Файлы таблиц, не указанные в манифесте, остались от прерванного слияния
+// или сброса и удаляются при открытии.
+type manifest struct {
+	NextTable uint64   `json:"next_table"`
+	Tables    []uint64 `json:"tables"` // От старых к новым
+}
+
+// db - встроенное упорядоченное хранилище ключ-значение (LSM-дерево).
+//
+// Запись: пакет изменений транзакции дописывается в журнал (с fsync) и
+// применяется к таблице в памяти. Заполненная таблица в памяти сбрасывается
+// в неизменяемую таблицу на диске, после чего журнал очищается.
+// Когда таблиц становится больше maxTables, фоновое слияние объединяет их
+// в одну, отбрасывая перекрытые версии и признаки удаления.
+//
+// Чтение: ключ ищется в таблице в памяти, затем в таблицах на диске от новых к старым.
+//
+// Транзакции записи выполняются последовательно под блокировкой записи,
+// поэтому проверки внутри транзакции атомарны относительно других записей.
+type db struct {
+	dir          string
+	memtableSize int
+	maxTables    int
+	log          *zap.Logger
+
+	mu        sync.RWMutex
+	wal       *wal
+	mem       *memtable
+	tables    []*table // От старых к новым
+	nextTable uint64
+	closed    bool
+
+	compacting bool
+	compaction sync.WaitGroup
+}
+
+// openDB открывает или создает хранилище в каталоге dir.
+func openDB(dir string, memtableSize, maxTables int, log *zap.Logger) (*db, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	d := &db{
+		dir:          dir,
+		memtableSize: memtableSize,
+		maxTables:    maxTables,
+		log:          log,
+		mem:          &memtable{},
+		nextTable:    1,
+	}
+
+	m, err := d.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	if m.NextTable > d.nextTable {
+		d.nextTable = m.NextTable
+	}
+	for _, num := range m.Tables {
+		t, err := openTable(dir, num)
+		if err != nil {
+			d.closeTables()
+			return nil, err
+		}
+		d.tables = append(d.tables, t)
+	}
+	d.removeOrphanTables(m.Tables)
+
+	w, batches, err := openWAL(filepath.Join(dir, walFileName), log)
+	if err != nil {
+		d.closeTables()
+		return nil, err
+	}
+	d.wal = w
+	for _, batch := range batches {
+		for _, mu := range batch {
+			d.mem.apply(mu)
+		}
+	}
+
+	return d, nil
+}
+
+// readManifest читает манифест; для нового каталога возвращает пустой манифест.
+func (d *db) readManifest() (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(filepath.Join(d.dir, manifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return m, nil
+}
+
+// writeManifest атомарно заменяет манифест текущим списком таблиц.
+// Вызывается под блокировкой записи.
+func (d *db) writeManifest() error {
+	m := manifest{NextTable: d.nextTable}
+	for _, t := range d.tables {
+		m.Tables = append(m.Tables, t.num)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(d.dir, manifestFileName)
+	tmp, err := os.CreateTemp(d.dir, manifestFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(d.dir)
+}
+
+// removeOrphanTables удаляет файлы таблиц, отсутствующие в манифесте.
+func (d *db) removeOrphanTables(live []uint64) {
+	keep := make(map[uint64]struct{}, len(live))
+	for _, num := range live {
+		keep[num] = struct{}{}
+	}
+
+	files, err := filepath.Glob(filepath.Join(d.dir, "*.sst"))
+	if err != nil {
+		return
+	}
+	for _, path := range files {
+		num, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), ".sst"), 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, ok := keep[num]; !ok {
+			d.log.Warn("Removing orphan kvstore table", zap.String("path", path))
+			os.Remove(path)
+		}
+	}
+}
+
+// view выполняет fn в транзакции только для чтения.
+func (d *db) view(fn func(tx *txn) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return errClosed
+	}
+	return fn(&txn{db: d})
+}
+
+// update выполняет fn в транзакции записи. Изменения сохраняются, только
+// если fn не вернула ошибку, и записываются в журнал одним пакетом.
+func (d *db) update(fn func(tx *txn) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return errClosed
+	}
+
+	tx := &txn{db: d, writable: true, pending: make(map[string]int)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.writes) == 0 {
+		return nil
+	}
+
+	if err := d.wal.append(tx.writes); err != nil {
+		return err
+	}
+	for _, mu := range tx.writes {
+		d.mem.apply(mu)
+	}
+
+	if d.mem.size >= d.memtableSize {
+		if err := d.flush(); err != nil {
+			// Изменения уже сохранены в журнале, сброс будет повторён при следующей записи
+			d.log.Error("Failed to flush kvstore memtable", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// get возвращает значение ключа без учёта транзакций. Вызывается под блокировкой.
+func (d *db) get(key string) ([]byte, error) {
+	if e, ok := d.mem.get(key); ok {
+		if e.deleted {
+			return nil, errNotFound
+		}
+		return e.value, nil
+	}
+
+	for i := len(d.tables) - 1; i >= 0; i-- {
+		e, ok, err := d.tables[i].get(key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if e.deleted {
+				return nil, errNotFound
+			}
+			return e.value, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// scan вызывает fn для ключей с префиксом prefix в порядке возрастания,
+// пока fn возвращает true. Вызывается под блокировкой.
+func (d *db) scan(prefix string, fn func(key string, value []byte) (bool, error)) error {
+	iters := []iterator{d.mem.iter(prefix)}
+	for i := len(d.tables) - 1; i >= 0; i-- {
+		iters = append(iters, d.tables[i].iter(prefix))
+	}
+
+	it := newMergeIterator(iters)
+	for it.next() {
+		e := it.entry()
+		if !strings.HasPrefix(e.key, prefix) {
+			break
+		}
+		if e.deleted {
+			continue
+		}
+		if more, err := fn(e.key, e.value); err != nil || !more {
+			return err
+		}
+	}
+	return it.err()
+}
+
+// flush сбрасывает таблицу в памяти на диск. Вызывается под блокировкой записи.
+func (d *db) flush() error {
+	if len(d.mem.entries) == 0 {
+		return nil
+	}
+
+	num := d.nextTable
+	if err := writeTable(d.dir, num, d.mem.iter(""), false); err != nil {
+		os.Remove(tablePath(d.dir, num))
+		return err
+	}
+	t, err := openTable(d.dir, num)
+	if err != nil {
+		os.Remove(tablePath(d.dir, num))
+		return err
+	}
+
+	d.nextTable++
+	d.tables = append(d.tables, t)
+	if err := d.writeManifest(); err != nil {
+		d.tables = d.tables[:len(d.tables)-1]
+		t.close()
+		os.Remove(t.path)
+		return err
+	}
+
+	d.mem = &memtable{}
+	if err := d.wal.reset(); err != nil {
+		return err
+	}
+
+	if len(d.tables) > d.maxTables && !d.compacting && !d.closed {
+		d.startCompaction()
+	}
+	return nil
+}
+
+// startCompaction запускает фоновое слияние текущих таблиц. Вызывается под блокировкой записи.
+func (d *db) startCompaction() {
+	d.compacting = true
+	inputs := append([]*table(nil), d.tables...)
+	num := d.nextTable
+	d.nextTable++
+
+	d.compaction.Add(1)
+	go func() {
+		defer d.compaction.Done()
+		if err := d.compact(inputs, num); err != nil {
+			d.log.Error("Failed to compact kvstore tables", zap.Error(err))
+		}
+	}()
+}
+
+// compact объединяет таблицы inputs в таблицу num и заменяет их ею.
+//
+// Таблицы неизменяемы, поэтому слияние выполняется без блокировки.
+// inputs включают самую старую таблицу, поэтому признаки удаления
+// больше ничего не перекрывают и отбрасываются.
+func (d *db) compact(inputs []*table, num uint64) error {
+	defer func() {
+		d.mu.Lock()
+		d.compacting = false
+		d.mu.Unlock()
+	}()
+
+	iters := make([]iterator, 0, len(inputs))
+	for i := len(inputs) - 1; i >= 0; i-- {
+		iters = append(iters, inputs[i].iter(""))
+	}
+	if err := writeTable(d.dir, num, newMergeIterator(iters), true); err != nil {
+		os.Remove(tablePath(d.dir, num))
+		return err
+	}
+	merged, err := openTable(d.dir, num)
+	if err != nil {
+		os.Remove(tablePath(d.dir, num))
+		return err
+	}
+
+	d.mu.Lock()
+	// Таблицы, сброшенные во время слияния, новее объединённой
+	tables := append([]*table{merged}, d.tables[len(inputs):]...)
+	previous := d.tables
+	d.tables = tables
+	if err := d.writeManifest(); err != nil {
+		d.tables = previous
+		d.mu.Unlock()
+		merged.close()
+		os.Remove(merged.path)
+		return err
+	}
+	d.mu.Unlock()
+
+	// Читатели удерживают блокировку чтения на всё время обращения к таблицам,
+	// поэтому после замены списка старые таблицы больше никто не использует
+	for _, t := range inputs {
+		t.close()
+		os.Remove(t.path)
+	}
+	return nil
+}
+
+// close сбрасывает таблицу в памяти на диск, дожидается слияния и закрывает файлы.
+func (d *db) close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	err := d.flush()
+	d.mu.Unlock()
+
+	// Новые транзакции после closed невозможны, слияние меняет только список таблиц
+	d.compaction.Wait()
+
+	if werr := d.wal.close(); err == nil {
+		err = werr
+	}
+	d.closeTables()
+	return err
+}
+
+// closeTables закрывает файлы таблиц.
+func (d *db) closeTables() {
+	for _, t := range d.tables {
+		t.close()
+	}
+}
+
+// txn - транзакция хранилища.
+// Чтение внутри транзакции записи учитывает её собственные изменения (кроме scan).
+type txn struct {
+	db       *db
+	writable bool
+	writes   []mutation
+	pending  map[string]int // Ключ -> индекс последнего изменения в writes
+}
+
+// get возвращает значение ключа или errNotFound.
+func (tx *txn) get(key string) ([]byte, error) {
+	if i, ok := tx.pending[key]; ok {
+		if tx.writes[i].op == opDelete {
+			return nil, errNotFound
+		}
+		return tx.writes[i].value, nil
+	}
+	return tx.db.get(key)
+}
+
+// has проверяет наличие ключа.
+func (tx *txn) has(key string) (bool, error) {
+	_, err := tx.get(key)
+	if errors.Is(err, errNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// put сохраняет значение ключа.
+func (tx *txn) put(key string, value []byte) {
+	tx.write(mutation{op: opPut, key: key, value: value})
+}
+
+// delete удаляет ключ.
+func (tx *txn) delete(key string) {
+	tx.write(mutation{op: opDelete, key: key})
+}
+
+// write добавляет изменение в пакет транзакции.
+func (tx *txn) write(mu mutation) {
+	if !tx.writable {
+		panic("kvstore: write in read-only transaction")
+	}
+	if i, ok := tx.pending[mu.key]; ok {
+		tx.writes[i] = mu
+		return
+	}
+	tx.pending[mu.key] = len(tx.writes)
+	tx.writes = append(tx.writes, mu)
+}
+
+// scan обходит сохранённые ключи с префиксом prefix в порядке возрастания.
+// Изменения текущей транзакции не учитываются, поэтому fn может изменять
+// просматриваемые ключи.
+func (tx *txn) scan(prefix string, fn func(key string, value []byte) (bool, error)) error {
+	return tx.db.scan(prefix, fn)
+}
+
+// syncDir сбрасывает на диск изменения каталога (создание и переименование файлов).
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
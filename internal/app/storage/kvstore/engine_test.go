@@ -0,0 +1,330 @@
+package kvstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// crash закрывает файлы хранилища без сброса таблицы в памяти,
+// как при аварийном завершении процесса.
+func crash(t *testing.T, d *db) {
+	t.Helper()
+	d.compaction.Wait()
+	require.NoError(t, d.wal.close())
+	d.closeTables()
+}
+
+// put записывает пары ключ-значение одной транзакцией.
+func put(t *testing.T, d *db, kv ...string) {
+	t.Helper()
+	require.NoError(t, d.update(func(tx *txn) error {
+		for i := 0; i < len(kv); i += 2 {
+			tx.put(kv[i], []byte(kv[i+1]))
+		}
+		return nil
+	}))
+}
+
+// del удаляет ключи одной транзакцией.
+func del(t *testing.T, d *db, keys ...string) {
+	t.Helper()
+	require.NoError(t, d.update(func(tx *txn) error {
+		for _, key := range keys {
+			tx.delete(key)
+		}
+		return nil
+	}))
+}
+
+// flushNow принудительно сбрасывает таблицу в памяти на диск.
+func flushNow(t *testing.T, d *db) {
+	t.Helper()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	require.NoError(t, d.flush())
+}
+
+// assertValues проверяет значения ключей; пустая строка - ключ отсутствует.
+func assertValues(t *testing.T, d *db, want map[string]string) {
+	t.Helper()
+	require.NoError(t, d.view(func(tx *txn) error {
+		for key, value := range want {
+			got, err := tx.get(key)
+			if value == "" {
+				assert.ErrorIs(t, err, errNotFound, key)
+				continue
+			}
+			if assert.NoError(t, err, key) {
+				assert.Equal(t, value, string(got), key)
+			}
+		}
+		return nil
+	}))
+}
+
+// scanKeys возвращает все ключи хранилища в порядке обхода.
+func scanKeys(t *testing.T, d *db) []string {
+	t.Helper()
+	var keys []string
+	require.NoError(t, d.view(func(tx *txn) error {
+		return tx.scan("", func(key string, _ []byte) (bool, error) {
+			keys = append(keys, key)
+			return true, nil
+		})
+	}))
+	return keys
+}
+
+// tableFiles возвращает имена файлов таблиц каталога.
+func tableFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.sst"))
+	require.NoError(t, err)
+	for i, path := range files {
+		files[i] = filepath.Base(path)
+	}
+	return files
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func TestDB_ReopenAfterCrash(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(t *testing.T, path string)
+	}{
+		{
+			name: "torn record header",
+			damage: func(t *testing.T, path string) {
+				f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+				require.NoError(t, err)
+				defer f.Close()
+				_, err = f.Write([]byte{0x20, 0x00, 0x00})
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "partial record payload",
+			damage: func(t *testing.T, path string) {
+				f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+				require.NoError(t, err)
+				defer f.Close()
+				// Заголовок обещает 64 байта, записано меньше
+				_, err = f.Write([]byte{0x40, 0, 0, 0, 0xde, 0xad, 0xbe, 0xef, 0x01, 0x01})
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			walPath := filepath.Join(dir, walFileName)
+
+			d, err := openDB(dir, defaultMemtableSize, defaultMaxTables, zap.NewNop())
+			require.NoError(t, err)
+			put(t, d, "a", "1", "b", "2")
+			put(t, d, "c", "3")
+			del(t, d, "a")
+			crash(t, d)
+
+			validSize := fileSize(t, walPath)
+			tt.damage(t, walPath)
+			require.Greater(t, fileSize(t, walPath), validSize)
+
+			core, logs := observer.New(zapcore.WarnLevel)
+			d, err = openDB(dir, defaultMemtableSize, defaultMaxTables, zap.New(core))
+			require.NoError(t, err)
+
+			// Целые пакеты восстановлены, повреждённый хвост отброшен и обрезан
+			assertValues(t, d, map[string]string{"a": "", "b": "2", "c": "3"})
+			assert.Equal(t, validSize, fileSize(t, walPath))
+			assert.Equal(t, 1, logs.FilterMessage("Discarding damaged kvstore WAL tail").Len())
+
+			// Новые записи дописываются после обрезанного хвоста и тоже переживают сбой
+			put(t, d, "d", "4")
+			crash(t, d)
+
+			d, err = openDB(dir, defaultMemtableSize, defaultMaxTables, zap.NewNop())
+			require.NoError(t, err)
+			defer d.close()
+			assertValues(t, d, map[string]string{"a": "", "b": "2", "c": "3", "d": "4"})
+		})
+	}
+
+	t.Run("corrupted last batch is dropped whole", func(t *testing.T) {
+		dir := t.TempDir()
+		walPath := filepath.Join(dir, walFileName)
+
+		d, err := openDB(dir, defaultMemtableSize, defaultMaxTables, zap.NewNop())
+		require.NoError(t, err)
+		put(t, d, "a", "1")
+		firstBatch := fileSize(t, walPath)
+		put(t, d, "a", "2", "b", "2")
+		crash(t, d)
+
+		data, err := os.ReadFile(walPath)
+		require.NoError(t, err)
+		data[len(data)-1] ^= 0xff
+		require.NoError(t, os.WriteFile(walPath, data, 0644))
+
+		d, err = openDB(dir, defaultMemtableSize, defaultMaxTables, zap.NewNop())
+		require.NoError(t, err)
+		defer d.close()
+
+		// Пакет транзакции не применяется частично
+		assertValues(t, d, map[string]string{"a": "1", "b": ""})
+		assert.Equal(t, firstBatch, fileSize(t, walPath))
+	})
+}
+
+func TestDB_FlushAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, walFileName)
+
+	// Маленькая таблица в памяти сбрасывается на диск через несколько записей,
+	// большой порог слияния оставляет все таблицы на месте
+	d, err := openDB(dir, 64, 100, zap.NewNop())
+	require.NoError(t, err)
+
+	want := make(map[string]string)
+	var keys []string
+	for i := 0; i < 30; i++ {
+		key, value := fmt.Sprintf("key%02d", i), fmt.Sprintf("value%d", i)
+		put(t, d, key, value)
+		want[key] = value
+		keys = append(keys, key)
+	}
+	put(t, d, "key00", "updated")
+	want["key00"] = "updated"
+	del(t, d, "key01")
+	want["key01"] = ""
+	keys = append(keys[:1], keys[2:]...)
+
+	require.Greater(t, len(d.tables), 1, "memtable should have been flushed")
+	assertValues(t, d, want)
+	assert.Equal(t, keys, scanKeys(t, d))
+
+	// Манифест перечисляет таблицы от старых к новым
+	m, err := d.readManifest()
+	require.NoError(t, err)
+	var nums []uint64
+	for _, tbl := range d.tables {
+		nums = append(nums, tbl.num)
+	}
+	assert.Equal(t, nums, m.Tables)
+	assert.Equal(t, d.nextTable, m.NextTable)
+	assert.Len(t, tableFiles(t, dir), len(nums))
+
+	// Сброс таблицы в памяти очищает журнал
+	put(t, d, "key99", "last")
+	want["key99"] = "last"
+	keys = append(keys, "key99")
+	require.NotZero(t, fileSize(t, walPath))
+	flushNow(t, d)
+	assert.Zero(t, fileSize(t, walPath))
+	assert.Empty(t, d.mem.entries)
+
+	require.NoError(t, d.close())
+	tmp, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmp)
+
+	d, err = openDB(dir, 64, 100, zap.NewNop())
+	require.NoError(t, err)
+	defer d.close()
+	assertValues(t, d, want)
+	assert.Equal(t, keys, scanKeys(t, d))
+}
+
+func TestDB_RemovesOrphanTables(t *testing.T) {
+	dir := t.TempDir()
+
+	d, err := openDB(dir, defaultMemtableSize, defaultMaxTables, zap.NewNop())
+	require.NoError(t, err)
+	put(t, d, "a", "1")
+	require.NoError(t, d.close())
+	live := tableFiles(t, dir)
+	require.Len(t, live, 1)
+
+	// Таблица, записанная прерванным сбросом или слиянием, но не попавшая в манифест
+	orphan := tablePath(dir, 999)
+	require.NoError(t, os.WriteFile(orphan, []byte("partial table"), 0644))
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	d, err = openDB(dir, defaultMemtableSize, defaultMaxTables, zap.New(core))
+	require.NoError(t, err)
+	defer d.close()
+
+	assert.NoFileExists(t, orphan)
+	assert.Equal(t, live, tableFiles(t, dir))
+	assert.Equal(t, 1, logs.FilterMessage("Removing orphan kvstore table").Len())
+	assertValues(t, d, map[string]string{"a": "1"})
+}
+
+func TestDB_Compaction(t *testing.T) {
+	dir := t.TempDir()
+
+	// Сброс выполняется вручную, слияние начинается при третьей таблице
+	d, err := openDB(dir, defaultMemtableSize, 2, zap.NewNop())
+	require.NoError(t, err)
+
+	put(t, d, "a", "1", "b", "1", "c", "1")
+	flushNow(t, d)
+	put(t, d, "a", "2")
+	del(t, d, "b")
+	flushNow(t, d)
+	del(t, d, "c")
+	put(t, d, "d", "1")
+	flushNow(t, d)
+
+	// Таблица, сброшенная во время слияния, остаётся новее объединённой
+	put(t, d, "a", "3", "e", "1")
+	flushNow(t, d)
+
+	d.compaction.Wait()
+
+	want := map[string]string{"a": "3", "b": "", "c": "", "d": "1", "e": "1"}
+	assertValues(t, d, want)
+	assert.Equal(t, []string{"a", "d", "e"}, scanKeys(t, d))
+	assert.LessOrEqual(t, len(d.tables), 2)
+
+	// Объединённая таблица хранит по одной (последней) версии ключа и не хранит признаков удаления
+	var merged []entry
+	it := d.tables[0].iter("")
+	for it.next() {
+		merged = append(merged, it.entry())
+	}
+	require.NoError(t, it.err())
+	assert.Equal(t, []entry{
+		{key: "a", value: []byte("2")},
+		{key: "d", value: []byte("1")},
+	}, merged)
+
+	// Исходные таблицы удалены, манифест указывает только на актуальные
+	m, err := d.readManifest()
+	require.NoError(t, err)
+	assert.Len(t, m.Tables, len(d.tables))
+	assert.Len(t, tableFiles(t, dir), len(d.tables))
+
+	require.NoError(t, d.close())
+
+	d, err = openDB(dir, defaultMemtableSize, 2, zap.NewNop())
+	require.NoError(t, err)
+	defer d.close()
+	assertValues(t, d, want)
+	assert.Equal(t, []string{"a", "d", "e"}, scanKeys(t, d))
+}
@@ -0,0 +1,763 @@
+// Package kvstore предоставляет хранилище URL поверх встроенного
+// упорядоченного хранилища ключ-значение (LSM-дерево) для развертывания на одном узле.
+//
+// В отличие от файлового хранилища, данные не загружаются в память целиком:
+// в памяти держатся только последние изменения и индексы блоков таблиц.
+//
+// Пакет включает:
+// - Журнал упреждающей записи с контрольными суммами пакетов
+// - Неизменяемые упорядоченные таблицы на диске и их фоновое слияние
+// - Транзакции, записывающие пакет изменений атомарно
+//
+// Схема ключей:
+// - u:<код> - JSON-запись models.UserURLMapping
+// - o:<userID>\x00<URL> - код (индекс по паре пользователь и оригинальный URL)
+// - l:<userID>\x00<код> - оригинальный URL (индекс ссылок пользователя)
+// - n:<userID> - количество ссылок пользователя
+// - p:<момент, 8 байт big-endian><код> - очередь физического удаления
+// - c:<код> - JSON-агрегаты переходов по ссылке
+// - m:urls, m:users, m:short_key_seq - счётчики
+package kvstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// Префиксы и ключи хранилища.
+const (
+	urlPrefix       = "u:"
+	originalPrefix  = "o:"
+	userLinkPrefix  = "l:"
+	userCountPrefix = "n:"
+	purgePrefix     = "p:"
+	clickPrefix     = "c:"
+	urlsCountKey    = "m:urls"
+	usersCountKey   = "m:users"
+	sequenceKey     = "m:short_key_seq"
+)
+
+// sequenceStart - первое значение счётчика коротких ключей (как short_key_seq в PostgreSQL).
+const sequenceStart = 1000000
+
+// KVStorage реализует интерфейс хранилища поверх встроенного хранилища ключ-значение.
+type KVStorage struct {
+	db           *db
+	memtableSize int
+	maxTables    int
+	log          *zap.Logger
+}
+
+// Option задаёт дополнительные параметры KVStorage.
+type Option func(*KVStorage)
+
+// WithMemtableSize задаёт объём изменений в памяти (в байтах), после которого они сбрасываются на диск.
+func WithMemtableSize(size int) Option {
+	return func(s *KVStorage) {
+		if size > 0 {
+			s.memtableSize = size
+		}
+	}
+}
+
+// WithMaxTables задаёт количество таблиц на диске, после которого запускается их слияние.
+func WithMaxTables(n int) Option {
+	return func(s *KVStorage) {
+		if n > 0 {
+			s.maxTables = n
+		}
+	}
+}
+
+// WithLogger задаёт логгер хранилища: восстановление журнала, удаление лишних таблиц
+// и ошибки фоновых сброса и слияния. По умолчанию события не логируются.
+func WithLogger(log *zap.Logger) Option {
+	return func(s *KVStorage) {
+		s.log = log
+	}
+}
+
+// NewKVStorage открывает или создает хранилище в каталоге dir.
+//
+// Параметры:
+//   - dir: каталог файлов хранилища
+//   - opts: дополнительные параметры (например, WithMemtableSize)
+//
+// Возвращает:
+//   - *KVStorage: инициализированное хранилище
+//   - error: ошибка открытия или восстановления данных
+func NewKVStorage(dir string, opts ...Option) (*KVStorage, error) {
+	s := &KVStorage{
+		memtableSize: defaultMemtableSize,
+		maxTables:    defaultMaxTables,
+		log:          zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	d, err := openDB(dir, s.memtableSize, s.maxTables, s.log)
+	if err != nil {
+		return nil, err
+	}
+	s.db = d
+
+	return s, nil
+}
+
+// Ключи хранилища.
+
+func urlKey(code string) string              { return urlPrefix + code }
+func originalKey(userID, url string) string  { return originalPrefix + userID + "\x00" + url }
+func userLinksPrefix(userID string) string   { return userLinkPrefix + userID + "\x00" }
+func userLinkKey(userID, code string) string { return userLinksPrefix(userID) + code }
+func userCountKey(userID string) string      { return userCountPrefix + userID }
+func clickKey(code string) string            { return clickPrefix + code }
+func purgeKey(at time.Time, code string) string {
+	return purgePrefix + string(binary.BigEndian.AppendUint64(nil, uint64(timeScore(at)))) + code
+}
+
+// Ping проверяет доступность хранилища.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//
+// Возвращает:
+//
+//	error - ошибка, если хранилище закрыто
+func (s *KVStorage) Ping(ctx context.Context) error {
+	return s.db.view(func(tx *txn) error { return nil })
+}
+
+// GetShortKey возвращает сокращенный URL для оригинального.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	originalURL - оригинальный URL
+//
+// Возвращает:
+//
+//	models.URLMapping - соответствие URL
+//	error - ошибка операции (storage.ErrURLNotFound если не найден)
+func (s *KVStorage) GetShortKey(ctx context.Context, originalURL string) (models.URLMapping, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return models.URLMapping{}, err
+	}
+
+	var code []byte
+	err = s.db.view(func(tx *txn) error {
+		code, err = tx.get(originalKey(userID, originalURL))
+		return err
+	})
+	if errors.Is(err, errNotFound) {
+		return models.URLMapping{}, storage.ErrURLNotFound
+	}
+	if err != nil {
+		return models.URLMapping{}, err
+	}
+
+	return models.URLMapping{
+		ShortURL:    string(code),
+		OriginalURL: originalURL,
+	}, nil
+}
+
+// GetRedirectURL возвращает оригинальный URL для сокращенного.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//	shortKey - сокращенный ключ URL
+//
+// Возвращает:
+//
+//	models.URLMapping - соответствие URL
+//	error - ошибка операции:
+//	  - storage.ErrURLNotFound если URL не существует
+//	  - storage.ErrURLDeleted если URL помечен как удаленный
+//	  - storage.ErrURLExpired если срок действия URL истёк
+func (s *KVStorage) GetRedirectURL(ctx context.Context, shortKey string) (models.URLMapping, error) {
+	var record models.UserURLMapping
+	err := s.db.view(func(tx *txn) error {
+		var err error
+		record, err = getRecord(tx, shortKey)
+		return err
+	})
+	if err != nil {
+		return models.URLMapping{ShortURL: shortKey}, err
+	}
+
+	if record.DeletedFlag {
+		return models.URLMapping{}, storage.ErrURLDeleted
+	}
+
+	if record.ExpiresAt != nil && !time.Now().Before(*record.ExpiresAt) {
+		return models.URLMapping{}, storage.ErrURLExpired
+	}
+
+	return models.URLMapping{
+		ShortURL:    record.ShortURL,
+		OriginalURL: record.OriginalURL,
+		ExpiresAt:   record.ExpiresAt,
+	}, nil
+}
+
+// getRecord читает запись ссылки. Возвращает storage.ErrURLNotFound, если ссылки нет.
+func getRecord(tx *txn, code string) (models.UserURLMapping, error) {
+	var record models.UserURLMapping
+	data, err := tx.get(urlKey(code))
+	if errors.Is(err, errNotFound) {
+		return record, storage.ErrURLNotFound
+	}
+	if err != nil {
+		return record, err
+	}
+
+	err = json.Unmarshal(data, &record)
+	return record, err
+}
+
+// SaveURL сохраняет новое соответствие URL.
+//
+// Проверки и запись выполняются в одной транзакции. Как и в PostgreSQL,
+// существующая пара (пользователь, URL) имеет приоритет перед конфликтом
+// по короткому ключу.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	mapping - соответствие URL для сохранения
+//
+// Возвращает:
+//
+//	error - ошибка операции:
+//	  - storage.ErrURLExists если URL уже сокращён пользователем (mapping.ShortURL содержит существующий ключ)
+//	  - storage.ErrShortURLExists если короткий ключ уже занят
+func (s *KVStorage) SaveURL(ctx context.Context, mapping *models.URLMapping) error {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.db.update(func(tx *txn) error {
+		code, err := tx.get(originalKey(userID, mapping.OriginalURL))
+		if err == nil {
+			mapping.ShortURL = string(code)
+			return storage.ErrURLExists
+		}
+		if !errors.Is(err, errNotFound) {
+			return err
+		}
+
+		if exists, err := tx.has(urlKey(mapping.ShortURL)); err != nil {
+			return err
+		} else if exists {
+			return storage.ErrShortURLExists
+		}
+
+		return insertRecord(tx, models.UserURLMapping{
+			ShortURL:    mapping.ShortURL,
+			OriginalURL: mapping.OriginalURL,
+			UserID:      userID,
+			ExpiresAt:   mapping.ExpiresAt,
+		})
+	})
+}
+
+// insertRecord сохраняет новую запись и обновляет индексы и счётчики.
+func insertRecord(tx *txn, record models.UserURLMapping) error {
+	if record.CreatedAt == nil {
+		now := time.Now()
+		record.CreatedAt = &now
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	tx.put(urlKey(record.ShortURL), data)
+	tx.put(originalKey(record.UserID, record.OriginalURL), []byte(record.ShortURL))
+	tx.put(userLinkKey(record.UserID, record.ShortURL), []byte(record.OriginalURL))
+	if record.ExpiresAt != nil {
+		tx.put(purgeKey(*record.ExpiresAt, record.ShortURL), nil)
+	}
+
+	if err := addCounter(tx, urlsCountKey, 1); err != nil {
+		return err
+	}
+	links, err := addCounterValue(tx, userCountKey(record.UserID), 1)
+	if err != nil {
+		return err
+	}
+	if links == 1 {
+		return addCounter(tx, usersCountKey, 1)
+	}
+	return nil
+}
+
+// GetExistingURLs возвращает существующие сокращения для URL.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	originalURLs - список оригинальных URL
+//
+// Возвращает:
+//
+//	map[string]string - соответствия URL (originalURL -> shortURL)
+//	error - ошибка операции
+func (s *KVStorage) GetExistingURLs(ctx context.Context, originalURLs []string) (map[string]string, error) {
+	existing := make(map[string]string)
+	if len(originalURLs) == 0 {
+		return existing, nil
+	}
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.view(func(tx *txn) error {
+		for _, originalURL := range originalURLs {
+			code, err := tx.get(originalKey(userID, originalURL))
+			if errors.Is(err, errNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			existing[originalURL] = string(code)
+		}
+		return nil
+	})
+	return existing, err
+}
+
+// SaveNewURLs сохраняет пакет новых URL в одной транзакции.
+//
+// Если хотя бы один короткий ключ занят (или повторяется внутри пакета),
+// ни один URL не сохраняется. URL, уже сокращённые пользователем, пропускаются.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	urls - список URL для сохранения
+//
+// Возвращает:
+//
+//	error - ошибка операции:
+//	  - storage.ErrShortURLExists если короткий ключ уже занят (пакет не сохраняется)
+func (s *KVStorage) SaveNewURLs(ctx context.Context, urls []models.URLMapping) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.db.update(func(tx *txn) error {
+		for _, url := range urls {
+			// Учитывает и URL, добавленные ранее в этой же транзакции
+			if exists, err := tx.has(originalKey(userID, url.OriginalURL)); err != nil {
+				return err
+			} else if exists {
+				continue
+			}
+
+			if exists, err := tx.has(urlKey(url.ShortURL)); err != nil {
+				return err
+			} else if exists {
+				return storage.ErrShortURLExists
+			}
+
+			err := insertRecord(tx, models.UserURLMapping{
+				ShortURL:    url.ShortURL,
+				OriginalURL: url.OriginalURL,
+				UserID:      userID,
+				ExpiresAt:   url.ExpiresAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetUserUrls возвращает страницу URL пользователя.
+//
+// Коды пользователя выбираются по префиксу индекса ссылок, записи
+// загружаются целиком и упорядочиваются в памяти (см. storage.PageUserURLs).
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	baseURL - базовый URL для построения полных коротких URL
+//	query - фильтры, порядок и страница
+//
+// Возвращает:
+//
+//	models.UserURLsPage - страница URL пользователя
+//	error - ошибка операции (storage.ErrInvalidCursor для неверного курсора)
+func (s *KVStorage) GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return models.UserURLsPage{}, err
+	}
+
+	prefix := userLinksPrefix(userID)
+	var records []models.UserURLMapping
+	err = s.db.view(func(tx *txn) error {
+		var codes []string
+		err := tx.scan(prefix, func(key string, value []byte) (bool, error) {
+			codes = append(codes, strings.TrimPrefix(key, prefix))
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, code := range codes {
+			record, err := getRecord(tx, code)
+			if errors.Is(err, storage.ErrURLNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.UserURLsPage{}, err
+	}
+	return storage.PageUserURLs(records, baseURL, query)
+}
+
+// BatchMarkAsDeleted помечает URL пользователя как удаленные.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	userID - идентификатор пользователя
+//	urls - список коротких URL для удаления
+//
+// Возвращает:
+//
+//	models.DeleteResult - удалённые, чужие и пропущенные коды
+//	error - ошибка операции
+func (s *KVStorage) BatchMarkAsDeleted(ctx context.Context, userID string, urls []string) (models.DeleteResult, error) {
+	var result models.DeleteResult
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	now := time.Now()
+	err := s.db.update(func(tx *txn) error {
+		for _, code := range urls {
+			record, err := getRecord(tx, code)
+			if errors.Is(err, storage.ErrURLNotFound) {
+				result.Skipped = append(result.Skipped, code)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if record.UserID != userID {
+				result.NotOwned = append(result.NotOwned, code)
+				continue
+			}
+			if record.DeletedFlag {
+				result.Skipped = append(result.Skipped, code)
+				continue
+			}
+
+			record.DeletedFlag = true
+			record.DeletedAt = &now
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			tx.put(urlKey(code), data)
+			tx.put(purgeKey(now, code), nil)
+			result.Deleted = append(result.Deleted, code)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.DeleteResult{}, err
+	}
+	return result, nil
+}
+
+// BatchRestore снимает пометку удаления с URL пользователя, удалённых
+// не раньше момента deletedAfter, и убирает их из очереди очистки.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	userID - идентификатор пользователя
+//	urls - список коротких URL для восстановления
+//	deletedAfter - начало окна восстановления
+//
+// Возвращает:
+//
+//	models.RestoreResult - восстановленные, неудалённые, чужие и недоступные коды
+//	error - ошибка операции
+func (s *KVStorage) BatchRestore(ctx context.Context, userID string, urls []string, deletedAfter time.Time) (models.RestoreResult, error) {
+	var result models.RestoreResult
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	err := s.db.update(func(tx *txn) error {
+		for _, code := range urls {
+			record, err := getRecord(tx, code)
+			if errors.Is(err, storage.ErrURLNotFound) {
+				result.Unavailable = append(result.Unavailable, code)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			switch {
+			case record.UserID != userID:
+				result.NotOwned = append(result.NotOwned, code)
+				continue
+			case !record.DeletedFlag:
+				result.Active = append(result.Active, code)
+				continue
+			case record.DeletedAt == nil || record.DeletedAt.Before(deletedAfter):
+				result.Unavailable = append(result.Unavailable, code)
+				continue
+			}
+
+			tx.delete(purgeKey(*record.DeletedAt, code))
+			record.DeletedFlag = false
+			record.DeletedAt = nil
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			tx.put(urlKey(code), data)
+			result.Restored = append(result.Restored, code)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.RestoreResult{}, err
+	}
+	return result, nil
+}
+
+// PurgeURLs физически удаляет записи, помеченные как удалённые до момента before
+// или истёкшие до него.
+//
+// Кандидаты выбираются из очереди p:, упорядоченной по моменту удаления или
+// истечения срока, поэтому полный просмотр ссылок не требуется.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	before - граница срока хранения
+//	limit - максимальное количество удаляемых записей
+//
+// Возвращает:
+//
+//	int - количество удалённых записей
+//	error - ошибка операции
+func (s *KVStorage) PurgeURLs(ctx context.Context, before time.Time, limit int) (int, error) {
+	bound := purgeKey(before, "")
+	purged := 0
+
+	err := s.db.update(func(tx *txn) error {
+		seen := make(map[string]struct{})
+		return tx.scan(purgePrefix, func(key string, _ []byte) (bool, error) {
+			if key >= bound || purged >= limit {
+				return false, ctx.Err()
+			}
+
+			code := key[len(bound):]
+			record, err := getRecord(tx, code)
+			if errors.Is(err, storage.ErrURLNotFound) {
+				// Запись уже удалена по другому элементу очереди
+				tx.delete(key)
+				return true, nil
+			}
+			if err != nil {
+				return false, err
+			}
+			if _, ok := seen[code]; ok || !isPurgeable(record, before) {
+				tx.delete(key)
+				return true, nil
+			}
+			seen[code] = struct{}{}
+
+			purged++
+			return true, deleteRecord(tx, record)
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// deleteRecord удаляет запись, её индексы, статистику и элементы очереди удаления.
+func deleteRecord(tx *txn, record models.UserURLMapping) error {
+	code := record.ShortURL
+	tx.delete(urlKey(code))
+	tx.delete(userLinkKey(record.UserID, code))
+	tx.delete(clickKey(code))
+	if record.ExpiresAt != nil {
+		tx.delete(purgeKey(*record.ExpiresAt, code))
+	}
+	if record.DeletedAt != nil {
+		tx.delete(purgeKey(*record.DeletedAt, code))
+	}
+
+	original := originalKey(record.UserID, record.OriginalURL)
+	if owner, err := tx.get(original); err == nil && string(owner) == code {
+		tx.delete(original)
+	}
+
+	if err := addCounter(tx, urlsCountKey, -1); err != nil {
+		return err
+	}
+	links, err := addCounterValue(tx, userCountKey(record.UserID), -1)
+	if err != nil {
+		return err
+	}
+	if links <= 0 {
+		tx.delete(userCountKey(record.UserID))
+		return addCounter(tx, usersCountKey, -1)
+	}
+	return nil
+}
+
+// isPurgeable проверяет, истёк ли срок хранения удалённой или просроченной записи.
+func isPurgeable(record models.UserURLMapping, before time.Time) bool {
+	if record.DeletedFlag && record.DeletedAt != nil && record.DeletedAt.Before(before) {
+		return true
+	}
+	return record.ExpiresAt != nil && record.ExpiresAt.Before(before)
+}
+
+// CountURLs возвращает количество сокращённых URL в сервисе.
+//
+// Параметры:
+//
+//	ctx - контекст
+//
+// Возвращает:
+//
+//	 int - количество сокращённых URL в сервисе
+//		error - ошибка операции
+func (s *KVStorage) CountURLs(ctx context.Context) (int, error) {
+	return s.counter(urlsCountKey)
+}
+
+// CountUsers возвращает количество пользователей в сервисе.
+//
+// Параметры:
+//
+//	ctx - контекст
+//
+// Возвращает:
+//
+//	 int - количество пользователей в сервисе
+//		error - ошибка операции
+func (s *KVStorage) CountUsers(ctx context.Context) (int, error) {
+	return s.counter(usersCountKey)
+}
+
+// counter возвращает значение счётчика.
+func (s *KVStorage) counter(key string) (int, error) {
+	var value int64
+	err := s.db.view(func(tx *txn) error {
+		var err error
+		value, err = readCounter(tx, key)
+		return err
+	})
+	return int(value), err
+}
+
+// NextSequenceValue возвращает очередное значение счётчика коротких ключей.
+// Используется генератором коротких ключей со стратегией sequence.
+func (s *KVStorage) NextSequenceValue(ctx context.Context) (uint64, error) {
+	var value int64
+	err := s.db.update(func(tx *txn) error {
+		var err error
+		value, err = addCounterValue(tx, sequenceKey, 1)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return uint64(value) + sequenceStart - 1, nil
+}
+
+// Close сбрасывает изменения на диск и закрывает файлы хранилища.
+func (s *KVStorage) Close() error {
+	return s.db.close()
+}
+
+// readCounter возвращает значение счётчика (0, если счётчика нет).
+func readCounter(tx *txn, key string) (int64, error) {
+	data, err := tx.get(key)
+	if errors.Is(err, errNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+// addCounter изменяет счётчик на delta.
+func addCounter(tx *txn, key string, delta int64) error {
+	_, err := addCounterValue(tx, key, delta)
+	return err
+}
+
+// addCounterValue изменяет счётчик на delta и возвращает новое значение.
+func addCounterValue(tx *txn, key string, delta int64) (int64, error) {
+	value, err := readCounter(tx, key)
+	if err != nil {
+		return 0, err
+	}
+	value += delta
+	tx.put(key, []byte(strconv.FormatInt(value, 10)))
+	return value, nil
+}
+
+// userIDFromContext возвращает идентификатор пользователя из контекста.
+func userIDFromContext(ctx context.Context) (string, error) {
+	userID, ok := ctx.Value(jwtauth.UserIDContextKey).(string)
+	if !ok {
+		return "", errors.New("userID is not set")
+	}
+	return userID, nil
+}
+
+// timeScore возвращает момент времени в миллисекундах для ключей очереди удаления.
+func timeScore(t time.Time) int64 {
+	return t.UnixMilli()
+}
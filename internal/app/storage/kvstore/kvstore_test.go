@@ -0,0 +1,206 @@
+package kvstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// userContext возвращает контекст с идентификатором пользователя.
+func userContext(userID string) context.Context {
+	return context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+}
+
+// openStorage открывает хранилище в каталоге dir и закрывает его по завершении теста.
+func openStorage(t *testing.T, dir string) *KVStorage {
+	t.Helper()
+	s, err := NewKVStorage(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// saveURL сохраняет ссылку пользователя.
+func saveURL(t *testing.T, s *KVStorage, userID, code, originalURL string) {
+	t.Helper()
+	mapping := models.URLMapping{ShortURL: code, OriginalURL: originalURL}
+	require.NoError(t, s.SaveURL(userContext(userID), &mapping))
+}
+
+// snapshot возвращает все пары ключ-значение хранилища.
+func snapshot(t *testing.T, s *KVStorage) map[string]string {
+	t.Helper()
+	kv := make(map[string]string)
+	require.NoError(t, s.db.view(func(tx *txn) error {
+		return tx.scan("", func(key string, value []byte) (bool, error) {
+			kv[key] = string(value)
+			return true, nil
+		})
+	}))
+	return kv
+}
+
+// userCodes возвращает коды всех ссылок пользователя.
+func userCodes(t *testing.T, s *KVStorage, userID string) []string {
+	t.Helper()
+	page, err := s.GetUserUrls(userContext(userID), "http://localhost", models.UserURLsQuery{})
+	require.NoError(t, err)
+	var codes []string
+	for _, url := range page.URLs {
+		codes = append(codes, strings.TrimPrefix(url.ShortURL, "http://localhost/"))
+	}
+	return codes
+}
+
+func TestKVStorage_SecondaryIndexes(t *testing.T) {
+	dir := t.TempDir()
+	s := openStorage(t, dir)
+
+	saveURL(t, s, "user1", "u1a", "https://example.com/a")
+	saveURL(t, s, "user1", "u1b", "https://example.com/b")
+	// Тот же URL другого пользователя индексируется отдельно
+	saveURL(t, s, "user2", "u2a", "https://example.com/a")
+
+	kv := snapshot(t, s)
+	assert.Equal(t, "u1a", kv[originalKey("user1", "https://example.com/a")])
+	assert.Equal(t, "u1b", kv[originalKey("user1", "https://example.com/b")])
+	assert.Equal(t, "u2a", kv[originalKey("user2", "https://example.com/a")])
+	assert.Equal(t, "https://example.com/a", kv[userLinkKey("user1", "u1a")])
+	assert.Equal(t, "https://example.com/b", kv[userLinkKey("user1", "u1b")])
+	assert.Equal(t, "https://example.com/a", kv[userLinkKey("user2", "u2a")])
+	assert.Equal(t, "2", kv[userCountKey("user1")])
+	assert.Equal(t, "1", kv[userCountKey("user2")])
+
+	// Пара (пользователь, URL) находится по индексу o:
+	mapping, err := s.GetShortKey(userContext("user2"), "https://example.com/a")
+	require.NoError(t, err)
+	assert.Equal(t, "u2a", mapping.ShortURL)
+	_, err = s.GetShortKey(userContext("user2"), "https://example.com/b")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	// Повторное сокращение возвращает существующий код, а не конфликт ключа
+	existing := models.URLMapping{ShortURL: "u1a", OriginalURL: "https://example.com/a"}
+	assert.ErrorIs(t, s.SaveURL(userContext("user1"), &existing), storage.ErrURLExists)
+	assert.Equal(t, "u1a", existing.ShortURL)
+
+	// Ссылки пользователя выбираются по индексу l:
+	assert.ElementsMatch(t, []string{"u1a", "u1b"}, userCodes(t, s, "user1"))
+	assert.Equal(t, []string{"u2a"}, userCodes(t, s, "user2"))
+	users, err := s.CountUsers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, users)
+
+	// Физическое удаление убирает запись из обоих индексов и счётчиков
+	_, err = s.BatchMarkAsDeleted(context.Background(), "user2", []string{"u2a"})
+	require.NoError(t, err)
+	purged, err := s.PurgeURLs(context.Background(), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+
+	kv = snapshot(t, s)
+	assert.NotContains(t, kv, urlKey("u2a"))
+	assert.NotContains(t, kv, originalKey("user2", "https://example.com/a"))
+	assert.NotContains(t, kv, userLinkKey("user2", "u2a"))
+	assert.NotContains(t, kv, userCountKey("user2"))
+	assert.Equal(t, "u1a", kv[originalKey("user1", "https://example.com/a")])
+	assert.Empty(t, userCodes(t, s, "user2"))
+	users, err = s.CountUsers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, users)
+
+	// URL снова можно сократить под тем же кодом
+	saveURL(t, s, "user2", "u2a", "https://example.com/a")
+
+	// Индексы сохраняются на диске вместе с записями
+	want := snapshot(t, s)
+	require.NoError(t, s.Close())
+	s = openStorage(t, dir)
+	assert.Equal(t, want, snapshot(t, s))
+	assert.Equal(t, []string{"u2a"}, userCodes(t, s, "user2"))
+}
+
+func TestKVStorage_SaveNewURLs_Conflict(t *testing.T) {
+	tests := []struct {
+		name string
+		urls []models.URLMapping
+	}{
+		{
+			name: "short key taken by another user",
+			urls: []models.URLMapping{
+				{ShortURL: "new1", OriginalURL: "https://example.com/new1"},
+				{ShortURL: "taken", OriginalURL: "https://example.com/new2"},
+				{ShortURL: "new3", OriginalURL: "https://example.com/new3"},
+			},
+		},
+		{
+			name: "short key repeated in batch",
+			urls: []models.URLMapping{
+				{ShortURL: "new1", OriginalURL: "https://example.com/new1"},
+				{ShortURL: "new1", OriginalURL: "https://example.com/new2"},
+			},
+		},
+		{
+			name: "conflict after existing URL",
+			urls: []models.URLMapping{
+				{ShortURL: "other", OriginalURL: "https://example.com/own"},
+				{ShortURL: "new1", OriginalURL: "https://example.com/new1"},
+				{ShortURL: "own", OriginalURL: "https://example.com/new2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openStorage(t, dir)
+			saveURL(t, s, "user2", "taken", "https://example.com/taken")
+			saveURL(t, s, "user1", "own", "https://example.com/own")
+			before := snapshot(t, s)
+
+			err := s.SaveNewURLs(userContext("user1"), tt.urls)
+			assert.ErrorIs(t, err, storage.ErrShortURLExists)
+
+			// Ни записи, ни индексы, ни счётчики пакета не сохранены
+			assert.Equal(t, before, snapshot(t, s))
+			assert.Equal(t, []string{"own"}, userCodes(t, s, "user1"))
+			_, err = s.GetRedirectURL(context.Background(), "new1")
+			assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+			// Отменённая транзакция не попадает в журнал
+			crash(t, s.db)
+			d, err := openDB(dir, defaultMemtableSize, defaultMaxTables, s.log)
+			require.NoError(t, err)
+			s.db = d
+			assert.Equal(t, before, snapshot(t, s))
+		})
+	}
+
+	t.Run("batch without conflicts is saved", func(t *testing.T) {
+		s := openStorage(t, t.TempDir())
+		saveURL(t, s, "user1", "own", "https://example.com/own")
+
+		err := s.SaveNewURLs(userContext("user1"), []models.URLMapping{
+			{ShortURL: "new1", OriginalURL: "https://example.com/new1"},
+			// Уже сокращённый URL пропускается, его код не проверяется
+			{ShortURL: "own", OriginalURL: "https://example.com/own"},
+			{ShortURL: "new2", OriginalURL: "https://example.com/new1"},
+			{ShortURL: "new3", OriginalURL: "https://example.com/new3"},
+		})
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{"own", "new1", "new3"}, userCodes(t, s, "user1"))
+		kv := snapshot(t, s)
+		assert.NotContains(t, kv, urlKey("new2"))
+		assert.Equal(t, "3", kv[userCountKey("user1")])
+		assert.Equal(t, "3", kv[urlsCountKey])
+		assert.Equal(t, "1", kv[usersCountKey])
+	})
+}
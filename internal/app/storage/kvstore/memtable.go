@@ -0,0 +1,142 @@
+package kvstore
+
+import (
+	"slices"
+	"sort"
+)
+
+// entry - версия ключа: значение или признак удаления.
+type entry struct {
+	key     string
+	value   []byte
+	deleted bool
+}
+
+// memtable - упорядоченная по ключу таблица последних изменений.
+// Изменения попадают сюда после записи в журнал и сбрасываются на диск
+// в виде таблицы, когда объём превышает порог.
+type memtable struct {
+	entries []entry
+	size    int // Примерный объём ключей и значений в байтах
+}
+
+// search возвращает позицию первого ключа, не меньшего key.
+func (m *memtable) search(key string) int {
+	return sort.Search(len(m.entries), func(i int) bool {
+		return m.entries[i].key >= key
+	})
+}
+
+// get возвращает последнюю версию ключа.
+func (m *memtable) get(key string) (entry, bool) {
+	i := m.search(key)
+	if i < len(m.entries) && m.entries[i].key == key {
+		return m.entries[i], true
+	}
+	return entry{}, false
+}
+
+// apply применяет изменение.
+func (m *memtable) apply(mu mutation) {
+	e := entry{key: mu.key, value: mu.value, deleted: mu.op == opDelete}
+
+	i := m.search(mu.key)
+	if i < len(m.entries) && m.entries[i].key == mu.key {
+		m.size += len(e.value) - len(m.entries[i].value)
+		m.entries[i] = e
+		return
+	}
+
+	m.entries = slices.Insert(m.entries, i, e)
+	m.size += len(e.key) + len(e.value)
+}
+
+// iterator - последовательный обход версий ключей в порядке возрастания.
+type iterator interface {
+	// next переходит к следующей версии; false - обход завершён или произошла ошибка.
+	next() bool
+	// entry возвращает текущую версию.
+	entry() entry
+	// err возвращает ошибку обхода.
+	err() error
+}
+
+// memIterator обходит таблицу в памяти.
+type memIterator struct {
+	entries []entry
+	pos     int
+}
+
+// iter возвращает итератор по ключам, начиная с первого не меньшего start.
+func (m *memtable) iter(start string) *memIterator {
+	return &memIterator{entries: m.entries, pos: m.search(start) - 1}
+}
+
+func (it *memIterator) next() bool {
+	it.pos++
+	return it.pos < len(it.entries)
+}
+
+func (it *memIterator) entry() entry { return it.entries[it.pos] }
+
+func (it *memIterator) err() error { return nil }
+
+// mergeIterator объединяет итераторы, упорядоченные от новых данных к старым.
+// Для каждого ключа возвращается только самая новая версия.
+type mergeIterator struct {
+	iters []iterator
+	valid []bool
+	cur   entry
+	error error
+}
+
+// newMergeIterator создает объединяющий итератор; iters упорядочены от новых к старым.
+func newMergeIterator(iters []iterator) *mergeIterator {
+	m := &mergeIterator{iters: iters, valid: make([]bool, len(iters))}
+	for i, it := range iters {
+		m.valid[i] = m.advance(i, it)
+	}
+	return m
+}
+
+// advance продвигает вложенный итератор и запоминает его ошибку.
+func (m *mergeIterator) advance(i int, it iterator) bool {
+	if it.next() {
+		return true
+	}
+	if err := it.err(); err != nil && m.error == nil {
+		m.error = err
+	}
+	return false
+}
+
+func (m *mergeIterator) next() bool {
+	if m.error != nil {
+		return false
+	}
+
+	newest := -1
+	for i, it := range m.iters {
+		if !m.valid[i] {
+			continue
+		}
+		if newest < 0 || it.entry().key < m.iters[newest].entry().key {
+			newest = i
+		}
+	}
+	if newest < 0 {
+		return false
+	}
+
+	m.cur = m.iters[newest].entry()
+	for i, it := range m.iters {
+		if m.valid[i] && it.entry().key == m.cur.key {
+			m.valid[i] = m.advance(i, it)
+		}
+	}
+	return m.error == nil
+}
+
+func (m *mergeIterator) entry() entry { return m.cur }
+
+func (m *mergeIterator) err() error { return m.error }
@@ -0,0 +1,335 @@
+package kvstore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+)
+
+// Параметры формата таблиц.
+const (
+	tableMagic      = 0x4b565354 // "KVST"
+	tableFooterSize = 20         // Смещение индекса (8), длина индекса (4), CRC индекса (4), сигнатура (4)
+	blockSize       = 4 << 10    // Целевой размер блока данных
+)
+
+// Формат таблицы (файл <номер>.sst):
+//
+//	[блок]... [индекс] [подвал]
+//
+// Блок - последовательность операций в порядке возрастания ключей и CRC-32C блока.
+// Индекс - первый ключ, смещение и длина каждого блока. В памяти держится только
+// индекс, поэтому поиск ключа читает с диска один блок.
+
+// blockHandle - положение блока в файле таблицы.
+type blockHandle struct {
+	firstKey string
+	offset   uint64
+	length   uint64
+}
+
+// table - неизменяемая упорядоченная таблица на диске.
+type table struct {
+	num   uint64
+	path  string
+	f     *os.File
+	index []blockHandle
+}
+
+// tablePath возвращает путь к файлу таблицы.
+func tablePath(dir string, num uint64) string {
+	return fmt.Sprintf("%s/%06d.sst", dir, num)
+}
+
+// openTable открывает таблицу и загружает её индекс.
+func openTable(dir string, num uint64) (*table, error) {
+	path := tablePath(dir, num)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{num: num, path: path, f: f}
+	if err := t.loadIndex(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("table %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// loadIndex читает подвал и индекс блоков.
+func (t *table) loadIndex() error {
+	info, err := t.f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < tableFooterSize {
+		return errCorrupted
+	}
+
+	footer := make([]byte, tableFooterSize)
+	if _, err := t.f.ReadAt(footer, info.Size()-tableFooterSize); err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint32(footer[16:]) != tableMagic {
+		return errCorrupted
+	}
+	offset := binary.LittleEndian.Uint64(footer[0:])
+	length := uint64(binary.LittleEndian.Uint32(footer[8:]))
+	if offset+length+tableFooterSize != uint64(info.Size()) {
+		return errCorrupted
+	}
+
+	data := make([]byte, length)
+	if _, err := t.f.ReadAt(data, int64(offset)); err != nil {
+		return err
+	}
+	if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(footer[12:]) {
+		return errCorrupted
+	}
+
+	for len(data) > 0 {
+		var h blockHandle
+		klen, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < klen {
+			return errCorrupted
+		}
+		h.firstKey, data = string(data[n:n+int(klen)]), data[n+int(klen):]
+		if h.offset, n = binary.Uvarint(data); n <= 0 {
+			return errCorrupted
+		}
+		data = data[n:]
+		if h.length, n = binary.Uvarint(data); n <= 0 {
+			return errCorrupted
+		}
+		data = data[n:]
+		t.index = append(t.index, h)
+	}
+	return nil
+}
+
+// readBlock читает и проверяет блок с номером i.
+func (t *table) readBlock(i int) ([]entry, error) {
+	h := t.index[i]
+	data := make([]byte, h.length)
+	if _, err := t.f.ReadAt(data, int64(h.offset)); err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, errCorrupted
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(body, crcTable) != sum {
+		return nil, fmt.Errorf("table %s block %d: %w", t.path, i, errCorrupted)
+	}
+
+	var entries []entry
+	for len(body) > 0 {
+		op, key, value, rest, err := readEntry(body)
+		if err != nil {
+			return nil, fmt.Errorf("table %s block %d: %w", t.path, i, err)
+		}
+		entries = append(entries, entry{key: key, value: value, deleted: op == opDelete})
+		body = rest
+	}
+	return entries, nil
+}
+
+// blockFor возвращает номер блока, который может содержать key (-1, если key меньше всех ключей).
+func (t *table) blockFor(key string) int {
+	return sort.Search(len(t.index), func(i int) bool {
+		return t.index[i].firstKey > key
+	}) - 1
+}
+
+// get возвращает версию ключа из таблицы.
+func (t *table) get(key string) (entry, bool, error) {
+	i := t.blockFor(key)
+	if i < 0 {
+		return entry{}, false, nil
+	}
+
+	entries, err := t.readBlock(i)
+	if err != nil {
+		return entry{}, false, err
+	}
+	j := sort.Search(len(entries), func(j int) bool {
+		return entries[j].key >= key
+	})
+	if j < len(entries) && entries[j].key == key {
+		return entries[j], true, nil
+	}
+	return entry{}, false, nil
+}
+
+// close закрывает файл таблицы.
+func (t *table) close() error {
+	return t.f.Close()
+}
+
+// tableIterator последовательно обходит блоки таблицы.
+type tableIterator struct {
+	t       *table
+	block   int
+	entries []entry
+	pos     int
+	start   string
+	error   error
+}
+
+// iter возвращает итератор по ключам, начиная с первого не меньшего start.
+func (t *table) iter(start string) *tableIterator {
+	block := t.blockFor(start)
+	if block < 0 {
+		block = 0
+	}
+	return &tableIterator{t: t, block: block - 1, start: start}
+}
+
+func (it *tableIterator) next() bool {
+	for {
+		it.pos++
+		if it.pos < len(it.entries) {
+			if it.entries[it.pos].key < it.start {
+				continue
+			}
+			return true
+		}
+
+		it.block++
+		if it.block >= len(it.t.index) {
+			return false
+		}
+		if it.entries, it.error = it.t.readBlock(it.block); it.error != nil {
+			return false
+		}
+		it.pos = -1
+	}
+}
+
+func (it *tableIterator) entry() entry { return it.entries[it.pos] }
+
+func (it *tableIterator) err() error { return it.error }
+
+// tableWriter записывает новую таблицу. Ключи добавляются в порядке возрастания.
+type tableWriter struct {
+	f      *os.File
+	w      *bufio.Writer
+	offset uint64
+	block  []byte
+	first  string
+	index  []blockHandle
+}
+
+// createTable создает файл новой таблицы.
+func createTable(dir string, num uint64) (*tableWriter, error) {
+	f, err := os.OpenFile(tablePath(dir, num), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &tableWriter{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// add добавляет версию ключа.
+func (tw *tableWriter) add(e entry) error {
+	if len(tw.block) == 0 {
+		tw.first = e.key
+	}
+	op := opPut
+	if e.deleted {
+		op = opDelete
+	}
+	tw.block = appendEntry(tw.block, op, e.key, e.value)
+
+	if len(tw.block) >= blockSize {
+		return tw.flushBlock()
+	}
+	return nil
+}
+
+// flushBlock дописывает накопленный блок и его контрольную сумму.
+func (tw *tableWriter) flushBlock() error {
+	if len(tw.block) == 0 {
+		return nil
+	}
+	tw.block = binary.LittleEndian.AppendUint32(tw.block, crc32.Checksum(tw.block, crcTable))
+	if _, err := tw.w.Write(tw.block); err != nil {
+		return err
+	}
+
+	tw.index = append(tw.index, blockHandle{
+		firstKey: tw.first,
+		offset:   tw.offset,
+		length:   uint64(len(tw.block)),
+	})
+	tw.offset += uint64(len(tw.block))
+	tw.block = tw.block[:0]
+	return nil
+}
+
+// finish записывает индекс и подвал, сбрасывает файл на диск и закрывает его.
+func (tw *tableWriter) finish() error {
+	if err := tw.flushBlock(); err != nil {
+		tw.f.Close()
+		return err
+	}
+
+	var index []byte
+	for _, h := range tw.index {
+		index = binary.AppendUvarint(index, uint64(len(h.firstKey)))
+		index = append(index, h.firstKey...)
+		index = binary.AppendUvarint(index, h.offset)
+		index = binary.AppendUvarint(index, h.length)
+	}
+
+	footer := make([]byte, tableFooterSize)
+	binary.LittleEndian.PutUint64(footer[0:], tw.offset)
+	binary.LittleEndian.PutUint32(footer[8:], uint32(len(index)))
+	binary.LittleEndian.PutUint32(footer[12:], crc32.Checksum(index, crcTable))
+	binary.LittleEndian.PutUint32(footer[16:], tableMagic)
+
+	for _, data := range [][]byte{index, footer} {
+		if _, err := tw.w.Write(data); err != nil {
+			tw.f.Close()
+			return err
+		}
+	}
+	if err := tw.w.Flush(); err != nil {
+		tw.f.Close()
+		return err
+	}
+	if err := tw.f.Sync(); err != nil {
+		tw.f.Close()
+		return err
+	}
+	return tw.f.Close()
+}
+
+// writeTable записывает версии ключей из итератора в новую таблицу.
+// Если dropDeleted, признаки удаления не сохраняются.
+func writeTable(dir string, num uint64, it iterator, dropDeleted bool) error {
+	tw, err := createTable(dir, num)
+	if err != nil {
+		return err
+	}
+
+	for it.next() {
+		e := it.entry()
+		if dropDeleted && e.deleted {
+			continue
+		}
+		if err := tw.add(e); err != nil {
+			tw.f.Close()
+			return err
+		}
+	}
+	if err := it.err(); err != nil {
+		tw.f.Close()
+		return err
+	}
+
+	return tw.finish()
+}
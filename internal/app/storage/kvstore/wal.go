@@ -0,0 +1,188 @@
+package kvstore
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// Виды операций в журнале и таблицах.
+const (
+	opPut    byte = 1
+	opDelete byte = 2
+)
+
+// walHeaderSize - размер заголовка записи журнала: длина и контрольная сумма пакета.
+const walHeaderSize = 8
+
+// crcTable - таблица CRC-32C для контрольных сумм журнала и таблиц.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorrupted - повреждённые данные в журнале или таблице.
+var errCorrupted = errors.New("kvstore: corrupted data")
+
+// mutation - изменение одного ключа.
+type mutation struct {
+	op    byte
+	key   string
+	value []byte
+}
+
+// wal - журнал упреждающей записи.
+//
+// Каждый пакет изменений транзакции записывается одной записью
+// [длина][CRC-32C][изменения], поэтому после сбоя пакет либо
+// восстанавливается целиком, либо отбрасывается.
+type wal struct {
+	f *os.File
+}
+
+// openWAL открывает журнал и возвращает сохранённые в нём пакеты.
+// Недописанный или повреждённый хвост журнала отбрасывается.
+func openWAL(path string, log *zap.Logger) (*wal, [][]mutation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	var (
+		batches [][]mutation
+		offset  int
+	)
+	for offset < len(data) {
+		batch, n, err := decodeWALRecord(data[offset:])
+		if err != nil {
+			log.Warn("Discarding damaged kvstore WAL tail",
+				zap.Int("offset", offset),
+				zap.Int("size", len(data)-offset),
+				zap.Error(err))
+			break
+		}
+		batches = append(batches, batch)
+		offset += n
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset < len(data) {
+		if err := f.Truncate(int64(offset)); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+	}
+	if _, err := f.Seek(int64(offset), 0); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return &wal{f: f}, batches, nil
+}
+
+// append записывает пакет изменений и сбрасывает его на диск.
+func (w *wal) append(batch []mutation) error {
+	payload := binary.AppendUvarint(nil, uint64(len(batch)))
+	for _, m := range batch {
+		payload = appendEntry(payload, m.op, m.key, m.value)
+	}
+
+	record := make([]byte, walHeaderSize, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record[0:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:], crc32.Checksum(payload, crcTable))
+	record = append(record, payload...)
+
+	if _, err := w.f.Write(record); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+// reset очищает журнал после сброса таблицы в памяти на диск.
+func (w *wal) reset() error {
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(0, 0); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+// close закрывает файл журнала.
+func (w *wal) close() error {
+	return w.f.Close()
+}
+
+// decodeWALRecord разбирает запись журнала и возвращает пакет и длину записи.
+func decodeWALRecord(data []byte) ([]mutation, int, error) {
+	if len(data) < walHeaderSize {
+		return nil, 0, errCorrupted
+	}
+	size := int(binary.LittleEndian.Uint32(data[0:]))
+	if len(data)-walHeaderSize < size {
+		return nil, 0, errCorrupted
+	}
+	payload := data[walHeaderSize : walHeaderSize+size]
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(data[4:]) {
+		return nil, 0, errCorrupted
+	}
+
+	count, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return nil, 0, errCorrupted
+	}
+	payload = payload[n:]
+
+	batch := make([]mutation, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var (
+			m   mutation
+			err error
+		)
+		m.op, m.key, m.value, payload, err = readEntry(payload)
+		if err != nil {
+			return nil, 0, err
+		}
+		batch = append(batch, m)
+	}
+
+	return batch, walHeaderSize + size, nil
+}
+
+// appendEntry кодирует операцию: вид, длина и ключ, длина и значение.
+func appendEntry(buf []byte, op byte, key string, value []byte) []byte {
+	buf = append(buf, op)
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = append(buf, key...)
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+// readEntry разбирает операцию, закодированную appendEntry, и возвращает остаток буфера.
+func readEntry(buf []byte) (op byte, key string, value []byte, rest []byte, err error) {
+	if len(buf) == 0 {
+		return 0, "", nil, nil, errCorrupted
+	}
+	op, buf = buf[0], buf[1:]
+	if op != opPut && op != opDelete {
+		return 0, "", nil, nil, errCorrupted
+	}
+
+	klen, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf)-n) < klen {
+		return 0, "", nil, nil, errCorrupted
+	}
+	key, buf = string(buf[n:n+int(klen)]), buf[n+int(klen):]
+
+	vlen, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf)-n) < vlen {
+		return 0, "", nil, nil, errCorrupted
+	}
+	value = append([]byte(nil), buf[n:n+int(vlen)]...)
+
+	return op, key, value, buf[n+int(vlen):], nil
+}
@@ -0,0 +1,221 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// Измерения разбивки переходов в таблице url_click_breakdown.
+const (
+	dimensionReferrer  = "referrer"
+	dimensionUserAgent = "user_agent"
+	dimensionIPPrefix  = "ip_prefix"
+)
+
+// clickTotals содержит агрегаты пакета событий по одной ссылке.
+type clickTotals struct {
+	clicks     int64
+	firstClick time.Time
+	lastClick  time.Time
+}
+
+// breakdownKey идентифицирует строку разбивки переходов.
+type breakdownKey struct {
+	shortCode string
+	dimension string
+	value     string
+}
+
+// RecordClicks сохраняет пакет событий перехода.
+//
+// События предварительно агрегируются, после чего счётчики обновляются
+// одной транзакцией. Строки обновляются в детерминированном порядке,
+// чтобы параллельные пакеты не приводили к взаимоблокировкам.
+// События для несуществующих ссылок пропускаются.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//	events - события перехода
+//
+// Возвращает:
+//
+//	error - ошибка операции
+func (s *PostgresStorage) RecordClicks(ctx context.Context, events []models.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	totals := make(map[string]*clickTotals)
+	breakdown := make(map[breakdownKey]int64)
+
+	for _, event := range events {
+		t, exists := totals[event.ShortURL]
+		if !exists {
+			t = &clickTotals{firstClick: event.At, lastClick: event.At}
+			totals[event.ShortURL] = t
+		}
+		t.clicks++
+		if event.At.Before(t.firstClick) {
+			t.firstClick = event.At
+		}
+		if event.At.After(t.lastClick) {
+			t.lastClick = event.At
+		}
+
+		for dimension, value := range map[string]string{
+			dimensionReferrer:  event.Referrer,
+			dimensionUserAgent: event.UserAgent,
+			dimensionIPPrefix:  event.IPPrefix,
+		} {
+			if value != "" {
+				breakdown[breakdownKey{event.ShortURL, dimension, value}]++
+			}
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	codes := make([]string, 0, len(totals))
+	for code := range totals {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		t := totals[code]
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO url_click_stats (short_code, clicks, first_click_at, last_click_at)
+		SELECT short_code, $2::bigint, $3::timestamptz, $4::timestamptz FROM short_urls WHERE short_code = $1
+		ON CONFLICT (short_code) DO UPDATE SET
+			clicks = url_click_stats.clicks + EXCLUDED.clicks,
+			first_click_at = LEAST(url_click_stats.first_click_at, EXCLUDED.first_click_at),
+			last_click_at = GREATEST(url_click_stats.last_click_at, EXCLUDED.last_click_at)`,
+			code, t.clicks, t.firstClick, t.lastClick)
+		if err != nil {
+			return fmt.Errorf("error updating click stats: %w", err)
+		}
+	}
+
+	keys := make([]breakdownKey, 0, len(breakdown))
+	for key := range breakdown {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].shortCode != keys[j].shortCode {
+			return keys[i].shortCode < keys[j].shortCode
+		}
+		if keys[i].dimension != keys[j].dimension {
+			return keys[i].dimension < keys[j].dimension
+		}
+		return keys[i].value < keys[j].value
+	})
+
+	for _, key := range keys {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO url_click_breakdown (short_code, dimension, value, clicks)
+		SELECT short_code, $2::varchar, $3::varchar, $4::bigint FROM short_urls WHERE short_code = $1
+		ON CONFLICT (short_code, dimension, value) DO UPDATE SET
+			clicks = url_click_breakdown.clicks + EXCLUDED.clicks`,
+			key.shortCode, key.dimension, key.value, breakdown[key])
+		if err != nil {
+			return fmt.Errorf("error updating click breakdown: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+// GetURLStats возвращает статистику переходов по ссылке текущего пользователя.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//	shortKey - сокращенный ключ URL
+//	top - количество значений в разбивках по источникам и User-Agent
+//
+// Возвращает:
+//
+//	models.URLStats - статистика переходов (ShortURL содержит короткий идентификатор)
+//	error - ошибка операции:
+//	  - storage.ErrURLNotFound если ссылка не найдена или принадлежит другому пользователю
+func (s *PostgresStorage) GetURLStats(ctx context.Context, shortKey string, top int) (models.URLStats, error) {
+	stats := models.URLStats{
+		ShortURL: shortKey,
+	}
+
+	var firstClick, lastClick sql.NullTime
+
+	userID := ctx.Value(jwtauth.UserIDContextKey)
+	err := s.db.QueryRowContext(ctx, `
+	SELECT u.original_url, COALESCE(c.clicks, 0), c.first_click_at, c.last_click_at,
+		(SELECT COUNT(*) FROM url_click_breakdown b WHERE b.short_code = u.short_code AND b.dimension = $3)
+	FROM short_urls u
+	LEFT JOIN url_click_stats c ON c.short_code = u.short_code
+	WHERE u.short_code = $1 AND u.user_id = $2`,
+		shortKey, userID, dimensionIPPrefix).
+		Scan(&stats.OriginalURL, &stats.Clicks, &firstClick, &lastClick, &stats.UniqueVisitors)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return stats, storage.ErrURLNotFound
+		}
+		return stats, err
+	}
+
+	if firstClick.Valid {
+		stats.FirstClickAt = &firstClick.Time
+	}
+	if lastClick.Valid {
+		stats.LastClickAt = &lastClick.Time
+	}
+
+	if stats.TopReferrers, err = s.topClickCounts(ctx, shortKey, dimensionReferrer, top); err != nil {
+		return stats, err
+	}
+	if stats.TopUserAgents, err = s.topClickCounts(ctx, shortKey, dimensionUserAgent, top); err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// topClickCounts возвращает не более top значений измерения с наибольшим количеством переходов.
+func (s *PostgresStorage) topClickCounts(ctx context.Context, shortKey, dimension string, top int) ([]models.ClickCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT value, clicks FROM url_click_breakdown
+	WHERE short_code = $1 AND dimension = $2
+	ORDER BY clicks DESC, value
+	LIMIT $3`, shortKey, dimension, top)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.ClickCount
+	for rows.Next() {
+		var count models.ClickCount
+		if err := rows.Scan(&count.Value, &count.Clicks); err != nil {
+			return nil, err
+		}
+		result = append(result, count)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// DefaultDeleteQueueLease - срок захвата задач очереди по умолчанию.
+const DefaultDeleteQueueLease = time.Minute
+
+// DeleteQueue реализует постоянную очередь задач удаления (и восстановления)
+// в таблице delete_queue.
+//
+// Очередь общая для всех экземпляров сервиса, поэтому задачи захватываются:
+// экземпляр, поставивший или получивший задачу, записывается её владельцем
+// (claimed_by) на срок захвата (claimed_until) и продлевает его, пока работает.
+// Pending возвращает только свободные задачи, задачи с истёкшим сроком захвата
+// (их владелец завершился аварийно) и собственные задачи экземпляра. Так
+// экземпляры не обрабатывают задачи друг друга и не повторяют удаление,
+// которое другой экземпляр уже отменил последующим восстановлением.
+type DeleteQueue struct {
+	db    *sql.DB
+	owner string        // Идентификатор экземпляра - владельца захваченных задач
+	lease time.Duration // Срок захвата задач
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// DeleteQueueOption задаёт дополнительные параметры DeleteQueue.
+type DeleteQueueOption func(*DeleteQueue)
+
+// WithLease задаёт срок захвата задач. Значения меньше или равные 0 игнорируются.
+func WithLease(lease time.Duration) DeleteQueueOption {
+	return func(q *DeleteQueue) {
+		if lease > 0 {
+			q.lease = lease
+		}
+	}
+}
+
+// DeleteQueue возвращает постоянную очередь задач удаления на соединениях хранилища.
+// Очередь продлевает захват своих задач в фоне до вызова Close.
+func (s *PostgresStorage) DeleteQueue(opts ...DeleteQueueOption) *DeleteQueue {
+	q := &DeleteQueue{
+		db:    s.db,
+		owner: uuid.NewString(),
+		lease: DefaultDeleteQueueLease,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	go q.renewLoop()
+	return q
+}
+
+// Enqueue сохраняет задачу, захваченную этим экземпляром, и возвращает её идентификатор.
+func (q *DeleteQueue) Enqueue(ctx context.Context, task models.DeleteTask) (int64, error) {
+	var id int64
+	err := q.db.QueryRowContext(ctx, `
+	INSERT INTO delete_queue (user_id, short_codes, restore, claimed_by, claimed_until)
+	VALUES ($1, $2, $3, $4, now() + $5 * interval '1 millisecond')
+	RETURNING id`,
+		task.UserID, task.ShortURLs, task.Restore, q.owner, q.lease.Milliseconds(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error enqueuing delete task: %w", err)
+	}
+	return id, nil
+}
+
+// Ack удаляет обработанные задачи из очереди.
+func (q *DeleteQueue) Ack(ctx context.Context, ids []int64) error {
+	if _, err := q.db.ExecContext(ctx, "DELETE FROM delete_queue WHERE id = ANY($1)", ids); err != nil {
+		return fmt.Errorf("error acknowledging delete tasks: %w", err)
+	}
+	return nil
+}
+
+// claimPendingQuery захватывает доступные задачи и возвращает их в порядке постановки.
+// FOR UPDATE SKIP LOCKED не даёт двум экземплярам, одновременно читающим очередь,
+// захватить одну задачу: строки, захватываемые другим запросом, пропускаются.
+const claimPendingQuery = `
+WITH claimed AS (
+	UPDATE delete_queue SET claimed_by = $1, claimed_until = now() + $2 * interval '1 millisecond'
+	WHERE id IN (
+		SELECT id FROM delete_queue
+		WHERE claimed_by = $1 OR claimed_until IS NULL OR claimed_until < now()
+		ORDER BY id
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, user_id, short_codes, restore
+)
+SELECT id, user_id, short_codes, restore FROM claimed ORDER BY id`
+
+// Pending захватывает неподтверждённые задачи, не занятые другими экземплярами,
+// и возвращает их в порядке постановки.
+func (q *DeleteQueue) Pending(ctx context.Context) ([]models.DeleteTask, error) {
+	rows, err := q.db.QueryContext(ctx, claimPendingQuery, q.owner, q.lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	typeMap := pgtype.NewMap()
+	var tasks []models.DeleteTask
+	for rows.Next() {
+		var task models.DeleteTask
+		if err := rows.Scan(&task.ID, &task.UserID, typeMap.SQLScanner(&task.ShortURLs), &task.Restore); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// Close прекращает продление захвата и освобождает задачи экземпляра,
+// чтобы их сразу мог получить следующий запущенный экземпляр.
+func (q *DeleteQueue) Close() error {
+	var err error
+	q.closeOnce.Do(func() {
+		close(q.stop)
+		<-q.done
+
+		ctx, cancel := context.WithTimeout(context.Background(), q.lease)
+		defer cancel()
+		if _, err = q.db.ExecContext(ctx,
+			"UPDATE delete_queue SET claimed_by = NULL, claimed_until = NULL WHERE claimed_by = $1", q.owner); err != nil {
+			err = fmt.Errorf("error releasing delete tasks: %w", err)
+		}
+	})
+	return err
+}
+
+// renewLoop продлевает захват задач экземпляра, пока очередь не закрыта.
+// Продление выполняется трижды за срок захвата, поэтому единичная ошибка
+// обращения к БД не приводит к потере захвата.
+func (q *DeleteQueue) renewLoop() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), q.lease/3)
+			// Ошибка не критична: продление повторится на следующем тике
+			_, _ = q.db.ExecContext(ctx,
+				"UPDATE delete_queue SET claimed_until = now() + $2 * interval '1 millisecond' WHERE claimed_by = $1",
+				q.owner, q.lease.Milliseconds())
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,6 @@
+-- +goose Down
+BEGIN;
+
+ALTER TABLE short_urls DROP COLUMN IF EXISTS expires_at;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- +goose Up
+BEGIN;
+
+-- Добавляем колонку срока действия ссылки (NULL - бессрочно)
+ALTER TABLE short_urls ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+COMMIT;
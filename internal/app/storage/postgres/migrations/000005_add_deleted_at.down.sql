@@ -0,0 +1,8 @@
+-- +goose Down
+BEGIN;
+
+DROP INDEX IF EXISTS idx_short_urls_expires_at;
+DROP INDEX IF EXISTS idx_short_urls_deleted_at;
+ALTER TABLE short_urls DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
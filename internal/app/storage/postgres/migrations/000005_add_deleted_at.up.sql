@@ -0,0 +1,14 @@
+-- +goose Up
+BEGIN;
+
+-- Момент мягкого удаления нужен для расчёта срока хранения перед физическим удалением
+ALTER TABLE short_urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Ранее удалённые записи отсчитывают срок хранения от момента миграции
+UPDATE short_urls SET deleted_at = now() WHERE is_deleted AND deleted_at IS NULL;
+
+-- Индексы для выборки устаревших записей фоновой очисткой
+CREATE INDEX IF NOT EXISTS idx_short_urls_deleted_at ON short_urls(deleted_at) WHERE is_deleted;
+CREATE INDEX IF NOT EXISTS idx_short_urls_expires_at ON short_urls(expires_at) WHERE expires_at IS NOT NULL;
+
+COMMIT;
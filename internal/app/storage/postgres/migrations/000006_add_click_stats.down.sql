@@ -0,0 +1,7 @@
+-- +goose Down
+BEGIN;
+
+DROP TABLE IF EXISTS url_click_breakdown;
+DROP TABLE IF EXISTS url_click_stats;
+
+COMMIT;
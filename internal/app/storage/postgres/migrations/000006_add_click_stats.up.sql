@@ -0,0 +1,21 @@
+-- +goose Up
+BEGIN;
+
+-- Агрегаты переходов по коротким ссылкам
+CREATE TABLE IF NOT EXISTS url_click_stats (
+    short_code VARCHAR(20) PRIMARY KEY REFERENCES short_urls(short_code) ON DELETE CASCADE,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    first_click_at TIMESTAMPTZ NOT NULL,
+    last_click_at TIMESTAMPTZ NOT NULL
+);
+
+-- Разбивка переходов по измерениям: источник, User-Agent, префикс сети клиента
+CREATE TABLE IF NOT EXISTS url_click_breakdown (
+    short_code VARCHAR(20) NOT NULL REFERENCES short_urls(short_code) ON DELETE CASCADE,
+    dimension VARCHAR(16) NOT NULL,
+    value VARCHAR(256) NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, dimension, value)
+);
+
+COMMIT;
@@ -0,0 +1,6 @@
+-- +goose Down
+BEGIN;
+
+DROP SEQUENCE IF EXISTS short_key_seq;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- +goose Up
+BEGIN;
+
+-- Последовательность для генерации коротких ключей (стратегия "sequence")
+CREATE SEQUENCE IF NOT EXISTS short_key_seq START WITH 1000000;
+
+COMMIT;
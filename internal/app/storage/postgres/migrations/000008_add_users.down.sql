@@ -0,0 +1,10 @@
+-- +goose Down
+BEGIN;
+
+DROP INDEX IF EXISTS idx_short_urls_user_created_at;
+ALTER TABLE short_urls DROP CONSTRAINT IF EXISTS fk_short_urls_user_id;
+ALTER TABLE short_urls DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE short_urls DROP COLUMN IF EXISTS created_at;
+DROP TABLE IF EXISTS users;
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- +goose Up
+BEGIN;
+
+-- Пользователи сервиса учитываются отдельно, без подсчёта по всем ссылкам
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Моменты создания и последнего изменения ссылки.
+-- Настоящий момент создания существующих ссылок неизвестен, поэтому все они
+-- получают одинаковый created_at (время миграции). Выдача ссылок пользователя
+-- упорядочена по (created_at, id), так что среди них сохраняется прежний
+-- порядок по id, а ссылки, созданные после миграции, идут следом.
+-- Момент удаления (deleted_at) для оценки не используется: он переставил бы
+-- удалённые ссылки относительно остальных.
+ALTER TABLE short_urls ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE short_urls ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Переносим пользователей из существующих ссылок
+INSERT INTO users (id, created_at)
+SELECT user_id, MIN(created_at) FROM short_urls WHERE user_id IS NOT NULL GROUP BY user_id
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE short_urls ADD CONSTRAINT fk_short_urls_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- Индекс для выборки ссылок пользователя в порядке создания
+CREATE INDEX IF NOT EXISTS idx_short_urls_user_created_at ON short_urls(user_id, created_at);
+
+COMMIT;
@@ -0,0 +1,6 @@
+-- +goose Down
+BEGIN;
+
+DROP TABLE IF EXISTS delete_queue;
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- +goose Up
+BEGIN;
+
+-- Постоянная очередь задач асинхронного удаления (outbox): задача удаляется
+-- из таблицы только после пометки её ссылок удалёнными
+CREATE TABLE IF NOT EXISTS delete_queue (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    short_codes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMIT;
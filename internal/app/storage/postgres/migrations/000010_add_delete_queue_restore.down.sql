@@ -0,0 +1,6 @@
+-- +goose Down
+BEGIN;
+
+ALTER TABLE delete_queue DROP COLUMN IF EXISTS restore;
+
+COMMIT;
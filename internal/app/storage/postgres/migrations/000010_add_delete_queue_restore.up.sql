@@ -0,0 +1,7 @@
+-- +goose Up
+BEGIN;
+
+-- Очередь хранит и задачи восстановления мягко удалённых ссылок
+ALTER TABLE delete_queue ADD COLUMN IF NOT EXISTS restore BOOLEAN NOT NULL DEFAULT false;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- +goose Down
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_short_urls_user_created_at ON short_urls(user_id, created_at);
+DROP INDEX IF EXISTS idx_short_urls_user_created_at_id;
+
+COMMIT;
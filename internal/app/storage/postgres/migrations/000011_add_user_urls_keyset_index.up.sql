@@ -0,0 +1,8 @@
+-- +goose Up
+BEGIN;
+
+-- Постраничная выборка ссылок пользователя по ключу (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_short_urls_user_created_at_id ON short_urls(user_id, created_at, id);
+DROP INDEX IF EXISTS idx_short_urls_user_created_at;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- +goose Down
+BEGIN;
+
+ALTER TABLE delete_queue DROP COLUMN IF EXISTS claimed_until;
+ALTER TABLE delete_queue DROP COLUMN IF EXISTS claimed_by;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- +goose Up
+BEGIN;
+
+-- Захват задач очереди экземпляром сервиса: задачу обрабатывает только
+-- владелец, пока не истёк срок захвата
+ALTER TABLE delete_queue ADD COLUMN IF NOT EXISTS claimed_by UUID;
+ALTER TABLE delete_queue ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ;
+
+COMMIT;
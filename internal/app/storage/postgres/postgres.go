@@ -15,19 +15,37 @@ import (
 	"strings"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
 
 	"github.com/ryabkov82/shortener/internal/app/jwtauth"
 	"github.com/ryabkov82/shortener/internal/app/models"
 	"github.com/ryabkov82/shortener/internal/app/storage"
 )
 
+// Коды ошибок PostgreSQL.
+const (
+	uniqueViolationCode     = "23505" // Нарушение ограничения уникальности
+	foreignKeyViolationCode = "23503" // Нарушение внешнего ключа
+)
+
+// maxSaveAttempts - количество попыток сохранения ссылок, если пользователь
+// был удалён очисткой (PurgeURLs) между его регистрацией и вставкой ссылки.
+const maxSaveAttempts = 3
+
+// insertUserQuery регистрирует пользователя, если он ещё не известен.
+// Ссылки ссылаются на таблицу users внешним ключом, поэтому пользователь
+// должен быть создан до сохранения его первой ссылки.
+const insertUserQuery = `INSERT INTO users (id) VALUES ($1) ON CONFLICT (id) DO NOTHING`
+
 // PostgresStorage реализует интерфейс хранилища для работы с PostgreSQL.
 type PostgresStorage struct {
 	db              *sql.DB
 	getShortURLStmt *sql.Stmt
 	getURLStmt      *sql.Stmt
 	insertURLStmt   *sql.Stmt
+	insertUserStmt  *sql.Stmt
 }
 
 // NewPostgresStorage создает новое подключение к PostgreSQL и инициализирует хранилище.
@@ -59,23 +77,31 @@ func NewPostgresStorage(storagePath string) (*PostgresStorage, error) {
 		return nil, err
 	}
 
-	getURLStmt, err := db.Prepare(`SELECT original_url, is_deleted FROM short_urls WHERE short_code = $1`)
+	getURLStmt, err := db.Prepare(`
+	SELECT original_url, is_deleted, expires_at, expires_at IS NOT NULL AND expires_at <= now()
+	FROM short_urls WHERE short_code = $1`)
 	if err != nil {
 		return nil, err
 	}
 
 	insertURLStmt, err := db.Prepare(`
-	INSERT INTO short_urls (original_url, short_code, user_id)
-	VALUES ($1, $2, $3)
+	INSERT INTO short_urls (original_url, short_code, user_id, expires_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, now(), now())
 	ON CONFLICT (user_id, original_url) DO UPDATE SET
-		original_url = EXCLUDED.original_url
+		original_url = EXCLUDED.original_url,
+		updated_at = now()
 	RETURNING short_code, xmax;
 	`)
 	if err != nil {
 		return nil, err
 	}
 
-	return &PostgresStorage{db, getShortURLStmt, getURLStmt, insertURLStmt}, nil
+	insertUserStmt, err := db.Prepare(insertUserQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresStorage{db, getShortURLStmt, getURLStmt, insertURLStmt, insertUserStmt}, nil
 }
 
 // Ping проверяет соединение с базой данных.
@@ -131,14 +157,21 @@ func (s *PostgresStorage) GetShortKey(ctx context.Context, originalURL string) (
 // Возвращает:
 //
 //	models.URLMapping - соответствие URL
-//	error - ошибка операции
+//	error - ошибка операции:
+//	  - storage.ErrURLNotFound если URL не существует
+//	  - storage.ErrURLDeleted если URL помечен как удаленный
+//	  - storage.ErrURLExpired если срок действия URL истёк
 func (s *PostgresStorage) GetRedirectURL(ctx context.Context, shortKey string) (models.URLMapping, error) {
 	mapping := models.URLMapping{
 		ShortURL: shortKey,
 	}
 
-	var deletedFlag bool
-	err := s.getURLStmt.QueryRowContext(ctx, shortKey).Scan(&mapping.OriginalURL, &deletedFlag)
+	var (
+		deletedFlag bool
+		expired     bool
+		expiresAt   sql.NullTime
+	)
+	err := s.getURLStmt.QueryRowContext(ctx, shortKey).Scan(&mapping.OriginalURL, &deletedFlag, &expiresAt, &expired)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return mapping, fmt.Errorf("%w", storage.ErrURLNotFound)
@@ -149,11 +182,26 @@ func (s *PostgresStorage) GetRedirectURL(ctx context.Context, shortKey string) (
 	if deletedFlag {
 		return mapping, storage.ErrURLDeleted
 	}
+
+	// Истечение срока проверяется по часам БД, чтобы все реплики сервиса давали одинаковый ответ
+	if expired {
+		return mapping, storage.ErrURLExpired
+	}
+
+	if expiresAt.Valid {
+		mapping.ExpiresAt = &expiresAt.Time
+	}
 	return mapping, nil
 }
 
 // SaveURL сохраняет новое соответствие URL.
 //
+// Уникальность короткого ключа гарантируется индексом по short_code,
+// поэтому проверка и вставка выполняются атомарно одним запросом.
+// Пользователь регистрируется в той же транзакции до вставки ссылки
+// (этого требует внешний ключ), поэтому при ошибке вставки ссылки
+// он не остаётся зарегистрированным без ссылок.
+//
 // Параметры:
 //
 //	ctx - контекст выполнения
@@ -161,22 +209,57 @@ func (s *PostgresStorage) GetRedirectURL(ctx context.Context, shortKey string) (
 //
 // Возвращает:
 //
-//	error - ошибка операции
+//	error - ошибка операции:
+//	  - storage.ErrURLExists если URL уже сокращён пользователем
+//	  - storage.ErrShortURLExists если короткий ключ уже занят
 func (s *PostgresStorage) SaveURL(ctx context.Context, mapping *models.URLMapping) error {
+	userID := ctx.Value(jwtauth.UserIDContextKey)
+
+	var err error
+	for attempt := 1; attempt <= maxSaveAttempts; attempt++ {
+		if err = s.saveURL(ctx, userID, mapping); !isForeignKeyViolation(err) {
+			break
+		}
+	}
+	return err
+}
+
+// saveURL регистрирует пользователя и сохраняет ссылку в одной транзакции.
+func (s *PostgresStorage) saveURL(ctx context.Context, userID any, mapping *models.URLMapping) error {
 	var xmax int64 // Системный столбец для определения конфликтов
 
-	userID := ctx.Value(jwtauth.UserIDContextKey)
-	err := s.insertURLStmt.QueryRowContext(ctx, mapping.OriginalURL, mapping.ShortURL, userID).Scan(&mapping.ShortURL, &xmax)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	if userID != nil {
+		if _, err := tx.StmtContext(ctx, s.insertUserStmt).ExecContext(ctx, userID); err != nil {
+			return fmt.Errorf("error saving user: %w", err)
+		}
+	}
+
+	err = tx.StmtContext(ctx, s.insertURLStmt).
+		QueryRowContext(ctx, mapping.OriginalURL, mapping.ShortURL, userID, mapping.ExpiresAt).
+		Scan(&mapping.ShortURL, &xmax)
 	if err != nil {
+		// Конфликт по (user_id, original_url) обрабатывается через ON CONFLICT,
+		// поэтому нарушение уникальности здесь означает занятый short_code.
+		if isUniqueViolation(err) {
+			return storage.ErrShortURLExists
+		}
 		return err
 	}
 
-	if xmax > 0 {
-		err = storage.ErrURLExists
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
-	return err
+	if xmax > 0 {
+		return storage.ErrURLExists
+	}
+	return nil
 }
 
 // GetExistingURLs возвращает существующие сокращения для URL.
@@ -216,16 +299,22 @@ func (s *PostgresStorage) GetExistingURLs(ctx context.Context, originalURLs []st
 	return existing, rows.Err()
 }
 
-// SaveNewURLs сохраняет пакет новых URL.
+// SaveNewURLs сохраняет пакет новых URL в одной транзакции.
+//
+// Пакет загружается через COPY во временную таблицу и переносится в short_urls
+// одним запросом с ON CONFLICT DO NOTHING, поэтому конфликт отдельной строки
+// не отменяет сохранение остальных. Для строк, чей URL уже сокращён
+// пользователем, в urls записывается существующий короткий ключ.
 //
 // Параметры:
 //
-//	ctx - контекст выполнения
+//	ctx - контекст выполнения (отмена прерывает загрузку и откатывает транзакцию)
 //	urls - список URL для сохранения
 //
 // Возвращает:
 //
-//	error - ошибка операции
+//	error - ошибка операции:
+//	  - *storage.BatchConflictError если часть строк не сохранена из-за конфликтов
 func (s *PostgresStorage) SaveNewURLs(ctx context.Context, urls []models.URLMapping) error {
 	if len(urls) == 0 {
 		return nil
@@ -233,99 +322,427 @@ func (s *PostgresStorage) SaveNewURLs(ctx context.Context, urls []models.URLMapp
 
 	userID := ctx.Value(jwtauth.UserIDContextKey)
 
-	tx, err := s.db.Begin()
+	conn, err := s.db.Conn(ctx)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
+	defer conn.Close()
+
+	var conflicts map[int]error
+	err = conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		for attempt := 1; attempt <= maxSaveAttempts; attempt++ {
+			if conflicts, err = copyNewURLs(ctx, pgxConn, userID, urls); !isForeignKeyViolation(err) {
+				break
+			}
 		}
-	}()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error saving batch: %w", err)
+	}
+
+	if len(conflicts) > 0 {
+		return &storage.BatchConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
 
-	stmt, err := tx.Prepare("INSERT INTO short_urls (original_url, short_code, user_id) VALUES($1, $2, $3)")
+// mergeBatchQuery переносит строки из batch_urls в short_urls и возвращает
+// результат по каждой строке: сохранена ли она и существующий ключ для её URL.
+// Снимок запроса не видит строк, вставленных им самим, поэтому существующий
+// ключ находится только для URL, сокращённых до начала загрузки.
+const mergeBatchQuery = `
+WITH inserted AS (
+	INSERT INTO short_urls (original_url, short_code, user_id, expires_at, created_at, updated_at)
+	SELECT original_url, short_code, $1::uuid, expires_at, now(), now() FROM batch_urls ORDER BY idx
+	ON CONFLICT DO NOTHING
+	RETURNING original_url, short_code
+)
+SELECT b.idx, i.short_code IS NOT NULL, COALESCE(e.short_code, '')
+FROM batch_urls b
+LEFT JOIN inserted i ON i.short_code = b.short_code AND i.original_url = b.original_url
+LEFT JOIN short_urls e ON e.user_id = $1::uuid AND e.original_url = b.original_url`
+
+// copyNewURLs загружает пакет в транзакции на соединении pgx и возвращает
+// конфликты по индексам строк.
+func copyNewURLs(ctx context.Context, conn *pgx.Conn, userID any, urls []models.URLMapping) (map[int]error, error) {
+	tx, err := conn.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback(ctx)
 
-	for _, url := range urls {
-		_, err = stmt.ExecContext(ctx, url.OriginalURL, url.ShortURL, userID)
-		if err != nil {
-			return err
+	if userID != nil {
+		if _, err = tx.Exec(ctx, insertUserQuery, userID); err != nil {
+			return nil, err
 		}
 	}
 
-	return tx.Commit()
+	_, err = tx.Exec(ctx, `
+	CREATE TEMP TABLE batch_urls (
+		idx INT NOT NULL,
+		original_url TEXT NOT NULL,
+		short_code VARCHAR(20) NOT NULL,
+		expires_at TIMESTAMPTZ
+	) ON COMMIT DROP`)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"batch_urls"},
+		[]string{"idx", "original_url", "short_code", "expires_at"},
+		pgx.CopyFromSlice(len(urls), func(i int) ([]any, error) {
+			return []any{i, urls[i].OriginalURL, urls[i].ShortURL, urls[i].ExpiresAt}, nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, mergeBatchQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make(map[int]error)
+	var (
+		idx          int
+		inserted     bool
+		existingCode string
+	)
+	_, err = pgx.ForEachRow(rows, []any{&idx, &inserted, &existingCode}, func() error {
+		switch {
+		case inserted:
+		case existingCode != "":
+			urls[idx].ShortURL = existingCode
+			conflicts[idx] = storage.ErrURLExists
+		default:
+			// Занят короткий ключ, либо URL повторяется в пакете
+			// и уже сохранён в предыдущей строке
+			conflicts[idx] = storage.ErrShortURLExists
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return conflicts, tx.Commit(ctx)
 }
 
-// GetUserUrls возвращает все URL пользователя.
+// GetUserUrls возвращает страницу URL пользователя в порядке их создания.
+//
+// Страницы выбираются по ключу (created_at, id) без OFFSET: курсор хранит
+// ключ последней выданной записи, и следующая страница начинается после него
+// (индекс idx_short_urls_user_created_at_id). Для определения наличия
+// следующей страницы запрашивается на одну запись больше limit.
+// Ссылки, созданные до миграции 000008, имеют общий created_at и выдаются
+// в порядке id.
 //
 // Параметры:
 //
 //	ctx - контекст выполнения
 //	baseURL - базовый URL сервиса
+//	query - фильтры, порядок и страница
 //
 // Возвращает:
 //
-//	[]models.URLMapping - список URL пользователя
-//	error - ошибка операции
-func (s *PostgresStorage) GetUserUrls(ctx context.Context, baseURL string) ([]models.URLMapping, error) {
+//	models.UserURLsPage - страница URL пользователя
+//	error - ошибка операции (storage.ErrInvalidCursor для неверного курсора)
+func (s *PostgresStorage) GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error) {
+	var page models.UserURLsPage
 	userID := ctx.Value(jwtauth.UserIDContextKey)
-	query := "SELECT original_url, short_code FROM short_urls WHERE user_id = $1"
 
-	rows, err := s.db.QueryContext(ctx, query, userID)
+	var (
+		conds = []string{"user_id = $1"}
+		args  = []any{userID}
+		order = "ASC"
+		cmp   = ">"
+	)
+	if query.Order == models.SortDesc {
+		order, cmp = "DESC", "<"
+	}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if query.Search != "" {
+		conds = append(conds, "strpos(lower(original_url), lower("+arg(query.Search)+")) > 0")
+	}
+	if query.CreatedFrom != nil {
+		conds = append(conds, "created_at >= "+arg(*query.CreatedFrom))
+	}
+	if query.CreatedTo != nil {
+		conds = append(conds, "created_at < "+arg(*query.CreatedTo))
+	}
+	if query.Cursor != "" {
+		after, err := storage.DecodeUserURLsCursor(query.Cursor)
+		if err != nil {
+			return page, err
+		}
+		conds = append(conds, fmt.Sprintf("(created_at, id) %s (%s, %s)", cmp, arg(after.CreatedAt), arg(int64(after.ID))))
+	}
+
+	sqlQuery := "SELECT id, original_url, short_code, expires_at, created_at FROM short_urls WHERE " +
+		strings.Join(conds, " AND ") +
+		fmt.Sprintf(" ORDER BY created_at %s, id %s", order, order)
+	if query.Limit > 0 {
+		sqlQuery += " LIMIT " + arg(query.Limit+1)
+	}
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
-		return nil, err
+		return page, err
 	}
 	defer rows.Close()
 
-	var userURLs []models.URLMapping
+	var last storage.UserURLsCursor
 	for rows.Next() {
-		var originalURL, shortURL string
-		if err := rows.Scan(&originalURL, &shortURL); err != nil {
-			return nil, err
+		var (
+			id          int64
+			originalURL string
+			shortURL    string
+			expiresAt   sql.NullTime
+			createdAt   time.Time
+		)
+		if err := rows.Scan(&id, &originalURL, &shortURL, &expiresAt, &createdAt); err != nil {
+			return page, err
+		}
+		if query.Limit > 0 && len(page.URLs) == query.Limit {
+			page.NextCursor = last.Encode()
+			break
 		}
-		userURLs = append(userURLs, models.URLMapping{
+
+		mapping := models.URLMapping{
 			OriginalURL: originalURL,
 			ShortURL:    baseURL + "/" + shortURL,
-		})
+			CreatedAt:   &createdAt,
+		}
+		if expiresAt.Valid {
+			mapping.ExpiresAt = &expiresAt.Time
+		}
+		page.URLs = append(page.URLs, mapping)
+		last = storage.UserURLsCursor{CreatedAt: createdAt, ID: uint64(id), Code: shortURL}
 	}
 
-	return userURLs, rows.Err()
+	return page, rows.Err()
 }
 
 // BatchMarkAsDeleted помечает URL пользователя как удаленные.
 //
+// Пометка и проверка владельца выполняются одним запросом: помеченные коды
+// возвращает UPDATE, чужие - выборка из того же снимка данных.
+//
 // Параметры:
 //
+//	ctx - контекст выполнения
 //	userID - идентификатор пользователя
 //	urls - список коротких URL для удаления
 //
 // Возвращает:
 //
+//	models.DeleteResult - удалённые, чужие и пропущенные коды
 //	error - ошибка операции
-func (s *PostgresStorage) BatchMarkAsDeleted(userID string, urls []string) error {
+func (s *PostgresStorage) BatchMarkAsDeleted(ctx context.Context, userID string, urls []string) (models.DeleteResult, error) {
+	var result models.DeleteResult
 	if len(urls) == 0 {
-		return nil
+		return result, nil
 	}
 
-	var params []interface{}
-	query := "UPDATE short_urls SET is_deleted = true WHERE short_code IN ("
+	rows, err := s.db.QueryContext(ctx, `
+	WITH deleted AS (
+		UPDATE short_urls SET is_deleted = true, deleted_at = now(), updated_at = now()
+		WHERE short_code = ANY($1) AND user_id = $2 AND NOT is_deleted
+		RETURNING short_code
+	)
+	SELECT short_code, true FROM deleted
+	UNION ALL
+	SELECT short_code, false FROM short_urls
+	WHERE short_code = ANY($1) AND user_id IS DISTINCT FROM $2`, urls, userID)
+	if err != nil {
+		return result, fmt.Errorf("error updating batch: %w", err)
+	}
+	defer rows.Close()
 
-	for i, url := range urls {
-		query += fmt.Sprintf("$%d,", i+1)
-		params = append(params, url)
+	handled := make(map[string]bool, len(urls))
+	for rows.Next() {
+		var (
+			code    string
+			deleted bool
+		)
+		if err := rows.Scan(&code, &deleted); err != nil {
+			return result, err
+		}
+		handled[code] = true
+		if deleted {
+			result.Deleted = append(result.Deleted, code)
+		} else {
+			result.NotOwned = append(result.NotOwned, code)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("error updating batch: %w", err)
 	}
-	query = strings.TrimSuffix(query, ",") + ") AND user_id = $" + fmt.Sprintf("%d", len(urls)+1)
-	params = append(params, userID)
 
-	_, err := s.db.Exec(query, params...)
+	for _, code := range urls {
+		if !handled[code] {
+			handled[code] = true
+			result.Skipped = append(result.Skipped, code)
+		}
+	}
+
+	return result, nil
+}
+
+// BatchRestore снимает пометку удаления с URL пользователя, удалённых
+// не раньше момента deletedAfter.
+//
+// Восстановление и разбор остальных кодов выполняются одним запросом:
+// восстановленные коды возвращает UPDATE, чужие и неудалённые - выборка
+// из того же снимка данных.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//	userID - идентификатор пользователя
+//	urls - список коротких URL для восстановления
+//	deletedAfter - начало окна восстановления
+//
+// Возвращает:
+//
+//	models.RestoreResult - восстановленные, неудалённые, чужие и недоступные коды
+//	error - ошибка операции
+func (s *PostgresStorage) BatchRestore(ctx context.Context, userID string, urls []string, deletedAfter time.Time) (models.RestoreResult, error) {
+	var result models.RestoreResult
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	WITH restored AS (
+		UPDATE short_urls SET is_deleted = false, deleted_at = NULL, updated_at = now()
+		WHERE short_code = ANY($1) AND user_id = $2 AND is_deleted AND deleted_at >= $3
+		RETURNING short_code
+	)
+	SELECT short_code, 'restored' FROM restored
+	UNION ALL
+	SELECT short_code, CASE WHEN user_id IS DISTINCT FROM $2 THEN 'not_owned' ELSE 'active' END
+	FROM short_urls
+	WHERE short_code = ANY($1) AND (user_id IS DISTINCT FROM $2 OR NOT is_deleted)`, urls, userID, deletedAfter)
 	if err != nil {
-		return fmt.Errorf("error updating batch: %w", err)
+		return result, fmt.Errorf("error restoring batch: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	handled := make(map[string]bool, len(urls))
+	for rows.Next() {
+		var code, state string
+		if err := rows.Scan(&code, &state); err != nil {
+			return result, err
+		}
+		handled[code] = true
+		switch state {
+		case "restored":
+			result.Restored = append(result.Restored, code)
+		case "not_owned":
+			result.NotOwned = append(result.NotOwned, code)
+		default:
+			result.Active = append(result.Active, code)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("error restoring batch: %w", err)
+	}
+
+	for _, code := range urls {
+		if !handled[code] {
+			handled[code] = true
+			result.Unavailable = append(result.Unavailable, code)
+		}
+	}
+
+	return result, nil
+}
+
+// PurgeURLs физически удаляет записи, помеченные как удалённые до момента before
+// или истёкшие до него, и пользователей, у которых не осталось ссылок.
+//
+// Пользователи удаляются после блокировки их строк (FOR UPDATE) отдельным
+// запросом: блокировка дожидается транзакций, уже вставивших ссылку
+// пользователя, и новый снимок данных видит эти ссылки, поэтому каскадное
+// удаление по внешнему ключу их не затрагивает. Вставка ссылки, начатая после
+// блокировки, завершается нарушением внешнего ключа и повторяется (SaveURL).
+//
+// Параметры:
+//
+//	ctx - контекст
+//	before - граница срока хранения
+//	limit - максимальное количество удаляемых записей
+//
+// Возвращает:
+//
+//	int - количество удалённых записей
+//	error - ошибка операции
+func (s *PostgresStorage) PurgeURLs(ctx context.Context, before time.Time, limit int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error purging urls: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+	DELETE FROM short_urls WHERE id IN (
+		SELECT id FROM short_urls
+		WHERE (is_deleted AND deleted_at < $1) OR expires_at < $1
+		LIMIT $2
+	)
+	RETURNING user_id`, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("error purging urls: %w", err)
+	}
+
+	var (
+		purged  int
+		userIDs []string
+		seen    = make(map[string]bool)
+	)
+	for rows.Next() {
+		var userID sql.NullString
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		purged++
+		if userID.Valid && !seen[userID.String] {
+			seen[userID.String] = true
+			userIDs = append(userIDs, userID.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error purging urls: %w", err)
+	}
+
+	if len(userIDs) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			`SELECT id FROM users WHERE id = ANY($1) ORDER BY id FOR UPDATE`, userIDs); err != nil {
+			return 0, fmt.Errorf("error locking users: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+		DELETE FROM users u
+		WHERE u.id = ANY($1) AND NOT EXISTS (SELECT 1 FROM short_urls s WHERE s.user_id = u.id)`,
+			userIDs); err != nil {
+			return 0, fmt.Errorf("error purging users: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error purging urls: %w", err)
+	}
+	return purged, nil
 }
 
 // CountURLs возвращает количество сокращённых URL в сервисе.
@@ -345,6 +762,8 @@ func (s *PostgresStorage) CountURLs(ctx context.Context) (int, error) {
 }
 
 // CountUsers возвращает количество пользователей в сервисе.
+// Пользователь учитывается с момента сохранения его первой ссылки
+// до очистки последней (PurgeURLs).
 //
 // Параметры:
 //
@@ -356,7 +775,7 @@ func (s *PostgresStorage) CountURLs(ctx context.Context) (int, error) {
 //		error - ошибка операции
 func (s *PostgresStorage) CountUsers(ctx context.Context) (int, error) {
 	var count int
-	err := s.db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT user_id) FROM short_urls").Scan(&count)
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count)
 	return count, err
 }
 
@@ -364,3 +783,26 @@ func (s *PostgresStorage) CountUsers(ctx context.Context) (int, error) {
 func (s *PostgresStorage) Close() error {
 	return s.db.Close()
 }
+
+// isUniqueViolation проверяет, вызвана ли ошибка нарушением ограничения уникальности.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// isForeignKeyViolation проверяет, вызвана ли ошибка нарушением внешнего ключа
+// (пользователь ссылки удалён параллельной очисткой).
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode
+}
+
+// NextSequenceValue возвращает очередное значение последовательности short_key_seq.
+// Используется генератором коротких ключей, общим для всех экземпляров сервиса.
+func (s *PostgresStorage) NextSequenceValue(ctx context.Context) (uint64, error) {
+	var value int64
+	if err := s.db.QueryRowContext(ctx, "SELECT nextval('short_key_seq')").Scan(&value); err != nil {
+		return 0, fmt.Errorf("ошибка получения значения последовательности: %w", err)
+	}
+	return uint64(value), nil
+}
@@ -0,0 +1,241 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// Измерения разбивки переходов (суффиксы ключей click:<код>:<измерение>).
+const (
+	dimensionReferrer  = "referrer"
+	dimensionUserAgent = "user_agent"
+	dimensionIPPrefix  = "ip_prefix"
+)
+
+// clickTotals содержит агрегаты пакета событий по одной ссылке.
+type clickTotals struct {
+	clicks     int64
+	firstClick time.Time
+	lastClick  time.Time
+	breakdown  map[string]map[string]int64 // измерение -> значение -> количество
+}
+
+// RecordClicks сохраняет пакет событий перехода.
+//
+// События предварительно агрегируются по ссылкам, после чего счётчики
+// обновляются атомарными командами HINCRBY и ZADD LT/GT одним конвейером.
+// События для несуществующих ссылок пропускаются.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//	events - события перехода
+//
+// Возвращает:
+//
+//	error - ошибка операции
+func (s *RedisStorage) RecordClicks(ctx context.Context, events []models.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	totals := make(map[string]*clickTotals)
+	for _, event := range events {
+		t, exists := totals[event.ShortURL]
+		if !exists {
+			t = &clickTotals{
+				firstClick: event.At,
+				lastClick:  event.At,
+				breakdown:  make(map[string]map[string]int64),
+			}
+			totals[event.ShortURL] = t
+		}
+		t.clicks++
+		if event.At.Before(t.firstClick) {
+			t.firstClick = event.At
+		}
+		if event.At.After(t.lastClick) {
+			t.lastClick = event.At
+		}
+
+		for dimension, value := range map[string]string{
+			dimensionReferrer:  event.Referrer,
+			dimensionUserAgent: event.UserAgent,
+			dimensionIPPrefix:  event.IPPrefix,
+		} {
+			if value == "" {
+				continue
+			}
+			if t.breakdown[dimension] == nil {
+				t.breakdown[dimension] = make(map[string]int64)
+			}
+			t.breakdown[dimension][value]++
+		}
+	}
+
+	codes := make([]string, 0, len(totals))
+	cmds := make([][]any, 0, len(totals))
+	for code := range totals {
+		codes = append(codes, code)
+		cmds = append(cmds, []any{"EXISTS", s.urlKey(code)})
+	}
+	replies, err := s.client.pipeline(ctx, cmds...)
+	if err != nil {
+		return err
+	}
+
+	cmds = cmds[:0]
+	for i, code := range codes {
+		if exists, err := asInt(replies[i], nil); err != nil {
+			return err
+		} else if exists == 0 {
+			continue
+		}
+
+		t := totals[code]
+		cmds = append(cmds,
+			[]any{"HINCRBY", s.clickKey(code), "clicks", t.clicks},
+			[]any{"ZADD", s.firstClickKey(), "LT", timeScore(t.firstClick), code},
+			[]any{"ZADD", s.lastClickKey(), "GT", timeScore(t.lastClick), code},
+		)
+		for dimension, values := range t.breakdown {
+			for value, clicks := range values {
+				cmds = append(cmds, []any{"HINCRBY", s.breakdownKey(code, dimension), value, clicks})
+			}
+		}
+	}
+
+	replies, err = s.client.pipeline(ctx, cmds...)
+	if err != nil {
+		return err
+	}
+	return firstError(replies)
+}
+
+// GetURLStats возвращает статистику переходов по ссылке текущего пользователя.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	shortKey - сокращенный ключ URL
+//	top - количество значений в разбивках по источникам и User-Agent
+//
+// Возвращает:
+//
+//	models.URLStats - статистика переходов (ShortURL содержит короткий идентификатор)
+//	error - ошибка операции:
+//	  - storage.ErrURLNotFound если ссылка не найдена или принадлежит другому пользователю
+func (s *RedisStorage) GetURLStats(ctx context.Context, shortKey string, top int) (models.URLStats, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return models.URLStats{}, err
+	}
+
+	record, err := s.getRecord(ctx, shortKey)
+	if err != nil {
+		return models.URLStats{}, err
+	}
+	if record.UserID != userID {
+		return models.URLStats{}, storage.ErrURLNotFound
+	}
+
+	stats := models.URLStats{
+		ShortURL:    shortKey,
+		OriginalURL: record.OriginalURL,
+	}
+
+	replies, err := s.client.pipeline(ctx,
+		[]any{"HGET", s.clickKey(shortKey), "clicks"},
+		[]any{"ZSCORE", s.firstClickKey(), shortKey},
+		[]any{"ZSCORE", s.lastClickKey(), shortKey},
+		[]any{"HLEN", s.breakdownKey(shortKey, dimensionIPPrefix)},
+		[]any{"HGETALL", s.breakdownKey(shortKey, dimensionReferrer)},
+		[]any{"HGETALL", s.breakdownKey(shortKey, dimensionUserAgent)},
+	)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Clicks, err = asInt(replies[0], nil)
+	if errors.Is(err, errNil) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+
+	if stats.FirstClickAt, err = asTime(replies[1]); err != nil {
+		return stats, err
+	}
+	if stats.LastClickAt, err = asTime(replies[2]); err != nil {
+		return stats, err
+	}
+	if stats.UniqueVisitors, err = asInt(replies[3], nil); err != nil {
+		return stats, err
+	}
+	if stats.TopReferrers, err = topClickCounts(replies[4], top); err != nil {
+		return stats, err
+	}
+	if stats.TopUserAgents, err = topClickCounts(replies[5], top); err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// asTime преобразует ответ ZSCORE в момент времени (nil, если оценки нет).
+func asTime(reply any) (*time.Time, error) {
+	score, err := asBytes(reply, nil)
+	if errors.Is(err, errNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := strconv.ParseFloat(string(score), 64)
+	if err != nil {
+		return nil, err
+	}
+	t := scoreTime(value)
+	return &t, nil
+}
+
+// topClickCounts возвращает не более top значений ответа HGETALL с наибольшим
+// количеством переходов; при равенстве значения упорядочиваются по возрастанию.
+func topClickCounts(reply any, top int) ([]models.ClickCount, error) {
+	pairs, err := asStrings(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.ClickCount, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		clicks, err := strconv.ParseInt(pairs[i+1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, models.ClickCount{Value: pairs[i], Clicks: clicks})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Clicks != counts[j].Clicks {
+			return counts[i].Clicks > counts[j].Clicks
+		}
+		return counts[i].Value < counts[j].Value
+	})
+
+	if len(counts) > top {
+		counts = counts[:top]
+	}
+	if len(counts) == 0 {
+		return nil, nil
+	}
+	return counts, nil
+}
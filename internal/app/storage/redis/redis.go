@@ -0,0 +1,874 @@
+// Package redis предоставляет реализацию хранилища URL поверх сервера,
+// совместимого с протоколом Redis (RESP).
+//
+// Пакет включает:
+// - Собственный клиент протокола RESP2 с пулом соединений и конвейерной отправкой команд
+// - Оптимистичные транзакции (WATCH/MULTI/EXEC) без серверных скриптов для записи из нескольких экземпляров
+// - Общие для всех экземпляров сервиса данные, счётчики и статистику переходов
+//
+// Схема ключей (все ключи начинаются с префикса, по умолчанию "shortener:"):
+// - url:<код> - JSON-запись models.UserURLMapping
+// - user:<userID> - хеш "оригинальный URL -> код"
+// - urls, users - множества кодов и пользователей (для статистики сервиса)
+// - purge - упорядоченное множество кодов по моменту удаления или истечения срока
+// - click:<код>, click:<код>:<измерение>, click_first, click_last - статистика переходов
+// - short_key_seq - счётчик для генератора коротких ключей
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// Параметры хранилища по умолчанию.
+const (
+	defaultKeyPrefix = "shortener:"
+	sequenceStart    = 1000000 // Первое значение счётчика коротких ключей (как short_key_seq в PostgreSQL)
+)
+
+// RedisStorage реализует интерфейс хранилища поверх Redis.
+type RedisStorage struct {
+	client   *client
+	prefix   string
+	poolSize int
+	timeout  time.Duration
+}
+
+// Option задаёт дополнительные параметры RedisStorage.
+type Option func(*RedisStorage)
+
+// WithKeyPrefix задаёт префикс ключей, позволяя нескольким сервисам использовать одну базу.
+func WithKeyPrefix(prefix string) Option {
+	return func(s *RedisStorage) {
+		s.prefix = prefix
+	}
+}
+
+// WithPoolSize задаёт максимальное количество свободных соединений в пуле.
+func WithPoolSize(size int) Option {
+	return func(s *RedisStorage) {
+		if size > 0 {
+			s.poolSize = size
+		}
+	}
+}
+
+// WithTimeout задаёт таймаут подключения и выполнения команд.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *RedisStorage) {
+		if timeout > 0 {
+			s.timeout = timeout
+		}
+	}
+}
+
+// NewRedisStorage создает хранилище и проверяет соединение с сервером.
+//
+// Параметры:
+//   - redisURL: адрес сервера вида redis://[user:password@]host:port[/db]
+//   - opts: дополнительные параметры (например, WithKeyPrefix)
+//
+// Возвращает:
+//   - *RedisStorage: инициализированное хранилище
+//   - error: ошибка разбора адреса или подключения
+func NewRedisStorage(redisURL string, opts ...Option) (*RedisStorage, error) {
+	s := &RedisStorage{
+		prefix:   defaultKeyPrefix,
+		poolSize: defaultPoolSize,
+		timeout:  defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	c, err := newClient(redisURL, s.poolSize, s.timeout)
+	if err != nil {
+		return nil, err
+	}
+	s.client = c
+
+	if err := s.Ping(context.Background()); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Ключи хранилища.
+
+func (s *RedisStorage) urlKey(code string) string    { return s.prefix + "url:" + code }
+func (s *RedisStorage) userKey(userID string) string { return s.prefix + "user:" + userID }
+func (s *RedisStorage) urlsKey() string              { return s.prefix + "urls" }
+func (s *RedisStorage) usersKey() string             { return s.prefix + "users" }
+func (s *RedisStorage) purgeKey() string             { return s.prefix + "purge" }
+func (s *RedisStorage) sequenceKey() string          { return s.prefix + "short_key_seq" }
+func (s *RedisStorage) clickKey(code string) string  { return s.prefix + "click:" + code }
+func (s *RedisStorage) firstClickKey() string        { return s.prefix + "click_first" }
+func (s *RedisStorage) lastClickKey() string         { return s.prefix + "click_last" }
+func (s *RedisStorage) breakdownKey(code, dimension string) string {
+	return s.prefix + "click:" + code + ":" + dimension
+}
+
+// Ping проверяет соединение с сервером.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//
+// Возвращает:
+//
+//	error - ошибка соединения
+func (s *RedisStorage) Ping(ctx context.Context) error {
+	ctxTm, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	_, err := s.client.do(ctxTm, "PING")
+	return err
+}
+
+// GetShortKey возвращает сокращенный URL для оригинального.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	originalURL - оригинальный URL
+//
+// Возвращает:
+//
+//	models.URLMapping - соответствие URL
+//	error - ошибка операции (storage.ErrURLNotFound если не найден)
+func (s *RedisStorage) GetShortKey(ctx context.Context, originalURL string) (models.URLMapping, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return models.URLMapping{}, err
+	}
+
+	shortURL, err := asBytes(s.client.do(ctx, "HGET", s.userKey(userID), originalURL))
+	if errors.Is(err, errNil) {
+		return models.URLMapping{}, storage.ErrURLNotFound
+	}
+	if err != nil {
+		return models.URLMapping{}, err
+	}
+
+	return models.URLMapping{
+		ShortURL:    string(shortURL),
+		OriginalURL: originalURL,
+	}, nil
+}
+
+// GetRedirectURL возвращает оригинальный URL для сокращенного.
+//
+// Параметры:
+//
+//	ctx - контекст выполнения
+//	shortKey - сокращенный ключ URL
+//
+// Возвращает:
+//
+//	models.URLMapping - соответствие URL
+//	error - ошибка операции:
+//	  - storage.ErrURLNotFound если URL не существует
+//	  - storage.ErrURLDeleted если URL помечен как удаленный
+//	  - storage.ErrURLExpired если срок действия URL истёк
+func (s *RedisStorage) GetRedirectURL(ctx context.Context, shortKey string) (models.URLMapping, error) {
+	record, err := s.getRecord(ctx, shortKey)
+	if err != nil {
+		return models.URLMapping{ShortURL: shortKey}, err
+	}
+
+	if record.DeletedFlag {
+		return models.URLMapping{}, storage.ErrURLDeleted
+	}
+
+	if record.ExpiresAt != nil && !time.Now().Before(*record.ExpiresAt) {
+		return models.URLMapping{}, storage.ErrURLExpired
+	}
+
+	return models.URLMapping{
+		ShortURL:    record.ShortURL,
+		OriginalURL: record.OriginalURL,
+		ExpiresAt:   record.ExpiresAt,
+	}, nil
+}
+
+// getRecord читает запись ссылки. Возвращает storage.ErrURLNotFound, если ссылки нет.
+func (s *RedisStorage) getRecord(ctx context.Context, code string) (models.UserURLMapping, error) {
+	data, err := asBytes(s.client.do(ctx, "GET", s.urlKey(code)))
+	if errors.Is(err, errNil) {
+		return models.UserURLMapping{}, storage.ErrURLNotFound
+	}
+	if err != nil {
+		return models.UserURLMapping{}, err
+	}
+
+	var record models.UserURLMapping
+	err = json.Unmarshal(data, &record)
+	return record, err
+}
+
+// SaveURL сохраняет новое соответствие URL.
+//
+// Ключ ссылки, поле хеша пользователя и вспомогательные индексы записываются
+// одной транзакцией (см. saveRecords), поэтому один короткий ключ не может быть
+// выдан дважды даже при записи из нескольких экземпляров сервиса, а сбой
+// не оставляет ссылку без владельца.
+// Как и в PostgreSQL, существующая пара (пользователь, URL) имеет приоритет
+// перед конфликтом по короткому ключу.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	mapping - соответствие URL для сохранения
+//
+// Возвращает:
+//
+//	error - ошибка операции:
+//	  - storage.ErrURLExists если URL уже сокращён пользователем (mapping.ShortURL содержит существующий ключ)
+//	  - storage.ErrShortURLExists если короткий ключ уже занят
+func (s *RedisStorage) SaveURL(ctx context.Context, mapping *models.URLMapping) error {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record := models.UserURLMapping{
+		ShortURL:    mapping.ShortURL,
+		OriginalURL: mapping.OriginalURL,
+		UserID:      userID,
+		ExpiresAt:   mapping.ExpiresAt,
+		CreatedAt:   &now,
+	}
+
+	existing, err := s.saveRecords(ctx, userID, []models.UserURLMapping{record})
+	if err != nil {
+		return err
+	}
+	if shortURL, ok := existing[mapping.OriginalURL]; ok {
+		mapping.ShortURL = shortURL
+		return storage.ErrURLExists
+	}
+	return nil
+}
+
+// saveRecords сохраняет новые ссылки пользователя одной транзакцией.
+//
+// Хеш пользователя и ключи ссылок отслеживаются (WATCH): URL, уже сокращённые
+// пользователем, пропускаются, а занятый короткий ключ отменяет сохранение всех
+// записей. Если другой экземпляр сервиса изменил отслеживаемые ключи до EXEC,
+// проверки повторяются с учётом его изменений.
+//
+// Возвращает коды URL, уже сокращённых пользователем (originalURL -> shortURL),
+// или storage.ErrShortURLExists.
+func (s *RedisStorage) saveRecords(ctx context.Context, userID string, records []models.UserURLMapping) (map[string]string, error) {
+	keys := make([]string, 0, len(records)+1)
+	keys = append(keys, s.userKey(userID))
+	hmget := []any{"HMGET", s.userKey(userID)}
+	for _, record := range records {
+		keys = append(keys, s.urlKey(record.ShortURL))
+		hmget = append(hmget, record.OriginalURL)
+	}
+
+	var existing map[string]string
+	replies, err := s.client.transaction(ctx, keys, func(t *tx) ([][]any, error) {
+		codes, err := asStrings(t.do(hmget...))
+		if err != nil {
+			return nil, err
+		}
+
+		existing = make(map[string]string)
+		var saved []models.UserURLMapping
+		exists := []any{"EXISTS"}
+		for i, record := range records {
+			if codes[i] != "" {
+				existing[record.OriginalURL] = codes[i]
+				continue
+			}
+			saved = append(saved, record)
+			exists = append(exists, s.urlKey(record.ShortURL))
+		}
+		if len(saved) == 0 {
+			return nil, nil
+		}
+
+		taken, err := asInt(t.do(exists...))
+		if err != nil {
+			return nil, err
+		}
+		if taken > 0 {
+			return nil, storage.ErrShortURLExists
+		}
+
+		cmds := make([][]any, 0, 2*len(saved)+2)
+		hset := []any{"HSET", s.userKey(userID)}
+		for _, record := range saved {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return nil, err
+			}
+			cmds = append(cmds, []any{"SET", s.urlKey(record.ShortURL), data})
+			hset = append(hset, record.OriginalURL, record.ShortURL)
+		}
+		cmds = append(cmds, hset)
+		return append(cmds, s.indexCommands(userID, saved)...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := firstError(replies); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// indexCommands возвращает команды обновления вспомогательных индексов для новых ссылок пользователя.
+func (s *RedisStorage) indexCommands(userID string, records []models.UserURLMapping) [][]any {
+	codes := []any{"SADD", s.urlsKey()}
+	cmds := [][]any{{"SADD", s.usersKey(), userID}}
+	for _, record := range records {
+		codes = append(codes, record.ShortURL)
+		if record.ExpiresAt != nil {
+			cmds = append(cmds, []any{"ZADD", s.purgeKey(), "LT", timeScore(*record.ExpiresAt), record.ShortURL})
+		}
+	}
+	return append(cmds, codes)
+}
+
+// GetExistingURLs возвращает существующие сокращения для URL.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	originalURLs - список оригинальных URL
+//
+// Возвращает:
+//
+//	map[string]string - соответствия URL (originalURL -> shortURL)
+//	error - ошибка операции
+func (s *RedisStorage) GetExistingURLs(ctx context.Context, originalURLs []string) (map[string]string, error) {
+	existing := make(map[string]string)
+	if len(originalURLs) == 0 {
+		return existing, nil
+	}
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{"HMGET", s.userKey(userID)}
+	for _, originalURL := range originalURLs {
+		args = append(args, originalURL)
+	}
+	codes, err := asStrings(s.client.do(ctx, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, code := range codes {
+		if code != "" {
+			existing[originalURLs[i]] = code
+		}
+	}
+	return existing, nil
+}
+
+// SaveNewURLs сохраняет пакет новых URL.
+//
+// Пакет сохраняется одной транзакцией (см. saveRecords): если хотя бы один
+// короткий ключ занят (или повторяется внутри пакета), ни один URL
+// не сохраняется. URL, уже сокращённые пользователем, пропускаются.
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	urls - список URL для сохранения
+//
+// Возвращает:
+//
+//	error - ошибка операции:
+//	  - storage.ErrShortURLExists если короткий ключ уже занят (пакет не сохраняется)
+func (s *RedisStorage) SaveNewURLs(ctx context.Context, urls []models.URLMapping) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var records []models.UserURLMapping
+	batchCodes := make(map[string]struct{}, len(urls))
+	batchURLs := make(map[string]struct{}, len(urls))
+	for _, url := range urls {
+		if _, exists := batchURLs[url.OriginalURL]; exists {
+			continue
+		}
+		if _, exists := batchCodes[url.ShortURL]; exists {
+			return storage.ErrShortURLExists
+		}
+		batchURLs[url.OriginalURL] = struct{}{}
+		batchCodes[url.ShortURL] = struct{}{}
+
+		records = append(records, models.UserURLMapping{
+			ShortURL:    url.ShortURL,
+			OriginalURL: url.OriginalURL,
+			UserID:      userID,
+			ExpiresAt:   url.ExpiresAt,
+			CreatedAt:   &now,
+		})
+	}
+
+	_, err = s.saveRecords(ctx, userID, records)
+	return err
+}
+
+// GetUserUrls возвращает страницу URL пользователя.
+//
+// Коды пользователя читаются из его хэша, записи загружаются одним конвейером
+// и упорядочиваются в памяти (см. storage.PageUserURLs).
+//
+// Параметры:
+//
+//	ctx - контекст с userID
+//	baseURL - базовый URL для построения полных коротких URL
+//	query - фильтры, порядок и страница
+//
+// Возвращает:
+//
+//	models.UserURLsPage - страница URL пользователя
+//	error - ошибка операции (storage.ErrInvalidCursor для неверного курсора)
+func (s *RedisStorage) GetUserUrls(ctx context.Context, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return models.UserURLsPage{}, err
+	}
+
+	pairs, err := asStrings(s.client.do(ctx, "HGETALL", s.userKey(userID)))
+	if err != nil {
+		return models.UserURLsPage{}, err
+	}
+
+	var cmds [][]any
+	for i := 0; i+1 < len(pairs); i += 2 {
+		cmds = append(cmds, []any{"GET", s.urlKey(pairs[i+1])})
+	}
+	replies, err := s.client.pipeline(ctx, cmds...)
+	if err != nil {
+		return models.UserURLsPage{}, err
+	}
+
+	records := make([]models.UserURLMapping, 0, len(replies))
+	for _, reply := range replies {
+		data, err := asBytes(reply, nil)
+		if errors.Is(err, errNil) {
+			continue
+		}
+		if err != nil {
+			return models.UserURLsPage{}, err
+		}
+
+		var record models.UserURLMapping
+		if err := json.Unmarshal(data, &record); err != nil {
+			return models.UserURLsPage{}, err
+		}
+		records = append(records, record)
+	}
+	return storage.PageUserURLs(records, baseURL, query)
+}
+
+// BatchMarkAsDeleted помечает URL пользователя как удаленные.
+//
+// Записи читаются и изменяются одной оптимистичной транзакцией (WATCH/MULTI/EXEC),
+// поэтому одновременные изменения тех же ссылок другими экземплярами сервиса
+// не теряются.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	userID - идентификатор пользователя
+//	urls - список коротких URL для удаления
+//
+// Возвращает:
+//
+//	models.DeleteResult - удалённые, чужие и пропущенные коды
+//	error - ошибка операции
+func (s *RedisStorage) BatchMarkAsDeleted(ctx context.Context, userID string, urls []string) (models.DeleteResult, error) {
+	var result models.DeleteResult
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	replies, err := s.client.transaction(ctx, s.urlKeys(urls), func(t *tx) ([][]any, error) {
+		result = models.DeleteResult{}
+		records, err := s.readRecords(t, urls)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		var cmds [][]any
+		for i, record := range records {
+			switch {
+			case record == nil || (record.UserID == userID && record.DeletedFlag):
+				result.Skipped = append(result.Skipped, urls[i])
+				continue
+			case record.UserID != userID:
+				result.NotOwned = append(result.NotOwned, urls[i])
+				continue
+			}
+
+			record.DeletedFlag = true
+			record.DeletedAt = &now
+			data, err := json.Marshal(record)
+			if err != nil {
+				return nil, err
+			}
+			cmds = append(cmds,
+				[]any{"SET", s.urlKey(record.ShortURL), data, "XX"},
+				[]any{"ZADD", s.purgeKey(), "LT", timeScore(now), record.ShortURL},
+			)
+			result.Deleted = append(result.Deleted, urls[i])
+		}
+		return cmds, nil
+	})
+	if err != nil {
+		return models.DeleteResult{}, err
+	}
+	if err := firstError(replies); err != nil {
+		return models.DeleteResult{}, err
+	}
+	return result, nil
+}
+
+// BatchRestore снимает пометку удаления с URL пользователя, удалённых
+// не раньше момента deletedAfter.
+//
+// Восстановленная запись убирается из множества purge; если у ссылки задан
+// срок действия, она возвращается в него с моментом истечения.
+// Как и BatchMarkAsDeleted, выполняется одной оптимистичной транзакцией.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	userID - идентификатор пользователя
+//	urls - список коротких URL для восстановления
+//	deletedAfter - начало окна восстановления
+//
+// Возвращает:
+//
+//	models.RestoreResult - восстановленные, неудалённые, чужие и недоступные коды
+//	error - ошибка операции
+func (s *RedisStorage) BatchRestore(ctx context.Context, userID string, urls []string, deletedAfter time.Time) (models.RestoreResult, error) {
+	var result models.RestoreResult
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	replies, err := s.client.transaction(ctx, s.urlKeys(urls), func(t *tx) ([][]any, error) {
+		result = models.RestoreResult{}
+		records, err := s.readRecords(t, urls)
+		if err != nil {
+			return nil, err
+		}
+
+		var cmds [][]any
+		for i, record := range records {
+			switch {
+			case record == nil:
+				result.Unavailable = append(result.Unavailable, urls[i])
+				continue
+			case record.UserID != userID:
+				result.NotOwned = append(result.NotOwned, urls[i])
+				continue
+			case !record.DeletedFlag:
+				result.Active = append(result.Active, urls[i])
+				continue
+			case record.DeletedAt == nil || record.DeletedAt.Before(deletedAfter):
+				result.Unavailable = append(result.Unavailable, urls[i])
+				continue
+			}
+
+			record.DeletedFlag = false
+			record.DeletedAt = nil
+			data, err := json.Marshal(record)
+			if err != nil {
+				return nil, err
+			}
+			cmds = append(cmds,
+				[]any{"SET", s.urlKey(record.ShortURL), data, "XX"},
+				[]any{"ZREM", s.purgeKey(), record.ShortURL},
+			)
+			if record.ExpiresAt != nil {
+				cmds = append(cmds, []any{"ZADD", s.purgeKey(), timeScore(*record.ExpiresAt), record.ShortURL})
+			}
+			result.Restored = append(result.Restored, urls[i])
+		}
+		return cmds, nil
+	})
+	if err != nil {
+		return models.RestoreResult{}, err
+	}
+	if err := firstError(replies); err != nil {
+		return models.RestoreResult{}, err
+	}
+	return result, nil
+}
+
+// urlKeys возвращает ключи записей ссылок.
+func (s *RedisStorage) urlKeys(codes []string) []string {
+	keys := make([]string, len(codes))
+	for i, code := range codes {
+		keys[i] = s.urlKey(code)
+	}
+	return keys
+}
+
+// readRecords читает записи ссылок на соединении транзакции.
+// Отсутствующим ссылкам соответствует nil.
+func (s *RedisStorage) readRecords(t *tx, codes []string) ([]*models.UserURLMapping, error) {
+	cmds := make([][]any, len(codes))
+	for i, code := range codes {
+		cmds[i] = []any{"GET", s.urlKey(code)}
+	}
+	replies, err := t.pipeline(cmds...)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*models.UserURLMapping, len(replies))
+	for i, reply := range replies {
+		data, err := asBytes(reply, nil)
+		if errors.Is(err, errNil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record models.UserURLMapping
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+		records[i] = &record
+	}
+	return records, nil
+}
+
+// PurgeURLs физически удаляет записи, помеченные как удалённые до момента before
+// или истёкшие до него.
+//
+// Кандидаты выбираются из упорядоченного множества purge, поэтому
+// полный просмотр ключей не требуется. Кандидаты, которые удалять рано
+// (например, восстановленные другим экземпляром сервиса), получают в множестве
+// актуальную оценку или исключаются из него, чтобы не просматриваться повторно.
+// Записи проверяются и удаляются одной оптимистичной транзакцией, поэтому
+// одновременно восстановленная ссылка не удаляется.
+//
+// Параметры:
+//
+//	ctx - контекст
+//	before - граница срока хранения
+//	limit - максимальное количество удаляемых записей
+//
+// Возвращает:
+//
+//	int - количество удалённых записей
+//	error - ошибка операции
+func (s *RedisStorage) PurgeURLs(ctx context.Context, before time.Time, limit int) (int, error) {
+	codes, err := asStrings(s.client.do(ctx, "ZRANGEBYSCORE", s.purgeKey(),
+		"-inf", "("+strconv.FormatInt(timeScore(before), 10), "LIMIT", 0, limit))
+	if err != nil || len(codes) == 0 {
+		return 0, err
+	}
+
+	var (
+		purged int
+		users  map[string]struct{}
+	)
+	replies, err := s.client.transaction(ctx, s.urlKeys(codes), func(t *tx) ([][]any, error) {
+		purged = 0
+		users = make(map[string]struct{})
+		records, err := s.readRecords(t, codes)
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			cmds       [][]any
+			candidates []*models.UserURLMapping
+			owners     [][]any
+		)
+		for i, record := range records {
+			switch {
+			case record == nil:
+				// Запись уже удалена другим экземпляром сервиса
+				cmds = append(cmds, []any{"ZREM", s.purgeKey(), codes[i]})
+			case !isPurgeable(*record, before):
+				if score, ok := purgeScore(*record); ok {
+					cmds = append(cmds, []any{"ZADD", s.purgeKey(), score, codes[i]})
+				} else {
+					cmds = append(cmds, []any{"ZREM", s.purgeKey(), codes[i]})
+				}
+			default:
+				candidates = append(candidates, record)
+				owners = append(owners, []any{"HGET", s.userKey(record.UserID), record.OriginalURL})
+			}
+		}
+
+		ownerReplies, err := t.pipeline(owners...)
+		if err != nil {
+			return nil, err
+		}
+		for i, record := range candidates {
+			code := record.ShortURL
+			cmds = append(cmds,
+				[]any{"DEL", s.urlKey(code), s.clickKey(code),
+					s.breakdownKey(code, dimensionReferrer),
+					s.breakdownKey(code, dimensionUserAgent),
+					s.breakdownKey(code, dimensionIPPrefix)},
+				[]any{"ZREM", s.purgeKey(), code},
+				[]any{"SREM", s.urlsKey(), code},
+				[]any{"ZREM", s.firstClickKey(), code},
+				[]any{"ZREM", s.lastClickKey(), code},
+			)
+			if owner, _ := asBytes(ownerReplies[i], nil); string(owner) == code {
+				cmds = append(cmds, []any{"HDEL", s.userKey(record.UserID), record.OriginalURL})
+				users[record.UserID] = struct{}{}
+			}
+		}
+		purged = len(candidates)
+		return cmds, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := firstError(replies); err != nil {
+		return 0, err
+	}
+
+	if err := s.removeEmptyUsers(ctx, users); err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// removeEmptyUsers исключает из множества пользователей тех, у кого не осталось ссылок.
+// Хеш пользователя отслеживается, поэтому пользователь, одновременно сохранивший
+// новую ссылку, не исключается.
+func (s *RedisStorage) removeEmptyUsers(ctx context.Context, users map[string]struct{}) error {
+	for userID := range users {
+		_, err := s.client.transaction(ctx, []string{s.userKey(userID)}, func(t *tx) ([][]any, error) {
+			n, err := asInt(t.do("HLEN", s.userKey(userID)))
+			if err != nil || n > 0 {
+				return nil, err
+			}
+			return [][]any{{"SREM", s.usersKey(), userID}}, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeScore возвращает оценку записи в множестве purge: момент удаления
+// или истечения срока действия (ранний из них). false - запись не должна
+// находиться в множестве.
+func purgeScore(record models.UserURLMapping) (int64, bool) {
+	var score *time.Time
+	if record.DeletedFlag && record.DeletedAt != nil {
+		score = record.DeletedAt
+	}
+	if record.ExpiresAt != nil && (score == nil || record.ExpiresAt.Before(*score)) {
+		score = record.ExpiresAt
+	}
+	if score == nil {
+		return 0, false
+	}
+	return timeScore(*score), true
+}
+
+// isPurgeable проверяет, истёк ли срок хранения удалённой или просроченной записи.
+func isPurgeable(record models.UserURLMapping, before time.Time) bool {
+	if record.DeletedFlag && record.DeletedAt != nil && record.DeletedAt.Before(before) {
+		return true
+	}
+	return record.ExpiresAt != nil && record.ExpiresAt.Before(before)
+}
+
+// CountURLs возвращает количество сокращённых URL в сервисе.
+//
+// Параметры:
+//
+//	ctx - контекст
+//
+// Возвращает:
+//
+//	 int - количество сокращённых URL в сервисе
+//		error - ошибка операции
+func (s *RedisStorage) CountURLs(ctx context.Context) (int, error) {
+	count, err := asInt(s.client.do(ctx, "SCARD", s.urlsKey()))
+	return int(count), err
+}
+
+// CountUsers возвращает количество пользователей в сервисе.
+//
+// Параметры:
+//
+//	ctx - контекст
+//
+// Возвращает:
+//
+//	 int - количество пользователей в сервисе
+//		error - ошибка операции
+func (s *RedisStorage) CountUsers(ctx context.Context) (int, error) {
+	count, err := asInt(s.client.do(ctx, "SCARD", s.usersKey()))
+	return int(count), err
+}
+
+// NextSequenceValue возвращает очередное значение счётчика коротких ключей.
+// Используется генератором коротких ключей, общим для всех экземпляров сервиса.
+func (s *RedisStorage) NextSequenceValue(ctx context.Context) (uint64, error) {
+	value, err := asInt(s.client.do(ctx, "INCR", s.sequenceKey()))
+	if err != nil {
+		return 0, err
+	}
+	return uint64(value) + sequenceStart - 1, nil
+}
+
+// Close освобождает ресурсы
+func (s *RedisStorage) Close() error {
+	return s.client.close()
+}
+
+// userIDFromContext возвращает идентификатор пользователя из контекста.
+func userIDFromContext(ctx context.Context) (string, error) {
+	userID, ok := ctx.Value(jwtauth.UserIDContextKey).(string)
+	if !ok {
+		return "", errors.New("userID is not set")
+	}
+	return userID, nil
+}
+
+// timeScore возвращает момент времени в миллисекундах для упорядоченных множеств.
+func timeScore(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// scoreTime преобразует оценку упорядоченного множества обратно в момент времени.
+func scoreTime(score float64) time.Time {
+	return time.UnixMilli(int64(math.Round(score)))
+}
@@ -0,0 +1,312 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+	"github.com/ryabkov82/shortener/test/fakeredis"
+)
+
+// newReplicas запускает сервер и возвращает n хранилищ, работающих с ним,
+// как экземпляры сервиса.
+func newReplicas(t *testing.T, n int) []*RedisStorage {
+	t.Helper()
+
+	srv, err := fakeredis.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { srv.Close() })
+
+	replicas := make([]*RedisStorage, n)
+	for i := range replicas {
+		replicas[i], err = NewRedisStorage(srv.URL())
+		require.NoError(t, err)
+		t.Cleanup(func() { replicas[i].Close() })
+	}
+	return replicas
+}
+
+func userContext(userID string) context.Context {
+	return context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+}
+
+// purgeScoreOf возвращает оценку кода в множестве purge (false - кода нет).
+func purgeScoreOf(t *testing.T, s *RedisStorage, code string) (int64, bool) {
+	t.Helper()
+	score, err := asInt(s.client.do(context.Background(), "ZSCORE", s.purgeKey(), code))
+	if err == errNil {
+		return 0, false
+	}
+	require.NoError(t, err)
+	return score, true
+}
+
+func TestClient_Transaction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries after concurrent change", func(t *testing.T) {
+		s := newReplicas(t, 1)[0]
+		_, err := s.client.do(ctx, "SET", "counter", 1)
+		require.NoError(t, err)
+
+		attempts := 0
+		replies, err := s.client.transaction(ctx, []string{"counter"}, func(tx *tx) ([][]any, error) {
+			attempts++
+			value, err := asInt(tx.do("GET", "counter"))
+			if err != nil {
+				return nil, err
+			}
+			if attempts == 1 {
+				// Другой клиент изменяет ключ между чтением и EXEC
+				_, err := s.client.do(ctx, "SET", "counter", 10)
+				require.NoError(t, err)
+			}
+			return [][]any{{"SET", "counter", value + 1}}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []any{"OK"}, replies)
+		assert.Equal(t, 2, attempts)
+
+		// Изменение другого клиента не потеряно
+		value, err := asInt(s.client.do(ctx, "GET", "counter"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), value)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		s := newReplicas(t, 1)[0]
+
+		attempts := 0
+		_, err := s.client.transaction(ctx, []string{"key"}, func(tx *tx) ([][]any, error) {
+			attempts++
+			_, err := s.client.do(ctx, "SET", "key", attempts)
+			require.NoError(t, err)
+			return [][]any{{"SET", "key", "tx"}}, nil
+		})
+		assert.ErrorIs(t, err, errTxAborted)
+		assert.Equal(t, maxTxAttempts, attempts)
+	})
+
+	t.Run("no commands releases watch", func(t *testing.T) {
+		s := newReplicas(t, 1)[0]
+
+		replies, err := s.client.transaction(ctx, []string{"key"}, func(tx *tx) ([][]any, error) {
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Nil(t, replies)
+
+		// Соединение возвращено в пул без отслеживания ключа:
+		// его изменение не отменяет следующую транзакцию
+		_, err = s.client.do(ctx, "SET", "key", "changed")
+		require.NoError(t, err)
+
+		attempts := 0
+		_, err = s.client.transaction(ctx, []string{"other"}, func(tx *tx) ([][]any, error) {
+			attempts++
+			return [][]any{{"SET", "other", "value"}}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestSaveURL_ConcurrentReplicas(t *testing.T) {
+	replicas := newReplicas(t, 4)
+	ctx := userContext("user1")
+
+	// Экземпляры одновременно сокращают один URL разными ключами
+	var wg sync.WaitGroup
+	errs := make([]error, len(replicas))
+	mappings := make([]models.URLMapping, len(replicas))
+	for i, s := range replicas {
+		mappings[i] = models.URLMapping{ShortURL: fmt.Sprintf("code%d", i), OriginalURL: "https://example.com/"}
+		wg.Add(1)
+		go func(i int, s *RedisStorage) {
+			defer wg.Done()
+			errs[i] = s.SaveURL(ctx, &mappings[i])
+		}(i, s)
+	}
+	wg.Wait()
+
+	var winner string
+	for i, err := range errs {
+		if err == nil {
+			require.Empty(t, winner, "two replicas saved the same URL")
+			winner = mappings[i].ShortURL
+		}
+	}
+	require.NotEmpty(t, winner)
+	for i, err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, storage.ErrURLExists)
+			assert.Equal(t, winner, mappings[i].ShortURL)
+		}
+	}
+
+	// Проигравшие ключи не заняты записями без владельца
+	s := replicas[0]
+	for i := range replicas {
+		code := fmt.Sprintf("code%d", i)
+		_, err := s.GetRedirectURL(ctx, code)
+		if code == winner {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, storage.ErrURLNotFound, code)
+		}
+	}
+	count, err := s.CountURLs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestSaveNewURLs_Atomic(t *testing.T) {
+	s := newReplicas(t, 1)[0]
+	ctx := userContext("user1")
+
+	taken := models.URLMapping{ShortURL: "taken", OriginalURL: "https://example.com/taken"}
+	require.NoError(t, s.SaveURL(userContext("user2"), &taken))
+
+	// Один занятый ключ отменяет весь пакет
+	err := s.SaveNewURLs(ctx, []models.URLMapping{
+		{ShortURL: "a", OriginalURL: "https://example.com/a"},
+		{ShortURL: "taken", OriginalURL: "https://example.com/b"},
+	})
+	assert.ErrorIs(t, err, storage.ErrShortURLExists)
+
+	_, err = s.GetRedirectURL(ctx, "a")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+	_, err = s.GetShortKey(ctx, "https://example.com/a")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+	users, err := s.CountUsers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, users)
+
+	// URL, уже сокращённые пользователем, пропускаются, остальные сохраняются вместе с индексами
+	require.NoError(t, s.SaveNewURLs(ctx, []models.URLMapping{
+		{ShortURL: "a", OriginalURL: "https://example.com/a"},
+	}))
+	require.NoError(t, s.SaveNewURLs(ctx, []models.URLMapping{
+		{ShortURL: "other", OriginalURL: "https://example.com/a"},
+		{ShortURL: "c", OriginalURL: "https://example.com/c"},
+	}))
+	existing, err := s.GetExistingURLs(ctx, []string{"https://example.com/a", "https://example.com/c"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"https://example.com/a": "a", "https://example.com/c": "c"}, existing)
+	_, err = s.GetRedirectURL(ctx, "other")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	count, err := s.CountURLs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	users, err = s.CountUsers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, users)
+}
+
+func TestDeleteRestore_ConcurrentReplicas(t *testing.T) {
+	replicas := newReplicas(t, 2)
+	ctx := userContext("user1")
+	codes := []string{"a", "b", "c"}
+
+	for _, code := range codes {
+		mapping := models.URLMapping{ShortURL: code, OriginalURL: "https://example.com/" + code}
+		require.NoError(t, replicas[0].SaveURL(ctx, &mapping))
+	}
+
+	// Один экземпляр удаляет ссылки, другой одновременно восстанавливает их
+	var wg sync.WaitGroup
+	for i, s := range replicas {
+		wg.Add(1)
+		go func(restore bool, s *RedisStorage) {
+			defer wg.Done()
+			for round := 0; round < 50; round++ {
+				var err error
+				if restore {
+					_, err = s.BatchRestore(ctx, "user1", codes, time.Time{})
+				} else {
+					_, err = s.BatchMarkAsDeleted(ctx, "user1", codes)
+				}
+				assert.NoError(t, err)
+			}
+		}(i == 1, s)
+	}
+	wg.Wait()
+
+	// Запись и множество purge согласованы: удалённая ссылка ждёт очистки с моментом удаления
+	s := replicas[0]
+	for _, code := range codes {
+		record, err := s.getRecord(ctx, code)
+		require.NoError(t, err)
+		score, queued := purgeScoreOf(t, s, code)
+		if record.DeletedFlag {
+			require.True(t, queued, code)
+			assert.Equal(t, timeScore(*record.DeletedAt), score, code)
+		} else {
+			assert.False(t, queued, code)
+			assert.Nil(t, record.DeletedAt, code)
+		}
+	}
+}
+
+func TestPurgeURLs_StaleCandidates(t *testing.T) {
+	s := newReplicas(t, 1)[0]
+	ctx := userContext("user1")
+	now := time.Now()
+
+	expires := now.Add(time.Hour)
+	active := models.URLMapping{ShortURL: "active", OriginalURL: "https://example.com/active"}
+	expiring := models.URLMapping{ShortURL: "expiring", OriginalURL: "https://example.com/expiring", ExpiresAt: &expires}
+	deleted := models.URLMapping{ShortURL: "deleted", OriginalURL: "https://example.com/deleted"}
+	for _, mapping := range []*models.URLMapping{&active, &expiring, &deleted} {
+		require.NoError(t, s.SaveURL(ctx, mapping))
+	}
+	_, err := s.BatchMarkAsDeleted(ctx, "user1", []string{"deleted"})
+	require.NoError(t, err)
+
+	// Устаревшие оценки: например, ссылки восстановлены до исправления множества
+	for _, code := range []string{"active", "expiring", "missing"} {
+		_, err := s.client.do(ctx, "ZADD", s.purgeKey(), 1, code)
+		require.NoError(t, err)
+	}
+
+	purged, err := s.PurgeURLs(ctx, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	// Кандидаты, которые удалять рано, больше не попадают в окно очистки
+	_, queued := purgeScoreOf(t, s, "active")
+	assert.False(t, queued)
+	_, queued = purgeScoreOf(t, s, "missing")
+	assert.False(t, queued)
+	score, queued := purgeScoreOf(t, s, "expiring")
+	assert.True(t, queued)
+	assert.Equal(t, timeScore(expires), score)
+
+	purged, err = s.PurgeURLs(ctx, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	_, err = s.GetRedirectURL(ctx, "active")
+	assert.NoError(t, err)
+	_, err = s.GetRedirectURL(ctx, "deleted")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	// Пользователь без ссылок исключается из статистики
+	_, err = s.BatchMarkAsDeleted(ctx, "user1", []string{"active", "expiring"})
+	require.NoError(t, err)
+	purged, err = s.PurgeURLs(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	users, err := s.CountUsers(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, users)
+}
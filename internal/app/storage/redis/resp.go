@@ -0,0 +1,495 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Параметры подключения по умолчанию.
+const (
+	defaultPoolSize = 16
+	defaultTimeout  = 5 * time.Second
+	maxTxAttempts   = 16 // Попыток оптимистичной транзакции при конкурирующих изменениях
+)
+
+// redisError - ответ сервера с ошибкой ("-ERR ...").
+// В отличие от сетевых ошибок, не делает соединение непригодным.
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// errNil - ответ сервера без значения (null bulk string или null array).
+var errNil = errors.New("redis: nil reply")
+
+// errTxAborted - EXEC отменён: отслеживаемый ключ изменён другим клиентом.
+var errTxAborted = errors.New("redis: transaction aborted by concurrent change")
+
+// client - минимальный клиент протокола RESP2 с пулом соединений.
+//
+// Ответы сервера представлены значениями Go:
+// - простая строка: string
+// - целое число: int64
+// - строка (bulk string): []byte, nil для отсутствующего значения
+// - массив: []any, nil для отсутствующего значения
+// - ошибка: redisError
+type client struct {
+	addr     string
+	username string
+	password string
+	db       int
+	timeout  time.Duration
+	pool     chan *conn
+}
+
+// conn - соединение с сервером.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// newClient создает клиента по адресу вида redis://[user:password@]host:port[/db].
+// Соединения устанавливаются при первом запросе.
+func newClient(rawURL string, poolSize int, timeout time.Duration) (*client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("invalid redis url scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("invalid redis url: host is empty")
+	}
+
+	c := &client{
+		addr:    u.Host,
+		timeout: timeout,
+		pool:    make(chan *conn, poolSize),
+	}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+
+	return c, nil
+}
+
+// do выполняет команду и возвращает ответ. Ответ-ошибка возвращается как error.
+func (c *client) do(ctx context.Context, args ...any) (any, error) {
+	replies, err := c.pipeline(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := replies[0].(redisError); ok {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// pipeline отправляет команды одним пакетом и возвращает ответы в том же порядке.
+// Ответы-ошибки отдельных команд возвращаются в срезе как redisError.
+func (c *client) pipeline(ctx context.Context, cmds ...[]any) ([]any, error) {
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := cn.roundTrip(ctx, c.timeout, cmds)
+	if err != nil {
+		// После сетевой ошибки или ошибки протокола состояние соединения неизвестно
+		cn.Close()
+		return nil, err
+	}
+
+	c.put(cn)
+	return replies, nil
+}
+
+// tx - соединение, закреплённое за оптимистичной транзакцией.
+type tx struct {
+	ctx     context.Context
+	cn      *conn
+	timeout time.Duration
+	broken  bool // После сетевой ошибки соединение не возвращается в пул
+}
+
+// do выполняет команду на соединении транзакции. Ответ-ошибка возвращается как error.
+func (t *tx) do(args ...any) (any, error) {
+	replies, err := t.pipeline(args)
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := replies[0].(redisError); ok {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// pipeline отправляет команды на соединении транзакции одним пакетом.
+func (t *tx) pipeline(cmds ...[]any) ([]any, error) {
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	replies, err := t.cn.roundTrip(t.ctx, t.timeout, cmds)
+	if err != nil {
+		t.broken = true
+	}
+	return replies, err
+}
+
+// transaction выполняет оптимистичную транзакцию.
+//
+// Ключи keys отслеживаются командой WATCH, после чего fn читает текущие
+// значения через tx и возвращает команды изменения. Команды выполняются
+// атомарно (MULTI/EXEC) и только если ни один отслеживаемый ключ не изменён
+// другим клиентом после WATCH; иначе транзакция повторяется целиком
+// (не более maxTxAttempts раз). Если fn возвращает ошибку или не возвращает
+// команд, транзакция не выполняется.
+//
+// Возвращает ответы на команды fn в том же порядке.
+func (c *client) transaction(ctx context.Context, keys []string, fn func(t *tx) ([][]any, error)) ([]any, error) {
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		replies, err := c.tryTransaction(ctx, keys, fn)
+		if !errors.Is(err, errTxAborted) {
+			return replies, err
+		}
+	}
+	return nil, errTxAborted
+}
+
+// tryTransaction выполняет одну попытку транзакции.
+func (c *client) tryTransaction(ctx context.Context, keys []string, fn func(t *tx) ([][]any, error)) ([]any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t := &tx{ctx: ctx, cn: cn, timeout: c.timeout}
+	defer func() {
+		if t.broken {
+			cn.Close()
+			return
+		}
+		c.put(cn)
+	}()
+
+	watch := make([]any, 0, len(keys)+1)
+	watch = append(watch, "WATCH")
+	for _, key := range keys {
+		watch = append(watch, key)
+	}
+	if _, err := t.do(watch...); err != nil {
+		return nil, err
+	}
+
+	cmds, err := fn(t)
+	if err != nil || len(cmds) == 0 {
+		// Соединение возвращается в пул без отслеживаемых ключей
+		if !t.broken {
+			if _, unwatchErr := t.do("UNWATCH"); unwatchErr != nil {
+				t.broken = true
+			}
+		}
+		return nil, err
+	}
+
+	batch := make([][]any, 0, len(cmds)+2)
+	batch = append(batch, []any{"MULTI"})
+	batch = append(batch, cmds...)
+	batch = append(batch, []any{"EXEC"})
+	replies, err := t.pipeline(batch...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch exec := replies[len(replies)-1].(type) {
+	case redisError:
+		// Команда отклонена при постановке в очередь (EXECABORT)
+		if err := firstError(replies[:len(replies)-1]); err != nil {
+			return nil, err
+		}
+		return nil, exec
+	case []any:
+		if exec == nil {
+			return nil, errTxAborted
+		}
+		return exec, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected EXEC reply %T", exec)
+	}
+}
+
+// get возвращает свободное соединение из пула или устанавливает новое.
+func (c *client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	var handshake [][]any
+	if c.password != "" {
+		if c.username != "" {
+			handshake = append(handshake, []any{"AUTH", c.username, c.password})
+		} else {
+			handshake = append(handshake, []any{"AUTH", c.password})
+		}
+	}
+	if c.db != 0 {
+		handshake = append(handshake, []any{"SELECT", c.db})
+	}
+	if len(handshake) > 0 {
+		replies, err := cn.roundTrip(ctx, c.timeout, handshake)
+		if err == nil {
+			err = firstError(replies)
+		}
+		if err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+// put возвращает соединение в пул (или закрывает, если пул заполнен).
+func (c *client) put(cn *conn) {
+	select {
+	case c.pool <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+// close закрывает свободные соединения пула.
+func (c *client) close() error {
+	for {
+		select {
+		case cn := <-c.pool:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// roundTrip записывает команды и читает ответы на них.
+func (cn *conn) roundTrip(ctx context.Context, timeout time.Duration, cmds [][]any) ([]any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	for _, args := range cmds {
+		if err := writeCommand(cn.w, args); err != nil {
+			return nil, err
+		}
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]any, len(cmds))
+	for i := range replies {
+		reply, err := readReply(cn.r)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+
+	return replies, nil
+}
+
+// writeCommand сериализует команду в массив строк RESP.
+func writeCommand(w *bufio.Writer, args []any) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case uint64:
+			s = strconv.FormatUint(v, 10)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Errorf("redis: unsupported argument type %T", arg)
+		}
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+	}
+	return nil
+}
+
+// readReply читает один ответ сервера.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	payload := string(line[1:])
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return redisError(payload), nil
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", payload)
+		}
+		if n < 0 {
+			return []byte(nil), nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", payload)
+		}
+		if n < 0 {
+			return []any(nil), nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", line[0])
+	}
+}
+
+// readLine читает строку протокола без завершающего CRLF.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply line")
+	}
+	return line[:len(line)-2], nil
+}
+
+// firstError возвращает первый ответ-ошибку пакета.
+func firstError(replies []any) error {
+	for _, reply := range replies {
+		if err, ok := reply.(redisError); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// Преобразование ответов сервера.
+
+// asInt возвращает целочисленный ответ.
+func asInt(reply any, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case []byte:
+		if v == nil {
+			return 0, errNil
+		}
+		return strconv.ParseInt(string(v), 10, 64)
+	case redisError:
+		return 0, v
+	default:
+		return 0, fmt.Errorf("redis: unexpected reply %T, expected integer", reply)
+	}
+}
+
+// asBytes возвращает строковый ответ или errNil для отсутствующего значения.
+func asBytes(reply any, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch v := reply.(type) {
+	case []byte:
+		if v == nil {
+			return nil, errNil
+		}
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case redisError:
+		return nil, v
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %T, expected string", reply)
+	}
+}
+
+// asStrings возвращает ответ-массив строк; отсутствующие элементы становятся пустыми строками.
+func asStrings(reply any, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch v := reply.(type) {
+	case []any:
+		result := make([]string, len(v))
+		for i, item := range v {
+			if b, ok := item.([]byte); ok {
+				result[i] = string(b)
+			}
+		}
+		return result, nil
+	case redisError:
+		return nil, v
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %T, expected array", reply)
+	}
+}
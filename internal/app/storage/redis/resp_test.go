@@ -0,0 +1,177 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCommand(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+
+	err := writeCommand(w, []any{"SET", []byte("key"), 42, int64(-7), uint64(8), 1.5, ""})
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t,
+		"*7\r\n$3\r\nSET\r\n$3\r\nkey\r\n$2\r\n42\r\n$2\r\n-7\r\n$1\r\n8\r\n$3\r\n1.5\r\n$0\r\n\r\n",
+		buf.String())
+
+	assert.Error(t, writeCommand(w, []any{"SET", struct{}{}}))
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  any
+	}{
+		{name: "simple string", input: "+OK\r\n", want: "OK"},
+		{name: "error", input: "-ERR unknown command\r\n", want: redisError("ERR unknown command")},
+		{name: "integer", input: ":-42\r\n", want: int64(-42)},
+		{name: "bulk string", input: "$5\r\nhello\r\n", want: []byte("hello")},
+		{name: "bulk string with CRLF inside", input: "$4\r\na\r\nb\r\n", want: []byte("a\r\nb")},
+		{name: "empty bulk string", input: "$0\r\n\r\n", want: []byte{}},
+		{name: "nil bulk string", input: "$-1\r\n", want: []byte(nil)},
+		{name: "nil array", input: "*-1\r\n", want: []any(nil)},
+		{name: "empty array", input: "*0\r\n", want: []any{}},
+		{
+			name:  "nested array",
+			input: "*4\r\n$3\r\nfoo\r\n$-1\r\n:1\r\n*2\r\n+OK\r\n-ERR bad\r\n",
+			want:  []any{[]byte("foo"), []byte(nil), int64(1), []any{"OK", redisError("ERR bad")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := readReply(bufio.NewReader(strings.NewReader(tt.input)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, reply)
+
+			// Ответ, поступающий по одному байту, разбирается так же
+			reply, err = readReply(bufio.NewReader(iotest.OneByteReader(strings.NewReader(tt.input))))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, reply)
+		})
+	}
+}
+
+func TestReadReply_Malformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty input", input: ""},
+		{name: "line without CRLF", input: "+OK"},
+		{name: "line without CR", input: "+OK\n"},
+		{name: "empty line", input: "\r\n"},
+		{name: "unknown type", input: "!oops\r\n"},
+		{name: "invalid integer", input: ":abc\r\n"},
+		{name: "invalid bulk length", input: "$x\r\n"},
+		{name: "truncated bulk string", input: "$5\r\nhel"},
+		{name: "invalid array length", input: "*x\r\n"},
+		{name: "truncated array", input: "*2\r\n+OK\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readReply(bufio.NewReader(strings.NewReader(tt.input)))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("truncated bulk string reports unexpected EOF", func(t *testing.T) {
+		_, err := readReply(bufio.NewReader(strings.NewReader("$5\r\nhel")))
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
+
+func TestReplyConversions(t *testing.T) {
+	failure := errors.New("connection reset")
+
+	n, err := asInt(int64(3), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	n, err = asInt([]byte("12"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), n)
+	_, err = asInt([]byte(nil), nil)
+	assert.ErrorIs(t, err, errNil)
+	_, err = asInt(redisError("WRONGTYPE"), nil)
+	assert.Equal(t, redisError("WRONGTYPE"), err)
+	_, err = asInt(nil, failure)
+	assert.ErrorIs(t, err, failure)
+
+	b, err := asBytes("OK", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("OK"), b)
+	_, err = asBytes([]byte(nil), nil)
+	assert.ErrorIs(t, err, errNil)
+	_, err = asBytes(int64(1), nil)
+	assert.Error(t, err)
+
+	s, err := asStrings([]any{[]byte("a"), []byte(nil), []byte("c")}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "", "c"}, s)
+	_, err = asStrings("OK", nil)
+	assert.Error(t, err)
+}
+
+func TestConnRoundTrip(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// Сервер проверяет пакет команд и отвечает частями
+	go func() {
+		r := bufio.NewReader(server)
+		for i := 0; i < 2; i++ {
+			if _, err := readReply(r); err != nil {
+				return
+			}
+		}
+		for _, part := range []string{"+PO", "NG\r\n-ERR wr", "ong\r\n"} {
+			if _, err := server.Write([]byte(part)); err != nil {
+				return
+			}
+		}
+	}()
+
+	cn := &conn{Conn: client, r: bufio.NewReader(client), w: bufio.NewWriter(client)}
+	replies, err := cn.roundTrip(context.Background(), time.Second, [][]any{{"PING"}, {"GET", "key"}})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"PONG", redisError("ERR wrong")}, replies)
+	assert.Equal(t, redisError("ERR wrong"), firstError(replies))
+}
+
+func TestConnRoundTrip_Timeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// Сервер читает команду, но отвечает не полностью
+	go func() {
+		if _, err := readReply(bufio.NewReader(server)); err != nil {
+			return
+		}
+		_, _ = server.Write([]byte("$5\r\nhe"))
+	}()
+
+	cn := &conn{Conn: client, r: bufio.NewReader(client), w: bufio.NewWriter(client)}
+	_, err := cn.roundTrip(context.Background(), 100*time.Millisecond, [][]any{{"GET", "key"}})
+
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+}
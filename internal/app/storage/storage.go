@@ -1,7 +1,10 @@
 // Package storage определяет интерфейсы хранилища и общие ошибки для работы с URL.
 package storage
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Общие ошибки хранилища URL
 var (
@@ -18,4 +21,23 @@ var (
 
 	// ErrURLDeleted возвращается при попытке доступа к URL, помеченному как удаленный.
 	ErrURLDeleted = errors.New("URL has been deleted")
+
+	// ErrURLExpired возвращается при попытке доступа к URL, срок действия которого истёк.
+	ErrURLExpired = errors.New("URL has expired")
 )
+
+// BatchConflictError возвращается при частичном сохранении пакета URL:
+// строки без конфликтов сохранены, конфликтующие перечислены в Conflicts.
+//
+// Хранилища, сохраняющие пакет атомарно, вместо неё возвращают ErrShortURLExists.
+type BatchConflictError struct {
+	// Conflicts - ошибки по индексам строк пакета:
+	//   - ErrShortURLExists: короткий ключ занят, строка не сохранена
+	//   - ErrURLExists: URL уже сокращён пользователем, в строку записан существующий ключ
+	Conflicts map[int]error
+}
+
+// Error возвращает описание ошибки.
+func (e *BatchConflictError) Error() string {
+	return fmt.Sprintf("batch conflicts: %d rows", len(e.Conflicts))
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// ErrInvalidCursor возвращается, если курсор страницы списка URL не удалось разобрать.
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// UserURLsCursor - позиция последней выданной записи в списке URL пользователя.
+//
+// Записи упорядочены по моменту создания, затем по ID (порядковому номеру
+// записи в хранилище, если он есть) и короткому коду.
+type UserURLsCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint64    `json:"i,omitempty"`
+	Code      string    `json:"c"`
+}
+
+// Encode возвращает курсор в виде непрозрачной строки для клиента.
+func (c UserURLsCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeUserURLsCursor разбирает курсор, полученный от клиента.
+// Возвращает ErrInvalidCursor, если строка не является курсором.
+func DecodeUserURLsCursor(s string) (UserURLsCursor, error) {
+	var c UserURLsCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Code == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// less сравнивает позиции записей в порядке возрастания.
+func (c UserURLsCursor) less(other UserURLsCursor) bool {
+	if !c.CreatedAt.Equal(other.CreatedAt) {
+		return c.CreatedAt.Before(other.CreatedAt)
+	}
+	if c.ID != other.ID {
+		return c.ID < other.ID
+	}
+	return c.Code < other.Code
+}
+
+// mappingCursor возвращает позицию записи. Записи без момента создания
+// (созданные до его появления) идут первыми.
+func mappingCursor(m models.UserURLMapping) UserURLsCursor {
+	c := UserURLsCursor{ID: m.UUID, Code: m.ShortURL}
+	if m.CreatedAt != nil {
+		c.CreatedAt = *m.CreatedAt
+	}
+	return c
+}
+
+// PageUserURLs отбирает, сортирует и разбивает на страницы записи пользователя.
+//
+// Используется хранилищами без индекса по моменту создания: они загружают
+// все записи пользователя и формируют страницу в памяти.
+//
+// Параметры:
+//
+//	records - все записи пользователя
+//	baseURL - базовый адрес для построения полных коротких URL
+//	query - фильтры, порядок и страница
+//
+// Возвращает:
+//
+//	models.UserURLsPage - страница списка
+//	error - ErrInvalidCursor, если курсор не удалось разобрать
+func PageUserURLs(records []models.UserURLMapping, baseURL string, query models.UserURLsQuery) (models.UserURLsPage, error) {
+	var (
+		page   models.UserURLsPage
+		after  UserURLsCursor
+		desc   = query.Order == models.SortDesc
+		search = strings.ToLower(query.Search)
+	)
+	if query.Cursor != "" {
+		var err error
+		if after, err = DecodeUserURLsCursor(query.Cursor); err != nil {
+			return page, err
+		}
+	}
+
+	selected := make([]models.UserURLMapping, 0, len(records))
+	for _, record := range records {
+		pos := mappingCursor(record)
+		switch {
+		case search != "" && !strings.Contains(strings.ToLower(record.OriginalURL), search):
+			continue
+		case query.CreatedFrom != nil && pos.CreatedAt.Before(*query.CreatedFrom):
+			continue
+		case query.CreatedTo != nil && !pos.CreatedAt.Before(*query.CreatedTo):
+			continue
+		case query.Cursor != "" && !desc && !after.less(pos):
+			continue
+		case query.Cursor != "" && desc && !pos.less(after):
+			continue
+		}
+		selected = append(selected, record)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		if desc {
+			return mappingCursor(selected[j]).less(mappingCursor(selected[i]))
+		}
+		return mappingCursor(selected[i]).less(mappingCursor(selected[j]))
+	})
+
+	if query.Limit > 0 && len(selected) > query.Limit {
+		selected = selected[:query.Limit]
+		page.NextCursor = mappingCursor(selected[len(selected)-1]).Encode()
+	}
+
+	page.URLs = make([]models.URLMapping, 0, len(selected))
+	for _, record := range selected {
+		page.URLs = append(page.URLs, models.URLMapping{
+			ShortURL:    baseURL + "/" + record.ShortURL,
+			OriginalURL: record.OriginalURL,
+			ExpiresAt:   record.ExpiresAt,
+			CreatedAt:   record.CreatedAt,
+		})
+	}
+	return page, nil
+}
@@ -0,0 +1,186 @@
+// Package clickstats предоставляет асинхронный регистратор переходов по коротким ссылкам.
+//
+// Пакет реализует:
+// - Неблокирующую постановку событий перехода в буферизованную очередь
+// - Накопление событий в пакеты по размеру или по времени
+// - Запись пакетов в хранилище в отдельной горутине
+// - Отбрасывание событий при переполнении очереди (без задержки редиректа)
+// - Поддержку плавного завершения работы с записью накопленных событий
+package clickstats
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// Repository определяет интерфейс хранилища, необходимый для работы Recorder.
+type Repository interface {
+	// RecordClicks сохраняет пакет событий перехода, обновляя агрегаты по ссылкам.
+	// События для несуществующих ссылок пропускаются.
+	RecordClicks(ctx context.Context, events []models.ClickEvent) error
+}
+
+// Stats содержит счётчики работы Recorder.
+type Stats struct {
+	Recorded uint64 // Количество событий, записанных в хранилище
+	Dropped  uint64 // Количество событий, отброшенных из-за переполнения очереди
+	Errors   uint64 // Количество ошибок записи пакетов в хранилище
+}
+
+// Recorder накапливает события переходов и асинхронно записывает их в хранилище.
+type Recorder struct {
+	repo          Repository
+	eventChan     chan models.ClickEvent
+	stopChan      chan struct{}
+	wg            sync.WaitGroup
+	batchSize     int
+	flushInterval time.Duration
+	log           *zap.Logger
+
+	recorded atomic.Uint64
+	dropped  atomic.Uint64
+	errors   atomic.Uint64
+}
+
+// Option задаёт дополнительный параметр Recorder.
+type Option func(*Recorder)
+
+// WithLogger задаёт логгер регистратора. По умолчанию события не логируются.
+func WithLogger(log *zap.Logger) Option {
+	return func(r *Recorder) {
+		r.log = log
+	}
+}
+
+// NewRecorder создает новый экземпляр Recorder с заданными параметрами.
+//
+// Параметры:
+//   - bufferSize: ёмкость очереди событий
+//   - batchSize: максимальный размер пакета перед записью
+//   - flushInterval: максимальное время накопления пакета
+//   - storage: реализация интерфейса Repository
+//   - opts: дополнительные параметры (например, WithLogger)
+func NewRecorder(bufferSize, batchSize int, flushInterval time.Duration, storage Repository, opts ...Option) *Recorder {
+	r := &Recorder{
+		repo:          storage,
+		eventChan:     make(chan models.ClickEvent, bufferSize),
+		stopChan:      make(chan struct{}),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		log:           zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start запускает сборщик пакетов в отдельной горутине.
+func (r *Recorder) Start() {
+	r.wg.Add(1)
+	go r.collector()
+}
+
+// Record ставит событие в очередь на запись.
+// Никогда не блокирует вызывающего: при переполнении очереди событие отбрасывается
+// и метод возвращает false.
+func (r *Recorder) Record(event models.ClickEvent) bool {
+	select {
+	case r.eventChan <- event:
+		return true
+	default:
+		r.dropped.Add(1)
+		return false
+	}
+}
+
+// Stats возвращает текущие значения счётчиков.
+func (r *Recorder) Stats() Stats {
+	return Stats{
+		Recorded: r.recorded.Load(),
+		Dropped:  r.dropped.Load(),
+		Errors:   r.errors.Load(),
+	}
+}
+
+// collector собирает события в пакеты и записывает их
+// при достижении batchSize или по истечении flushInterval.
+func (r *Recorder) collector() {
+	defer r.wg.Done()
+
+	batch := make([]models.ClickEvent, 0, r.batchSize)
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopChan:
+			// Забираем события, успевшие попасть в очередь до остановки
+			for {
+				select {
+				case event := <-r.eventChan:
+					batch = append(batch, event)
+					if len(batch) >= r.batchSize {
+						batch = r.flush(batch)
+					}
+				default:
+					r.flush(batch)
+					return
+				}
+			}
+
+		case event := <-r.eventChan:
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize {
+				batch = r.flush(batch)
+				ticker.Reset(r.flushInterval)
+			}
+
+		case <-ticker.C:
+			batch = r.flush(batch)
+		}
+	}
+}
+
+// flush записывает пакет в хранилище и возвращает пустой срез для следующего пакета.
+func (r *Recorder) flush(batch []models.ClickEvent) []models.ClickEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := r.repo.RecordClicks(context.Background(), batch); err != nil {
+		r.errors.Add(1)
+		r.log.Error("Failed to record click stats",
+			zap.Int("events", len(batch)),
+			zap.Error(err))
+	} else {
+		r.recorded.Add(uint64(len(batch)))
+	}
+
+	return make([]models.ClickEvent, 0, r.batchSize)
+}
+
+// GracefulStop выполняет плавное завершение работы с заданным таймаутом.
+// Дожидается записи накопленных событий или истечения таймаута.
+func (r *Recorder) GracefulStop(timeout time.Duration) {
+	close(r.stopChan)
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.log.Info("Click recorder stopped")
+	case <-time.After(timeout):
+		r.log.Warn("Timed out waiting for click recorder to stop")
+	}
+}
@@ -0,0 +1,208 @@
+package clickstats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// stubRepository запоминает записанные пакеты событий. Пока err не nil,
+// запись завершается ошибкой; пока не закрыт канал block, запись блокируется.
+type stubRepository struct {
+	mu      sync.Mutex
+	err     error
+	block   chan struct{}
+	batches [][]models.ClickEvent
+}
+
+func (r *stubRepository) RecordClicks(_ context.Context, events []models.ClickEvent) error {
+	if r.block != nil {
+		<-r.block
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, append([]models.ClickEvent(nil), events...))
+	return nil
+}
+
+// batchSizes возвращает размеры записанных пакетов.
+func (r *stubRepository) batchSizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sizes := make([]int, 0, len(r.batches))
+	for _, batch := range r.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+// shortURLs возвращает коды ссылок записанных событий в порядке записи.
+func (r *stubRepository) shortURLs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var codes []string
+	for _, batch := range r.batches {
+		for _, event := range batch {
+			codes = append(codes, event.ShortURL)
+		}
+	}
+	return codes
+}
+
+// recordEvents ставит в очередь n событий с кодами prefix-0 ... prefix-(n-1).
+func recordEvents(t *testing.T, r *Recorder, prefix string, n int) []string {
+	t.Helper()
+
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		code := fmt.Sprintf("%s-%d", prefix, i)
+		require.True(t, r.Record(models.ClickEvent{ShortURL: code, At: time.Now()}), code)
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func TestRecorder_DropsWhenBufferIsFull(t *testing.T) {
+	repo := &stubRepository{}
+	// Сборщик не запущен, поэтому события из очереди никто не забирает
+	r := NewRecorder(3, 10, time.Hour, repo)
+
+	want := recordEvents(t, r, "kept", 3)
+	for i := 0; i < 2; i++ {
+		assert.False(t, r.Record(models.ClickEvent{ShortURL: "dropped"}))
+	}
+	assert.Equal(t, Stats{Dropped: 2}, r.Stats())
+
+	// Отброшенные события не записываются, принятые - записываются
+	r.Start()
+	r.GracefulStop(time.Second)
+
+	assert.Equal(t, want, repo.shortURLs())
+	assert.Equal(t, Stats{Recorded: 3, Dropped: 2}, r.Stats())
+}
+
+func TestRecorder_Flush(t *testing.T) {
+	t.Run("on batch size", func(t *testing.T) {
+		repo := &stubRepository{}
+		r := NewRecorder(100, 3, time.Hour, repo)
+		r.Start()
+		defer r.GracefulStop(time.Second)
+
+		want := recordEvents(t, r, "code", 7)
+
+		// Полные пакеты записываются сразу, не дожидаясь интервала
+		require.Eventually(t, func() bool { return r.Stats().Recorded == 6 }, time.Second, time.Millisecond)
+		assert.Equal(t, []int{3, 3}, repo.batchSizes())
+		assert.Equal(t, want[:6], repo.shortURLs())
+	})
+
+	t.Run("on interval", func(t *testing.T) {
+		repo := &stubRepository{}
+		r := NewRecorder(100, 100, 20*time.Millisecond, repo)
+		r.Start()
+		defer r.GracefulStop(time.Second)
+
+		want := recordEvents(t, r, "code", 2)
+
+		// Неполный пакет записывается по истечении интервала
+		require.Eventually(t, func() bool { return r.Stats().Recorded == 2 }, time.Second, time.Millisecond)
+		assert.Equal(t, []int{2}, repo.batchSizes())
+		assert.Equal(t, want, repo.shortURLs())
+
+		want = append(want, recordEvents(t, r, "next", 1)...)
+		require.Eventually(t, func() bool { return r.Stats().Recorded == 3 }, time.Second, time.Millisecond)
+		assert.Equal(t, []int{2, 1}, repo.batchSizes())
+		assert.Equal(t, want, repo.shortURLs())
+	})
+}
+
+func TestRecorder_GracefulStop(t *testing.T) {
+	t.Run("drains queued events", func(t *testing.T) {
+		// Сборщик может увидеть остановку раньше событий в очереди
+		for i := 0; i < 20; i++ {
+			repo := &stubRepository{}
+			r := NewRecorder(100, 4, time.Hour, repo)
+
+			want := recordEvents(t, r, fmt.Sprint(i), 10)
+
+			r.Start()
+			r.GracefulStop(time.Second)
+
+			require.Equal(t, want, repo.shortURLs())
+			// Очередь дочитывается пакетами не больше batchSize
+			assert.Equal(t, []int{4, 4, 2}, repo.batchSizes())
+			assert.Equal(t, Stats{Recorded: 10}, r.Stats())
+		}
+	})
+
+	t.Run("flushes partial batch", func(t *testing.T) {
+		repo := &stubRepository{}
+		r := NewRecorder(100, 100, time.Hour, repo)
+		r.Start()
+
+		want := recordEvents(t, r, "code", 3)
+		r.GracefulStop(time.Second)
+
+		assert.Equal(t, want, repo.shortURLs())
+		assert.Equal(t, []int{3}, repo.batchSizes())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		repo := &stubRepository{block: make(chan struct{})}
+		defer close(repo.block)
+
+		core, logs := observer.New(zap.WarnLevel)
+		r := NewRecorder(100, 1, time.Hour, repo, WithLogger(zap.New(core)))
+		r.Start()
+		recordEvents(t, r, "code", 1)
+
+		// Запись в хранилище не завершается - остановка ограничена таймаутом
+		start := time.Now()
+		r.GracefulStop(20 * time.Millisecond)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, 1, logs.FilterMessage("Timed out waiting for click recorder to stop").Len())
+	})
+}
+
+func TestRecorder_Errors(t *testing.T) {
+	failure := errors.New("storage unavailable")
+	repo := &stubRepository{err: failure}
+	core, logs := observer.New(zap.ErrorLevel)
+	r := NewRecorder(100, 2, time.Hour, repo, WithLogger(zap.New(core)))
+	r.Start()
+
+	recordEvents(t, r, "failed", 4)
+	require.Eventually(t, func() bool { return r.Stats().Errors == 2 }, time.Second, time.Millisecond)
+
+	// Хранилище снова доступно: следующие пакеты записываются
+	repo.mu.Lock()
+	repo.err = nil
+	repo.mu.Unlock()
+	want := recordEvents(t, r, "recorded", 3)
+	r.GracefulStop(time.Second)
+
+	// Ошибка считается на пакет, события неудачного пакета не учитываются как записанные
+	assert.Equal(t, Stats{Recorded: 3, Errors: 2}, r.Stats())
+	assert.Equal(t, want, repo.shortURLs())
+
+	entries := logs.FilterMessage("Failed to record click stats").All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, failure.Error(), entries[0].ContextMap()["error"])
+	assert.EqualValues(t, 2, entries[0].ContextMap()["events"])
+}
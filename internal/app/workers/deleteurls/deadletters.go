@@ -0,0 +1,86 @@
+package deleteurls
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// DeadLetterStore хранит задачи удаления, не выполненные после всех повторных попыток.
+type DeadLetterStore interface {
+	// Add сохраняет задачу, присваивая ей идентификатор.
+	Add(ctx context.Context, letter models.DeadLetter) error
+	// List возвращает сохранённые задачи в порядке поступления.
+	List(ctx context.Context) ([]models.DeadLetter, error)
+	// Take удаляет и возвращает задачи с указанными идентификаторами
+	// (все задачи, если ids пуст).
+	Take(ctx context.Context, ids []int64) ([]models.DeadLetter, error)
+}
+
+// MemoryDeadLetters - DeadLetterStore в памяти процесса с ограничением размера.
+//
+// При переполнении вытесняются самые старые записи. Задачи постоянной очереди
+// при этом не теряются: они не подтверждены и будут повторены после перезапуска.
+type MemoryDeadLetters struct {
+	mu      sync.Mutex
+	limit   int
+	nextID  int64
+	letters []models.DeadLetter
+}
+
+// NewMemoryDeadLetters создает хранилище не более чем на limit записей.
+func NewMemoryDeadLetters(limit int) *MemoryDeadLetters {
+	return &MemoryDeadLetters{limit: limit, nextID: 1}
+}
+
+// Add сохраняет задачу, вытесняя самую старую запись при переполнении.
+func (m *MemoryDeadLetters) Add(ctx context.Context, letter models.DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	letter.ID = m.nextID
+	m.nextID++
+
+	m.letters = append(m.letters, letter)
+	if len(m.letters) > m.limit {
+		m.letters = append(m.letters[:0], m.letters[len(m.letters)-m.limit:]...)
+	}
+	return nil
+}
+
+// List возвращает копию сохранённых задач.
+func (m *MemoryDeadLetters) List(ctx context.Context) ([]models.DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.DeadLetter(nil), m.letters...), nil
+}
+
+// Take удаляет и возвращает задачи с указанными идентификаторами.
+func (m *MemoryDeadLetters) Take(ctx context.Context, ids []int64) ([]models.DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(ids) == 0 {
+		taken := m.letters
+		m.letters = nil
+		return taken, nil
+	}
+
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var taken []models.DeadLetter
+	kept := m.letters[:0]
+	for _, letter := range m.letters {
+		if wanted[letter.ID] {
+			taken = append(taken, letter)
+		} else {
+			kept = append(kept, letter)
+		}
+	}
+	m.letters = kept
+	return taken, nil
+}
@@ -0,0 +1,153 @@
+package deleteurls
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// letterIDs возвращает идентификаторы записей в порядке следования.
+func letterIDs(letters []models.DeadLetter) []int64 {
+	ids := []int64{}
+	for _, letter := range letters {
+		ids = append(ids, letter.ID)
+	}
+	return ids
+}
+
+func TestMemoryDeadLetters(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("list in order of arrival", func(t *testing.T) {
+		store := NewMemoryDeadLetters(10)
+		for _, user := range []string{"user1", "user2", "user3"} {
+			require.NoError(t, store.Add(ctx, models.DeadLetter{UserID: user, ShortURLs: []string{"a"}}))
+		}
+
+		letters, err := store.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, letterIDs(letters))
+		assert.Equal(t, "user2", letters[1].UserID)
+
+		// List возвращает копию
+		letters[0].UserID = "changed"
+		letters, err = store.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "user1", letters[0].UserID)
+	})
+
+	t.Run("oldest letters are evicted", func(t *testing.T) {
+		store := NewMemoryDeadLetters(2)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, store.Add(ctx, models.DeadLetter{UserID: "user1"}))
+		}
+
+		letters, err := store.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{4, 5}, letterIDs(letters))
+	})
+
+	t.Run("take selected letters", func(t *testing.T) {
+		store := NewMemoryDeadLetters(10)
+		for i := 0; i < 4; i++ {
+			require.NoError(t, store.Add(ctx, models.DeadLetter{UserID: "user1"}))
+		}
+
+		taken, err := store.Take(ctx, []int64{3, 1, 42})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 3}, letterIDs(taken))
+
+		letters, err := store.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2, 4}, letterIDs(letters))
+
+		// Идентификаторы не переиспользуются
+		require.NoError(t, store.Add(ctx, models.DeadLetter{UserID: "user1"}))
+		letters, err = store.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2, 4, 5}, letterIDs(letters))
+	})
+
+	t.Run("take all letters", func(t *testing.T) {
+		store := NewMemoryDeadLetters(10)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, store.Add(ctx, models.DeadLetter{UserID: "user1"}))
+		}
+
+		taken, err := store.Take(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, letterIDs(taken))
+
+		letters, err := store.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, letters)
+	})
+}
+
+func TestDeleteWorker_ReplayDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubRepository{failures: 100, err: errors.New("connection refused")}
+	dead := NewMemoryDeadLetters(10)
+	queue, err := OpenFileQueue(t.TempDir() + "/delete.journal")
+	require.NoError(t, err)
+	defer queue.Close()
+
+	w := NewDeleteWorker(1, 1, 10*time.Millisecond, repo,
+		WithRetryPolicy(fastRetry), WithDeadLetters(dead), WithQueue(queue))
+	w.Start()
+	defer w.GracefulStop(time.Second)
+
+	firstJob, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a"}})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return w.Stats().Dead == 1 }, time.Second, time.Millisecond)
+	_, err = w.Submit(DeleteTask{UserID: "user2", ShortURLs: []string{"b"}})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return w.Stats().Dead == 2 }, time.Second, time.Millisecond)
+
+	letters, err := w.DeadLetters(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, letterIDs(letters))
+
+	// Хранилище снова доступно: повторяется только выбранная задача
+	repo.setFailures(0)
+	n, err := w.ReplayDeadLetters(ctx, []int64{1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Eventually(t, func() bool { return len(repo.deletedURLs()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"a"}, repo.deletedURLs())
+
+	// Задание, ожидавшее кодов задачи, завершено
+	require.Eventually(t, func() bool {
+		job, ok := w.Job(firstJob)
+		return ok && job.Status == models.DeleteJobDone
+	}, time.Second, time.Millisecond)
+
+	letters, err = w.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, letterIDs(letters))
+
+	// В постоянной очереди осталась только задача, ожидающая в dead-letter
+	require.Eventually(t, func() bool {
+		pending, err := queue.Pending(ctx)
+		return err == nil && len(pending) == 1 && pending[0].UserID == "user2"
+	}, time.Second, time.Millisecond)
+
+	// Повтор всех оставшихся задач
+	n, err = w.ReplayDeadLetters(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Eventually(t, func() bool { return len(repo.deletedURLs()) == 2 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		pending, err := queue.Pending(ctx)
+		return err == nil && len(pending) == 0
+	}, time.Second, time.Millisecond)
+	letters, err = w.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+}
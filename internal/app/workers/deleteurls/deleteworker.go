@@ -2,128 +2,402 @@
 //
 // Пакет реализует паттерн "рабочий пул" с:
 // - Группировкой запросов по пользователям
-// - Настраиваемым размером пакета и временем ожидания
+// - Настраиваемым размером пакета (по количеству URL) и временем ожидания
+// - Ограниченной очередью задач: при её заполнении Submit возвращает ErrQueueFull
 // - Параллельной обработкой с помощью пула воркеров
-// - Поддержкой плавного завершения работы
+// - Поддержкой плавного завершения работы: после GracefulStop Submit возвращает ErrStopped
+// - Необязательной постоянной очередью задач (Queue), переживающей перезапуск
+// - Повторными попытками с экспоненциальной паузой и хранилищем невыполненных задач (dead-letter)
+// - Отслеживанием хода выполнения заданий на удаление по идентификатору
+// - Восстановлением мягко удалённых URL в пределах окна восстановления
 package deleteurls
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
 )
 
+// Параметры DeleteWorker по умолчанию.
+const (
+	defaultDeadLetterLimit = 1000  // Размер хранилища dead-letter
+	defaultQueueSize       = 10000 // Ёмкость очереди задач
+	defaultRestoreWindow   = 24 * time.Hour
+)
+
+// ErrQueueFull возвращается Submit, когда очередь задач заполнена.
+// Вызывающему следует повторить запрос позже.
+var ErrQueueFull = errors.New("delete queue is full")
+
+// ErrStopped возвращается Submit и ReplayDeadLetters после вызова GracefulStop.
+var ErrStopped = errors.New("delete worker is stopped")
+
 // Repository определяет интерфейс хранилища, необходимый для работы DeleteWorker.
 type Repository interface {
 	// BatchMarkAsDeleted помечает несколько URL как удаленные для указанного пользователя.
-	// Возвращает ошибку в случае неудачи.
-	BatchMarkAsDeleted(userID string, urls []string) error
+	// Возвращает удалённые, чужие и пропущенные коды или ошибку в случае неудачи.
+	BatchMarkAsDeleted(ctx context.Context, userID string, urls []string) (models.DeleteResult, error)
+	// BatchRestore снимает пометку удаления с URL пользователя, удалённых не раньше deletedAfter.
+	// Возвращает восстановленные, неудалённые, чужие и недоступные коды или ошибку в случае неудачи.
+	BatchRestore(ctx context.Context, userID string, urls []string, deletedAfter time.Time) (models.RestoreResult, error)
+}
+
+// Stats содержит счётчики работы DeleteWorker.
+type Stats struct {
+	Deleted  uint64 // Количество URL, помеченных удалёнными
+	NotOwned uint64 // Количество URL, не удалённых из-за принадлежности другому пользователю
+	Skipped  uint64 // Количество URL, которые не найдены или уже были удалены
+	Errors   uint64 // Количество ошибок при обращении к хранилищу
+	Retries  uint64 // Количество повторных попыток
+	Dead     uint64 // Количество задач, переданных в хранилище dead-letter
+	Restored uint64 // Количество восстановленных URL
 }
 
 // DeleteTask представляет запрос на удаление нескольких сокращенных URL для пользователя.
-type DeleteTask struct {
-	UserID    string   // ID пользователя, инициировавшего запрос
-	ShortURLs []string // Список сокращенных URL для пометки как удаленных
+type DeleteTask = models.DeleteTask
+
+// Queue определяет постоянную очередь задач удаления.
+//
+// Задача сохраняется в очереди при постановке и удаляется из неё только после
+// успешной пометки URL в хранилище. Неподтверждённые задачи (например, после
+// аварийного завершения или таймаута GracefulStop) обрабатываются повторно
+// при следующем запуске. Повторная пометка безопасна: уже удалённые URL пропускаются.
+type Queue interface {
+	// Enqueue сохраняет задачу и возвращает её идентификатор в очереди.
+	Enqueue(ctx context.Context, task DeleteTask) (int64, error)
+	// Ack удаляет обработанные задачи из очереди.
+	Ack(ctx context.Context, ids []int64) error
+	// Pending возвращает неподтверждённые задачи в порядке постановки.
+	// Очередь, общая для нескольких экземпляров сервиса, не должна возвращать
+	// задачи, которые обрабатывает другой работающий экземпляр.
+	Pending(ctx context.Context) ([]DeleteTask, error)
+}
+
+// Option задаёт дополнительные параметры DeleteWorker.
+type Option func(*DeleteWorker)
+
+// WithQueue включает постоянную очередь задач.
+func WithQueue(queue Queue) Option {
+	return func(w *DeleteWorker) {
+		w.queue = queue
+	}
+}
+
+// WithQueueSize задаёт ёмкость очереди задач. Значения меньше 1 игнорируются.
+func WithQueueSize(size int) Option {
+	return func(w *DeleteWorker) {
+		if size > 0 {
+			w.queueSize = size
+		}
+	}
+}
+
+// WithRetryPolicy задаёт повторные попытки при ошибках хранилища.
+// Количество попыток меньше 1 игнорируется.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(w *DeleteWorker) {
+		if policy.MaxAttempts > 0 {
+			w.retry = policy
+		}
+	}
+}
+
+// WithDeadLetters задаёт хранилище задач, не выполненных после всех попыток.
+// По умолчанию используется MemoryDeadLetters на 1000 записей.
+func WithDeadLetters(store DeadLetterStore) Option {
+	return func(w *DeleteWorker) {
+		w.deadLetters = store
+	}
+}
+
+// WithRestoreWindow задаёт окно восстановления: восстановить можно только URL,
+// удалённые не раньше чем window назад. Значения меньше или равные 0 игнорируются.
+func WithRestoreWindow(window time.Duration) Option {
+	return func(w *DeleteWorker) {
+		if window > 0 {
+			w.restoreWindow = window
+		}
+	}
+}
+
+// WithLogger задаёт логгер воркера. По умолчанию события не логируются.
+func WithLogger(log *zap.Logger) Option {
+	return func(w *DeleteWorker) {
+		w.log = log
+	}
+}
+
+// userOp - подряд идущие задачи пользователя одного вида.
+type userOp struct {
+	restore bool
+	urls    []string
+	ids     []int64 // Идентификаторы задач в постоянной очереди
+}
+
+// userBatch - накопленные задачи одного пользователя в порядке поступления.
+// Удаления и восстановления выполняются последовательно, чтобы
+// восстановление не опередило предшествующее ему удаление.
+type userBatch struct {
+	ops []*userOp
 }
 
 // DeleteWorker управляет жизненным циклом обработки удаления URL.
 // Агрегирует запросы в пакеты и обрабатывает их асинхронно.
 type DeleteWorker struct {
-	repo        Repository
-	taskChan    chan DeleteTask
-	batchChan   chan map[string][]string
-	stopChan    chan struct{}
-	wg          sync.WaitGroup
+	repo      Repository
+	queue     Queue // Постоянная очередь задач (nil - задачи хранятся только в памяти)
+	taskChan  chan DeleteTask
+	slots     chan struct{} // Места в taskChan, занятые отправителями до записи в канал
+	batchChan chan map[string]*userBatch
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+
+	// stopMu согласует постановку задач с остановкой: после установки stopped
+	// в taskChan больше не пишут, и сборщик может забрать из него все задачи
+	stopMu  sync.RWMutex
+	stopped bool
+
 	workerCount int
-	batchSize   int
+	batchSize   int // Количество URL, при котором пакет отправляется в обработку
+	queueSize   int
 	batchWindow time.Duration
+	retry       RetryPolicy
+	deadLetters DeadLetterStore
+	jobs        *jobTracker
+	log         *zap.Logger
+
+	// restoreWindow - окно восстановления удалённых URL
+	restoreWindow time.Duration
+
+	// ctx отменяется, если обработка не завершилась за время GracefulStop
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	deleted  atomic.Uint64
+	notOwned atomic.Uint64
+	skipped  atomic.Uint64
+	errors   atomic.Uint64
+	retries  atomic.Uint64
+	dead     atomic.Uint64
+	restored atomic.Uint64
 }
 
 // NewDeleteWorker создает новый экземпляр DeleteWorker с заданными параметрами.
 //
 // Параметры:
 //   - workerCount: количество воркеров для обработки пакетов
-//   - batchSize: максимальный размер пакета перед обработкой
+//   - batchSize: количество URL, при накоплении которого пакет отправляется в обработку
 //   - batchWindow: максимальное время ожидания формирования пакета
 //   - storage: реализация интерфейса Repository
-func NewDeleteWorker(workerCount, batchSize int, batchWindow time.Duration, storage Repository) *DeleteWorker {
-	return &DeleteWorker{
-		taskChan:    make(chan DeleteTask, 10000),
-		batchChan:   make(chan map[string][]string, 100),
+//   - opts: дополнительные параметры (например, WithQueue, WithRetryPolicy)
+func NewDeleteWorker(workerCount, batchSize int, batchWindow time.Duration, storage Repository, opts ...Option) *DeleteWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &DeleteWorker{
+		batchChan:   make(chan map[string]*userBatch, 100),
 		stopChan:    make(chan struct{}),
 		workerCount: workerCount,
 		batchSize:   batchSize,
 		batchWindow: batchWindow,
+		queueSize:   defaultQueueSize,
 		repo:        storage,
+		retry:       DefaultRetryPolicy,
+		deadLetters: NewMemoryDeadLetters(defaultDeadLetterLimit),
+		jobs:        newJobTracker(defaultJobLimit),
+		log:         zap.NewNop(),
+		ctx:         ctx,
+		cancel:      cancel,
+
+		restoreWindow: defaultRestoreWindow,
 	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.taskChan = make(chan DeleteTask, w.queueSize)
+	w.slots = make(chan struct{}, w.queueSize)
+	return w
 }
 
 // Start запускает воркеры и сборщик пакетов.
+// При наличии постоянной очереди неподтверждённые задачи обрабатываются первыми.
 func (w *DeleteWorker) Start() {
+	var pending []DeleteTask
+	if w.queue != nil {
+		var err error
+		if pending, err = w.queue.Pending(w.ctx); err != nil {
+			w.log.Error("Failed to read delete queue", zap.Error(err))
+		} else if len(pending) > 0 {
+			w.log.Info("Delete tasks restored from queue", zap.Int("count", len(pending)))
+		}
+	}
+
 	w.wg.Add(w.workerCount + 1) // +1 для сборщика пакетов
 
-	go w.batchCollector() // Запускаем сборщик пакетов
+	go w.batchCollector(pending) // Запускаем сборщик пакетов
 
 	for i := 0; i < w.workerCount; i++ {
 		go w.batchProcessor() // Запускаем воркеры
 	}
 }
 
-// Submit добавляет новую задачу на удаление в очередь обработки.
-// Возвращает ошибку если очередь переполнена.
-func (w *DeleteWorker) Submit(task DeleteTask) error {
+// Stats возвращает текущие значения счётчиков.
+func (w *DeleteWorker) Stats() Stats {
+	return Stats{
+		Deleted:  w.deleted.Load(),
+		NotOwned: w.notOwned.Load(),
+		Skipped:  w.skipped.Load(),
+		Errors:   w.errors.Load(),
+		Retries:  w.retries.Load(),
+		Dead:     w.dead.Load(),
+		Restored: w.restored.Load(),
+	}
+}
+
+// Submit добавляет новую задачу на удаление (или восстановление, если задан
+// task.Restore) в очередь обработки и возвращает идентификатор задания
+// для отслеживания хода выполнения (см. Job).
+// При наличии постоянной очереди задача сначала сохраняется в ней.
+// Возвращает ErrQueueFull, если очередь заполнена, ErrStopped после GracefulStop
+// или ошибку сохранения задачи.
+func (w *DeleteWorker) Submit(task DeleteTask) (string, error) {
+	jobID := uuid.NewString()
+	w.jobs.add(jobID, task.UserID, task.Restore, task.ShortURLs)
+
+	if err := w.submit(task); err != nil {
+		w.jobs.remove(jobID)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// Job возвращает состояние задания на удаление или восстановление.
+// Возвращает false, если задание не найдено (в том числе вытеснено или создано до перезапуска).
+func (w *DeleteWorker) Job(id string) (models.DeleteJob, bool) {
+	return w.jobs.get(id)
+}
+
+// submit ставит задачу в постоянную очередь (если она задана) и в канал обработки.
+//
+// Место в канале резервируется до записи в постоянную очередь: отклонённая
+// задача не должна выполниться после перезапуска, а зарезервированное место
+// гарантирует, что запись в канал не заблокируется. Место освобождается,
+// когда сборщик пакетов забирает задачу из канала.
+func (w *DeleteWorker) submit(task DeleteTask) error {
+	w.stopMu.RLock()
+	defer w.stopMu.RUnlock()
+	if w.stopped {
+		return ErrStopped
+	}
+
 	select {
-	case w.taskChan <- task:
-		return nil
+	case w.slots <- struct{}{}:
 	default:
-		return errors.New("очередь переполнена")
+		return ErrQueueFull
+	}
+
+	if w.queue != nil {
+		id, err := w.queue.Enqueue(w.ctx, task)
+		if err != nil {
+			<-w.slots
+			return fmt.Errorf("ошибка сохранения задачи удаления: %w", err)
+		}
+		task.ID = id
+	}
+
+	w.taskChan <- task
+	return nil
+}
+
+// ack подтверждает обработку задач в постоянной очереди.
+// Неподтверждённые задачи будут повторены при следующем запуске.
+func (w *DeleteWorker) ack(ids []int64) {
+	if w.queue == nil || len(ids) == 0 {
+		return
+	}
+	if err := w.queue.Ack(w.ctx, ids); err != nil {
+		w.log.Error("Failed to acknowledge delete tasks", zap.Int64s("ids", ids), zap.Error(err))
 	}
 }
 
 // batchCollector собирает задачи в пакеты по пользователям.
-// Отправляет пакеты на обработку при достижении batchSize или по истечении batchWindow.
-func (w *DeleteWorker) batchCollector() {
+// Отправляет пакеты на обработку, когда в них накапливается batchSize URL
+// или по истечении batchWindow.
+// Задачи pending, восстановленные из постоянной очереди, добавляются в пакеты первыми.
+func (w *DeleteWorker) batchCollector(pending []DeleteTask) {
 	defer w.wg.Done()
 
-	batch := make(map[string][]string)
+	batch := make(map[string]*userBatch)
+	batchURLs := 0
+	add := func(task DeleteTask) {
+		ub, exists := batch[task.UserID]
+		if !exists {
+			ub = &userBatch{}
+			batch[task.UserID] = ub
+		}
+		// Подряд идущие задачи одного вида объединяются
+		if n := len(ub.ops); n == 0 || ub.ops[n-1].restore != task.Restore {
+			ub.ops = append(ub.ops, &userOp{restore: task.Restore})
+		}
+		op := ub.ops[len(ub.ops)-1]
+		op.urls = append(op.urls, task.ShortURLs...)
+		if task.ID != 0 {
+			op.ids = append(op.ids, task.ID)
+		}
+
+		batchURLs += len(task.ShortURLs)
+		if batchURLs >= w.batchSize {
+			w.batchChan <- batch
+			batch = make(map[string]*userBatch)
+			batchURLs = 0
+		}
+	}
+
+	for _, task := range pending {
+		add(task)
+	}
+
 	ticker := time.NewTicker(w.batchWindow)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-w.stopChan:
+			// Задачи, поставленные до остановки, уже в канале: забираем их все
+			for drained := false; !drained; {
+				select {
+				case task := <-w.taskChan:
+					<-w.slots
+					add(task)
+				default:
+					drained = true
+				}
+			}
 			if len(batch) > 0 {
 				w.batchChan <- batch
 			}
 			close(w.batchChan)
 			return
 
-		case task, ok := <-w.taskChan:
-			if !ok {
-				if len(batch) > 0 {
-					w.batchChan <- batch
-				}
-				close(w.batchChan)
-				return
-			}
+		case task := <-w.taskChan:
+			<-w.slots
 
-			if urls, exists := batch[task.UserID]; exists {
-				batch[task.UserID] = append(urls, task.ShortURLs...)
-			} else {
-				batch[task.UserID] = task.ShortURLs
-			}
-
-			if len(batch) >= w.batchSize {
-				w.batchChan <- batch
-				batch = make(map[string][]string)
+			add(task)
+			if len(batch) == 0 {
 				ticker.Reset(w.batchWindow)
 			}
 
 		case <-ticker.C:
 			if len(batch) > 0 {
 				w.batchChan <- batch
-				batch = make(map[string][]string)
+				batch = make(map[string]*userBatch)
+				batchURLs = 0
 			}
 		}
 	}
@@ -139,43 +413,247 @@ func (w *DeleteWorker) batchProcessor() {
 
 		concurrencyLimit := make(chan struct{}, w.workerCount*2)
 
-		for userID, urls := range batch {
+		for userID, ub := range batch {
 			concurrencyLimit <- struct{}{}
 
-			go func(userID string, urls []string) {
+			go func(userID string, ub *userBatch) {
 				defer batchWg.Done()
 				defer func() { <-concurrencyLimit }()
 
-				const subBatchSize = 50
-				for i := 0; i < len(urls); i += subBatchSize {
-					end := i + subBatchSize
-					if end > len(urls) {
-						end = len(urls)
-					}
-					subBatch := urls[i:end]
-
-					if err := w.processUserBatch(userID, subBatch); err != nil {
-						log.Printf("Ошибка при пометке URL как удалённых для пользователя %s: %v", userID, err)
-					}
+				for _, op := range ub.ops {
+					w.processOp(userID, op)
 				}
-			}(userID, urls)
+			}(userID, ub)
 		}
 
 		batchWg.Wait()
 	}
 }
 
-// processUserBatch выполняет пометку URL как удаленных в хранилище.
-func (w *DeleteWorker) processUserBatch(userID string, urls []string) error {
-	if err := w.repo.BatchMarkAsDeleted(userID, urls); err != nil {
+// processOp обрабатывает задачи пользователя одного вида подпакетами.
+// Задачи подтверждаются, только если все их URL обработаны,
+// иначе необработанные URL передаются в хранилище dead-letter.
+func (w *DeleteWorker) processOp(userID string, op *userOp) {
+	const subBatchSize = 50
+	var (
+		failed   []string
+		attempts int
+		lastErr  error
+	)
+	urls := op.urls
+	for i := 0; i < len(urls); i += subBatchSize {
+		end := i + subBatchSize
+		if end > len(urls) {
+			end = len(urls)
+		}
+		subBatch := urls[i:end]
+
+		if n, err := w.processWithRetry(userID, op.restore, subBatch); err != nil {
+			failed = append(failed, subBatch...)
+			attempts, lastErr = n, err
+		}
+	}
+
+	if len(failed) == 0 {
+		w.ack(op.ids)
+		return
+	}
+	w.deadLetter(models.DeadLetter{
+		UserID:    userID,
+		ShortURLs: failed,
+		Attempts:  attempts,
+		LastError: lastErr.Error(),
+		FailedAt:  time.Now(),
+		Restore:   op.restore,
+		TaskIDs:   op.ids,
+	})
+}
+
+// processWithRetry выполняет пометку URL с повторами согласно политике retry.
+// Возвращает количество выполненных попыток и ошибку последней из них.
+func (w *DeleteWorker) processWithRetry(userID string, restore bool, urls []string) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := w.processUserBatch(userID, restore, urls)
+		if err == nil {
+			return attempt, nil
+		}
+		if attempt >= w.retry.MaxAttempts || w.ctx.Err() != nil {
+			w.log.Error("Failed to mark URLs as deleted",
+				zap.String("user_id", userID),
+				zap.Bool("restore", restore),
+				zap.Int("attempts", attempt),
+				zap.Error(err),
+			)
+			return attempt, err
+		}
+
+		delay := w.retry.backoff(attempt)
+		w.log.Warn("Failed to mark URLs as deleted, retrying",
+			zap.String("user_id", userID),
+			zap.Bool("restore", restore),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-w.ctx.Done():
+			timer.Stop()
+			return attempt, err
+		}
+		w.retries.Add(1)
+	}
+}
+
+// deadLetter передаёт невыполненную задачу в хранилище dead-letter.
+// При остановке по таймауту задача не сохраняется: она не подтверждена
+// в постоянной очереди и будет повторена после перезапуска.
+func (w *DeleteWorker) deadLetter(letter models.DeadLetter) {
+	if w.ctx.Err() != nil {
+		return
+	}
+	w.jobs.fail(letter.UserID, letter.Restore, letter.ShortURLs)
+
+	if err := w.deadLetters.Add(w.ctx, letter); err != nil {
+		w.log.Error("Failed to store dead letter",
+			zap.String("user_id", letter.UserID),
+			zap.Strings("short_urls", letter.ShortURLs),
+			zap.Error(err),
+		)
+		return
+	}
+	w.dead.Add(1)
+	w.log.Warn("Delete task moved to dead letters",
+		zap.String("user_id", letter.UserID),
+		zap.Bool("restore", letter.Restore),
+		zap.Int("urls", len(letter.ShortURLs)),
+		zap.Int("attempts", letter.Attempts),
+		zap.String("error", letter.LastError),
+	)
+}
+
+// DeadLetters возвращает задачи, не выполненные после всех повторных попыток.
+func (w *DeleteWorker) DeadLetters(ctx context.Context) ([]models.DeadLetter, error) {
+	return w.deadLetters.List(ctx)
+}
+
+// ReplayDeadLetters повторно ставит в очередь задачи из хранилища dead-letter.
+//
+// Параметры:
+//   - ctx: контекст
+//   - ids: идентификаторы задач (все задачи, если пуст)
+//
+// Возвращает:
+//   - int: количество задач, поставленных в очередь
+//   - error: ошибка чтения хранилища или постановки в очередь; задачи,
+//     которые не удалось поставить в очередь, возвращаются в хранилище
+func (w *DeleteWorker) ReplayDeadLetters(ctx context.Context, ids []int64) (int, error) {
+	letters, err := w.deadLetters.Take(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, letter := range letters {
+		// Задания, ожидающие этих кодов, снова переходят в ожидание
+		w.jobs.reopen(letter.UserID, letter.Restore, letter.ShortURLs)
+		if err := w.submit(DeleteTask{UserID: letter.UserID, ShortURLs: letter.ShortURLs, Restore: letter.Restore}); err != nil {
+			w.jobs.fail(letter.UserID, letter.Restore, letter.ShortURLs)
+			for _, rest := range letters[i:] {
+				if addErr := w.deadLetters.Add(ctx, rest); addErr != nil {
+					w.log.Error("Failed to return dead letter", zap.Int64("id", rest.ID), zap.Error(addErr))
+				}
+			}
+			return i, err
+		}
+		// Задача поставлена в очередь заново, прежние записи постоянной очереди не нужны
+		w.ack(letter.TaskIDs)
+	}
+
+	w.log.Info("Dead letters replayed", zap.Int("count", len(letters)))
+	return len(letters), nil
+}
+
+// processUserBatch выполняет пометку URL как удаленных (или её снятие) в хранилище
+// и учитывает результат в счётчиках.
+func (w *DeleteWorker) processUserBatch(userID string, restore bool, urls []string) error {
+	if restore {
+		return w.processRestoreBatch(userID, urls)
+	}
+
+	result, err := w.repo.BatchMarkAsDeleted(w.ctx, userID, urls)
+	if err != nil {
+		w.errors.Add(1)
 		return err
 	}
+
+	// Пропущенные коды уже удалены или не существуют - повторять их не нужно
+	w.jobs.resolve(userID, false, append(result.Deleted, result.Skipped...), result.NotOwned)
+
+	w.deleted.Add(uint64(len(result.Deleted)))
+	w.notOwned.Add(uint64(len(result.NotOwned)))
+	w.skipped.Add(uint64(len(result.Skipped)))
+
+	if len(result.NotOwned) > 0 {
+		w.log.Warn("User requested deletion of URLs owned by others",
+			zap.String("user_id", userID),
+			zap.Strings("short_urls", result.NotOwned),
+		)
+	}
+	w.log.Debug("URLs marked as deleted",
+		zap.String("user_id", userID),
+		zap.Int("deleted", len(result.Deleted)),
+		zap.Int("not_owned", len(result.NotOwned)),
+		zap.Int("skipped", len(result.Skipped)),
+	)
+
+	return nil
+}
+
+// processRestoreBatch снимает пометку удаления с URL, удалённых в пределах
+// окна восстановления, и учитывает результат в счётчиках.
+// Неудалённые коды считаются выполненными, чужие и недоступные - невыполненными.
+func (w *DeleteWorker) processRestoreBatch(userID string, urls []string) error {
+	result, err := w.repo.BatchRestore(w.ctx, userID, urls, time.Now().Add(-w.restoreWindow))
+	if err != nil {
+		w.errors.Add(1)
+		return err
+	}
+
+	failed := append(append([]string(nil), result.NotOwned...), result.Unavailable...)
+	w.jobs.resolve(userID, true, append(result.Restored, result.Active...), failed)
+
+	w.restored.Add(uint64(len(result.Restored)))
+	w.notOwned.Add(uint64(len(result.NotOwned)))
+
+	if len(failed) > 0 {
+		w.log.Warn("User requested restore of unavailable URLs",
+			zap.String("user_id", userID),
+			zap.Strings("not_owned", result.NotOwned),
+			zap.Strings("unavailable", result.Unavailable),
+		)
+	}
+	w.log.Debug("URLs restored",
+		zap.String("user_id", userID),
+		zap.Int("restored", len(result.Restored)),
+		zap.Int("active", len(result.Active)),
+		zap.Int("not_owned", len(result.NotOwned)),
+		zap.Int("unavailable", len(result.Unavailable)),
+	)
+
 	return nil
 }
 
 // GracefulStop выполняет плавное завершение работы с заданным таймаутом.
-// Дожидается завершения обработки текущих задач или истечения таймаута.
+// Новые задачи отклоняются с ErrStopped, поставленные ранее обрабатываются.
+// Дожидается завершения обработки задач или истечения таймаута.
 func (w *DeleteWorker) GracefulStop(timeout time.Duration) {
+	// Дожидаемся отправителей, уже записывающих задачи в канал
+	w.stopMu.Lock()
+	w.stopped = true
+	w.stopMu.Unlock()
+
 	close(w.stopChan)
 
 	done := make(chan struct{})
@@ -186,10 +664,10 @@ func (w *DeleteWorker) GracefulStop(timeout time.Duration) {
 
 	select {
 	case <-done:
-		log.Println("Все воркеры завершили работу")
+		w.log.Info("Delete workers stopped")
 	case <-time.After(timeout):
-		log.Println("Таймаут ожидания завершения воркеров")
+		w.log.Warn("Timed out waiting for delete workers to stop")
 	}
-
-	close(w.taskChan)
+	// Прерываем обращения к хранилищу, которые ещё выполняются после таймаута
+	w.cancel()
 }
@@ -0,0 +1,231 @@
+package deleteurls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+func TestDeleteWorker_QueueFull(t *testing.T) {
+	t.Run("rejected task is not persisted", func(t *testing.T) {
+		queue, err := OpenFileQueue(t.TempDir() + "/delete.journal")
+		require.NoError(t, err)
+		defer queue.Close()
+
+		// Воркер не запущен, поэтому задачи из канала никто не забирает
+		w := NewDeleteWorker(1, 100, time.Hour, &stubRepository{}, WithQueueSize(2), WithQueue(queue))
+
+		for i := 0; i < 2; i++ {
+			_, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{fmt.Sprint(i)}})
+			require.NoError(t, err)
+		}
+		jobID, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"rejected"}})
+		assert.ErrorIs(t, err, ErrQueueFull)
+		assert.Empty(t, jobID)
+
+		pending, err := queue.Pending(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, pending, 2)
+	})
+
+	t.Run("concurrent submits do not exceed capacity", func(t *testing.T) {
+		const (
+			size    = 10
+			senders = 50
+		)
+		w := NewDeleteWorker(1, 1000, time.Hour, &stubRepository{}, WithQueueSize(size))
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			accepted int
+			rejected int
+		)
+		for i := 0; i < senders; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{fmt.Sprint(i)}})
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					accepted++
+				case assert.ErrorIs(t, err, ErrQueueFull):
+					rejected++
+				}
+			}(i)
+		}
+
+		// Ни один отправитель не блокируется на заполненном канале
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Submit blocked on a full queue")
+		}
+
+		assert.Equal(t, size, accepted)
+		assert.Equal(t, senders-size, rejected)
+		assert.Len(t, w.taskChan, size)
+	})
+
+	t.Run("queue accepts tasks again after draining", func(t *testing.T) {
+		repo := &stubRepository{}
+		w := NewDeleteWorker(1, 1, 10*time.Millisecond, repo, WithQueueSize(1))
+
+		_, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a"}})
+		require.NoError(t, err)
+		_, err = w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"b"}})
+		require.ErrorIs(t, err, ErrQueueFull)
+
+		w.Start()
+		defer w.GracefulStop(time.Second)
+
+		require.Eventually(t, func() bool {
+			_, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"b"}})
+			return err == nil
+		}, time.Second, time.Millisecond)
+		require.Eventually(t, func() bool { return len(repo.deletedURLs()) == 2 }, time.Second, time.Millisecond)
+		assert.ElementsMatch(t, []string{"a", "b"}, repo.deletedURLs())
+	})
+
+	t.Run("failed enqueue releases the slot", func(t *testing.T) {
+		queue, err := OpenFileQueue(t.TempDir() + "/delete.journal")
+		require.NoError(t, err)
+		w := NewDeleteWorker(1, 100, time.Hour, &stubRepository{}, WithQueueSize(1), WithQueue(queue))
+
+		// Журнал закрыт - запись в постоянную очередь завершается ошибкой
+		require.NoError(t, queue.Close())
+		_, err = w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a"}})
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrQueueFull)
+
+		queue, err = OpenFileQueue(queue.path)
+		require.NoError(t, err)
+		defer queue.Close()
+		w.queue = queue
+
+		_, err = w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a"}})
+		assert.NoError(t, err)
+	})
+}
+
+func TestDeleteWorker_GracefulStop(t *testing.T) {
+	t.Run("tasks queued before stop are processed", func(t *testing.T) {
+		// Сборщик может увидеть остановку раньше задач в канале
+		for i := 0; i < 20; i++ {
+			repo := &stubRepository{}
+			w := NewDeleteWorker(2, 1000, time.Hour, repo)
+
+			var want []string
+			for j := 0; j < 5; j++ {
+				code := fmt.Sprintf("%d-%d", i, j)
+				want = append(want, code)
+				_, err := w.Submit(DeleteTask{UserID: fmt.Sprint("user", j%2), ShortURLs: []string{code}})
+				require.NoError(t, err)
+			}
+
+			w.Start()
+			w.GracefulStop(time.Second)
+
+			require.ElementsMatch(t, want, repo.deletedURLs())
+			assert.Empty(t, w.taskChan)
+			assert.Empty(t, w.slots)
+		}
+	})
+
+	t.Run("submit after stop", func(t *testing.T) {
+		dead := NewMemoryDeadLetters(10)
+		w := NewDeleteWorker(1, 1, 10*time.Millisecond, &stubRepository{}, WithDeadLetters(dead))
+		w.Start()
+		w.GracefulStop(time.Second)
+
+		jobID, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a"}})
+		assert.ErrorIs(t, err, ErrStopped)
+		assert.Empty(t, jobID)
+		_, err = w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a"}, Restore: true})
+		assert.ErrorIs(t, err, ErrStopped)
+
+		// Задачи dead-letter остаются в хранилище
+		require.NoError(t, dead.Add(context.Background(), models.DeadLetter{UserID: "user1", ShortURLs: []string{"b"}}))
+		replayed, err := w.ReplayDeadLetters(context.Background(), nil)
+		assert.ErrorIs(t, err, ErrStopped)
+		assert.Zero(t, replayed)
+		letters, err := dead.List(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, letters, 1)
+	})
+
+	t.Run("submit after stop timeout", func(t *testing.T) {
+		failure := errors.New("storage unavailable")
+		repo := &stubRepository{failures: 100, err: failure}
+		slow := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Minute}
+		w := NewDeleteWorker(1, 1, 10*time.Millisecond, repo, WithRetryPolicy(slow))
+		w.Start()
+
+		_, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a"}})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return repo.callCount() == 1 }, time.Second, time.Millisecond)
+
+		// Обработка не завершилась за время остановки
+		w.GracefulStop(10 * time.Millisecond)
+
+		assert.NotPanics(t, func() {
+			_, err = w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"b"}})
+		})
+		assert.ErrorIs(t, err, ErrStopped)
+	})
+
+	t.Run("concurrent submits during stop", func(t *testing.T) {
+		repo := &stubRepository{}
+		w := NewDeleteWorker(2, 10, time.Millisecond, repo, WithQueueSize(1000))
+		w.Start()
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			accepted []string
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; ; j++ {
+					code := fmt.Sprintf("%d-%d", i, j)
+					_, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{code}})
+					switch {
+					case errors.Is(err, ErrStopped):
+						return
+					case errors.Is(err, ErrQueueFull):
+						// Отклонённая задача не выполняется
+					case assert.NoError(t, err):
+						mu.Lock()
+						accepted = append(accepted, code)
+						mu.Unlock()
+					}
+				}
+			}(i)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		w.GracefulStop(5 * time.Second)
+		wg.Wait()
+
+		// Каждая принятая задача обработана
+		assert.ElementsMatch(t, accepted, repo.deletedURLs())
+	})
+}
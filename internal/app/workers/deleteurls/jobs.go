@@ -0,0 +1,234 @@
+package deleteurls
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// defaultJobLimit - количество заданий, хранимых для опроса клиентами.
+const defaultJobLimit = 10000
+
+// jobCode - код пользователя, ожидаемый заданиями удаления или восстановления.
+type jobCode struct {
+	userID  string
+	code    string
+	restore bool
+}
+
+// job - состояние одного задания на удаление (или восстановление).
+type job struct {
+	id        string
+	userID    string
+	restore   bool
+	pending   map[string]struct{}
+	done      map[string]struct{}
+	failed    map[string]struct{}
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// jobTracker отслеживает ход выполнения заданий на удаление.
+//
+// Задания сопоставляются с результатами по паре (пользователь, код) отдельно
+// для удаления и восстановления, поэтому
+// задачи не нужно сопровождать идентификаторами заданий при объединении в пакеты.
+// Если один код ожидают несколько заданий пользователя, результат засчитывается всем.
+// Задания хранятся только в памяти: после перезапуска задачи из постоянной
+// очереди выполняются, но их ход отследить нельзя.
+type jobTracker struct {
+	mu     sync.Mutex
+	limit  int
+	jobs   map[string]*job
+	order  []string                    // Идентификаторы заданий в порядке создания
+	byCode map[jobCode]map[string]*job // Незавершённые задания по ожидаемым кодам
+}
+
+func newJobTracker(limit int) *jobTracker {
+	return &jobTracker{
+		limit:  limit,
+		jobs:   make(map[string]*job),
+		byCode: make(map[jobCode]map[string]*job),
+	}
+}
+
+// add регистрирует задание. При превышении limit вытесняются самые старые задания.
+func (t *jobTracker) add(id, userID string, restore bool, codes []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	j := &job{
+		id:        id,
+		userID:    userID,
+		restore:   restore,
+		pending:   make(map[string]struct{}, len(codes)),
+		done:      make(map[string]struct{}),
+		failed:    make(map[string]struct{}),
+		createdAt: now,
+		updatedAt: now,
+	}
+	for _, code := range codes {
+		j.pending[code] = struct{}{}
+		t.watch(j, code)
+	}
+
+	t.jobs[id] = j
+	t.order = append(t.order, id)
+	for len(t.order) > t.limit {
+		t.removeLocked(t.order[0])
+	}
+}
+
+// remove удаляет задание (например, если задачу не удалось поставить в очередь).
+func (t *jobTracker) remove(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.removeLocked(id)
+}
+
+func (t *jobTracker) removeLocked(id string) {
+	j, ok := t.jobs[id]
+	if !ok {
+		return
+	}
+	for code := range j.pending {
+		t.unwatch(j, code)
+	}
+	for code := range j.failed {
+		t.unwatch(j, code)
+	}
+	delete(t.jobs, id)
+	for i, oid := range t.order {
+		if oid == id {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// watch связывает задание с ожидаемым кодом.
+func (t *jobTracker) watch(j *job, code string) {
+	key := jobCode{userID: j.userID, code: code, restore: j.restore}
+	jobs, ok := t.byCode[key]
+	if !ok {
+		jobs = make(map[string]*job)
+		t.byCode[key] = jobs
+	}
+	jobs[j.id] = j
+}
+
+// unwatch отвязывает задание от кода.
+func (t *jobTracker) unwatch(j *job, code string) {
+	key := jobCode{userID: j.userID, code: code, restore: j.restore}
+	delete(t.byCode[key], j.id)
+	if len(t.byCode[key]) == 0 {
+		delete(t.byCode, key)
+	}
+}
+
+// resolve учитывает результат обработки URL пользователя: коды done
+// считаются выполненными, коды failed - невыполненными.
+func (t *jobTracker) resolve(userID string, restore bool, done, failed []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for _, code := range done {
+		key := jobCode{userID: userID, code: code, restore: restore}
+		for _, j := range t.byCode[key] {
+			delete(j.pending, code)
+			delete(j.failed, code)
+			j.done[code] = struct{}{}
+			j.updatedAt = now
+		}
+		delete(t.byCode, key)
+	}
+	for _, code := range failed {
+		key := jobCode{userID: userID, code: code, restore: restore}
+		for _, j := range t.byCode[key] {
+			delete(j.pending, code)
+			j.failed[code] = struct{}{}
+			j.updatedAt = now
+		}
+		delete(t.byCode, key)
+	}
+}
+
+// fail отмечает коды, не обработанные после всех попыток.
+// Задания остаются связанными с кодами, чтобы учесть повтор из dead-letter.
+func (t *jobTracker) fail(userID string, restore bool, codes []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for _, code := range codes {
+		for _, j := range t.byCode[jobCode{userID: userID, code: code, restore: restore}] {
+			if _, ok := j.pending[code]; ok {
+				delete(j.pending, code)
+				j.failed[code] = struct{}{}
+				j.updatedAt = now
+			}
+		}
+	}
+}
+
+// reopen возвращает невыполненные коды в ожидание при повторе из dead-letter.
+func (t *jobTracker) reopen(userID string, restore bool, codes []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for _, code := range codes {
+		for _, j := range t.byCode[jobCode{userID: userID, code: code, restore: restore}] {
+			if _, ok := j.failed[code]; ok {
+				delete(j.failed, code)
+				j.pending[code] = struct{}{}
+				j.updatedAt = now
+			}
+		}
+	}
+}
+
+// get возвращает снимок состояния задания.
+func (t *jobTracker) get(id string) (models.DeleteJob, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	j, ok := t.jobs[id]
+	if !ok {
+		return models.DeleteJob{}, false
+	}
+
+	status := models.DeleteJobDone
+	switch {
+	case len(j.pending) > 0:
+		status = models.DeleteJobPending
+	case len(j.failed) > 0:
+		status = models.DeleteJobFailed
+	}
+
+	return models.DeleteJob{
+		ID:        j.id,
+		UserID:    j.userID,
+		Status:    status,
+		Pending:   sortedCodes(j.pending),
+		Done:      sortedCodes(j.done),
+		Failed:    sortedCodes(j.failed),
+		CreatedAt: j.createdAt,
+		UpdatedAt: j.updatedAt,
+		Restore:   j.restore,
+	}, true
+}
+
+// sortedCodes возвращает коды множества в алфавитном порядке.
+func sortedCodes(set map[string]struct{}) []string {
+	codes := make([]string, 0, len(set))
+	for code := range set {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
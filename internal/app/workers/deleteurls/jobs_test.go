@@ -0,0 +1,170 @@
+package deleteurls
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+func getJob(t *testing.T, tracker *jobTracker, id string) models.DeleteJob {
+	t.Helper()
+	job, ok := tracker.get(id)
+	require.True(t, ok, "job %s not found", id)
+	return job
+}
+
+func TestJobTracker_Transitions(t *testing.T) {
+	t.Run("pending to done", func(t *testing.T) {
+		tracker := newJobTracker(10)
+		tracker.add("job1", "user1", false, []string{"a", "b"})
+
+		job := getJob(t, tracker, "job1")
+		assert.Equal(t, models.DeleteJobPending, job.Status)
+		assert.Equal(t, []string{"a", "b"}, job.Pending)
+		assert.Empty(t, job.Done)
+		assert.Equal(t, "user1", job.UserID)
+		assert.Equal(t, job.CreatedAt, job.UpdatedAt)
+
+		tracker.resolve("user1", false, []string{"b"}, nil)
+		job = getJob(t, tracker, "job1")
+		assert.Equal(t, models.DeleteJobPending, job.Status)
+		assert.Equal(t, []string{"a"}, job.Pending)
+		assert.Equal(t, []string{"b"}, job.Done)
+		assert.False(t, job.UpdatedAt.Before(job.CreatedAt))
+
+		tracker.resolve("user1", false, []string{"a"}, nil)
+		job = getJob(t, tracker, "job1")
+		assert.Equal(t, models.DeleteJobDone, job.Status)
+		assert.Empty(t, job.Pending)
+		assert.Equal(t, []string{"a", "b"}, job.Done)
+		assert.Empty(t, job.Failed)
+	})
+
+	t.Run("pending to failed", func(t *testing.T) {
+		tracker := newJobTracker(10)
+		tracker.add("job1", "user1", false, []string{"a", "b", "c"})
+
+		// Чужой код обработан, но не удалён
+		tracker.resolve("user1", false, []string{"a"}, []string{"b"})
+		job := getJob(t, tracker, "job1")
+		assert.Equal(t, models.DeleteJobPending, job.Status)
+		assert.Equal(t, []string{"c"}, job.Pending)
+
+		// Статус failed - только когда обработаны все коды
+		tracker.fail("user1", false, []string{"c"})
+		job = getJob(t, tracker, "job1")
+		assert.Equal(t, models.DeleteJobFailed, job.Status)
+		assert.Empty(t, job.Pending)
+		assert.Equal(t, []string{"a"}, job.Done)
+		assert.Equal(t, []string{"b", "c"}, job.Failed)
+	})
+
+	t.Run("dead letter replay", func(t *testing.T) {
+		tracker := newJobTracker(10)
+		tracker.add("job1", "user1", false, []string{"a", "b"})
+
+		tracker.fail("user1", false, []string{"a", "b"})
+		assert.Equal(t, models.DeleteJobFailed, getJob(t, tracker, "job1").Status)
+
+		tracker.reopen("user1", false, []string{"a", "b"})
+		job := getJob(t, tracker, "job1")
+		assert.Equal(t, models.DeleteJobPending, job.Status)
+		assert.Equal(t, []string{"a", "b"}, job.Pending)
+		assert.Empty(t, job.Failed)
+
+		tracker.resolve("user1", false, []string{"a", "b"}, nil)
+		assert.Equal(t, models.DeleteJobDone, getJob(t, tracker, "job1").Status)
+	})
+
+	t.Run("results are matched by user, code and kind", func(t *testing.T) {
+		tracker := newJobTracker(10)
+		tracker.add("del1", "user1", false, []string{"a"})
+		tracker.add("del2", "user1", false, []string{"a", "b"})
+		tracker.add("restore", "user1", true, []string{"a"})
+		tracker.add("other", "user2", false, []string{"a"})
+
+		tracker.resolve("user1", false, []string{"a"}, nil)
+
+		// Результат засчитывается всем заданиям пользователя, ожидающим код
+		assert.Equal(t, models.DeleteJobDone, getJob(t, tracker, "del1").Status)
+		assert.Equal(t, []string{"a"}, getJob(t, tracker, "del2").Done)
+		// Восстановление и задания других пользователей не затрагиваются
+		assert.Equal(t, models.DeleteJobPending, getJob(t, tracker, "restore").Status)
+		assert.True(t, getJob(t, tracker, "restore").Restore)
+		assert.Equal(t, models.DeleteJobPending, getJob(t, tracker, "other").Status)
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		tracker := newJobTracker(10)
+		_, ok := tracker.get("missing")
+		assert.False(t, ok)
+	})
+}
+
+func TestJobTracker_Eviction(t *testing.T) {
+	tracker := newJobTracker(2)
+	tracker.add("job1", "user1", false, []string{"a"})
+	tracker.add("job2", "user1", false, []string{"b"})
+	tracker.fail("user1", false, []string{"b"})
+	tracker.add("job3", "user1", false, []string{"c"})
+
+	// Вытесняется самое старое задание
+	_, ok := tracker.get("job1")
+	assert.False(t, ok)
+	getJob(t, tracker, "job2")
+	getJob(t, tracker, "job3")
+
+	// Вытесненное задание больше не связано со своими кодами
+	assert.NotContains(t, tracker.byCode, jobCode{userID: "user1", code: "a"})
+
+	// Как и невыполненные коды вытесненного задания
+	tracker.add("job4", "user1", false, []string{"d"})
+	_, ok = tracker.get("job2")
+	assert.False(t, ok)
+	assert.NotContains(t, tracker.byCode, jobCode{userID: "user1", code: "b"})
+	assert.Len(t, tracker.jobs, 2)
+	assert.Equal(t, []string{"job3", "job4"}, tracker.order)
+
+	// Удалённое задание не учитывает результаты
+	tracker.remove("job3")
+	_, ok = tracker.get("job3")
+	assert.False(t, ok)
+	assert.NotContains(t, tracker.byCode, jobCode{userID: "user1", code: "c"})
+	tracker.resolve("user1", false, []string{"c"}, nil)
+	assert.Equal(t, []string{"job4"}, tracker.order)
+}
+
+func TestDeleteWorker_JobPartialFailure(t *testing.T) {
+	// Пакет делится на подпакеты по 50 кодов; подпакет с кодом "code060" не обрабатывается
+	codes := make([]string, 120)
+	for i := range codes {
+		codes[i] = fmt.Sprintf("code%03d", i)
+	}
+	repo := &stubRepository{failOn: "code060", err: errors.New("connection refused")}
+	w := NewDeleteWorker(1, len(codes), 10*time.Millisecond, repo,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	w.Start()
+	defer w.GracefulStop(time.Second)
+
+	jobID, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: codes})
+	require.NoError(t, err)
+
+	var job models.DeleteJob
+	require.Eventually(t, func() bool {
+		job, _ = w.Job(jobID)
+		return job.Status != models.DeleteJobPending
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+	assert.Empty(t, job.Pending)
+	assert.Equal(t, append(append([]string(nil), codes[:50]...), codes[100:]...), job.Done)
+	assert.Equal(t, codes[50:100], job.Failed)
+	assert.Equal(t, uint64(70), w.Stats().Deleted)
+	assert.Equal(t, uint64(1), w.Stats().Dead)
+}
@@ -0,0 +1,231 @@
+package deleteurls
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// journalRecord - строка журнала очереди: задача (ID > 0) или подтверждение (Ack).
+type journalRecord struct {
+	ID        int64    `json:"id,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+	ShortURLs []string `json:"short_urls,omitempty"`
+	Restore   bool     `json:"restore,omitempty"`
+	Ack       []int64  `json:"ack,omitempty"`
+}
+
+// FileQueue реализует Queue в виде журнала на диске (JSON-строки).
+//
+// Задачи и подтверждения дописываются в конец файла. Постановка задачи
+// подтверждается только после fsync; подтверждения на диск не сбрасываются,
+// так как потеря подтверждения приводит лишь к безопасному повтору задачи.
+// При открытии журнал перезаписывается, оставляя только неподтверждённые
+// задачи, а при подтверждении последней задачи файл очищается.
+type FileQueue struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	nextID  int64
+	pending map[int64]DeleteTask
+}
+
+// OpenFileQueue открывает журнал очереди, создавая файл при необходимости.
+//
+// Неполная последняя строка (запись, прерванная аварийным завершением)
+// отбрасывается: постановка такой задачи не была подтверждена.
+//
+// Параметры:
+//   - path: путь к файлу журнала
+//
+// Возвращает:
+//   - *FileQueue: открытая очередь
+//   - error: ошибка чтения или перезаписи журнала
+func OpenFileQueue(path string) (*FileQueue, error) {
+	q := &FileQueue{
+		path:    path,
+		nextID:  1,
+		pending: make(map[int64]DeleteTask),
+	}
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	if err := q.rewrite(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// load читает журнал и восстанавливает неподтверждённые задачи.
+func (q *FileQueue) load() error {
+	file, err := os.Open(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Неполная последняя строка отбрасывается при перезаписи журнала
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("повреждён журнал очереди удаления %s: %w", q.path, err)
+		}
+		q.apply(record)
+	}
+}
+
+// apply учитывает запись журнала в наборе неподтверждённых задач.
+func (q *FileQueue) apply(record journalRecord) {
+	for _, id := range record.Ack {
+		delete(q.pending, id)
+	}
+	if record.ID > 0 {
+		q.pending[record.ID] = DeleteTask{ID: record.ID, UserID: record.UserID, ShortURLs: record.ShortURLs, Restore: record.Restore}
+		if record.ID >= q.nextID {
+			q.nextID = record.ID + 1
+		}
+	}
+}
+
+// rewrite атомарно заменяет журнал файлом, содержащим только неподтверждённые задачи.
+func (q *FileQueue) rewrite() error {
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, task := range q.sortedPending() {
+		if err := encoder.Encode(journalRecord{ID: task.ID, UserID: task.UserID, ShortURLs: task.ShortURLs, Restore: task.Restore}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(q.path)); err != nil {
+		return err
+	}
+
+	if q.file != nil {
+		q.file.Close()
+	}
+	q.file, err = os.OpenFile(q.path, os.O_WRONLY|os.O_APPEND, 0644)
+	return err
+}
+
+// sortedPending возвращает неподтверждённые задачи в порядке постановки.
+func (q *FileQueue) sortedPending() []DeleteTask {
+	tasks := make([]DeleteTask, 0, len(q.pending))
+	for _, task := range q.pending {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// write дописывает запись в конец журнала.
+func (q *FileQueue) write(record journalRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = q.file.Write(append(data, '\n'))
+	return err
+}
+
+// Enqueue сохраняет задачу в журнале и возвращает её идентификатор.
+func (q *FileQueue) Enqueue(ctx context.Context, task DeleteTask) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	task.ID = q.nextID
+	if err := q.write(journalRecord{ID: task.ID, UserID: task.UserID, ShortURLs: task.ShortURLs, Restore: task.Restore}); err != nil {
+		return 0, err
+	}
+	if err := q.file.Sync(); err != nil {
+		return 0, err
+	}
+
+	q.nextID++
+	q.pending[task.ID] = task
+	return task.ID, nil
+}
+
+// Ack отмечает задачи обработанными.
+func (q *FileQueue) Ack(ctx context.Context, ids []int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, id := range ids {
+		delete(q.pending, id)
+	}
+
+	// Все задачи обработаны - журнал можно очистить вместо дописывания
+	if len(q.pending) == 0 {
+		if err := q.file.Truncate(0); err == nil {
+			return nil
+		}
+	}
+	return q.write(journalRecord{Ack: ids})
+}
+
+// Pending возвращает неподтверждённые задачи в порядке постановки.
+func (q *FileQueue) Pending(ctx context.Context) ([]DeleteTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.sortedPending(), nil
+}
+
+// Close закрывает файл журнала.
+func (q *FileQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.file.Close()
+}
+
+// syncDir сбрасывает на диск изменения каталога (переименование файла).
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
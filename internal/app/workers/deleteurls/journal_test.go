@@ -0,0 +1,157 @@
+package deleteurls
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enqueue ставит задачи в очередь и возвращает их идентификаторы.
+func enqueue(t *testing.T, q *FileQueue, tasks ...DeleteTask) []int64 {
+	t.Helper()
+	ids := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		id, err := q.Enqueue(context.Background(), task)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func pending(t *testing.T, q *FileQueue) []DeleteTask {
+	t.Helper()
+	tasks, err := q.Pending(context.Background())
+	require.NoError(t, err)
+	return tasks
+}
+
+func TestFileQueue_ReopenRestoresUnackedTasks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delete.journal")
+	ctx := context.Background()
+
+	q, err := OpenFileQueue(path)
+	require.NoError(t, err)
+	ids := enqueue(t, q,
+		DeleteTask{UserID: "user1", ShortURLs: []string{"a", "b"}},
+		DeleteTask{UserID: "user2", ShortURLs: []string{"c"}},
+		DeleteTask{UserID: "user1", ShortURLs: []string{"a"}, Restore: true},
+	)
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+	require.NoError(t, q.Ack(ctx, []int64{2}))
+	require.NoError(t, q.Close())
+
+	q, err = OpenFileQueue(path)
+	require.NoError(t, err)
+	defer q.Close()
+
+	want := []DeleteTask{
+		{ID: 1, UserID: "user1", ShortURLs: []string{"a", "b"}},
+		{ID: 3, UserID: "user1", ShortURLs: []string{"a"}, Restore: true},
+	}
+	assert.Equal(t, want, pending(t, q))
+
+	// Журнал перезаписан без подтверждённой задачи и подтверждения
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+	assert.NotContains(t, string(data), "ack")
+
+	// Идентификаторы продолжаются после восстановленных задач
+	assert.Equal(t, []int64{4}, enqueue(t, q, DeleteTask{UserID: "user3", ShortURLs: []string{"d"}}))
+}
+
+func TestFileQueue_TornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delete.journal")
+
+	q, err := OpenFileQueue(path)
+	require.NoError(t, err)
+	enqueue(t, q,
+		DeleteTask{UserID: "user1", ShortURLs: []string{"a"}},
+		DeleteTask{UserID: "user1", ShortURLs: []string{"b"}},
+	)
+	require.NoError(t, q.Close())
+
+	// Запись, прерванная аварийным завершением до fsync
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id":3,"user_id":"user1","short_u`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q, err = OpenFileQueue(path)
+	require.NoError(t, err)
+
+	tasks := pending(t, q)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, []int64{1, 2}, []int64{tasks[0].ID, tasks[1].ID})
+
+	// Неполная строка удалена из журнала и не склеивается со следующей записью
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "short_u\"")
+	assert.True(t, strings.HasSuffix(string(data), "\n"))
+
+	// Идентификатор неподтверждённой постановки выдаётся заново
+	assert.Equal(t, []int64{3}, enqueue(t, q, DeleteTask{UserID: "user1", ShortURLs: []string{"c"}}))
+	require.NoError(t, q.Close())
+
+	q, err = OpenFileQueue(path)
+	require.NoError(t, err)
+	defer q.Close()
+	tasks = pending(t, q)
+	require.Len(t, tasks, 3)
+	assert.Equal(t, []string{"c"}, tasks[2].ShortURLs)
+}
+
+func TestFileQueue_CorruptedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delete.journal")
+	require.NoError(t, os.WriteFile(path, []byte("{\"id\":1,\"user_id\":\"user1\"}\nnot json\n"), 0644))
+
+	// Повреждение в середине журнала - не последствие аварийного завершения
+	_, err := OpenFileQueue(path)
+	assert.Error(t, err)
+}
+
+func TestFileQueue_AckAllTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delete.journal")
+	ctx := context.Background()
+
+	q, err := OpenFileQueue(path)
+	require.NoError(t, err)
+	ids := enqueue(t, q,
+		DeleteTask{UserID: "user1", ShortURLs: []string{"a"}},
+		DeleteTask{UserID: "user2", ShortURLs: []string{"b"}},
+	)
+
+	require.NoError(t, q.Ack(ctx, ids[:1]))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Size())
+
+	// Подтверждение последней задачи очищает журнал
+	require.NoError(t, q.Ack(ctx, ids[1:]))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	// Запись после очистки дописывается с начала файла
+	enqueue(t, q, DeleteTask{UserID: "user3", ShortURLs: []string{"c"}})
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `{"id":3,`), string(data))
+	require.NoError(t, q.Close())
+
+	q, err = OpenFileQueue(path)
+	require.NoError(t, err)
+	defer q.Close()
+
+	// Подтверждённые задачи не повторяются
+	tasks := pending(t, q)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "user3", tasks[0].UserID)
+}
@@ -0,0 +1,41 @@
+package deleteurls
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy задаёт повторные попытки пометки URL при ошибках хранилища.
+type RetryPolicy struct {
+	MaxAttempts    int           // Общее количество попыток, включая первую
+	InitialBackoff time.Duration // Пауза перед второй попыткой
+	MaxBackoff     time.Duration // Верхняя граница паузы
+}
+
+// DefaultRetryPolicy - политика повторов по умолчанию.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// backoff возвращает паузу перед попыткой attempt+1 (attempt начинается с 1).
+//
+// Пауза удваивается с каждой попыткой до MaxBackoff, а затем случайно
+// уменьшается не более чем вдвое, чтобы повторы разных пакетов
+// не приходили в хранилище одновременно.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
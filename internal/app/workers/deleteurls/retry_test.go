@@ -0,0 +1,213 @@
+package deleteurls
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+)
+
+// stubRepository помечает URL удалёнными (восстановленными), если вызов
+// не попадает в число первых failures вызовов, завершающихся ошибкой err,
+// и не содержит код failOn.
+type stubRepository struct {
+	mu       sync.Mutex
+	failures int
+	failOn   string
+	err      error
+	calls    int
+	deleted  []string
+	restored []string
+}
+
+func (r *stubRepository) call(urls []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.calls <= r.failures {
+		return r.err
+	}
+	if r.failOn != "" && slices.Contains(urls, r.failOn) {
+		return r.err
+	}
+	return nil
+}
+
+func (r *stubRepository) BatchMarkAsDeleted(_ context.Context, _ string, urls []string) (models.DeleteResult, error) {
+	if err := r.call(urls); err != nil {
+		return models.DeleteResult{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = append(r.deleted, urls...)
+	return models.DeleteResult{Deleted: urls}, nil
+}
+
+func (r *stubRepository) BatchRestore(_ context.Context, _ string, urls []string, _ time.Time) (models.RestoreResult, error) {
+	if err := r.call(urls); err != nil {
+		return models.RestoreResult{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.restored = append(r.restored, urls...)
+	return models.RestoreResult{Restored: urls}, nil
+}
+
+// setFailures задаёт количество следующих вызовов, завершающихся ошибкой.
+func (r *stubRepository) setFailures(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = r.calls + n
+}
+
+func (r *stubRepository) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+func (r *stubRepository) deletedURLs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.deleted...)
+}
+
+// fastRetry - политика с короткими паузами для тестов.
+var fastRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{attempt: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 3, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{attempt: 4, min: 400 * time.Millisecond, max: 800 * time.Millisecond},
+		// Дальше пауза ограничена MaxBackoff
+		{attempt: 5, min: 500 * time.Millisecond, max: time.Second},
+		{attempt: 50, min: 500 * time.Millisecond, max: time.Second},
+	}
+
+	for _, tt := range tests {
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 1000; i++ {
+			delay := policy.backoff(tt.attempt)
+			require.GreaterOrEqual(t, delay, tt.min, "attempt %d", tt.attempt)
+			require.LessOrEqual(t, delay, tt.max, "attempt %d", tt.attempt)
+			seen[delay] = true
+		}
+		// Паузы случайны, а не совпадают у всех пакетов
+		assert.Greater(t, len(seen), 1, "attempt %d", tt.attempt)
+	}
+
+	t.Run("zero backoff", func(t *testing.T) {
+		assert.Zero(t, RetryPolicy{MaxAttempts: 3}.backoff(1))
+		assert.Zero(t, RetryPolicy{MaxAttempts: 3}.backoff(5))
+	})
+
+	t.Run("initial backoff above max", func(t *testing.T) {
+		p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 100 * time.Millisecond}
+		for i := 0; i < 100; i++ {
+			delay := p.backoff(1)
+			assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+			assert.LessOrEqual(t, delay, 100*time.Millisecond)
+		}
+	})
+}
+
+func TestDeleteWorker_Retry(t *testing.T) {
+	failure := errors.New("connection refused")
+
+	t.Run("succeeds after transient errors", func(t *testing.T) {
+		repo := &stubRepository{failures: 2, err: failure}
+		dead := NewMemoryDeadLetters(10)
+		w := NewDeleteWorker(1, 1, 10*time.Millisecond, repo, WithRetryPolicy(fastRetry), WithDeadLetters(dead))
+		w.Start()
+
+		_, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a", "b"}})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return len(repo.deletedURLs()) == 2 }, time.Second, time.Millisecond)
+		w.GracefulStop(time.Second)
+
+		assert.Equal(t, 3, repo.callCount())
+		assert.Equal(t, Stats{Deleted: 2, Errors: 2, Retries: 2}, w.Stats())
+		letters, err := dead.List(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, letters)
+	})
+
+	t.Run("moves task to dead letters after max attempts", func(t *testing.T) {
+		repo := &stubRepository{failures: 100, err: failure}
+		dead := NewMemoryDeadLetters(10)
+		queue, err := OpenFileQueue(t.TempDir() + "/delete.journal")
+		require.NoError(t, err)
+		defer queue.Close()
+
+		w := NewDeleteWorker(1, 1, 10*time.Millisecond, repo,
+			WithRetryPolicy(fastRetry), WithDeadLetters(dead), WithQueue(queue))
+		w.Start()
+
+		before := time.Now()
+		jobID, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a", "b"}})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return w.Stats().Dead == 1 }, time.Second, time.Millisecond)
+		w.GracefulStop(time.Second)
+
+		// Попыток ровно MaxAttempts, после последней повторов нет
+		assert.Equal(t, fastRetry.MaxAttempts, repo.callCount())
+		assert.Equal(t, Stats{Errors: 3, Retries: 2, Dead: 1}, w.Stats())
+
+		letters, err := dead.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		letter := letters[0]
+		assert.Equal(t, int64(1), letter.ID)
+		assert.Equal(t, "user1", letter.UserID)
+		assert.Equal(t, []string{"a", "b"}, letter.ShortURLs)
+		assert.Equal(t, fastRetry.MaxAttempts, letter.Attempts)
+		assert.Equal(t, failure.Error(), letter.LastError)
+		assert.False(t, letter.FailedAt.Before(before))
+		assert.Equal(t, []int64{1}, letter.TaskIDs)
+
+		// Задача не подтверждена в постоянной очереди
+		pending, err := queue.Pending(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, pending, 1)
+
+		job, ok := w.Job(jobID)
+		require.True(t, ok)
+		assert.Equal(t, models.DeleteJobFailed, job.Status)
+	})
+
+	t.Run("stop during backoff does not dead-letter", func(t *testing.T) {
+		repo := &stubRepository{failures: 100, err: failure}
+		dead := NewMemoryDeadLetters(10)
+		slow := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Minute}
+		w := NewDeleteWorker(1, 1, 10*time.Millisecond, repo, WithRetryPolicy(slow), WithDeadLetters(dead))
+		w.Start()
+
+		_, err := w.Submit(DeleteTask{UserID: "user1", ShortURLs: []string{"a"}})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return repo.callCount() == 1 }, time.Second, time.Millisecond)
+
+		// Таймаут остановки прерывает паузу; задача будет повторена после перезапуска
+		w.GracefulStop(20 * time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		assert.Equal(t, 1, repo.callCount())
+		assert.Zero(t, w.Stats().Dead)
+		letters, err := dead.List(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, letters)
+	})
+}
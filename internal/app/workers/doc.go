@@ -10,6 +10,22 @@
 //
 //   - Graceful shutdown
 //
+//   - **PurgeWorker**: Периодическая очистка устаревших URL
+//
+//   - Физическое удаление помеченных как удалённые и истёкших ссылок
+//
+//   - Настраиваемый срок хранения и размер порции
+//
+//   - Счётчики проходов и удалённых записей
+//
+//   - **clickstats.Recorder**: Асинхронная запись статистики переходов
+//
+//   - Неблокирующая постановка событий в очередь
+//
+//   - Запись пакетами по размеру или по времени
+//
+//   - Отбрасывание событий при переполнении очереди
+//
 //   - **TaskQueue**: Очередь задач для фоновой обработки
 //
 //   - Буферизованный канал задач
@@ -30,11 +46,14 @@
 //	worker.Start()
 //
 //	// Отправка задач
-//	worker.Submit(workers.DeleteTask{
+//	jobID, err := worker.Submit(workers.DeleteTask{
 //	    UserID:    "123",
 //	    ShortURLs: []string{"abc", "def"},
 //	})
 //
+//	// Опрос хода удаления
+//	job, ok := worker.Job(jobID)
+//
 //	// Остановка
 //	worker.GracefulStop(5 * time.Second)
 //
@@ -0,0 +1,180 @@
+// Package purgeurls предоставляет фоновый обработчик (janitor) для физического удаления
+// устаревших записей из хранилища сервиса сокращения ссылок.
+//
+// Пакет реализует:
+// - Периодическую очистку помеченных как удалённые и истёкших ссылок
+// - Настраиваемый срок хранения (retention) перед физическим удалением
+// - Удаление порциями ограниченного размера, чтобы не блокировать хранилище
+// - Счётчики выполненных проходов, удалённых записей и ошибок
+// - Поддержку плавного завершения работы
+package purgeurls
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultBatchSize - размер порции удаления, если задан неположительный размер.
+const DefaultBatchSize = 1000
+
+// Repository определяет интерфейс хранилища, необходимый для работы PurgeWorker.
+type Repository interface {
+	// PurgeURLs физически удаляет не более limit записей, которые были помечены как удалённые
+	// или срок действия которых истёк раньше момента before.
+	// Возвращает количество удалённых записей.
+	PurgeURLs(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// Stats содержит счётчики работы PurgeWorker.
+type Stats struct {
+	Runs   uint64 // Количество выполненных проходов очистки
+	Purged uint64 // Общее количество физически удалённых записей
+	Errors uint64 // Количество ошибок при обращении к хранилищу
+}
+
+// PurgeWorker периодически удаляет из хранилища записи старше срока хранения.
+type PurgeWorker struct {
+	repo      Repository
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	interval  time.Duration
+	retention time.Duration
+	batchSize int
+	log       *zap.Logger
+
+	runs   atomic.Uint64
+	purged atomic.Uint64
+	errors atomic.Uint64
+}
+
+// Option задаёт дополнительный параметр PurgeWorker.
+type Option func(*PurgeWorker)
+
+// WithLogger задаёт логгер воркера. По умолчанию события не логируются.
+func WithLogger(log *zap.Logger) Option {
+	return func(w *PurgeWorker) {
+		w.log = log
+	}
+}
+
+// NewPurgeWorker создает новый экземпляр PurgeWorker с заданными параметрами.
+//
+// Параметры:
+//   - interval: период между проходами очистки
+//   - retention: сколько хранить удалённые и истёкшие записи перед физическим удалением
+//   - batchSize: максимальное количество записей, удаляемых за одно обращение к хранилищу
+//     (неположительное значение заменяется на DefaultBatchSize)
+//   - storage: реализация интерфейса Repository
+//   - opts: дополнительные параметры (например, WithLogger)
+func NewPurgeWorker(interval, retention time.Duration, batchSize int, storage Repository, opts ...Option) *PurgeWorker {
+	// При нулевой порции RunOnce никогда не получил бы неполную порцию и не завершился бы
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	w := &PurgeWorker{
+		repo:      storage,
+		stopChan:  make(chan struct{}),
+		interval:  interval,
+		retention: retention,
+		batchSize: batchSize,
+		log:       zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Start запускает периодическую очистку в отдельной горутине.
+func (w *PurgeWorker) Start() {
+	w.wg.Add(1)
+	go w.loop()
+}
+
+// Stats возвращает текущие значения счётчиков.
+func (w *PurgeWorker) Stats() Stats {
+	return Stats{
+		Runs:   w.runs.Load(),
+		Purged: w.purged.Load(),
+		Errors: w.errors.Load(),
+	}
+}
+
+// loop запускает проходы очистки по таймеру до получения сигнала остановки.
+func (w *PurgeWorker) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+			w.RunOnce(context.Background())
+		}
+	}
+}
+
+// RunOnce выполняет один проход очистки.
+//
+// Записи удаляются порциями по batchSize, пока хранилище возвращает полные порции.
+// Проход прерывается при ошибке хранилища или получении сигнала остановки.
+// Возвращает количество удалённых за проход записей.
+func (w *PurgeWorker) RunOnce(ctx context.Context) int {
+	w.runs.Add(1)
+
+	before := time.Now().Add(-w.retention)
+	total := 0
+
+	for {
+		select {
+		case <-w.stopChan:
+			return total
+		default:
+		}
+
+		n, err := w.repo.PurgeURLs(ctx, before, w.batchSize)
+		if err != nil {
+			w.errors.Add(1)
+			w.log.Error("Failed to purge expired URLs",
+				zap.Int("purged", total),
+				zap.Error(err))
+			return total
+		}
+
+		total += n
+		w.purged.Add(uint64(n))
+
+		if n < w.batchSize {
+			if total > 0 {
+				w.log.Info("Expired URLs purged", zap.Int("count", total))
+			}
+			return total
+		}
+	}
+}
+
+// GracefulStop выполняет плавное завершение работы с заданным таймаутом.
+// Дожидается завершения текущего прохода очистки или истечения таймаута.
+func (w *PurgeWorker) GracefulStop(timeout time.Duration) {
+	close(w.stopChan)
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.log.Info("Purge worker stopped")
+	case <-time.After(timeout):
+		w.log.Warn("Timed out waiting for purge worker to stop")
+	}
+}
@@ -0,0 +1,120 @@
+package purgeurls
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubRepository возвращает заранее заданные результаты PurgeURLs по порядку.
+type stubRepository struct {
+	mu      sync.Mutex
+	results []int
+	errs    []error
+	calls   int
+	limits  []int
+	before  []time.Time
+}
+
+func (r *stubRepository) PurgeURLs(ctx context.Context, before time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.calls
+	r.calls++
+	r.limits = append(r.limits, limit)
+	r.before = append(r.before, before)
+
+	if i < len(r.errs) && r.errs[i] != nil {
+		return 0, r.errs[i]
+	}
+	if i < len(r.results) {
+		return r.results[i], nil
+	}
+	return 0, nil
+}
+
+func TestPurgeWorker_RunOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   []int
+		errs      []error
+		wantTotal int
+		wantCalls int
+		wantStats Stats
+	}{
+		{
+			name:      "stops on short batch",
+			results:   []int{10, 10, 3, 10},
+			wantTotal: 23,
+			wantCalls: 3,
+			wantStats: Stats{Runs: 1, Purged: 23},
+		},
+		{
+			name:      "empty storage",
+			results:   []int{0},
+			wantTotal: 0,
+			wantCalls: 1,
+			wantStats: Stats{Runs: 1},
+		},
+		{
+			name:      "stops and counts error",
+			results:   []int{10, 0},
+			errs:      []error{nil, errors.New("connection refused")},
+			wantTotal: 10,
+			wantCalls: 2,
+			wantStats: Stats{Runs: 1, Purged: 10, Errors: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubRepository{results: tt.results, errs: tt.errs}
+			w := NewPurgeWorker(time.Hour, 24*time.Hour, 10, repo)
+
+			started := time.Now()
+			total := w.RunOnce(context.Background())
+
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Equal(t, tt.wantCalls, repo.calls)
+			assert.Equal(t, tt.wantStats, w.Stats())
+			for i := range repo.limits {
+				assert.Equal(t, 10, repo.limits[i])
+				// Граница считается от начала прохода с учётом срока хранения
+				assert.WithinDuration(t, started.Add(-24*time.Hour), repo.before[i], time.Second)
+			}
+		})
+	}
+
+	t.Run("counters accumulate across runs", func(t *testing.T) {
+		repo := &stubRepository{results: []int{4, 2}}
+		w := NewPurgeWorker(time.Hour, time.Hour, 10, repo)
+
+		w.RunOnce(context.Background())
+		w.RunOnce(context.Background())
+
+		assert.Equal(t, Stats{Runs: 2, Purged: 6}, w.Stats())
+	})
+
+	t.Run("stopped worker does not purge", func(t *testing.T) {
+		repo := &stubRepository{results: []int{10}}
+		w := NewPurgeWorker(time.Hour, time.Hour, 10, repo)
+		w.GracefulStop(time.Second)
+
+		assert.Equal(t, 0, w.RunOnce(context.Background()))
+		assert.Equal(t, 0, repo.calls)
+	})
+	t.Run("non-positive batch size uses default", func(t *testing.T) {
+		for _, batchSize := range []int{0, -5} {
+			repo := &stubRepository{results: []int{3}}
+			w := NewPurgeWorker(time.Hour, time.Hour, batchSize, repo)
+
+			assert.Equal(t, 3, w.RunOnce(context.Background()))
+			assert.Equal(t, []int{DefaultBatchSize}, repo.limits)
+		}
+	})
+}
@@ -0,0 +1,950 @@
+// Package fakeredis предоставляет встроенный сервер, совместимый с протоколом Redis (RESP),
+// для тестов хранилища без запущенного Redis.
+//
+// Поддерживается подмножество команд, используемое хранилищем redis:
+//   - Служебные: PING, AUTH, SELECT, FLUSHALL
+//   - Строки: GET, SET (NX, XX), MSETNX, DEL, EXISTS, INCR
+//   - Хеши: HGET, HMGET, HSET, HSETNX, HDEL, HGETALL, HLEN, HINCRBY
+//   - Множества: SADD, SREM, SCARD
+//   - Упорядоченные множества: ZADD (NX, XX, LT, GT), ZREM, ZSCORE, ZRANGEBYSCORE (LIMIT)
+//   - Транзакции: WATCH, UNWATCH, MULTI, EXEC, DISCARD
+//
+// Все команды выполняются под одной блокировкой, поэтому атомарны, как в Redis.
+// WATCH запоминает значения ключей: EXEC отменяется, если значение
+// отслеживаемого ключа изменилось (в отличие от Redis, запись того же
+// значения транзакцию не отменяет).
+// Срок жизни ключей и несколько баз данных не поддерживаются.
+//
+// Пример использования:
+//
+//	srv, err := fakeredis.NewServer()
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer srv.Close()
+//
+//	st, err := redis.NewRedisStorage(srv.URL())
+package fakeredis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server - встроенный сервер, совместимый с протоколом Redis.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	strings map[string]string
+	hashes  map[string]map[string]string
+	sets    map[string]map[string]struct{}
+	zsets   map[string]map[string]float64
+	conns   map[net.Conn]struct{}
+	closed  bool
+}
+
+// reply - ответ сервера.
+type (
+	simpleString string
+	errorReply   string
+	bulkString   *string
+)
+
+// Типовые ответы.
+var (
+	replyOK        = simpleString("OK")
+	errWrongType   = errorReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errSyntax      = errorReply("ERR syntax error")
+	errNotInteger  = errorReply("ERR value is not an integer or out of range")
+	errNotFloat    = errorReply("ERR value is not a valid float")
+	errUnknownArgs = "ERR wrong number of arguments for '%s' command"
+	errExecAbort   = errorReply("EXECABORT Transaction discarded because of previous errors.")
+	replyQueued    = simpleString("QUEUED")
+)
+
+// nilArray - отсутствующий массив (ответ EXEC отменённой транзакции).
+type nilArray struct{}
+
+// session - состояние транзакции соединения.
+type session struct {
+	watched map[string]string // Ключ -> значение на момент WATCH
+	multi   bool
+	queued  [][]string
+	failed  bool // Команда транзакции отклонена при постановке в очередь
+}
+
+// NewServer запускает сервер на случайном порту локального интерфейса.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		strings:  make(map[string]string),
+		hashes:   make(map[string]map[string]string),
+		sets:     make(map[string]map[string]struct{}),
+		zsets:    make(map[string]map[string]float64),
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr возвращает адрес сервера в формате host:port.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// URL возвращает адрес сервера в формате redis://host:port.
+func (s *Server) URL() string {
+	return "redis://" + s.Addr()
+}
+
+// FlushAll удаляет все ключи (аналог команды FLUSHALL).
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cmdFlushAll(s, nil)
+}
+
+// Close останавливает сервер и закрывает соединения клиентов.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// serve принимает соединения до вызова Close.
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// handle обрабатывает команды одного соединения.
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	sess := &session{}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				writeReply(w, errorReply("ERR protocol error: "+err.Error()))
+				w.Flush()
+			}
+			return
+		}
+
+		writeReply(w, s.handleCommand(sess, args))
+
+		// Ответы на конвейер команд отправляются одним пакетом
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readCommand читает команду - массив строк RESP.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return nil, errors.New("expected array")
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n <= 0 {
+		return nil, errors.New("invalid array length")
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errors.New("expected bulk string")
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, errors.New("invalid bulk length")
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// readLine читает строку протокола без завершающего CRLF.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// writeReply сериализует ответ.
+func writeReply(w *bufio.Writer, reply any) {
+	switch v := reply.(type) {
+	case simpleString:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case errorReply:
+		fmt.Fprintf(w, "-%s\r\n", v)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case int:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case bulkString:
+		if v == nil {
+			w.WriteString("$-1\r\n")
+		} else {
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(*v), *v)
+		}
+	case nilArray:
+		w.WriteString("*-1\r\n")
+	case []any:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	default:
+		fmt.Fprintf(w, "-ERR internal error: unexpected reply %T\r\n", reply)
+	}
+}
+
+// nilBulk - отсутствующее значение.
+var nilBulk = bulkString(nil)
+
+// handleCommand выполняет команду соединения с учётом транзакции.
+func (s *Server) handleCommand(sess *session, args []string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name := strings.ToUpper(args[0]); name {
+	case "MULTI":
+		if sess.multi {
+			return errorReply("ERR MULTI calls can not be nested")
+		}
+		sess.multi = true
+		return replyOK
+
+	case "EXEC":
+		if !sess.multi {
+			return errorReply("ERR EXEC without MULTI")
+		}
+		defer sess.reset()
+		if sess.failed {
+			return errExecAbort
+		}
+		for key, value := range sess.watched {
+			if s.dump(key) != value {
+				return nilArray{}
+			}
+		}
+		replies := make([]any, len(sess.queued))
+		for i, queued := range sess.queued {
+			replies[i] = s.exec(queued)
+		}
+		return replies
+
+	case "DISCARD":
+		if !sess.multi {
+			return errorReply("ERR DISCARD without MULTI")
+		}
+		sess.reset()
+		return replyOK
+
+	case "WATCH":
+		if sess.multi {
+			return errorReply("ERR WATCH inside MULTI is not allowed")
+		}
+		if len(args) < 2 {
+			return errorReply(fmt.Sprintf(errUnknownArgs, "watch"))
+		}
+		if sess.watched == nil {
+			sess.watched = make(map[string]string)
+		}
+		for _, key := range args[1:] {
+			if _, ok := sess.watched[key]; !ok {
+				sess.watched[key] = s.dump(key)
+			}
+		}
+		return replyOK
+
+	case "UNWATCH":
+		sess.watched = nil
+		return replyOK
+	}
+
+	if sess.multi {
+		if _, errReply := lookup(args); errReply != nil {
+			sess.failed = true
+			return errReply
+		}
+		sess.queued = append(sess.queued, args)
+		return replyQueued
+	}
+	return s.exec(args)
+}
+
+// reset завершает транзакцию и снимает отслеживание ключей.
+func (sess *session) reset() {
+	*sess = session{}
+}
+
+// lookup находит команду и проверяет количество аргументов.
+func lookup(args []string) (command, any) {
+	name := strings.ToUpper(args[0])
+	cmd, ok := commands[name]
+	if !ok {
+		return command{}, errorReply(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+	if len(args)-1 < cmd.minArgs || (cmd.step > 0 && (len(args)-1-cmd.minArgs)%cmd.step != 0) {
+		return command{}, errorReply(fmt.Sprintf(errUnknownArgs, strings.ToLower(name)))
+	}
+	return cmd, nil
+}
+
+// exec выполняет команду. Вызывается под блокировкой сервера.
+func (s *Server) exec(args []string) any {
+	cmd, errReply := lookup(args)
+	if errReply != nil {
+		return errReply
+	}
+	return cmd.fn(s, args[1:])
+}
+
+// dump возвращает представление значения ключа для сравнения в WATCH.
+func (s *Server) dump(key string) string {
+	var b strings.Builder
+	if v, ok := s.strings[key]; ok {
+		fmt.Fprintf(&b, "string:%q", v)
+	}
+	if h, ok := s.hashes[key]; ok {
+		fields := make([]string, 0, len(h))
+		for field, value := range h {
+			fields = append(fields, fmt.Sprintf("%q=%q", field, value))
+		}
+		sort.Strings(fields)
+		fmt.Fprintf(&b, "hash:%s", strings.Join(fields, ","))
+	}
+	if set, ok := s.sets[key]; ok {
+		members := make([]string, 0, len(set))
+		for member := range set {
+			members = append(members, strconv.Quote(member))
+		}
+		sort.Strings(members)
+		fmt.Fprintf(&b, "set:%s", strings.Join(members, ","))
+	}
+	if zset, ok := s.zsets[key]; ok {
+		members := make([]string, 0, len(zset))
+		for member, score := range zset {
+			members = append(members, fmt.Sprintf("%q=%v", member, score))
+		}
+		sort.Strings(members)
+		fmt.Fprintf(&b, "zset:%s", strings.Join(members, ","))
+	}
+	return b.String()
+}
+
+// command описывает команду: минимальное количество аргументов
+// и шаг повторяющихся аргументов (0 - произвольное количество).
+type command struct {
+	minArgs int
+	step    int
+	fn      func(s *Server, args []string) any
+}
+
+// commands - поддерживаемые команды.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"PING":     {0, 0, cmdPing},
+		"AUTH":     {1, 0, func(*Server, []string) any { return replyOK }},
+		"SELECT":   {1, 0, func(*Server, []string) any { return replyOK }},
+		"FLUSHALL": {0, 0, cmdFlushAll},
+
+		"GET":    {1, 0, cmdGet},
+		"SET":    {2, 0, cmdSet},
+		"MSETNX": {2, 2, cmdMSetNX},
+		"DEL":    {1, 0, cmdDel},
+		"EXISTS": {1, 0, cmdExists},
+		"INCR":   {1, 0, cmdIncr},
+
+		"HGET":    {2, 0, cmdHGet},
+		"HMGET":   {2, 0, cmdHMGet},
+		"HSET":    {3, 2, cmdHSet},
+		"HSETNX":  {3, 0, cmdHSetNX},
+		"HDEL":    {2, 0, cmdHDel},
+		"HGETALL": {1, 0, cmdHGetAll},
+		"HLEN":    {1, 0, cmdHLen},
+		"HINCRBY": {3, 0, cmdHIncrBy},
+
+		"SADD":  {2, 0, cmdSAdd},
+		"SREM":  {2, 0, cmdSRem},
+		"SCARD": {1, 0, cmdSCard},
+
+		"ZADD":          {3, 0, cmdZAdd},
+		"ZREM":          {2, 0, cmdZRem},
+		"ZSCORE":        {2, 0, cmdZScore},
+		"ZRANGEBYSCORE": {3, 0, cmdZRangeByScore},
+	}
+}
+
+// checkType проверяет, что ключ отсутствует или хранит значение типа want.
+func (s *Server) checkType(key, want string) bool {
+	if _, ok := s.strings[key]; ok && want != "string" {
+		return false
+	}
+	if _, ok := s.hashes[key]; ok && want != "hash" {
+		return false
+	}
+	if _, ok := s.sets[key]; ok && want != "set" {
+		return false
+	}
+	if _, ok := s.zsets[key]; ok && want != "zset" {
+		return false
+	}
+	return true
+}
+
+// exists проверяет наличие ключа любого типа.
+func (s *Server) exists(key string) bool {
+	_, str := s.strings[key]
+	_, hash := s.hashes[key]
+	_, set := s.sets[key]
+	_, zset := s.zsets[key]
+	return str || hash || set || zset
+}
+
+// del удаляет ключ любого типа.
+func (s *Server) del(key string) bool {
+	if !s.exists(key) {
+		return false
+	}
+	delete(s.strings, key)
+	delete(s.hashes, key)
+	delete(s.sets, key)
+	delete(s.zsets, key)
+	return true
+}
+
+func cmdPing(_ *Server, args []string) any {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return simpleString("PONG")
+}
+
+func cmdFlushAll(s *Server, _ []string) any {
+	s.strings = make(map[string]string)
+	s.hashes = make(map[string]map[string]string)
+	s.sets = make(map[string]map[string]struct{})
+	s.zsets = make(map[string]map[string]float64)
+	return replyOK
+}
+
+// Строки.
+
+func cmdGet(s *Server, args []string) any {
+	if !s.checkType(args[0], "string") {
+		return errWrongType
+	}
+	if v, ok := s.strings[args[0]]; ok {
+		return v
+	}
+	return nilBulk
+}
+
+func cmdSet(s *Server, args []string) any {
+	key, value := args[0], args[1]
+	var nx, xx bool
+	for _, opt := range args[2:] {
+		switch strings.ToUpper(opt) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		default:
+			return errSyntax
+		}
+	}
+	if nx && xx {
+		return errSyntax
+	}
+
+	exists := s.exists(key)
+	if (nx && exists) || (xx && !exists) {
+		return nilBulk
+	}
+	s.del(key)
+	s.strings[key] = value
+	return replyOK
+}
+
+func cmdMSetNX(s *Server, args []string) any {
+	for i := 0; i < len(args); i += 2 {
+		if s.exists(args[i]) {
+			return int64(0)
+		}
+	}
+	for i := 0; i < len(args); i += 2 {
+		s.strings[args[i]] = args[i+1]
+	}
+	return int64(1)
+}
+
+func cmdDel(s *Server, args []string) any {
+	var n int64
+	for _, key := range args {
+		if s.del(key) {
+			n++
+		}
+	}
+	return n
+}
+
+func cmdExists(s *Server, args []string) any {
+	var n int64
+	for _, key := range args {
+		if s.exists(key) {
+			n++
+		}
+	}
+	return n
+}
+
+func cmdIncr(s *Server, args []string) any {
+	if !s.checkType(args[0], "string") {
+		return errWrongType
+	}
+	value := int64(0)
+	if v, ok := s.strings[args[0]]; ok {
+		var err error
+		if value, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return errNotInteger
+		}
+	}
+	value++
+	s.strings[args[0]] = strconv.FormatInt(value, 10)
+	return value
+}
+
+// Хеши.
+
+// hash возвращает хеш ключа (nil, если ключа нет) или ошибку типа.
+func (s *Server) hash(key string, create bool) (map[string]string, any) {
+	if !s.checkType(key, "hash") {
+		return nil, errWrongType
+	}
+	h, ok := s.hashes[key]
+	if !ok && create {
+		h = make(map[string]string)
+		s.hashes[key] = h
+	}
+	return h, nil
+}
+
+func cmdHGet(s *Server, args []string) any {
+	h, errReply := s.hash(args[0], false)
+	if errReply != nil {
+		return errReply
+	}
+	if v, ok := h[args[1]]; ok {
+		return v
+	}
+	return nilBulk
+}
+
+func cmdHMGet(s *Server, args []string) any {
+	h, errReply := s.hash(args[0], false)
+	if errReply != nil {
+		return errReply
+	}
+	result := make([]any, 0, len(args)-1)
+	for _, field := range args[1:] {
+		if v, ok := h[field]; ok {
+			result = append(result, v)
+		} else {
+			result = append(result, nilBulk)
+		}
+	}
+	return result
+}
+
+func cmdHSet(s *Server, args []string) any {
+	h, errReply := s.hash(args[0], true)
+	if errReply != nil {
+		return errReply
+	}
+	var n int64
+	for i := 1; i < len(args); i += 2 {
+		if _, ok := h[args[i]]; !ok {
+			n++
+		}
+		h[args[i]] = args[i+1]
+	}
+	return n
+}
+
+func cmdHSetNX(s *Server, args []string) any {
+	h, errReply := s.hash(args[0], true)
+	if errReply != nil {
+		return errReply
+	}
+	if _, ok := h[args[1]]; ok {
+		return int64(0)
+	}
+	h[args[1]] = args[2]
+	return int64(1)
+}
+
+func cmdHDel(s *Server, args []string) any {
+	h, errReply := s.hash(args[0], false)
+	if errReply != nil {
+		return errReply
+	}
+	var n int64
+	for _, field := range args[1:] {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			n++
+		}
+	}
+	if h != nil && len(h) == 0 {
+		delete(s.hashes, args[0])
+	}
+	return n
+}
+
+func cmdHGetAll(s *Server, args []string) any {
+	h, errReply := s.hash(args[0], false)
+	if errReply != nil {
+		return errReply
+	}
+	result := make([]any, 0, 2*len(h))
+	for field, value := range h {
+		result = append(result, field, value)
+	}
+	return result
+}
+
+func cmdHLen(s *Server, args []string) any {
+	h, errReply := s.hash(args[0], false)
+	if errReply != nil {
+		return errReply
+	}
+	return int64(len(h))
+}
+
+func cmdHIncrBy(s *Server, args []string) any {
+	delta, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	h, errReply := s.hash(args[0], true)
+	if errReply != nil {
+		return errReply
+	}
+	value := int64(0)
+	if v, ok := h[args[1]]; ok {
+		if value, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return errorReply("ERR hash value is not an integer")
+		}
+	}
+	value += delta
+	h[args[1]] = strconv.FormatInt(value, 10)
+	return value
+}
+
+// Множества.
+
+func cmdSAdd(s *Server, args []string) any {
+	if !s.checkType(args[0], "set") {
+		return errWrongType
+	}
+	set, ok := s.sets[args[0]]
+	if !ok {
+		set = make(map[string]struct{})
+		s.sets[args[0]] = set
+	}
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := set[member]; !ok {
+			set[member] = struct{}{}
+			n++
+		}
+	}
+	return n
+}
+
+func cmdSRem(s *Server, args []string) any {
+	if !s.checkType(args[0], "set") {
+		return errWrongType
+	}
+	set := s.sets[args[0]]
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := set[member]; ok {
+			delete(set, member)
+			n++
+		}
+	}
+	if set != nil && len(set) == 0 {
+		delete(s.sets, args[0])
+	}
+	return n
+}
+
+func cmdSCard(s *Server, args []string) any {
+	if !s.checkType(args[0], "set") {
+		return errWrongType
+	}
+	return int64(len(s.sets[args[0]]))
+}
+
+// Упорядоченные множества.
+
+func cmdZAdd(s *Server, args []string) any {
+	key := args[0]
+	var nx, xx, lt, gt bool
+	i := 1
+options:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "LT":
+			lt = true
+		case "GT":
+			gt = true
+		default:
+			break options
+		}
+	}
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 || (nx && xx) || (lt && gt) || (nx && (lt || gt)) {
+		return errSyntax
+	}
+	if !s.checkType(key, "zset") {
+		return errWrongType
+	}
+
+	scores := make([]float64, len(pairs)/2)
+	for j := range scores {
+		score, err := parseScore(pairs[2*j])
+		if err != nil {
+			return errNotFloat
+		}
+		scores[j] = score
+	}
+
+	zset, ok := s.zsets[key]
+	if !ok {
+		zset = make(map[string]float64)
+	}
+
+	var added int64
+	for j, score := range scores {
+		member := pairs[2*j+1]
+		current, exists := zset[member]
+		switch {
+		case exists && nx, !exists && xx:
+			continue
+		case exists && lt && score >= current, exists && gt && score <= current:
+			continue
+		}
+		if !exists {
+			added++
+		}
+		zset[member] = score
+	}
+
+	if len(zset) > 0 {
+		s.zsets[key] = zset
+	}
+	return added
+}
+
+func cmdZRem(s *Server, args []string) any {
+	if !s.checkType(args[0], "zset") {
+		return errWrongType
+	}
+	zset := s.zsets[args[0]]
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := zset[member]; ok {
+			delete(zset, member)
+			n++
+		}
+	}
+	if zset != nil && len(zset) == 0 {
+		delete(s.zsets, args[0])
+	}
+	return n
+}
+
+func cmdZScore(s *Server, args []string) any {
+	if !s.checkType(args[0], "zset") {
+		return errWrongType
+	}
+	if score, ok := s.zsets[args[0]][args[1]]; ok {
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	}
+	return nilBulk
+}
+
+func cmdZRangeByScore(s *Server, args []string) any {
+	if !s.checkType(args[0], "zset") {
+		return errWrongType
+	}
+
+	minScore, minExcl, err := parseBound(args[1])
+	if err != nil {
+		return errorReply("ERR min or max is not a float")
+	}
+	maxScore, maxExcl, err := parseBound(args[2])
+	if err != nil {
+		return errorReply("ERR min or max is not a float")
+	}
+
+	offset, count := 0, -1
+	var withScores bool
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "WITHSCORES":
+			withScores = true
+		case "LIMIT":
+			if i+2 >= len(args) {
+				return errSyntax
+			}
+			if offset, err = strconv.Atoi(args[i+1]); err != nil {
+				return errNotInteger
+			}
+			if count, err = strconv.Atoi(args[i+2]); err != nil {
+				return errNotInteger
+			}
+			i += 2
+		default:
+			return errSyntax
+		}
+	}
+
+	type entry struct {
+		member string
+		score  float64
+	}
+	var entries []entry
+	for member, score := range s.zsets[args[0]] {
+		if score < minScore || (minExcl && score == minScore) {
+			continue
+		}
+		if score > maxScore || (maxExcl && score == maxScore) {
+			continue
+		}
+		entries = append(entries, entry{member, score})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].score != entries[j].score {
+			return entries[i].score < entries[j].score
+		}
+		return entries[i].member < entries[j].member
+	})
+
+	if offset >= len(entries) {
+		entries = nil
+	} else {
+		entries = entries[offset:]
+	}
+	if count >= 0 && count < len(entries) {
+		entries = entries[:count]
+	}
+
+	result := make([]any, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.member)
+		if withScores {
+			result = append(result, strconv.FormatFloat(e.score, 'f', -1, 64))
+		}
+	}
+	return result
+}
+
+// parseScore разбирает оценку, включая -inf и +inf.
+func parseScore(value string) (float64, error) {
+	switch strings.ToLower(value) {
+	case "-inf":
+		return math.Inf(-1), nil
+	case "+inf", "inf":
+		return math.Inf(1), nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseBound разбирает границу диапазона ("(" означает строгое неравенство).
+func parseBound(value string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(value, "(")
+	score, err := parseScore(strings.TrimPrefix(value, "("))
+	return score, exclusive, err
+}
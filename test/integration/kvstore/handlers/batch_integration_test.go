@@ -0,0 +1,20 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestBatch_KV тестирует пакетное сокращение URL (HTTP) со встроенным хранилищем ключ-значение.
+// Пакет сохраняется одной транзакцией хранилища.
+func TestBatch_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestBatch(t, client)
+}
+
+// TestBatchGRPC_KV тестирует пакетное сокращение URL (gRPC) со встроенным хранилищем ключ-значение.
+func TestBatchGRPC_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestBatchGRPC(t, grpcClient)
+}
@@ -0,0 +1,19 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestDelUserUrls_KV тестирует асинхронное удаление URL пользователя (HTTP) со встроенным хранилищем ключ-значение.
+func TestDelUserUrls_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestDelUserUrls(t, serv, client)
+}
+
+// TestDelUserUrlsGRPC_KV тестирует асинхронное удаление URL пользователя (gRPC) со встроенным хранилищем ключ-значение.
+func TestDelUserUrlsGRPC_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestDelUserUrlsGRPC(t, serv, grpcClient)
+}
@@ -0,0 +1,169 @@
+package integration
+
+import (
+	"log"
+	"os"
+	"testing"
+
+	pb "github.com/ryabkov82/shortener/api"
+	grpchandlers "github.com/ryabkov82/shortener/internal/app/handlers/grpc"
+	"github.com/ryabkov82/shortener/internal/app/handlers/grpc/base"
+	grpcbatch "github.com/ryabkov82/shortener/internal/app/handlers/grpc/batch"
+	grpcdeluserurls "github.com/ryabkov82/shortener/internal/app/handlers/grpc/deluserurls"
+	grpcredirect "github.com/ryabkov82/shortener/internal/app/handlers/grpc/redirect"
+	grpcshorturl "github.com/ryabkov82/shortener/internal/app/handlers/grpc/shorturl"
+	grpcurlstats "github.com/ryabkov82/shortener/internal/app/handlers/grpc/urlstats"
+	grpcuserurls "github.com/ryabkov82/shortener/internal/app/handlers/grpc/userurls"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/batch"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/deluserurls"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/ping"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/redirect"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/shortenapi"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/shorturl"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/urlstats"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/userurls"
+
+	"github.com/ryabkov82/shortener/internal/app/logger"
+	"github.com/ryabkov82/shortener/internal/app/server/grpc/interceptors"
+	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/auth"
+	mwlogger "github.com/ryabkov82/shortener/internal/app/server/http/middleware/logger"
+	"github.com/ryabkov82/shortener/internal/app/server/http/middleware/mwgzip"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/test/testutils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc"
+)
+
+var (
+	client     *resty.Client
+	grpcClient pb.ShortenerClient
+	serv       *service.Service
+	testKV     service.Repository
+	rootDir    string
+	stop       func()
+)
+
+// TestMain является точкой входа для интеграционных тестов встроенного хранилища ключ-значение.
+//
+// Функция выполняет:
+//  1. Создание временного каталога для файлов хранилища
+//  2. Инициализацию хранилища, сервисного слоя, HTTP и gRPC серверов (см. start)
+//  3. Запуск всех тестов и очистку ресурсов
+//
+// Особенности:
+//   - Внешние зависимости не требуются: хранилище работает в процессе теста
+//   - Перед каждым тестом создается новое хранилище (см. resetStorage)
+//   - HTTP клиент сброшен (отключен cookie jar)
+func TestMain(m *testing.M) {
+	if err := logger.Initialize("debug"); err != nil {
+		panic(err)
+	}
+
+	dir, err := os.MkdirTemp("", "shortener-kv-")
+	if err != nil {
+		panic(err)
+	}
+	rootDir = dir
+
+	if stop, err = start(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	stop()
+	if err := os.RemoveAll(rootDir); err != nil {
+		log.Printf("Failed to remove storage directory: %v", err)
+	}
+
+	os.Exit(code)
+}
+
+// start открывает хранилище в новом каталоге и запускает серверы со всеми обработчиками.
+// Возвращает функцию остановки серверов и закрытия хранилища.
+func start() (func(), error) {
+	dir, err := os.MkdirTemp(rootDir, "db-")
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := testutils.InitializeKVStorage(dir)
+	if err != nil {
+		return nil, err
+	}
+	testKV = st
+
+	serv = service.NewService(testKV)
+
+	baseURL := "http://localhost:8080/"
+
+	tc := testutils.NewTestClient(func(r chi.Router) {
+		r.Use(mwlogger.RequestLogging(logger.Log))
+		r.Use(mwgzip.Gzip)
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.JWTAutoIssue(testutils.TestSecretKey))
+
+			r.Post("/", shorturl.GetHandler(serv, baseURL, logger.Log))
+			r.Get("/{id}", redirect.GetHandler(serv, logger.Log))
+			r.Post("/api/shorten", shortenapi.GetHandler(serv, baseURL, logger.Log))
+			r.Get("/ping", ping.GetHandler(serv, logger.Log))
+			r.Post("/api/shorten/batch", batch.GetHandler(serv, baseURL, logger.Log))
+		})
+
+		// Группа со строгой аутентификацией
+		r.Group(func(r chi.Router) {
+			r.Use(auth.StrictJWTAutoIssue(testutils.TestSecretKey))
+			r.Get("/api/user/urls", userurls.GetHandler(serv, baseURL, logger.Log))
+			r.Delete("/api/user/urls", deluserurls.GetHandler(serv, baseURL, logger.Log))
+			r.Get("/api/user/urls/{id}/stats", urlstats.GetHandler(serv, baseURL, logger.Log))
+		})
+	})
+
+	client = tc.Client
+	// Отключение cookie jar (не сохранять cookies)
+	client.SetCookieJar(nil)
+
+	baseHandler := &base.BaseHandler{Logger: logger.Log}
+	grpcURL := "http://localhost:8080"
+	gc, err := testutils.NewTestGRPCClient(
+		[]grpc.UnaryServerInterceptor{
+			interceptors.LoggingInterceptor(logger.Log),
+			interceptors.JWTAutoIssueGRPC(testutils.TestSecretKey, logger.Log),
+		},
+		grpchandlers.NewServer(
+			baseHandler,
+			grpchandlers.WithCreateShortURLEndpoint(grpcshorturl.New(baseHandler, serv, grpcURL)),
+			grpchandlers.WithGetOriginalURLEndpoint(grpcredirect.New(baseHandler, serv)),
+			grpchandlers.WithBatchCreateEndpoint(grpcbatch.New(baseHandler, serv, baseURL)),
+			grpchandlers.WithGetUserURLsEndpoint(grpcuserurls.New(baseHandler, serv, grpcURL)),
+			grpchandlers.WithDeleteUserURLsEndpoint(grpcdeluserurls.New(baseHandler, serv)),
+			grpchandlers.WithGetURLStatsEndpoint(grpcurlstats.New(baseHandler, serv, grpcURL)),
+		),
+		logger.Log,
+	)
+	if err != nil {
+		return nil, err
+	}
+	grpcClient = pb.NewShortenerClient(gc.Conn)
+
+	return func() {
+		gc.Close()
+		tc.Close()
+		serv.Close()
+	}, nil
+}
+
+// resetStorage заменяет хранилище новым пустым, чтобы HTTP и gRPC варианты
+// одних сценариев (например, занятие псевдонима) не влияли друг на друга.
+func resetStorage(t *testing.T) {
+	t.Helper()
+
+	stop()
+	var err error
+	if stop, err = start(); err != nil {
+		t.Fatalf("Failed to restart storage: %v", err)
+	}
+}
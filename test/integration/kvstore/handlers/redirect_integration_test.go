@@ -0,0 +1,19 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestRedirect_KV тестирует редиректы (HTTP) со встроенным хранилищем ключ-значение.
+func TestRedirect_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestRedirect(t, testKV, client)
+}
+
+// TestRedirectGRPC_KV тестирует получение оригинального URL (gRPC) со встроенным хранилищем ключ-значение.
+func TestRedirectGRPC_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestRedirectGRPC(t, testKV, grpcClient)
+}
@@ -0,0 +1,27 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestShortenURL_KV тестирует сокращение URL (POST /) со встроенным хранилищем ключ-значение.
+func TestShortenURL_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestShortenURL(t, client)
+}
+
+// TestShortenAPI_KV тестирует JSON API сокращения URL, включая псевдонимы, со встроенным хранилищем ключ-значение.
+func TestShortenAPI_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestShortenAPI(t, client)
+	testhandlers.TestShortenAPICustomAlias(t, client)
+}
+
+// TestShortenURLGRPC_KV тестирует сокращение URL (gRPC), включая псевдонимы, со встроенным хранилищем ключ-значение.
+func TestShortenURLGRPC_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestShortenURLGRPC(t, grpcClient)
+	testhandlers.TestShortenURLCustomAliasGRPC(t, grpcClient)
+}
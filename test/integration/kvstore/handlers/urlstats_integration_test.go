@@ -0,0 +1,21 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestURLStats_KV тестирует статистику переходов (HTTP) со встроенным хранилищем ключ-значение.
+//
+// Агрегаты переходов по ссылке обновляются одной транзакцией на пакет событий.
+func TestURLStats_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestURLStats(t, serv, client)
+}
+
+// TestURLStatsGRPC_KV тестирует статистику переходов (gRPC) со встроенным хранилищем ключ-значение.
+func TestURLStatsGRPC_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestURLStatsGRPC(t, serv, grpcClient)
+}
@@ -0,0 +1,19 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestUserUrls_KV тестирует получение URL пользователя (HTTP) со встроенным хранилищем ключ-значение.
+func TestUserUrls_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestUserUrls(t, serv, client)
+}
+
+// TestUserUrlsGRPC_KV тестирует получение URL пользователя (gRPC) со встроенным хранилищем ключ-значение.
+func TestUserUrlsGRPC_KV(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestUserUrlsGRPC(t, serv, grpcClient)
+}
@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage/postgres"
+)
+
+// pendingIDs возвращает идентификаторы задач пользователя userID, захваченных Pending.
+func pendingIDs(t *testing.T, q *postgres.DeleteQueue, userID string) []int64 {
+	t.Helper()
+	tasks, err := q.Pending(context.Background())
+	require.NoError(t, err)
+
+	ids := []int64{}
+	for _, task := range tasks {
+		if task.UserID == userID {
+			ids = append(ids, task.ID)
+		}
+	}
+	return ids
+}
+
+// TestDeleteQueue_Postgres_TwoConsumers проверяет, что экземпляры сервиса,
+// разделяющие очередь, не получают задачи друг друга.
+func TestDeleteQueue_Postgres_TwoConsumers(t *testing.T) {
+	pg := testPG.(*postgres.PostgresStorage)
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	t.Run("enqueued tasks stay with their owner", func(t *testing.T) {
+		userID := uuid.NewString()
+		first, second := pg.DeleteQueue(), pg.DeleteQueue()
+		defer first.Close()
+		defer second.Close()
+
+		// Удаление и последующее восстановление, поставленные первым экземпляром
+		deleteID, err := first.Enqueue(ctx, models.DeleteTask{UserID: userID, ShortURLs: []string{"a"}})
+		require.NoError(t, err)
+		restoreID, err := first.Enqueue(ctx, models.DeleteTask{UserID: userID, ShortURLs: []string{"a"}, Restore: true})
+		require.NoError(t, err)
+
+		// Запущенный второй экземпляр не повторяет их
+		assert.Empty(t, pendingIDs(t, second, userID))
+		assert.Equal(t, []int64{deleteID, restoreID}, pendingIDs(t, first, userID))
+
+		// После подтверждения задачи не возвращаются никому
+		require.NoError(t, first.Ack(ctx, []int64{deleteID, restoreID}))
+		assert.Empty(t, pendingIDs(t, first, userID))
+		assert.Empty(t, pendingIDs(t, second, userID))
+	})
+
+	t.Run("closed queue releases its tasks", func(t *testing.T) {
+		userID := uuid.NewString()
+		first, second := pg.DeleteQueue(), pg.DeleteQueue()
+		defer second.Close()
+
+		id, err := first.Enqueue(ctx, models.DeleteTask{UserID: userID, ShortURLs: []string{"a"}})
+		require.NoError(t, err)
+		require.NoError(t, first.Close())
+
+		assert.Equal(t, []int64{id}, pendingIDs(t, second, userID))
+	})
+
+	t.Run("expired claim is taken over", func(t *testing.T) {
+		userID := uuid.NewString()
+		first, second := pg.DeleteQueue(), pg.DeleteQueue()
+		defer first.Close()
+		defer second.Close()
+
+		id, err := first.Enqueue(ctx, models.DeleteTask{UserID: userID, ShortURLs: []string{"a"}})
+		require.NoError(t, err)
+		// Владелец завершился аварийно и перестал продлевать захват
+		_, err = db.Exec("UPDATE delete_queue SET claimed_until = now() - interval '1 second' WHERE id = $1", id)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int64{id}, pendingIDs(t, second, userID))
+		// Задача перешла ко второму экземпляру
+		assert.Empty(t, pendingIDs(t, first, userID))
+	})
+
+	t.Run("concurrent consumers split free tasks", func(t *testing.T) {
+		userID := uuid.NewString()
+		first, second := pg.DeleteQueue(), pg.DeleteQueue()
+		defer first.Close()
+		defer second.Close()
+
+		// Свободные задачи, например оставшиеся со времени до захвата задач
+		const total = 200
+		want := make([]int64, 0, total)
+		for i := 0; i < total; i++ {
+			var id int64
+			require.NoError(t, db.QueryRow(
+				"INSERT INTO delete_queue (user_id, short_codes) VALUES ($1, $2) RETURNING id",
+				userID, []string{"a"},
+			).Scan(&id))
+			want = append(want, id)
+		}
+
+		var (
+			wg     sync.WaitGroup
+			claims [2][]models.DeleteTask
+			errs   [2]error
+		)
+		for i, q := range []*postgres.DeleteQueue{first, second} {
+			wg.Add(1)
+			go func(i int, q *postgres.DeleteQueue) {
+				defer wg.Done()
+				claims[i], errs[i] = q.Pending(ctx)
+			}(i, q)
+		}
+		wg.Wait()
+
+		// Каждая задача захвачена ровно одним экземпляром, в порядке постановки
+		var got []int64
+		for i, tasks := range claims {
+			require.NoError(t, errs[i])
+			var ids []int64
+			for _, task := range tasks {
+				if task.UserID == userID {
+					ids = append(ids, task.ID)
+				}
+			}
+			assert.True(t, sort.SliceIsSorted(ids, func(i, j int) bool { return ids[i] < ids[j] }))
+			got = append(got, ids...)
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		assert.Equal(t, want, got)
+	})
+}
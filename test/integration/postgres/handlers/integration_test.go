@@ -12,6 +12,7 @@ import (
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/redirect"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/shortenapi"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/shorturl"
+	"github.com/ryabkov82/shortener/internal/app/handlers/http/urlstats"
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/userurls"
 
 	"github.com/ryabkov82/shortener/internal/app/logger"
@@ -31,6 +32,8 @@ var (
 	client *resty.Client
 	serv   *service.Service
 	testPG service.Repository
+	// testDSN строка подключения к тестовой БД для прямых проверок данных
+	testDSN string
 )
 
 // TestMain является точкой входа для интеграционных тестов и настраивает тестовое окружение.
@@ -79,6 +82,7 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(err)
 	}
+	testDSN = dsn
 
 	// 2. Подготовка тестового окружения
 	if err = logger.Initialize("debug"); err != nil {
@@ -113,6 +117,7 @@ func TestMain(m *testing.M) {
 			r.Use(auth.StrictJWTAutoIssue(testutils.TestSecretKey))
 			r.Get("/api/user/urls", userurls.GetHandler(serv, baseURL, logger.Log))
 			r.Delete("/api/user/urls", deluserurls.GetHandler(serv, baseURL, logger.Log))
+			r.Get("/api/user/urls/{id}/stats", urlstats.GetHandler(serv, baseURL, logger.Log))
 		})
 	})
 
@@ -33,4 +33,5 @@ import (
 func TestShortenAPI_Postgres(t *testing.T) {
 
 	testhandlers.TestShortenAPI(t, client)
+	testhandlers.TestShortenAPICustomAlias(t, client)
 }
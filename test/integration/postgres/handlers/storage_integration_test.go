@@ -0,0 +1,251 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/storage"
+)
+
+// openTestDB открывает отдельное подключение к тестовой БД для проверки данных.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("pgx", testDSN)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// userContext возвращает контекст запроса пользователя userID.
+func userContext(userID string) context.Context {
+	return context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+}
+
+// uniqueCode возвращает короткий ключ, не пересекающийся с другими тестами.
+func uniqueCode(prefix string) string {
+	return prefix + uuid.NewString()[:8]
+}
+
+func countUserRows(t *testing.T, db *sql.DB, userID string) int {
+	t.Helper()
+	var n int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM users WHERE id = $1", userID).Scan(&n))
+	return n
+}
+
+// TestSaveURL_Postgres_Users проверяет регистрацию пользователя при сохранении ссылки
+// в одной транзакции со вставкой ссылки и разбор конфликтов SaveURL.
+func TestSaveURL_Postgres_Users(t *testing.T) {
+	db := openTestDB(t)
+	owner := uuid.NewString()
+	ctx := userContext(owner)
+
+	original := "https://example.com/" + uuid.NewString()
+	code := uniqueCode("su")
+
+	// Первая ссылка регистрирует пользователя
+	m := models.URLMapping{OriginalURL: original, ShortURL: code}
+	require.NoError(t, testPG.SaveURL(ctx, &m))
+	assert.Equal(t, 1, countUserRows(t, db, owner))
+
+	// Повторное сокращение URL: пользователь уже есть, возвращается существующий ключ
+	m = models.URLMapping{OriginalURL: original, ShortURL: uniqueCode("su")}
+	err := testPG.SaveURL(ctx, &m)
+	assert.ErrorIs(t, err, storage.ErrURLExists)
+	assert.Equal(t, code, m.ShortURL)
+	assert.Equal(t, 1, countUserRows(t, db, owner))
+
+	// Занятый ключ другим пользователем: нарушение уникальности ссылки
+	// отображается в ErrShortURLExists, регистрация пользователя откатывается
+	other := uuid.NewString()
+	m = models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: code}
+	err = testPG.SaveURL(userContext(other), &m)
+	assert.ErrorIs(t, err, storage.ErrShortURLExists)
+	assert.Zero(t, countUserRows(t, db, other))
+
+	// Ссылка без пользователя не создаёт записей в users
+	var usersBefore, usersAfter int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM users").Scan(&usersBefore))
+	m = models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: uniqueCode("su")}
+	require.NoError(t, testPG.SaveURL(context.Background(), &m))
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM users").Scan(&usersAfter))
+	assert.Equal(t, usersBefore, usersAfter)
+}
+
+// TestUsersForeignKey_Postgres проверяет внешний ключ short_urls.user_id -> users.id.
+func TestUsersForeignKey_Postgres(t *testing.T) {
+	db := openTestDB(t)
+
+	t.Run("link of unknown user is rejected", func(t *testing.T) {
+		_, err := db.Exec("INSERT INTO short_urls (original_url, short_code, user_id) VALUES ($1, $2, $3)",
+			"https://example.com/"+uuid.NewString(), uniqueCode("fk"), uuid.NewString())
+
+		var pgErr *pgconn.PgError
+		require.True(t, errors.As(err, &pgErr), "expected PostgreSQL error, got %v", err)
+		assert.Equal(t, "23503", pgErr.Code) // foreign_key_violation
+	})
+
+	t.Run("deleting user removes links", func(t *testing.T) {
+		userID := uuid.NewString()
+		ctx := userContext(userID)
+		for i := 0; i < 2; i++ {
+			m := models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: uniqueCode("fk")}
+			require.NoError(t, testPG.SaveURL(ctx, &m))
+		}
+
+		_, err := db.Exec("DELETE FROM users WHERE id = $1", userID)
+		require.NoError(t, err)
+
+		var n int
+		require.NoError(t, db.QueryRow("SELECT count(*) FROM short_urls WHERE user_id = $1", userID).Scan(&n))
+		assert.Zero(t, n)
+	})
+}
+
+// TestSaveURL_Postgres_UpdatedAt проверяет, что updated_at выставляется при вставке
+// и обновляется при повторном сокращении URL.
+func TestSaveURL_Postgres_UpdatedAt(t *testing.T) {
+	db := openTestDB(t)
+	ctx := userContext(uuid.NewString())
+	code := uniqueCode("ua")
+
+	updatedAt := func() (created, updated time.Time) {
+		t.Helper()
+		require.NoError(t, db.QueryRow("SELECT created_at, updated_at FROM short_urls WHERE short_code = $1", code).
+			Scan(&created, &updated))
+		return created, updated
+	}
+
+	m := models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: code}
+	require.NoError(t, testPG.SaveURL(ctx, &m))
+	created, updated := updatedAt()
+	assert.Equal(t, created, updated)
+
+	time.Sleep(10 * time.Millisecond)
+	again := models.URLMapping{OriginalURL: m.OriginalURL, ShortURL: uniqueCode("ua")}
+	require.ErrorIs(t, testPG.SaveURL(ctx, &again), storage.ErrURLExists)
+	createdAgain, updatedAgain := updatedAt()
+	assert.Equal(t, created, createdAgain)
+	assert.True(t, updatedAgain.After(updated), "updated_at is not refreshed on conflict")
+}
+
+// TestPurgeURLs_Postgres_Users проверяет удаление пользователей,
+// у которых после очистки не осталось ссылок.
+func TestPurgeURLs_Postgres_Users(t *testing.T) {
+	db := openTestDB(t)
+	owner := uuid.NewString()
+	ctx := userContext(owner)
+
+	codes := []string{uniqueCode("pu"), uniqueCode("pu")}
+	for _, code := range codes {
+		m := models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: code}
+		require.NoError(t, testPG.SaveURL(ctx, &m))
+	}
+
+	// Одна ссылка остаётся: пользователь сохраняется
+	_, err := testPG.BatchMarkAsDeleted(ctx, owner, codes[:1])
+	require.NoError(t, err)
+	purged, err := testPG.PurgeURLs(context.Background(), time.Now().Add(time.Minute), 1000)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, 1)
+	assert.Equal(t, 1, countUserRows(t, db, owner))
+
+	// Последняя ссылка очищена: пользователь удаляется
+	usersBefore, err := testPG.CountUsers(context.Background())
+	require.NoError(t, err)
+	_, err = testPG.BatchMarkAsDeleted(ctx, owner, codes[1:])
+	require.NoError(t, err)
+	_, err = testPG.PurgeURLs(context.Background(), time.Now().Add(time.Minute), 1000)
+	require.NoError(t, err)
+	assert.Zero(t, countUserRows(t, db, owner))
+	usersAfter, err := testPG.CountUsers(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, usersAfter, usersBefore)
+
+	// Пользователь регистрируется заново со следующей ссылкой
+	m := models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: uniqueCode("pu")}
+	require.NoError(t, testPG.SaveURL(ctx, &m))
+	assert.Equal(t, 1, countUserRows(t, db, owner))
+}
+
+// TestSaveNewURLs_Postgres_Conflicts проверяет загрузку пакета через COPY
+// и построчное сопоставление конфликтов ON CONFLICT DO NOTHING.
+func TestSaveNewURLs_Postgres_Conflicts(t *testing.T) {
+	ctx := userContext(uuid.NewString())
+
+	// URL, уже сокращённый пользователем
+	known := models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: uniqueCode("bk")}
+	require.NoError(t, testPG.SaveURL(ctx, &known))
+	// Ключ, занятый другим пользователем
+	taken := models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: uniqueCode("bt")}
+	require.NoError(t, testPG.SaveURL(userContext(uuid.NewString()), &taken))
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	fresh := "https://example.com/" + uuid.NewString()
+	urls := []models.URLMapping{
+		{OriginalURL: fresh, ShortURL: uniqueCode("bn")},
+		{OriginalURL: known.OriginalURL, ShortURL: uniqueCode("bn")},
+		{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: taken.ShortURL},
+		{OriginalURL: fresh, ShortURL: uniqueCode("bn")}, // Повтор URL внутри пакета
+		{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: uniqueCode("bn"), ExpiresAt: &expiresAt},
+	}
+	err := testPG.SaveNewURLs(ctx, urls)
+
+	var conflicts *storage.BatchConflictError
+	require.True(t, errors.As(err, &conflicts), "expected BatchConflictError, got %v", err)
+	assert.Len(t, conflicts.Conflicts, 3)
+	// Конфликт по original_url: строка получает существующий ключ
+	assert.ErrorIs(t, conflicts.Conflicts[1], storage.ErrURLExists)
+	assert.Equal(t, known.ShortURL, urls[1].ShortURL)
+	// Конфликт по short_code и повтор URL в пакете: строки не сохранены
+	assert.ErrorIs(t, conflicts.Conflicts[2], storage.ErrShortURLExists)
+	assert.ErrorIs(t, conflicts.Conflicts[3], storage.ErrShortURLExists)
+
+	// Строки без конфликтов сохранены вместе с остальными полями
+	for _, idx := range []int{0, 4} {
+		got, err := testPG.GetRedirectURL(context.Background(), urls[idx].ShortURL)
+		require.NoError(t, err, "row %d", idx)
+		assert.Equal(t, urls[idx].OriginalURL, got.OriginalURL)
+	}
+	got, err := testPG.GetRedirectURL(context.Background(), urls[4].ShortURL)
+	require.NoError(t, err)
+	require.NotNil(t, got.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*got.ExpiresAt))
+
+	// Ключ, занятый другим пользователем, по-прежнему ведёт на его URL
+	got, err = testPG.GetRedirectURL(context.Background(), taken.ShortURL)
+	require.NoError(t, err)
+	assert.Equal(t, taken.OriginalURL, got.OriginalURL)
+}
+
+// TestBatch_Postgres_ReportsConflicts проверяет, что сервис сообщает о конфликтах
+// пакета по каждой строке.
+func TestBatch_Postgres_ReportsConflicts(t *testing.T) {
+	const baseURL = "http://localhost:8080"
+	ctx := userContext(uuid.NewString())
+
+	known := models.URLMapping{OriginalURL: "https://example.com/" + uuid.NewString(), ShortURL: uniqueCode("sk")}
+	require.NoError(t, testPG.SaveURL(ctx, &known))
+
+	resp, err := serv.Batch(ctx, []models.BatchRequest{
+		{CorrelationID: "1", OriginalURL: known.OriginalURL},
+		{CorrelationID: "2", OriginalURL: "https://example.com/" + uuid.NewString()},
+	}, baseURL)
+	require.NoError(t, err)
+	require.Len(t, resp, 2)
+
+	assert.Equal(t, baseURL+"/"+known.ShortURL, resp[0].ShortURL)
+	assert.Equal(t, storage.ErrURLExists.Error(), resp[0].Error)
+	assert.NotEmpty(t, resp[1].ShortURL)
+	assert.Empty(t, resp[1].Error)
+}
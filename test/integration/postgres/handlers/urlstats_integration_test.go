@@ -0,0 +1,26 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestURLStats_Postgres тестирует статистику переходов по ссылке с использованием PostgreSQL.
+//
+// Проверяет:
+//   - Запись переходов в таблицы url_click_stats и url_click_breakdown
+//   - Агрегацию повторных переходов (ON CONFLICT ... DO UPDATE)
+//   - Подсчёт уникальных посетителей по префиксу сети
+//   - Доступ к статистике только для владельца ссылки
+//
+// Особенности:
+//   - Использует тестовые сценарии из testhandlers.TestURLStats
+//   - Переходы записываются асинхронно, тест ожидает их появления в БД
+//
+// Пример использования:
+//
+//	go test -v -run TestURLStats_Postgres
+func TestURLStats_Postgres(t *testing.T) {
+	testhandlers.TestURLStats(t, serv, client)
+}
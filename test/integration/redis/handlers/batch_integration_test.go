@@ -0,0 +1,20 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestBatch_Redis тестирует пакетное сокращение URL (HTTP) с хранилищем Redis.
+// Короткие ключи пакета занимаются одной командой MSETNX.
+func TestBatch_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestBatch(t, client)
+}
+
+// TestBatchGRPC_Redis тестирует пакетное сокращение URL (gRPC) с хранилищем Redis.
+func TestBatchGRPC_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestBatchGRPC(t, grpcClient)
+}
@@ -0,0 +1,19 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestDelUserUrls_Redis тестирует асинхронное удаление URL пользователя (HTTP) с хранилищем Redis.
+func TestDelUserUrls_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestDelUserUrls(t, serv, client)
+}
+
+// TestDelUserUrlsGRPC_Redis тестирует асинхронное удаление URL пользователя (gRPC) с хранилищем Redis.
+func TestDelUserUrlsGRPC_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestDelUserUrlsGRPC(t, serv, grpcClient)
+}
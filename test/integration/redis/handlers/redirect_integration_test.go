@@ -0,0 +1,19 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestRedirect_Redis тестирует редиректы (HTTP) с хранилищем Redis.
+func TestRedirect_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestRedirect(t, testRedis, client)
+}
+
+// TestRedirectGRPC_Redis тестирует получение оригинального URL (gRPC) с хранилищем Redis.
+func TestRedirectGRPC_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestRedirectGRPC(t, testRedis, grpcClient)
+}
@@ -0,0 +1,27 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestShortenURL_Redis тестирует сокращение URL (POST /) с хранилищем Redis.
+func TestShortenURL_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestShortenURL(t, client)
+}
+
+// TestShortenAPI_Redis тестирует JSON API сокращения URL, включая псевдонимы, с хранилищем Redis.
+func TestShortenAPI_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestShortenAPI(t, client)
+	testhandlers.TestShortenAPICustomAlias(t, client)
+}
+
+// TestShortenURLGRPC_Redis тестирует сокращение URL (gRPC), включая псевдонимы, с хранилищем Redis.
+func TestShortenURLGRPC_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestShortenURLGRPC(t, grpcClient)
+	testhandlers.TestShortenURLCustomAliasGRPC(t, grpcClient)
+}
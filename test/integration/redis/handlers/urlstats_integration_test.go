@@ -0,0 +1,22 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestURLStats_Redis тестирует статистику переходов (HTTP) с хранилищем Redis.
+//
+// Проверяет агрегацию счётчиков командами HINCRBY, первый и последний переход
+// (ZADD LT/GT) и подсчёт уникальных посетителей по префиксу сети.
+func TestURLStats_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestURLStats(t, serv, client)
+}
+
+// TestURLStatsGRPC_Redis тестирует статистику переходов (gRPC) с хранилищем Redis.
+func TestURLStatsGRPC_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestURLStatsGRPC(t, serv, grpcClient)
+}
@@ -0,0 +1,19 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ryabkov82/shortener/test/testhandlers"
+)
+
+// TestUserUrls_Redis тестирует получение URL пользователя (HTTP) с хранилищем Redis.
+func TestUserUrls_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestUserUrls(t, serv, client)
+}
+
+// TestUserUrlsGRPC_Redis тестирует получение URL пользователя (gRPC) с хранилищем Redis.
+func TestUserUrlsGRPC_Redis(t *testing.T) {
+	resetStorage(t)
+	testhandlers.TestUserUrlsGRPC(t, serv, grpcClient)
+}
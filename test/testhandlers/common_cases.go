@@ -3,6 +3,7 @@ package testhandlers
 import (
 	"net/http"
 
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
 	"github.com/ryabkov82/shortener/test/testutils"
 )
 
@@ -56,6 +57,60 @@ func CommonShortenURLTestCases() []ShortenURLTestCase {
 	}
 }
 
+// CustomAliasTestCase описывает сценарий создания ссылки с пользовательским псевдонимом.
+type CustomAliasTestCase struct {
+	Name        string
+	OriginalURL string
+	Alias       string
+	Want        testutils.StatusCode
+	Cookie      *http.Cookie
+}
+
+// CommonCustomAliasTestCases возвращает сценарии для проверки пользовательских псевдонимов.
+// Сценарии зависят от порядка выполнения: второй использует псевдоним, занятый первым.
+func CommonCustomAliasTestCases() []CustomAliasTestCase {
+
+	cookie, _ := testutils.CreateSignedCookie()
+
+	return []CustomAliasTestCase{
+		{
+			Name:        "custom alias",
+			OriginalURL: "https://example.com/promo",
+			Alias:       "promo2026",
+			Want:        testutils.StatusCreated,
+			Cookie:      cookie,
+		},
+		{
+			Name:        "alias already in use",
+			OriginalURL: "https://example.com/other",
+			Alias:       "promo2026",
+			Want:        testutils.StatusConflict,
+			Cookie:      cookie,
+		},
+		{
+			Name:        "reserved alias",
+			OriginalURL: "https://example.com/reserved",
+			Alias:       "ping",
+			Want:        testutils.StatusBadRequest,
+			Cookie:      cookie,
+		},
+		{
+			Name:        "invalid characters",
+			OriginalURL: "https://example.com/invalid",
+			Alias:       "bad alias!",
+			Want:        testutils.StatusBadRequest,
+			Cookie:      cookie,
+		},
+		{
+			Name:        "too short alias",
+			OriginalURL: "https://example.com/short",
+			Alias:       "ab",
+			Want:        testutils.StatusBadRequest,
+			Cookie:      cookie,
+		},
+	}
+}
+
 type RedirectResult struct {
 	Location string
 	Status   testutils.StatusCode
@@ -66,9 +121,13 @@ type RedirectTestCase struct {
 	ShortKey       string
 	ExpectedStatus testutils.StatusCode
 	ExpectedURL    string
+	ExpectedReason string // Причина из errmap.Reason (только для gRPC)
 }
 
-func CommonRedirectTestCases(shortKey string, originalURL string) []RedirectTestCase {
+// CommonRedirectTestCases возвращает сценарии редиректа, общие для HTTP и gRPC.
+// Удалённая и просроченная ссылки в обоих транспортах дают StatusGone,
+// в gRPC они различаются причиной ошибки.
+func CommonRedirectTestCases(shortKey string, originalURL string, expiredKey string, deletedKey string) []RedirectTestCase {
 	return []RedirectTestCase{
 		{
 			Name:           "valid redirect",
@@ -82,6 +141,20 @@ func CommonRedirectTestCases(shortKey string, originalURL string) []RedirectTest
 			ExpectedStatus: testutils.StatusNotFound,
 			ExpectedURL:    "",
 		},
+		{
+			Name:           "expired",
+			ShortKey:       expiredKey,
+			ExpectedStatus: testutils.StatusGone,
+			ExpectedURL:    "",
+			ExpectedReason: errmap.ReasonURLExpired,
+		},
+		{
+			Name:           "deleted",
+			ShortKey:       deletedKey,
+			ExpectedStatus: testutils.StatusGone,
+			ExpectedURL:    "",
+			ExpectedReason: errmap.ReasonURLDeleted,
+		},
 	}
 }
 
@@ -0,0 +1,126 @@
+package testhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/test/testutils"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// deleteJobWaitTimeout - время ожидания завершения асинхронного удаления.
+const deleteJobWaitTimeout = 5 * time.Second
+
+// TestDeleteJob тестирует отслеживание хода удаления (DELETE /api/user/urls
+// и GET /api/user/deletions/{id}).
+//
+// Проверяет следующие сценарии:
+//   - Ответ на удаление содержит идентификатор задания
+//   - Собственные и несуществующие коды попадают в done, чужие - в failed
+//   - Задание недоступно другому пользователю и по неизвестному идентификатору (404)
+//
+// Роутер клиента должен обслуживать оба маршрута.
+func TestDeleteJob(t *testing.T, serv *service.Service, client *resty.Client) {
+
+	user1URLs, user2URLs := prepareTestURLs(serv)
+	cookie1, err := testutils.CreateCookieByUserID("bf38c714-b8df-4f75-8578-ea6b5df32758")
+	require.NoError(t, err)
+	stranger, _ := testutils.CreateSignedCookie()
+
+	codes := []string{user1URLs["url1"], user2URLs["url5"], "nonexistent"}
+	body, err := json.Marshal(codes)
+	require.NoError(t, err)
+
+	resp, err := client.R().
+		SetCookie(cookie1).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Delete("/api/user/urls")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode())
+
+	var deleteResp models.DeleteResponse
+	require.NoError(t, json.Unmarshal(resp.Body(), &deleteResp))
+	require.NotEmpty(t, deleteResp.JobID)
+
+	var job models.DeleteJob
+	require.Eventually(t, func() bool {
+		resp, err := client.R().
+			SetCookie(cookie1).
+			Get("/api/user/deletions/" + deleteResp.JobID)
+		if err != nil || resp.StatusCode() != http.StatusOK {
+			return false
+		}
+		job = models.DeleteJob{}
+		return json.Unmarshal(resp.Body(), &job) == nil && job.Status != models.DeleteJobPending
+	}, deleteJobWaitTimeout, 50*time.Millisecond)
+
+	assert.Equal(t, deleteResp.JobID, job.ID)
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+	assert.Empty(t, job.Pending)
+	assert.ElementsMatch(t, []string{user1URLs["url1"], "nonexistent"}, job.Done)
+	assert.Equal(t, []string{user2URLs["url5"]}, job.Failed)
+
+	for name, tt := range map[string]struct {
+		cookie *http.Cookie
+		jobID  string
+	}{
+		"other user":  {stranger, deleteResp.JobID},
+		"unknown job": {cookie1, "no-such-job"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := client.R().
+				SetCookie(tt.cookie).
+				Get("/api/user/deletions/" + tt.jobID)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+		})
+	}
+}
+
+// TestDeleteJobGRPC тестирует отслеживание хода удаления через gRPC
+// (DeleteUserURLs и GetDeleteJob): сценарии совпадают с TestDeleteJob.
+func TestDeleteJobGRPC(t *testing.T, serv *service.Service, grpcClient pb.ShortenerClient) {
+
+	user1URLs, user2URLs := prepareTestURLs(serv)
+	cookie1, err := testutils.CreateCookieByUserID("bf38c714-b8df-4f75-8578-ea6b5df32758")
+	require.NoError(t, err)
+	stranger, _ := testutils.CreateSignedCookie()
+
+	ctx := testutils.ContextWithJWT(context.Background(), cookie1.Value)
+
+	deleteResp, err := grpcClient.DeleteUserURLs(ctx, &pb.DeleteRequest{
+		ShortUrls: []string{user1URLs["url1"], user2URLs["url5"], "nonexistent"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, deleteResp.JobId)
+
+	var job *pb.DeleteJobResponse
+	require.Eventually(t, func() bool {
+		job, err = grpcClient.GetDeleteJob(ctx, &pb.DeleteJobRequest{JobId: deleteResp.JobId})
+		return err == nil && job.Status != models.DeleteJobPending
+	}, deleteJobWaitTimeout, 50*time.Millisecond)
+
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+	assert.Empty(t, job.Pending)
+	assert.ElementsMatch(t, []string{user1URLs["url1"], "nonexistent"}, job.Done)
+	assert.Equal(t, []string{user2URLs["url5"]}, job.Failed)
+
+	strangerCtx := testutils.ContextWithJWT(context.Background(), stranger.Value)
+	_, err = grpcClient.GetDeleteJob(strangerCtx, &pb.DeleteJobRequest{JobId: deleteResp.JobId})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = grpcClient.GetDeleteJob(ctx, &pb.DeleteJobRequest{JobId: "no-such-job"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -132,10 +132,8 @@ func TestDelUserUrlsGRPC(t *testing.T, serv *service.Service, grpcClient pb.Shor
 				}
 
 				// Проверки
-				if redirectStatus == testutils.StatusNotFound {
-					if len(tt.shouldBeMarked) > 0 {
-						assert.Contains(t, tt.shouldBeMarked, code)
-					}
+				if redirectStatus == testutils.StatusGone {
+					assert.Contains(t, tt.shouldBeMarked, code)
 				} else {
 					assert.NotContains(t, tt.shouldBeMarked, code)
 				}
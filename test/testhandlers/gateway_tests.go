@@ -0,0 +1,183 @@
+package testhandlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
+	"github.com/ryabkov82/shortener/test/testutils"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// gatewayError - тело ответа REST-шлюза с ошибкой.
+type gatewayError struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+	Reason  string     `json:"reason"`
+}
+
+// TestGateway тестирует REST-шлюз к обработчикам gRPC (маршруты /v1/...).
+//
+// Проверяет следующие сценарии:
+//   - Создание ссылки (POST /v1/urls) и повторное создание (409 с существующей ссылкой)
+//   - Получение оригинального URL по параметру пути (GET /v1/urls/{short_url})
+//   - Ошибки сервиса отображаются в те же статусы, что и у HTTP API:
+//     404 для неизвестного ключа, 400 для неверного псевдонима, 410 для удалённой ссылки
+//     (с причиной URL_DELETED в теле ошибки)
+//   - Параметры строки запроса (GET /v1/user/urls?limit=...) и их валидация
+//   - Тело ошибки содержит код gRPC и сообщение
+//   - Методы для доверенной подсети недоступны без неё (403)
+//
+// Роутер клиента должен регистрировать маршруты шлюза с middleware аутентификации
+// и защитой методов доверенной подсети.
+func TestGateway(t *testing.T, client *resty.Client) {
+
+	cookie, _ := testutils.CreateSignedCookie()
+
+	var created struct {
+		ShortURL string `json:"short_url"`
+	}
+
+	t.Run("create", func(t *testing.T) {
+		resp, err := client.R().
+			SetCookie(cookie).
+			SetHeader("Content-Type", "application/json").
+			SetBody(`{"original_url": "https://example.com/gateway"}`).
+			Post("/v1/urls")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+		require.NoError(t, json.Unmarshal(resp.Body(), &created))
+		require.NotEmpty(t, created.ShortURL)
+	})
+
+	shortKey := created.ShortURL[strings.LastIndex(created.ShortURL, "/")+1:]
+
+	t.Run("create existing", func(t *testing.T) {
+		resp, err := client.R().
+			SetCookie(cookie).
+			SetBody(`{"original_url": "https://example.com/gateway"}`).
+			Post("/v1/urls")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode())
+		assert.JSONEq(t, `{"short_url": "`+created.ShortURL+`"}`, resp.String())
+	})
+
+	t.Run("invalid alias", func(t *testing.T) {
+		resp, err := client.R().
+			SetCookie(cookie).
+			SetBody(`{"original_url": "https://example.com/alias", "custom_alias": "ab"}`).
+			Post("/v1/urls")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		resp, err := client.R().
+			SetCookie(cookie).
+			SetBody(`{"original_url": 42}`).
+			Post("/v1/urls")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+	})
+
+	t.Run("get original URL", func(t *testing.T) {
+		resp, err := client.R().SetCookie(cookie).Get("/v1/urls/" + shortKey)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+		assert.JSONEq(t, `{"original_url": "https://example.com/gateway"}`, resp.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		resp, err := client.R().SetCookie(cookie).Get("/v1/urls/NoSuchKey")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+		assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+		var body gatewayError
+		require.NoError(t, json.Unmarshal(resp.Body(), &body))
+		assert.Equal(t, codes.NotFound, body.Code)
+		assert.Equal(t, "Shortened key not found", body.Message)
+	})
+
+	t.Run("user urls", func(t *testing.T) {
+		_, err := client.R().
+			SetCookie(cookie).
+			SetBody(`{"original_url": "https://example.com/gateway2"}`).
+			Post("/v1/urls")
+		require.NoError(t, err)
+
+		resp, err := client.R().
+			SetCookie(cookie).
+			SetQueryParam("limit", "1").
+			SetQueryParam("order", "desc").
+			Get("/v1/user/urls")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+
+		var page struct {
+			URLs []struct {
+				OriginalURL string `json:"original_url"`
+			} `json:"urls"`
+			NextCursor string `json:"next_cursor"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body(), &page))
+		require.Len(t, page.URLs, 1)
+		assert.Equal(t, "https://example.com/gateway2", page.URLs[0].OriginalURL)
+		assert.NotEmpty(t, page.NextCursor)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		for _, query := range []string{"limit=abc", "limit=5000", "unknown=1", "created_from=yesterday"} {
+			resp, err := client.R().SetCookie(cookie).Get("/v1/user/urls?" + query)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode(), query)
+		}
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		resp, err := client.R().
+			SetCookie(cookie).
+			SetBody(`{"short_urls": ["` + shortKey + `"]}`).
+			Delete("/v1/user/urls")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+
+		var job struct {
+			JobID string `json:"job_id"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body(), &job))
+		require.NotEmpty(t, job.JobID)
+
+		assert.Eventually(t, func() bool {
+			resp, err := client.R().SetCookie(cookie).Get("/v1/user/deletions/" + job.JobID)
+			if err != nil || resp.StatusCode() != http.StatusOK {
+				return false
+			}
+			var state struct {
+				Status string `json:"status"`
+			}
+			return json.Unmarshal(resp.Body(), &state) == nil && state.Status == "done"
+		}, deleteJobWaitTimeout, 50*time.Millisecond)
+
+		resp, err = client.R().SetCookie(cookie).Get("/v1/urls/" + shortKey)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusGone, resp.StatusCode())
+
+		var body gatewayError
+		require.NoError(t, json.Unmarshal(resp.Body(), &body))
+		assert.Equal(t, errmap.ReasonURLDeleted, body.Reason)
+	})
+
+	t.Run("trusted subnet", func(t *testing.T) {
+		resp, err := client.R().SetCookie(cookie).Get("/v1/internal/stats")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+	})
+}
@@ -3,10 +3,12 @@ package testhandlers
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/ryabkov82/shortener/internal/app/jwtauth"
 
 	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/handlers/errmap"
 	"github.com/ryabkov82/shortener/internal/app/models"
 	"github.com/ryabkov82/shortener/internal/app/service"
 	"github.com/ryabkov82/shortener/test/testutils"
@@ -20,6 +22,8 @@ func TestRedirectGRPC(t *testing.T, repo service.Repository, grpcClient pb.Short
 	const (
 		shortKey    = "EYm7J2zF"
 		originalURL = "https://practicum.yandex.ru/"
+		expiredKey  = "Xp1r3dK9"
+		deletedKey  = "D3l3t3dK"
 	)
 
 	mapping := models.URLMapping{
@@ -27,11 +31,26 @@ func TestRedirectGRPC(t *testing.T, repo service.Repository, grpcClient pb.Short
 		OriginalURL: originalURL,
 	}
 
+	expiredAt := time.Now().Add(-time.Hour)
+	expiredMapping := models.URLMapping{
+		ShortURL:    expiredKey,
+		OriginalURL: "https://practicum.yandex.ru/expired",
+		ExpiresAt:   &expiredAt,
+	}
+
+	deletedMapping := models.URLMapping{
+		ShortURL:    deletedKey,
+		OriginalURL: "https://practicum.yandex.ru/deleted",
+	}
+
 	cookie, userID := testutils.CreateSignedCookie()
 	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
 	repo.SaveURL(ctx, &mapping)
+	repo.SaveURL(ctx, &expiredMapping)
+	repo.SaveURL(ctx, &deletedMapping)
+	repo.BatchMarkAsDeleted(ctx, userID, []string{deletedKey})
 
-	tests := CommonRedirectTestCases(shortKey, originalURL)
+	tests := CommonRedirectTestCases(shortKey, originalURL, expiredKey, deletedKey)
 
 	for _, tt := range tests {
 		t.Run("gRPC_"+tt.Name, func(t *testing.T) {
@@ -60,6 +79,7 @@ func TestRedirectGRPC(t *testing.T, repo service.Repository, grpcClient pb.Short
 			} else {
 				assert.Error(t, err)
 			}
+			assert.Equal(t, tt.ExpectedReason, errmap.Reason(err))
 
 		})
 	}
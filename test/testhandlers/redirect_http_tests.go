@@ -5,11 +5,13 @@ import (
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/ryabkov82/shortener/internal/app/jwtauth"
 
 	"github.com/ryabkov82/shortener/internal/app/models"
 	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/internal/app/storage/cache"
 	"github.com/ryabkov82/shortener/test/testutils"
 
 	"github.com/go-resty/resty/v2"
@@ -21,6 +23,7 @@ import (
 // Проверяет следующие сценарии:
 //   - Успешный редирект на оригинальный URL (StatusTemporaryRedirect)
 //   - Обработку несуществующего короткого URL (StatusNotFound)
+//   - Обработку URL с истёкшим сроком действия и удалённого URL (StatusGone)
 //   - Корректность заголовка Location при редиректе
 //   - Работу JWT авторизации через cookie
 //   - Обработку gzip сжатия через middleware
@@ -46,6 +49,8 @@ func TestRedirect(t *testing.T, repo service.Repository, client *resty.Client) {
 	const (
 		shortKey    = "EYm7J2zF"
 		originalURL = "https://practicum.yandex.ru/"
+		expiredKey  = "Xp1r3dK9"
+		deletedKey  = "D3l3t3dK"
 	)
 
 	mapping := models.URLMapping{
@@ -53,6 +58,18 @@ func TestRedirect(t *testing.T, repo service.Repository, client *resty.Client) {
 		OriginalURL: originalURL,
 	}
 
+	expiredAt := time.Now().Add(-time.Hour)
+	expiredMapping := models.URLMapping{
+		ShortURL:    expiredKey,
+		OriginalURL: "https://practicum.yandex.ru/expired",
+		ExpiresAt:   &expiredAt,
+	}
+
+	deletedMapping := models.URLMapping{
+		ShortURL:    deletedKey,
+		OriginalURL: "https://practicum.yandex.ru/deleted",
+	}
+
 	var redirectAttemptedError = errors.New("redirect")
 	redirectPolicy := resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
 		// return nil for continue redirect otherwise return error to stop/prevent redirect
@@ -62,8 +79,11 @@ func TestRedirect(t *testing.T, repo service.Repository, client *resty.Client) {
 	cookie, userID := testutils.CreateSignedCookie()
 	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
 	repo.SaveURL(ctx, &mapping)
+	repo.SaveURL(ctx, &expiredMapping)
+	repo.SaveURL(ctx, &deletedMapping)
+	repo.BatchMarkAsDeleted(ctx, userID, []string{deletedKey})
 
-	tests := CommonRedirectTestCases(shortKey, originalURL)
+	tests := CommonRedirectTestCases(shortKey, originalURL, expiredKey, deletedKey)
 
 	for _, tt := range tests {
 		t.Run("HTTP_"+tt.Name, func(t *testing.T) {
@@ -91,3 +111,66 @@ func TestRedirect(t *testing.T, repo service.Repository, client *resty.Client) {
 	}
 
 }
+
+// TestRedirectCached тестирует редиректы (GET /{id}) через кэширующую обёртку хранилища.
+//
+// Проверяет следующие сценарии:
+//   - Отрицательный результат (404) кэшируется и сбрасывается при сохранении ссылки
+//   - Повторный редирект обслуживается из кэша
+//   - После BatchMarkAsDeleted редирект сразу возвращает 410 Gone
+//   - Счётчики попаданий и промахов кэша
+//
+// Особенности:
+//   - repo должен быть обёрткой, через которую работает обработчик
+func TestRedirectCached(t *testing.T, repo *cache.CachedRepository, client *resty.Client) {
+	const (
+		shortKey    = "C4ch3dK3"
+		originalURL = "https://practicum.yandex.ru/cached"
+	)
+
+	var redirectAttemptedError = errors.New("redirect")
+	client.SetRedirectPolicy(resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+		return redirectAttemptedError
+	}))
+
+	cookie, userID := testutils.CreateSignedCookie()
+	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+
+	redirect := func(t *testing.T) *resty.Response {
+		resp, err := client.R().SetCookie(cookie).Get("/" + shortKey)
+		if errors.Is(err, redirectAttemptedError) {
+			err = nil
+		}
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("HTTP_not found is cached", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, redirect(t).StatusCode())
+		assert.Equal(t, http.StatusNotFound, redirect(t).StatusCode())
+
+		stats := repo.CacheStats()
+		assert.Equal(t, int64(1), stats.Hits)
+		assert.Equal(t, int64(1), stats.Misses)
+	})
+
+	t.Run("HTTP_save invalidates negative entry", func(t *testing.T) {
+		mapping := models.URLMapping{ShortURL: shortKey, OriginalURL: originalURL}
+		assert.NoError(t, repo.SaveURL(ctx, &mapping))
+
+		resp := redirect(t)
+		assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode())
+		assert.Equal(t, originalURL, resp.Header().Get("Location"))
+
+		resp = redirect(t)
+		assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode())
+		assert.Equal(t, int64(2), repo.CacheStats().Hits)
+	})
+
+	t.Run("HTTP_delete returns gone immediately", func(t *testing.T) {
+		result, err := repo.BatchMarkAsDeleted(context.Background(), userID, []string{shortKey})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{shortKey}, result.Deleted)
+		assert.Equal(t, http.StatusGone, redirect(t).StatusCode())
+	})
+}
@@ -0,0 +1,145 @@
+package testhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/test/testutils"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestRestoreURLs тестирует восстановление удалённых URL
+// (POST /api/user/urls/restore).
+//
+// Проверяет следующие сценарии:
+//   - Удалённая ссылка пользователя восстанавливается и снова перенаправляет
+//   - Неудалённые ссылки считаются выполненными, чужие и несуществующие - нет
+//   - Задание восстановления отслеживается по GET /api/user/deletions/{id}
+//   - Пустой список отклоняется (400)
+//
+// Роутер клиента должен обслуживать маршруты удаления, восстановления,
+// хода выполнения заданий и редиректа.
+func TestRestoreURLs(t *testing.T, serv *service.Service, client *resty.Client) {
+
+	user1URLs, user2URLs := prepareTestURLs(serv)
+	cookie1, err := testutils.CreateCookieByUserID("bf38c714-b8df-4f75-8578-ea6b5df32758")
+	require.NoError(t, err)
+
+	// submit отправляет запрос и дожидается завершения задания
+	submit := func(method, path string, codes []string) models.DeleteJob {
+		body, err := json.Marshal(codes)
+		require.NoError(t, err)
+
+		resp, err := client.R().
+			SetCookie(cookie1).
+			SetHeader("Content-Type", "application/json").
+			SetBody(body).
+			Execute(method, path)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode())
+
+		var accepted models.DeleteResponse
+		require.NoError(t, json.Unmarshal(resp.Body(), &accepted))
+
+		var job models.DeleteJob
+		require.Eventually(t, func() bool {
+			resp, err := client.R().
+				SetCookie(cookie1).
+				Get("/api/user/deletions/" + accepted.JobID)
+			if err != nil || resp.StatusCode() != http.StatusOK {
+				return false
+			}
+			job = models.DeleteJob{}
+			return json.Unmarshal(resp.Body(), &job) == nil && job.Status != models.DeleteJobPending
+		}, deleteJobWaitTimeout, 50*time.Millisecond)
+		return job
+	}
+
+	// Редирект не выполняется: проверяется только ответ сервиса
+	client.SetRedirectPolicy(resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}))
+	redirectStatus := func(code string) int {
+		resp, err := client.R().SetCookie(cookie1).Get("/" + code)
+		require.NoError(t, err)
+		return resp.StatusCode()
+	}
+
+	job := submit(http.MethodDelete, "/api/user/urls", []string{user1URLs["url2"]})
+	require.Equal(t, models.DeleteJobDone, job.Status)
+	require.Equal(t, http.StatusGone, redirectStatus(user1URLs["url2"]))
+
+	job = submit(http.MethodPost, "/api/user/urls/restore",
+		[]string{user1URLs["url2"], user1URLs["url3"], user2URLs["url5"], "nonexistent"})
+
+	assert.True(t, job.Restore)
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+	assert.ElementsMatch(t, []string{user1URLs["url2"], user1URLs["url3"]}, job.Done)
+	assert.ElementsMatch(t, []string{user2URLs["url5"], "nonexistent"}, job.Failed)
+	assert.Equal(t, http.StatusTemporaryRedirect, redirectStatus(user1URLs["url2"]))
+
+	t.Run("empty list", func(t *testing.T) {
+		resp, err := client.R().
+			SetCookie(cookie1).
+			SetHeader("Content-Type", "application/json").
+			SetBody("[]").
+			Post("/api/user/urls/restore")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+	})
+}
+
+// TestRestoreURLsGRPC тестирует восстановление удалённых URL через gRPC
+// (RestoreUserURLs): сценарии совпадают с TestRestoreURLs.
+func TestRestoreURLsGRPC(t *testing.T, serv *service.Service, grpcClient pb.ShortenerClient) {
+
+	user1URLs, user2URLs := prepareTestURLs(serv)
+	cookie1, err := testutils.CreateCookieByUserID("bf38c714-b8df-4f75-8578-ea6b5df32758")
+	require.NoError(t, err)
+
+	ctx := testutils.ContextWithJWT(context.Background(), cookie1.Value)
+
+	wait := func(jobID string) *pb.DeleteJobResponse {
+		var job *pb.DeleteJobResponse
+		require.Eventually(t, func() bool {
+			job, err = grpcClient.GetDeleteJob(ctx, &pb.DeleteJobRequest{JobId: jobID})
+			return err == nil && job.Status != models.DeleteJobPending
+		}, deleteJobWaitTimeout, 50*time.Millisecond)
+		return job
+	}
+
+	deleteResp, err := grpcClient.DeleteUserURLs(ctx, &pb.DeleteRequest{ShortUrls: []string{user1URLs["url2"]}})
+	require.NoError(t, err)
+	require.Equal(t, models.DeleteJobDone, wait(deleteResp.JobId).Status)
+
+	_, err = grpcClient.GetOriginalURL(ctx, &pb.GetRequest{ShortUrl: user1URLs["url2"]})
+	require.Error(t, err)
+
+	restoreResp, err := grpcClient.RestoreUserURLs(ctx, &pb.RestoreRequest{
+		ShortUrls: []string{user1URLs["url2"], user1URLs["url3"], user2URLs["url5"], "nonexistent"},
+	})
+	require.NoError(t, err)
+	job := wait(restoreResp.JobId)
+
+	assert.True(t, job.Restore)
+	assert.Equal(t, models.DeleteJobFailed, job.Status)
+	assert.ElementsMatch(t, []string{user1URLs["url2"], user1URLs["url3"]}, job.Done)
+	assert.ElementsMatch(t, []string{user2URLs["url5"], "nonexistent"}, job.Failed)
+
+	_, err = grpcClient.GetOriginalURL(ctx, &pb.GetRequest{ShortUrl: user1URLs["url2"]})
+	assert.NoError(t, err)
+
+	_, err = grpcClient.RestoreUserURLs(ctx, &pb.RestoreRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/ryabkov82/shortener/internal/app/handlers/http/shortenapi"
@@ -21,6 +22,7 @@ import (
 //   - Успешное создание короткой ссылки (StatusCreated)
 //   - Попытку повторного сокращения того же URL (StatusConflict)
 //   - Обработку некорректного URL (StatusBadRequest)
+//   - Создание ссылки с ограниченным сроком действия и отклонение некорректного TTL
 //   - Корректность формата JSON ответа
 //   - Работу JWT авторизации через cookie
 //   - Поддержку gzip сжатия запросов и ответов
@@ -68,6 +70,18 @@ func TestShortenAPI(t *testing.T, client *resty.Client) {
 			cookie:         cookie,
 			wantStatusCode: 400,
 		},
+		{
+			name:           "ttl link",
+			request:        shortenapi.Request{URL: "https://practicum.yandex.ru/ttl", TTLSeconds: 3600},
+			cookie:         cookie,
+			wantStatusCode: 201,
+		},
+		{
+			name:           "negative ttl",
+			request:        shortenapi.Request{URL: "https://practicum.yandex.ru/negative-ttl", TTLSeconds: -1},
+			cookie:         cookie,
+			wantStatusCode: 400,
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,3 +121,34 @@ func TestShortenAPI(t *testing.T, client *resty.Client) {
 	}
 
 }
+
+// TestShortenAPICustomAlias тестирует создание ссылок с пользовательским псевдонимом
+// через JSON API (POST /api/shorten).
+//
+// Проверяет следующие сценарии:
+//   - Успешное создание ссылки с псевдонимом (StatusCreated, ссылка оканчивается псевдонимом)
+//   - Повторное использование занятого псевдонима (StatusConflict)
+//   - Зарезервированные и невалидные псевдонимы (StatusBadRequest)
+func TestShortenAPICustomAlias(t *testing.T, client *resty.Client) {
+
+	for _, tt := range CommonCustomAliasTestCases() {
+		t.Run("HTTP_"+tt.Name, func(t *testing.T) {
+
+			resp, err := client.R().
+				SetCookie(tt.Cookie).
+				SetHeader("Content-Type", "application/json").
+				SetBody(shortenapi.Request{URL: tt.OriginalURL, CustomAlias: tt.Alias}).
+				Post("/api/shorten")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Want, testutils.HTTPStatusToStatusCode(resp.StatusCode()))
+
+			if tt.Want == testutils.StatusCreated {
+				var response shortenapi.Response
+				err = json.Unmarshal(resp.Body(), &response)
+				assert.NoError(t, err)
+				assert.True(t, strings.HasSuffix(response.Result, "/"+tt.Alias))
+			}
+		})
+	}
+}
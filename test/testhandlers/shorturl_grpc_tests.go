@@ -3,6 +3,7 @@ package testhandlers
 import (
 	"context"
 	"net/url"
+	"strings"
 	"testing"
 
 	pb "github.com/ryabkov82/shortener/api"
@@ -46,3 +47,33 @@ func TestShortenURLGRPC(t *testing.T, grpcClient pb.ShortenerClient) {
 		})
 	}
 }
+
+// TestShortenURLCustomAliasGRPC тестирует создание ссылок с пользовательским псевдонимом через gRPC.
+func TestShortenURLCustomAliasGRPC(t *testing.T, grpcClient pb.ShortenerClient) {
+
+	for _, tt := range CommonCustomAliasTestCases() {
+		t.Run("gRPC_"+tt.Name, func(t *testing.T) {
+
+			ctx := testutils.ContextWithJWT(context.Background(), tt.Cookie.Value)
+
+			resp, err := grpcClient.CreateShortURL(ctx, &pb.CreateRequest{
+				OriginalUrl: tt.OriginalURL,
+				CustomAlias: tt.Alias,
+			})
+
+			got := testutils.StatusCreated
+			if err != nil {
+				got = testutils.StatusInternalError
+				if s, ok := status.FromError(err); ok {
+					got = testutils.GRPCCodeToStatusCode(s.Code())
+				}
+			}
+
+			assert.Equal(t, tt.Want, got)
+
+			if tt.Want == testutils.StatusCreated {
+				assert.True(t, strings.HasSuffix(resp.GetShortUrl(), "/"+tt.Alias))
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package testhandlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestStreamCreateGRPC тестирует потоковое пакетное создание коротких URL (StreamCreate).
+//
+// Проверяет следующие сценарии:
+//   - Создание пакета, превышающего размер одной части сохранения
+//   - Сохранение порядка и correlation_id в ответе
+//   - Повторную передачу уже сокращённых URL (возвращаются прежние ссылки)
+//   - Выдачу токена, если клиент не передал его при открытии потока
+//   - Отклонение пустого потока (InvalidArgument)
+func TestStreamCreateGRPC(t *testing.T, grpcClient pb.ShortenerClient) {
+
+	cookie, _ := testutils.CreateSignedCookie()
+	ctx := testutils.ContextWithJWT(context.Background(), cookie.Value)
+
+	send := func(t *testing.T, ctx context.Context, items []*pb.BatchCreateItem) (*pb.BatchCreateResponse, string, error) {
+		stream, err := grpcClient.StreamCreate(ctx)
+		require.NoError(t, err)
+		for _, item := range items {
+			if err := stream.Send(item); err != nil {
+				// Сервер завершил поток - причина будет получена в CloseAndRecv
+				require.ErrorIs(t, err, io.EOF)
+				break
+			}
+		}
+		resp, err := stream.CloseAndRecv()
+
+		var token string
+		if md, hErr := stream.Header(); hErr == nil && len(md.Get("token")) > 0 {
+			token = md.Get("token")[0]
+		}
+		return resp, token, err
+	}
+
+	items := make([]*pb.BatchCreateItem, 2500)
+	for i := range items {
+		items[i] = &pb.BatchCreateItem{
+			CorrelationId: fmt.Sprintf("id-%d", i),
+			OriginalUrl:   fmt.Sprintf("https://example.com/stream/%d", i),
+		}
+	}
+
+	t.Run("Создание большого пакета", func(t *testing.T) {
+		resp, _, err := send(t, ctx, items)
+		require.NoError(t, err)
+		require.Len(t, resp.Items, len(items))
+		for i, item := range resp.Items {
+			assert.Equal(t, items[i].CorrelationId, item.CorrelationId)
+			assert.NotEmpty(t, item.ShortUrl)
+			assert.Empty(t, item.Error)
+		}
+
+		again, _, err := send(t, ctx, items[:10])
+		require.NoError(t, err)
+		for i, item := range again.Items {
+			assert.Equal(t, resp.Items[i].ShortUrl, item.ShortUrl)
+		}
+	})
+
+	t.Run("Выдача токена без авторизации", func(t *testing.T) {
+		resp, token, err := send(t, context.Background(), []*pb.BatchCreateItem{
+			{CorrelationId: "1", OriginalUrl: "https://example.com/stream/anonymous"},
+		})
+		require.NoError(t, err)
+		assert.Len(t, resp.Items, 1)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("Пустой поток", func(t *testing.T) {
+		_, _, err := send(t, ctx, nil)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
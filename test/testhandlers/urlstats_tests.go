@@ -0,0 +1,179 @@
+package testhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/ryabkov82/shortener/api"
+	"github.com/ryabkov82/shortener/internal/app/jwtauth"
+	"github.com/ryabkov82/shortener/internal/app/models"
+	"github.com/ryabkov82/shortener/internal/app/service"
+	"github.com/ryabkov82/shortener/test/testutils"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// statsWaitTimeout - время ожидания асинхронной записи переходов в хранилище.
+const statsWaitTimeout = 5 * time.Second
+
+// URLStatsTestCase описывает сценарий запроса статистики переходов.
+type URLStatsTestCase struct {
+	Name           string
+	ShortKey       string
+	Cookie         *http.Cookie
+	ExpectedStatus testutils.StatusCode
+}
+
+// CommonURLStatsTestCases возвращает сценарии доступа к статистике ссылки:
+// владелец получает статистику, другой пользователь и несуществующий ключ - 404.
+func CommonURLStatsTestCases(shortKey string, owner *http.Cookie) []URLStatsTestCase {
+	stranger, _ := testutils.CreateSignedCookie()
+
+	return []URLStatsTestCase{
+		{
+			Name:           "owner",
+			ShortKey:       shortKey,
+			Cookie:         owner,
+			ExpectedStatus: testutils.StatusOK,
+		},
+		{
+			Name:           "other user",
+			ShortKey:       shortKey,
+			Cookie:         stranger,
+			ExpectedStatus: testutils.StatusNotFound,
+		},
+		{
+			Name:           "unknown key",
+			ShortKey:       "NoSuchKey",
+			Cookie:         owner,
+			ExpectedStatus: testutils.StatusNotFound,
+		},
+	}
+}
+
+// TestURLStats тестирует статистику переходов (GET /{id} и GET /api/user/urls/{id}/stats).
+//
+// Проверяет следующие сценарии:
+//   - Переходы по ссылке учитываются в статистике (с учётом асинхронной записи)
+//   - Уникальные посетители считаются по префиксу сети (/24 для IPv4)
+//   - Источники переходов агрегируются по заголовку Referer
+//   - Статистика доступна только владельцу ссылки (StatusNotFound для остальных)
+//
+// Роутер клиента должен обслуживать оба маршрута: редирект и статистику.
+func TestURLStats(t *testing.T, serv *service.Service, client *resty.Client) {
+
+	cookie, userID := testutils.CreateSignedCookie()
+	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+
+	shortKey, err := serv.GetShortKey(ctx, "https://example.com/stats")
+	require.NoError(t, err)
+
+	redirectAttemptedError := errors.New("redirect")
+	client.SetRedirectPolicy(resty.RedirectPolicyFunc(func(*http.Request, []*http.Request) error {
+		return redirectAttemptedError
+	}))
+
+	// Два перехода из одной сети /24 и один из другой
+	for _, ip := range []string{"203.0.113.10", "203.0.113.20", "198.51.100.7"} {
+		resp, err := client.R().
+			SetHeader("Referer", "https://news.example/").
+			SetHeader("User-Agent", "stats-test-agent").
+			SetHeader("X-Real-IP", ip).
+			Get("/" + shortKey)
+		if errors.Is(err, redirectAttemptedError) {
+			err = nil
+		}
+		require.NoError(t, err)
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode())
+	}
+
+	var stats models.URLStats
+	assert.Eventually(t, func() bool {
+		resp, err := client.R().SetCookie(cookie).Get("/api/user/urls/" + shortKey + "/stats")
+		if err != nil || resp.StatusCode() != http.StatusOK {
+			return false
+		}
+		return json.Unmarshal(resp.Body(), &stats) == nil && stats.Clicks == 3
+	}, statsWaitTimeout, 50*time.Millisecond)
+
+	assert.Equal(t, int64(3), stats.Clicks)
+	assert.Equal(t, int64(2), stats.UniqueVisitors)
+	assert.True(t, strings.HasSuffix(stats.ShortURL, "/"+shortKey))
+	assert.Equal(t, "https://example.com/stats", stats.OriginalURL)
+	assert.NotNil(t, stats.FirstClickAt)
+	assert.NotNil(t, stats.LastClickAt)
+	if assert.Len(t, stats.TopReferrers, 1) {
+		assert.Equal(t, models.ClickCount{Value: "https://news.example/", Clicks: 3}, stats.TopReferrers[0])
+	}
+	if assert.Len(t, stats.TopUserAgents, 1) {
+		assert.Equal(t, "stats-test-agent", stats.TopUserAgents[0].Value)
+	}
+
+	for _, tt := range CommonURLStatsTestCases(shortKey, cookie) {
+		t.Run("HTTP_"+tt.Name, func(t *testing.T) {
+			resp, err := client.R().SetCookie(tt.Cookie).Get("/api/user/urls/" + tt.ShortKey + "/stats")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ExpectedStatus, testutils.HTTPStatusToStatusCode(resp.StatusCode()))
+		})
+	}
+}
+
+// TestURLStatsGRPC тестирует статистику переходов через gRPC (GetOriginalURL и GetURLStats).
+//
+// Сервер должен обслуживать оба метода.
+func TestURLStatsGRPC(t *testing.T, serv *service.Service, grpcClient pb.ShortenerClient) {
+
+	cookie, userID := testutils.CreateSignedCookie()
+	ctx := context.WithValue(context.Background(), jwtauth.UserIDContextKey, userID)
+
+	shortKey, err := serv.GetShortKey(ctx, "https://example.com/stats-grpc")
+	require.NoError(t, err)
+
+	for _, ip := range []string{"2001:db8:1:1::1", "2001:db8:1:2::1"} {
+		md := metadata.Pairs("referer", "https://grpc.example/", "x-real-ip", ip)
+		callCtx := metadata.NewOutgoingContext(context.Background(), md)
+		_, err := grpcClient.GetOriginalURL(callCtx, &pb.GetRequest{ShortUrl: shortKey})
+		require.NoError(t, err)
+	}
+
+	ownerCtx := testutils.ContextWithJWT(context.Background(), cookie.Value)
+
+	var resp *pb.URLStatsResponse
+	assert.Eventually(t, func() bool {
+		resp, err = grpcClient.GetURLStats(ownerCtx, &pb.URLStatsRequest{ShortUrl: shortKey})
+		return err == nil && resp.Clicks == 2
+	}, statsWaitTimeout, 50*time.Millisecond)
+
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, int64(2), resp.Clicks)
+		// Оба адреса принадлежат одной сети /48
+		assert.Equal(t, int64(1), resp.UniqueVisitors)
+		assert.NotNil(t, resp.FirstClickAt)
+		if assert.Len(t, resp.TopReferrers, 1) {
+			assert.Equal(t, "https://grpc.example/", resp.TopReferrers[0].Value)
+		}
+	}
+
+	for _, tt := range CommonURLStatsTestCases(shortKey, cookie) {
+		t.Run("gRPC_"+tt.Name, func(t *testing.T) {
+			ctx := testutils.ContextWithJWT(context.Background(), tt.Cookie.Value)
+			_, err := grpcClient.GetURLStats(ctx, &pb.URLStatsRequest{ShortUrl: tt.ShortKey})
+
+			statsStatus := testutils.StatusOK
+			if err != nil {
+				s, _ := status.FromError(err)
+				statsStatus = testutils.GRPCCodeToStatusCode(s.Code())
+			}
+			assert.Equal(t, tt.ExpectedStatus, statsStatus)
+		})
+	}
+}
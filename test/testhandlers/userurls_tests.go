@@ -3,8 +3,12 @@ package testhandlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	pb "github.com/ryabkov82/shortener/api"
 	"github.com/ryabkov82/shortener/internal/app/models"
@@ -15,6 +19,10 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // TestUserUrls тестирует обработчик получения списка URL пользователя (GET /api/user/urls).
@@ -163,6 +171,209 @@ func TestUserUrlsGRPC(t *testing.T, serv *service.Service, grpcClient pb.Shorten
 	})
 }
 
+// TestUserUrlsPagination тестирует постраничную выдачу, фильтры и сортировку
+// списка URL пользователя (GET /api/user/urls).
+//
+// Проверяет следующие сценарии:
+//   - Обход всех страниц по курсору из заголовка X-Next-Cursor в порядке создания
+//   - Обратный порядок (order=desc)
+//   - Поиск по подстроке оригинального URL без учёта регистра
+//   - Фильтр по диапазону дат создания
+//   - Отклонение неверных параметров (400)
+func TestUserUrlsPagination(t *testing.T, serv *service.Service, client *resty.Client) {
+
+	cookie, userID := testutils.CreateSignedCookie()
+	originals := []string{
+		"https://example.com/page/1",
+		"https://example.org/page/2",
+		"https://EXAMPLE.com/page/3",
+	}
+	for _, original := range originals {
+		prepareTestUserURLs(serv, []models.UserURLMapping{{UserID: userID, OriginalURL: original}})
+	}
+
+	list := func(t *testing.T, params map[string]string) ([]string, string, int) {
+		resp, err := client.R().
+			SetCookie(cookie).
+			SetQueryParams(params).
+			Get("/api/user/urls")
+		require.NoError(t, err)
+		if resp.StatusCode() != http.StatusOK {
+			return nil, "", resp.StatusCode()
+		}
+
+		var urls []models.URLMapping
+		require.NoError(t, json.Unmarshal(resp.Body(), &urls))
+		result := make([]string, 0, len(urls))
+		for _, u := range urls {
+			assert.NotNil(t, u.CreatedAt)
+			result = append(result, u.OriginalURL)
+		}
+		return result, resp.Header().Get("X-Next-Cursor"), resp.StatusCode()
+	}
+
+	t.Run("Обход страниц по курсору", func(t *testing.T) {
+		for _, order := range []string{models.SortAsc, models.SortDesc} {
+			var (
+				all    []string
+				cursor string
+			)
+			for page := 0; page < len(originals); page++ {
+				urls, next, status := list(t, map[string]string{"limit": "2", "order": order, "cursor": cursor})
+				require.Equal(t, http.StatusOK, status)
+				all = append(all, urls...)
+				if cursor = next; cursor == "" {
+					break
+				}
+			}
+
+			expected := originals
+			if order == models.SortDesc {
+				expected = []string{originals[2], originals[1], originals[0]}
+			}
+			assert.Equal(t, expected, all, order)
+		}
+	})
+
+	t.Run("Поиск по оригинальному URL", func(t *testing.T) {
+		urls, next, status := list(t, map[string]string{"search": "example.COM"})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{originals[0], originals[2]}, urls)
+		assert.Empty(t, next)
+	})
+
+	t.Run("Фильтр по дате создания", func(t *testing.T) {
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		_, _, status := list(t, map[string]string{"created_from": future})
+		assert.Equal(t, http.StatusNoContent, status)
+
+		urls, _, status := list(t, map[string]string{"created_to": future})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Len(t, urls, len(originals))
+	})
+
+	t.Run("Неверные параметры", func(t *testing.T) {
+		for _, params := range []map[string]string{
+			{"limit": "0"},
+			{"limit": "1001"},
+			{"order": "random"},
+			{"cursor": "not-a-cursor"},
+			{"created_from": "yesterday"},
+		} {
+			_, _, status := list(t, params)
+			assert.Equal(t, http.StatusBadRequest, status, params)
+		}
+	})
+}
+
+// TestUserUrlsPaginationGRPC тестирует постраничную выдачу списка URL через gRPC
+// (GetUserURLs): сценарии совпадают с TestUserUrlsPagination.
+func TestUserUrlsPaginationGRPC(t *testing.T, serv *service.Service, grpcClient pb.ShortenerClient) {
+
+	cookie, userID := testutils.CreateSignedCookie()
+	originals := []string{
+		"https://example.com/page/1",
+		"https://example.org/page/2",
+		"https://EXAMPLE.com/page/3",
+	}
+	for _, original := range originals {
+		prepareTestUserURLs(serv, []models.UserURLMapping{{UserID: userID, OriginalURL: original}})
+	}
+	ctx := testutils.ContextWithJWT(context.Background(), cookie.Value)
+
+	var (
+		all []string
+		req = &pb.UserURLsRequest{Limit: 2, Order: models.SortDesc}
+	)
+	for page := 0; page < len(originals); page++ {
+		resp, err := grpcClient.GetUserURLs(ctx, req)
+		require.NoError(t, err)
+		for _, u := range resp.Urls {
+			assert.NotNil(t, u.CreatedAt)
+			all = append(all, u.OriginalUrl)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		req.Cursor = resp.NextCursor
+	}
+	assert.Equal(t, []string{originals[2], originals[1], originals[0]}, all)
+
+	resp, err := grpcClient.GetUserURLs(ctx, &pb.UserURLsRequest{
+		Search:      "EXAMPLE.com",
+		CreatedFrom: timestamppb.New(time.Now().Add(-time.Hour)),
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Urls, 2)
+	assert.Equal(t, originals[0], resp.Urls[0].OriginalUrl)
+	assert.Equal(t, originals[2], resp.Urls[1].OriginalUrl)
+
+	_, err = grpcClient.GetUserURLs(ctx, &pb.UserURLsRequest{Cursor: "not-a-cursor"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestStreamUserURLsGRPC тестирует выгрузку URL пользователя потоком (StreamUserURLs).
+//
+// Проверяет следующие сценарии:
+//   - Выгрузку всех записей, превышающих размер одной страницы хранилища
+//   - Ограничение количества записей и обратный порядок
+//   - Пустой поток для нового пользователя
+//   - Отклонение неверного курсора (InvalidArgument)
+func TestStreamUserURLsGRPC(t *testing.T, serv *service.Service, grpcClient pb.ShortenerClient) {
+
+	cookie, userID := testutils.CreateSignedCookie()
+	testURLs := make([]models.UserURLMapping, 1200)
+	for i := range testURLs {
+		testURLs[i] = models.UserURLMapping{UserID: userID, OriginalURL: fmt.Sprintf("https://example.com/export/%d", i)}
+	}
+	prepareTestUserURLs(serv, testURLs)
+	ctx := testutils.ContextWithJWT(context.Background(), cookie.Value)
+
+	receive := func(t *testing.T, ctx context.Context, req *pb.UserURLsRequest) ([]string, error) {
+		stream, err := grpcClient.StreamUserURLs(ctx, req)
+		require.NoError(t, err)
+		var urls []string
+		for {
+			u, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return urls, nil
+			}
+			if err != nil {
+				return urls, err
+			}
+			urls = append(urls, u.OriginalUrl)
+		}
+	}
+
+	t.Run("Выгрузка всех записей", func(t *testing.T) {
+		urls, err := receive(t, ctx, &pb.UserURLsRequest{})
+		require.NoError(t, err)
+		require.Len(t, urls, len(testURLs))
+		for i, u := range urls {
+			assert.Equal(t, testURLs[i].OriginalURL, u)
+		}
+	})
+
+	t.Run("Ограничение и обратный порядок", func(t *testing.T) {
+		urls, err := receive(t, ctx, &pb.UserURLsRequest{Limit: 3, Order: models.SortDesc})
+		require.NoError(t, err)
+		n := len(testURLs)
+		assert.Equal(t, []string{testURLs[n-1].OriginalURL, testURLs[n-2].OriginalURL, testURLs[n-3].OriginalURL}, urls)
+	})
+
+	t.Run("Пустой поток для нового пользователя", func(t *testing.T) {
+		other, _ := testutils.CreateSignedCookie()
+		urls, err := receive(t, testutils.ContextWithJWT(context.Background(), other.Value), &pb.UserURLsRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, urls)
+	})
+
+	t.Run("Неверный курсор", func(t *testing.T) {
+		_, err := receive(t, ctx, &pb.UserURLsRequest{Cursor: "not-a-cursor"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
 func prepareTestUserURLs(serv *service.Service, testURLs []models.UserURLMapping) {
 
 	for _, url := range testURLs {
@@ -37,12 +37,34 @@ func NewTestGRPCClient(
 	service pb.ShortenerServer,
 	logger *zap.Logger,
 ) (*TestGRPCClient, error) {
+	return NewTestGRPCStreamClient(interceptors, nil, service, logger)
+}
+
+// NewTestGRPCStreamClient создает тестовое окружение для gRPC тестов
+// с интерцепторами потоковых методов.
+//
+// Параметры:
+//   - interceptors: список унарных gRPC интерцепторов
+//   - streamInterceptors: список потоковых gRPC интерцепторов
+//   - service: реализация gRPC сервера
+//   - logger: логгер для записи событий
+//
+// Возвращает:
+//   - *TestGRPCClient - готовый к использованию тестовый клиент с сервером
+//   - error - ошибка инициализации
+func NewTestGRPCStreamClient(
+	interceptors []grpc.UnaryServerInterceptor,
+	streamInterceptors []grpc.StreamServerInterceptor,
+	service pb.ShortenerServer,
+	logger *zap.Logger,
+) (*TestGRPCClient, error) {
 
 	// Создаем виртуальное соединение
 	lis := bufconn.Listen(1024 * 1024)
 
 	srv := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
 	)
 
 	// Регистрируем сервисы
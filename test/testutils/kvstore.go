@@ -0,0 +1,32 @@
+package testutils
+
+import (
+	"github.com/ryabkov82/shortener/internal/app/storage/kvstore"
+)
+
+// InitializeKVStorage создает встроенное хранилище ключ-значение для тестов.
+//
+// Параметры:
+//   - dir: каталог файлов хранилища (например, из os.MkdirTemp)
+//
+// Возвращает:
+//   - *kvstore.KVStorage: инициализированное хранилище
+//   - error: ошибка открытия хранилища
+//
+// Особенности:
+//   - Небольшой объём таблицы в памяти, чтобы тесты проходили через сброс
+//     таблиц на диск и их слияние
+//
+// Пример использования:
+//
+//	st, err := InitializeKVStorage(t.TempDir())
+//	if err != nil {
+//	    log.Fatalf("Failed to initialize storage: %v", err)
+//	}
+//	defer st.Close()
+func InitializeKVStorage(dir string) (*kvstore.KVStorage, error) {
+	return kvstore.NewKVStorage(dir,
+		kvstore.WithMemtableSize(4<<10),
+		kvstore.WithMaxTables(2),
+	)
+}
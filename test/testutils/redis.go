@@ -0,0 +1,37 @@
+package testutils
+
+import (
+	"github.com/ryabkov82/shortener/internal/app/storage/redis"
+	"github.com/ryabkov82/shortener/test/fakeredis"
+)
+
+// InitializeRedisStorage запускает встроенный Redis-совместимый сервер
+// и создает подключённое к нему хранилище для тестов.
+//
+// Возвращает:
+//   - *redis.RedisStorage: хранилище, подключённое к серверу
+//   - *fakeredis.Server: сервер (закрывается после хранилища)
+//   - error: ошибка запуска сервера или подключения
+//
+// Пример использования:
+//
+//	st, srv, err := InitializeRedisStorage()
+//	if err != nil {
+//	    log.Fatalf("Failed to initialize storage: %v", err)
+//	}
+//	defer srv.Close()
+//	defer st.Close()
+func InitializeRedisStorage() (*redis.RedisStorage, *fakeredis.Server, error) {
+	srv, err := fakeredis.NewServer()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st, err := redis.NewRedisStorage(srv.URL())
+	if err != nil {
+		srv.Close()
+		return nil, nil, err
+	}
+
+	return st, srv, nil
+}
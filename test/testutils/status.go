@@ -20,6 +20,7 @@ const (
 	StatusTemporaryRedirect
 	StatusAccepted
 	StatusNoContent
+	StatusGone
 	StatusServiceUnavailable
 	StatusGatewayTimeout
 	StatusInternalError
@@ -37,6 +38,8 @@ func GRPCCodeToStatusCode(code codes.Code) StatusCode {
 		return StatusNotFound
 	case codes.AlreadyExists:
 		return StatusConflict
+	case codes.FailedPrecondition:
+		return StatusGone
 	case codes.PermissionDenied:
 		return StatusForbidden
 	case codes.Unauthenticated:
@@ -81,6 +84,8 @@ func HTTPStatusToStatusCode(code int) StatusCode {
 		return StatusAccepted
 	case http.StatusNoContent:
 		return StatusNoContent
+	case http.StatusGone:
+		return StatusGone
 	default:
 		return StatusUnknown
 	}